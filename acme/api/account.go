@@ -1,6 +1,7 @@
 package api
 
 import (
+	"crypto"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -73,6 +74,90 @@ func (a *AccountService) Update(accountURL string, req acme.Account) (acme.Accou
 	return account, nil
 }
 
+// UpdateContacts Updates the contacts of an account.
+// An empty list removes all the contacts.
+func (a *AccountService) UpdateContacts(accountURL string, contacts []string) (acme.Account, error) {
+	if accountURL == "" {
+		return acme.Account{}, errors.New("account[update contacts]: empty URL")
+	}
+
+	if contacts == nil {
+		contacts = []string{}
+	}
+
+	// The contact field is always sent (acme.Account omits an empty contact list).
+	req := struct {
+		Contact []string `json:"contact"`
+	}{Contact: contacts}
+
+	var account acme.Account
+	_, err := a.core.post(accountURL, req, &account)
+	if err != nil {
+		return acme.Account{}, err
+	}
+
+	return account, nil
+}
+
+// ListOrders Lists the URLs of the orders of an account, all the pages are fetched.
+// The ordersURL is the `orders` field of the account.
+func (a *AccountService) ListOrders(ordersURL string) ([]string, error) {
+	if ordersURL == "" {
+		return nil, errors.New("account[orders]: empty URL")
+	}
+
+	var orders []string
+
+	seen := make(map[string]struct{})
+
+	for pageURL := ordersURL; pageURL != ""; {
+		if _, ok := seen[pageURL]; ok {
+			return nil, fmt.Errorf("account[orders]: pagination loop on %s", pageURL)
+		}
+
+		seen[pageURL] = struct{}{}
+
+		var list acme.OrdersList
+		resp, err := a.core.postAsGet(pageURL, &list)
+		if err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, list.Orders...)
+
+		pageURL = getLink(resp.Header, "next")
+	}
+
+	return orders, nil
+}
+
+// ChangeKey Changes the key of the account (account key rollover).
+// https://www.rfc-editor.org/rfc/rfc8555.html#section-7.3.5
+func (a *AccountService) ChangeKey(newKey crypto.PrivateKey) error {
+	uri := a.core.GetDirectory().KeyChangeURL
+	if uri == "" {
+		return errors.New("account[keyChange]: the server does not advertise a key change endpoint")
+	}
+
+	if newKey == nil {
+		return errors.New("account[keyChange]: the new key cannot be nil")
+	}
+
+	keyChangeJWS, err := a.core.jws.SignKeyChangeContent(uri, newKey)
+	if err != nil {
+		return fmt.Errorf("acme: error signing key change content: %w", err)
+	}
+
+	_, err = a.core.retrievablePost(uri, []byte(keyChangeJWS.FullSerialize()), nil)
+	if err != nil {
+		return err
+	}
+
+	a.core.jws.SetPrivateKey(newKey)
+
+	return nil
+}
+
 // Deactivate Deactivates an account.
 func (a *AccountService) Deactivate(accountURL string) error {
 	if accountURL == "" {
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"crypto"
 	"encoding/json"
 	"errors"
@@ -23,6 +24,7 @@ type Core struct {
 	nonceManager *nonces.Manager
 	jws          *secure.JWS
 	directory    acme.Directory
+	directoryURL string
 	HTTPClient   *http.Client
 
 	common         service // Reuse a single struct instead of allocating one for each service on the heap.
@@ -46,7 +48,7 @@ func New(httpClient *http.Client, userAgent, caDirURL, kid string, privateKey cr
 
 	jws := secure.NewJWS(privateKey, kid, nonceManager)
 
-	c := &Core{doer: doer, nonceManager: nonceManager, jws: jws, directory: dir, HTTPClient: httpClient}
+	c := &Core{doer: doer, nonceManager: nonceManager, jws: jws, directory: dir, directoryURL: caDirURL, HTTPClient: httpClient}
 
 	c.common.core = c
 	c.Accounts = (*AccountService)(&c.common)
@@ -75,7 +77,15 @@ func (a *Core) postAsGet(uri string, response interface{}) (*http.Response, erro
 	return a.retrievablePost(uri, []byte{}, response)
 }
 
+// retrievablePost signs and sends the request again when the nonce is invalidated,
+// or after the delay requested by the server when it throttles the client (429 or 503 with a Retry-After header).
 func (a *Core) retrievablePost(uri string, content []byte, response interface{}) (*http.Response, error) {
+	return a.doer.Throttler().Retry(uri, func() (*http.Response, error) {
+		return a.noncePost(uri, content, response)
+	})
+}
+
+func (a *Core) noncePost(uri string, content []byte, response interface{}) (*http.Response, error) {
 	// during tests, allow to support ~90% of bad nonce with a minimum of attempts.
 	bo := backoff.NewExponentialBackOff()
 	bo.InitialInterval = 200 * time.Millisecond
@@ -139,15 +149,63 @@ func (a *Core) signEABContent(newAccountURL, kid string, hmac []byte) ([]byte, e
 	return []byte(eabJWS.FullSerialize()), nil
 }
 
+// SetMaxRetryAfter sets the maximum delay, cumulated over the retries, honored when the server throttles a request (HTTP 429 or 503).
+// The throttled requests are not counted in the retries of the invalid nonces.
+// If the server requests a longer delay, the error is returned immediately.
+func (a *Core) SetMaxRetryAfter(maxWait time.Duration) {
+	a.doer.Throttler().SetMaxWait(maxWait)
+}
+
+// SetThrottleContext sets the context of the pauses requested by the server:
+// the pending requests are aborted when the context is done.
+func (a *Core) SetThrottleContext(ctx context.Context) {
+	a.doer.Throttler().SetContext(ctx)
+}
+
+// SetNoncePoolSize sets the size of the pool of nonces prefetched in the background
+// (useful to reduce the latency when issuing a lot of certificates).
+// The default size is 0: a nonce is fetched only when there is no nonce available from the previous responses.
+func (a *Core) SetNoncePoolSize(size int) {
+	a.nonceManager.SetPoolSize(size)
+}
+
+// SetThrottleHook sets a function called each time the server throttles the client.
+func (a *Core) SetThrottleHook(fn func(ThrottleEvent)) {
+	if fn == nil {
+		a.doer.Throttler().SetNotify(nil)
+		return
+	}
+
+	a.doer.Throttler().SetNotify(func(uri string, statusCode int, retryAfter time.Duration) {
+		fn(ThrottleEvent{URL: uri, StatusCode: statusCode, RetryAfter: retryAfter})
+	})
+}
+
 // GetKeyAuthorization Gets the key authorization.
 func (a *Core) GetKeyAuthorization(token string) (string, error) {
 	return a.jws.GetKeyAuthorization(token)
 }
 
+// GetAccountURL Gets the URL of the account (key identifier).
+func (a *Core) GetAccountURL() string {
+	return a.jws.GetKid()
+}
+
 func (a *Core) GetDirectory() acme.Directory {
 	return a.directory
 }
 
+// GetDirectoryURL Gets the URL of the directory.
+func (a *Core) GetDirectoryURL() string {
+	return a.directoryURL
+}
+
+// Probe checks the availability of the ACME server by fetching the directory.
+func (a *Core) Probe() error {
+	_, err := getDirectory(a.doer, a.directoryURL)
+	return err
+}
+
 func getDirectory(do *sender.Doer, caDirURL string) (acme.Directory, error) {
 	var dir acme.Directory
 	if _, err := do.Get(caDirURL, &dir); err != nil {
@@ -14,6 +14,11 @@ type Manager struct {
 	do       *sender.Doer
 	nonceURL string
 	nonces   []string
+
+	// poolSize the size of the pool of nonces (0: no prefetching).
+	poolSize    int
+	prefetching bool
+
 	sync.Mutex
 }
 
@@ -25,6 +30,18 @@ func NewManager(do *sender.Doer, nonceURL string) *Manager {
 	}
 }
 
+// SetPoolSize Sets the size of the pool of nonces.
+// When the size is greater than 0, the pool is refilled in the background (prefetching) each time a nonce is used,
+// and the oldest nonces are dropped when the pool is full.
+// Otherwise, a nonce is fetched only when there is no nonce available.
+func (n *Manager) SetPoolSize(size int) {
+	n.Lock()
+	n.poolSize = max(size, 0)
+	n.Unlock()
+
+	n.refill()
+}
+
 // Pop Pops a nonce.
 func (n *Manager) Pop() (string, bool) {
 	n.Lock()
@@ -43,17 +60,64 @@ func (n *Manager) Pop() (string, bool) {
 func (n *Manager) Push(nonce string) {
 	n.Lock()
 	defer n.Unlock()
+
+	if n.poolSize > 0 && len(n.nonces) >= n.poolSize {
+		// The oldest nonces are the most likely to be expired.
+		n.nonces = n.nonces[len(n.nonces)-n.poolSize+1:]
+	}
+
 	n.nonces = append(n.nonces, nonce)
 }
 
 // Nonce implement jose.NonceSource.
 func (n *Manager) Nonce() (string, error) {
+	defer n.refill()
+
 	if nonce, ok := n.Pop(); ok {
 		return nonce, nil
 	}
 	return n.getNonce()
 }
 
+// refill starts the prefetching of nonces in the background if the pool is not full.
+func (n *Manager) refill() {
+	n.Lock()
+	defer n.Unlock()
+
+	if n.poolSize == 0 || n.prefetching || len(n.nonces) >= n.poolSize {
+		return
+	}
+
+	n.prefetching = true
+
+	go n.prefetch()
+}
+
+func (n *Manager) prefetch() {
+	defer func() {
+		n.Lock()
+		n.prefetching = false
+		n.Unlock()
+	}()
+
+	for {
+		n.Lock()
+		full := len(n.nonces) >= n.poolSize
+		n.Unlock()
+
+		if full {
+			return
+		}
+
+		nonce, err := n.getNonce()
+		if err != nil {
+			return
+		}
+
+		n.Push(nonce)
+	}
+}
+
 func (n *Manager) getNonce() (string, error) {
 	resp, err := n.do.Head(n.nonceURL)
 	if err != nil {
@@ -3,12 +3,16 @@ package nonces
 import (
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/go-acme/lego/v4/acme"
 	"github.com/go-acme/lego/v4/acme/api/internal/sender"
 	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNotHoldingLockWhileMakingHTTPRequests(t *testing.T) {
@@ -53,3 +57,46 @@ func TestNotHoldingLockWhileMakingHTTPRequests(t *testing.T) {
 		t.Fatal("JWS is probably holding a lock while making HTTP request")
 	}
 }
+
+func TestManager_SetPoolSize(t *testing.T) {
+	var counter atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Replay-Nonce", strconv.Itoa(int(counter.Add(1))))
+	}))
+	t.Cleanup(server.Close)
+
+	doer := sender.NewDoer(http.DefaultClient, "lego-test")
+	manager := NewManager(doer, server.URL)
+
+	manager.SetPoolSize(3)
+
+	assert.Eventually(t, func() bool { return poolLen(manager) == 3 }, time.Second, 10*time.Millisecond)
+
+	nonce, err := manager.Nonce()
+	require.NoError(t, err)
+
+	assert.Equal(t, "3", nonce)
+
+	assert.Eventually(t, func() bool { return poolLen(manager) == 3 }, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, int32(4), counter.Load())
+}
+
+func TestManager_Push_poolSize(t *testing.T) {
+	manager := NewManager(nil, "")
+	manager.poolSize = 2
+
+	manager.Push("a")
+	manager.Push("b")
+	manager.Push("c")
+
+	assert.Equal(t, []string{"b", "c"}, manager.nonces)
+}
+
+func poolLen(manager *Manager) int {
+	manager.Lock()
+	defer manager.Unlock()
+
+	return len(manager.nonces)
+}
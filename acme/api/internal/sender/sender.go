@@ -23,6 +23,7 @@ func contentType(ct string) RequestOption {
 type Doer struct {
 	httpClient *http.Client
 	userAgent  string
+	throttler  *Throttler
 }
 
 // NewDoer Creates a new Doer.
@@ -30,32 +31,46 @@ func NewDoer(client *http.Client, userAgent string) *Doer {
 	return &Doer{
 		httpClient: client,
 		userAgent:  userAgent,
+		throttler:  newThrottler(),
 	}
 }
 
+// Throttler returns the throttler shared by all the requests of the Doer.
+func (d *Doer) Throttler() *Throttler {
+	return d.throttler
+}
+
 // Get performs a GET request with a proper User-Agent string.
+// The request is retried while the server throttles the client.
 // If "response" is not provided, callers should close resp.Body when done reading from it.
 func (d *Doer) Get(url string, response interface{}) (*http.Response, error) {
-	req, err := d.newRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
-	}
+	return d.throttler.Retry(url, func() (*http.Response, error) {
+		req, err := d.newRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
 
-	return d.do(req, response)
+		return d.do(req, response)
+	})
 }
 
 // Head performs a HEAD request with a proper User-Agent string.
+// The request is retried while the server throttles the client.
 // The response body (resp.Body) is already closed when this function returns.
 func (d *Doer) Head(url string) (*http.Response, error) {
-	req, err := d.newRequest(http.MethodHead, url, nil)
-	if err != nil {
-		return nil, err
-	}
+	return d.throttler.Retry(url, func() (*http.Response, error) {
+		req, err := d.newRequest(http.MethodHead, url, nil)
+		if err != nil {
+			return nil, err
+		}
 
-	return d.do(req, nil)
+		return d.do(req, nil)
+	})
 }
 
 // Post performs a POST request with a proper User-Agent string.
+// The request is not retried when the server throttles the client:
+// a signed request must be signed again with a new nonce (see Throttler.Retry).
 // If "response" is not provided, callers should close resp.Body when done reading from it.
 func (d *Doer) Post(url string, body io.Reader, bodyType string, response interface{}) (*http.Response, error) {
 	req, err := d.newRequest(http.MethodPost, url, body, contentType(bodyType))
@@ -142,6 +157,10 @@ func checkError(req *http.Request, resp *http.Response) error {
 			return &acme.NonceError{ProblemDetails: errorDetails}
 		}
 
+		if errorDetails.HTTPStatus == http.StatusConflict && errorDetails.Type == acme.AlreadyReplacedErr {
+			return &acme.AlreadyReplacedError{ProblemDetails: errorDetails}
+		}
+
 		return errorDetails
 	}
 	return nil
@@ -0,0 +1,141 @@
+package sender
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme"
+)
+
+// DefaultMaxRetryAfter is the default maximum delay honored by the throttler for a request.
+const DefaultMaxRetryAfter = time.Minute
+
+// Throttler pauses all the requests of a Doer when the ACME server responds with a Retry-After header (429 or 503).
+type Throttler struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	until   time.Time
+	maxWait time.Duration
+	notify  func(uri string, statusCode int, retryAfter time.Duration)
+}
+
+func newThrottler() *Throttler {
+	return &Throttler{ctx: context.Background(), maxWait: DefaultMaxRetryAfter}
+}
+
+// SetContext sets the context of the pauses: the pauses are interrupted when the context is done.
+func (t *Throttler) SetContext(ctx context.Context) {
+	t.mu.Lock()
+	t.ctx = ctx
+	t.mu.Unlock()
+}
+
+// SetMaxWait sets the maximum delay, cumulated over the retries, honored for a request.
+func (t *Throttler) SetMaxWait(maxWait time.Duration) {
+	t.mu.Lock()
+	t.maxWait = maxWait
+	t.mu.Unlock()
+}
+
+// SetNotify sets a function called each time the server throttles the client.
+func (t *Throttler) SetNotify(fn func(uri string, statusCode int, retryAfter time.Duration)) {
+	t.mu.Lock()
+	t.notify = fn
+	t.mu.Unlock()
+}
+
+// Retry runs the request, and runs it again after the delay requested by the server while it is throttled.
+// The requests are not retried once the cumulated delay exceeds the maximum delay.
+func (t *Throttler) Retry(uri string, do func() (*http.Response, error)) (*http.Response, error) {
+	var waited time.Duration
+
+	for {
+		err := t.wait()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := do()
+		if err == nil {
+			return resp, nil
+		}
+
+		retryAfter, ok := t.check(uri, resp)
+		if !ok || waited+retryAfter > t.getMaxWait() {
+			return resp, err
+		}
+
+		waited += retryAfter
+
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}
+}
+
+// wait blocks until the end of the pause requested by the server.
+func (t *Throttler) wait() error {
+	t.mu.Lock()
+	ctx, until := t.ctx, t.until
+	t.mu.Unlock()
+
+	d := time.Until(until)
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// check returns the delay requested by the server if the response is throttled.
+// The pause is shared by all the requests, unless the delay exceeds the maximum delay.
+func (t *Throttler) check(uri string, resp *http.Response) (time.Duration, bool) {
+	if resp == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return 0, false
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	retryAfter, err := acme.ParseRetryAfter(value, time.Now())
+	if err != nil {
+		return 0, false
+	}
+
+	t.mu.Lock()
+
+	notify := t.notify
+
+	if retryAfter <= t.maxWait {
+		if until := time.Now().Add(retryAfter); until.After(t.until) {
+			t.until = until
+		}
+	}
+
+	t.mu.Unlock()
+
+	if notify != nil {
+		notify(uri, resp.StatusCode, retryAfter)
+	}
+
+	return retryAfter, true
+}
+
+func (t *Throttler) getMaxWait() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.maxWait
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+)
+
+// Middleware wraps the round-trips of the HTTP requests sent to the ACME server
+// (ex: metrics, retries, audit, tracing spans).
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter to allow the use of ordinary functions as http.RoundTripper.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithMiddlewares returns a copy of the HTTP client with the middlewares applied to its transport.
+// The first middleware is the outermost: it sees the requests first and the responses last.
+func WithMiddlewares(client *http.Client, middlewares ...Middleware) *http.Client {
+	if len(middlewares) == 0 {
+		return client
+	}
+
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		transport = middlewares[i](transport)
+	}
+
+	clone := *client
+	clone.Transport = transport
+
+	return &clone
+}
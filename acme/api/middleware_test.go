@@ -0,0 +1,50 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMiddlewares(t *testing.T) {
+	_, apiURL := tester.SetupFakeAPI(t)
+
+	var mu sync.Mutex
+	var calls []string
+
+	record := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				calls = append(calls, name+" "+req.Method+" "+req.URL.Path)
+				mu.Unlock()
+
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	client := WithMiddlewares(http.DefaultClient, record("first"), record("second"))
+
+	assert.Nil(t, http.DefaultClient.Transport)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	_, err = New(client, "lego-test", apiURL+"/dir", "", key)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"first GET /dir", "second GET /dir"}, calls)
+}
+
+func TestWithMiddlewares_none(t *testing.T) {
+	client := &http.Client{}
+
+	assert.Same(t, client, WithMiddlewares(client))
+}
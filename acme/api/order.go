@@ -17,6 +17,10 @@ type OrderOptions struct {
 	// order is intended to replace.
 	// - https://datatracker.ietf.org/doc/html/draft-ietf-acme-ari-03#section-5
 	ReplacesCertID string
+
+	// The name of one of the profiles advertised by the server (directory meta "profiles").
+	// - https://datatracker.ietf.org/doc/draft-aaron-acme-profiles/
+	Profile string
 }
 
 type OrderService service
@@ -53,10 +57,22 @@ func (o *OrderService) NewWithOptions(domains []string, opts *OrderOptions) (acm
 		if o.core.GetDirectory().RenewalInfo != "" {
 			orderReq.Replaces = opts.ReplacesCertID
 		}
+
+		orderReq.Profile = opts.Profile
 	}
 
 	var order acme.Order
 	resp, err := o.core.post(o.core.GetDirectory().NewOrderURL, orderReq, &order)
+
+	var are *acme.AlreadyReplacedError
+	if orderReq.Replaces != "" && errors.As(err, &are) {
+		// The certificate has already been replaced (ex: by another client):
+		// the order is created again without the "replaces" field.
+		// https://datatracker.ietf.org/doc/html/draft-ietf-acme-ari-03#section-5
+		orderReq.Replaces = ""
+		resp, err = o.core.post(o.core.GetDirectory().NewOrderURL, orderReq, &order)
+	}
+
 	if err != nil {
 		return acme.ExtendedOrder{}, err
 	}
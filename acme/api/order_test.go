@@ -52,6 +52,7 @@ func TestOrderService_NewWithOptions(t *testing.T) {
 			Authorizations: order.Authorizations,
 			Finalize:       order.Finalize,
 			Certificate:    order.Certificate,
+			Profile:        order.Profile,
 		})
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -91,6 +92,19 @@ func TestOrderService_NewWithOptions(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "with profile",
+			opts: &OrderOptions{
+				Profile: "shortlived",
+			},
+			expected: acme.ExtendedOrder{
+				Order: acme.Order{
+					Status:      "valid",
+					Identifiers: []acme.Identifier{{Type: "dns", Value: "example.com"}},
+					Profile:     "shortlived",
+				},
+			},
+		},
 	}
 
 	for _, test := range testCases {
@@ -105,6 +119,62 @@ func TestOrderService_NewWithOptions(t *testing.T) {
 	}
 }
 
+func TestOrderService_NewWithOptions_alreadyReplaced(t *testing.T) {
+	mux, apiURL := tester.SetupFakeAPI(t)
+
+	// small value keeps test fast
+	privateKey, errK := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, errK, "Could not generate test key")
+
+	var replaces []string
+
+	mux.HandleFunc("/newOrder", func(w http.ResponseWriter, r *http.Request) {
+		body, err := readSignedBody(r, privateKey)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		order := acme.Order{}
+		err = json.Unmarshal(body, &order)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		replaces = append(replaces, order.Replaces)
+
+		if order.Replaces != "" {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(acme.ProblemDetails{
+				Type:       acme.AlreadyReplacedErr,
+				Detail:     "certificate already replaced",
+				HTTPStatus: http.StatusConflict,
+			})
+			return
+		}
+
+		err = tester.WriteJSONResponse(w, acme.Order{
+			Status:      acme.StatusPending,
+			Identifiers: order.Identifiers,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+
+	core, err := New(http.DefaultClient, "lego-test", apiURL+"/dir", "", privateKey)
+	require.NoError(t, err)
+
+	order, err := core.Orders.NewWithOptions([]string{"example.com"}, &OrderOptions{ReplacesCertID: "aYhba4dGQEHhs3uEe6CuLN4ByNQ.AIdlQyE"})
+	require.NoError(t, err)
+
+	assert.Equal(t, acme.StatusPending, order.Status)
+	assert.Equal(t, []string{"aYhba4dGQEHhs3uEe6CuLN4ByNQ.AIdlQyE", ""}, replaces)
+}
+
 func readSignedBody(r *http.Request, privateKey *rsa.PrivateKey) ([]byte, error) {
 	reqBody, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -24,5 +24,5 @@ func (c *CertificateService) GetRenewalInfo(certID string) (*http.Response, erro
 		return nil, errors.New("renewalInfo[get]: 'certID' cannot be empty")
 	}
 
-	return c.core.HTTPClient.Get(c.core.GetDirectory().RenewalInfo + "/" + certID)
+	return c.core.doer.Get(c.core.GetDirectory().RenewalInfo+"/"+certID, nil)
 }
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"time"
+
+	"github.com/go-acme/lego/v4/acme/api/internal/sender"
+)
+
+// DefaultMaxRetryAfter is the default maximum delay, cumulated over the retries, honored for a throttled request.
+const DefaultMaxRetryAfter = sender.DefaultMaxRetryAfter
+
+// ThrottleEvent describes a request throttled by the ACME server.
+type ThrottleEvent struct {
+	// URL of the throttled request.
+	URL string
+	// StatusCode of the response (429 or 503).
+	StatusCode int
+	// RetryAfter is the delay requested by the server.
+	RetryAfter time.Duration
+}
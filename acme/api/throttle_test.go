@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCore_throttled(t *testing.T) {
+	mux, apiURL := tester.SetupFakeAPI(t)
+
+	var calls atomic.Int32
+
+	mux.HandleFunc("/order/1", func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"type":"urn:ietf:params:acme:error:rateLimited","detail":"slow down","status":429}`))
+			return
+		}
+
+		err := tester.WriteJSONResponse(w, acme.Order{Status: acme.StatusValid})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	core, err := New(http.DefaultClient, "lego-test", apiURL+"/dir", "", privateKey)
+	require.NoError(t, err)
+
+	var events []ThrottleEvent
+	core.SetThrottleHook(func(event ThrottleEvent) {
+		events = append(events, event)
+	})
+
+	start := time.Now()
+
+	order, err := core.Orders.Get(apiURL + "/order/1")
+	require.NoError(t, err)
+
+	assert.Equal(t, acme.StatusValid, order.Status)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.EqualValues(t, 2, calls.Load())
+
+	expected := []ThrottleEvent{{URL: apiURL + "/order/1", StatusCode: http.StatusTooManyRequests, RetryAfter: time.Second}}
+	assert.Equal(t, expected, events)
+}
+
+func TestCore_throttled_tooLong(t *testing.T) {
+	mux, apiURL := tester.SetupFakeAPI(t)
+
+	var calls atomic.Int32
+
+	mux.HandleFunc("/order/1", func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+
+		w.Header().Set("Retry-After", "3600")
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	})
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	core, err := New(http.DefaultClient, "lego-test", apiURL+"/dir", "", privateKey)
+	require.NoError(t, err)
+
+	core.SetMaxRetryAfter(time.Minute)
+
+	_, err = core.Orders.Get(apiURL + "/order/1")
+	require.Error(t, err)
+
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestCore_throttled_cumulatedDelay(t *testing.T) {
+	mux, apiURL := tester.SetupFakeAPI(t)
+
+	var calls atomic.Int32
+
+	mux.HandleFunc("/order/1", func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+	})
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	core, err := New(http.DefaultClient, "lego-test", apiURL+"/dir", "", privateKey)
+	require.NoError(t, err)
+
+	core.SetMaxRetryAfter(2 * time.Second)
+
+	_, err = core.Orders.Get(apiURL + "/order/1")
+	require.Error(t, err)
+
+	// 2 retries of 1 second, then the maximum delay is reached.
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+func TestCore_throttled_context(t *testing.T) {
+	mux, apiURL := tester.SetupFakeAPI(t)
+
+	mux.HandleFunc("/order/1", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	})
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	core, err := New(http.DefaultClient, "lego-test", apiURL+"/dir", "", privateKey)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	core.SetThrottleContext(ctx)
+	core.SetThrottleHook(func(ThrottleEvent) { cancel() })
+
+	start := time.Now()
+
+	_, err = core.Orders.Get(apiURL + "/order/1")
+	require.ErrorIs(t, err, context.Canceled)
+
+	assert.Less(t, time.Since(start), 30*time.Second)
+}
+
+func TestCore_throttled_get(t *testing.T) {
+	mux, apiURL := tester.SetupFakeAPI(t)
+
+	var calls atomic.Int32
+
+	mux.HandleFunc("/renewalInfo/", func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	core, err := New(http.DefaultClient, "lego-test", apiURL+"/dir", "", privateKey)
+	require.NoError(t, err)
+
+	var events []ThrottleEvent
+	core.SetThrottleHook(func(event ThrottleEvent) {
+		events = append(events, event)
+	})
+
+	resp, err := core.Certificates.GetRenewalInfo("certID")
+	require.NoError(t, err)
+
+	_ = resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 2, calls.Load())
+
+	expected := []ThrottleEvent{{URL: apiURL + "/renewalInfo/certID", StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Second}}
+	assert.Equal(t, expected, events)
+}
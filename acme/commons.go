@@ -74,6 +74,11 @@ type Meta struct {
 	// then the CA requires that all new-account requests include an "externalAccountBinding" field
 	// associating the new account with an external account.
 	ExternalAccountRequired bool `json:"externalAccountRequired"`
+
+	// profiles (optional, object):
+	// A map of profile names to human-readable descriptions of those profiles.
+	// - https://datatracker.ietf.org/doc/draft-aaron-acme-profiles/
+	Profiles map[string]string `json:"profiles"`
 }
 
 // ExtendedAccount an extended Account.
@@ -122,6 +127,15 @@ type Account struct {
 	ExternalAccountBinding json.RawMessage `json:"externalAccountBinding,omitempty"`
 }
 
+// OrdersList the list of the orders of an account.
+// The list can be paginated: the next page is in the response header `Link` with the relation "next".
+// - https://www.rfc-editor.org/rfc/rfc8555.html#section-7.1.2.1
+type OrdersList struct {
+	// orders (required, array of string):
+	// An array of URLs, each identifying an order belonging to the account.
+	Orders []string `json:"orders"`
+}
+
 // ExtendedOrder a extended Order.
 type ExtendedOrder struct {
 	Order
@@ -130,6 +144,14 @@ type ExtendedOrder struct {
 	Location string `json:"-"`
 }
 
+// ExtendedAuthorization an extended Authorization.
+type ExtendedAuthorization struct {
+	Authorization
+
+	// The authorization URL, contains the value of the response header `Location`
+	Location string `json:"-"`
+}
+
 // Order the ACME order Object.
 // - https://www.rfc-editor.org/rfc/rfc8555.html#section-7.1.3
 type Order struct {
@@ -187,6 +209,11 @@ type Order struct {
 	// previously-issued certificate which this order is intended to replace.
 	// - https://datatracker.ietf.org/doc/html/draft-ietf-acme-ari-03#section-5
 	Replaces string `json:"replaces,omitempty"`
+
+	// profile (string, optional):
+	// A string containing the name of one of the profiles advertised in the directory's meta.
+	// - https://datatracker.ietf.org/doc/draft-aaron-acme-profiles/
+	Profile string `json:"profile,omitempty"`
 }
 
 // Authorization the ACME authorization object.
@@ -5,9 +5,34 @@ import (
 )
 
 // Errors types.
+// - https://www.rfc-editor.org/rfc/rfc8555.html#section-6.7
 const (
-	errNS       = "urn:ietf:params:acme:error:"
-	BadNonceErr = errNS + "badNonce"
+	errNS                      = "urn:ietf:params:acme:error:"
+	AccountDoesNotExistErr     = errNS + "accountDoesNotExist"
+	AlreadyRevokedErr          = errNS + "alreadyRevoked"
+	BadCSRErr                  = errNS + "badCSR"
+	BadNonceErr                = errNS + "badNonce"
+	BadPublicKeyErr            = errNS + "badPublicKey"
+	BadRevocationReasonErr     = errNS + "badRevocationReason"
+	BadSignatureAlgorithmErr   = errNS + "badSignatureAlgorithm"
+	CAAErr                     = errNS + "caa"
+	CompoundErr                = errNS + "compound"
+	ConnectionErr              = errNS + "connection"
+	DNSErr                     = errNS + "dns"
+	ExternalAccountRequiredErr = errNS + "externalAccountRequired"
+	IncorrectResponseErr       = errNS + "incorrectResponse"
+	InvalidContactErr          = errNS + "invalidContact"
+	MalformedErr               = errNS + "malformed"
+	OrderNotReadyErr           = errNS + "orderNotReady"
+	RateLimitedErr             = errNS + "rateLimited"
+	RejectedIdentifierErr      = errNS + "rejectedIdentifier"
+	ServerInternalErr          = errNS + "serverInternal"
+	TLSErr                     = errNS + "tls"
+	UnauthorizedErr            = errNS + "unauthorized"
+	UnsupportedContactErr      = errNS + "unsupportedContact"
+	UnsupportedIdentifierErr   = errNS + "unsupportedIdentifier"
+	UserActionRequiredErr      = errNS + "userActionRequired"
+	AlreadyReplacedErr         = errNS + "alreadyReplaced"
 )
 
 // ProblemDetails the problem details object.
@@ -51,8 +76,90 @@ func (p ProblemDetails) Error() string {
 	return msg
 }
 
+func (s SubProblem) Error() string {
+	return fmt.Sprintf("acme: error: %s :: %s :: %s", s.Identifier.Value, s.Type, s.Detail)
+}
+
 // NonceError represents the error which is returned
 // if the nonce sent by the client was not accepted by the server.
 type NonceError struct {
 	*ProblemDetails
 }
+
+func (e *NonceError) Unwrap() error {
+	return e.ProblemDetails
+}
+
+// AlreadyReplacedError represents the error which is returned
+// if the certificate identified by the "replaces" field of a new order has already been replaced.
+// - https://datatracker.ietf.org/doc/html/draft-ietf-acme-ari-03#section-5
+type AlreadyReplacedError struct {
+	*ProblemDetails
+}
+
+func (e *AlreadyReplacedError) Unwrap() error {
+	return e.ProblemDetails
+}
+
+// IdentifierError is an error related to an identifier (ex: a domain of a SAN order).
+type IdentifierError struct {
+	Identifier string
+	Err        error
+}
+
+func (e *IdentifierError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Identifier, e.Err)
+}
+
+func (e *IdentifierError) Unwrap() error {
+	return e.Err
+}
+
+// ProblemsByIdentifier returns the problems sent by the CA contained in err, keyed by identifier value
+// (ex: to know which domains of a SAN order failed the validation and why).
+// The subproblems (RFC 8555 section 6.7.1) are returned as problems of their identifiers.
+// A problem not related to an identifier is keyed by an empty string.
+func ProblemsByIdentifier(err error) map[string]*ProblemDetails {
+	problems := make(map[string]*ProblemDetails)
+
+	collectProblems(err, "", problems)
+
+	return problems
+}
+
+func collectProblems(err error, identifier string, problems map[string]*ProblemDetails) {
+	switch e := err.(type) {
+	case nil:
+		return
+
+	case *IdentifierError:
+		identifier = e.Identifier
+
+	case *ProblemDetails:
+		for _, sub := range e.SubProblems {
+			problems[sub.Identifier.Value] = &ProblemDetails{
+				Type:       sub.Type,
+				Detail:     sub.Detail,
+				HTTPStatus: e.HTTPStatus,
+				Method:     e.Method,
+				URL:        e.URL,
+			}
+		}
+
+		if len(e.SubProblems) == 0 || identifier != "" {
+			problems[identifier] = e
+		}
+
+		return
+	}
+
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		collectProblems(u.Unwrap(), identifier, problems)
+
+	case interface{ Unwrap() []error }:
+		for _, e := range u.Unwrap() {
+			collectProblems(e, identifier, problems)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package acme
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblemsByIdentifier(t *testing.T) {
+	dnsProblem := &ProblemDetails{Type: DNSErr, Detail: "NXDOMAIN", HTTPStatus: 400}
+	caaProblem := &ProblemDetails{Type: CAAErr, Detail: "CAA record forbids issuance", HTTPStatus: 403}
+
+	err := fmt.Errorf("error: one or more domains had a problem:\n%w", errors.Join(
+		&IdentifierError{Identifier: "a.example.com", Err: dnsProblem},
+		&IdentifierError{Identifier: "b.example.com", Err: fmt.Errorf("wrapped: %w", caaProblem)},
+		&IdentifierError{Identifier: "c.example.com", Err: errors.New("could not determine solvers")},
+	))
+
+	problems := ProblemsByIdentifier(err)
+
+	expected := map[string]*ProblemDetails{
+		"a.example.com": dnsProblem,
+		"b.example.com": caaProblem,
+	}
+
+	assert.Equal(t, expected, problems)
+}
+
+func TestProblemsByIdentifier_subProblems(t *testing.T) {
+	problem := &ProblemDetails{
+		Type:       RejectedIdentifierErr,
+		Detail:     "Some of the identifiers requested were rejected",
+		HTTPStatus: 400,
+		SubProblems: []SubProblem{
+			{Type: MalformedErr, Detail: "Invalid underscore in DNS name", Identifier: Identifier{Type: "dns", Value: "_example.org"}},
+			{Type: RejectedIdentifierErr, Detail: "This CA will not issue for this name", Identifier: Identifier{Type: "dns", Value: "example.net"}},
+		},
+	}
+
+	problems := ProblemsByIdentifier(&NonceError{ProblemDetails: problem})
+
+	require.Len(t, problems, 2)
+
+	assert.Equal(t, MalformedErr, problems["_example.org"].Type)
+	assert.Equal(t, RejectedIdentifierErr, problems["example.net"].Type)
+	assert.Equal(t, 400, problems["example.net"].HTTPStatus)
+}
+
+func TestProblemsByIdentifier_noIdentifier(t *testing.T) {
+	problem := &ProblemDetails{Type: RateLimitedErr, HTTPStatus: 429}
+
+	problems := ProblemsByIdentifier(fmt.Errorf("new order: %w", problem))
+
+	assert.Equal(t, map[string]*ProblemDetails{"": problem}, problems)
+}
@@ -0,0 +1,24 @@
+package acme
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ParseRetryAfter parses the value of the Retry-After header: a number of seconds or an HTTP-date.
+// A date in the past is a delay of 0.
+// https://www.rfc-editor.org/rfc/rfc9110.html#section-10.2.3
+func ParseRetryAfter(value string, now time.Time) (time.Duration, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), nil
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Retry-After value: %q", value)
+	}
+
+	return max(date.Sub(now), 0), nil
+}
@@ -0,0 +1,59 @@
+package acme
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		desc     string
+		value    string
+		expected time.Duration
+	}{
+		{
+			desc:     "seconds",
+			value:    "21600",
+			expected: 6 * time.Hour,
+		},
+		{
+			desc:     "negative seconds",
+			value:    "-10",
+			expected: 0,
+		},
+		{
+			desc:     "HTTP-date",
+			value:    "Fri, 01 Mar 2024 18:00:00 GMT",
+			expected: 6 * time.Hour,
+		},
+		{
+			desc:     "HTTP-date in the past",
+			value:    "Fri, 01 Mar 2024 06:00:00 GMT",
+			expected: 0,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			retryAfter, err := ParseRetryAfter(test.value, now)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, retryAfter)
+		})
+	}
+}
+
+func TestParseRetryAfter_invalid(t *testing.T) {
+	_, err := ParseRetryAfter("tomorrow", time.Now())
+	require.EqualError(t, err, `invalid Retry-After value: "tomorrow"`)
+
+	_, err = ParseRetryAfter("", time.Now())
+	require.EqualError(t, err, `invalid Retry-After value: ""`)
+}
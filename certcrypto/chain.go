@@ -0,0 +1,130 @@
+package certcrypto
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+// https://www.rfc-editor.org/rfc/rfc2315.html#section-14
+var (
+	oidPKCS7Data       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidPKCS7SignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+)
+
+// CertificateChain a certificate and the chain of its issuers.
+type CertificateChain struct {
+	Leaf          *x509.Certificate
+	Intermediates []*x509.Certificate
+	// Root the self-signed root certificate, nil if the chain does not contain it
+	// (the CAs usually do not provide the root certificates).
+	Root *x509.Certificate
+}
+
+// ParseCertificateChain parses the certificate chain from the certificate (or bundle) and the issuer certificates (optional).
+// The issuer certificates are only used if the certificate is not a bundle.
+func ParseCertificateChain(certPEM, issuerPEM []byte) (*CertificateChain, error) {
+	certificates, err := ParsePEMBundle(certPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	issuers := certificates[1:]
+
+	if len(issuers) == 0 && len(issuerPEM) > 0 {
+		issuers, err = ParsePEMBundle(issuerPEM)
+		if err != nil {
+			return nil, fmt.Errorf("issuer: %w", err)
+		}
+	}
+
+	chain := &CertificateChain{Leaf: certificates[0]}
+
+	for _, issuer := range issuers {
+		if isSelfSigned(issuer) {
+			chain.Root = issuer
+			continue
+		}
+
+		chain.Intermediates = append(chain.Intermediates, issuer)
+	}
+
+	return chain, nil
+}
+
+// Certificates returns the certificates of the chain (the leaf, the intermediates, and the root if any).
+func (c *CertificateChain) Certificates() []*x509.Certificate {
+	certificates := append([]*x509.Certificate{c.Leaf}, c.Intermediates...)
+
+	if c.Root != nil {
+		certificates = append(certificates, c.Root)
+	}
+
+	return certificates
+}
+
+// PEMEncodeCertificates encodes the certificates into PEM blocks.
+func PEMEncodeCertificates(certificates []*x509.Certificate) []byte {
+	var buf bytes.Buffer
+
+	for _, cert := range certificates {
+		buf.Write(PEMEncode(DERCertificateBytes(cert.Raw)))
+	}
+
+	return buf.Bytes()
+}
+
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      pkcs7ContentInfo
+	Certificates     asn1.RawValue
+	SignerInfos      []asn1.RawValue `asn1:"set"`
+}
+
+// EncodePKCS7 encodes the certificates into a degenerate "certs-only" PKCS#7 SignedData (.p7b), DER encoded.
+func EncodePKCS7(certificates []*x509.Certificate) ([]byte, error) {
+	if len(certificates) == 0 {
+		return nil, errors.New("PKCS#7: no certificates")
+	}
+
+	var raw bytes.Buffer
+	for _, cert := range certificates {
+		raw.Write(cert.Raw)
+	}
+
+	signedData, err := asn1.Marshal(pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{},
+		ContentInfo:      pkcs7ContentInfo{ContentType: oidPKCS7Data},
+		// certificates [0] IMPLICIT ExtendedCertificatesAndCertificates
+		Certificates: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw.Bytes()},
+		SignerInfos:  []asn1.RawValue{},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("PKCS#7: %w", err)
+	}
+
+	content, err := asn1.Marshal(pkcs7ContentInfo{
+		ContentType: oidPKCS7SignedData,
+		// content [0] EXPLICIT
+		Content: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedData},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("PKCS#7: %w", err)
+	}
+
+	return content, nil
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(cert) == nil
+}
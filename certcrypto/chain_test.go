@@ -0,0 +1,117 @@
+package certcrypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestCertificate(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+	}
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+func TestParseCertificateChain(t *testing.T) {
+	root, rootKey := createTestCertificate(t, "Root", true, nil, nil)
+	intermediate, intermediateKey := createTestCertificate(t, "Intermediate", true, root, rootKey)
+	leaf, _ := createTestCertificate(t, "example.com", false, intermediate, intermediateKey)
+
+	testCases := []struct {
+		desc      string
+		certPEM   []byte
+		issuerPEM []byte
+		expected  *CertificateChain
+	}{
+		{
+			desc:     "bundle",
+			certPEM:  PEMEncodeCertificates([]*x509.Certificate{leaf, intermediate}),
+			expected: &CertificateChain{Leaf: leaf, Intermediates: []*x509.Certificate{intermediate}},
+		},
+		{
+			desc:      "issuer",
+			certPEM:   PEMEncodeCertificates([]*x509.Certificate{leaf}),
+			issuerPEM: PEMEncodeCertificates([]*x509.Certificate{intermediate, root}),
+			expected:  &CertificateChain{Leaf: leaf, Intermediates: []*x509.Certificate{intermediate}, Root: root},
+		},
+		{
+			desc:     "leaf only",
+			certPEM:  PEMEncodeCertificates([]*x509.Certificate{leaf}),
+			expected: &CertificateChain{Leaf: leaf},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			chain, err := ParseCertificateChain(test.certPEM, test.issuerPEM)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, chain)
+		})
+	}
+}
+
+func TestEncodePKCS7(t *testing.T) {
+	root, rootKey := createTestCertificate(t, "Root", true, nil, nil)
+	leaf, _ := createTestCertificate(t, "example.com", false, root, rootKey)
+
+	raw, err := EncodePKCS7([]*x509.Certificate{leaf, root})
+	require.NoError(t, err)
+
+	var contentInfo pkcs7ContentInfo
+	rest, err := asn1.Unmarshal(raw, &contentInfo)
+	require.NoError(t, err)
+	require.Empty(t, rest)
+
+	assert.Equal(t, oidPKCS7SignedData, contentInfo.ContentType)
+
+	var signedData pkcs7SignedData
+	_, err = asn1.Unmarshal(contentInfo.Content.Bytes, &signedData)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, signedData.Version)
+
+	certificates, err := x509.ParseCertificates(signedData.Certificates.Bytes)
+	require.NoError(t, err)
+
+	assert.Equal(t, []*x509.Certificate{leaf, root}, certificates)
+}
+
+func TestEncodePKCS7_empty(t *testing.T) {
+	_, err := EncodePKCS7(nil)
+	require.EqualError(t, err, "PKCS#7: no certificates")
+}
@@ -1,10 +1,10 @@
 package certcrypto
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/ed25519"
-	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -16,6 +16,7 @@ import (
 	"math/big"
 	"net"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -116,26 +117,56 @@ func ParsePEMPrivateKey(key []byte) (crypto.PrivateKey, error) {
 	return nil, errors.New("failed to parse private key")
 }
 
+// GeneratePrivateKey generates a private key of the key type (see RegisterKeyType).
 func GeneratePrivateKey(keyType KeyType) (crypto.PrivateKey, error) {
-	switch keyType {
-	case EC256:
-		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	case EC384:
-		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
-	case RSA2048:
-		return rsa.GenerateKey(rand.Reader, 2048)
-	case RSA3072:
-		return rsa.GenerateKey(rand.Reader, 3072)
-	case RSA4096:
-		return rsa.GenerateKey(rand.Reader, 4096)
-	case RSA8192:
-		return rsa.GenerateKey(rand.Reader, 8192)
-	}
-
-	return nil, fmt.Errorf("invalid KeyType: %s", keyType)
+	keyGenerators.RLock()
+	generator, ok := keyGenerators.m[keyType]
+	keyGenerators.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("invalid KeyType: %s", keyType)
+	}
+
+	return generator()
+}
+
+// GetKeyType returns the key type of a private key (the RSA and ECDSA key types).
+func GetKeyType(privateKey crypto.PrivateKey) (KeyType, error) {
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		keyType := KeyType(strconv.Itoa(key.N.BitLen()))
+		if !slices.Contains([]KeyType{RSA2048, RSA3072, RSA4096, RSA8192}, keyType) {
+			return "", fmt.Errorf("unsupported RSA key size: %d", key.N.BitLen())
+		}
+
+		return keyType, nil
+
+	case *ecdsa.PrivateKey:
+		switch key.Curve.Params().Name {
+		case "P-256":
+			return EC256, nil
+		case "P-384":
+			return EC384, nil
+		default:
+			return "", fmt.Errorf("unsupported ECDSA curve: %s", key.Curve.Params().Name)
+		}
+
+	default:
+		return "", fmt.Errorf("unsupported private key type: %T", privateKey)
+	}
 }
 
 func GenerateCSR(privateKey crypto.PrivateKey, domain string, san []string, mustStaple bool) ([]byte, error) {
+	return GenerateCustomCSR(privateKey, domain, san, mustStaple, nil)
+}
+
+// CSRTemplateFunc customizes the CSR template before signing
+// (ex: extra extensions, custom SAN ordering, key usages).
+type CSRTemplateFunc func(template *x509.CertificateRequest) error
+
+// GenerateCustomCSR generates a CSR like GenerateCSR,
+// the template is passed to customize (if not nil) before signing.
+func GenerateCustomCSR(privateKey crypto.PrivateKey, domain string, san []string, mustStaple bool, customize CSRTemplateFunc) ([]byte, error) {
 	var dnsNames []string
 	var ipAddresses []net.IP
 	for _, altname := range san {
@@ -159,11 +190,34 @@ func GenerateCSR(privateKey crypto.PrivateKey, domain string, san []string, must
 		})
 	}
 
+	if customize != nil {
+		err := customize(&template)
+		if err != nil {
+			return nil, fmt.Errorf("customize CSR: %w", err)
+		}
+	}
+
 	return x509.CreateCertificateRequest(rand.Reader, &template, privateKey)
 }
 
+// HasMustStaple checks if the certificate has the OCSP must-staple extension.
+func HasMustStaple(cert *x509.Certificate) bool {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(tlsFeatureExtensionOID) && bytes.Equal(ext.Value, ocspMustStapleFeature) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func PEMEncode(data interface{}) []byte {
-	return pem.EncodeToMemory(PEMBlock(data))
+	pemBlock := PEMBlock(data)
+	if pemBlock == nil {
+		return nil
+	}
+
+	return pem.EncodeToMemory(pemBlock)
 }
 
 func PEMBlock(data interface{}) *pem.Block {
@@ -178,6 +232,13 @@ func PEMBlock(data interface{}) *pem.Block {
 		pemBlock = &pem.Block{Type: "CERTIFICATE REQUEST", Bytes: key.Raw}
 	case DERCertificateBytes:
 		pemBlock = &pem.Block{Type: "CERTIFICATE", Bytes: []byte(data.(DERCertificateBytes))}
+	case crypto.Signer:
+		// Other private keys (ex: Ed25519): PKCS #8.
+		// The keys that cannot be exported (ex: HSM) have no PEM block.
+		keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+		if err == nil {
+			pemBlock = &pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}
+		}
 	}
 
 	return pemBlock
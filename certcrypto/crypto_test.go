@@ -3,9 +3,14 @@ package certcrypto
 import (
 	"bytes"
 	"crypto"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/pem"
+	"errors"
 	"regexp"
 	"testing"
 	"time"
@@ -21,6 +26,57 @@ func TestGeneratePrivateKey(t *testing.T) {
 	assert.NotNil(t, key)
 }
 
+func TestRegisterKeyType(t *testing.T) {
+	const keyType = KeyType("ED25519-test")
+
+	_, err := GeneratePrivateKey(keyType)
+	require.EqualError(t, err, "invalid KeyType: ED25519-test")
+
+	err = RegisterKeyType(keyType, func() (crypto.Signer, error) {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, KeyTypes(), keyType)
+
+	key, err := GeneratePrivateKey(keyType)
+	require.NoError(t, err)
+
+	assert.IsType(t, ed25519.PrivateKey{}, key)
+
+	pemKey := PEMEncode(key)
+	require.NotNil(t, pemKey)
+
+	parsed, err := ParsePEMPrivateKey(pemKey)
+	require.NoError(t, err)
+
+	assert.Equal(t, key, parsed)
+}
+
+func TestRegisterKeyType_error(t *testing.T) {
+	require.EqualError(t, RegisterKeyType("", nil), "register key type: empty key type")
+	require.EqualError(t, RegisterKeyType("foo", nil), "register key type foo: nil generator")
+}
+
+type opaqueSigner struct {
+	crypto.Signer
+}
+
+func TestPEMEncode_opaqueSigner(t *testing.T) {
+	privateKey, err := GeneratePrivateKey(EC256)
+	require.NoError(t, err)
+
+	signer := opaqueSigner{Signer: privateKey.(crypto.Signer)}
+
+	assert.Nil(t, PEMEncode(signer))
+
+	csr, err := GenerateCSR(signer, "example.com", nil, false)
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, csr)
+}
+
 func TestGenerateCSR(t *testing.T) {
 	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
 	require.NoError(t, err, "Error generating private key")
@@ -103,6 +159,106 @@ func TestGenerateCSR(t *testing.T) {
 	}
 }
 
+func TestGenerateCustomCSR(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err, "Error generating private key")
+
+	extension := pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}, Value: []byte{0x05, 0x00}}
+
+	raw, err := GenerateCustomCSR(privateKey, "lego.acme", []string{"lego.acme", "a.lego.acme"}, false, func(template *x509.CertificateRequest) error {
+		template.DNSNames = []string{"a.lego.acme", "lego.acme"}
+		template.ExtraExtensions = append(template.ExtraExtensions, extension)
+		return nil
+	})
+	require.NoError(t, err)
+
+	csr, err := x509.ParseCertificateRequest(raw)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a.lego.acme", "lego.acme"}, csr.DNSNames)
+	assert.Contains(t, csr.Extensions, extension)
+}
+
+func TestGenerateCustomCSR_error(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err, "Error generating private key")
+
+	_, err = GenerateCustomCSR(privateKey, "lego.acme", nil, false, func(_ *x509.CertificateRequest) error {
+		return errors.New("boom")
+	})
+	require.EqualError(t, err, "customize CSR: boom")
+}
+
+func TestGenerateCSR_ipAddresses(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err, "Error generating private key")
+
+	raw, err := GenerateCSR(privateKey, "", []string{"203.0.113.10", "lego.acme", "2001:db8::1"}, false)
+	require.NoError(t, err)
+
+	csr, err := x509.ParseCertificateRequest(raw)
+	require.NoError(t, err)
+
+	assert.Empty(t, csr.Subject.CommonName)
+	assert.Equal(t, []string{"lego.acme"}, csr.DNSNames)
+	require.Len(t, csr.IPAddresses, 2)
+	assert.Equal(t, "203.0.113.10", csr.IPAddresses[0].String())
+	assert.Equal(t, "2001:db8::1", csr.IPAddresses[1].String())
+
+	assert.Equal(t, []string{"lego.acme", "203.0.113.10", "2001:db8::1"}, ExtractDomainsCSR(csr))
+}
+
+func TestGetKeyType(t *testing.T) {
+	for _, keyType := range []KeyType{EC256, EC384, RSA2048} {
+		t.Run(string(keyType), func(t *testing.T) {
+			t.Parallel()
+
+			privateKey, err := GeneratePrivateKey(keyType)
+			require.NoError(t, err)
+
+			actual, err := GetKeyType(privateKey)
+			require.NoError(t, err)
+
+			assert.Equal(t, keyType, actual)
+		})
+	}
+}
+
+func TestGetKeyType_error(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	_, err = GetKeyType(privateKey)
+	require.EqualError(t, err, "unsupported private key type: ed25519.PrivateKey")
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	_, err = GetKeyType(rsaKey)
+	require.EqualError(t, err, "unsupported RSA key size: 1024")
+}
+
+func TestHasMustStaple(t *testing.T) {
+	privateKey, err := GeneratePrivateKey(RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := GeneratePemCert(privateKey.(*rsa.PrivateKey), "example.com", []pkix.Extension{{Id: tlsFeatureExtensionOID, Value: ocspMustStapleFeature}})
+	require.NoError(t, err)
+
+	cert, err := ParsePEMCertificate(certPEM)
+	require.NoError(t, err)
+
+	assert.True(t, HasMustStaple(cert))
+
+	certPEM, err = GeneratePemCert(privateKey.(*rsa.PrivateKey), "example.com", nil)
+	require.NoError(t, err)
+
+	cert, err = ParsePEMCertificate(certPEM)
+	require.NoError(t, err)
+
+	assert.False(t, HasMustStaple(cert))
+}
+
 func TestPEMEncode(t *testing.T) {
 	buf := bytes.NewBufferString("TestingRSAIsSoMuchFun")
 
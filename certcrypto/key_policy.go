@@ -0,0 +1,70 @@
+package certcrypto
+
+import (
+	"errors"
+	"fmt"
+)
+
+// KeyPolicyMode the mode of a key management policy.
+type KeyPolicyMode string
+
+// Constants for all key management policy modes.
+const (
+	// KeyPolicyAlwaysNew a new private key is generated for each renewal.
+	KeyPolicyAlwaysNew = KeyPolicyMode("always-new")
+	// KeyPolicyReuseExisting the existing private key is reused for all the renewals.
+	KeyPolicyReuseExisting = KeyPolicyMode("reuse-existing")
+	// KeyPolicyRotate the existing private key is reused, and a new private key is generated every N renewals.
+	KeyPolicyRotate = KeyPolicyMode("rotate")
+)
+
+// KeyPolicy a key management policy: defines if the private key of a certificate is reused on renewal.
+type KeyPolicy struct {
+	Mode KeyPolicyMode
+
+	// RotateEvery the number of renewals between two key rotations (KeyPolicyRotate only).
+	// Ex: with 3, the private key is used for the issuance and the 2 next renewals, the 3rd renewal uses a new private key.
+	RotateEvery int
+}
+
+// NewKeyPolicy creates a key management policy.
+// The rotateEvery value is only used, and must be positive, with KeyPolicyRotate.
+func NewKeyPolicy(mode KeyPolicyMode, rotateEvery int) (KeyPolicy, error) {
+	switch mode {
+	case KeyPolicyAlwaysNew, KeyPolicyReuseExisting:
+		return KeyPolicy{Mode: mode}, nil
+
+	case KeyPolicyRotate:
+		if rotateEvery <= 0 {
+			return KeyPolicy{}, errors.New("key policy: the number of renewals between two key rotations must be positive")
+		}
+
+		return KeyPolicy{Mode: mode, RotateEvery: rotateEvery}, nil
+
+	default:
+		return KeyPolicy{}, fmt.Errorf("key policy: unsupported mode: %s", mode)
+	}
+}
+
+// ShouldReuse returns true if the existing private key should be reused for the next renewal.
+// keyRenewals is the number of renewals already done with the existing private key.
+func (p KeyPolicy) ShouldReuse(keyRenewals int) bool {
+	switch p.Mode {
+	case KeyPolicyReuseExisting:
+		return true
+
+	case KeyPolicyRotate:
+		return keyRenewals+1 < p.RotateEvery
+
+	default:
+		return false
+	}
+}
+
+func (p KeyPolicy) String() string {
+	if p.Mode == KeyPolicyRotate {
+		return fmt.Sprintf("%s (every %d renewals)", p.Mode, p.RotateEvery)
+	}
+
+	return string(p.Mode)
+}
@@ -0,0 +1,64 @@
+package certcrypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyPolicy_ShouldReuse(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		mode        KeyPolicyMode
+		rotateEvery int
+		expected    []bool
+	}{
+		{
+			desc:     "always new",
+			mode:     KeyPolicyAlwaysNew,
+			expected: []bool{false, false, false, false},
+		},
+		{
+			desc:     "reuse existing",
+			mode:     KeyPolicyReuseExisting,
+			expected: []bool{true, true, true, true},
+		},
+		{
+			desc:        "rotate every renewal",
+			mode:        KeyPolicyRotate,
+			rotateEvery: 1,
+			expected:    []bool{false, false, false, false},
+		},
+		{
+			desc:        "rotate every 3 renewals",
+			mode:        KeyPolicyRotate,
+			rotateEvery: 3,
+			expected:    []bool{true, true, false, false},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			policy, err := NewKeyPolicy(test.mode, test.rotateEvery)
+			require.NoError(t, err)
+
+			var reuse []bool
+			for keyRenewals := range len(test.expected) {
+				reuse = append(reuse, policy.ShouldReuse(keyRenewals))
+			}
+
+			assert.Equal(t, test.expected, reuse)
+		})
+	}
+}
+
+func TestNewKeyPolicy_invalid(t *testing.T) {
+	_, err := NewKeyPolicy("foo", 0)
+	require.EqualError(t, err, "key policy: unsupported mode: foo")
+
+	_, err = NewKeyPolicy(KeyPolicyRotate, 0)
+	require.EqualError(t, err, "key policy: the number of renewals between two key rotations must be positive")
+}
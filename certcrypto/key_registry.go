@@ -0,0 +1,70 @@
+package certcrypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// KeyGenerator generates a private key.
+// Any crypto.Signer can be used (ex: a key stored in an HSM, a post-quantum or hybrid key).
+type KeyGenerator func() (crypto.Signer, error)
+
+var keyGenerators = struct {
+	sync.RWMutex
+	m map[KeyType]KeyGenerator
+}{
+	m: map[KeyType]KeyGenerator{
+		EC256:   func() (crypto.Signer, error) { return ecdsa.GenerateKey(elliptic.P256(), rand.Reader) },
+		EC384:   func() (crypto.Signer, error) { return ecdsa.GenerateKey(elliptic.P384(), rand.Reader) },
+		RSA2048: rsaKeyGenerator(2048),
+		RSA3072: rsaKeyGenerator(3072),
+		RSA4096: rsaKeyGenerator(4096),
+		RSA8192: rsaKeyGenerator(8192),
+	},
+}
+
+// RegisterKeyType registers the generator of a key type (ex: a new algorithm accepted by a CA),
+// the key type can then be used everywhere a KeyType is expected.
+// The generator of an existing key type is replaced.
+func RegisterKeyType(keyType KeyType, generator KeyGenerator) error {
+	if keyType == "" {
+		return fmt.Errorf("register key type: empty key type")
+	}
+
+	if generator == nil {
+		return fmt.Errorf("register key type %s: nil generator", keyType)
+	}
+
+	keyGenerators.Lock()
+	keyGenerators.m[keyType] = generator
+	keyGenerators.Unlock()
+
+	return nil
+}
+
+// KeyTypes returns the registered key types.
+func KeyTypes() []KeyType {
+	keyGenerators.RLock()
+	defer keyGenerators.RUnlock()
+
+	var keyTypes []KeyType
+	for keyType := range keyGenerators.m {
+		keyTypes = append(keyTypes, keyType)
+	}
+
+	sort.Slice(keyTypes, func(i, j int) bool { return keyTypes[i] < keyTypes[j] })
+
+	return keyTypes
+}
+
+func rsaKeyGenerator(bits int) KeyGenerator {
+	return func() (crypto.Signer, error) {
+		return rsa.GenerateKey(rand.Reader, bits)
+	}
+}
@@ -0,0 +1,304 @@
+package certcrypto
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// KeyStoreFormat the format of a Java keystore.
+type KeyStoreFormat string
+
+// Constants for all keystore formats.
+const (
+	// KeyStoreFormatJKS the legacy Java KeyStore format, supported by all the Java versions.
+	KeyStoreFormatJKS = KeyStoreFormat("JKS")
+	// KeyStoreFormatPKCS12 the PKCS#12 format (AES-256-CBC and PBKDF2 with SHA-256), the default format since Java 9.
+	KeyStoreFormatPKCS12 = KeyStoreFormat("PKCS12")
+)
+
+// https://github.com/openjdk/jdk/blob/master/src/java.base/share/classes/sun/security/provider/JavaKeyStore.java
+const (
+	jksMagic             = 0xfeedfeed
+	jksVersion           = 2
+	jksPrivateKeyTag     = 1
+	jksTrustedCertTag    = 2
+	jksCertificateType   = "X.509"
+	jksIntegrityWhitener = "Mighty Aphrodite"
+)
+
+// https://github.com/openjdk/jdk/blob/master/src/java.base/share/classes/sun/security/provider/KeyProtector.java
+var oidJKSKeyProtector = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 17, 1, 1}
+
+// KeyStoreOptions the options of the keystores and truststores.
+type KeyStoreOptions struct {
+	// Format the format of the store (default: JKS).
+	Format KeyStoreFormat
+
+	// Password the password of the store.
+	Password string
+
+	// KeyPassword the password of the private key (JKS only).
+	// Default: the password of the store.
+	KeyPassword string
+
+	// Alias the alias of the private key entry (JKS only),
+	// or the prefix of the aliases of the trusted certificate entries ("<alias>-1", "<alias>-2", etc.).
+	// The JKS aliases are case-insensitive and stored in lowercase.
+	Alias string
+}
+
+// EncodeKeyStore encodes the private key, the certificate, and the certificate chain (issuers) into a password-protected Java keystore.
+// The inputs are PEM encoded.
+// If the chain is empty, the certificates following the first certificate of certPEM (bundle) are used as chain.
+func EncodeKeyStore(privateKeyPEM, certPEM, chainPEM []byte, opts KeyStoreOptions) ([]byte, error) {
+	if err := opts.validate(); err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+
+	privateKey, err := ParsePEMPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: private key: %w", err)
+	}
+
+	certificates, err := ParsePEMBundle(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: certificate: %w", err)
+	}
+
+	chain := certificates[1:]
+
+	if len(chainPEM) > 0 {
+		chain, err = ParsePEMBundle(chainPEM)
+		if err != nil {
+			return nil, fmt.Errorf("keystore: certificate chain: %w", err)
+		}
+	}
+
+	var raw []byte
+
+	switch opts.Format {
+	case KeyStoreFormatPKCS12:
+		raw, err = pkcs12.Modern2023.Encode(privateKey, certificates[0], chain, opts.Password)
+
+	default:
+		raw, err = encodeJKSKeyStore(privateKey, append(certificates[:1:1], chain...), opts)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+
+	return raw, nil
+}
+
+// EncodeTrustStore encodes the certificates into a password-protected Java truststore (trusted certificate entries).
+func EncodeTrustStore(certificates []*x509.Certificate, opts KeyStoreOptions) ([]byte, error) {
+	if err := opts.validate(); err != nil {
+		return nil, fmt.Errorf("truststore: %w", err)
+	}
+
+	if len(certificates) == 0 {
+		return nil, errors.New("truststore: no certificates")
+	}
+
+	var raw []byte
+	var err error
+
+	switch opts.Format {
+	case KeyStoreFormatPKCS12:
+		var entries []pkcs12.TrustStoreEntry
+		for i, cert := range certificates {
+			entries = append(entries, pkcs12.TrustStoreEntry{Cert: cert, FriendlyName: opts.trustedAlias(i)})
+		}
+
+		raw, err = pkcs12.Modern2023.EncodeTrustStoreEntries(entries, opts.Password)
+
+	default:
+		raw, err = encodeJKSTrustStore(certificates, opts)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("truststore: %w", err)
+	}
+
+	return raw, nil
+}
+
+func (o KeyStoreOptions) validate() error {
+	switch o.Format {
+	case KeyStoreFormatJKS, "":
+	case KeyStoreFormatPKCS12:
+		if o.KeyPassword != "" && o.KeyPassword != o.Password {
+			return errors.New("the PKCS12 format does not support a key password different from the store password")
+		}
+	default:
+		return fmt.Errorf("invalid format: %s", o.Format)
+	}
+
+	if o.Password == "" {
+		return errors.New("empty password")
+	}
+
+	if o.Alias == "" {
+		return errors.New("empty alias")
+	}
+
+	return nil
+}
+
+func (o KeyStoreOptions) trustedAlias(index int) string {
+	return fmt.Sprintf("%s-%d", o.Alias, index+1)
+}
+
+func encodeJKSKeyStore(privateKey crypto.PrivateKey, certificates []*x509.Certificate, opts KeyStoreOptions) ([]byte, error) {
+	keyPassword := opts.KeyPassword
+	if keyPassword == "" {
+		keyPassword = opts.Password
+	}
+
+	protectedKey, err := protectJKSPrivateKey(privateKey, keyPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	w := newJKSWriter(1)
+
+	w.writeUint32(jksPrivateKeyTag)
+	w.writeEntryHeader(opts.Alias)
+	w.writeBytes(protectedKey)
+	w.writeUint32(uint32(len(certificates)))
+
+	for _, cert := range certificates {
+		w.writeCertificate(cert)
+	}
+
+	return w.sum(opts.Password), nil
+}
+
+func encodeJKSTrustStore(certificates []*x509.Certificate, opts KeyStoreOptions) ([]byte, error) {
+	w := newJKSWriter(len(certificates))
+
+	for i, cert := range certificates {
+		w.writeUint32(jksTrustedCertTag)
+		w.writeEntryHeader(opts.trustedAlias(i))
+		w.writeCertificate(cert)
+	}
+
+	return w.sum(opts.Password), nil
+}
+
+// protectJKSPrivateKey encrypts the private key with the proprietary algorithm of the JKS format (KeyProtector):
+// salt || (PKCS#8 private key XOR SHA-1 keystream) || SHA-1(password || PKCS#8 private key).
+func protectJKSPrivateKey(privateKey crypto.PrivateKey, password string) ([]byte, error) {
+	plainKey, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordBytes := jksPassword(password)
+
+	salt := make([]byte, sha1.Size)
+	if _, err = rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	encryptedKey := make([]byte, len(plainKey))
+
+	digest := salt
+	for offset := 0; offset < len(plainKey); offset += sha1.Size {
+		digest = sha1Sum(passwordBytes, digest)
+
+		for i := 0; i < sha1.Size && offset+i < len(plainKey); i++ {
+			encryptedKey[offset+i] = plainKey[offset+i] ^ digest[i]
+		}
+	}
+
+	data := bytes.Join([][]byte{salt, encryptedKey, sha1Sum(passwordBytes, plainKey)}, nil)
+
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		EncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidJKSKeyProtector, Parameters: asn1.NullRawValue},
+		EncryptedData:       data,
+	})
+}
+
+// jksWriter writes the JKS format (big-endian, Java DataOutputStream).
+type jksWriter struct {
+	buf       bytes.Buffer
+	timestamp int64
+}
+
+func newJKSWriter(count int) *jksWriter {
+	w := &jksWriter{timestamp: time.Now().UnixMilli()}
+
+	w.writeUint32(jksMagic)
+	w.writeUint32(jksVersion)
+	w.writeUint32(uint32(count))
+
+	return w
+}
+
+func (w *jksWriter) writeUint32(v uint32) {
+	_ = binary.Write(&w.buf, binary.BigEndian, v)
+}
+
+// writeEntryHeader writes the alias (lowercase, as Java does) and the creation date of an entry.
+func (w *jksWriter) writeEntryHeader(alias string) {
+	w.writeUTF(strings.ToLower(alias))
+	_ = binary.Write(&w.buf, binary.BigEndian, w.timestamp)
+}
+
+// writeUTF writes a string prefixed by its length (uint16).
+// The Java modified UTF-8 only differs from UTF-8 for the NUL character and the supplementary characters.
+func (w *jksWriter) writeUTF(s string) {
+	_ = binary.Write(&w.buf, binary.BigEndian, uint16(len(s)))
+	w.buf.WriteString(s)
+}
+
+func (w *jksWriter) writeBytes(b []byte) {
+	w.writeUint32(uint32(len(b)))
+	w.buf.Write(b)
+}
+
+func (w *jksWriter) writeCertificate(cert *x509.Certificate) {
+	w.writeUTF(jksCertificateType)
+	w.writeBytes(cert.Raw)
+}
+
+// sum appends the integrity check of the store: SHA-1(password || "Mighty Aphrodite" || data).
+func (w *jksWriter) sum(password string) []byte {
+	digest := sha1Sum(jksPassword(password), []byte(jksIntegrityWhitener), w.buf.Bytes())
+
+	return append(w.buf.Bytes(), digest...)
+}
+
+// jksPassword encodes the password as Java does (UTF-16 big-endian, without BOM).
+func jksPassword(password string) []byte {
+	var b []byte
+	for _, c := range utf16.Encode([]rune(password)) {
+		b = append(b, byte(c>>8), byte(c))
+	}
+
+	return b
+}
+
+func sha1Sum(parts ...[]byte) []byte {
+	h := sha1.New()
+	for _, p := range parts {
+		h.Write(p)
+	}
+
+	return h.Sum(nil)
+}
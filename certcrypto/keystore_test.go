@@ -0,0 +1,244 @@
+package certcrypto
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func TestEncodeKeyStore_JKS(t *testing.T) {
+	root, rootKey := createTestCertificate(t, "Root", true, nil, nil)
+	leaf, leafKey := createTestCertificate(t, "example.com", false, root, rootKey)
+
+	opts := KeyStoreOptions{Format: KeyStoreFormatJKS, Password: "changeit", KeyPassword: "secret", Alias: "Example"}
+
+	raw, err := EncodeKeyStore(PEMEncode(leafKey), PEMEncodeCertificates([]*x509.Certificate{leaf}), PEMEncodeCertificates([]*x509.Certificate{root}), opts)
+	require.NoError(t, err)
+
+	entries := readTestJKS(t, raw, "changeit")
+	require.Len(t, entries, 1)
+
+	entry := entries[0]
+	assert.Equal(t, uint32(jksPrivateKeyTag), entry.tag)
+	assert.Equal(t, "example", entry.alias)
+	assert.Equal(t, []*x509.Certificate{leaf, root}, entry.certificates)
+
+	privateKey := unprotectTestJKSPrivateKey(t, entry.protectedKey, "secret")
+	assert.Equal(t, leafKey, privateKey)
+}
+
+func TestEncodeKeyStore_PKCS12(t *testing.T) {
+	root, rootKey := createTestCertificate(t, "Root", true, nil, nil)
+	leaf, leafKey := createTestCertificate(t, "example.com", false, root, rootKey)
+
+	opts := KeyStoreOptions{Format: KeyStoreFormatPKCS12, Password: "changeit", Alias: "example"}
+
+	raw, err := EncodeKeyStore(PEMEncode(leafKey), PEMEncodeCertificates([]*x509.Certificate{leaf, root}), nil, opts)
+	require.NoError(t, err)
+
+	privateKey, cert, caCerts, err := pkcs12.DecodeChain(raw, "changeit")
+	require.NoError(t, err)
+
+	assert.Equal(t, leafKey, privateKey)
+	assert.Equal(t, leaf, cert)
+	assert.Equal(t, []*x509.Certificate{root}, caCerts)
+}
+
+func TestEncodeKeyStore_invalidOptions(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		opts     KeyStoreOptions
+		expected string
+	}{
+		{
+			desc:     "invalid format",
+			opts:     KeyStoreOptions{Format: "foo", Password: "changeit", Alias: "example"},
+			expected: "keystore: invalid format: foo",
+		},
+		{
+			desc:     "empty password",
+			opts:     KeyStoreOptions{Alias: "example"},
+			expected: "keystore: empty password",
+		},
+		{
+			desc:     "empty alias",
+			opts:     KeyStoreOptions{Password: "changeit"},
+			expected: "keystore: empty alias",
+		},
+		{
+			desc:     "PKCS12 key password",
+			opts:     KeyStoreOptions{Format: KeyStoreFormatPKCS12, Password: "changeit", KeyPassword: "secret", Alias: "example"},
+			expected: "keystore: the PKCS12 format does not support a key password different from the store password",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := EncodeKeyStore(nil, nil, nil, test.opts)
+			require.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func TestEncodeTrustStore_JKS(t *testing.T) {
+	root, rootKey := createTestCertificate(t, "Root", true, nil, nil)
+	intermediate, _ := createTestCertificate(t, "Intermediate", true, root, rootKey)
+
+	opts := KeyStoreOptions{Password: "changeit", Alias: "ca"}
+
+	raw, err := EncodeTrustStore([]*x509.Certificate{intermediate, root}, opts)
+	require.NoError(t, err)
+
+	entries := readTestJKS(t, raw, "changeit")
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, uint32(jksTrustedCertTag), entries[0].tag)
+	assert.Equal(t, "ca-1", entries[0].alias)
+	assert.Equal(t, []*x509.Certificate{intermediate}, entries[0].certificates)
+
+	assert.Equal(t, uint32(jksTrustedCertTag), entries[1].tag)
+	assert.Equal(t, "ca-2", entries[1].alias)
+	assert.Equal(t, []*x509.Certificate{root}, entries[1].certificates)
+}
+
+func TestEncodeTrustStore_PKCS12(t *testing.T) {
+	root, rootKey := createTestCertificate(t, "Root", true, nil, nil)
+	intermediate, _ := createTestCertificate(t, "Intermediate", true, root, rootKey)
+
+	opts := KeyStoreOptions{Format: KeyStoreFormatPKCS12, Password: "changeit", Alias: "ca"}
+
+	raw, err := EncodeTrustStore([]*x509.Certificate{intermediate, root}, opts)
+	require.NoError(t, err)
+
+	certs, err := pkcs12.DecodeTrustStore(raw, "changeit")
+	require.NoError(t, err)
+
+	assert.Equal(t, []*x509.Certificate{intermediate, root}, certs)
+}
+
+func TestEncodeTrustStore_empty(t *testing.T) {
+	_, err := EncodeTrustStore(nil, KeyStoreOptions{Password: "changeit", Alias: "ca"})
+	require.EqualError(t, err, "truststore: no certificates")
+}
+
+type testJKSEntry struct {
+	tag          uint32
+	alias        string
+	protectedKey []byte
+	certificates []*x509.Certificate
+}
+
+// readTestJKS a minimal JKS reader, the integrity of the store is checked.
+func readTestJKS(t *testing.T, raw []byte, password string) []testJKSEntry {
+	t.Helper()
+
+	require.Greater(t, len(raw), 20)
+
+	data, digest := raw[:len(raw)-20], raw[len(raw)-20:]
+	require.Equal(t, sha1Sum(jksPassword(password), []byte(jksIntegrityWhitener), data), digest, "invalid integrity check")
+
+	r := bytes.NewReader(data)
+
+	readUint32 := func() uint32 {
+		var v uint32
+		require.NoError(t, binary.Read(r, binary.BigEndian, &v))
+		return v
+	}
+
+	readBytes := func(n int) []byte {
+		b := make([]byte, n)
+		_, err := r.Read(b)
+		require.NoError(t, err)
+		return b
+	}
+
+	readUTF := func() string {
+		var n uint16
+		require.NoError(t, binary.Read(r, binary.BigEndian, &n))
+		return string(readBytes(int(n)))
+	}
+
+	readCertificate := func() *x509.Certificate {
+		require.Equal(t, jksCertificateType, readUTF())
+
+		cert, err := x509.ParseCertificate(readBytes(int(readUint32())))
+		require.NoError(t, err)
+
+		return cert
+	}
+
+	require.Equal(t, uint32(jksMagic), readUint32())
+	require.Equal(t, uint32(jksVersion), readUint32())
+
+	count := readUint32()
+
+	var entries []testJKSEntry
+
+	for range count {
+		entry := testJKSEntry{tag: readUint32(), alias: readUTF()}
+
+		var timestamp int64
+		require.NoError(t, binary.Read(r, binary.BigEndian, &timestamp))
+
+		switch entry.tag {
+		case jksPrivateKeyTag:
+			entry.protectedKey = readBytes(int(readUint32()))
+
+			for range readUint32() {
+				entry.certificates = append(entry.certificates, readCertificate())
+			}
+
+		case jksTrustedCertTag:
+			entry.certificates = append(entry.certificates, readCertificate())
+
+		default:
+			require.FailNow(t, "unknown tag", entry.tag)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	require.Zero(t, r.Len())
+
+	return entries
+}
+
+func unprotectTestJKSPrivateKey(t *testing.T, protectedKey []byte, password string) crypto.PrivateKey {
+	t.Helper()
+
+	var info encryptedPrivateKeyInfo
+	_, err := asn1.Unmarshal(protectedKey, &info)
+	require.NoError(t, err)
+
+	require.Equal(t, oidJKSKeyProtector, info.EncryptionAlgorithm.Algorithm)
+
+	data := info.EncryptedData
+	salt, encryptedKey, check := data[:20], data[20:len(data)-20], data[len(data)-20:]
+
+	plainKey := make([]byte, len(encryptedKey))
+
+	digest := salt
+	for offset := 0; offset < len(encryptedKey); offset += 20 {
+		digest = sha1Sum(jksPassword(password), digest)
+
+		for i := 0; i < 20 && offset+i < len(encryptedKey); i++ {
+			plainKey[offset+i] = encryptedKey[offset+i] ^ digest[i]
+		}
+	}
+
+	require.Equal(t, sha1Sum(jksPassword(password), plainKey), check, "invalid key password")
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(plainKey)
+	require.NoError(t, err)
+
+	return privateKey
+}
@@ -0,0 +1,76 @@
+package certcrypto
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// PFXFormat the encoding format (encryption algorithms) of a PKCS#12 (.pfx) bundle.
+type PFXFormat string
+
+// Constants for all PFX formats.
+const (
+	// PFXFormatRC2 legacy encryption (RC2 and 3DES), supported by most of the systems.
+	PFXFormatRC2 = PFXFormat("RC2")
+	// PFXFormatDES legacy encryption (3DES).
+	PFXFormatDES = PFXFormat("DES")
+	// PFXFormatSHA256 modern encryption (AES-256-CBC and PBKDF2 with SHA-256).
+	PFXFormatSHA256 = PFXFormat("SHA256")
+)
+
+// EncodePFX encodes the private key, the certificate, and the certificate chain (issuers) into a password-protected PKCS#12 bundle.
+// The inputs are PEM encoded.
+// If the chain is empty, the certificates following the first certificate of certPEM (bundle) are used as chain.
+func EncodePFX(privateKeyPEM, certPEM, chainPEM []byte, password string, format PFXFormat) ([]byte, error) {
+	encoder, err := getPFXEncoder(format)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := ParsePEMPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("PFX: private key: %w", err)
+	}
+
+	certificates, err := ParsePEMBundle(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("PFX: certificate: %w", err)
+	}
+
+	chain := certificates[1:]
+
+	if len(chainPEM) > 0 {
+		chain, err = ParsePEMBundle(chainPEM)
+		if err != nil {
+			return nil, fmt.Errorf("PFX: certificate chain: %w", err)
+		}
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("PFX: unsupported private key")
+	}
+
+	pfx, err := encoder.Encode(signer, certificates[0], chain, password)
+	if err != nil {
+		return nil, fmt.Errorf("PFX: %w", err)
+	}
+
+	return pfx, nil
+}
+
+func getPFXEncoder(format PFXFormat) (*pkcs12.Encoder, error) {
+	switch format {
+	case PFXFormatSHA256:
+		return pkcs12.Modern2023, nil
+	case PFXFormatDES:
+		return pkcs12.LegacyDES, nil
+	case PFXFormatRC2, "":
+		return pkcs12.LegacyRC2, nil
+	default:
+		return nil, fmt.Errorf("invalid PFX format: %s", format)
+	}
+}
@@ -0,0 +1,53 @@
+package certcrypto
+
+import (
+	"crypto/rsa"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func TestEncodePFX(t *testing.T) {
+	privateKey, err := GeneratePrivateKey(RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := GeneratePemCert(privateKey.(*rsa.PrivateKey), "example.com", nil)
+	require.NoError(t, err)
+
+	issuerKey, err := GeneratePrivateKey(RSA2048)
+	require.NoError(t, err)
+
+	issuerPEM, err := GeneratePemCert(issuerKey.(*rsa.PrivateKey), "issuer.example.com", nil)
+	require.NoError(t, err)
+
+	bundle := append(append([]byte{}, certPEM...), issuerPEM...)
+
+	for _, format := range []PFXFormat{PFXFormatRC2, PFXFormatDES, PFXFormatSHA256} {
+		t.Run(string(format), func(t *testing.T) {
+			pfx, err := EncodePFX(PEMEncode(privateKey), bundle, nil, "secret", format)
+			require.NoError(t, err)
+
+			key, cert, chain, err := pkcs12.DecodeChain(pfx, "secret")
+			require.NoError(t, err)
+
+			assert.Equal(t, privateKey, key)
+			assert.Equal(t, []string{"example.com"}, cert.DNSNames)
+
+			require.Len(t, chain, 1)
+			assert.Equal(t, []string{"issuer.example.com"}, chain[0].DNSNames)
+		})
+	}
+}
+
+func TestEncodePFX_invalidFormat(t *testing.T) {
+	privateKey, err := GeneratePrivateKey(RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := GeneratePemCert(privateKey.(*rsa.PrivateKey), "example.com", nil)
+	require.NoError(t, err)
+
+	_, err = EncodePFX(PEMEncode(privateKey), certPEM, nil, "secret", "foo")
+	require.EqualError(t, err, "invalid PFX format: foo")
+}
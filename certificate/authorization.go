@@ -1,6 +1,10 @@
 package certificate
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/go-acme/lego/v4/acme"
@@ -39,7 +43,7 @@ func (c *Certifier) getAuthorizations(order acme.ExtendedOrder) ([]acme.Authoriz
 	}
 
 	for i, auth := range order.Authorizations {
-		log.Infof("[%s] AuthURL: %s", order.Identifiers[i].Value, auth)
+		log.WithDomain(order.Identifiers[i].Value).Infof("AuthURL: %s", auth)
 	}
 
 	close(resc)
@@ -48,6 +52,93 @@ func (c *Certifier) getAuthorizations(order acme.ExtendedOrder) ([]acme.Authoriz
 	return responses, failures.Join()
 }
 
+// PreAuthorize creates an authorization for the identifier (domain or IP address) and solves its challenge,
+// ahead of the orders (ex: during a maintenance window).
+// https://www.rfc-editor.org/rfc/rfc8555.html#section-7.4.1
+//
+// The valid authorization is reused by the server for the subsequent orders of the account containing the identifier,
+// until the authorization expires: the challenges of these orders are not solved again.
+// Wildcard domains cannot be pre-authorized.
+func (c *Certifier) PreAuthorize(identifier string) (acme.ExtendedAuthorization, error) {
+	if strings.HasPrefix(identifier, "*.") {
+		return acme.ExtendedAuthorization{}, fmt.Errorf("[%s] acme: wildcard domains cannot be pre-authorized", identifier)
+	}
+
+	domains := sanitizeDomain([]string{identifier})
+	if len(domains) == 0 {
+		return acme.ExtendedAuthorization{}, fmt.Errorf("[%s] acme: invalid identifier", identifier)
+	}
+
+	ident := acme.Identifier{Type: "dns", Value: domains[0]}
+	if net.ParseIP(domains[0]) != nil {
+		ident.Type = "ip"
+	}
+
+	log.WithDomain(ident.Value).Infof("acme: Pre-authorizing")
+
+	authz, err := c.core.Authorizations.New(ident)
+	if err != nil {
+		return acme.ExtendedAuthorization{}, err
+	}
+
+	if authz.Status == acme.StatusValid {
+		log.WithDomain(ident.Value).Infof("acme: Authorization already valid")
+		return authz, nil
+	}
+
+	err = c.resolver.Solve([]acme.Authorization{authz.Authorization})
+	if err != nil {
+		return acme.ExtendedAuthorization{}, err
+	}
+
+	authz.Authorization, err = c.core.Authorizations.Get(authz.Location)
+	if err != nil {
+		return acme.ExtendedAuthorization{}, err
+	}
+
+	return authz, nil
+}
+
+// DeactivateAuthorization relinquishes an authorization,
+// so a pending authorization (ex: from an abandoned order) does not count against the limits of the CA.
+// https://www.rfc-editor.org/rfc/rfc8555.html#section-7.5.2
+func (c *Certifier) DeactivateAuthorization(authzURL string) error {
+	return c.core.Authorizations.Deactivate(authzURL)
+}
+
+// DeactivateOrderAuthorizations relinquishes the pending authorizations of an order.
+// There is no order cancellation in RFC 8555:
+// the order becomes invalid when one of its authorizations is deactivated.
+func (c *Certifier) DeactivateOrderAuthorizations(orderURL string) error {
+	order, err := c.core.Orders.Get(orderURL)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, authzURL := range order.Authorizations {
+		auth, err := c.core.Authorizations.Get(authzURL)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if auth.Status != acme.StatusPending {
+			continue
+		}
+
+		log.Infof("Deactivating auth: %s", authzURL)
+
+		err = c.DeactivateAuthorization(authzURL)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 func (c *Certifier) deactivateAuthorizations(order acme.ExtendedOrder, force bool) {
 	for _, authzURL := range order.Authorizations {
 		auth, err := c.core.Authorizations.Get(authzURL)
@@ -56,13 +147,20 @@ func (c *Certifier) deactivateAuthorizations(order acme.ExtendedOrder, force boo
 			continue
 		}
 
-		if auth.Status == acme.StatusValid && !force {
-			log.Infof("Skipping deactivating of valid auth: %s", authzURL)
+		switch auth.Status {
+		case acme.StatusPending:
+		case acme.StatusValid:
+			if !force {
+				log.Infof("Skipping deactivating of valid auth: %s", authzURL)
+				continue
+			}
+		default:
+			// invalid, deactivated, expired or revoked: the authorization cannot be deactivated.
 			continue
 		}
 
 		log.Infof("Deactivating auth: %s", authzURL)
-		if c.core.Authorizations.Deactivate(authzURL) != nil {
+		if c.DeactivateAuthorization(authzURL) != nil {
 			log.Infof("Unable to deactivate the authorization: %s", authzURL)
 		}
 	}
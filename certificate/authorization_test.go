@@ -0,0 +1,176 @@
+package certificate
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/acme/api"
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertifier_DeactivateOrderAuthorizations(t *testing.T) {
+	mux, apiURL := tester.SetupFakeAPI(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var deactivated []string
+
+	statuses := map[string]string{
+		"/authz/1": acme.StatusPending,
+		"/authz/2": acme.StatusValid,
+		"/authz/3": acme.StatusInvalid,
+	}
+
+	mux.HandleFunc("/order/1", func(w http.ResponseWriter, _ *http.Request) {
+		err := tester.WriteJSONResponse(w, acme.Order{
+			Status:         acme.StatusPending,
+			Authorizations: []string{apiURL + "/authz/1", apiURL + "/authz/2", apiURL + "/authz/3"},
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	mux.HandleFunc("/authz/", func(w http.ResponseWriter, r *http.Request) {
+		payload, err := readJWSPayload(key, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		status := statuses[r.URL.Path]
+
+		if len(payload) > 0 {
+			var authz acme.Authorization
+			if err = json.Unmarshal(payload, &authz); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			mu.Lock()
+			deactivated = append(deactivated, r.URL.Path)
+			mu.Unlock()
+
+			status = authz.Status
+		}
+
+		err = tester.WriteJSONResponse(w, acme.Authorization{Status: status})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", "", key)
+	require.NoError(t, err)
+
+	certifier := NewCertifier(core, &resolverMock{}, CertifierOptions{KeyType: certcrypto.RSA2048})
+
+	err = certifier.DeactivateOrderAuthorizations(apiURL + "/order/1")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"/authz/1"}, deactivated)
+}
+
+// readJWSPayload reads the payload of the JWS of the request (empty for POST-as-GET).
+func readJWSPayload(privateKey *rsa.PrivateKey, r *http.Request) ([]byte, error) {
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	jws, err := jose.ParseSigned(string(reqBody), []jose.SignatureAlgorithm{jose.RS256})
+	if err != nil {
+		return nil, err
+	}
+
+	return jws.Verify(&jose.JSONWebKey{Key: privateKey.Public(), Algorithm: "RSA"})
+}
+
+type recordingResolver struct {
+	authorizations []acme.Authorization
+}
+
+func (r *recordingResolver) Solve(authorizations []acme.Authorization) error {
+	r.authorizations = append(r.authorizations, authorizations...)
+	return nil
+}
+
+func TestCertifier_PreAuthorize(t *testing.T) {
+	mux, apiURL := tester.SetupFakeAPI(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var identifier acme.Identifier
+
+	mux.HandleFunc("/newAuthz", func(w http.ResponseWriter, r *http.Request) {
+		payload, err := readJWSPayload(key, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var authzReq struct {
+			Identifier acme.Identifier `json:"identifier"`
+		}
+
+		if err = json.Unmarshal(payload, &authzReq); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		identifier = authzReq.Identifier
+
+		w.Header().Set("Location", apiURL+"/authz/1")
+		w.WriteHeader(http.StatusCreated)
+
+		err = tester.WriteJSONResponse(w, acme.Authorization{Status: acme.StatusPending, Identifier: authzReq.Identifier})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	mux.HandleFunc("/authz/1", func(w http.ResponseWriter, _ *http.Request) {
+		err := tester.WriteJSONResponse(w, acme.Authorization{Status: acme.StatusValid, Identifier: identifier})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", "", key)
+	require.NoError(t, err)
+
+	resolver := &recordingResolver{}
+
+	certifier := NewCertifier(core, resolver, CertifierOptions{KeyType: certcrypto.RSA2048})
+
+	authz, err := certifier.PreAuthorize("example.com")
+	require.NoError(t, err)
+
+	assert.Equal(t, acme.Identifier{Type: "dns", Value: "example.com"}, identifier)
+
+	assert.Equal(t, apiURL+"/authz/1", authz.Location)
+	assert.Equal(t, acme.StatusValid, authz.Status)
+
+	require.Len(t, resolver.authorizations, 1)
+	assert.Equal(t, acme.StatusPending, resolver.authorizations[0].Status)
+}
+
+func TestCertifier_PreAuthorize_wildcard(t *testing.T) {
+	certifier := NewCertifier(nil, &resolverMock{}, CertifierOptions{KeyType: certcrypto.RSA2048})
+
+	_, err := certifier.PreAuthorize("*.example.com")
+	require.EqualError(t, err, "[*.example.com] acme: wildcard domains cannot be pre-authorized")
+}
@@ -0,0 +1,351 @@
+package certificate
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/log"
+)
+
+// shortLivedLifetime certificates with a shorter lifetime are renewed at the half of their lifetime
+// (instead of the last third).
+const shortLivedLifetime = 10 * 24 * time.Hour
+
+// ResourceStorage saves the certificate resources renewed by an AutoRenewer.
+type ResourceStorage interface {
+	Save(res *Resource) error
+}
+
+// AutoRenewEvent is emitted by an AutoRenewer after each renewal attempt.
+type AutoRenewEvent struct {
+	Domain string
+	// Resource is the renewed certificate, nil if the renewal failed.
+	Resource *Resource
+	// Err is the error of the renewal, nil if the renewal succeeded.
+	Err error
+}
+
+// AutoRenewerOptions the options of an AutoRenewer.
+type AutoRenewerOptions struct {
+	// RenewOptions the options used to renew the certificates.
+	RenewOptions RenewOptions
+	// RenewBefore renews the certificates when their remaining lifetime is below this duration,
+	// when the CA does not provide renewal information (ARI).
+	// By default, the certificates are renewed after 2/3 of their lifetime (1/2 for short-lived certificates).
+	RenewBefore time.Duration
+	// CheckInterval the maximum interval between two checks of a certificate (default: 6 hours).
+	CheckInterval time.Duration
+	// RetryDelay the delay before retrying a failed renewal (default: 10 minutes).
+	RetryDelay time.Duration
+	// Jitter the maximum random delay subtracted from the renewal time based on the lifetime of a certificate,
+	// to spread the renewals of the certificates issued at the same time (the renewals are never delayed).
+	// The renewal information (ARI) already contains a random renewal time.
+	Jitter time.Duration
+	// Concurrency the maximum number of certificates renewed concurrently (default: 1).
+	// The challenge providers must support concurrent use for different domains.
+	Concurrency int
+	// OnEvent is called after each renewal attempt (concurrently if Concurrency is greater than 1).
+	OnEvent func(event AutoRenewEvent)
+}
+
+// AutoRenewStatus the status of a certificate managed by an AutoRenewer.
+type AutoRenewStatus struct {
+	Domain   string
+	NotAfter time.Time
+	// NextCheck the time of the next check of the certificate (zero before the first check).
+	NextCheck time.Time
+	// LastRenewal the time of the last successful renewal (zero if the certificate has not been renewed).
+	LastRenewal time.Time
+	// LastError the error of the last renewal attempt, nil if it succeeded.
+	LastError error
+}
+
+type managedResource struct {
+	res    *Resource
+	next   time.Time
+	jitter time.Duration
+
+	lastRenewal time.Time
+	lastError   error
+}
+
+// AutoRenewer watches the renewal windows (ARI or expiration) of certificates and renews them in-process.
+type AutoRenewer struct {
+	storage ResourceStorage
+	options AutoRenewerOptions
+
+	renew       func(res Resource, options *RenewOptions) (*Resource, error)
+	renewalInfo func(req RenewalInfoRequest) (*RenewalInfoResponse, error)
+	now         func() time.Time
+
+	mu        sync.Mutex
+	resources []*managedResource
+	wakeup    chan struct{}
+}
+
+// NewAutoRenewer creates a new AutoRenewer for the certificate resources.
+func NewAutoRenewer(certifier *Certifier, storage ResourceStorage, resources []*Resource, options AutoRenewerOptions) *AutoRenewer {
+	if options.CheckInterval <= 0 {
+		options.CheckInterval = 6 * time.Hour
+	}
+
+	if options.RetryDelay <= 0 {
+		options.RetryDelay = 10 * time.Minute
+	}
+
+	a := &AutoRenewer{
+		storage:     storage,
+		options:     options,
+		renew:       certifier.RenewWithOptions,
+		renewalInfo: certifier.GetRenewalInfo,
+		now:         time.Now,
+		wakeup:      make(chan struct{}, 1),
+	}
+
+	for _, res := range resources {
+		a.resources = append(a.resources, a.newManagedResource(res))
+	}
+
+	return a
+}
+
+func (a *AutoRenewer) newManagedResource(res *Resource) *managedResource {
+	m := &managedResource{res: res}
+
+	if a.options.Jitter > 0 {
+		m.jitter = time.Duration(rand.Int63n(int64(a.options.Jitter)))
+	}
+
+	return m
+}
+
+// Add adds a certificate resource to the AutoRenewer.
+func (a *AutoRenewer) Add(res *Resource) {
+	a.mu.Lock()
+	a.resources = append(a.resources, a.newManagedResource(res))
+	a.mu.Unlock()
+
+	select {
+	case a.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Status returns the status of the managed certificates.
+func (a *AutoRenewer) Status() []AutoRenewStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var statuses []AutoRenewStatus
+
+	for _, m := range a.resources {
+		status := AutoRenewStatus{
+			Domain:      m.res.Domain,
+			NextCheck:   m.next,
+			LastRenewal: m.lastRenewal,
+			LastError:   m.lastError,
+		}
+
+		certificates, err := certcrypto.ParsePEMBundle(m.res.Certificate)
+		if err == nil {
+			status.NotAfter = certificates[0].NotAfter
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// Run checks and renews the certificates until the context is canceled.
+func (a *AutoRenewer) Run(ctx context.Context) error {
+	for {
+		next := a.process(ctx)
+
+		timer := time.NewTimer(next.Sub(a.now()))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-a.wakeup:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// process checks the due certificates and returns the time of the next check.
+func (a *AutoRenewer) process(ctx context.Context) time.Time {
+	a.mu.Lock()
+	resources := append([]*managedResource(nil), a.resources...)
+	a.mu.Unlock()
+
+	next := a.now().Add(a.options.CheckInterval)
+
+	sem := make(chan struct{}, max(a.options.Concurrency, 1))
+
+	var wg sync.WaitGroup
+
+	for _, m := range resources {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if m.next.After(a.now()) {
+			continue
+		}
+
+		sem <- struct{}{}
+
+		wg.Add(1)
+
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+
+			checkAt := a.check(m)
+
+			a.mu.Lock()
+			m.next = checkAt
+			a.mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	for _, m := range resources {
+		if m.next.Before(next) {
+			next = m.next
+		}
+	}
+
+	return next
+}
+
+// check renews the certificate if needed, and returns the time of the next check.
+func (a *AutoRenewer) check(m *managedResource) time.Time {
+	now := a.now()
+
+	certificates, err := certcrypto.ParsePEMBundle(m.res.Certificate)
+	if err != nil {
+		a.emit(AutoRenewEvent{Domain: m.res.Domain, Err: fmt.Errorf("parse certificate: %w", err)})
+		return now.Add(a.options.CheckInterval)
+	}
+
+	cert := certificates[0]
+
+	renewAt, nextCheck := a.renewalTime(cert, now, m.jitter)
+	if renewAt.After(now) {
+		return nextCheck
+	}
+
+	options := a.options.RenewOptions
+
+	options.ReplacesCertID, err = MakeARICertID(cert)
+	if err != nil {
+		options.ReplacesCertID = ""
+	}
+
+	log.WithDomain(m.res.Domain).Infof("acme: Automatic renewal")
+
+	renewed, err := a.renew(*m.res, &options)
+	if err == nil && renewed == nil {
+		err = errors.New("no certificate returned")
+	}
+
+	if err == nil {
+		err = a.storage.Save(renewed)
+	}
+
+	if err != nil {
+		a.mu.Lock()
+		m.lastError = err
+		a.mu.Unlock()
+
+		a.emit(AutoRenewEvent{Domain: m.res.Domain, Err: err})
+		return now.Add(a.options.RetryDelay)
+	}
+
+	a.mu.Lock()
+	m.res = renewed
+	m.lastRenewal = now
+	m.lastError = nil
+	a.mu.Unlock()
+
+	a.emit(AutoRenewEvent{Domain: renewed.Domain, Resource: renewed})
+
+	return a.nextCheckAfterRenewal(m, now)
+}
+
+// nextCheckAfterRenewal returns the time of the next check, computed from the renewed certificate.
+func (a *AutoRenewer) nextCheckAfterRenewal(m *managedResource, now time.Time) time.Time {
+	certificates, err := certcrypto.ParsePEMBundle(m.res.Certificate)
+	if err != nil {
+		return now.Add(a.options.CheckInterval)
+	}
+
+	renewAt, nextCheck := a.renewalTime(certificates[0], now, m.jitter)
+
+	// The renewed certificate is not renewed again immediately (ex: the renewal information of the CA is not updated yet).
+	if !renewAt.After(now) {
+		return now.Add(a.options.RetryDelay)
+	}
+
+	return nextCheck
+}
+
+// renewalTime returns the renewal time of the certificate and the time of the next check.
+// The renewal information (ARI) of the CA is used if available, otherwise the renewal time is based on the lifetime (minus the jitter).
+func (a *AutoRenewer) renewalTime(cert *x509.Certificate, now time.Time, jitter time.Duration) (time.Time, time.Time) {
+	nextCheck := now.Add(a.options.CheckInterval)
+
+	info, err := a.renewalInfo(RenewalInfoRequest{Cert: cert})
+	if err == nil {
+		if info.RetryAfter > 0 && info.RetryAfter < a.options.CheckInterval {
+			nextCheck = now.Add(info.RetryAfter)
+		}
+
+		renewAt := info.ShouldRenewAt(now, nextCheck.Sub(now))
+		if renewAt == nil {
+			return nextCheck, nextCheck
+		}
+
+		return *renewAt, *renewAt
+	}
+
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+
+	renewAt := cert.NotAfter.Add(-lifetime / 3)
+
+	switch {
+	case a.options.RenewBefore > 0:
+		renewAt = cert.NotAfter.Add(-a.options.RenewBefore)
+	case lifetime < shortLivedLifetime:
+		renewAt = cert.NotAfter.Add(-lifetime / 2)
+	}
+
+	// The jitter is subtracted: a certificate is never renewed later than its renewal time.
+	renewAt = renewAt.Add(-jitter)
+
+	if renewAt.Before(nextCheck) {
+		return renewAt, renewAt
+	}
+
+	return renewAt, nextCheck
+}
+
+func (a *AutoRenewer) emit(event AutoRenewEvent) {
+	if event.Err != nil {
+		log.WithDomain(event.Domain).Warnf("acme: Automatic renewal failed: %v", event.Err)
+	}
+
+	if a.options.OnEvent != nil {
+		a.options.OnEvent(event)
+	}
+}
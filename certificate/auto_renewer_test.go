@@ -0,0 +1,271 @@
+package certificate
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryResourceStorage struct {
+	mu        sync.Mutex
+	resources []*Resource
+}
+
+func (m *memoryResourceStorage) Save(res *Resource) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.resources = append(m.resources, res)
+
+	return nil
+}
+
+func TestAutoRenewer_Run(t *testing.T) {
+	now := time.Now()
+
+	// short-lived certificate: 6 days, renewed after 3 days.
+	expiring := &Resource{Domain: "example.com", Certificate: createTestCertificate(t, now.Add(-4*24*time.Hour), now.Add(2*24*time.Hour))}
+	renewed := &Resource{Domain: "example.com", Certificate: createTestCertificate(t, now, now.Add(6*24*time.Hour))}
+
+	storage := &memoryResourceStorage{}
+	events := make(chan AutoRenewEvent, 10)
+
+	renewer := NewAutoRenewer(nil, storage, []*Resource{expiring}, AutoRenewerOptions{
+		OnEvent: func(event AutoRenewEvent) { events <- event },
+	})
+
+	renewer.renewalInfo = func(_ RenewalInfoRequest) (*RenewalInfoResponse, error) {
+		return nil, errors.New("no ARI")
+	}
+
+	var calls int
+
+	renewer.renew = func(res Resource, options *RenewOptions) (*Resource, error) {
+		calls++
+
+		assert.Equal(t, expiring.Certificate, res.Certificate)
+		assert.NotEmpty(t, options.ReplacesCertID)
+
+		return renewed, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error)
+
+	go func() { done <- renewer.Run(ctx) }()
+
+	select {
+	case event := <-events:
+		require.NoError(t, event.Err)
+		assert.Same(t, renewed, event.Resource)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no renewal")
+	}
+
+	statuses := renewer.Status()
+	require.Len(t, statuses, 1)
+
+	assert.Equal(t, "example.com", statuses[0].Domain)
+	assert.False(t, statuses[0].LastRenewal.IsZero())
+	assert.NoError(t, statuses[0].LastError)
+
+	cancel()
+
+	require.ErrorIs(t, <-done, context.Canceled)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, []*Resource{renewed}, storage.resources)
+}
+
+func TestAutoRenewer_process_concurrency(t *testing.T) {
+	now := time.Now()
+
+	var resources []*Resource
+	for _, domain := range []string{"a.example.com", "b.example.com", "c.example.com", "d.example.com"} {
+		resources = append(resources, &Resource{Domain: domain, Certificate: createTestCertificate(t, now.Add(-4*24*time.Hour), now.Add(2*24*time.Hour))})
+	}
+
+	renewer := NewAutoRenewer(nil, &memoryResourceStorage{}, resources, AutoRenewerOptions{Concurrency: 2})
+
+	renewer.renewalInfo = func(_ RenewalInfoRequest) (*RenewalInfoResponse, error) {
+		return nil, errors.New("no ARI")
+	}
+
+	var (
+		mu        sync.Mutex
+		inFlight  int
+		maxFlight int
+		released  bool
+	)
+
+	// The renewals wait until 2 renewals are in flight: the test would block if the renewals were sequential.
+	full := make(chan struct{})
+
+	renewer.renew = func(res Resource, _ *RenewOptions) (*Resource, error) {
+		mu.Lock()
+		inFlight++
+		maxFlight = max(maxFlight, inFlight)
+
+		if inFlight == 2 && !released {
+			released = true
+			close(full)
+		}
+		mu.Unlock()
+
+		<-full
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		return &Resource{Domain: res.Domain, Certificate: createTestCertificate(t, now, now.Add(6*24*time.Hour))}, nil
+	}
+
+	renewer.process(context.Background())
+
+	assert.Equal(t, 2, maxFlight)
+
+	for _, status := range renewer.Status() {
+		assert.False(t, status.LastRenewal.IsZero(), status.Domain)
+	}
+}
+
+func TestAutoRenewer_check_nextCheck(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	testCases := []struct {
+		desc     string
+		renewed  *Resource
+		expected time.Time
+	}{
+		{
+			desc:     "renewed certificate",
+			renewed:  &Resource{Domain: "example.com", Certificate: createTestCertificate(t, now, now.Add(6*24*time.Hour))},
+			expected: now.Add(6 * time.Hour),
+		},
+		{
+			desc:     "renewed certificate already due",
+			renewed:  &Resource{Domain: "example.com", Certificate: createTestCertificate(t, now.Add(-4*24*time.Hour), now.Add(2*24*time.Hour))},
+			expected: now.Add(10 * time.Minute),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			expiring := &Resource{Domain: "example.com", Certificate: createTestCertificate(t, now.Add(-4*24*time.Hour), now.Add(2*24*time.Hour))}
+
+			renewer := NewAutoRenewer(nil, &memoryResourceStorage{}, []*Resource{expiring}, AutoRenewerOptions{})
+
+			renewer.now = func() time.Time { return now }
+			renewer.renewalInfo = func(_ RenewalInfoRequest) (*RenewalInfoResponse, error) {
+				return nil, errors.New("no ARI")
+			}
+			renewer.renew = func(_ Resource, _ *RenewOptions) (*Resource, error) {
+				return test.renewed, nil
+			}
+
+			assert.Equal(t, test.expected, renewer.check(renewer.resources[0]))
+		})
+	}
+}
+
+func TestAutoRenewer_renewalTime(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		desc      string
+		notBefore time.Time
+		notAfter  time.Time
+		options   AutoRenewerOptions
+		expected  time.Time
+	}{
+		{
+			desc:      "90 days",
+			notBefore: now.Add(-30 * 24 * time.Hour),
+			notAfter:  now.Add(60 * 24 * time.Hour),
+			expected:  now.Add(30 * 24 * time.Hour),
+		},
+		{
+			desc:      "short-lived",
+			notBefore: now.Add(-24 * time.Hour),
+			notAfter:  now.Add(5 * 24 * time.Hour),
+			expected:  now.Add(2 * 24 * time.Hour),
+		},
+		{
+			desc:      "renew before",
+			notBefore: now.Add(-30 * 24 * time.Hour),
+			notAfter:  now.Add(60 * 24 * time.Hour),
+			options:   AutoRenewerOptions{RenewBefore: 10 * 24 * time.Hour},
+			expected:  now.Add(50 * 24 * time.Hour),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			renewer := NewAutoRenewer(nil, nil, nil, test.options)
+			renewer.renewalInfo = func(_ RenewalInfoRequest) (*RenewalInfoResponse, error) {
+				return nil, errors.New("no ARI")
+			}
+
+			renewAt, nextCheck := renewer.renewalTime(&x509.Certificate{NotBefore: test.notBefore, NotAfter: test.notAfter}, now, 0)
+
+			assert.Equal(t, test.expected, renewAt)
+			assert.Equal(t, now.Add(6*time.Hour), nextCheck)
+		})
+	}
+}
+
+func TestAutoRenewer_renewalTime_jitter(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	renewer := NewAutoRenewer(nil, &memoryResourceStorage{}, nil, AutoRenewerOptions{})
+
+	renewer.renewalInfo = func(_ RenewalInfoRequest) (*RenewalInfoResponse, error) {
+		return nil, errors.New("no ARI")
+	}
+
+	cert := &x509.Certificate{NotBefore: now, NotAfter: now.Add(90 * 24 * time.Hour)}
+
+	renewAt, _ := renewer.renewalTime(cert, now, 2*time.Hour)
+
+	assert.Equal(t, now.Add(60*24*time.Hour-2*time.Hour), renewAt)
+}
+
+func createTestCertificate(t *testing.T, notBefore, notAfter time.Time) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:   big.NewInt(42),
+		Subject:        pkix.Name{CommonName: "example.com"},
+		DNSNames:       []string{"example.com"},
+		NotBefore:      notBefore,
+		NotAfter:       notAfter,
+		AuthorityKeyId: []byte{1, 2, 3, 4},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
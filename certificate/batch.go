@@ -0,0 +1,121 @@
+package certificate
+
+import (
+	"sync"
+
+	"github.com/go-acme/lego/v4/log"
+)
+
+// BatchResult the result of an ObtainRequest of a batch.
+type BatchResult struct {
+	Request  ObtainRequest
+	Resource *Resource
+	Err      error
+}
+
+// ObtainBatch obtains the certificates of several requests, with at most `concurrency` orders in flight.
+//
+// The requests sharing domains are processed sequentially, in order:
+// the authorizations validated for a request are reused by the next requests (if the CA allows it),
+// and the same challenge is never solved concurrently.
+// The challenge providers must support concurrent use for different domains.
+//
+// The results are in the same order as the requests.
+func (c *Certifier) ObtainBatch(requests []ObtainRequest, concurrency int) []BatchResult {
+	return obtainBatch(requests, concurrency, c.Obtain)
+}
+
+// ObtainBatch obtains the certificates of several requests (see Certifier.ObtainBatch) if the rate limits allow it.
+func (s *RenewalScheduler) ObtainBatch(certifier *Certifier, requests []ObtainRequest, concurrency int) []BatchResult {
+	return obtainBatch(requests, concurrency, func(request ObtainRequest) (*Resource, error) {
+		return s.Obtain(certifier, request)
+	})
+}
+
+func obtainBatch(requests []ObtainRequest, concurrency int, obtain func(request ObtainRequest) (*Resource, error)) []BatchResult {
+	results := make([]BatchResult, len(requests))
+
+	groups := groupByDomains(requests)
+
+	log.Infof("acme: Obtaining %d certificates (%d groups, concurrency %d)", len(requests), len(groups), concurrency)
+
+	sem := make(chan struct{}, max(concurrency, 1))
+
+	var wg sync.WaitGroup
+
+	for _, group := range groups {
+		wg.Add(1)
+
+		go func(group []int) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			for _, i := range group {
+				cert, err := obtain(requests[i])
+
+				results[i] = BatchResult{Request: requests[i], Resource: cert, Err: err}
+			}
+		}(group)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// groupByDomains groups the indexes of the requests sharing domains (transitively).
+// The groups and their indexes are sorted by index.
+func groupByDomains(requests []ObtainRequest) [][]int {
+	parents := make([]int, len(requests))
+	for i := range parents {
+		parents[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+
+		return parents[i]
+	}
+
+	owners := make(map[string]int)
+
+	for i, request := range requests {
+		for _, domain := range normalizeDomains(sanitizeDomain(request.Domains)) {
+			owner, ok := owners[domain]
+			if !ok {
+				owners[domain] = i
+				continue
+			}
+
+			a, b := find(owner), find(i)
+			if a != b {
+				parents[max(a, b)] = min(a, b)
+			}
+		}
+	}
+
+	var groups [][]int
+
+	roots := make(map[int]int)
+
+	for i := range requests {
+		root := find(i)
+
+		index, ok := roots[root]
+		if !ok {
+			index = len(groups)
+			roots[root] = index
+
+			groups = append(groups, nil)
+		}
+
+		groups[index] = append(groups[index], i)
+	}
+
+	return groups
+}
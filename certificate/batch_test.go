@@ -0,0 +1,97 @@
+package certificate
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_groupByDomains(t *testing.T) {
+	requests := []ObtainRequest{
+		{Domains: []string{"a.example.com"}},
+		{Domains: []string{"b.example.com"}},
+		{Domains: []string{"c.example.com", "A.example.com"}},
+		{Domains: []string{"d.example.com", "b.example.com"}},
+		{Domains: []string{"d.example.com", "e.example.com"}},
+		{Domains: []string{"f.example.com"}},
+	}
+
+	groups := groupByDomains(requests)
+
+	assert.Equal(t, [][]int{{0, 2}, {1, 3, 4}, {5}}, groups)
+}
+
+func Test_obtainBatch(t *testing.T) {
+	requests := []ObtainRequest{
+		{Domains: []string{"a.example.com"}},
+		{Domains: []string{"b.example.com"}},
+		{Domains: []string{"c.example.com"}},
+		{Domains: []string{"a.example.com", "d.example.com"}},
+		{Domains: []string{"e.example.com"}},
+	}
+
+	var inFlight, maxInFlight atomic.Int32
+
+	var mu sync.Mutex
+	var order []string
+
+	results := obtainBatch(requests, 2, func(request ObtainRequest) (*Resource, error) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			previous := maxInFlight.Load()
+			if current <= previous || maxInFlight.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+
+		mu.Lock()
+		order = append(order, request.Domains[len(request.Domains)-1])
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		if request.Domains[0] == "c.example.com" {
+			return nil, errors.New("boom")
+		}
+
+		return &Resource{Domain: request.Domains[0]}, nil
+	})
+
+	require.Len(t, results, len(requests))
+
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+
+	for i, result := range results {
+		assert.Equal(t, requests[i].Domains, result.Request.Domains)
+
+		if i == 2 {
+			require.EqualError(t, result.Err, "boom")
+			assert.Nil(t, result.Resource)
+
+			continue
+		}
+
+		require.NoError(t, result.Err)
+		assert.Equal(t, requests[i].Domains[0], result.Resource.Domain)
+	}
+
+	// the requests sharing domains are processed in order.
+	assert.Less(t, indexOf(order, "a.example.com"), indexOf(order, "d.example.com"))
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+
+	return -1
+}
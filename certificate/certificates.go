@@ -2,13 +2,16 @@ package certificate
 
 import (
 	"bytes"
+	"context"
 	"crypto"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"net"
+	"slices"
 	"strings"
 	"time"
 
@@ -16,6 +19,7 @@ import (
 	"github.com/go-acme/lego/v4/acme/api"
 	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
 	"github.com/go-acme/lego/v4/log"
 	"github.com/go-acme/lego/v4/platform/wait"
 	"golang.org/x/crypto/ocsp"
@@ -36,19 +40,80 @@ const (
 // maxBodySize is the maximum size of body that we will read.
 const maxBodySize = 1024 * 1024
 
+// Prefixes of the preferred chain selectors.
+const (
+	preferredChainSHA256 = "sha256"
+	preferredChainAKI    = "aki"
+)
+
 // Resource represents a CA issued certificate.
 // PrivateKey, Certificate and IssuerCertificate are all
 // already PEM encoded and can be directly written to disk.
 // Certificate may be a certificate bundle,
 // depending on the options supplied to create it.
 type Resource struct {
-	Domain            string `json:"domain"`
-	CertURL           string `json:"certUrl"`
-	CertStableURL     string `json:"certStableUrl"`
+	Domain        string `json:"domain"`
+	CertURL       string `json:"certUrl"`
+	CertStableURL string `json:"certStableUrl"`
+
+	// OrderURL the URL of the ACME order.
+	OrderURL string `json:"orderUrl,omitempty"`
+	// Profile the ACME profile of the order.
+	Profile string `json:"profile,omitempty"`
+
 	PrivateKey        []byte `json:"-"`
 	Certificate       []byte `json:"-"`
 	IssuerCertificate []byte `json:"-"`
 	CSR               []byte `json:"-"`
+
+	// SCTs the results of the verification of the embedded SCTs (only if a SCTPolicy is defined).
+	SCTs []SCTResult `json:"-"`
+
+	// Challenges the challenges solved to validate the domains of the order
+	// (the domains with an authorization already valid are not solved again, and are not listed).
+	Challenges []SolvedChallenge `json:"-"`
+}
+
+// SolvedChallenge a challenge solved to validate a domain of an order.
+type SolvedChallenge struct {
+	Domain string
+	Type   challenge.Type
+}
+
+// PFX encodes the private key, the certificate, and the issuer certificates into a password-protected PKCS#12 (.pfx) bundle
+// (ex: for Windows/IIS or Java).
+// The private key is required.
+func (r *Resource) PFX(password string, format certcrypto.PFXFormat) ([]byte, error) {
+	if len(r.PrivateKey) == 0 {
+		return nil, fmt.Errorf("[%s] PFX: the private key is missing", r.Domain)
+	}
+
+	return certcrypto.EncodePFX(r.PrivateKey, r.Certificate, r.IssuerCertificate, password, format)
+}
+
+// KeyStore encodes the private key, the certificate, and the issuer certificates into a password-protected Java keystore.
+// The private key is required.
+func (r *Resource) KeyStore(opts certcrypto.KeyStoreOptions) ([]byte, error) {
+	if len(r.PrivateKey) == 0 {
+		return nil, fmt.Errorf("[%s] keystore: the private key is missing", r.Domain)
+	}
+
+	return certcrypto.EncodeKeyStore(r.PrivateKey, r.Certificate, r.IssuerCertificate, opts)
+}
+
+// TrustStore encodes the issuer certificates (intermediates and root) into a password-protected Java truststore.
+func (r *Resource) TrustStore(opts certcrypto.KeyStoreOptions) ([]byte, error) {
+	chain, err := certcrypto.ParseCertificateChain(r.Certificate, r.IssuerCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] truststore: %w", r.Domain, err)
+	}
+
+	certificates := chain.Intermediates
+	if chain.Root != nil {
+		certificates = append(certificates, chain.Root)
+	}
+
+	return certcrypto.EncodeTrustStore(certificates, opts)
 }
 
 // ObtainRequest The request to obtain certificate.
@@ -59,14 +124,27 @@ type Resource struct {
 // A new private key is generated for every invocation of the function Obtain.
 // If you do not want that you can supply your own private key in the privateKey parameter.
 // If this parameter is non-nil it will be used instead of generating a new one.
+// The private key can be any crypto.Signer,
+// the private key of the Resource is empty if the key cannot be exported.
+//
+// If `Signer` is non-nil, the CSR is signed through the signer (ex: a key stored in an HSM, a KMS, or a TPM),
+// the private key is never exported: the private key of the Resource is always empty.
+// `PrivateKey` and `Signer` are mutually exclusive.
 //
 // If `Bundle` is true, the `[]byte` contains both the issuer certificate and your issued certificate as a bundle.
 //
 // If `AlwaysDeactivateAuthorizations` is true, the authorizations are also relinquished if the obtain request was successful.
 // See https://datatracker.ietf.org/doc/html/rfc8555#section-7.5.2.
+//
+// `PreferredChain` selects the chain, if the CA offers multiple certificate chains:
+// the Common Name of the root (ex: "ISRG Root X1"),
+// "sha256:<fingerprint>" (SHA-256 fingerprint of a certificate of the chain),
+// or "aki:<key ID>" (Authority Key Identifier of the top certificate of the chain, i.e. the key ID of the root).
+// The CAs usually don't include the root in the chains: the fingerprint of the root doesn't match, use its key ID instead.
 type ObtainRequest struct {
 	Domains    []string
 	PrivateKey crypto.PrivateKey
+	Signer     crypto.Signer
 	MustStaple bool
 
 	NotBefore                      time.Time
@@ -78,6 +156,20 @@ type ObtainRequest struct {
 	// order is intended to replace.
 	// - https://datatracker.ietf.org/doc/html/draft-ietf-acme-ari-03#section-5
 	ReplacesCertID string
+	// The name of the issuance profile to use (ex: "classic", "tlsserver", "shortlived").
+	// The available profiles are advertised in the directory meta.
+	// - https://datatracker.ietf.org/doc/draft-aaron-acme-profiles/
+	Profile string
+	// CustomizeCSR is called with the CSR template before signing (ex: extra extensions, custom SAN ordering).
+	// The identifiers of the CSR must still match the identifiers of the order.
+	CustomizeCSR certcrypto.CSRTemplateFunc
+	// ResumePendingOrder reuses a pending (or ready) order of the account with the same identifiers and options, if any,
+	// instead of creating a new order (ex: after an interrupted obtain).
+	// Requires the server to provide the list of the orders of the account.
+	ResumePendingOrder bool
+	// NormalizeSANs normalizes, deduplicates and sorts the domains (see NormalizeSANs),
+	// and sorts the SANs of the CSR: the CSRs of the renewals are byte-comparable (except the signature and the key).
+	NormalizeSANs bool
 }
 
 // ObtainForCSRRequest The request to obtain a certificate matching the CSR passed into it.
@@ -86,6 +178,12 @@ type ObtainRequest struct {
 //
 // If `AlwaysDeactivateAuthorizations` is true, the authorizations are also relinquished if the obtain request was successful.
 // See https://datatracker.ietf.org/doc/html/rfc8555#section-7.5.2.
+//
+// `PreferredChain` selects the chain, if the CA offers multiple certificate chains:
+// the Common Name of the root (ex: "ISRG Root X1"),
+// "sha256:<fingerprint>" (SHA-256 fingerprint of a certificate of the chain),
+// or "aki:<key ID>" (Authority Key Identifier of the top certificate of the chain, i.e. the key ID of the root).
+// The CAs usually don't include the root in the chains: the fingerprint of the root doesn't match, use its key ID instead.
 type ObtainForCSRRequest struct {
 	CSR *x509.CertificateRequest
 
@@ -98,16 +196,34 @@ type ObtainForCSRRequest struct {
 	// order is intended to replace.
 	// - https://datatracker.ietf.org/doc/html/draft-ietf-acme-ari-03#section-5
 	ReplacesCertID string
+	// The name of the issuance profile to use (ex: "classic", "tlsserver", "shortlived").
+	// The available profiles are advertised in the directory meta.
+	// - https://datatracker.ietf.org/doc/draft-aaron-acme-profiles/
+	Profile string
+	// ResumePendingOrder reuses a pending (or ready) order of the account with the same identifiers and options, if any,
+	// instead of creating a new order (ex: after an interrupted obtain).
+	// Requires the server to provide the list of the orders of the account.
+	ResumePendingOrder bool
 }
 
 type resolver interface {
 	Solve(authorizations []acme.Authorization) error
 }
 
+// challengeTyper is implemented by the resolvers which can tell the type of the challenge chosen to validate an authorization.
+type challengeTyper interface {
+	ChallengeType(authz acme.Authorization) (challenge.Type, bool)
+}
+
 type CertifierOptions struct {
 	KeyType             certcrypto.KeyType
 	Timeout             time.Duration
 	OverallRequestLimit int
+	// SCTPolicy verifies the SCTs embedded in the issued certificates (Certificate Transparency), if defined.
+	SCTPolicy *SCTPolicy
+	// CAAPreCheck checks the CAA records of the domains against the CAA identities of the CA (directory meta)
+	// before creating an order.
+	CAAPreCheck bool
 }
 
 // Certifier A service to obtain/renew/revoke certificates.
@@ -116,6 +232,7 @@ type Certifier struct {
 	resolver            resolver
 	options             CertifierOptions
 	overallRequestLimit int
+	ocsp                *OCSPChecker
 }
 
 // NewCertifier creates a Certifier.
@@ -126,6 +243,12 @@ func NewCertifier(core *api.Core, resolver resolver, options CertifierOptions) *
 		options:  options,
 	}
 
+	if core != nil {
+		c.ocsp = NewOCSPChecker(core.HTTPClient)
+	} else {
+		c.ocsp = NewOCSPChecker(nil)
+	}
+
 	c.overallRequestLimit = options.OverallRequestLimit
 	if c.overallRequestLimit <= 0 {
 		c.overallRequestLimit = DefaultOverallRequestLimit
@@ -143,21 +266,43 @@ func (c *Certifier) Obtain(request ObtainRequest) (*Resource, error) {
 		return nil, errors.New("no domains to obtain a certificate for")
 	}
 
+	if request.PrivateKey != nil && request.Signer != nil {
+		return nil, errors.New("cannot obtain a certificate with both a private key and a signer")
+	}
+
+	err := ValidatePreferredChain(request.PreferredChain)
+	if err != nil {
+		return nil, err
+	}
+
 	domains := sanitizeDomain(request.Domains)
 
+	customizeCSR := request.CustomizeCSR
+
+	if request.NormalizeSANs {
+		var err error
+		domains, err = NormalizeSANs(domains)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] acme: %w", strings.Join(request.Domains, ", "), err)
+		}
+
+		customizeCSR = sortCSRSANs(customizeCSR)
+	}
+
 	if request.Bundle {
-		log.Infof("[%s] acme: Obtaining bundled SAN certificate", strings.Join(domains, ", "))
+		log.WithDomain(strings.Join(domains, ", ")).Infof("acme: Obtaining bundled SAN certificate")
 	} else {
-		log.Infof("[%s] acme: Obtaining SAN certificate", strings.Join(domains, ", "))
+		log.WithDomain(strings.Join(domains, ", ")).Infof("acme: Obtaining SAN certificate")
 	}
 
 	orderOpts := &api.OrderOptions{
 		NotBefore:      request.NotBefore,
 		NotAfter:       request.NotAfter,
 		ReplacesCertID: request.ReplacesCertID,
+		Profile:        request.Profile,
 	}
 
-	order, err := c.core.Orders.NewWithOptions(domains, orderOpts)
+	order, err := c.newOrder(domains, orderOpts, request.ResumePendingOrder)
 	if err != nil {
 		return nil, err
 	}
@@ -166,20 +311,27 @@ func (c *Certifier) Obtain(request ObtainRequest) (*Resource, error) {
 	if err != nil {
 		// If any challenge fails, return. Do not generate partial SAN certificates.
 		c.deactivateAuthorizations(order, request.AlwaysDeactivateAuthorizations)
-		return nil, err
+		return nil, newOrderError(order, err)
 	}
 
+	solved := c.solvedChallenges(authz)
+
 	err = c.resolver.Solve(authz)
 	if err != nil {
 		// If any challenge fails, return. Do not generate partial SAN certificates.
 		c.deactivateAuthorizations(order, request.AlwaysDeactivateAuthorizations)
-		return nil, err
+		return nil, newOrderError(order, err)
 	}
 
-	log.Infof("[%s] acme: Validations succeeded; requesting certificates", strings.Join(domains, ", "))
+	log.WithDomain(strings.Join(domains, ", ")).Infof("acme: Validations succeeded; requesting certificates")
 
 	failures := newObtainError()
-	cert, err := c.getForOrder(domains, order, request.Bundle, request.PrivateKey, request.MustStaple, request.PreferredChain)
+	var cert *Resource
+	if request.Signer != nil {
+		cert, err = c.getForOrderWithSigner(domains, order, request.Bundle, request.Signer, request.MustStaple, request.PreferredChain, customizeCSR)
+	} else {
+		cert, err = c.getForOrder(domains, order, request.Bundle, request.PrivateKey, request.MustStaple, request.PreferredChain, customizeCSR)
+	}
 	if err != nil {
 		for _, auth := range authz {
 			failures.Add(challenge.GetTargetedDomain(auth), err)
@@ -190,7 +342,11 @@ func (c *Certifier) Obtain(request ObtainRequest) (*Resource, error) {
 		c.deactivateAuthorizations(order, true)
 	}
 
-	return cert, failures.Join()
+	if cert != nil {
+		cert.Challenges = solved
+	}
+
+	return cert, newOrderError(order, failures.Join())
 }
 
 // ObtainForCSR tries to obtain a certificate matching the CSR passed into it.
@@ -207,23 +363,29 @@ func (c *Certifier) ObtainForCSR(request ObtainForCSRRequest) (*Resource, error)
 		return nil, errors.New("cannot obtain resource for CSR: CSR is missing")
 	}
 
+	err := ValidatePreferredChain(request.PreferredChain)
+	if err != nil {
+		return nil, err
+	}
+
 	// figure out what domains it concerns
 	// start with the common name
 	domains := certcrypto.ExtractDomainsCSR(request.CSR)
 
 	if request.Bundle {
-		log.Infof("[%s] acme: Obtaining bundled SAN certificate given a CSR", strings.Join(domains, ", "))
+		log.WithDomain(strings.Join(domains, ", ")).Infof("acme: Obtaining bundled SAN certificate given a CSR")
 	} else {
-		log.Infof("[%s] acme: Obtaining SAN certificate given a CSR", strings.Join(domains, ", "))
+		log.WithDomain(strings.Join(domains, ", ")).Infof("acme: Obtaining SAN certificate given a CSR")
 	}
 
 	orderOpts := &api.OrderOptions{
 		NotBefore:      request.NotBefore,
 		NotAfter:       request.NotAfter,
 		ReplacesCertID: request.ReplacesCertID,
+		Profile:        request.Profile,
 	}
 
-	order, err := c.core.Orders.NewWithOptions(domains, orderOpts)
+	order, err := c.newOrder(domains, orderOpts, request.ResumePendingOrder)
 	if err != nil {
 		return nil, err
 	}
@@ -232,17 +394,19 @@ func (c *Certifier) ObtainForCSR(request ObtainForCSRRequest) (*Resource, error)
 	if err != nil {
 		// If any challenge fails, return. Do not generate partial SAN certificates.
 		c.deactivateAuthorizations(order, request.AlwaysDeactivateAuthorizations)
-		return nil, err
+		return nil, newOrderError(order, err)
 	}
 
+	solved := c.solvedChallenges(authz)
+
 	err = c.resolver.Solve(authz)
 	if err != nil {
 		// If any challenge fails, return. Do not generate partial SAN certificates.
 		c.deactivateAuthorizations(order, request.AlwaysDeactivateAuthorizations)
-		return nil, err
+		return nil, newOrderError(order, err)
 	}
 
-	log.Infof("[%s] acme: Validations succeeded; requesting certificates", strings.Join(domains, ", "))
+	log.WithDomain(strings.Join(domains, ", ")).Infof("acme: Validations succeeded; requesting certificates")
 
 	failures := newObtainError()
 	cert, err := c.getForCSR(domains, order, request.Bundle, request.CSR.Raw, nil, request.PreferredChain)
@@ -259,12 +423,173 @@ func (c *Certifier) ObtainForCSR(request ObtainForCSRRequest) (*Resource, error)
 	if cert != nil {
 		// Add the CSR to the certificate so that it can be used for renewals.
 		cert.CSR = certcrypto.PEMEncode(request.CSR)
+		cert.Challenges = solved
 	}
 
-	return cert, failures.Join()
+	return cert, newOrderError(order, failures.Join())
 }
 
-func (c *Certifier) getForOrder(domains []string, order acme.ExtendedOrder, bundle bool, privateKey crypto.PrivateKey, mustStaple bool, preferredChain string) (*Resource, error) {
+// solvedChallenges returns the challenges chosen by the resolver to validate the authorizations,
+// the valid authorizations are skipped (not solved again).
+func (c *Certifier) solvedChallenges(authorizations []acme.Authorization) []SolvedChallenge {
+	typer, ok := c.resolver.(challengeTyper)
+	if !ok {
+		return nil
+	}
+
+	var solved []SolvedChallenge
+
+	for _, authz := range authorizations {
+		if authz.Status == acme.StatusValid {
+			continue
+		}
+
+		chlgType, ok := typer.ChallengeType(authz)
+		if !ok {
+			continue
+		}
+
+		solved = append(solved, SolvedChallenge{Domain: challenge.GetTargetedDomain(authz), Type: chlgType})
+	}
+
+	return solved
+}
+
+// newOrder creates a new order, or resumes a pending order with the same identifiers.
+func (c *Certifier) newOrder(domains []string, opts *api.OrderOptions, resume bool) (acme.ExtendedOrder, error) {
+	if resume {
+		order, err := c.findPendingOrder(domains, opts)
+		if err != nil {
+			log.WithDomain(strings.Join(domains, ", ")).Warnf("acme: Unable to find a pending order: %v", err)
+		} else if order != nil {
+			log.WithDomain(strings.Join(domains, ", ")).Infof("acme: Resuming the order %s", order.Location)
+			return *order, nil
+		}
+	}
+
+	if c.options.CAAPreCheck {
+		err := c.checkCAA(domains)
+		if err != nil {
+			return acme.ExtendedOrder{}, err
+		}
+	}
+
+	return c.core.Orders.NewWithOptions(domains, opts)
+}
+
+// checkCAA checks the CAA records of the domains against the CAA identities of the CA and the account URL,
+// before creating an order that would fail.
+func (c *Certifier) checkCAA(domains []string) error {
+	identities := c.core.GetDirectory().Meta.CaaIdentities
+	if len(identities) == 0 {
+		log.WithDomain(strings.Join(domains, ", ")).Infof("acme: Skipping the CAA pre-check: the CA does not provide its CAA identities")
+		return nil
+	}
+
+	var errs []error
+
+	for _, domain := range domains {
+		if net.ParseIP(domain) != nil {
+			continue
+		}
+
+		err := dns01.CheckCAAWithOptions(domain, dns01.CAAOptions{
+			IssuerDomains: identities,
+			AccountURI:    c.core.GetAccountURL(),
+		})
+		if err != nil {
+			errs = append(errs, &acme.IdentifierError{Identifier: domain, Err: err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// findPendingOrder looks up a pending or ready order of the account with the same identifiers and the same options
+// (profile, replaced certificate, validity period).
+func (c *Certifier) findPendingOrder(domains []string, opts *api.OrderOptions) (*acme.ExtendedOrder, error) {
+	account, err := c.core.Accounts.Get(c.core.GetAccountURL())
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Orders == "" {
+		return nil, errors.New("the server does not provide the orders of the account")
+	}
+
+	orderURLs, err := c.core.Accounts.ListOrders(account.Orders)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := normalizeDomains(domains)
+
+	for _, orderURL := range orderURLs {
+		order, err := c.core.Orders.Get(orderURL)
+		if err != nil {
+			return nil, err
+		}
+
+		if order.Status != acme.StatusPending && order.Status != acme.StatusReady {
+			continue
+		}
+
+		if !matchOrderOptions(order.Order, opts, c.core.GetDirectory().RenewalInfo != "") {
+			continue
+		}
+
+		var identifiers []string
+		for _, identifier := range order.Identifiers {
+			identifiers = append(identifiers, identifier.Value)
+		}
+
+		if slices.Equal(normalizeDomains(identifiers), expected) {
+			order.Location = orderURL
+			return &order, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// matchOrderOptions checks if the order has been created with the options.
+// The replaced certificate is only sent to the servers supporting ARI.
+func matchOrderOptions(order acme.Order, opts *api.OrderOptions, ari bool) bool {
+	if opts == nil {
+		opts = &api.OrderOptions{}
+	}
+
+	if opts.Profile != "" && order.Profile != opts.Profile {
+		return false
+	}
+
+	replaces := ""
+	if ari {
+		replaces = opts.ReplacesCertID
+	}
+
+	if order.Replaces != replaces {
+		return false
+	}
+
+	return matchOrderTime(order.NotBefore, opts.NotBefore) && matchOrderTime(order.NotAfter, opts.NotAfter)
+}
+
+// matchOrderTime checks if the time of an order (RFC 3339, empty if not defined) is the expected time (zero if not defined).
+func matchOrderTime(value string, expected time.Time) bool {
+	if value == "" || expected.IsZero() {
+		return value == "" && expected.IsZero()
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false
+	}
+
+	return t.Equal(expected.Truncate(time.Second))
+}
+
+func (c *Certifier) getForOrder(domains []string, order acme.ExtendedOrder, bundle bool, privateKey crypto.PrivateKey, mustStaple bool, preferredChain string, customizeCSR certcrypto.CSRTemplateFunc) (*Resource, error) {
 	if privateKey == nil {
 		var err error
 		privateKey, err = certcrypto.GeneratePrivateKey(c.options.KeyType)
@@ -273,8 +598,28 @@ func (c *Certifier) getForOrder(domains []string, order acme.ExtendedOrder, bund
 		}
 	}
 
+	csr, err := c.createCSR(domains, order, privateKey, mustStaple, customizeCSR)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.getForCSR(domains, order, bundle, csr, certcrypto.PEMEncode(privateKey), preferredChain)
+}
+
+// getForOrderWithSigner the CSR is signed through the signer, the private key is never exported.
+func (c *Certifier) getForOrderWithSigner(domains []string, order acme.ExtendedOrder, bundle bool, signer crypto.Signer, mustStaple bool, preferredChain string, customizeCSR certcrypto.CSRTemplateFunc) (*Resource, error) {
+	csr, err := c.createCSR(domains, order, signer, mustStaple, customizeCSR)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.getForCSR(domains, order, bundle, csr, nil, preferredChain)
+}
+
+func (c *Certifier) createCSR(domains []string, order acme.ExtendedOrder, privateKey crypto.PrivateKey, mustStaple bool, customizeCSR certcrypto.CSRTemplateFunc) ([]byte, error) {
+	// IP addresses are only added to the SAN extension (RFC 8738).
 	commonName := ""
-	if len(domains[0]) <= 64 {
+	if len(domains[0]) <= 64 && net.ParseIP(domains[0]) == nil {
 		commonName = domains[0]
 	}
 
@@ -296,13 +641,7 @@ func (c *Certifier) getForOrder(domains []string, order acme.ExtendedOrder, bund
 		}
 	}
 
-	// TODO: should the CSR be customizable?
-	csr, err := certcrypto.GenerateCSR(privateKey, commonName, san, mustStaple)
-	if err != nil {
-		return nil, err
-	}
-
-	return c.getForCSR(domains, order, bundle, csr, certcrypto.PEMEncode(privateKey), preferredChain)
+	return certcrypto.GenerateCustomCSR(privateKey, commonName, san, mustStaple, customizeCSR)
 }
 
 func (c *Certifier) getForCSR(domains []string, order acme.ExtendedOrder, bundle bool, csr, privateKeyPem []byte, preferredChain string) (*Resource, error) {
@@ -314,6 +653,8 @@ func (c *Certifier) getForCSR(domains []string, order acme.ExtendedOrder, bundle
 	certRes := &Resource{
 		Domain:     domains[0],
 		CertURL:    respOrder.Certificate,
+		OrderURL:   order.Location,
+		Profile:    order.Profile,
 		PrivateKey: privateKeyPem,
 	}
 
@@ -325,7 +666,7 @@ func (c *Certifier) getForCSR(domains []string, order acme.ExtendedOrder, bundle
 		}
 
 		if ok {
-			return certRes, nil
+			return c.checkSCTs(certRes)
 		}
 	}
 
@@ -347,8 +688,11 @@ func (c *Certifier) getForCSR(domains []string, order acme.ExtendedOrder, bundle
 
 		return done, nil
 	})
+	if err != nil {
+		return certRes, err
+	}
 
-	return certRes, err
+	return c.checkSCTs(certRes)
 }
 
 // checkResponse checks to see if the certificate is ready and a link is contained in the response.
@@ -377,7 +721,7 @@ func (c *Certifier) checkResponse(order acme.ExtendedOrder, certRes *Resource, b
 	certRes.CertStableURL = order.Certificate
 
 	if preferredChain == "" {
-		log.Infof("[%s] Server responded with a certificate.", certRes.Domain)
+		log.WithDomain(certRes.Domain).Infof("Server responded with a certificate.")
 
 		return true, nil
 	}
@@ -389,7 +733,7 @@ func (c *Certifier) checkResponse(order acme.ExtendedOrder, certRes *Resource, b
 		}
 
 		if ok {
-			log.Infof("[%s] Server responded with a certificate for the preferred certificate chains %q.", certRes.Domain, preferredChain)
+			log.WithDomain(certRes.Domain).Infof("Server responded with a certificate for the preferred certificate chains %q.", preferredChain)
 
 			certRes.IssuerCertificate = cert.Issuer
 			certRes.Certificate = cert.Cert
@@ -440,6 +784,16 @@ type RenewOptions struct {
 	AlwaysDeactivateAuthorizations bool
 	// Not supported for CSR request.
 	MustStaple bool
+	// Not supported for CSR request.
+	CustomizeCSR certcrypto.CSRTemplateFunc
+	// Signer signs the CSR of the new certificate (ex: a key stored in an HSM), instead of the private key of the Resource.
+	// Not supported for CSR request.
+	Signer crypto.Signer
+	// Not supported for CSR request.
+	NormalizeSANs bool
+	// A string uniquely identifying the certificate to renew (see MakeARICertID).
+	// - https://datatracker.ietf.org/doc/html/draft-ietf-acme-ari-03#section-5
+	ReplacesCertID string
 }
 
 // Renew takes a Resource and tries to renew the certificate.
@@ -487,7 +841,7 @@ func (c *Certifier) RenewWithOptions(certRes Resource, options *RenewOptions) (*
 
 	// This is just meant to be informal for the user.
 	timeLeft := x509Cert.NotAfter.Sub(time.Now().UTC())
-	log.Infof("[%s] acme: Trying renewal with %d hours remaining", certRes.Domain, int(timeLeft.Hours()))
+	log.WithDomain(certRes.Domain).Infof("acme: Trying renewal with %d hours remaining", int(timeLeft.Hours()))
 
 	// We always need to request a new certificate to renew.
 	// Start by checking to see if the certificate was based off a CSR,
@@ -506,13 +860,14 @@ func (c *Certifier) RenewWithOptions(certRes Resource, options *RenewOptions) (*
 			request.Bundle = options.Bundle
 			request.PreferredChain = options.PreferredChain
 			request.AlwaysDeactivateAuthorizations = options.AlwaysDeactivateAuthorizations
+			request.ReplacesCertID = options.ReplacesCertID
 		}
 
 		return c.ObtainForCSR(request)
 	}
 
 	var privateKey crypto.PrivateKey
-	if certRes.PrivateKey != nil {
+	if certRes.PrivateKey != nil && (options == nil || options.Signer == nil) {
 		privateKey, err = certcrypto.ParsePEMPrivateKey(certRes.PrivateKey)
 		if err != nil {
 			return nil, err
@@ -526,11 +881,15 @@ func (c *Certifier) RenewWithOptions(certRes Resource, options *RenewOptions) (*
 
 	if options != nil {
 		request.MustStaple = options.MustStaple
+		request.CustomizeCSR = options.CustomizeCSR
+		request.Signer = options.Signer
+		request.NormalizeSANs = options.NormalizeSANs
 		request.NotBefore = options.NotBefore
 		request.NotAfter = options.NotAfter
 		request.Bundle = options.Bundle
 		request.PreferredChain = options.PreferredChain
 		request.AlwaysDeactivateAuthorizations = options.AlwaysDeactivateAuthorizations
+		request.ReplacesCertID = options.ReplacesCertID
 	}
 
 	return c.Obtain(request)
@@ -541,79 +900,18 @@ func (c *Certifier) RenewWithOptions(certRes Resource, options *RenewOptions) (*
 //
 // The returned []byte can be passed directly into the OCSPStaple property of a tls.Certificate.
 // If the bundle only contains the issued certificate,
-// this function will try to get the issuer certificate from the IssuingCertificateURL in the certificate.
+// this function will try to get the issuer certificate from the IssuingCertificateURLs in the certificate.
+//
+// The responses are cached until their NextUpdate, and all the OCSP servers of the certificate are tried, in order.
 //
 // If the []byte and/or ocsp.Response return values are nil, the OCSP status may be assumed OCSPUnknown.
 func (c *Certifier) GetOCSP(bundle []byte) ([]byte, *ocsp.Response, error) {
-	certificates, err := certcrypto.ParsePEMBundle(bundle)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	// We expect the certificate slice to be ordered downwards the chain.
-	// SRV CRT -> CA. We need to pull the leaf and issuer certs out of it,
-	// which should always be the first two certificates.
-	// If there's no OCSP server listed in the leaf cert, there's nothing to do.
-	// And if we have only one certificate so far, we need to get the issuer cert.
-
-	issuedCert := certificates[0]
-
-	if len(issuedCert.OCSPServer) == 0 {
-		return nil, nil, errors.New("no OCSP server specified in cert")
-	}
-
-	if len(certificates) == 1 {
-		// TODO: build fallback. If this fails, check the remaining array entries.
-		if len(issuedCert.IssuingCertificateURL) == 0 {
-			return nil, nil, errors.New("no issuing certificate URL")
-		}
-
-		resp, errC := c.core.HTTPClient.Get(issuedCert.IssuingCertificateURL[0])
-		if errC != nil {
-			return nil, nil, errC
-		}
-		defer resp.Body.Close()
-
-		issuerBytes, errC := io.ReadAll(http.MaxBytesReader(nil, resp.Body, maxBodySize))
-		if errC != nil {
-			return nil, nil, errC
-		}
-
-		issuerCert, errC := x509.ParseCertificate(issuerBytes)
-		if errC != nil {
-			return nil, nil, errC
-		}
-
-		// Insert it into the slice on position 0
-		// We want it ordered right SRV CRT -> CA
-		certificates = append(certificates, issuerCert)
-	}
-
-	issuerCert := certificates[1]
-
-	// Finally kick off the OCSP request.
-	ocspReq, err := ocsp.CreateRequest(issuedCert, issuerCert, nil)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	resp, err := c.core.HTTPClient.Post(issuedCert.OCSPServer[0], "application/ocsp-request", bytes.NewReader(ocspReq))
-	if err != nil {
-		return nil, nil, err
-	}
-	defer resp.Body.Close()
-
-	ocspResBytes, err := io.ReadAll(http.MaxBytesReader(nil, resp.Body, maxBodySize))
-	if err != nil {
-		return nil, nil, err
-	}
-
-	ocspRes, err := ocsp.ParseResponse(ocspResBytes, issuerCert)
-	if err != nil {
-		return nil, nil, err
-	}
+	return c.ocsp.Check(bundle)
+}
 
-	return ocspResBytes, ocspRes, nil
+// WaitForGoodOCSPStatus polls the OCSP responders until the certificate has a good status (see OCSPChecker.WaitForGoodStatus).
+func (c *Certifier) WaitForGoodOCSPStatus(ctx context.Context, bundle []byte, interval time.Duration) (*ocsp.Response, error) {
+	return c.ocsp.WaitForGoodStatus(ctx, bundle, interval)
 }
 
 // Get attempts to fetch the certificate at the supplied URL.
@@ -648,7 +946,58 @@ func (c *Certifier) Get(url string, bundle bool) (*Resource, error) {
 	}, nil
 }
 
+// ValidatePreferredChain checks the format of a preferred chain (see ObtainRequest.PreferredChain).
+func ValidatePreferredChain(preferredChain string) error {
+	_, _, err := parsePreferredChain(preferredChain)
+	return err
+}
+
+// parsePreferredChain returns the kind of the preferred chain ("sha256", "aki", or "" for a Common Name) and the decoded value.
+func parsePreferredChain(preferredChain string) (string, []byte, error) {
+	kind, value, found := strings.Cut(preferredChain, ":")
+	if !found {
+		return "", nil, nil
+	}
+
+	switch kind = strings.ToLower(kind); kind {
+	case preferredChainSHA256:
+		fingerprint, err := decodeHexID(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid preferred chain fingerprint %q: %w", value, err)
+		}
+
+		if len(fingerprint) != sha256.Size {
+			return "", nil, fmt.Errorf("invalid preferred chain fingerprint %q: %d bytes instead of %d", value, len(fingerprint), sha256.Size)
+		}
+
+		return kind, fingerprint, nil
+
+	case preferredChainAKI:
+		keyID, err := decodeHexID(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid preferred chain authority key identifier %q: %w", value, err)
+		}
+
+		return kind, keyID, nil
+
+	default:
+		return "", nil, nil
+	}
+}
+
+// hasPreferredChain checks if the issuer chain matches the preferred chain.
+// The preferred chain can be:
+//   - the Common Name of the issuer of the top certificate of the chain (ex: "ISRG Root X1").
+//   - "sha256:<fingerprint>": the SHA-256 fingerprint of one of the certificates of the chain (ex: the root when the CA includes it).
+//   - "aki:<key ID>": the Authority Key Identifier of the top certificate of the chain, i.e. the Subject Key Identifier of the root.
+//
+// The fingerprint and the key ID are hexadecimal, case-insensitive, and the colons are optional.
 func hasPreferredChain(issuer []byte, preferredChain string) (bool, error) {
+	kind, id, err := parsePreferredChain(preferredChain)
+	if err != nil {
+		return false, err
+	}
+
 	certs, err := certcrypto.ParsePEMBundle(issuer)
 	if err != nil {
 		return false, err
@@ -656,11 +1005,32 @@ func hasPreferredChain(issuer []byte, preferredChain string) (bool, error) {
 
 	topCert := certs[len(certs)-1]
 
-	if topCert.Issuer.CommonName == preferredChain {
-		return true, nil
+	switch kind {
+	case preferredChainSHA256:
+		for _, cert := range certs {
+			sum := sha256.Sum256(cert.Raw)
+			if bytes.Equal(sum[:], id) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+
+	case preferredChainAKI:
+		return len(topCert.AuthorityKeyId) > 0 && bytes.Equal(topCert.AuthorityKeyId, id), nil
+
+	default:
+		return topCert.Issuer.CommonName == preferredChain, nil
 	}
+}
 
-	return false, nil
+func decodeHexID(value string) ([]byte, error) {
+	raw := strings.ReplaceAll(strings.TrimSpace(value), ":", "")
+	if raw == "" {
+		return nil, errors.New("empty value")
+	}
+
+	return hex.DecodeString(raw)
 }
 
 func checkOrderStatus(order acme.ExtendedOrder) (bool, error) {
@@ -682,6 +1052,12 @@ func checkOrderStatus(order acme.ExtendedOrder) (bool, error) {
 func sanitizeDomain(domains []string) []string {
 	var sanitizedDomains []string
 	for _, domain := range domains {
+		if ip := net.ParseIP(domain); ip != nil {
+			// Canonical form of the IP address (ex: lowercase and compressed IPv6).
+			sanitizedDomains = append(sanitizedDomains, ip.String())
+			continue
+		}
+
 		sanitizedDomain, err := idna.ToASCII(domain)
 		if err != nil {
 			log.Infof("skip domain %q: unable to sanitize (punnycode): %v", domain, err)
@@ -1,16 +1,24 @@
 package certificate
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
 	"fmt"
+	"math/big"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/go-acme/lego/v4/acme"
 	"github.com/go-acme/lego/v4/acme/api"
 	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/challenge"
 	"github.com/go-acme/lego/v4/platform/tester"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -357,6 +365,171 @@ func Test_checkResponse_alternate(t *testing.T) {
 	assert.Equal(t, issuerMock2, string(certRes.IssuerCertificate), "IssuerCertificate")
 }
 
+func Test_hasPreferredChain(t *testing.T) {
+	issuer := createIssuerChain(t)
+
+	testCases := []struct {
+		desc           string
+		issuer         []byte
+		preferredChain string
+		expected       bool
+	}{
+		{
+			desc:           "common name",
+			issuer:         []byte(issuerMock2),
+			preferredChain: "DST Root CA X3",
+			expected:       true,
+		},
+		{
+			desc:           "common name: no match",
+			issuer:         []byte(issuerMock),
+			preferredChain: "DST Root CA X3",
+		},
+		{
+			desc:           "sha256 fingerprint",
+			issuer:         []byte(issuerMock2),
+			preferredChain: "sha256:0687260331a72403d909f105e69bcf0d32e1bd2493ffc6d9206d11bcd6770739",
+			expected:       true,
+		},
+		{
+			desc:           "sha256 fingerprint: uppercase with colons",
+			issuer:         []byte(issuerMock2),
+			preferredChain: "SHA256:06:87:26:03:31:A7:24:03:D9:09:F1:05:E6:9B:CF:0D:32:E1:BD:24:93:FF:C6:D9:20:6D:11:BC:D6:77:07:39",
+			expected:       true,
+		},
+		{
+			desc:           "sha256 fingerprint: no match",
+			issuer:         []byte(issuerMock),
+			preferredChain: "sha256:0687260331a72403d909f105e69bcf0d32e1bd2493ffc6d9206d11bcd6770739",
+		},
+		{
+			desc:           "authority key identifier",
+			issuer:         issuer,
+			preferredChain: "aki:01:02:03:04",
+			expected:       true,
+		},
+		{
+			desc:           "authority key identifier: no match",
+			issuer:         issuer,
+			preferredChain: "aki:05060708",
+		},
+		{
+			desc:           "authority key identifier: no extension",
+			issuer:         []byte(issuerMock2),
+			preferredChain: "aki:01020304",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			ok, err := hasPreferredChain(test.issuer, test.preferredChain)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, ok)
+		})
+	}
+}
+
+func Test_hasPreferredChain_invalid(t *testing.T) {
+	_, err := hasPreferredChain([]byte(issuerMock2), "sha256:zz")
+	require.Error(t, err)
+
+	_, err = hasPreferredChain([]byte(issuerMock2), "aki:")
+	require.Error(t, err)
+}
+
+func TestValidatePreferredChain(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		preferredChain string
+		expected       string
+	}{
+		{
+			desc:           "common name",
+			preferredChain: "ISRG Root X1",
+		},
+		{
+			desc:           "fingerprint",
+			preferredChain: "sha256:06:87:26:03:31:a7:24:03:d9:09:f1:05:e6:9b:cf:0d:32:e1:bd:24:93:ff:c6:d9:20:6d:11:bc:d6:77:07:39",
+		},
+		{
+			desc:           "authority key identifier",
+			preferredChain: "aki:79b459e67bb6e5e40173800888c81a58f6e99b6e",
+		},
+		{
+			desc:           "invalid fingerprint",
+			preferredChain: "sha256:zz",
+			expected:       `invalid preferred chain fingerprint "zz": encoding/hex: invalid byte: U+007A 'z'`,
+		},
+		{
+			desc:           "truncated fingerprint",
+			preferredChain: "sha256:0687260331a72403",
+			expected:       `invalid preferred chain fingerprint "0687260331a72403": 8 bytes instead of 32`,
+		},
+		{
+			desc:           "empty authority key identifier",
+			preferredChain: "aki:",
+			expected:       `invalid preferred chain authority key identifier "": empty value`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidatePreferredChain(test.preferredChain)
+			if test.expected == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, test.expected)
+			}
+		})
+	}
+}
+
+// createIssuerChain creates an intermediate certificate signed by a root with the Subject Key Identifier 01020304.
+func createIssuerChain(t *testing.T) []byte {
+	t.Helper()
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		SubjectKeyId:          []byte{1, 2, 3, 4},
+	}
+
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, rootKey.Public(), rootKey)
+	require.NoError(t, err)
+
+	root, err := x509.ParseCertificate(rootDER)
+	require.NoError(t, err)
+
+	intermediateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	intermediateTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Test Intermediate"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	intermediateDER, err := x509.CreateCertificate(rand.Reader, intermediateTemplate, root, intermediateKey.Public(), rootKey)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: intermediateDER})
+}
+
 func Test_Get(t *testing.T) {
 	mux, apiURL := tester.SetupFakeAPI(t)
 
@@ -389,6 +562,12 @@ func Test_Get(t *testing.T) {
 	assert.Equal(t, issuerMock, string(certRes.IssuerCertificate), "IssuerCertificate")
 }
 
+func Test_sanitizeDomain(t *testing.T) {
+	domains := sanitizeDomain([]string{"example.com", "ñandú.example.com", "203.0.113.10", "2001:DB8:0::0:1"})
+
+	assert.Equal(t, []string{"example.com", "xn--and-6ma2c.example.com", "203.0.113.10", "2001:db8::1"}, domains)
+}
+
 type resolverMock struct {
 	error error
 }
@@ -396,3 +575,232 @@ type resolverMock struct {
 func (r *resolverMock) Solve(_ []acme.Authorization) error {
 	return r.error
 }
+
+type challengeTyperMock struct {
+	resolverMock
+}
+
+func (r *challengeTyperMock) ChallengeType(authz acme.Authorization) (challenge.Type, bool) {
+	for _, chlg := range authz.Challenges {
+		if chlg.Type == string(challenge.DNS01) {
+			return challenge.DNS01, true
+		}
+	}
+
+	return "", false
+}
+
+func TestCertifier_solvedChallenges(t *testing.T) {
+	authorizations := []acme.Authorization{
+		{
+			Identifier: acme.Identifier{Type: "dns", Value: "example.com"},
+			Status:     acme.StatusPending,
+			Challenges: []acme.Challenge{{Type: string(challenge.HTTP01)}, {Type: string(challenge.DNS01)}},
+		},
+		{
+			Identifier: acme.Identifier{Type: "dns", Value: "example.com"},
+			Wildcard:   true,
+			Status:     acme.StatusPending,
+			Challenges: []acme.Challenge{{Type: string(challenge.DNS01)}},
+		},
+		{
+			Identifier: acme.Identifier{Type: "dns", Value: "valid.example.com"},
+			Status:     acme.StatusValid,
+			Challenges: []acme.Challenge{{Type: string(challenge.DNS01)}},
+		},
+		{
+			Identifier: acme.Identifier{Type: "dns", Value: "unsolvable.example.com"},
+			Status:     acme.StatusPending,
+			Challenges: []acme.Challenge{{Type: string(challenge.HTTP01)}},
+		},
+	}
+
+	certifier := NewCertifier(nil, &challengeTyperMock{}, CertifierOptions{KeyType: certcrypto.EC256})
+
+	expected := []SolvedChallenge{
+		{Domain: "example.com", Type: challenge.DNS01},
+		{Domain: "*.example.com", Type: challenge.DNS01},
+	}
+
+	assert.Equal(t, expected, certifier.solvedChallenges(authorizations))
+
+	certifier = NewCertifier(nil, &resolverMock{}, CertifierOptions{KeyType: certcrypto.EC256})
+
+	assert.Empty(t, certifier.solvedChallenges(authorizations))
+}
+
+func TestCertifier_findPendingOrder(t *testing.T) {
+	mux, apiURL := tester.SetupFakeAPI(t)
+
+	mux.HandleFunc("/account/1", func(w http.ResponseWriter, _ *http.Request) {
+		err := tester.WriteJSONResponse(w, acme.Account{Status: acme.StatusValid, Orders: apiURL + "/account/1/orders"})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	mux.HandleFunc("/account/1/orders", func(w http.ResponseWriter, _ *http.Request) {
+		err := tester.WriteJSONResponse(w, acme.OrdersList{Orders: []string{apiURL + "/order/1", apiURL + "/order/2", apiURL + "/order/3"}})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	orders := map[string]acme.Order{
+		"/order/1": {Status: acme.StatusValid, Identifiers: []acme.Identifier{{Type: "dns", Value: "example.com"}, {Type: "dns", Value: "www.example.com"}}},
+		"/order/2": {Status: acme.StatusPending, Identifiers: []acme.Identifier{{Type: "dns", Value: "example.com"}}},
+		"/order/3": {Status: acme.StatusPending, Identifiers: []acme.Identifier{{Type: "dns", Value: "www.example.com"}, {Type: "dns", Value: "example.com"}}},
+	}
+
+	mux.HandleFunc("/order/", func(w http.ResponseWriter, r *http.Request) {
+		err := tester.WriteJSONResponse(w, orders[r.URL.Path])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", apiURL+"/account/1", key)
+	require.NoError(t, err)
+
+	certifier := NewCertifier(core, &resolverMock{}, CertifierOptions{KeyType: certcrypto.RSA2048})
+
+	order, err := certifier.findPendingOrder([]string{"example.com", "www.example.com"}, &api.OrderOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, order)
+
+	assert.Equal(t, apiURL+"/order/3", order.Location)
+
+	order, err = certifier.findPendingOrder([]string{"example.com", "www.example.com"}, &api.OrderOptions{Profile: "shortlived"})
+	require.NoError(t, err)
+
+	assert.Nil(t, order)
+
+	order, err = certifier.findPendingOrder([]string{"example.com", "www.example.com"}, &api.OrderOptions{ReplacesCertID: "foo"})
+	require.NoError(t, err)
+
+	assert.Nil(t, order)
+}
+
+func Test_matchOrderOptions(t *testing.T) {
+	notAfter := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		desc     string
+		order    acme.Order
+		opts     *api.OrderOptions
+		ari      bool
+		expected bool
+	}{
+		{
+			desc:     "no options",
+			order:    acme.Order{},
+			expected: true,
+		},
+		{
+			desc:     "same options",
+			order:    acme.Order{Profile: "shortlived", Replaces: "foo", NotAfter: "2025-03-01T12:00:00Z"},
+			opts:     &api.OrderOptions{Profile: "shortlived", ReplacesCertID: "foo", NotAfter: notAfter},
+			ari:      true,
+			expected: true,
+		},
+		{
+			desc:     "replaced certificate without ARI",
+			order:    acme.Order{},
+			opts:     &api.OrderOptions{ReplacesCertID: "foo"},
+			expected: true,
+		},
+		{
+			desc:  "different replaced certificate",
+			order: acme.Order{Replaces: "bar"},
+			opts:  &api.OrderOptions{ReplacesCertID: "foo"},
+			ari:   true,
+		},
+		{
+			desc:  "order replacing a certificate",
+			order: acme.Order{Replaces: "bar"},
+			opts:  &api.OrderOptions{},
+			ari:   true,
+		},
+		{
+			desc:  "different notAfter",
+			order: acme.Order{NotAfter: "2025-03-02T12:00:00Z"},
+			opts:  &api.OrderOptions{NotAfter: notAfter},
+		},
+		{
+			desc:  "order with a notBefore",
+			order: acme.Order{NotBefore: "2025-03-01T12:00:00Z"},
+			opts:  &api.OrderOptions{},
+		},
+		{
+			desc:  "different profile",
+			order: acme.Order{Profile: "classic"},
+			opts:  &api.OrderOptions{Profile: "shortlived"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, matchOrderOptions(test.order, test.opts, test.ari))
+		})
+	}
+}
+
+type opaqueSigner struct {
+	crypto.Signer
+}
+
+func TestCertifier_createCSR_signer(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	certifier := NewCertifier(nil, &resolverMock{}, CertifierOptions{KeyType: certcrypto.EC256})
+
+	order := acme.ExtendedOrder{Order: acme.Order{
+		Identifiers: []acme.Identifier{{Type: "dns", Value: "example.com"}, {Type: "dns", Value: "www.example.com"}},
+	}}
+
+	csrDER, err := certifier.createCSR([]string{"example.com", "www.example.com"}, order, opaqueSigner{Signer: privateKey}, false, nil)
+	require.NoError(t, err)
+
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	require.NoError(t, err)
+
+	require.NoError(t, csr.CheckSignature())
+
+	assert.Equal(t, privateKey.Public(), csr.PublicKey)
+	assert.Equal(t, []string{"example.com", "www.example.com"}, csr.DNSNames)
+}
+
+func TestCertifier_Obtain_privateKeyAndSigner(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	certifier := NewCertifier(nil, &resolverMock{}, CertifierOptions{KeyType: certcrypto.EC256})
+
+	_, err = certifier.Obtain(ObtainRequest{
+		Domains:    []string{"example.com"},
+		PrivateKey: privateKey,
+		Signer:     privateKey,
+	})
+	require.EqualError(t, err, "cannot obtain a certificate with both a private key and a signer")
+}
+
+func TestCertifier_checkCAA_noIdentities(t *testing.T) {
+	_, apiURL := tester.SetupFakeAPI(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", "", key)
+	require.NoError(t, err)
+
+	certifier := NewCertifier(core, &resolverMock{}, CertifierOptions{KeyType: certcrypto.RSA2048, CAAPreCheck: true})
+
+	// the CA does not provide its CAA identities: no DNS queries.
+	require.NoError(t, certifier.checkCAA([]string{"example.com"}))
+}
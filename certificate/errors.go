@@ -3,6 +3,8 @@ package certificate
 import (
 	"errors"
 	"fmt"
+
+	"github.com/go-acme/lego/v4/acme"
 )
 
 type obtainError struct {
@@ -28,12 +30,36 @@ func (e *obtainError) Join() error {
 
 	var err error
 	for d, e := range e.data {
-		err = errors.Join(err, fmt.Errorf("%s: %w", d, e))
+		err = errors.Join(err, &acme.IdentifierError{Identifier: d, Err: e})
 	}
 
 	return fmt.Errorf("error: one or more domains had a problem:\n%w", err)
 }
 
+// OrderError an error that occurred after the creation of an order,
+// the URL of the order can be used to diagnose the failure.
+type OrderError struct {
+	URL string
+	Err error
+}
+
+func (e *OrderError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *OrderError) Unwrap() error {
+	return e.Err
+}
+
+// newOrderError wraps the error with the URL of the order, nil if the error is nil.
+func newOrderError(order acme.ExtendedOrder, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &OrderError{URL: order.Location, Err: err}
+}
+
 type domainError struct {
 	Domain string
 	Error  error
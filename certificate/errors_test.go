@@ -4,6 +4,8 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -67,3 +69,25 @@ func Test_obtainError_Join_same_domain(t *testing.T) {
 	ca := &CarrotError{}
 	require.ErrorAs(t, err, &ca)
 }
+
+func Test_newOrderError(t *testing.T) {
+	failures := newObtainError()
+
+	failures.Add("example.com", &TomatoError{})
+
+	order := acme.ExtendedOrder{Location: "https://example.com/acme/order/123"}
+
+	err := newOrderError(order, failures.Join())
+
+	var orderErr *OrderError
+	require.ErrorAs(t, err, &orderErr)
+	assert.Equal(t, "https://example.com/acme/order/123", orderErr.URL)
+	assert.Equal(t, failures.Join().Error(), err.Error())
+
+	to := &TomatoError{}
+	require.ErrorAs(t, err, &to)
+}
+
+func Test_newOrderError_no_error(t *testing.T) {
+	require.NoError(t, newOrderError(acme.ExtendedOrder{Location: "https://example.com/acme/order/123"}, nil))
+}
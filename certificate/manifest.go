@@ -0,0 +1,110 @@
+package certificate
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+)
+
+// Manifest a machine-readable description of an issued certificate,
+// to inventory the certificates without parsing the PEM files.
+type Manifest struct {
+	Domain string `json:"domain"`
+
+	// Serial the serial number of the certificate (hexadecimal).
+	Serial string `json:"serial"`
+
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+
+	// SANs the DNS names and the IP addresses of the certificate.
+	SANs []string `json:"sans"`
+
+	// Chain the certificates of the chain, from the leaf certificate to the root certificate (if provided by the CA).
+	Chain []ManifestCertificate `json:"chain"`
+
+	// ARICertID the certificate identifier used by the ACME Renewal Information (ARI).
+	ARICertID string `json:"ariCertId,omitempty"`
+
+	Profile  string `json:"profile,omitempty"`
+	OrderURL string `json:"orderUrl,omitempty"`
+	CertURL  string `json:"certUrl,omitempty"`
+
+	// Challenges the challenges solved to obtain the certificate,
+	// the domains with an authorization already valid are not listed (the providers are not filled by NewManifest, the caller knows them).
+	Challenges []ManifestChallenge `json:"challenges,omitempty"`
+}
+
+// ManifestCertificate a certificate of the chain of a Manifest.
+type ManifestCertificate struct {
+	Subject string `json:"subject"`
+	Issuer  string `json:"issuer"`
+
+	// SHA256 the SHA-256 fingerprint of the certificate (hexadecimal).
+	SHA256 string `json:"sha256"`
+}
+
+// ManifestChallenge a challenge solved to obtain the certificate of a Manifest.
+type ManifestChallenge struct {
+	// Type the challenge type (ex: http-01, dns-01).
+	Type string `json:"type"`
+
+	// Provider the name of the challenge provider (ex: webroot, cloudflare).
+	Provider string `json:"provider,omitempty"`
+
+	// Domain the domain validated by the challenge.
+	Domain string `json:"domain,omitempty"`
+}
+
+// NewManifest creates the manifest of a certificate resource.
+func NewManifest(certRes *Resource) (*Manifest, error) {
+	chain, err := certcrypto.ParseCertificateChain(certRes.Certificate, certRes.IssuerCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] manifest: %w", certRes.Domain, err)
+	}
+
+	leaf := chain.Leaf
+
+	manifest := &Manifest{
+		Domain:    certRes.Domain,
+		Serial:    hex.EncodeToString(leaf.SerialNumber.Bytes()),
+		NotBefore: leaf.NotBefore,
+		NotAfter:  leaf.NotAfter,
+		SANs:      certcrypto.ExtractDomains(leaf),
+		Profile:   certRes.Profile,
+		OrderURL:  certRes.OrderURL,
+		CertURL:   certRes.CertURL,
+	}
+
+	if len(leaf.AuthorityKeyId) > 0 {
+		manifest.ARICertID, err = MakeARICertID(leaf)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] manifest: %w", certRes.Domain, err)
+		}
+	}
+
+	for _, chlg := range certRes.Challenges {
+		manifest.Challenges = append(manifest.Challenges, ManifestChallenge{Type: chlg.Type.String(), Domain: chlg.Domain})
+	}
+
+	certificates := append([]*x509.Certificate{leaf}, chain.Intermediates...)
+	if chain.Root != nil {
+		certificates = append(certificates, chain.Root)
+	}
+
+	for _, cert := range certificates {
+		sum := sha256.Sum256(cert.Raw)
+
+		manifest.Chain = append(manifest.Chain, ManifestCertificate{
+			Subject: cert.Subject.String(),
+			Issuer:  cert.Issuer.String(),
+			SHA256:  hex.EncodeToString(sum[:]),
+		})
+	}
+
+	return manifest, nil
+}
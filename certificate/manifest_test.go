@@ -0,0 +1,104 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewManifest(t *testing.T) {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		SubjectKeyId:          []byte{1, 2, 3, 4},
+	}
+
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, rootKey.Public(), rootKey)
+	require.NoError(t, err)
+
+	root, err := x509.ParseCertificate(rootDER)
+	require.NoError(t, err)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	notBefore := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(0x0a0b0c),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com", "www.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("192.0.2.1")},
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(90 * 24 * time.Hour),
+	}
+
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, root, leafKey.Public(), rootKey)
+	require.NoError(t, err)
+
+	certRes := &Resource{
+		Domain:            "example.com",
+		CertURL:           "https://example.com/cert/1",
+		OrderURL:          "https://example.com/order/1",
+		Profile:           "shortlived",
+		Certificate:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER}),
+		IssuerCertificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootDER}),
+		Challenges: []SolvedChallenge{
+			{Domain: "example.com", Type: challenge.DNS01},
+			{Domain: "192.0.2.1", Type: challenge.HTTP01},
+		},
+	}
+
+	manifest, err := NewManifest(certRes)
+	require.NoError(t, err)
+
+	leafSum := sha256.Sum256(leafDER)
+	rootSum := sha256.Sum256(rootDER)
+
+	expected := &Manifest{
+		Domain:    "example.com",
+		Serial:    "0a0b0c",
+		NotBefore: notBefore,
+		NotAfter:  notBefore.Add(90 * 24 * time.Hour),
+		SANs:      []string{"example.com", "www.example.com", "192.0.2.1"},
+		Chain: []ManifestCertificate{
+			{Subject: "CN=example.com", Issuer: "CN=Test Root", SHA256: hex.EncodeToString(leafSum[:])},
+			{Subject: "CN=Test Root", Issuer: "CN=Test Root", SHA256: hex.EncodeToString(rootSum[:])},
+		},
+		ARICertID: "AQIDBA.CgsM",
+		Profile:   "shortlived",
+		OrderURL:  "https://example.com/order/1",
+		CertURL:   "https://example.com/cert/1",
+		Challenges: []ManifestChallenge{
+			{Type: "dns-01", Domain: "example.com"},
+			{Type: "http-01", Domain: "192.0.2.1"},
+		},
+	}
+
+	assert.Equal(t, expected, manifest)
+}
+
+func TestNewManifest_invalidCertificate(t *testing.T) {
+	_, err := NewManifest(&Resource{Domain: "example.com", Certificate: []byte("foo")})
+	require.Error(t, err)
+}
@@ -0,0 +1,295 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/log"
+	"golang.org/x/crypto/ocsp"
+)
+
+// ErrOCSPRevoked is returned by OCSPChecker.WaitForGoodStatus when the certificate is revoked.
+var ErrOCSPRevoked = errors.New("certificate revoked")
+
+// minOCSPWaitInterval the minimum interval between two requests of OCSPChecker.WaitForGoodStatus.
+const minOCSPWaitInterval = time.Second
+
+type ocspCacheEntry struct {
+	raw      []byte
+	response *ocsp.Response
+}
+
+// OCSPChecker gets the OCSP responses of certificates.
+//
+// The responses are cached until their NextUpdate,
+// and all the responders of a certificate are tried, in order, until one of them responds.
+type OCSPChecker struct {
+	httpClient      *http.Client
+	now             func() time.Time
+	minWaitInterval time.Duration
+
+	mu    sync.Mutex
+	cache map[string]ocspCacheEntry
+}
+
+// NewOCSPChecker creates a new OCSPChecker.
+func NewOCSPChecker(httpClient *http.Client) *OCSPChecker {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &OCSPChecker{
+		httpClient:      httpClient,
+		now:             time.Now,
+		minWaitInterval: minOCSPWaitInterval,
+		cache:           make(map[string]ocspCacheEntry),
+	}
+}
+
+// Check takes a PEM encoded cert or cert bundle returning the raw OCSP response,
+// the parsed response, and an error, if any.
+// A cached response is returned if it is still valid (before its NextUpdate).
+//
+// If the bundle only contains the issued certificate,
+// the issuer certificate is fetched from the IssuingCertificateURLs in the certificate.
+func (o *OCSPChecker) Check(bundle []byte) ([]byte, *ocsp.Response, error) {
+	return o.check(bundle, true)
+}
+
+// WaitForGoodStatus polls the OCSP responders, every interval, until the certificate has a good status.
+// It is intended to be used after the issuance of a certificate,
+// to detect early the propagation problems on the CA side.
+//
+// The cache is bypassed.
+// The interval cannot be less than 1 second.
+// ErrOCSPRevoked is returned if the certificate is revoked.
+func (o *OCSPChecker) WaitForGoodStatus(ctx context.Context, bundle []byte, interval time.Duration) (*ocsp.Response, error) {
+	interval = max(interval, o.minWaitInterval)
+
+	var lastErr error
+
+	for {
+		_, response, err := o.check(bundle, false)
+
+		switch {
+		case err != nil:
+			lastErr = err
+		case response.Status == ocsp.Good:
+			return response, nil
+		case response.Status == ocsp.Revoked:
+			return response, ErrOCSPRevoked
+		default:
+			lastErr = errors.New("unknown OCSP status")
+		}
+
+		log.Infof("acme: Waiting for a good OCSP status: %v", lastErr)
+
+		timer := time.NewTimer(interval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("wait for a good OCSP status: %w: %w", ctx.Err(), lastErr)
+		case <-timer.C:
+		}
+	}
+}
+
+// NeedsOCSPRefresh reports whether a stored OCSP response (ex: a staple file) of the certificate must be refreshed:
+// the response is for another certificate, it has no NextUpdate,
+// or more than half of its validity period (ThisUpdate to NextUpdate) has elapsed.
+func NeedsOCSPRefresh(response *ocsp.Response, cert *x509.Certificate, now time.Time) bool {
+	if response.SerialNumber == nil || response.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		return true
+	}
+
+	// Without NextUpdate, newer information is always available (RFC 6960 Section 2.4).
+	if response.NextUpdate.IsZero() {
+		return true
+	}
+
+	refreshAt := response.ThisUpdate.Add(response.NextUpdate.Sub(response.ThisUpdate) / 2)
+
+	return !now.Before(refreshAt)
+}
+
+func (o *OCSPChecker) check(bundle []byte, useCache bool) ([]byte, *ocsp.Response, error) {
+	certificates, err := certcrypto.ParsePEMBundle(bundle)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// We expect the certificate slice to be ordered downwards the chain.
+	// SRV CRT -> CA. We need to pull the leaf and issuer certs out of it,
+	// which should always be the first two certificates.
+	// If there's no OCSP server listed in the leaf cert, there's nothing to do.
+	// And if we have only one certificate so far, we need to get the issuer cert.
+
+	issuedCert := certificates[0]
+
+	if len(issuedCert.OCSPServer) == 0 {
+		return nil, nil, errors.New("no OCSP server specified in cert")
+	}
+
+	if len(certificates) == 1 {
+		issuerCert, errI := o.fetchIssuer(issuedCert)
+		if errI != nil {
+			return nil, nil, errI
+		}
+
+		certificates = append(certificates, issuerCert)
+	}
+
+	issuerCert := certificates[1]
+
+	key := ocspCacheKey(issuedCert, issuerCert)
+
+	if useCache {
+		if entry, ok := o.cached(key); ok {
+			return entry.raw, entry.response, nil
+		}
+	}
+
+	// Finally kick off the OCSP request.
+	ocspReq, err := ocsp.CreateRequest(issuedCert, issuerCert, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var errs []error
+
+	for _, server := range issuedCert.OCSPServer {
+		raw, response, errR := o.request(server, ocspReq, issuerCert)
+		if errR != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", server, errR))
+			continue
+		}
+
+		o.store(key, ocspCacheEntry{raw: raw, response: response})
+
+		return raw, response, nil
+	}
+
+	return nil, nil, errors.Join(errs...)
+}
+
+// fetchIssuer fetches the issuer certificate from the IssuingCertificateURLs of the certificate.
+func (o *OCSPChecker) fetchIssuer(cert *x509.Certificate) (*x509.Certificate, error) {
+	if len(cert.IssuingCertificateURL) == 0 {
+		return nil, errors.New("no issuing certificate URL")
+	}
+
+	var errs []error
+
+	for _, issuerURL := range cert.IssuingCertificateURL {
+		issuerBytes, err := o.get(issuerURL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", issuerURL, err))
+			continue
+		}
+
+		issuerCert, err := x509.ParseCertificate(issuerBytes)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", issuerURL, err))
+			continue
+		}
+
+		return issuerCert, nil
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+func (o *OCSPChecker) get(url string) ([]byte, error) {
+	resp, err := o.httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(http.MaxBytesReader(nil, resp.Body, maxBodySize))
+}
+
+func (o *OCSPChecker) request(server string, ocspReq []byte, issuerCert *x509.Certificate) ([]byte, *ocsp.Response, error) {
+	resp, err := o.httpClient.Post(server, "application/ocsp-request", bytes.NewReader(ocspReq))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	raw, err := io.ReadAll(http.MaxBytesReader(nil, resp.Body, maxBodySize))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response, err := ocsp.ParseResponse(raw, issuerCert)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return raw, response, nil
+}
+
+func (o *OCSPChecker) cached(key string) (ocspCacheEntry, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entry, ok := o.cache[key]
+	if !ok {
+		return ocspCacheEntry{}, false
+	}
+
+	if !o.now().Before(entry.response.NextUpdate) {
+		delete(o.cache, key)
+		return ocspCacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+func (o *OCSPChecker) store(key string, entry ocspCacheEntry) {
+	// Without NextUpdate, newer information is always available (RFC 6960 Section 2.4).
+	if entry.response.NextUpdate.IsZero() {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := o.now()
+
+	for k, e := range o.cache {
+		if !now.Before(e.response.NextUpdate) {
+			delete(o.cache, k)
+		}
+	}
+
+	o.cache[key] = entry
+}
+
+// ocspCacheKey identifies a certificate by its issuer and its serial number.
+func ocspCacheKey(cert, issuer *x509.Certificate) string {
+	issuerHash := sha256.Sum256(issuer.Raw)
+
+	return hex.EncodeToString(issuerHash[:]) + ":" + cert.SerialNumber.Text(16)
+}
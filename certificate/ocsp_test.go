@@ -0,0 +1,306 @@
+package certificate
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+type ocspFixture struct {
+	issuer    *x509.Certificate
+	issuerKey crypto.Signer
+	leaf      *x509.Certificate
+	bundle    []byte
+}
+
+func newOCSPFixture(t *testing.T, ocspServers ...string) *ocspFixture {
+	t.Helper()
+
+	issuerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	issuerTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+
+	issuerDER, err := x509.CreateCertificate(rand.Reader, issuerTemplate, issuerTemplate, issuerKey.Public(), issuerKey)
+	require.NoError(t, err)
+
+	issuer, err := x509.ParseCertificate(issuerDER)
+	require.NoError(t, err)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		OCSPServer:   ocspServers,
+	}
+
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, issuer, leafKey.Public(), issuerKey)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(leafDER)
+	require.NoError(t, err)
+
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER})
+	bundle = append(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: issuerDER})...)
+
+	return &ocspFixture{issuer: issuer, issuerKey: issuerKey, leaf: leaf, bundle: bundle}
+}
+
+func (f *ocspFixture) respond(w http.ResponseWriter, status int, nextUpdate time.Time) {
+	raw, err := ocsp.CreateResponse(f.issuer, f.issuer, ocsp.Response{
+		Status:       status,
+		SerialNumber: f.leaf.SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Minute),
+		NextUpdate:   nextUpdate,
+		RevokedAt:    time.Now().Add(-time.Minute),
+	}, f.issuerKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(raw)
+}
+
+func TestOCSPChecker_Check(t *testing.T) {
+	var failingCalls, calls atomic.Int32
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		failingCalls.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(failing.Close)
+
+	var responder http.HandlerFunc
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		responder(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	fixture := newOCSPFixture(t, failing.URL, server.URL)
+
+	now := time.Now()
+
+	responder = func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if _, err = ocsp.ParseRequest(body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		fixture.respond(w, ocsp.Good, now.Add(time.Hour))
+	}
+
+	checker := NewOCSPChecker(server.Client())
+	checker.now = func() time.Time { return now }
+
+	raw, response, err := checker.Check(fixture.bundle)
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, raw)
+	assert.Equal(t, ocsp.Good, response.Status)
+	assert.EqualValues(t, 1, failingCalls.Load())
+	assert.EqualValues(t, 1, calls.Load())
+
+	// cached until NextUpdate.
+	_, response, err = checker.Check(fixture.bundle)
+	require.NoError(t, err)
+
+	assert.Equal(t, ocsp.Good, response.Status)
+	assert.EqualValues(t, 1, calls.Load())
+
+	// expired.
+	checker.now = func() time.Time { return now.Add(2 * time.Hour) }
+
+	_, _, err = checker.Check(fixture.bundle)
+	require.NoError(t, err)
+
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestOCSPChecker_Check_allRespondersFail(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(failing.Close)
+
+	fixture := newOCSPFixture(t, failing.URL+"/a", failing.URL+"/b")
+
+	checker := NewOCSPChecker(failing.Client())
+
+	_, _, err := checker.Check(fixture.bundle)
+	require.Error(t, err)
+
+	assert.ErrorContains(t, err, failing.URL+"/a: unexpected status code: 503")
+	assert.ErrorContains(t, err, failing.URL+"/b: unexpected status code: 503")
+}
+
+func TestOCSPChecker_WaitForGoodStatus(t *testing.T) {
+	var calls atomic.Int32
+
+	var fixture *ocspFixture
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) < 3 {
+			fixture.respond(w, ocsp.Unknown, time.Now().Add(time.Hour))
+			return
+		}
+
+		fixture.respond(w, ocsp.Good, time.Now().Add(time.Hour))
+	}))
+	t.Cleanup(server.Close)
+
+	fixture = newOCSPFixture(t, server.URL)
+
+	checker := NewOCSPChecker(server.Client())
+	checker.minWaitInterval = 10 * time.Millisecond
+
+	response, err := checker.WaitForGoodStatus(context.Background(), fixture.bundle, 10*time.Millisecond)
+	require.NoError(t, err)
+
+	assert.Equal(t, ocsp.Good, response.Status)
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+func TestOCSPChecker_WaitForGoodStatus_revoked(t *testing.T) {
+	var fixture *ocspFixture
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fixture.respond(w, ocsp.Revoked, time.Now().Add(time.Hour))
+	}))
+	t.Cleanup(server.Close)
+
+	fixture = newOCSPFixture(t, server.URL)
+
+	checker := NewOCSPChecker(server.Client())
+
+	_, err := checker.WaitForGoodStatus(context.Background(), fixture.bundle, 10*time.Millisecond)
+	require.ErrorIs(t, err, ErrOCSPRevoked)
+}
+
+func TestOCSPChecker_WaitForGoodStatus_timeout(t *testing.T) {
+	var fixture *ocspFixture
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fixture.respond(w, ocsp.Unknown, time.Now().Add(time.Hour))
+	}))
+	t.Cleanup(server.Close)
+
+	fixture = newOCSPFixture(t, server.URL)
+
+	checker := NewOCSPChecker(server.Client())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := checker.WaitForGoodStatus(ctx, fixture.bundle, 10*time.Millisecond)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.ErrorContains(t, err, "unknown OCSP status")
+}
+
+func TestOCSPChecker_WaitForGoodStatus_minInterval(t *testing.T) {
+	var calls atomic.Int32
+
+	var fixture *ocspFixture
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		fixture.respond(w, ocsp.Unknown, time.Now().Add(time.Hour))
+	}))
+	t.Cleanup(server.Close)
+
+	fixture = newOCSPFixture(t, server.URL)
+
+	checker := NewOCSPChecker(server.Client())
+	checker.minWaitInterval = 100 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+
+	_, err := checker.WaitForGoodStatus(ctx, fixture.bundle, 0)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.LessOrEqual(t, calls.Load(), int32(3))
+}
+
+func TestNeedsOCSPRefresh(t *testing.T) {
+	fixture := newOCSPFixture(t)
+
+	now := time.Now()
+
+	testCases := []struct {
+		desc     string
+		response *ocsp.Response
+		expected bool
+	}{
+		{
+			desc:     "fresh",
+			response: &ocsp.Response{SerialNumber: fixture.leaf.SerialNumber, ThisUpdate: now.Add(-time.Hour), NextUpdate: now.Add(3 * time.Hour)},
+		},
+		{
+			desc:     "half of the validity period elapsed",
+			response: &ocsp.Response{SerialNumber: fixture.leaf.SerialNumber, ThisUpdate: now.Add(-3 * time.Hour), NextUpdate: now.Add(time.Hour)},
+			expected: true,
+		},
+		{
+			desc:     "expired",
+			response: &ocsp.Response{SerialNumber: fixture.leaf.SerialNumber, ThisUpdate: now.Add(-3 * time.Hour), NextUpdate: now.Add(-time.Hour)},
+			expected: true,
+		},
+		{
+			desc:     "no next update",
+			response: &ocsp.Response{SerialNumber: fixture.leaf.SerialNumber, ThisUpdate: now.Add(-time.Minute)},
+			expected: true,
+		},
+		{
+			desc:     "other certificate",
+			response: &ocsp.Response{SerialNumber: big.NewInt(1), ThisUpdate: now.Add(-time.Hour), NextUpdate: now.Add(3 * time.Hour)},
+			expected: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, NeedsOCSPRefresh(test.response, fixture.leaf, now))
+		})
+	}
+}
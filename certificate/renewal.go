@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"time"
 
 	"github.com/go-acme/lego/v4/acme"
@@ -84,6 +85,10 @@ func (c *Certifier) GetRenewalInfo(req RenewalInfoRequest) (*RenewalInfoResponse
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("renewalInfo[get]: unexpected status code: %d", resp.StatusCode)
+	}
+
 	var info RenewalInfoResponse
 	err = json.NewDecoder(resp.Body).Decode(&info)
 	if err != nil {
@@ -91,7 +96,7 @@ func (c *Certifier) GetRenewalInfo(req RenewalInfoRequest) (*RenewalInfoResponse
 	}
 
 	if retry := resp.Header.Get("Retry-After"); retry != "" {
-		info.RetryAfter, err = time.ParseDuration(retry + "s")
+		info.RetryAfter, err = acme.ParseRetryAfter(retry, time.Now())
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,379 @@
+package certificate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme"
+	"golang.org/x/net/publicsuffix"
+)
+
+// RateLimit is a limit of the CA: at most Count events during the sliding window Period.
+type RateLimit struct {
+	Count  int
+	Period time.Duration
+}
+
+// RateLimits the limits of the CA tracked by the RenewalScheduler.
+// A limit with a zero Count is not checked.
+type RateLimits struct {
+	// NewOrdersPerAccount the number of new orders per account.
+	NewOrdersPerAccount RateLimit
+	// CertificatesPerRegisteredDomain the number of certificates per registered domain (eTLD+1).
+	// The renewals (same set of domains as a previous certificate) are exempt.
+	CertificatesPerRegisteredDomain RateLimit
+	// DuplicateCertificates the number of certificates for the exact same set of domains.
+	DuplicateCertificates RateLimit
+	// FailedValidations the number of failed validations per account and per domain.
+	FailedValidations RateLimit
+}
+
+// LERateLimits the Let's Encrypt rate limits.
+// https://letsencrypt.org/docs/rate-limits/
+var LERateLimits = RateLimits{
+	NewOrdersPerAccount:             RateLimit{Count: 300, Period: 3 * time.Hour},
+	CertificatesPerRegisteredDomain: RateLimit{Count: 50, Period: 7 * 24 * time.Hour},
+	DuplicateCertificates:           RateLimit{Count: 5, Period: 7 * 24 * time.Hour},
+	FailedValidations:               RateLimit{Count: 5, Period: time.Hour},
+}
+
+// IssuanceEvent an obtain call tracked by the RenewalScheduler.
+type IssuanceEvent struct {
+	Account string    `json:"account"`
+	Domains []string  `json:"domains"`
+	Time    time.Time `json:"time"`
+	// Issued is true if a certificate has been issued.
+	Issued bool `json:"issued,omitempty"`
+	// FailedDomains the domains which failed the validation.
+	FailedDomains []string `json:"failedDomains,omitempty"`
+}
+
+// IssuanceStore stores the events of a RenewalScheduler.
+type IssuanceStore interface {
+	Load() ([]IssuanceEvent, error)
+	Save(events []IssuanceEvent) error
+}
+
+// RateLimitError is returned by the RenewalScheduler when an obtain call would exceed a rate limit of the CA.
+type RateLimitError struct {
+	Limit      string
+	RetryAfter time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit %q exceeded: retry after %s", e.Limit, e.RetryAfter.Format(time.RFC3339))
+}
+
+// RenewalScheduler tracks the orders and the issued certificates to respect the rate limits of the CA.
+// The obtain calls that would exceed a limit are rejected (RateLimitError),
+// Next can be used to schedule them.
+type RenewalScheduler struct {
+	store  IssuanceStore
+	limits RateLimits
+
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// NewRenewalScheduler creates a new RenewalScheduler.
+func NewRenewalScheduler(store IssuanceStore, limits RateLimits) *RenewalScheduler {
+	return &RenewalScheduler{
+		store:  store,
+		limits: limits,
+		now:    time.Now,
+	}
+}
+
+// Obtain obtains a certificate (see Certifier.Obtain) if the rate limits allow it, and records the result.
+func (s *RenewalScheduler) Obtain(certifier *Certifier, request ObtainRequest) (*Resource, error) {
+	account := certifier.core.GetAccountURL()
+	domains := sanitizeDomain(request.Domains)
+
+	if err := s.Check(account, domains); err != nil {
+		return nil, err
+	}
+
+	cert, err := certifier.Obtain(request)
+
+	errR := s.Record(account, domains, err)
+	if errR != nil {
+		return cert, errors.Join(err, errR)
+	}
+
+	return cert, err
+}
+
+// Check returns a RateLimitError if an obtain call for the domains would exceed a rate limit.
+func (s *RenewalScheduler) Check(account string, domains []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events, err := s.store.Load()
+	if err != nil {
+		return fmt.Errorf("renewal scheduler: load: %w", err)
+	}
+
+	now := s.now()
+
+	next, limit := s.next(events, account, normalizeDomains(domains), now)
+	if next.After(now) {
+		return &RateLimitError{Limit: limit, RetryAfter: next}
+	}
+
+	return nil
+}
+
+// Next returns the time from which an obtain call for the domains is allowed by the rate limits
+// (the current time if the call is allowed now).
+func (s *RenewalScheduler) Next(account string, domains []string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events, err := s.store.Load()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("renewal scheduler: load: %w", err)
+	}
+
+	now := s.now()
+
+	next, _ := s.next(events, account, normalizeDomains(domains), now)
+	if next.Before(now) {
+		return now, nil
+	}
+
+	return next, nil
+}
+
+// Record records the result of an obtain call.
+// The domains which failed the validation are extracted from the error (see acme.ProblemsByIdentifier).
+func (s *RenewalScheduler) Record(account string, domains []string, obtainErr error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events, err := s.store.Load()
+	if err != nil {
+		return fmt.Errorf("renewal scheduler: load: %w", err)
+	}
+
+	now := s.now()
+
+	event := IssuanceEvent{
+		Account: account,
+		Domains: normalizeDomains(domains),
+		Time:    now,
+		Issued:  obtainErr == nil,
+	}
+
+	for identifier := range acme.ProblemsByIdentifier(obtainErr) {
+		if identifier != "" {
+			event.FailedDomains = append(event.FailedDomains, identifier)
+		}
+	}
+
+	slices.Sort(event.FailedDomains)
+
+	// Removes the events older than the longest period.
+	maxPeriod := max(s.limits.NewOrdersPerAccount.Period, s.limits.CertificatesPerRegisteredDomain.Period,
+		s.limits.DuplicateCertificates.Period, s.limits.FailedValidations.Period)
+
+	events = slices.DeleteFunc(events, func(e IssuanceEvent) bool {
+		return e.Time.Before(now.Add(-maxPeriod))
+	})
+
+	err = s.store.Save(append(events, event))
+	if err != nil {
+		return fmt.Errorf("renewal scheduler: save: %w", err)
+	}
+
+	return nil
+}
+
+// next returns the time from which the obtain call is allowed, and the name of the most restrictive limit.
+func (s *RenewalScheduler) next(events []IssuanceEvent, account string, domains []string, now time.Time) (time.Time, string) {
+	var next time.Time
+	var limit string
+
+	update := func(name string, t time.Time) {
+		if t.After(next) {
+			next = t
+			limit = name
+		}
+	}
+
+	var orders, duplicates []time.Time
+	renewal := false
+
+	for _, event := range events {
+		if event.Account == account {
+			orders = append(orders, event.Time)
+		}
+
+		if event.Issued && slices.Equal(event.Domains, domains) {
+			duplicates = append(duplicates, event.Time)
+			renewal = true
+		}
+	}
+
+	update("new orders per account", nextAllowed(orders, s.limits.NewOrdersPerAccount, now))
+	update("duplicate certificates", nextAllowed(duplicates, s.limits.DuplicateCertificates, now))
+
+	for _, domain := range domains {
+		var failures []time.Time
+
+		for _, event := range events {
+			if event.Account == account && slices.Contains(event.FailedDomains, domain) {
+				failures = append(failures, event.Time)
+			}
+		}
+
+		update("failed validations for "+domain, nextAllowed(failures, s.limits.FailedValidations, now))
+	}
+
+	if renewal {
+		return next, limit
+	}
+
+	for _, registered := range registeredDomains(domains) {
+		var certs []time.Time
+
+		for _, event := range events {
+			if event.Issued && slices.Contains(registeredDomains(event.Domains), registered) {
+				certs = append(certs, event.Time)
+			}
+		}
+
+		update("certificates per registered domain "+registered, nextAllowed(certs, s.limits.CertificatesPerRegisteredDomain, now))
+	}
+
+	return next, limit
+}
+
+// nextAllowed returns the time from which a new event is allowed by the limit.
+func nextAllowed(times []time.Time, limit RateLimit, now time.Time) time.Time {
+	if limit.Count <= 0 {
+		return time.Time{}
+	}
+
+	var inWindow []time.Time
+
+	for _, t := range times {
+		if t.After(now.Add(-limit.Period)) {
+			inWindow = append(inWindow, t)
+		}
+	}
+
+	if len(inWindow) < limit.Count {
+		return time.Time{}
+	}
+
+	slices.SortFunc(inWindow, func(a, b time.Time) int { return a.Compare(b) })
+
+	return inWindow[len(inWindow)-limit.Count].Add(limit.Period)
+}
+
+func normalizeDomains(domains []string) []string {
+	var normalized []string
+
+	for _, domain := range domains {
+		normalized = append(normalized, strings.ToLower(strings.TrimSuffix(domain, ".")))
+	}
+
+	slices.Sort(normalized)
+
+	return slices.Compact(normalized)
+}
+
+// registeredDomains returns the registered domains (eTLD+1) of the domains.
+// The IP addresses and the public suffixes are kept as is.
+func registeredDomains(domains []string) []string {
+	var registered []string
+
+	for _, domain := range domains {
+		etldPlusOne, err := publicsuffix.EffectiveTLDPlusOne(strings.TrimPrefix(domain, "*."))
+		if err != nil {
+			etldPlusOne = domain
+		}
+
+		if !slices.Contains(registered, etldPlusOne) {
+			registered = append(registered, etldPlusOne)
+		}
+	}
+
+	return registered
+}
+
+// MemoryIssuanceStore an IssuanceStore in memory.
+type MemoryIssuanceStore struct {
+	events []IssuanceEvent
+}
+
+// Load loads the events.
+func (m *MemoryIssuanceStore) Load() ([]IssuanceEvent, error) {
+	return slices.Clone(m.events), nil
+}
+
+// Save saves the events.
+func (m *MemoryIssuanceStore) Save(events []IssuanceEvent) error {
+	m.events = slices.Clone(events)
+	return nil
+}
+
+// FileIssuanceStore an IssuanceStore in a JSON file.
+type FileIssuanceStore struct {
+	path string
+}
+
+// NewFileIssuanceStore creates a new FileIssuanceStore.
+func NewFileIssuanceStore(path string) *FileIssuanceStore {
+	return &FileIssuanceStore{path: path}
+}
+
+// Load loads the events.
+func (f *FileIssuanceStore) Load() ([]IssuanceEvent, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var events []IssuanceEvent
+
+	err = json.Unmarshal(data, &events)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Save saves the events.
+func (f *FileIssuanceStore) Save(events []IssuanceEvent) error {
+	data, err := json.MarshalIndent(events, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(f.path), 0o700)
+	if err != nil {
+		return err
+	}
+
+	tmp := f.path + ".tmp"
+
+	err = os.WriteFile(tmp, data, 0o600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, f.path)
+}
@@ -0,0 +1,135 @@
+package certificate
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRenewalScheduler(t *testing.T, store IssuanceStore, now time.Time) *RenewalScheduler {
+	t.Helper()
+
+	scheduler := NewRenewalScheduler(store, LERateLimits)
+	scheduler.now = func() time.Time { return now }
+
+	return scheduler
+}
+
+func TestRenewalScheduler_duplicateCertificates(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	scheduler := newTestRenewalScheduler(t, &MemoryIssuanceStore{}, now)
+
+	domains := []string{"b.example.com", "a.example.com"}
+
+	for range 5 {
+		require.NoError(t, scheduler.Check("acc", domains))
+		require.NoError(t, scheduler.Record("acc", domains, nil))
+	}
+
+	err := scheduler.Check("acc", []string{"A.example.com", "b.example.com"})
+
+	var rateLimitErr *RateLimitError
+	require.ErrorAs(t, err, &rateLimitErr)
+
+	assert.Equal(t, "duplicate certificates", rateLimitErr.Limit)
+	assert.Equal(t, now.Add(LERateLimits.DuplicateCertificates.Period), rateLimitErr.RetryAfter)
+
+	// another set of domains is allowed.
+	require.NoError(t, scheduler.Check("acc", []string{"a.example.com"}))
+}
+
+func TestRenewalScheduler_certificatesPerRegisteredDomain(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	store := &MemoryIssuanceStore{}
+
+	var events []IssuanceEvent
+	for i := range 50 {
+		events = append(events, IssuanceEvent{
+			Account: "other",
+			Domains: []string{string(rune('a'+i%26)) + string(rune('a'+i/26)) + ".example.co.uk"},
+			Time:    now.Add(-time.Duration(50-i) * time.Hour),
+			Issued:  true,
+		})
+	}
+
+	require.NoError(t, store.Save(events))
+
+	scheduler := newTestRenewalScheduler(t, store, now)
+
+	err := scheduler.Check("acc", []string{"new.example.co.uk"})
+
+	var rateLimitErr *RateLimitError
+	require.ErrorAs(t, err, &rateLimitErr)
+
+	assert.Equal(t, "certificates per registered domain example.co.uk", rateLimitErr.Limit)
+	assert.Equal(t, now.Add(-50*time.Hour).Add(LERateLimits.CertificatesPerRegisteredDomain.Period), rateLimitErr.RetryAfter)
+
+	// renewals are exempt.
+	require.NoError(t, scheduler.Check("acc", []string{"aa.example.co.uk"}))
+
+	// other registered domains are allowed.
+	require.NoError(t, scheduler.Check("acc", []string{"example.org"}))
+}
+
+func TestRenewalScheduler_failedValidations(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	scheduler := newTestRenewalScheduler(t, &MemoryIssuanceStore{}, now)
+
+	obtainErr := &acme.IdentifierError{
+		Identifier: "b.example.com",
+		Err:        &acme.ProblemDetails{Type: acme.DNSErr, HTTPStatus: 400},
+	}
+
+	for range 5 {
+		require.NoError(t, scheduler.Record("acc", []string{"a.example.com", "b.example.com"}, obtainErr))
+	}
+
+	err := scheduler.Check("acc", []string{"b.example.com"})
+
+	var rateLimitErr *RateLimitError
+	require.ErrorAs(t, err, &rateLimitErr)
+
+	assert.Equal(t, "failed validations for b.example.com", rateLimitErr.Limit)
+
+	next, err := scheduler.Next("acc", []string{"b.example.com"})
+	require.NoError(t, err)
+
+	assert.Equal(t, now.Add(time.Hour), next)
+
+	// the limit is per account.
+	require.NoError(t, scheduler.Check("other", []string{"b.example.com"}))
+
+	next, err = scheduler.Next("acc", []string{"a.example.com"})
+	require.NoError(t, err)
+
+	assert.Equal(t, now, next)
+}
+
+func TestFileIssuanceStore(t *testing.T) {
+	store := NewFileIssuanceStore(filepath.Join(t.TempDir(), "scheduler", "events.json"))
+
+	events, err := store.Load()
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	expected := []IssuanceEvent{{
+		Account:       "acc",
+		Domains:       []string{"example.com"},
+		Time:          time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC),
+		FailedDomains: []string{"example.com"},
+	}}
+
+	require.NoError(t, store.Save(expected))
+
+	events, err = store.Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, events)
+}
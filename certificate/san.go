@@ -0,0 +1,77 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+)
+
+// NormalizeSANs normalizes the domains of a certificate request:
+// the domains are lowercased (without trailing dot), deduplicated,
+// and sorted (except the first domain, used as the Common Name).
+//
+// An error is returned if a domain is redundant with a wildcard domain of the request
+// (ex: "www.example.com" and "*.example.com"), as the CAs reject these requests.
+func NormalizeSANs(domains []string) ([]string, error) {
+	if len(domains) == 0 {
+		return nil, nil
+	}
+
+	var normalized []string
+
+	seen := make(map[string]struct{})
+
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+
+		if _, ok := seen[domain]; ok {
+			continue
+		}
+
+		seen[domain] = struct{}{}
+
+		normalized = append(normalized, domain)
+	}
+
+	for _, domain := range normalized {
+		if strings.HasPrefix(domain, "*.") {
+			continue
+		}
+
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			continue
+		}
+
+		if _, ok := seen["*."+parent]; ok {
+			return nil, fmt.Errorf("domain %q is redundant with the wildcard domain %q", domain, "*."+parent)
+		}
+	}
+
+	slices.Sort(normalized[1:])
+
+	return normalized, nil
+}
+
+// sortCSRSANs sorts the SANs of the CSR template before calling the next customization (if any),
+// so the CSRs do not depend on the order of the identifiers returned by the server.
+func sortCSRSANs(next certcrypto.CSRTemplateFunc) certcrypto.CSRTemplateFunc {
+	return func(template *x509.CertificateRequest) error {
+		slices.Sort(template.DNSNames)
+
+		slices.SortFunc(template.IPAddresses, func(a, b net.IP) int {
+			return bytes.Compare(a, b)
+		})
+
+		if next == nil {
+			return nil
+		}
+
+		return next(template)
+	}
+}
@@ -0,0 +1,87 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeSANs(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		domains  []string
+		expected []string
+	}{
+		{
+			desc:     "sorted except the first domain",
+			domains:  []string{"www.example.com", "c.example.com", "example.com", "a.example.com"},
+			expected: []string{"www.example.com", "a.example.com", "c.example.com", "example.com"},
+		},
+		{
+			desc:     "lowercase and trailing dot",
+			domains:  []string{"Example.com.", "WWW.example.com"},
+			expected: []string{"example.com", "www.example.com"},
+		},
+		{
+			desc:     "duplicates",
+			domains:  []string{"example.com", "www.example.com", "EXAMPLE.com", "www.example.com."},
+			expected: []string{"example.com", "www.example.com"},
+		},
+		{
+			desc:     "wildcard and apex",
+			domains:  []string{"example.com", "*.example.com"},
+			expected: []string{"example.com", "*.example.com"},
+		},
+		{
+			desc:     "wildcard and deeper subdomain",
+			domains:  []string{"*.example.com", "a.b.example.com"},
+			expected: []string{"*.example.com", "a.b.example.com"},
+		},
+		{
+			desc:     "IP addresses",
+			domains:  []string{"example.com", "192.0.2.2", "192.0.2.1"},
+			expected: []string{"example.com", "192.0.2.1", "192.0.2.2"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			domains, err := NormalizeSANs(test.domains)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, domains)
+		})
+	}
+}
+
+func TestNormalizeSANs_redundant(t *testing.T) {
+	_, err := NormalizeSANs([]string{"example.com", "WWW.example.com", "*.example.com"})
+	require.EqualError(t, err, `domain "www.example.com" is redundant with the wildcard domain "*.example.com"`)
+}
+
+func Test_sortCSRSANs(t *testing.T) {
+	var called bool
+
+	customize := sortCSRSANs(func(template *x509.CertificateRequest) error {
+		called = true
+
+		assert.Equal(t, []string{"a.example.com", "b.example.com", "example.com"}, template.DNSNames)
+		assert.Equal(t, []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2")}, template.IPAddresses)
+
+		return nil
+	})
+
+	template := &x509.CertificateRequest{
+		DNSNames:    []string{"example.com", "b.example.com", "a.example.com"},
+		IPAddresses: []net.IP{net.ParseIP("192.0.2.2"), net.ParseIP("192.0.2.1")},
+	}
+
+	require.NoError(t, customize(template))
+
+	assert.True(t, called)
+}
@@ -0,0 +1,350 @@
+package certificate
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/log"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// oidSCTList the OID of the embedded SCT list extension (RFC 6962 Section 3.3).
+var oidSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// https://www.rfc-editor.org/rfc/rfc5246.html#section-7.4.1.4.1
+const (
+	sctHashSHA256     = 4
+	sctSignatureRSA   = 1
+	sctSignatureECDSA = 3
+)
+
+// CTLog a Certificate Transparency log.
+type CTLog struct {
+	Name      string
+	PublicKey crypto.PublicKey
+	// ID the SHA-256 hash of the DER encoded public key of the log.
+	ID [sha256.Size]byte
+}
+
+// NewCTLog creates a CTLog from the DER encoded public key (SubjectPublicKeyInfo) of the log.
+func NewCTLog(name string, publicKeyDER []byte) (*CTLog, error) {
+	publicKey, err := x509.ParsePKIXPublicKey(publicKeyDER)
+	if err != nil {
+		return nil, fmt.Errorf("CT log %s: %w", name, err)
+	}
+
+	return &CTLog{Name: name, PublicKey: publicKey, ID: sha256.Sum256(publicKeyDER)}, nil
+}
+
+// SCTPolicy the Certificate Transparency requirements of the issued certificates.
+type SCTPolicy struct {
+	// Logs the known CT logs.
+	Logs []*CTLog
+	// MinVerified the minimum number of embedded SCTs verified against the known logs.
+	// 0 only reports the SCTs on the Resource.
+	MinVerified int
+}
+
+// SCT a Signed Certificate Timestamp (RFC 6962 Section 3.2).
+type SCT struct {
+	Version            uint8
+	LogID              [sha256.Size]byte
+	Timestamp          time.Time
+	Extensions         []byte
+	HashAlgorithm      uint8
+	SignatureAlgorithm uint8
+	Signature          []byte
+}
+
+// SCTResult the result of the verification of an embedded SCT.
+type SCTResult struct {
+	SCT SCT
+	// Log the name of the log, empty if the log is unknown.
+	Log string
+	// Err the verification error, nil if the SCT is verified.
+	Err error
+}
+
+// SCTPolicyError is returned when an issued certificate does not satisfy the SCTPolicy.
+// The certificate is still returned.
+type SCTPolicyError struct {
+	Domain   string
+	Verified int
+	Required int
+}
+
+func (e *SCTPolicyError) Error() string {
+	return fmt.Sprintf("[%s] acme: the certificate has %d verified SCTs, %d required", e.Domain, e.Verified, e.Required)
+}
+
+// VerifySCTs verifies the SCTs embedded in the certificate against the known CT logs.
+// The certificate and the issuer certificate are PEM encoded.
+func VerifySCTs(certPEM, issuerPEM []byte, logs []*CTLog) ([]SCTResult, error) {
+	cert, err := certcrypto.ParsePEMCertificate(certPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer, err := certcrypto.ParsePEMCertificate(issuerPEM)
+	if err != nil {
+		return nil, fmt.Errorf("issuer: %w", err)
+	}
+
+	scts, err := parseEmbeddedSCTs(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scts) == 0 {
+		return nil, nil
+	}
+
+	tbs, err := removeSCTListExtension(cert.RawTBSCertificate)
+	if err != nil {
+		return nil, err
+	}
+
+	issuerKeyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+
+	var results []SCTResult
+
+	for _, sct := range scts {
+		result := SCTResult{SCT: sct, Err: errors.New("unknown log")}
+
+		for _, ctLog := range logs {
+			if ctLog.ID != sct.LogID {
+				continue
+			}
+
+			result.Log = ctLog.Name
+			result.Err = verifySCT(sct, ctLog.PublicKey, issuerKeyHash, tbs)
+
+			break
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// checkSCTs verifies the SCTs of the certificate if a SCTPolicy is defined.
+func (c *Certifier) checkSCTs(certRes *Resource) (*Resource, error) {
+	policy := c.options.SCTPolicy
+	if policy == nil {
+		return certRes, nil
+	}
+
+	results, err := VerifySCTs(certRes.Certificate, certRes.IssuerCertificate, policy.Logs)
+	if err != nil {
+		return certRes, fmt.Errorf("[%s] acme: verify SCTs: %w", certRes.Domain, err)
+	}
+
+	certRes.SCTs = results
+
+	var verified int
+
+	for _, result := range results {
+		if result.Err != nil {
+			log.WithDomain(certRes.Domain).Warnf("acme: SCT of the log %x not verified: %v", result.SCT.LogID, result.Err)
+			continue
+		}
+
+		verified++
+	}
+
+	if verified < policy.MinVerified {
+		return certRes, &SCTPolicyError{Domain: certRes.Domain, Verified: verified, Required: policy.MinVerified}
+	}
+
+	return certRes, nil
+}
+
+// parseEmbeddedSCTs parses the SignedCertificateTimestampList extension (RFC 6962 Section 3.3).
+func parseEmbeddedSCTs(cert *x509.Certificate) ([]SCT, error) {
+	var raw []byte
+
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidSCTList) {
+			raw = ext.Value
+			break
+		}
+	}
+
+	if raw == nil {
+		return nil, nil
+	}
+
+	var list []byte
+	if _, err := asn1.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("SCT list: %w", err)
+	}
+
+	input := cryptobyte.String(list)
+
+	var scts cryptobyte.String
+	if !input.ReadUint16LengthPrefixed(&scts) || !input.Empty() {
+		return nil, errors.New("SCT list: malformed")
+	}
+
+	var results []SCT
+
+	for !scts.Empty() {
+		var serialized cryptobyte.String
+		if !scts.ReadUint16LengthPrefixed(&serialized) {
+			return nil, errors.New("SCT list: malformed SCT")
+		}
+
+		sct, err := parseSCT(serialized)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, sct)
+	}
+
+	return results, nil
+}
+
+func parseSCT(input cryptobyte.String) (SCT, error) {
+	var sct SCT
+
+	var logID []byte
+	var timestamp uint64
+	var extensions, signature cryptobyte.String
+
+	if !input.ReadUint8(&sct.Version) ||
+		!input.ReadBytes(&logID, sha256.Size) ||
+		!input.ReadUint64(&timestamp) ||
+		!input.ReadUint16LengthPrefixed(&extensions) ||
+		!input.ReadUint8(&sct.HashAlgorithm) ||
+		!input.ReadUint8(&sct.SignatureAlgorithm) ||
+		!input.ReadUint16LengthPrefixed(&signature) ||
+		!input.Empty() {
+		return SCT{}, errors.New("SCT: malformed")
+	}
+
+	if sct.Version != 0 {
+		return SCT{}, fmt.Errorf("SCT: unsupported version %d", sct.Version)
+	}
+
+	copy(sct.LogID[:], logID)
+	sct.Timestamp = time.UnixMilli(int64(timestamp)).UTC()
+	sct.Extensions = extensions
+	sct.Signature = signature
+
+	return sct, nil
+}
+
+// verifySCT verifies the signature of a precertificate SCT (RFC 6962 Section 3.2).
+func verifySCT(sct SCT, publicKey crypto.PublicKey, issuerKeyHash [sha256.Size]byte, tbs []byte) error {
+	if sct.HashAlgorithm != sctHashSHA256 {
+		return fmt.Errorf("unsupported hash algorithm %d", sct.HashAlgorithm)
+	}
+
+	var b cryptobyte.Builder
+	b.AddUint8(sct.Version)
+	b.AddUint8(0) // signature_type: certificate_timestamp
+	b.AddUint64(uint64(sct.Timestamp.UnixMilli()))
+	b.AddUint16(1) // entry_type: precert_entry
+	b.AddBytes(issuerKeyHash[:])
+	b.AddUint24LengthPrefixed(func(child *cryptobyte.Builder) { child.AddBytes(tbs) })
+	b.AddUint16LengthPrefixed(func(child *cryptobyte.Builder) { child.AddBytes(sct.Extensions) })
+
+	signed, err := b.Bytes()
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(signed)
+
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if sct.SignatureAlgorithm != sctSignatureECDSA || !ecdsa.VerifyASN1(key, digest[:], sct.Signature) {
+			return errors.New("invalid signature")
+		}
+
+	case *rsa.PublicKey:
+		if sct.SignatureAlgorithm != sctSignatureRSA {
+			return errors.New("invalid signature")
+		}
+
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sct.Signature); err != nil {
+			return fmt.Errorf("invalid signature: %w", err)
+		}
+
+	default:
+		return fmt.Errorf("unsupported log public key %T", publicKey)
+	}
+
+	return nil
+}
+
+// removeSCTListExtension rebuilds the TBSCertificate without the SCT list extension,
+// as signed by the logs (RFC 6962 Section 3.2).
+func removeSCTListExtension(rawTBS []byte) ([]byte, error) {
+	input := cryptobyte.String(rawTBS)
+
+	var tbs cryptobyte.String
+	if !input.ReadASN1(&tbs, cryptobyte_asn1.SEQUENCE) {
+		return nil, errors.New("TBSCertificate: malformed")
+	}
+
+	var b cryptobyte.Builder
+
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(child *cryptobyte.Builder) {
+		for !tbs.Empty() {
+			var element cryptobyte.String
+			var tag cryptobyte_asn1.Tag
+			if !tbs.ReadAnyASN1Element(&element, &tag) {
+				child.SetError(errors.New("TBSCertificate: malformed element"))
+				return
+			}
+
+			if tag != cryptobyte_asn1.Tag(3).Constructed().ContextSpecific() {
+				child.AddBytes(element)
+				continue
+			}
+
+			var explicit, extensions cryptobyte.String
+			if !element.ReadASN1(&explicit, tag) || !explicit.ReadASN1(&extensions, cryptobyte_asn1.SEQUENCE) {
+				child.SetError(errors.New("TBSCertificate: malformed extensions"))
+				return
+			}
+
+			child.AddASN1(tag, func(explicitBuilder *cryptobyte.Builder) {
+				explicitBuilder.AddASN1(cryptobyte_asn1.SEQUENCE, func(list *cryptobyte.Builder) {
+					for !extensions.Empty() {
+						var extension, content cryptobyte.String
+						var id asn1.ObjectIdentifier
+						if !extensions.ReadASN1Element(&extension, cryptobyte_asn1.SEQUENCE) {
+							list.SetError(errors.New("TBSCertificate: malformed extension"))
+							return
+						}
+
+						element := extension
+						if !element.ReadASN1(&content, cryptobyte_asn1.SEQUENCE) || !content.ReadASN1ObjectIdentifier(&id) {
+							list.SetError(errors.New("TBSCertificate: malformed extension"))
+							return
+						}
+
+						if !id.Equal(oidSCTList) {
+							list.AddBytes(extension)
+						}
+					}
+				})
+			})
+		}
+	})
+
+	return b.Bytes()
+}
@@ -0,0 +1,236 @@
+package certificate
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+type sctFixture struct {
+	issuer    *x509.Certificate
+	issuerKey crypto.Signer
+	leafKey   crypto.Signer
+	template  *x509.Certificate
+}
+
+func newSCTFixture(t *testing.T) *sctFixture {
+	t.Helper()
+
+	issuerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	issuerTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	issuerDER, err := x509.CreateCertificate(rand.Reader, issuerTemplate, issuerTemplate, issuerKey.Public(), issuerKey)
+	require.NoError(t, err)
+
+	issuer, err := x509.ParseCertificate(issuerDER)
+	require.NoError(t, err)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	return &sctFixture{
+		issuer:    issuer,
+		issuerKey: issuerKey,
+		leafKey:   leafKey,
+		template: &x509.Certificate{
+			SerialNumber: big.NewInt(42),
+			Subject:      pkix.Name{CommonName: "example.com"},
+			DNSNames:     []string{"example.com"},
+			NotBefore:    time.Now().Add(-time.Hour).Truncate(time.Second),
+			NotAfter:     time.Now().Add(24 * time.Hour).Truncate(time.Second),
+		},
+	}
+}
+
+// createCertificate creates a certificate with the SCTs signed by the log keys.
+func (f *sctFixture) createCertificate(t *testing.T, logKeys ...*ecdsa.PrivateKey) (*x509.Certificate, []byte) {
+	t.Helper()
+
+	// The TBSCertificate signed by the logs: the certificate without the SCT list extension.
+	preDER, err := x509.CreateCertificate(rand.Reader, f.template, f.issuer, f.leafKey.Public(), f.issuerKey)
+	require.NoError(t, err)
+
+	pre, err := x509.ParseCertificate(preDER)
+	require.NoError(t, err)
+
+	issuerKeyHash := sha256.Sum256(f.issuer.RawSubjectPublicKeyInfo)
+
+	var list cryptobyte.Builder
+	list.AddUint16LengthPrefixed(func(scts *cryptobyte.Builder) {
+		for _, logKey := range logKeys {
+			sct := SCT{
+				LogID:              logID(t, logKey),
+				Timestamp:          time.Now().Truncate(time.Millisecond),
+				HashAlgorithm:      sctHashSHA256,
+				SignatureAlgorithm: sctSignatureECDSA,
+			}
+
+			var signed cryptobyte.Builder
+			signed.AddUint8(0)
+			signed.AddUint8(0)
+			signed.AddUint64(uint64(sct.Timestamp.UnixMilli()))
+			signed.AddUint16(1)
+			signed.AddBytes(issuerKeyHash[:])
+			signed.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(pre.RawTBSCertificate) })
+			signed.AddUint16(0)
+
+			digest := sha256.Sum256(signed.BytesOrPanic())
+
+			signature, errS := ecdsa.SignASN1(rand.Reader, logKey, digest[:])
+			require.NoError(t, errS)
+
+			scts.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddUint8(0)
+				b.AddBytes(sct.LogID[:])
+				b.AddUint64(uint64(sct.Timestamp.UnixMilli()))
+				b.AddUint16(0)
+				b.AddUint8(sct.HashAlgorithm)
+				b.AddUint8(sct.SignatureAlgorithm)
+				b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(signature) })
+			})
+		}
+	})
+
+	value, err := asn1.Marshal(list.BytesOrPanic())
+	require.NoError(t, err)
+
+	template := *f.template
+	template.ExtraExtensions = []pkix.Extension{{Id: oidSCTList, Value: value}}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, f.issuer, f.leafKey.Public(), f.issuerKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	require.Equal(t, pre.RawTBSCertificate, mustRemoveSCTListExtension(t, cert.RawTBSCertificate))
+
+	return cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func mustRemoveSCTListExtension(t *testing.T, tbs []byte) []byte {
+	t.Helper()
+
+	raw, err := removeSCTListExtension(tbs)
+	require.NoError(t, err)
+
+	return raw
+}
+
+func logID(t *testing.T, logKey *ecdsa.PrivateKey) [sha256.Size]byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(logKey.Public())
+	require.NoError(t, err)
+
+	return sha256.Sum256(der)
+}
+
+func newTestCTLog(t *testing.T, name string, logKey *ecdsa.PrivateKey) *CTLog {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(logKey.Public())
+	require.NoError(t, err)
+
+	ctLog, err := NewCTLog(name, der)
+	require.NoError(t, err)
+
+	return ctLog
+}
+
+func TestVerifySCTs(t *testing.T) {
+	fixture := newSCTFixture(t)
+
+	knownKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	unknownKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	_, certPEM := fixture.createCertificate(t, knownKey, unknownKey)
+
+	issuerPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: fixture.issuer.Raw})
+
+	results, err := VerifySCTs(certPEM, issuerPEM, []*CTLog{newTestCTLog(t, "known", knownKey)})
+	require.NoError(t, err)
+
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "known", results[0].Log)
+	require.NoError(t, results[0].Err)
+
+	assert.Empty(t, results[1].Log)
+	require.EqualError(t, results[1].Err, "unknown log")
+}
+
+func TestVerifySCTs_invalidSignature(t *testing.T) {
+	fixture := newSCTFixture(t)
+
+	logKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	_, certPEM := fixture.createCertificate(t, logKey)
+
+	// another issuer: the issuer key hash doesn't match.
+	other := newSCTFixture(t)
+	issuerPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: other.issuer.Raw})
+
+	results, err := VerifySCTs(certPEM, issuerPEM, []*CTLog{newTestCTLog(t, "log", logKey)})
+	require.NoError(t, err)
+
+	require.Len(t, results, 1)
+	require.EqualError(t, results[0].Err, "invalid signature")
+}
+
+func TestCertifier_checkSCTs(t *testing.T) {
+	fixture := newSCTFixture(t)
+
+	logKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	_, certPEM := fixture.createCertificate(t, logKey)
+
+	certRes := &Resource{
+		Domain:            "example.com",
+		Certificate:       certPEM,
+		IssuerCertificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: fixture.issuer.Raw}),
+	}
+
+	certifier := NewCertifier(nil, &resolverMock{}, CertifierOptions{
+		SCTPolicy: &SCTPolicy{Logs: []*CTLog{newTestCTLog(t, "log", logKey)}, MinVerified: 2},
+	})
+
+	res, err := certifier.checkSCTs(certRes)
+
+	var policyErr *SCTPolicyError
+	require.ErrorAs(t, err, &policyErr)
+
+	assert.Equal(t, 1, policyErr.Verified)
+	assert.Equal(t, 2, policyErr.Required)
+
+	require.NotNil(t, res)
+	assert.Len(t, res.SCTs, 1)
+}
@@ -18,6 +18,10 @@ const (
 	// Note: GetRecord returns a DNS record which will fulfill this challenge.
 	DNS01 = Type("dns-01")
 
+	// DNSAccount01 is the "dns-account-01" ACME challenge https://datatracker.ietf.org/doc/draft-ietf-acme-dns-account-label/
+	// Note: the TXT record is created on an account-scoped label (i.e. `_<label>._acme-challenge.[domain].`).
+	DNSAccount01 = Type("dns-account-01")
+
 	// TLSALPN01 is the "tls-alpn-01" ACME challenge https://www.rfc-editor.org/rfc/rfc8737.html
 	TLSALPN01 = Type("tls-alpn-01")
 )
@@ -0,0 +1,162 @@
+package dns01
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// CAAError is returned when the CAA records of a domain do not allow the CA to issue a certificate.
+type CAAError struct {
+	Domain string
+	// Name the domain name holding the relevant CAA records (the domain or one of its parents).
+	Name   string
+	Reason string
+}
+
+func (e *CAAError) Error() string {
+	return fmt.Sprintf("[%s] CAA records of %s: %s", e.Domain, e.Name, e.Reason)
+}
+
+// CAAOptions the options of the CAA check.
+type CAAOptions struct {
+	// IssuerDomains the CAA identities of the CA (directory meta `caaIdentities`).
+	IssuerDomains []string
+	// AccountURI the URL of the ACME account, checked against the "accounturi" parameters (RFC 8657).
+	// Ignored if empty.
+	AccountURI string
+}
+
+// CheckCAA checks that the CAA records of the domain allow the CA to issue a certificate (RFC 8659),
+// the issuerDomains are the CAA identities of the CA (directory meta `caaIdentities`).
+// The wildcard domains (ex: "*.example.com") are checked against the "issuewild" properties.
+func CheckCAA(domain string, issuerDomains []string) error {
+	return CheckCAAWithOptions(domain, CAAOptions{IssuerDomains: issuerDomains})
+}
+
+// CheckCAAWithOptions checks that the CAA records of the domain allow the CA to issue a certificate (see CheckCAA).
+func CheckCAAWithOptions(domain string, opts CAAOptions) error {
+	return checkCAA(domain, opts, recursiveNameservers)
+}
+
+func checkCAA(domain string, opts CAAOptions, nameservers []string) error {
+	name, wildcard := strings.CutPrefix(domain, "*.")
+
+	labels := dns.SplitDomainName(name)
+
+	// The relevant RRset is the CAA RRset of the closest domain name (the domain, then its parents).
+	// https://www.rfc-editor.org/rfc/rfc8659.html#section-3
+	for i := range labels {
+		fqdn := dns.Fqdn(strings.Join(labels[i:], "."))
+
+		records, err := lookupCAA(fqdn, nameservers)
+		if err != nil {
+			return fmt.Errorf("[%s] CAA lookup: %w", domain, err)
+		}
+
+		if len(records) > 0 {
+			return evaluateCAA(domain, fqdn, records, wildcard, opts)
+		}
+	}
+
+	return nil
+}
+
+func lookupCAA(fqdn string, nameservers []string) ([]*dns.CAA, error) {
+	msg, err := dnsQuery(fqdn, dns.TypeCAA, nameservers, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Rcode != dns.RcodeSuccess && msg.Rcode != dns.RcodeNameError {
+		return nil, &DNSError{Message: fmt.Sprintf("unexpected response code '%s'", dns.RcodeToString[msg.Rcode]), MsgOut: msg}
+	}
+
+	var records []*dns.CAA
+
+	// The CNAMEs are followed by the recursive nameservers.
+	for _, rr := range msg.Answer {
+		if caa, ok := rr.(*dns.CAA); ok {
+			records = append(records, caa)
+		}
+	}
+
+	return records, nil
+}
+
+func evaluateCAA(domain, fqdn string, records []*dns.CAA, wildcard bool, opts CAAOptions) error {
+	var issue, issueWild []string
+
+	for _, record := range records {
+		switch strings.ToLower(record.Tag) {
+		case "issue":
+			issue = append(issue, record.Value)
+		case "issuewild":
+			issueWild = append(issueWild, record.Value)
+		case "iodef", "issuemail", "issuevmc":
+		default:
+			// https://www.rfc-editor.org/rfc/rfc8659.html#section-4.1
+			if record.Flag&128 != 0 {
+				return &CAAError{Domain: domain, Name: fqdn, Reason: fmt.Sprintf("unknown critical property %q", record.Tag)}
+			}
+		}
+	}
+
+	tag, properties := "issue", issue
+	if wildcard && len(issueWild) > 0 {
+		tag, properties = "issuewild", issueWild
+	}
+
+	if len(properties) == 0 {
+		return nil
+	}
+
+	for _, property := range properties {
+		if matchCAAProperty(property, opts) {
+			return nil
+		}
+	}
+
+	var quoted []string
+	for _, property := range properties {
+		quoted = append(quoted, strconv.Quote(property))
+	}
+
+	return &CAAError{
+		Domain: domain,
+		Name:   fqdn,
+		Reason: fmt.Sprintf("the CA (%s) is not allowed to issue: %s %s", strings.Join(opts.IssuerDomains, ", "), tag, strings.Join(quoted, ", ")),
+	}
+}
+
+// matchCAAProperty checks if an "issue" or "issuewild" property value allows the CA (and the account) to issue.
+// https://www.rfc-editor.org/rfc/rfc8659.html#section-4.2
+func matchCAAProperty(value string, opts CAAOptions) bool {
+	issuer, parameters, _ := strings.Cut(value, ";")
+	issuer = strings.TrimSpace(issuer)
+
+	if issuer == "" {
+		return false
+	}
+
+	if !slices.ContainsFunc(opts.IssuerDomains, func(issuerDomain string) bool { return strings.EqualFold(issuerDomain, issuer) }) {
+		return false
+	}
+
+	if opts.AccountURI == "" {
+		return true
+	}
+
+	// https://www.rfc-editor.org/rfc/rfc8657.html#section-3
+	for _, parameter := range strings.Split(parameters, ";") {
+		key, val, _ := strings.Cut(strings.TrimSpace(parameter), "=")
+		if strings.EqualFold(key, "accounturi") && val != opts.AccountURI {
+			return false
+		}
+	}
+
+	return true
+}
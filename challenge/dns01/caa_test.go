@@ -0,0 +1,135 @@
+package dns01
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startCAAServer(t *testing.T, records map[string][]*dns.CAA) string {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	mux := dns.NewServeMux()
+	mux.HandleFunc(".", func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+
+		for _, record := range records[r.Question[0].Name] {
+			rr := *record
+			rr.Hdr = dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeCAA, Class: dns.ClassINET, Ttl: 60}
+			m.Answer = append(m.Answer, &rr)
+		}
+
+		_ = w.WriteMsg(m)
+	})
+
+	server := &dns.Server{PacketConn: pc, Handler: mux}
+
+	started := make(chan struct{})
+	server.NotifyStartedFunc = func() { close(started) }
+
+	go func() { _ = server.ActivateAndServe() }()
+
+	<-started
+
+	t.Cleanup(func() { _ = server.Shutdown() })
+
+	return pc.LocalAddr().String()
+}
+
+func Test_checkCAA(t *testing.T) {
+	ns := startCAAServer(t, map[string][]*dns.CAA{
+		"example.com.": {
+			{Tag: "issue", Value: "LetsEncrypt.org"},
+			{Tag: "issuewild", Value: ";"},
+			{Tag: "iodef", Value: "mailto:security@example.com"},
+		},
+		"other.example.com.": {
+			{Tag: "issue", Value: "ca.example.net; accounturi=https://ca.example.net/acct/1"},
+		},
+		"critical.example.com.": {
+			{Flag: 128, Tag: "unknown", Value: "foo"},
+			{Tag: "issue", Value: "letsencrypt.org"},
+		},
+		"wild.example.org.": {
+			{Tag: "issue", Value: "ca.example.net"},
+		},
+		"account.example.org.": {
+			{Tag: "issue", Value: "letsencrypt.org; accounturi=https://acme.example/acct/1"},
+		},
+	})
+
+	testCases := []struct {
+		desc       string
+		domain     string
+		accountURI string
+		expected   string
+	}{
+		{
+			desc:   "allowed",
+			domain: "example.com",
+		},
+		{
+			desc:   "inherited from the parent",
+			domain: "www.example.com",
+		},
+		{
+			desc:     "not allowed",
+			domain:   "other.example.com",
+			expected: `[other.example.com] CAA records of other.example.com.: the CA (letsencrypt.org) is not allowed to issue: issue "ca.example.net; accounturi=https://ca.example.net/acct/1"`,
+		},
+		{
+			desc:     "wildcard forbidden",
+			domain:   "*.example.com",
+			expected: `[*.example.com] CAA records of example.com.: the CA (letsencrypt.org) is not allowed to issue: issuewild ";"`,
+		},
+		{
+			desc:     "wildcard without issuewild",
+			domain:   "*.wild.example.org",
+			expected: `[*.wild.example.org] CAA records of wild.example.org.: the CA (letsencrypt.org) is not allowed to issue: issue "ca.example.net"`,
+		},
+		{
+			desc:     "unknown critical property",
+			domain:   "critical.example.com",
+			expected: `[critical.example.com] CAA records of critical.example.com.: unknown critical property "unknown"`,
+		},
+		{
+			desc:   "no CAA records",
+			domain: "example.net",
+		},
+		{
+			desc:       "account URI",
+			domain:     "account.example.org",
+			accountURI: "https://acme.example/acct/1",
+		},
+		{
+			desc:       "another account URI",
+			domain:     "account.example.org",
+			accountURI: "https://acme.example/acct/2",
+			expected:   `[account.example.org] CAA records of account.example.org.: the CA (letsencrypt.org) is not allowed to issue: issue "letsencrypt.org; accounturi=https://acme.example/acct/1"`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			opts := CAAOptions{IssuerDomains: []string{"letsencrypt.org"}, AccountURI: test.accountURI}
+
+			err := checkCAA(test.domain, opts, []string{ns})
+			if test.expected == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var caaErr *CAAError
+			require.ErrorAs(t, err, &caaErr)
+
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package dns01
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"strings"
+
+	"github.com/go-acme/lego/v4/acme/api"
+	"github.com/go-acme/lego/v4/challenge"
+)
+
+// ErrAccountChallengeNotSupported is returned when the DNS provider doesn't implement challenge.AccountProvider.
+var ErrAccountChallengeNotSupported = errors.New("the DNS provider doesn't support the dns-account-01 challenge")
+
+// NewAccountChallenge creates a solver for the dns-account-01 challenge.
+// The TXT record is created on `_<label>._acme-challenge.[domain].`, where the label is derived from the account URL,
+// so several ACME accounts can validate the same domain without colliding.
+// The provider must implement challenge.AccountProvider.
+func NewAccountChallenge(core *api.Core, validate ValidateFunc, provider challenge.Provider, opts ...ChallengeOption) *Challenge {
+	chlg := NewChallenge(core, validate, provider, opts...)
+	chlg.chlgType = challenge.DNSAccount01
+
+	return chlg
+}
+
+// AccountLabel returns the account-scoped label used by the dns-account-01 challenge:
+// an underscore followed by the lowercase base32 encoding of the first 10 bytes of the SHA-256 digest of the account URL.
+// https://datatracker.ietf.org/doc/html/draft-ietf-acme-dns-account-label-00#section-3.1
+func AccountLabel(accountURL string) string {
+	digest := sha256.Sum256([]byte(accountURL))
+
+	return "_" + strings.ToLower(base32.StdEncoding.EncodeToString(digest[:10]))
+}
+
+// GetAccountChallengeInfo returns information used to create a DNS record which will fulfill the `dns-account-01` challenge.
+func GetAccountChallengeInfo(label, domain, keyAuth string) ChallengeInfo {
+	return getChallengeInfo(label+"._acme-challenge", domain, keyAuth)
+}
+
+// accountLabel returns the label of the account of the dns-account-01 challenge (empty for the dns-01 challenge).
+func (c *Challenge) accountLabel() (string, error) {
+	if c.chlgType != challenge.DNSAccount01 {
+		return "", nil
+	}
+
+	accountURL := c.core.GetAccountURL()
+	if accountURL == "" {
+		return "", errors.New("the account URL is required by the dns-account-01 challenge")
+	}
+
+	return AccountLabel(accountURL), nil
+}
+
+func (c *Challenge) present(label, domain, token, keyAuth string) error {
+	if label == "" {
+		return c.provider.Present(domain, token, keyAuth)
+	}
+
+	provider, ok := c.provider.(challenge.AccountProvider)
+	if !ok {
+		return ErrAccountChallengeNotSupported
+	}
+
+	return provider.PresentAccount(label, domain, token, keyAuth)
+}
+
+func (c *Challenge) cleanUp(label, domain, token, keyAuth string) error {
+	if label == "" {
+		return c.provider.CleanUp(domain, token, keyAuth)
+	}
+
+	provider, ok := c.provider.(challenge.AccountProvider)
+	if !ok {
+		return ErrAccountChallengeNotSupported
+	}
+
+	return provider.CleanUpAccount(label, domain, token, keyAuth)
+}
+
+func (c *Challenge) challengeInfo(label, domain, keyAuth string) ChallengeInfo {
+	if label == "" {
+		return GetChallengeInfo(domain, keyAuth)
+	}
+
+	return GetAccountChallengeInfo(label, domain, keyAuth)
+}
@@ -0,0 +1,139 @@
+package dns01
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"testing"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/acme/api"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type providerRecordMock struct {
+	presented, cleaned string
+}
+
+func (p *providerRecordMock) Present(domain, token, keyAuth string) error {
+	p.presented = GetChallengeInfo(domain, keyAuth).FQDN
+	return nil
+}
+
+func (p *providerRecordMock) CleanUp(domain, token, keyAuth string) error {
+	p.cleaned = GetChallengeInfo(domain, keyAuth).FQDN
+	return nil
+}
+
+func (p *providerRecordMock) PresentAccount(label, domain, token, keyAuth string) error {
+	p.presented = GetAccountChallengeInfo(label, domain, keyAuth).FQDN
+	return nil
+}
+
+func (p *providerRecordMock) CleanUpAccount(label, domain, token, keyAuth string) error {
+	p.cleaned = GetAccountChallengeInfo(label, domain, keyAuth).FQDN
+	return nil
+}
+
+func TestAccountLabel(t *testing.T) {
+	// https://datatracker.ietf.org/doc/html/draft-ietf-acme-dns-account-label-00#section-3.1
+	label := AccountLabel("https://example.com/acme/acct/ExampleAccount")
+
+	assert.Equal(t, "_ujmmovf2vn55tgye", label)
+}
+
+func TestNewAccountChallenge(t *testing.T) {
+	t.Setenv("LEGO_DISABLE_CNAME_SUPPORT", "true")
+
+	_, apiURL := tester.SetupFakeAPI(t)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", "https://example.com/acme/acct/ExampleAccount", privateKey)
+	require.NoError(t, err)
+
+	provider := &providerRecordMock{}
+
+	var validated string
+
+	chlg := NewAccountChallenge(core,
+		func(_ *api.Core, _ string, chlng acme.Challenge) error {
+			validated = chlng.Type
+			return nil
+		},
+		provider,
+		WrapPreCheck(func(_, fqdn, _ string, _ PreCheckFunc) (bool, error) {
+			assert.Equal(t, "_ujmmovf2vn55tgye._acme-challenge.example.org.", fqdn)
+			return true, nil
+		}),
+	)
+
+	authz := acme.Authorization{
+		Identifier: acme.Identifier{Value: "example.org"},
+		Challenges: []acme.Challenge{
+			{Type: challenge.DNS01.String(), Token: "dns01"},
+			{Type: challenge.DNSAccount01.String(), Token: "dnsaccount01"},
+		},
+	}
+
+	err = chlg.PreSolve(authz)
+	require.NoError(t, err)
+
+	err = chlg.Solve(authz)
+	require.NoError(t, err)
+
+	err = chlg.CleanUp(authz)
+	require.NoError(t, err)
+
+	assert.Equal(t, "_ujmmovf2vn55tgye._acme-challenge.example.org.", provider.presented)
+	assert.Equal(t, "_ujmmovf2vn55tgye._acme-challenge.example.org.", provider.cleaned)
+	assert.Equal(t, challenge.DNSAccount01.String(), validated)
+}
+
+func TestNewAccountChallenge_notSupported(t *testing.T) {
+	_, apiURL := tester.SetupFakeAPI(t)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", "https://example.com/acme/acct/ExampleAccount", privateKey)
+	require.NoError(t, err)
+
+	chlg := NewAccountChallenge(core, nil, &providerMock{})
+
+	authz := acme.Authorization{
+		Identifier: acme.Identifier{Value: "example.org"},
+		Challenges: []acme.Challenge{
+			{Type: challenge.DNSAccount01.String(), Token: "dnsaccount01"},
+		},
+	}
+
+	err = chlg.PreSolve(authz)
+	require.ErrorIs(t, err, ErrAccountChallengeNotSupported)
+}
+
+func TestNewAccountChallenge_noAccount(t *testing.T) {
+	_, apiURL := tester.SetupFakeAPI(t)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", "", privateKey)
+	require.NoError(t, err)
+
+	chlg := NewAccountChallenge(core, nil, &providerRecordMock{})
+
+	authz := acme.Authorization{
+		Identifier: acme.Identifier{Value: "example.org"},
+		Challenges: []acme.Challenge{
+			{Type: challenge.DNSAccount01.String(), Token: "dnsaccount01"},
+		},
+	}
+
+	err = chlg.PreSolve(authz)
+	require.EqualError(t, err, "[example.org] acme: the account URL is required by the dns-account-01 challenge")
+}
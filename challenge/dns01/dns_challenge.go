@@ -3,6 +3,7 @@ package dns01
 import (
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -28,6 +29,9 @@ const (
 	DefaultTTL = 120
 )
 
+// ErrPropagationTimeout is returned (errors.Is) when the TXT record is not propagated before the propagation timeout.
+var ErrPropagationTimeout = errors.New("propagation timeout")
+
 type ValidateFunc func(core *api.Core, domain string, chlng acme.Challenge) error
 
 type ChallengeOption func(*Challenge) error
@@ -43,13 +47,14 @@ func CondOption(condition bool, opt ChallengeOption) ChallengeOption {
 	return opt
 }
 
-// Challenge implements the dns-01 challenge.
+// Challenge implements the dns-01 challenge (and the dns-account-01 challenge).
 type Challenge struct {
 	core       *api.Core
 	validate   ValidateFunc
 	provider   challenge.Provider
 	preCheck   preCheck
 	dnsTimeout time.Duration
+	chlgType   challenge.Type
 }
 
 func NewChallenge(core *api.Core, validate ValidateFunc, provider challenge.Provider, opts ...ChallengeOption) *Challenge {
@@ -59,6 +64,7 @@ func NewChallenge(core *api.Core, validate ValidateFunc, provider challenge.Prov
 		provider:   provider,
 		preCheck:   newPreCheck(),
 		dnsTimeout: 10 * time.Second,
+		chlgType:   challenge.DNS01,
 	}
 
 	for _, opt := range opts {
@@ -75,9 +81,9 @@ func NewChallenge(core *api.Core, validate ValidateFunc, provider challenge.Prov
 // It does not validate record propagation, or do anything at all with the acme server.
 func (c *Challenge) PreSolve(authz acme.Authorization) error {
 	domain := challenge.GetTargetedDomain(authz)
-	log.Infof("[%s] acme: Preparing to solve DNS-01", domain)
+	log.WithDomain(domain).Infof("acme: Preparing to solve %s", c.name())
 
-	chlng, err := challenge.FindChallenge(challenge.DNS01, authz)
+	chlng, err := challenge.FindChallenge(c.chlgType, authz)
 	if err != nil {
 		return err
 	}
@@ -92,7 +98,12 @@ func (c *Challenge) PreSolve(authz acme.Authorization) error {
 		return err
 	}
 
-	err = c.provider.Present(authz.Identifier.Value, chlng.Token, keyAuth)
+	label, err := c.accountLabel()
+	if err != nil {
+		return fmt.Errorf("[%s] acme: %w", domain, err)
+	}
+
+	err = c.present(label, authz.Identifier.Value, chlng.Token, keyAuth)
 	if err != nil {
 		return fmt.Errorf("[%s] acme: error presenting token: %w", domain, err)
 	}
@@ -102,9 +113,9 @@ func (c *Challenge) PreSolve(authz acme.Authorization) error {
 
 func (c *Challenge) Solve(authz acme.Authorization) error {
 	domain := challenge.GetTargetedDomain(authz)
-	log.Infof("[%s] acme: Trying to solve DNS-01", domain)
+	log.WithDomain(domain).Infof("acme: Trying to solve %s", c.name())
 
-	chlng, err := challenge.FindChallenge(challenge.DNS01, authz)
+	chlng, err := challenge.FindChallenge(c.chlgType, authz)
 	if err != nil {
 		return err
 	}
@@ -115,7 +126,12 @@ func (c *Challenge) Solve(authz acme.Authorization) error {
 		return err
 	}
 
-	info := GetChallengeInfo(authz.Identifier.Value, keyAuth)
+	label, err := c.accountLabel()
+	if err != nil {
+		return fmt.Errorf("[%s] acme: %w", domain, err)
+	}
+
+	info := c.challengeInfo(label, authz.Identifier.Value, keyAuth)
 
 	var timeout, interval time.Duration
 	switch provider := c.provider.(type) {
@@ -125,17 +141,21 @@ func (c *Challenge) Solve(authz acme.Authorization) error {
 		timeout, interval = DefaultPropagationTimeout, DefaultPollingInterval
 	}
 
-	log.Infof("[%s] acme: Checking DNS record propagation. [nameservers=%s]", domain, strings.Join(recursiveNameservers, ","))
+	log.WithDomain(domain).Infof("acme: Checking DNS record propagation. [nameservers=%s]", strings.Join(recursiveNameservers, ","))
 
 	time.Sleep(interval)
 
 	err = wait.For("propagation", timeout, interval, func() (bool, error) {
 		stop, errP := c.preCheck.call(domain, info.EffectiveFQDN, info.Value)
 		if !stop || errP != nil {
-			log.Infof("[%s] acme: Waiting for DNS record propagation.", domain)
+			log.WithDomain(domain).Infof("acme: Waiting for DNS record propagation.")
 		}
 		return stop, errP
 	})
+	if errors.Is(err, wait.ErrTimeout) {
+		return &propagationTimeoutError{err: err}
+	}
+
 	if err != nil {
 		return err
 	}
@@ -146,9 +166,9 @@ func (c *Challenge) Solve(authz acme.Authorization) error {
 
 // CleanUp cleans the challenge.
 func (c *Challenge) CleanUp(authz acme.Authorization) error {
-	log.Infof("[%s] acme: Cleaning DNS-01 challenge", challenge.GetTargetedDomain(authz))
+	log.WithDomain(challenge.GetTargetedDomain(authz)).Infof("acme: Cleaning %s challenge", c.name())
 
-	chlng, err := challenge.FindChallenge(challenge.DNS01, authz)
+	chlng, err := challenge.FindChallenge(c.chlgType, authz)
 	if err != nil {
 		return err
 	}
@@ -158,7 +178,16 @@ func (c *Challenge) CleanUp(authz acme.Authorization) error {
 		return err
 	}
 
-	return c.provider.CleanUp(authz.Identifier.Value, chlng.Token, keyAuth)
+	label, err := c.accountLabel()
+	if err != nil {
+		return err
+	}
+
+	return c.cleanUp(label, authz.Identifier.Value, chlng.Token, keyAuth)
+}
+
+func (c *Challenge) name() string {
+	return strings.ToUpper(c.chlgType.String())
 }
 
 func (c *Challenge) Sequential() (bool, time.Duration) {
@@ -194,6 +223,10 @@ type ChallengeInfo struct {
 
 // GetChallengeInfo returns information used to create a DNS record which will fulfill the `dns-01` challenge.
 func GetChallengeInfo(domain, keyAuth string) ChallengeInfo {
+	return getChallengeInfo("_acme-challenge", domain, keyAuth)
+}
+
+func getChallengeInfo(prefix, domain, keyAuth string) ChallengeInfo {
 	keyAuthShaBytes := sha256.Sum256([]byte(keyAuth))
 	// base64URL encoding without padding
 	value := base64.RawURLEncoding.EncodeToString(keyAuthShaBytes[:sha256.Size])
@@ -202,13 +235,13 @@ func GetChallengeInfo(domain, keyAuth string) ChallengeInfo {
 
 	return ChallengeInfo{
 		Value:         value,
-		FQDN:          getChallengeFQDN(domain, false),
-		EffectiveFQDN: getChallengeFQDN(domain, !ok),
+		FQDN:          getChallengeFQDN(prefix, domain, false),
+		EffectiveFQDN: getChallengeFQDN(prefix, domain, !ok),
 	}
 }
 
-func getChallengeFQDN(domain string, followCNAME bool) string {
-	fqdn := fmt.Sprintf("_acme-challenge.%s.", domain)
+func getChallengeFQDN(prefix, domain string, followCNAME bool) string {
+	fqdn := fmt.Sprintf("%s.%s.", prefix, domain)
 
 	if !followCNAME {
 		return fqdn
@@ -237,3 +270,20 @@ func getChallengeFQDN(domain string, followCNAME bool) string {
 
 	return fqdn
 }
+
+// propagationTimeoutError wraps the error of the propagation check, and matches ErrPropagationTimeout.
+type propagationTimeoutError struct {
+	err error
+}
+
+func (e *propagationTimeoutError) Error() string {
+	return e.err.Error()
+}
+
+func (e *propagationTimeoutError) Unwrap() error {
+	return e.err
+}
+
+func (e *propagationTimeoutError) Is(target error) bool {
+	return target == ErrPropagationTimeout
+}
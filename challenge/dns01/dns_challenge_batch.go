@@ -0,0 +1,93 @@
+package dns01
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/log"
+)
+
+// Batch returns true if the DNS provider can submit the records of several authorizations in a single operation.
+// The batch operations are not used by the dns-account-01 challenge.
+func (c *Challenge) Batch() bool {
+	if c.chlgType == challenge.DNSAccount01 {
+		return false
+	}
+
+	_, ok := c.provider.(challenge.BatchProvider)
+	return ok
+}
+
+// PreSolveBatch submits the TXT records of several authorizations in a single operation of the DNS provider.
+// It does not validate record propagation, or do anything at all with the acme server.
+func (c *Challenge) PreSolveBatch(authzs []acme.Authorization) error {
+	provider, ok := c.provider.(challenge.BatchProvider)
+	if !ok {
+		return errors.New("acme: the DNS provider doesn't support the batch operations")
+	}
+
+	challenges := make([]challenge.BatchChallenge, 0, len(authzs))
+
+	for _, authz := range authzs {
+		domain := challenge.GetTargetedDomain(authz)
+		log.WithDomain(domain).Infof("acme: Preparing to solve %s (batch)", c.name())
+
+		bc, err := c.batchChallenge(authz)
+		if err != nil {
+			return fmt.Errorf("[%s] acme: %w", domain, err)
+		}
+
+		challenges = append(challenges, bc)
+	}
+
+	err := provider.PresentBatch(challenges)
+	if err != nil {
+		return fmt.Errorf("acme: error presenting the tokens (batch): %w", err)
+	}
+
+	return nil
+}
+
+// CleanUpBatch cleans the challenges of several authorizations in a single operation of the DNS provider.
+func (c *Challenge) CleanUpBatch(authzs []acme.Authorization) error {
+	provider, ok := c.provider.(challenge.BatchProvider)
+	if !ok {
+		return errors.New("acme: the DNS provider doesn't support the batch operations")
+	}
+
+	challenges := make([]challenge.BatchChallenge, 0, len(authzs))
+
+	for _, authz := range authzs {
+		domain := challenge.GetTargetedDomain(authz)
+		log.WithDomain(domain).Infof("acme: Cleaning %s challenge (batch)", c.name())
+
+		bc, err := c.batchChallenge(authz)
+		if err != nil {
+			return fmt.Errorf("[%s] acme: %w", domain, err)
+		}
+
+		challenges = append(challenges, bc)
+	}
+
+	return provider.CleanUpBatch(challenges)
+}
+
+func (c *Challenge) batchChallenge(authz acme.Authorization) (challenge.BatchChallenge, error) {
+	chlng, err := challenge.FindChallenge(c.chlgType, authz)
+	if err != nil {
+		return challenge.BatchChallenge{}, err
+	}
+
+	keyAuth, err := c.core.GetKeyAuthorization(chlng.Token)
+	if err != nil {
+		return challenge.BatchChallenge{}, err
+	}
+
+	return challenge.BatchChallenge{
+		Domain:  authz.Identifier.Value,
+		Token:   chlng.Token,
+		KeyAuth: keyAuth,
+	}, nil
+}
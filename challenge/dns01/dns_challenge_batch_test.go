@@ -0,0 +1,105 @@
+package dns01
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/acme/api"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type providerBatchMock struct {
+	providerMock
+
+	presentBatch, cleanUpBatch error
+
+	presented, cleaned []challenge.BatchChallenge
+}
+
+func (p *providerBatchMock) PresentBatch(challenges []challenge.BatchChallenge) error {
+	p.presented = challenges
+	return p.presentBatch
+}
+
+func (p *providerBatchMock) CleanUpBatch(challenges []challenge.BatchChallenge) error {
+	p.cleaned = challenges
+	return p.cleanUpBatch
+}
+
+func TestChallenge_Batch(t *testing.T) {
+	assert.True(t, NewChallenge(nil, nil, &providerBatchMock{}).Batch())
+	assert.False(t, NewChallenge(nil, nil, &providerMock{}).Batch())
+}
+
+func TestChallenge_PreSolveBatch(t *testing.T) {
+	_, apiURL := tester.SetupFakeAPI(t)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", "", privateKey)
+	require.NoError(t, err)
+
+	authzs := []acme.Authorization{
+		createStubAuthorizationDNS01("example.com", "tokenA"),
+		createStubAuthorizationDNS01("example.org", "tokenB"),
+	}
+
+	provider := &providerBatchMock{}
+
+	chlg := NewChallenge(core, nil, provider)
+
+	err = chlg.PreSolveBatch(authzs)
+	require.NoError(t, err)
+
+	require.Len(t, provider.presented, 2)
+	assert.Equal(t, "example.com", provider.presented[0].Domain)
+	assert.Equal(t, "tokenA", provider.presented[0].Token)
+	assert.NotEmpty(t, provider.presented[0].KeyAuth)
+	assert.Equal(t, "example.org", provider.presented[1].Domain)
+	assert.Equal(t, "tokenB", provider.presented[1].Token)
+
+	err = chlg.CleanUpBatch(authzs)
+	require.NoError(t, err)
+
+	assert.Equal(t, provider.presented, provider.cleaned)
+}
+
+func TestChallenge_PreSolveBatch_errors(t *testing.T) {
+	_, apiURL := tester.SetupFakeAPI(t)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", "", privateKey)
+	require.NoError(t, err)
+
+	authzs := []acme.Authorization{createStubAuthorizationDNS01("example.com", "tokenA")}
+
+	err = NewChallenge(core, nil, &providerMock{}).PreSolveBatch(authzs)
+	require.EqualError(t, err, "acme: the DNS provider doesn't support the batch operations")
+
+	err = NewChallenge(core, nil, &providerBatchMock{presentBatch: errors.New("OOPS")}).PreSolveBatch(authzs)
+	require.EqualError(t, err, "acme: error presenting the tokens (batch): OOPS")
+
+	err = NewChallenge(core, nil, &providerBatchMock{cleanUpBatch: errors.New("OOPS")}).CleanUpBatch(authzs)
+	require.EqualError(t, err, "OOPS")
+}
+
+func createStubAuthorizationDNS01(domain, token string) acme.Authorization {
+	return acme.Authorization{
+		Identifier: acme.Identifier{
+			Value: domain,
+		},
+		Challenges: []acme.Challenge{
+			{Type: challenge.DNS01.String(), Token: token},
+		},
+	}
+}
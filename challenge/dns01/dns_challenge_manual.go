@@ -20,9 +20,26 @@ func NewDNSProviderManual() (*DNSProviderManual, error) {
 }
 
 // Present prints instructions for manually creating the TXT record.
-func (*DNSProviderManual) Present(domain, token, keyAuth string) error {
-	info := GetChallengeInfo(domain, keyAuth)
+func (d *DNSProviderManual) Present(domain, token, keyAuth string) error {
+	return d.present(GetChallengeInfo(domain, keyAuth))
+}
+
+// CleanUp prints instructions for manually removing the TXT record.
+func (d *DNSProviderManual) CleanUp(domain, token, keyAuth string) error {
+	return d.cleanUp(GetChallengeInfo(domain, keyAuth))
+}
+
+// PresentAccount prints instructions for manually creating the TXT record of the dns-account-01 challenge.
+func (d *DNSProviderManual) PresentAccount(label, domain, token, keyAuth string) error {
+	return d.present(GetAccountChallengeInfo(label, domain, keyAuth))
+}
+
+// CleanUpAccount prints instructions for manually removing the TXT record of the dns-account-01 challenge.
+func (d *DNSProviderManual) CleanUpAccount(label, domain, token, keyAuth string) error {
+	return d.cleanUp(GetAccountChallengeInfo(label, domain, keyAuth))
+}
 
+func (*DNSProviderManual) present(info ChallengeInfo) error {
 	authZone, err := FindZoneByFqdn(info.EffectiveFQDN)
 	if err != nil {
 		return fmt.Errorf("manual: could not find zone: %w", err)
@@ -40,10 +57,7 @@ func (*DNSProviderManual) Present(domain, token, keyAuth string) error {
 	return nil
 }
 
-// CleanUp prints instructions for manually removing the TXT record.
-func (*DNSProviderManual) CleanUp(domain, token, keyAuth string) error {
-	info := GetChallengeInfo(domain, keyAuth)
-
+func (*DNSProviderManual) cleanUp(info ChallengeInfo) error {
 	authZone, err := FindZoneByFqdn(info.EffectiveFQDN)
 	if err != nil {
 		return fmt.Errorf("manual: could not find zone: %w", err)
@@ -0,0 +1,306 @@
+package dns01
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/platform/config/env"
+)
+
+// Route associates a domain (and its subdomains) to a DNS provider.
+type Route struct {
+	Domain   string
+	Provider challenge.Provider
+}
+
+// Router is a DNS provider routing the challenges of each domain to the DNS provider of the domain,
+// so a certificate can contain domains hosted by different DNS providers.
+// The route of the longest matching domain is used, the default provider (if any) is used when no route matches.
+type Router struct {
+	routes          map[string]challenge.Provider
+	defaultProvider challenge.Provider
+}
+
+// sequentialRouter is a Router containing at least one sequential provider.
+type sequentialRouter struct {
+	*Router
+
+	interval time.Duration
+}
+
+// Sequential All DNS challenges for this provider will be resolved sequentially.
+// Returns the longest interval of the sequential providers.
+func (r *sequentialRouter) Sequential() time.Duration {
+	return r.interval
+}
+
+// batchRouter is a Router containing at least one batch provider.
+type batchRouter struct {
+	*Router
+}
+
+// PresentBatch creates the TXT records of the challenges with the DNS providers of the domains:
+// the challenges of a batch provider are presented in a single operation, the others one by one.
+// If a challenge fails, the challenges already presented are cleaned up.
+func (r *batchRouter) PresentBatch(challenges []challenge.BatchChallenge) error {
+	routed, err := r.group(challenges)
+	if err != nil {
+		return err
+	}
+
+	for i, rc := range routed {
+		err = rc.present()
+		if err != nil {
+			for _, presented := range routed[:i] {
+				_ = presented.cleanUp()
+			}
+
+			return fmt.Errorf("router: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CleanUpBatch removes the TXT records of the challenges with the DNS providers of the domains.
+func (r *batchRouter) CleanUpBatch(challenges []challenge.BatchChallenge) error {
+	routed, err := r.group(challenges)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, rc := range routed {
+		errs = append(errs, rc.cleanUp())
+	}
+
+	err = errors.Join(errs...)
+	if err != nil {
+		return fmt.Errorf("router: %w", err)
+	}
+
+	return nil
+}
+
+// NewRouter creates a DNS provider routing the challenges to the providers by domain.
+// The returned provider is sequential if at least one of the providers is sequential.
+// Otherwise, the returned provider is a batch provider if at least one of the providers is a batch provider.
+func NewRouter(routes []Route, defaultProvider challenge.Provider) (challenge.ProviderTimeout, error) {
+	if len(routes) == 0 && defaultProvider == nil {
+		return nil, errors.New("router: no DNS providers")
+	}
+
+	router := &Router{routes: make(map[string]challenge.Provider), defaultProvider: defaultProvider}
+
+	for _, route := range routes {
+		domain := env.NormalizeDomain(route.Domain)
+		if domain == "" {
+			return nil, fmt.Errorf("router: invalid domain %q", route.Domain)
+		}
+
+		if route.Provider == nil {
+			return nil, fmt.Errorf("router: missing DNS provider for the domain %s", domain)
+		}
+
+		if _, ok := router.routes[domain]; ok {
+			return nil, fmt.Errorf("router: duplicate route for the domain %s", domain)
+		}
+
+		router.routes[domain] = route.Provider
+	}
+
+	var interval time.Duration
+	var isSequential bool
+
+	for _, provider := range router.providers() {
+		if p, ok := provider.(sequential); ok {
+			isSequential = true
+			interval = max(interval, p.Sequential())
+		}
+	}
+
+	if isSequential {
+		return &sequentialRouter{Router: router, interval: interval}, nil
+	}
+
+	// The batch operations are not used with the sequential providers.
+	for _, provider := range router.providers() {
+		if _, ok := provider.(challenge.BatchProvider); ok {
+			return &batchRouter{Router: router}, nil
+		}
+	}
+
+	return router, nil
+}
+
+// Present creates the TXT record with the DNS provider of the domain.
+func (r *Router) Present(domain, token, keyAuth string) error {
+	provider, err := r.lookup(domain)
+	if err != nil {
+		return err
+	}
+
+	return provider.Present(domain, token, keyAuth)
+}
+
+// CleanUp removes the TXT record with the DNS provider of the domain.
+func (r *Router) CleanUp(domain, token, keyAuth string) error {
+	provider, err := r.lookup(domain)
+	if err != nil {
+		return err
+	}
+
+	return provider.CleanUp(domain, token, keyAuth)
+}
+
+// PresentAccount creates the TXT record of the dns-account-01 challenge with the DNS provider of the domain.
+func (r *Router) PresentAccount(label, domain, token, keyAuth string) error {
+	provider, err := r.lookupAccount(domain)
+	if err != nil {
+		return err
+	}
+
+	return provider.PresentAccount(label, domain, token, keyAuth)
+}
+
+// CleanUpAccount removes the TXT record of the dns-account-01 challenge with the DNS provider of the domain.
+func (r *Router) CleanUpAccount(label, domain, token, keyAuth string) error {
+	provider, err := r.lookupAccount(domain)
+	if err != nil {
+		return err
+	}
+
+	return provider.CleanUpAccount(label, domain, token, keyAuth)
+}
+
+// Timeout returns the longest timeout and the longest interval of the DNS providers.
+func (r *Router) Timeout() (timeout, interval time.Duration) {
+	for _, provider := range r.providers() {
+		t, i := DefaultPropagationTimeout, DefaultPollingInterval
+		if p, ok := provider.(challenge.ProviderTimeout); ok {
+			t, i = p.Timeout()
+		}
+
+		timeout = max(timeout, t)
+		interval = max(interval, i)
+	}
+
+	return timeout, interval
+}
+
+func (r *Router) lookup(domain string) (challenge.Provider, error) {
+	_, provider, err := r.route(domain)
+
+	return provider, err
+}
+
+// route returns the domain of the route of a domain (empty for the default provider), and its provider.
+func (r *Router) route(domain string) (string, challenge.Provider, error) {
+	if routeDomain, provider, ok := env.MatchDomain(r.routes, domain); ok {
+		return routeDomain, provider, nil
+	}
+
+	if r.defaultProvider == nil {
+		return "", nil, fmt.Errorf("router: no DNS provider for the domain %s", env.NormalizeDomain(domain))
+	}
+
+	return "", r.defaultProvider, nil
+}
+
+// group groups the challenges by route, in the order of the challenges.
+func (r *Router) group(challenges []challenge.BatchChallenge) ([]*routedChallenges, error) {
+	var routed []*routedChallenges
+
+	byRoute := make(map[string]*routedChallenges)
+
+	for _, chlg := range challenges {
+		routeDomain, provider, err := r.route(chlg.Domain)
+		if err != nil {
+			return nil, err
+		}
+
+		rc, ok := byRoute[routeDomain]
+		if !ok {
+			rc = &routedChallenges{provider: provider}
+			byRoute[routeDomain] = rc
+			routed = append(routed, rc)
+		}
+
+		rc.challenges = append(rc.challenges, chlg)
+	}
+
+	return routed, nil
+}
+
+func (r *Router) lookupAccount(domain string) (challenge.AccountProvider, error) {
+	provider, err := r.lookup(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	p, ok := provider.(challenge.AccountProvider)
+	if !ok {
+		return nil, fmt.Errorf("router: domain %s: %w", env.NormalizeDomain(domain), ErrAccountChallengeNotSupported)
+	}
+
+	return p, nil
+}
+
+func (r *Router) providers() []challenge.Provider {
+	var providers []challenge.Provider
+
+	for _, provider := range r.routes {
+		providers = append(providers, provider)
+	}
+
+	if r.defaultProvider != nil {
+		providers = append(providers, r.defaultProvider)
+	}
+
+	return providers
+}
+
+// routedChallenges the challenges of a batch routed to the same provider.
+type routedChallenges struct {
+	provider   challenge.Provider
+	challenges []challenge.BatchChallenge
+}
+
+// present presents the challenges in a single operation if the provider is a batch provider, otherwise one by one.
+// If a challenge fails, the challenges already presented are cleaned up.
+func (rc *routedChallenges) present() error {
+	if p, ok := rc.provider.(challenge.BatchProvider); ok {
+		return p.PresentBatch(rc.challenges)
+	}
+
+	for i, chlg := range rc.challenges {
+		err := rc.provider.Present(chlg.Domain, chlg.Token, chlg.KeyAuth)
+		if err != nil {
+			for _, presented := range rc.challenges[:i] {
+				_ = rc.provider.CleanUp(presented.Domain, presented.Token, presented.KeyAuth)
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cleanUp cleans up the challenges in a single operation if the provider is a batch provider, otherwise one by one.
+func (rc *routedChallenges) cleanUp() error {
+	if p, ok := rc.provider.(challenge.BatchProvider); ok {
+		return p.CleanUpBatch(rc.challenges)
+	}
+
+	var errs []error
+
+	for _, chlg := range rc.challenges {
+		errs = append(errs, rc.provider.CleanUp(chlg.Domain, chlg.Token, chlg.KeyAuth))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,228 @@
+package dns01
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingProvider struct {
+	name    string
+	domains *[]string
+}
+
+func (p recordingProvider) Present(domain, _, _ string) error {
+	*p.domains = append(*p.domains, p.name+":"+domain)
+	return nil
+}
+
+func (p recordingProvider) CleanUp(_, _, _ string) error {
+	return nil
+}
+
+type timeoutProvider struct {
+	recordingProvider
+
+	timeout, interval time.Duration
+}
+
+func (p timeoutProvider) Timeout() (timeout, interval time.Duration) {
+	return p.timeout, p.interval
+}
+
+type sequentialProvider struct {
+	recordingProvider
+}
+
+func (sequentialProvider) Sequential() time.Duration {
+	return 30 * time.Second
+}
+
+func TestNewRouter(t *testing.T) {
+	var domains []string
+
+	router, err := NewRouter([]Route{
+		{Domain: "example.com", Provider: recordingProvider{name: "a", domains: &domains}},
+		{Domain: "*.sub.example.com.", Provider: recordingProvider{name: "b", domains: &domains}},
+		{Domain: "Example.ORG", Provider: recordingProvider{name: "c", domains: &domains}},
+	}, recordingProvider{name: "default", domains: &domains})
+	require.NoError(t, err)
+
+	for _, domain := range []string{"example.com", "www.example.com", "sub.example.com", "a.sub.example.com", "example.org", "example.net", "notexample.com"} {
+		require.NoError(t, router.Present(domain, "", ""))
+	}
+
+	expected := []string{
+		"a:example.com",
+		"a:www.example.com",
+		"b:sub.example.com",
+		"b:a.sub.example.com",
+		"c:example.org",
+		"default:example.net",
+		"default:notexample.com",
+	}
+
+	assert.Equal(t, expected, domains)
+
+	_, ok := router.(sequential)
+	assert.False(t, ok)
+}
+
+func TestNewRouter_noDefault(t *testing.T) {
+	var domains []string
+
+	router, err := NewRouter([]Route{
+		{Domain: "example.com", Provider: recordingProvider{name: "a", domains: &domains}},
+	}, nil)
+	require.NoError(t, err)
+
+	err = router.Present("example.org", "", "")
+	require.EqualError(t, err, "router: no DNS provider for the domain example.org")
+}
+
+func TestNewRouter_errors(t *testing.T) {
+	provider := recordingProvider{domains: &[]string{}}
+
+	testCases := []struct {
+		desc     string
+		routes   []Route
+		expected string
+	}{
+		{
+			desc:     "no providers",
+			expected: "router: no DNS providers",
+		},
+		{
+			desc:     "empty domain",
+			routes:   []Route{{Domain: " ", Provider: provider}},
+			expected: `router: invalid domain " "`,
+		},
+		{
+			desc:     "missing provider",
+			routes:   []Route{{Domain: "example.com"}},
+			expected: "router: missing DNS provider for the domain example.com",
+		},
+		{
+			desc:     "duplicate domain",
+			routes:   []Route{{Domain: "example.com", Provider: provider}, {Domain: "EXAMPLE.com.", Provider: provider}},
+			expected: "router: duplicate route for the domain example.com",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewRouter(test.routes, nil)
+			require.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func TestRouter_Timeout(t *testing.T) {
+	domains := &[]string{}
+
+	router, err := NewRouter([]Route{
+		{Domain: "example.com", Provider: timeoutProvider{recordingProvider: recordingProvider{domains: domains}, timeout: 5 * time.Minute, interval: time.Second}},
+		{Domain: "example.org", Provider: recordingProvider{domains: domains}},
+	}, nil)
+	require.NoError(t, err)
+
+	timeout, interval := router.Timeout()
+	assert.Equal(t, 5*time.Minute, timeout)
+	assert.Equal(t, DefaultPollingInterval, interval)
+}
+
+func TestRouter_Sequential(t *testing.T) {
+	domains := &[]string{}
+
+	router, err := NewRouter([]Route{
+		{Domain: "example.com", Provider: sequentialProvider{recordingProvider: recordingProvider{domains: domains}}},
+	}, recordingProvider{domains: domains})
+	require.NoError(t, err)
+
+	p, ok := router.(sequential)
+	require.True(t, ok)
+
+	assert.Equal(t, 30*time.Second, p.Sequential())
+}
+
+func TestRouter_batch(t *testing.T) {
+	domains := &[]string{}
+
+	batchProvider := &providerBatchMock{}
+
+	router, err := NewRouter([]Route{
+		{Domain: "example.com", Provider: batchProvider},
+	}, recordingProvider{name: "default", domains: domains})
+	require.NoError(t, err)
+
+	// The challenges of a wrapped batch provider are presented with the batch operations.
+	assert.True(t, NewChallenge(nil, nil, router).Batch())
+
+	p, ok := router.(challenge.BatchProvider)
+	require.True(t, ok)
+
+	challenges := []challenge.BatchChallenge{
+		{Domain: "a.example.com", Token: "a"},
+		{Domain: "example.org", Token: "b"},
+		{Domain: "b.example.com", Token: "c"},
+	}
+
+	require.NoError(t, p.PresentBatch(challenges))
+
+	assert.Equal(t, []challenge.BatchChallenge{challenges[0], challenges[2]}, batchProvider.presented)
+	assert.Equal(t, []string{"default:example.org"}, *domains)
+
+	require.NoError(t, p.CleanUpBatch(challenges))
+
+	assert.Equal(t, []challenge.BatchChallenge{challenges[0], challenges[2]}, batchProvider.cleaned)
+}
+
+func TestRouter_batch_error(t *testing.T) {
+	batchProvider := &providerBatchMock{}
+
+	router, err := NewRouter([]Route{
+		{Domain: "example.com", Provider: batchProvider},
+	}, &providerMock{present: errors.New("OOPS")})
+	require.NoError(t, err)
+
+	p, ok := router.(challenge.BatchProvider)
+	require.True(t, ok)
+
+	challenges := []challenge.BatchChallenge{
+		{Domain: "a.example.com", Token: "a"},
+		{Domain: "example.org", Token: "b"},
+	}
+
+	err = p.PresentBatch(challenges)
+	require.EqualError(t, err, "router: OOPS")
+
+	// The challenges already presented are cleaned up.
+	assert.Equal(t, []challenge.BatchChallenge{challenges[0]}, batchProvider.cleaned)
+}
+
+func TestRouter_noBatch(t *testing.T) {
+	domains := &[]string{}
+
+	router, err := NewRouter([]Route{
+		{Domain: "example.com", Provider: recordingProvider{domains: domains}},
+	}, nil)
+	require.NoError(t, err)
+
+	_, ok := router.(challenge.BatchProvider)
+	assert.False(t, ok)
+
+	// The batch operations are not used with the sequential providers.
+	router, err = NewRouter([]Route{
+		{Domain: "example.com", Provider: sequentialProvider{recordingProvider: recordingProvider{domains: domains}}},
+	}, &providerBatchMock{})
+	require.NoError(t, err)
+
+	_, ok = router.(challenge.BatchProvider)
+	assert.False(t, ok)
+}
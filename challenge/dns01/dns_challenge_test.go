@@ -200,6 +200,31 @@ func TestChallenge_Solve(t *testing.T) {
 	}
 }
 
+func TestChallenge_Solve_propagationTimeout(t *testing.T) {
+	_, apiURL := tester.SetupFakeAPI(t)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", "", privateKey)
+	require.NoError(t, err)
+
+	chlg := NewChallenge(core,
+		func(_ *api.Core, _ string, _ acme.Challenge) error { return nil },
+		&providerTimeoutMock{timeout: time.Second, interval: 200 * time.Millisecond},
+		WrapPreCheck(func(_, _, _ string, _ PreCheckFunc) (bool, error) { return false, errors.New("NXDOMAIN") }),
+	)
+
+	authz := acme.Authorization{
+		Identifier: acme.Identifier{Value: "example.com"},
+		Challenges: []acme.Challenge{{Type: challenge.DNS01.String()}},
+	}
+
+	err = chlg.Solve(authz)
+	require.ErrorIs(t, err, ErrPropagationTimeout)
+	require.EqualError(t, err, "propagation: time limit exceeded: last error: NXDOMAIN")
+}
+
 func TestChallenge_CleanUp(t *testing.T) {
 	_, apiURL := tester.SetupFakeAPI(t)
 
@@ -7,11 +7,31 @@ import (
 	"net/http"
 	"net/textproto"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/go-acme/lego/v4/internal/sharedlistener"
 	"github.com/go-acme/lego/v4/log"
+	"golang.org/x/time/rate"
 )
 
+// ValidationAttempt describes a request received by the ProviderServer.
+// It is used for audit purposes (see ProviderServer.SetAuditLogger).
+type ValidationAttempt struct {
+	Time       time.Time
+	Domain     string
+	RemoteAddr string
+	Method     string
+	Host       string
+	Path       string
+	UserAgent  string
+	// Matched is true when the request matched the challenge (path, method, and domain).
+	Matched bool
+	// StatusCode is the HTTP status code sent to the client.
+	StatusCode int
+}
+
 // ProviderServer implements ChallengeProvider for `http-01` challenge.
 // It may be instantiated without using the NewProviderServer function if
 // you want only to use the default values.
@@ -24,6 +44,17 @@ type ProviderServer struct {
 	matcher  domainMatcher
 	done     chan bool
 	listener net.Listener
+
+	// inherited a listener provided by the caller (ex: systemd socket activation), not closed by the server.
+	inherited net.Listener
+
+	strict  bool
+	limiter *rate.Limiter
+	audit   func(ValidationAttempt)
+
+	redirector   PortRedirector
+	redirectFrom int
+	redirectTo   int
 }
 
 // NewProviderServer creates a new ProviderServer on the selected interface and port.
@@ -41,20 +72,44 @@ func NewUnixProviderServer(socketPath string, mode fs.FileMode) *ProviderServer
 	return &ProviderServer{network: "unix", address: socketPath, socketMode: mode, matcher: &hostMatcher{}}
 }
 
+// NewProviderServerWithListener creates a new ProviderServer using an existing listener (ex: a socket passed by systemd).
+// The listener is owned by the caller: it's not closed by CleanUp, so it can be used by the next challenges.
+func NewProviderServerWithListener(listener net.Listener) *ProviderServer {
+	return &ProviderServer{
+		network:   listener.Addr().Network(),
+		address:   listener.Addr().String(),
+		inherited: listener,
+		matcher:   &hostMatcher{},
+	}
+}
+
 // Present starts a web server and makes the token available at `ChallengePath(token)` for web requests.
 func (s *ProviderServer) Present(domain, token, keyAuth string) error {
 	var err error
-	s.listener, err = net.Listen(s.network, s.GetAddress())
-	if err != nil {
-		return fmt.Errorf("could not start HTTP server for challenge: %w", err)
+	if s.inherited != nil {
+		s.listener = sharedlistener.New(s.inherited)
+	} else {
+		s.listener, err = net.Listen(s.network, s.GetAddress())
+		if err != nil {
+			return fmt.Errorf("could not start HTTP server for challenge: %w", err)
+		}
 	}
 
-	if s.network == "unix" {
+	if s.network == "unix" && s.inherited == nil {
 		if err = os.Chmod(s.address, s.socketMode); err != nil {
 			return fmt.Errorf("chmod %s: %w", s.address, err)
 		}
 	}
 
+	if s.redirector != nil {
+		err = s.installRedirect()
+		if err != nil {
+			_ = s.listener.Close()
+			s.listener = nil
+			return err
+		}
+	}
+
 	s.done = make(chan bool)
 
 	go s.serve(domain, token, keyAuth)
@@ -76,6 +131,14 @@ func (s *ProviderServer) CleanUp(domain, token, keyAuth string) error {
 
 	<-s.done
 
+	if s.redirector != nil && s.redirectTo > 0 {
+		err := s.redirector.Remove(s.redirectFrom, s.redirectTo)
+		s.redirectTo = 0
+		if err != nil {
+			return fmt.Errorf("could not remove the port redirect of the HTTP challenge server: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -102,46 +165,148 @@ func (s *ProviderServer) SetProxyHeader(headerName string) {
 	}
 }
 
+// SetPortRedirect makes the server install a redirect rule from the port `from` (usually 80)
+// to its own listening port when a challenge is presented, and remove it on cleanup.
+// It's only supported by TCP servers.
+func (s *ProviderServer) SetPortRedirect(redirector PortRedirector, from int) {
+	s.redirector = redirector
+	s.redirectFrom = from
+}
+
+// SetStrictValidation changes the way requests that do not match the challenge are answered.
+// By default, the server answers them with a dummy body and a 200 status code.
+// In strict mode, requests with an unexpected method or domain are rejected with a 403 status code,
+// and requests for other paths with a 404 status code.
+func (s *ProviderServer) SetStrictValidation(strict bool) {
+	s.strict = strict
+}
+
+// SetRateLimit limits the number of requests per second to the challenge path answered by the server,
+// using a token bucket of the given burst size.
+// The requests over the limit are rejected with a 429 status code.
+// A limit lower or equal to 0 disables the rate limiting.
+func (s *ProviderServer) SetRateLimit(limit float64, burst int) {
+	if limit <= 0 {
+		s.limiter = nil
+		return
+	}
+
+	s.limiter = rate.NewLimiter(rate.Limit(limit), max(burst, 1))
+}
+
+// SetAuditLogger sets a function called for every request received by the server.
+func (s *ProviderServer) SetAuditLogger(fn func(ValidationAttempt)) {
+	s.audit = fn
+}
+
+func (s *ProviderServer) installRedirect() error {
+	if s.network != "tcp" {
+		return fmt.Errorf("port redirect is not supported by %s HTTP challenge servers", s.network)
+	}
+
+	_, port, err := net.SplitHostPort(s.listener.Addr().String())
+	if err != nil {
+		return fmt.Errorf("could not get the port of the HTTP challenge server: %w", err)
+	}
+
+	to, err := strconv.Atoi(port)
+	if err != nil {
+		return fmt.Errorf("could not get the port of the HTTP challenge server: %w", err)
+	}
+
+	if to == s.redirectFrom {
+		return nil
+	}
+
+	err = s.redirector.Install(s.redirectFrom, to)
+	if err != nil {
+		return fmt.Errorf("could not install the port redirect of the HTTP challenge server: %w", err)
+	}
+
+	s.redirectTo = to
+
+	return nil
+}
+
 func (s *ProviderServer) serve(domain, token, keyAuth string) {
+	httpServer := &http.Server{Handler: s.handler(domain, token, keyAuth)}
+
+	// Once httpServer is shut down
+	// we don't want any lingering connections, so disable KeepAlives.
+	httpServer.SetKeepAlivesEnabled(false)
+
+	err := httpServer.Serve(s.listener)
+	if err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+		log.Println(err)
+	}
+
+	s.done <- true
+}
+
+func (s *ProviderServer) handler(domain, token, keyAuth string) http.Handler {
 	path := ChallengePath(token)
 
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempt := ValidationAttempt{
+			Time:       time.Now(),
+			Domain:     domain,
+			RemoteAddr: r.RemoteAddr,
+			Method:     r.Method,
+			Host:       r.Host,
+			Path:       r.URL.Path,
+			UserAgent:  r.UserAgent(),
+		}
+
+		attempt.StatusCode = s.respond(w, r, path, domain, keyAuth, &attempt)
+
+		if s.audit != nil {
+			s.audit(attempt)
+		}
+	})
+}
+
+func (s *ProviderServer) respond(w http.ResponseWriter, r *http.Request, path, domain, keyAuth string, attempt *ValidationAttempt) int {
+	if r.URL.Path != path {
+		http.NotFound(w, r)
+		return http.StatusNotFound
+	}
+
+	// Only the requests for the challenge path consume the rate limit budget.
+	if s.limiter != nil && !s.limiter.Allow() {
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return http.StatusTooManyRequests
+	}
+
 	// The incoming request will be validated to prevent DNS rebind attacks.
 	// We only respond with the keyAuth, when we're receiving a GET requests with
 	// the "Host" header matching the domain (the latter is configurable though SetProxyHeader).
-	mux := http.NewServeMux()
-	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodGet && s.matcher.matches(r, domain) {
-			w.Header().Set("Content-Type", "text/plain")
-
-			_, err := w.Write([]byte(keyAuth))
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-
-			log.Infof("[%s] Served key authentication", domain)
-			return
-		}
+	if r.Method == http.MethodGet && s.matcher.matches(r, domain) {
+		attempt.Matched = true
 
-		log.Warnf("Received request for domain %s with method %s but the domain did not match any challenge. Please ensure you are passing the %s header properly.", r.Host, r.Method, s.matcher.name())
+		w.Header().Set("Content-Type", "text/plain")
 
-		_, err := w.Write([]byte("TEST"))
+		_, err := w.Write([]byte(keyAuth))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+			return http.StatusInternalServerError
 		}
-	})
 
-	httpServer := &http.Server{Handler: mux}
+		log.WithDomain(domain).Infof("Served key authentication")
+		return http.StatusOK
+	}
 
-	// Once httpServer is shut down
-	// we don't want any lingering connections, so disable KeepAlives.
-	httpServer.SetKeepAlivesEnabled(false)
+	log.Warnf("Received request for domain %s with method %s but the domain did not match any challenge. Please ensure you are passing the %s header properly.", r.Host, r.Method, s.matcher.name())
 
-	err := httpServer.Serve(s.listener)
-	if err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
-		log.Println(err)
+	if s.strict {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return http.StatusForbidden
 	}
 
-	s.done <- true
+	_, err := w.Write([]byte("TEST"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return http.StatusInternalServerError
+	}
+
+	return http.StatusOK
 }
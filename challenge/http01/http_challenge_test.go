@@ -9,6 +9,7 @@ import (
 	"io/fs"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/textproto"
 	"os"
 	"path/filepath"
@@ -435,3 +436,141 @@ func testServeWithProxy(t *testing.T, header, extra *testProxyHeader, expectErro
 		require.NoError(t, err)
 	}
 }
+
+func TestProviderServer_handler_strict(t *testing.T) {
+	providerServer := NewProviderServer("", "")
+	providerServer.SetStrictValidation(true)
+
+	var attempts []ValidationAttempt
+	providerServer.SetAuditLogger(func(attempt ValidationAttempt) {
+		attempts = append(attempts, attempt)
+	})
+
+	handler := providerServer.handler("example.com", "token", "keyAuth")
+
+	testCases := []struct {
+		desc         string
+		method       string
+		target       string
+		host         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			desc:         "valid request",
+			method:       http.MethodGet,
+			target:       ChallengePath("token"),
+			host:         "example.com",
+			expectedCode: http.StatusOK,
+			expectedBody: "keyAuth",
+		},
+		{
+			desc:         "host mismatch",
+			method:       http.MethodGet,
+			target:       ChallengePath("token"),
+			host:         "example.org",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "method mismatch",
+			method:       http.MethodPost,
+			target:       ChallengePath("token"),
+			host:         "example.com",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "unknown path",
+			method:       http.MethodGet,
+			target:       "/wp-admin",
+			host:         "example.com",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		req := httptest.NewRequest(test.method, test.target, nil)
+		req.Host = test.host
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, test.expectedCode, rec.Code, test.desc)
+
+		if test.expectedBody != "" {
+			assert.Equal(t, test.expectedBody, rec.Body.String(), test.desc)
+		}
+	}
+
+	require.Len(t, attempts, len(testCases))
+
+	for i, test := range testCases {
+		assert.Equal(t, test.expectedCode, attempts[i].StatusCode, test.desc)
+		assert.Equal(t, test.expectedCode == http.StatusOK, attempts[i].Matched, test.desc)
+		assert.Equal(t, test.host, attempts[i].Host, test.desc)
+		assert.Equal(t, "example.com", attempts[i].Domain, test.desc)
+	}
+}
+
+func TestProviderServer_handler_rateLimit(t *testing.T) {
+	providerServer := NewProviderServer("", "")
+	providerServer.SetRateLimit(0.001, 2)
+
+	handler := providerServer.handler("example.com", "token", "keyAuth")
+
+	// The requests for other paths don't consume the budget.
+	for range 3 {
+		req := httptest.NewRequest(http.MethodGet, "/other", nil)
+		req.Host = "example.com"
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+
+	var codes []int
+	for range 3 {
+		req := httptest.NewRequest(http.MethodGet, ChallengePath("token"), nil)
+		req.Host = "example.com"
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		codes = append(codes, rec.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+}
+
+func TestProviderServer_withListener(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	providerServer := NewProviderServerWithListener(listener)
+
+	assert.Equal(t, listener.Addr().String(), providerServer.GetAddress())
+
+	// the listener is used by successive challenges.
+	for _, token := range []string{"token1", "token2"} {
+		require.NoError(t, providerServer.Present("example.com", token, token+".keyAuth"))
+
+		req, err := http.NewRequest(http.MethodGet, "http://"+listener.Addr().String()+ChallengePath(token), nil)
+		require.NoError(t, err)
+
+		req.Host = "example.com"
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, token+".keyAuth", string(body))
+
+		require.NoError(t, providerServer.CleanUp("example.com", token, token+".keyAuth"))
+	}
+}
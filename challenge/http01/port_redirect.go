@@ -0,0 +1,161 @@
+package http01
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PortRedirector installs and removes a redirect rule from a privileged port (ex: 80) to the port of the ProviderServer.
+// It allows an unprivileged lego process to listen on a high port while the validation requests are sent to the port 80.
+type PortRedirector interface {
+	// Install installs a rule redirecting the TCP traffic from the port `from` to the port `to`.
+	Install(from, to int) error
+	// Remove removes the rule previously installed by Install.
+	Remove(from, to int) error
+}
+
+// commandRunner executes a command.
+type commandRunner func(ctx context.Context, name string, args ...string) error
+
+func runCommand(ctx context.Context, name string, args ...string) error {
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// IPTablesRedirector manages the redirect rule with iptables (and ip6tables).
+type IPTablesRedirector struct {
+	// IPv6 also manages the rule with ip6tables.
+	IPv6 bool
+
+	run commandRunner
+}
+
+// NewIPTablesRedirector creates a new IPTablesRedirector.
+func NewIPTablesRedirector(ipv6 bool) *IPTablesRedirector {
+	return &IPTablesRedirector{IPv6: ipv6, run: runCommand}
+}
+
+// Install appends the redirect rule to the `nat` table.
+// If a rule cannot be installed, the rules already installed are removed.
+func (r *IPTablesRedirector) Install(from, to int) error {
+	binaries := r.binaries()
+
+	for i, bin := range binaries {
+		err := r.exec(bin, "-A", from, to)
+		if err == nil {
+			continue
+		}
+
+		for _, installed := range binaries[:i] {
+			_ = r.exec(installed, "-D", from, to)
+		}
+
+		return fmt.Errorf("iptables: install redirect rule: %w", err)
+	}
+
+	return nil
+}
+
+// Remove deletes the redirect rule from the `nat` table.
+func (r *IPTablesRedirector) Remove(from, to int) error {
+	var errs []string
+	for _, bin := range r.binaries() {
+		err := r.exec(bin, "-D", from, to)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("iptables: remove redirect rule: %s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (r *IPTablesRedirector) binaries() []string {
+	if r.IPv6 {
+		return []string{"iptables", "ip6tables"}
+	}
+
+	return []string{"iptables"}
+}
+
+func (r *IPTablesRedirector) exec(bin, action string, from, to int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return r.run(ctx, bin, "-t", "nat", action, "PREROUTING",
+		"-p", "tcp", "--dport", strconv.Itoa(from),
+		"-j", "REDIRECT", "--to-ports", strconv.Itoa(to),
+		"-m", "comment", "--comment", "lego http-01")
+}
+
+// NFTablesRedirector manages the redirect rule with nftables.
+// The rule is created inside a dedicated table, which is deleted on removal.
+type NFTablesRedirector struct {
+	// Table is the name of the dedicated table (inet family).
+	Table string
+
+	run commandRunner
+}
+
+// NewNFTablesRedirector creates a new NFTablesRedirector.
+func NewNFTablesRedirector() *NFTablesRedirector {
+	return &NFTablesRedirector{Table: "lego", run: runCommand}
+}
+
+// Install creates the dedicated table, a nat prerouting chain, and the redirect rule.
+// If a command fails after the creation of the table, the table is deleted.
+func (r *NFTablesRedirector) Install(from, to int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	commands := [][]string{
+		{"add", "table", "inet", r.Table},
+		{"add", "chain", "inet", r.Table, "prerouting", "{ type nat hook prerouting priority dstnat; }"},
+		{"add", "rule", "inet", r.Table, "prerouting", "tcp", "dport", strconv.Itoa(from), "redirect", "to", ":" + strconv.Itoa(to)},
+	}
+
+	for i, args := range commands {
+		err := r.run(ctx, "nft", args...)
+		if err == nil {
+			continue
+		}
+
+		if i > 0 {
+			_ = r.run(ctx, "nft", "delete", "table", "inet", r.Table)
+		}
+
+		return fmt.Errorf("nftables: install redirect rule: %w", err)
+	}
+
+	return nil
+}
+
+// Remove deletes the dedicated table.
+func (r *NFTablesRedirector) Remove(_, _ int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := r.run(ctx, "nft", "delete", "table", "inet", r.Table)
+	if err != nil {
+		return fmt.Errorf("nftables: remove redirect rule: %w", err)
+	}
+
+	return nil
+}
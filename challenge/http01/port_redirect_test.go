@@ -0,0 +1,184 @@
+package http01
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedCommands []string
+
+func (r *recordedCommands) run(_ context.Context, name string, args ...string) error {
+	*r = append(*r, name+" "+strings.Join(args, " "))
+	return nil
+}
+
+// failOn records the commands, and fails the command starting with the prefix.
+func (r *recordedCommands) failOn(prefix string) commandRunner {
+	return func(ctx context.Context, name string, args ...string) error {
+		_ = r.run(ctx, name, args...)
+
+		if strings.HasPrefix((*r)[len(*r)-1], prefix) {
+			return errors.New("failed")
+		}
+
+		return nil
+	}
+}
+
+func TestIPTablesRedirector(t *testing.T) {
+	var commands recordedCommands
+
+	redirector := &IPTablesRedirector{IPv6: true, run: commands.run}
+
+	err := redirector.Install(80, 8080)
+	require.NoError(t, err)
+
+	err = redirector.Remove(80, 8080)
+	require.NoError(t, err)
+
+	expected := recordedCommands{
+		"iptables -t nat -A PREROUTING -p tcp --dport 80 -j REDIRECT --to-ports 8080 -m comment --comment lego http-01",
+		"ip6tables -t nat -A PREROUTING -p tcp --dport 80 -j REDIRECT --to-ports 8080 -m comment --comment lego http-01",
+		"iptables -t nat -D PREROUTING -p tcp --dport 80 -j REDIRECT --to-ports 8080 -m comment --comment lego http-01",
+		"ip6tables -t nat -D PREROUTING -p tcp --dport 80 -j REDIRECT --to-ports 8080 -m comment --comment lego http-01",
+	}
+
+	assert.Equal(t, expected, commands)
+}
+
+func TestNFTablesRedirector(t *testing.T) {
+	var commands recordedCommands
+
+	redirector := &NFTablesRedirector{Table: "lego", run: commands.run}
+
+	err := redirector.Install(80, 8080)
+	require.NoError(t, err)
+
+	err = redirector.Remove(80, 8080)
+	require.NoError(t, err)
+
+	expected := recordedCommands{
+		"nft add table inet lego",
+		"nft add chain inet lego prerouting { type nat hook prerouting priority dstnat; }",
+		"nft add rule inet lego prerouting tcp dport 80 redirect to :8080",
+		"nft delete table inet lego",
+	}
+
+	assert.Equal(t, expected, commands)
+}
+
+func TestIPTablesRedirector_Install_rollback(t *testing.T) {
+	var commands recordedCommands
+
+	redirector := &IPTablesRedirector{IPv6: true, run: commands.failOn("ip6tables")}
+
+	err := redirector.Install(80, 8080)
+	require.EqualError(t, err, "iptables: install redirect rule: failed")
+
+	expected := recordedCommands{
+		"iptables -t nat -A PREROUTING -p tcp --dport 80 -j REDIRECT --to-ports 8080 -m comment --comment lego http-01",
+		"ip6tables -t nat -A PREROUTING -p tcp --dport 80 -j REDIRECT --to-ports 8080 -m comment --comment lego http-01",
+		"iptables -t nat -D PREROUTING -p tcp --dport 80 -j REDIRECT --to-ports 8080 -m comment --comment lego http-01",
+	}
+
+	assert.Equal(t, expected, commands)
+}
+
+func TestNFTablesRedirector_Install_rollback(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		failOn   string
+		expected recordedCommands
+	}{
+		{
+			desc:   "table",
+			failOn: "nft add table",
+			expected: recordedCommands{
+				"nft add table inet lego",
+			},
+		},
+		{
+			desc:   "rule",
+			failOn: "nft add rule",
+			expected: recordedCommands{
+				"nft add table inet lego",
+				"nft add chain inet lego prerouting { type nat hook prerouting priority dstnat; }",
+				"nft add rule inet lego prerouting tcp dport 80 redirect to :8080",
+				"nft delete table inet lego",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			var commands recordedCommands
+
+			redirector := &NFTablesRedirector{Table: "lego", run: commands.failOn(test.failOn)}
+
+			err := redirector.Install(80, 8080)
+			require.EqualError(t, err, "nftables: install redirect rule: failed")
+
+			assert.Equal(t, test.expected, commands)
+		})
+	}
+}
+
+type fakeRedirector struct {
+	installed map[int]int
+	err       error
+}
+
+func (f *fakeRedirector) Install(from, to int) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	f.installed[from] = to
+	return nil
+}
+
+func (f *fakeRedirector) Remove(from, _ int) error {
+	delete(f.installed, from)
+	return nil
+}
+
+func TestProviderServer_SetPortRedirect(t *testing.T) {
+	redirector := &fakeRedirector{installed: map[int]int{}}
+
+	providerServer := NewProviderServer("127.0.0.1", "23458")
+	providerServer.SetPortRedirect(redirector, 80)
+
+	err := providerServer.Present("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[int]int{80: 23458}, redirector.installed)
+
+	err = providerServer.CleanUp("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	assert.Empty(t, redirector.installed)
+}
+
+func TestProviderServer_SetPortRedirect_error(t *testing.T) {
+	redirector := &fakeRedirector{err: errors.New("permission denied")}
+
+	providerServer := NewProviderServer("127.0.0.1", "23459")
+	providerServer.SetPortRedirect(redirector, 80)
+
+	err := providerServer.Present("example.com", "token", "keyAuth")
+	require.EqualError(t, err, "could not install the port redirect of the HTTP challenge server: permission denied")
+
+	// the listener must be released.
+	providerServer = NewProviderServer("127.0.0.1", "23459")
+
+	err = providerServer.Present("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	err = providerServer.CleanUp("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+}
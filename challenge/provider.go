@@ -26,3 +26,31 @@ type ProviderTimeout interface {
 	Provider
 	Timeout() (timeout, interval time.Duration)
 }
+
+// BatchProvider allows for implementing a Provider
+// that can present (and clean up) the solutions of several challenges in a single operation,
+// such as a DNS provider creating all the TXT records of an order with one API call.
+// If PresentBatch returns an error, no solution must be presented:
+// the challenges are then presented one by one with Present.
+type BatchProvider interface {
+	Provider
+	PresentBatch(challenges []BatchChallenge) error
+	CleanUpBatch(challenges []BatchChallenge) error
+}
+
+// BatchChallenge the parameters of a challenge of a batch (the parameters of Present and CleanUp).
+type BatchChallenge struct {
+	Domain  string
+	Token   string
+	KeyAuth string
+}
+
+// AccountProvider allows for implementing a DNS Provider
+// that can solve the dns-account-01 challenge.
+// The name of the TXT record contains the label of the ACME account (i.e. `<label>._acme-challenge.[domain].`),
+// the label is passed explicitly because it cannot be derived from the key authorization.
+type AccountProvider interface {
+	Provider
+	PresentAccount(label, domain, token, keyAuth string) error
+	CleanUpAccount(label, domain, token, keyAuth string) error
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
+
+	"github.com/go-acme/lego/v4/acme"
 )
 
 // obtainError is returned when there are specific errors available per domain.
@@ -23,3 +25,19 @@ func (e obtainError) Error() string {
 	}
 	return buffer.String()
 }
+
+// Unwrap returns the errors per domain (acme.IdentifierError).
+func (e obtainError) Unwrap() []error {
+	var domains []string
+	for domain := range e {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	var errs []error
+	for _, domain := range domains {
+		errs = append(errs, &acme.IdentifierError{Identifier: domain, Err: e[domain]})
+	}
+
+	return errs
+}
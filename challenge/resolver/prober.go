@@ -2,6 +2,7 @@ package resolver
 
 import (
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/go-acme/lego/v4/acme"
@@ -25,6 +26,13 @@ type cleanup interface {
 	CleanUp(authorization acme.Authorization) error
 }
 
+// Interface for challenges like dns, where the records of several challenges can be set (and deleted) in a single operation.
+type batchSolver interface {
+	Batch() bool
+	PreSolveBatch(authorizations []acme.Authorization) error
+	CleanUpBatch(authorizations []acme.Authorization) error
+}
+
 type sequential interface {
 	Sequential() (bool, time.Duration)
 }
@@ -45,6 +53,11 @@ func NewProber(solverManager *SolverManager) *Prober {
 	}
 }
 
+// ChallengeType returns the type of the challenge chosen by Solve to validate the authorization.
+func (p *Prober) ChallengeType(authz acme.Authorization) (challenge.Type, bool) {
+	return p.solverManager.challengeType(authz)
+}
+
 // Solve Looks through the challenge combinations to find a solvable match.
 // Then solves the challenges in series and returns.
 func (p *Prober) Solve(authorizations []acme.Authorization) error {
@@ -59,7 +72,7 @@ func (p *Prober) Solve(authorizations []acme.Authorization) error {
 		domain := challenge.GetTargetedDomain(authz)
 		if authz.Status == acme.StatusValid {
 			// Boulder might recycle recent validated authz (see issue #267)
-			log.Infof("[%s] acme: authorization already valid; skipping challenge", domain)
+			log.WithDomain(domain).Infof("acme: authorization already valid; skipping challenge")
 			continue
 		}
 
@@ -128,8 +141,15 @@ func sequentialSolve(authSolvers []*selectedAuthSolver, failures obtainError) {
 }
 
 func parallelSolve(authSolvers []*selectedAuthSolver, failures obtainError) {
+	// For the batch solvers, submit the challenges in a single operation by solver.
+	batches := preSolveBatches(authSolvers)
+
 	// For all valid preSolvers, first submit the challenges, so they have max time to propagate
 	for _, authSolver := range authSolvers {
+		if batches.contains(authSolver) {
+			continue
+		}
+
 		authz := authSolver.authz
 		if solvr, ok := authSolver.solver.(preSolver); ok {
 			err := solvr.PreSolve(authz)
@@ -141,7 +161,13 @@ func parallelSolve(authSolvers []*selectedAuthSolver, failures obtainError) {
 
 	defer func() {
 		// Clean all created TXT records
+		cleanUpBatches(batches)
+
 		for _, authSolver := range authSolvers {
+			if batches.contains(authSolver) {
+				continue
+			}
+
 			cleanUp(authSolver.solver, authSolver.authz)
 		}
 	}()
@@ -162,12 +188,72 @@ func parallelSolve(authSolvers []*selectedAuthSolver, failures obtainError) {
 	}
 }
 
+// authSolverBatches the challenges submitted in a single operation, by solver.
+type authSolverBatches map[batchSolver][]*selectedAuthSolver
+
+func (b authSolverBatches) contains(authSolver *selectedAuthSolver) bool {
+	solvr, ok := authSolver.solver.(batchSolver)
+	if !ok {
+		return false
+	}
+
+	return slices.Contains(b[solvr], authSolver)
+}
+
+// preSolveBatches submits the challenges of the batch solvers in a single operation by solver.
+// When a batch fails, its challenges are not part of the returned batches: they are submitted one by one.
+func preSolveBatches(authSolvers []*selectedAuthSolver) authSolverBatches {
+	batches := make(authSolverBatches)
+
+	for _, authSolver := range authSolvers {
+		if solvr, ok := authSolver.solver.(batchSolver); ok && solvr.Batch() {
+			batches[solvr] = append(batches[solvr], authSolver)
+		}
+	}
+
+	for solvr, batch := range batches {
+		// A batch of one challenge is a regular submission.
+		if len(batch) < 2 {
+			delete(batches, solvr)
+			continue
+		}
+
+		err := solvr.PreSolveBatch(authorizations(batch))
+		if err != nil {
+			log.Warnf("acme: the batch submission of %d challenges failed, the challenges are submitted one by one: %v", len(batch), err)
+
+			delete(batches, solvr)
+		}
+	}
+
+	return batches
+}
+
+func cleanUpBatches(batches authSolverBatches) {
+	for solvr, batch := range batches {
+		err := solvr.CleanUpBatch(authorizations(batch))
+		if err != nil {
+			log.Warnf("acme: cleaning up the batch of %d challenges failed: %v ", len(batch), err)
+		}
+	}
+}
+
+func authorizations(authSolvers []*selectedAuthSolver) []acme.Authorization {
+	var authzs []acme.Authorization
+
+	for _, authSolver := range authSolvers {
+		authzs = append(authzs, authSolver.authz)
+	}
+
+	return authzs
+}
+
 func cleanUp(solvr solver, authz acme.Authorization) {
 	if solvr, ok := solvr.(cleanup); ok {
 		domain := challenge.GetTargetedDomain(authz)
 		err := solvr.CleanUp(authz)
 		if err != nil {
-			log.Warnf("[%s] acme: cleaning up failed: %v ", domain, err)
+			log.WithDomain(domain).Warnf("acme: cleaning up failed: %v ", err)
 		}
 	}
 }
@@ -25,6 +25,43 @@ func (s *preSolverMock) CleanUp(authorization acme.Authorization) error {
 	return s.cleanUp[authorization.Identifier.Value]
 }
 
+type batchSolverMock struct {
+	preSolverMock
+
+	preSolveBatch error
+
+	preSolved, batchPreSolved, batchCleaned []string
+}
+
+func (s *batchSolverMock) PreSolve(authorization acme.Authorization) error {
+	s.preSolved = append(s.preSolved, authorization.Identifier.Value)
+	return s.preSolverMock.PreSolve(authorization)
+}
+
+func (s *batchSolverMock) Batch() bool {
+	return true
+}
+
+func (s *batchSolverMock) PreSolveBatch(authorizations []acme.Authorization) error {
+	if s.preSolveBatch != nil {
+		return s.preSolveBatch
+	}
+
+	for _, authz := range authorizations {
+		s.batchPreSolved = append(s.batchPreSolved, authz.Identifier.Value)
+	}
+
+	return nil
+}
+
+func (s *batchSolverMock) CleanUpBatch(authorizations []acme.Authorization) error {
+	for _, authz := range authorizations {
+		s.batchCleaned = append(s.batchCleaned, authz.Identifier.Value)
+	}
+
+	return nil
+}
+
 func createStubAuthorizationHTTP01(domain, status string) acme.Authorization {
 	return acme.Authorization{
 		Status:  status,
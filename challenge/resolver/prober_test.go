@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-acme/lego/v4/acme"
 	"github.com/go-acme/lego/v4/challenge"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -115,3 +116,95 @@ func TestProber_Solve(t *testing.T) {
 		})
 	}
 }
+
+func TestProber_Solve_batch(t *testing.T) {
+	testCases := []struct {
+		desc                   string
+		preSolveBatch          error
+		authz                  []acme.Authorization
+		expectedPreSolved      []string
+		expectedBatchPreSolved []string
+		expectedBatchCleaned   []string
+	}{
+		{
+			desc: "batch",
+			authz: []acme.Authorization{
+				createStubAuthorizationHTTP01("acme.wtf", acme.StatusProcessing),
+				createStubAuthorizationHTTP01("lego.wtf", acme.StatusProcessing),
+			},
+			expectedBatchPreSolved: []string{"acme.wtf", "lego.wtf"},
+			expectedBatchCleaned:   []string{"acme.wtf", "lego.wtf"},
+		},
+		{
+			desc: "one challenge",
+			authz: []acme.Authorization{
+				createStubAuthorizationHTTP01("acme.wtf", acme.StatusProcessing),
+			},
+			expectedPreSolved: []string{"acme.wtf"},
+		},
+		{
+			desc:          "batch failure",
+			preSolveBatch: errors.New("batch error"),
+			authz: []acme.Authorization{
+				createStubAuthorizationHTTP01("acme.wtf", acme.StatusProcessing),
+				createStubAuthorizationHTTP01("lego.wtf", acme.StatusProcessing),
+			},
+			expectedPreSolved: []string{"acme.wtf", "lego.wtf"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			solvr := &batchSolverMock{
+				preSolverMock: preSolverMock{
+					preSolve: map[string]error{},
+					solve:    map[string]error{},
+					cleanUp:  map[string]error{},
+				},
+				preSolveBatch: test.preSolveBatch,
+			}
+
+			prober := &Prober{
+				solverManager: &SolverManager{solvers: map[challenge.Type]solver{challenge.HTTP01: solvr}},
+			}
+
+			err := prober.Solve(test.authz)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedPreSolved, solvr.preSolved)
+			assert.Equal(t, test.expectedBatchPreSolved, solvr.batchPreSolved)
+			assert.Equal(t, test.expectedBatchCleaned, solvr.batchCleaned)
+		})
+	}
+}
+
+func TestProber_ChallengeType(t *testing.T) {
+	prober := &Prober{
+		solverManager: &SolverManager{solvers: map[challenge.Type]solver{
+			challenge.HTTP01: &preSolverMock{},
+			challenge.DNS01:  &preSolverMock{},
+		}},
+	}
+
+	authz := acme.Authorization{
+		Identifier: acme.Identifier{Type: "dns", Value: "example.com"},
+		Challenges: []acme.Challenge{
+			{Type: challenge.TLSALPN01.String()},
+			{Type: challenge.HTTP01.String()},
+			{Type: challenge.DNS01.String()},
+		},
+	}
+
+	chlgType, ok := prober.ChallengeType(authz)
+	require.True(t, ok)
+
+	assert.Equal(t, challenge.HTTP01, chlgType)
+
+	// the challenges of the authorization are not reordered.
+	assert.Equal(t, challenge.TLSALPN01.String(), authz.Challenges[0].Type)
+
+	_, ok = prober.ChallengeType(acme.Authorization{Challenges: []acme.Challenge{{Type: challenge.TLSALPN01.String()}}})
+	assert.False(t, ok)
+}
@@ -3,6 +3,7 @@ package resolver
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
 	"strconv"
 	"time"
@@ -53,6 +54,17 @@ func (c *SolverManager) SetDNS01Provider(p challenge.Provider, opts ...dns01.Cha
 	return nil
 }
 
+// SetDNSAccount01Provider specifies a custom provider p that can solve the given DNS-ACCOUNT-01 challenge.
+// The provider must implement challenge.AccountProvider.
+func (c *SolverManager) SetDNSAccount01Provider(p challenge.Provider, opts ...dns01.ChallengeOption) error {
+	if _, ok := p.(challenge.AccountProvider); !ok {
+		return dns01.ErrAccountChallengeNotSupported
+	}
+
+	c.solvers[challenge.DNSAccount01] = dns01.NewAccountChallenge(c.core, validate, p, opts...)
+	return nil
+}
+
 // Remove removes a challenge type from the available solvers.
 func (c *SolverManager) Remove(chlgType challenge.Type) {
 	delete(c.solvers, chlgType)
@@ -60,19 +72,36 @@ func (c *SolverManager) Remove(chlgType challenge.Type) {
 
 // Checks all challenges from the server in order and returns the first matching solver.
 func (c *SolverManager) chooseSolver(authz acme.Authorization) solver {
+	domain := challenge.GetTargetedDomain(authz)
+
+	chlgType, ok := c.challengeType(authz)
+	if !ok {
+		for _, chlg := range authz.Challenges {
+			log.WithDomain(domain).Infof("acme: Could not find solver for: %s", chlg.Type)
+		}
+
+		return nil
+	}
+
+	log.WithDomain(domain).Infof("acme: use %s solver", chlgType)
+
+	return c.solvers[chlgType]
+}
+
+// challengeType returns the type of the first challenge of the authorization with a solver.
+func (c *SolverManager) challengeType(authz acme.Authorization) (challenge.Type, bool) {
+	challenges := slices.Clone(authz.Challenges)
+
 	// Allow to have a deterministic challenge order
-	sort.Sort(byType(authz.Challenges))
+	sort.Sort(byType(challenges))
 
-	domain := challenge.GetTargetedDomain(authz)
-	for _, chlg := range authz.Challenges {
-		if solvr, ok := c.solvers[challenge.Type(chlg.Type)]; ok {
-			log.Infof("[%s] acme: use %s solver", domain, chlg.Type)
-			return solvr
+	for _, chlg := range challenges {
+		if _, ok := c.solvers[challenge.Type(chlg.Type)]; ok {
+			return challenge.Type(chlg.Type), true
 		}
-		log.Infof("[%s] acme: Could not find solver for: %s", domain, chlg.Type)
 	}
 
-	return nil
+	return "", false
 }
 
 func validate(core *api.Core, domain string, chlg acme.Challenge) error {
@@ -87,7 +116,7 @@ func validate(core *api.Core, domain string, chlg acme.Challenge) error {
 	}
 
 	if valid {
-		log.Infof("[%s] The server validated our request", domain)
+		log.WithDomain(domain).Infof("The server validated our request")
 		return nil
 	}
 
@@ -120,7 +149,7 @@ func validate(core *api.Core, domain string, chlg acme.Challenge) error {
 		}
 
 		if valid {
-			log.Infof("[%s] The server validated our request", domain)
+			log.WithDomain(domain).Infof("The server validated our request")
 			return nil
 		}
 
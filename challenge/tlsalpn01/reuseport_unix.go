@@ -0,0 +1,23 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package tlsalpn01
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// reusePortControl sets the SO_REUSEPORT option on the socket.
+func reusePortControl(_, _ string, conn syscall.RawConn) error {
+	var errS error
+
+	err := conn.Control(func(fd uintptr) {
+		errS = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	})
+	if err != nil {
+		return err
+	}
+
+	return errS
+}
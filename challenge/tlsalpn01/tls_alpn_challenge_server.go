@@ -1,13 +1,15 @@
 package tlsalpn01
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
-	"strings"
+	"time"
 
+	"github.com/go-acme/lego/v4/internal/sharedlistener"
 	"github.com/go-acme/lego/v4/log"
 )
 
@@ -27,6 +29,13 @@ type ProviderServer struct {
 	iface    string
 	port     string
 	listener net.Listener
+	server   *http.Server
+
+	// inherited a listener provided by the caller (ex: systemd socket activation), not closed by the server.
+	inherited net.Listener
+
+	reusePort    bool
+	drainTimeout time.Duration
 }
 
 // NewProviderServer creates a new ProviderServer on the selected interface and port.
@@ -36,10 +45,35 @@ func NewProviderServer(iface, port string) *ProviderServer {
 	return &ProviderServer{iface: iface, port: port}
 }
 
+// NewProviderServerWithListener creates a new ProviderServer using an existing TCP listener (ex: a socket passed by systemd).
+// The listener is owned by the caller: it's not closed by CleanUp, so it can be used by the next challenges.
+func NewProviderServerWithListener(listener net.Listener) *ProviderServer {
+	s := &ProviderServer{inherited: listener}
+
+	if host, port, err := net.SplitHostPort(listener.Addr().String()); err == nil {
+		s.iface, s.port = host, port
+	}
+
+	return s
+}
+
 func (s *ProviderServer) GetAddress() string {
 	return net.JoinHostPort(s.iface, s.port)
 }
 
+// SetReusePort enables the SO_REUSEPORT socket option on the listener,
+// so the server can bind the port while another socket (ex: a previous challenge server still draining) is bound to it.
+// It's only supported on Linux, macOS, and BSD systems.
+func (s *ProviderServer) SetReusePort(reuse bool) {
+	s.reusePort = reuse
+}
+
+// SetDrainTimeout sets the maximum time to wait for the in-flight connections to complete on CleanUp.
+// By default (0), the connections are closed immediately.
+func (s *ProviderServer) SetDrainTimeout(timeout time.Duration) {
+	s.drainTimeout = timeout
+}
+
 // Present generates a certificate with an SHA-256 digest of the keyAuth provided
 // as the acmeValidation-v1 extension value to conform to the ACME-TLS-ALPN spec.
 func (s *ProviderServer) Present(domain, token, keyAuth string) error {
@@ -64,33 +98,64 @@ func (s *ProviderServer) Present(domain, token, keyAuth string) error {
 	// https://www.rfc-editor.org/rfc/rfc8737.html#section-6.2
 	tlsConf.NextProtos = []string{ACMETLS1Protocol}
 
-	// Create the listener with the created tls.Config.
-	s.listener, err = tls.Listen("tcp", s.GetAddress(), tlsConf)
+	listener, err := s.listen()
 	if err != nil {
 		return fmt.Errorf("could not start HTTPS server for challenge: %w", err)
 	}
 
+	// Create the listener with the created tls.Config.
+	s.listener = tls.NewListener(listener, tlsConf)
+
+	s.server = &http.Server{ReadHeaderTimeout: 10 * time.Second}
+
 	// Shut the server down when we're finished.
-	go func() {
-		err := http.Serve(s.listener, nil)
-		if err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+	go func(server *http.Server, listener net.Listener) {
+		err := server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Println(err)
 		}
-	}()
+	}(s.server, s.listener)
 
 	return nil
 }
 
+func (s *ProviderServer) listen() (net.Listener, error) {
+	if s.inherited != nil {
+		return sharedlistener.New(s.inherited), nil
+	}
+
+	lc := net.ListenConfig{}
+	if s.reusePort {
+		lc.Control = reusePortControl
+	}
+
+	return lc.Listen(context.Background(), "tcp", s.GetAddress())
+}
+
 // CleanUp closes the HTTPS server.
+// If a drain timeout is defined, the in-flight connections are completed before closing the server.
 func (s *ProviderServer) CleanUp(domain, token, keyAuth string) error {
 	if s.listener == nil {
 		return nil
 	}
 
-	// Server was created, close it.
-	if err := s.listener.Close(); err != nil && errors.Is(err, http.ErrServerClosed) {
-		return err
+	server := s.server
+	s.listener = nil
+	s.server = nil
+
+	if s.drainTimeout <= 0 {
+		// Server was created, close it.
+		return server.Close()
 	}
 
-	return nil
+	ctx, cancel := context.WithTimeout(context.Background(), s.drainTimeout)
+	defer cancel()
+
+	err := server.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.WithDomain(domain).Warnf("acme: the TLS-ALPN-01 challenge server has not been drained in %s, closing the remaining connections", s.drainTimeout)
+		return server.Close()
+	}
+
+	return err
 }
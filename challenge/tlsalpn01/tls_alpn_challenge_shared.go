@@ -0,0 +1,192 @@
+package tlsalpn01
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrCertNotFound is returned by a CertStorage when there is no challenge certificate for a server name.
+var ErrCertNotFound = errors.New("challenge certificate not found")
+
+// CertStorage stores the PEM encoded challenge certificates and their private keys, keyed by server name (SNI).
+// The storage is shared between lego and the TLS servers that may receive the validation connections.
+type CertStorage interface {
+	Put(serverName string, certPEM, keyPEM []byte) error
+	Get(serverName string) (certPEM, keyPEM []byte, err error)
+	Delete(serverName string) error
+}
+
+// SharedCertStore implements ChallengeProvider for `TLS-ALPN-01` challenge.
+// The challenge certificates are written into a shared CertStorage,
+// so any instance behind a load balancer can answer the validation connection.
+//
+// lego uses Present and CleanUp,
+// the TLS servers use GetCertificate or GetConfigForClient (see ProviderCertStore).
+type SharedCertStore struct {
+	storage CertStorage
+}
+
+// NewSharedCertStore creates a new SharedCertStore.
+func NewSharedCertStore(storage CertStorage) *SharedCertStore {
+	return &SharedCertStore{storage: storage}
+}
+
+// Present generates the challenge certificate for the domain and writes it into the storage.
+func (s *SharedCertStore) Present(domain, token, keyAuth string) error {
+	certPEM, keyPEM, err := ChallengeBlocks(domain, keyAuth)
+	if err != nil {
+		return err
+	}
+
+	err = s.storage.Put(normalizeServerName(domain), certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("could not store the challenge certificate: %w", err)
+	}
+
+	return nil
+}
+
+// CleanUp removes the challenge certificate of the domain from the storage.
+func (s *SharedCertStore) CleanUp(domain, token, keyAuth string) error {
+	err := s.storage.Delete(normalizeServerName(domain))
+	if err != nil && !errors.Is(err, ErrCertNotFound) {
+		return fmt.Errorf("could not remove the challenge certificate: %w", err)
+	}
+
+	return nil
+}
+
+// GetCertificate returns the challenge certificate when the ClientHello is an ACME TLS-ALPN-01 validation request,
+// nil otherwise.
+// It can be used as tls.Config.GetCertificate (see ProviderCertStore.GetCertificate).
+func (s *SharedCertStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if !IsChallengeHello(hello) {
+		return nil, nil
+	}
+
+	certPEM, keyPEM, err := s.storage.Get(helloServerName(hello))
+	if errors.Is(err, ErrCertNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cert, nil
+}
+
+// GetConfigForClient returns a dedicated tls.Config when the ClientHello is an ACME TLS-ALPN-01 validation request
+// and a challenge certificate is available for the requested server name,
+// nil otherwise.
+// It can be used as tls.Config.GetConfigForClient.
+func (s *SharedCertStore) GetConfigForClient(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+	cert, err := s.GetCertificate(hello)
+	if err != nil || cert == nil {
+		return nil, err
+	}
+
+	return challengeConfig(cert), nil
+}
+
+// FileCertStorage is a CertStorage storing the challenge certificates in a directory
+// (ex: a shared volume mounted by all the instances).
+// There is one file per server name (`<server name>.pem`) containing the certificate and the private key.
+type FileCertStorage struct {
+	dir string
+}
+
+// NewFileCertStorage creates a new FileCertStorage.
+func NewFileCertStorage(dir string) (*FileCertStorage, error) {
+	if dir == "" {
+		return nil, errors.New("the directory is missing")
+	}
+
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the directory: %w", err)
+	}
+
+	return &FileCertStorage{dir: dir}, nil
+}
+
+// Put writes the challenge certificate atomically.
+func (f *FileCertStorage) Put(serverName string, certPEM, keyPEM []byte) error {
+	tmp, err := os.CreateTemp(f.dir, ".lego-*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	_, err = tmp.Write(bytes.Join([][]byte{certPEM, keyPEM}, nil))
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if errC := tmp.Close(); err == nil {
+		err = errC
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.filename(serverName))
+}
+
+// Get reads the challenge certificate.
+func (f *FileCertStorage) Get(serverName string) ([]byte, []byte, error) {
+	data, err := os.ReadFile(f.filename(serverName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrCertNotFound
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var certPEM, keyPEM []byte
+
+	for {
+		var block *pem.Block
+
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type == "CERTIFICATE" {
+			certPEM = append(certPEM, pem.EncodeToMemory(block)...)
+		} else {
+			keyPEM = append(keyPEM, pem.EncodeToMemory(block)...)
+		}
+	}
+
+	return certPEM, keyPEM, nil
+}
+
+// Delete removes the challenge certificate.
+func (f *FileCertStorage) Delete(serverName string) error {
+	err := os.Remove(f.filename(serverName))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrCertNotFound
+	}
+
+	return err
+}
+
+func (f *FileCertStorage) filename(serverName string) string {
+	// The server name is a DNS name or a reverse DNS name (IP address), it cannot contain a path separator.
+	return filepath.Join(f.dir, filepath.Base(serverName)+".pem")
+}
@@ -0,0 +1,76 @@
+package tlsalpn01
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedCertStore(t *testing.T) {
+	storage, err := NewFileCertStorage(t.TempDir())
+	require.NoError(t, err)
+
+	// lego and the TLS server are two distinct instances sharing the storage.
+	provider := NewSharedCertStore(storage)
+	server := NewSharedCertStore(storage)
+
+	hello := &tls.ClientHelloInfo{ServerName: "Example.com.", SupportedProtos: []string{ACMETLS1Protocol}}
+
+	err = provider.Present("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	config, err := server.GetConfigForClient(hello)
+	require.NoError(t, err)
+	require.NotNil(t, config)
+
+	require.Len(t, config.Certificates, 1)
+	leaf, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"example.com"}, leaf.DNSNames)
+	assert.Equal(t, []string{ACMETLS1Protocol}, config.NextProtos)
+
+	// regular request.
+	cert, err := server.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com", SupportedProtos: []string{"h2"}})
+	require.NoError(t, err)
+	assert.Nil(t, cert)
+
+	err = provider.CleanUp("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	cert, err = server.GetCertificate(hello)
+	require.NoError(t, err)
+	assert.Nil(t, cert)
+
+	// already removed.
+	err = provider.CleanUp("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+}
+
+func TestSharedCertStore_ipAddress(t *testing.T) {
+	storage, err := NewFileCertStorage(t.TempDir())
+	require.NoError(t, err)
+
+	store := NewSharedCertStore(storage)
+
+	err = store.Present("2001:db8::1", "token", "keyAuth")
+	require.NoError(t, err)
+
+	hello := &tls.ClientHelloInfo{
+		ServerName:      "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+		SupportedProtos: []string{ACMETLS1Protocol},
+	}
+
+	cert, err := store.GetCertificate(hello)
+	require.NoError(t, err)
+	require.NotNil(t, cert)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+
+	require.Len(t, leaf.IPAddresses, 1)
+	assert.Equal(t, "2001:db8::1", leaf.IPAddresses[0].String())
+}
@@ -0,0 +1,124 @@
+package tlsalpn01
+
+import (
+	"crypto/tls"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// ProviderCertStore implements ChallengeProvider for `TLS-ALPN-01` challenge.
+// Instead of starting its own server, it keeps the challenge certificates in memory,
+// and exposes them through GetCertificate and GetConfigForClient.
+// Those methods can be wired into the tls.Config of an already running TLS server,
+// to solve the challenge without lego binding the port 443.
+type ProviderCertStore struct {
+	mu    sync.RWMutex
+	certs map[string]*tls.Certificate
+}
+
+// NewProviderCertStore creates a new ProviderCertStore.
+func NewProviderCertStore() *ProviderCertStore {
+	return &ProviderCertStore{certs: make(map[string]*tls.Certificate)}
+}
+
+// Present generates the challenge certificate for the domain and keeps it until CleanUp is called.
+func (s *ProviderCertStore) Present(domain, token, keyAuth string) error {
+	cert, err := ChallengeCert(domain, keyAuth)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.certs[normalizeServerName(domain)] = cert
+	s.mu.Unlock()
+
+	return nil
+}
+
+// CleanUp removes the challenge certificate of the domain.
+func (s *ProviderCertStore) CleanUp(domain, token, keyAuth string) error {
+	s.mu.Lock()
+	delete(s.certs, normalizeServerName(domain))
+	s.mu.Unlock()
+
+	return nil
+}
+
+// GetCertificate returns the challenge certificate when the ClientHello is an ACME TLS-ALPN-01 validation request,
+// nil otherwise (the TLS server then falls back to its own certificates).
+//
+// It can be used as tls.Config.GetCertificate,
+// but the `acme-tls/1` protocol must be added to tls.Config.NextProtos for the ALPN negotiation to succeed.
+// Prefer GetConfigForClient which doesn't require any other change.
+func (s *ProviderCertStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if !IsChallengeHello(hello) {
+		return nil, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.certs[helloServerName(hello)], nil
+}
+
+// GetConfigForClient returns a dedicated tls.Config when the ClientHello is an ACME TLS-ALPN-01 validation request
+// and a challenge certificate is available for the requested server name,
+// nil otherwise (the TLS server then uses its own tls.Config).
+//
+// It can be used as tls.Config.GetConfigForClient.
+func (s *ProviderCertStore) GetConfigForClient(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+	cert, err := s.GetCertificate(hello)
+	if err != nil || cert == nil {
+		return nil, err
+	}
+
+	return challengeConfig(cert), nil
+}
+
+func challengeConfig(cert *tls.Certificate) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{*cert},
+		NextProtos:   []string{ACMETLS1Protocol},
+	}
+}
+
+// IsChallengeHello returns true if the ClientHello only offers the `acme-tls/1` protocol,
+// as required for TLS-ALPN-01 validation requests.
+// https://www.rfc-editor.org/rfc/rfc8737.html#section-3
+func IsChallengeHello(hello *tls.ClientHelloInfo) bool {
+	return hello != nil && len(hello.SupportedProtos) == 1 && slices.Contains(hello.SupportedProtos, ACMETLS1Protocol)
+}
+
+// normalizeServerName returns the key of the challenge certificate of a domain or an IP address.
+// The SNI of the validation requests for an IP address is the reverse DNS name of the address,
+// so the IP addresses are keyed by their reverse DNS names.
+// https://www.rfc-editor.org/rfc/rfc8738.html#section-6
+func normalizeServerName(name string) string {
+	if net.ParseIP(name) != nil {
+		reverse, err := dns.ReverseAddr(name)
+		if err == nil {
+			name = reverse
+		}
+	}
+
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// helloServerName returns the key of the challenge certificate requested by a ClientHello.
+// Without SNI (some validation requests for IP addresses), the local address of the connection is used.
+func helloServerName(hello *tls.ClientHelloInfo) string {
+	if hello.ServerName != "" || hello.Conn == nil {
+		return normalizeServerName(hello.ServerName)
+	}
+
+	host, _, err := net.SplitHostPort(hello.Conn.LocalAddr().String())
+	if err != nil {
+		return ""
+	}
+
+	return normalizeServerName(host)
+}
@@ -0,0 +1,169 @@
+package tlsalpn01
+
+import (
+	"crypto/rsa"
+	"crypto/tls"
+	"net"
+	"testing"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderCertStore(t *testing.T) {
+	store := NewProviderCertStore()
+
+	defaultCert := generateTestCert(t, "default.example.com")
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates:       []tls.Certificate{*defaultCert},
+		GetConfigForClient: store.GetConfigForClient,
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, errA := listener.Accept()
+			if errA != nil {
+				return
+			}
+
+			go func() {
+				_ = conn.(*tls.Conn).Handshake()
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	err = store.Present("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	// validation request.
+	state := dialTLS(t, listener.Addr(), "Example.com", []string{ACMETLS1Protocol})
+	assert.Equal(t, ACMETLS1Protocol, state.NegotiatedProtocol)
+	assert.Equal(t, []string{"example.com"}, state.PeerCertificates[0].DNSNames)
+	assert.True(t, hasACMEExtension(state), "expected the challenge certificate")
+
+	// regular request.
+	state = dialTLS(t, listener.Addr(), "example.com", []string{"h2", "http/1.1"})
+	assert.Equal(t, []string{"default.example.com"}, state.PeerCertificates[0].DNSNames)
+
+	err = store.CleanUp("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	cert, err := store.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com", SupportedProtos: []string{ACMETLS1Protocol}})
+	require.NoError(t, err)
+	assert.Nil(t, cert)
+}
+
+func TestProviderCertStore_ipAddress(t *testing.T) {
+	store := NewProviderCertStore()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		GetConfigForClient: store.GetConfigForClient,
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, errA := listener.Accept()
+			if errA != nil {
+				return
+			}
+
+			go func() {
+				_ = conn.(*tls.Conn).Handshake()
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	err = store.Present("127.0.0.1", "token", "keyAuth")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc       string
+		serverName string
+	}{
+		{desc: "reverse DNS name", serverName: "1.0.0.127.in-addr.arpa"},
+		{desc: "without SNI", serverName: ""},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			state := dialTLS(t, listener.Addr(), test.serverName, []string{ACMETLS1Protocol})
+			assert.Equal(t, ACMETLS1Protocol, state.NegotiatedProtocol)
+			require.Len(t, state.PeerCertificates[0].IPAddresses, 1)
+			assert.Equal(t, "127.0.0.1", state.PeerCertificates[0].IPAddresses[0].String())
+		})
+	}
+}
+
+func Test_normalizeServerName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "Example.com.", expected: "example.com"},
+		{name: "192.0.2.1", expected: "1.2.0.192.in-addr.arpa"},
+		{name: "2001:db8::1", expected: "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, normalizeServerName(test.name))
+		})
+	}
+}
+
+func TestIsChallengeHello(t *testing.T) {
+	assert.True(t, IsChallengeHello(&tls.ClientHelloInfo{SupportedProtos: []string{ACMETLS1Protocol}}))
+	assert.False(t, IsChallengeHello(&tls.ClientHelloInfo{SupportedProtos: []string{"h2", ACMETLS1Protocol}}))
+	assert.False(t, IsChallengeHello(&tls.ClientHelloInfo{}))
+	assert.False(t, IsChallengeHello(nil))
+}
+
+func dialTLS(t *testing.T, addr net.Addr, serverName string, protos []string) tls.ConnectionState {
+	t.Helper()
+
+	conn, err := tls.Dial("tcp", addr.String(), &tls.Config{
+		ServerName:         serverName,
+		NextProtos:         protos,
+		InsecureSkipVerify: true,
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn.ConnectionState()
+}
+
+func hasACMEExtension(state tls.ConnectionState) bool {
+	for _, ext := range state.PeerCertificates[0].Extensions {
+		if idPeAcmeIdentifierV1.Equal(ext.Id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func generateTestCert(t *testing.T, domain string) *tls.Certificate {
+	t.Helper()
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := certcrypto.GeneratePemCert(privateKey.(*rsa.PrivateKey), domain, nil)
+	require.NoError(t, err)
+
+	cert, err := tls.X509KeyPair(certPEM, certcrypto.PEMEncode(privateKey))
+	require.NoError(t, err)
+
+	return &cert
+}
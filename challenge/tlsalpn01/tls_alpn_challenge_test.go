@@ -9,7 +9,9 @@ import (
 	"encoding/asn1"
 	"net"
 	"net/http"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/go-acme/lego/v4/acme"
 	"github.com/go-acme/lego/v4/acme/api"
@@ -191,3 +193,93 @@ func TestChallengeIPaddress(t *testing.T) {
 
 	require.NoError(t, solver.Solve(authz))
 }
+
+func TestProviderServer_SetReusePort(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("SO_REUSEPORT behavior is only tested on Linux")
+	}
+
+	first := NewProviderServer("127.0.0.1", "24458")
+	first.SetReusePort(true)
+
+	err := first.Present("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = first.CleanUp("example.com", "token", "keyAuth") })
+
+	second := NewProviderServer("127.0.0.1", "24458")
+	second.SetReusePort(true)
+
+	err = second.Present("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	err = second.CleanUp("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	third := NewProviderServer("127.0.0.1", "24458")
+
+	err = third.Present("example.com", "token", "keyAuth")
+	require.Error(t, err)
+}
+
+func TestProviderServer_SetDrainTimeout(t *testing.T) {
+	server := NewProviderServer("127.0.0.1", "24459")
+	server.SetDrainTimeout(time.Second)
+
+	err := server.Present("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	conn, err := tls.Dial("tcp", "127.0.0.1:24459", &tls.Config{
+		ServerName:         "example.com",
+		NextProtos:         []string{ACMETLS1Protocol},
+		InsecureSkipVerify: true,
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	start := time.Now()
+
+	err = server.CleanUp("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	// the port is released.
+	err = server.Present("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	err = server.CleanUp("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+}
+
+func TestProviderServer_withListener(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	server := NewProviderServerWithListener(listener)
+
+	assert.Equal(t, listener.Addr().String(), server.GetAddress())
+
+	// the listener is used by successive challenges.
+	for range 2 {
+		err = server.Present("example.com", "token", "keyAuth")
+		require.NoError(t, err)
+
+		conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+			ServerName:         "example.com",
+			NextProtos:         []string{ACMETLS1Protocol},
+			InsecureSkipVerify: true,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, ACMETLS1Protocol, conn.ConnectionState().NegotiatedProtocol)
+
+		_ = conn.Close()
+
+		err = server.CleanUp("example.com", "token", "keyAuth")
+		require.NoError(t, err)
+	}
+}
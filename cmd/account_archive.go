@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// accountArchive the content of an account archive: the account (email and registration resource) and its private key.
+type accountArchive struct {
+	Server  string   `json:"server"`
+	Account *Account `json:"account"`
+	// Key the private key of the account (PEM).
+	Key string `json:"key"`
+}
+
+// encodeAccountArchive encrypts the archive with the passphrase.
+// The result is an ASCII-armored age file (https://age-encryption.org/v1) with a passphrase (scrypt) recipient,
+// it can also be decrypted with the age tools.
+func encodeAccountArchive(archive *accountArchive, passphrase []byte) ([]byte, error) {
+	content, err := json.Marshal(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := age.NewScryptRecipient(string(passphrase))
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+
+	armorWriter := armor.NewWriter(buf)
+
+	w, err := age.Encrypt(armorWriter, recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = w.Write(content); err != nil {
+		return nil, err
+	}
+
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	if err = armorWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeAccountArchive decrypts an archive created by encodeAccountArchive.
+func decodeAccountArchive(raw, passphrase []byte) (*accountArchive, error) {
+	identity, err := age.NewScryptIdentity(string(passphrase))
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := age.Decrypt(armor.NewReader(bytes.NewReader(raw)), identity)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, errors.New("unable to decrypt the account archive: invalid passphrase")
+		}
+
+		return nil, fmt.Errorf("invalid account archive: %w", err)
+	}
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account archive: %w", err)
+	}
+
+	var archive accountArchive
+
+	err = json.Unmarshal(content, &archive)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account archive: %w", err)
+	}
+
+	if archive.Account == nil || archive.Account.Email == "" || archive.Key == "" {
+		return nil, errors.New("invalid account archive: the account or the key is missing")
+	}
+
+	return &archive, nil
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/registration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_accountArchive(t *testing.T) {
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.EC256)
+	require.NoError(t, err)
+
+	archive := &accountArchive{
+		Server: "https://acme-v02.api.letsencrypt.org/directory",
+		Account: &Account{
+			Email:        "you@example.com",
+			Registration: &registration.Resource{URI: "https://acme-v02.api.letsencrypt.org/acme/acct/123"},
+		},
+		Key: string(certcrypto.PEMEncode(privateKey)),
+	}
+
+	raw, err := encodeAccountArchive(archive, []byte("secret"))
+	require.NoError(t, err)
+
+	assert.Contains(t, string(raw), "-----BEGIN AGE ENCRYPTED FILE-----")
+	assert.NotContains(t, string(raw), "you@example.com")
+
+	decoded, err := decodeAccountArchive(raw, []byte("secret"))
+	require.NoError(t, err)
+
+	assert.Equal(t, archive, decoded)
+
+	_, err = decodeAccountArchive(raw, []byte("invalid"))
+	require.EqualError(t, err, "unable to decrypt the account archive: invalid passphrase")
+}
+
+func Test_decodeAccountArchive_invalid(t *testing.T) {
+	_, err := decodeAccountArchive([]byte("invalid"), []byte("secret"))
+	require.ErrorContains(t, err, "invalid account archive: ")
+
+	_, err = decodeAccountArchive(certcrypto.PEMEncode(certcrypto.DERCertificateBytes("data")), []byte("secret"))
+	require.ErrorContains(t, err, "invalid account archive: ")
+}
@@ -1,20 +1,21 @@
 package cmd
 
 import (
+	"bytes"
 	"crypto"
-	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
+	"fmt"
 	"net/url"
-	"os"
-	"path/filepath"
+	"path"
 	"strings"
 
 	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/go-acme/lego/v4/lego"
 	"github.com/go-acme/lego/v4/log"
 	"github.com/go-acme/lego/v4/registration"
+	"github.com/go-acme/lego/v4/storage"
 	"github.com/urfave/cli/v2"
 )
 
@@ -57,145 +58,242 @@ const (
 //	     │      │             └── CA server ("server" option)
 //	     │      └── root accounts directory
 //	     └── "path" option
+//
+// The paths are relative to the storage backend (the "path" option is only used by the file storage).
 type AccountsStorage struct {
+	backend         storage.CertificatesStorage
 	userID          string
+	server          string
 	rootPath        string
 	rootUserPath    string
 	keysPath        string
 	accountFilePath string
+	keyPass         []byte
+	encryptKeys     bool
 	ctx             *cli.Context
 }
 
 // NewAccountsStorage Creates a new AccountsStorage.
 func NewAccountsStorage(ctx *cli.Context) *AccountsStorage {
-	// TODO: move to account struct? Currently MUST pass email.
-	email := getEmail(ctx)
+	return newAccountsStorage(ctx, ctx.String(flgServer))
+}
 
-	serverURL, err := url.Parse(ctx.String(flgServer))
+// newAccountsStorage Creates a new AccountsStorage for the CA server (each CA has its own accounts).
+func newAccountsStorage(ctx *cli.Context, server string) *AccountsStorage {
+	accountsStorage, err := createAccountsStorage(ctx, server)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	rootPath := filepath.Join(ctx.String(flgPath), baseAccountsRootFolderName)
-	serverPath := strings.NewReplacer(":", "_", "/", string(os.PathSeparator)).Replace(serverURL.Host)
-	accountsPath := filepath.Join(rootPath, serverPath)
-	rootUserPath := filepath.Join(accountsPath, email)
+	return accountsStorage
+}
+
+// createAccountsStorage Creates a new AccountsStorage for the CA server, and returns the errors of the options.
+func createAccountsStorage(ctx *cli.Context, server string) (*AccountsStorage, error) {
+	// TODO: move to account struct? Currently MUST pass email.
+	email := ctx.String(flgEmail)
+	if email == "" {
+		return nil, fmt.Errorf("you have to pass an account (email address) to the program using --%s or -m", flgEmail)
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	rootPath := baseAccountsRootFolderName
+	serverPath := strings.NewReplacer(":", "_").Replace(serverURL.Host)
+	accountsPath := path.Join(rootPath, serverPath)
+	rootUserPath := path.Join(accountsPath, email)
 
 	return &AccountsStorage{
+		backend:         setupStorage(ctx),
 		userID:          email,
+		server:          server,
 		rootPath:        rootPath,
 		rootUserPath:    rootUserPath,
-		keysPath:        filepath.Join(rootUserPath, baseKeysFolderName),
-		accountFilePath: filepath.Join(rootUserPath, accountFileName),
+		keysPath:        path.Join(rootUserPath, baseKeysFolderName),
+		accountFilePath: path.Join(rootUserPath, accountFileName),
+		keyPass:         getPassphrase(ctx, flgAccountKeyPass, flgAccountKeyPassFile),
+		encryptKeys:     ctx.Bool(flgAccountEncryptKeys),
 		ctx:             ctx,
-	}
+	}, nil
 }
 
-func (s *AccountsStorage) ExistsAccountFilePath() bool {
-	accountFile := filepath.Join(s.rootUserPath, accountFileName)
-	if _, err := os.Stat(accountFile); os.IsNotExist(err) {
-		return false
-	} else if err != nil {
-		log.Fatal(err)
-	}
-	return true
+func (s *AccountsStorage) ExistsAccountFilePath() (bool, error) {
+	return s.backend.Exists(s.accountFilePath)
 }
 
+// GetRootPath returns the location of the accounts in the storage (ex: the path of the directory on the disk).
 func (s *AccountsStorage) GetRootPath() string {
-	return s.rootPath
+	return s.backend.Location(s.rootPath)
 }
 
+// GetRootUserPath returns the location of the account in the storage (ex: the path of the directory on the disk).
 func (s *AccountsStorage) GetRootUserPath() string {
-	return s.rootUserPath
+	return s.backend.Location(s.rootUserPath)
 }
 
 func (s *AccountsStorage) GetUserID() string {
 	return s.userID
 }
 
+// ListAccounts returns the paths of the account files of all the CA servers and users.
+func (s *AccountsStorage) ListAccounts() ([]string, error) {
+	keys, err := s.backend.List(s.rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []string
+
+	for _, key := range keys {
+		if path.Base(key) == accountFileName {
+			accounts = append(accounts, key)
+		}
+	}
+
+	return accounts, nil
+}
+
 func (s *AccountsStorage) Save(account *Account) error {
 	jsonBytes, err := json.MarshalIndent(account, "", "\t")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(s.accountFilePath, jsonBytes, filePerm)
+	return s.backend.WriteFile(s.accountFilePath, jsonBytes)
 }
 
-func (s *AccountsStorage) LoadAccount(privateKey crypto.PrivateKey) *Account {
-	fileBytes, err := os.ReadFile(s.accountFilePath)
+func (s *AccountsStorage) LoadAccount(privateKey crypto.PrivateKey) (*Account, error) {
+	fileBytes, err := s.backend.ReadFile(s.accountFilePath)
 	if err != nil {
-		log.Fatalf("Could not load file for account %s: %v", s.userID, err)
+		return nil, &storageError{err: fmt.Errorf("could not load file for account %s: %w", s.userID, err)}
 	}
 
 	var account Account
 	err = json.Unmarshal(fileBytes, &account)
 	if err != nil {
-		log.Fatalf("Could not parse file for account %s: %v", s.userID, err)
+		return nil, &storageError{err: fmt.Errorf("could not parse file for account %s: %w", s.userID, err)}
 	}
 
 	account.key = privateKey
 
 	if account.Registration == nil || account.Registration.Body.Status == "" {
-		reg, err := tryRecoverRegistration(s.ctx, privateKey)
+		reg, err := tryRecoverRegistration(s.ctx, s.server, privateKey)
 		if err != nil {
-			log.Fatalf("Could not load account for %s. Registration is nil: %#v", s.userID, err)
+			return nil, fmt.Errorf("could not load account for %s, registration is nil: %w", s.userID, err)
 		}
 
 		account.Registration = reg
 		err = s.Save(&account)
 		if err != nil {
-			log.Fatalf("Could not save account for %s. Registration is nil: %#v", s.userID, err)
+			return nil, &storageError{err: fmt.Errorf("could not save account for %s: %w", s.userID, err)}
 		}
 	}
 
-	return &account
+	return &account, nil
 }
 
-func (s *AccountsStorage) GetPrivateKey(keyType certcrypto.KeyType) crypto.PrivateKey {
-	accKeyPath := filepath.Join(s.keysPath, s.userID+".key")
+func (s *AccountsStorage) GetPrivateKey(keyType certcrypto.KeyType) (crypto.PrivateKey, error) {
+	accKeyPath := s.accountKeyPath()
 
-	if _, err := os.Stat(accKeyPath); os.IsNotExist(err) {
+	exists, err := s.backend.Exists(accKeyPath)
+	if err != nil {
+		return nil, &storageError{err: err}
+	}
+
+	if !exists {
 		log.Printf("No key found for account %s. Generating a %s key.", s.userID, keyType)
-		s.createKeysFolder()
 
-		privateKey, err := generatePrivateKey(accKeyPath, keyType)
+		privateKey, err := s.generatePrivateKey(accKeyPath, keyType)
 		if err != nil {
-			log.Fatalf("Could not generate RSA private account key for account %s: %v", s.userID, err)
+			return nil, fmt.Errorf("could not generate the private account key for account %s: %w", s.userID, err)
 		}
 
-		log.Printf("Saved key to %s", accKeyPath)
-		return privateKey
+		log.Printf("Saved key to %s", s.backend.Location(accKeyPath))
+		return privateKey, nil
 	}
 
-	privateKey, err := loadPrivateKey(accKeyPath)
+	privateKey, err := s.loadPrivateKey(accKeyPath)
 	if err != nil {
-		log.Fatalf("Could not load RSA private key from file %s: %v", accKeyPath, err)
+		return nil, &storageError{err: fmt.Errorf("could not load the private key from file %s: %w", s.backend.Location(accKeyPath), err)}
 	}
 
-	return privateKey
+	return privateKey, nil
 }
 
-func (s *AccountsStorage) createKeysFolder() {
-	if err := createNonExistingFolder(s.keysPath); err != nil {
-		log.Fatalf("Could not check/create directory for account %s: %v", s.userID, err)
+// NextPrivateKey returns the private key used by an account key rollover, and true if the key already existed.
+// The key is saved in a dedicated file (`<userID>.key.next`) until CommitNextPrivateKey is called:
+// the key of an interrupted rollover is reused, because the server may already know it.
+func (s *AccountsStorage) NextPrivateKey(keyType certcrypto.KeyType) (crypto.PrivateKey, bool, error) {
+	nextKeyPath := s.accountKeyPath() + ".next"
+
+	exists, err := s.backend.Exists(nextKeyPath)
+	if err != nil {
+		return nil, false, err
 	}
+
+	if exists {
+		privateKey, err := s.loadPrivateKey(nextKeyPath)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return privateKey, true, nil
+	}
+
+	privateKey, err := s.generatePrivateKey(nextKeyPath, keyType)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return privateKey, false, nil
 }
 
-func generatePrivateKey(file string, keyType certcrypto.KeyType) (crypto.PrivateKey, error) {
-	privateKey, err := certcrypto.GeneratePrivateKey(keyType)
+// CommitNextPrivateKey replaces the private key by the key returned by NextPrivateKey.
+// The previous key is kept as `<userID>.key.old`, the private key is replaced in a single write,
+// and the next key is removed last: an interrupted commit can be run again.
+func (s *AccountsStorage) CommitNextPrivateKey() error {
+	accKeyPath := s.accountKeyPath()
+
+	next, err := s.backend.ReadFile(accKeyPath + ".next")
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	certOut, err := os.Create(file)
+	current, err := s.backend.ReadFile(accKeyPath)
+	if err != nil {
+		return err
+	}
+
+	// The private key has already been replaced by an interrupted commit.
+	if !bytes.Equal(current, next) {
+		err = s.backend.WriteFile(accKeyPath+".old", current)
+		if err != nil {
+			return err
+		}
+
+		err = s.backend.WriteFile(accKeyPath, next)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.backend.Remove(accKeyPath + ".next")
+}
+
+func (s *AccountsStorage) accountKeyPath() string {
+	return path.Join(s.keysPath, s.userID+".key")
+}
+
+func (s *AccountsStorage) generatePrivateKey(key string, keyType certcrypto.KeyType) (crypto.PrivateKey, error) {
+	privateKey, err := certcrypto.GeneratePrivateKey(keyType)
 	if err != nil {
 		return nil, err
 	}
-	defer certOut.Close()
 
-	pemKey := certcrypto.PEMBlock(privateKey)
-	err = pem.Encode(certOut, pemKey)
+	err = s.writePrivateKey(key, privateKey)
 	if err != nil {
 		return nil, err
 	}
@@ -203,28 +301,68 @@ func generatePrivateKey(file string, keyType certcrypto.KeyType) (crypto.Private
 	return privateKey, nil
 }
 
-func loadPrivateKey(file string) (crypto.PrivateKey, error) {
-	keyBytes, err := os.ReadFile(file)
+// writePrivateKey writes the private key, encrypted if a passphrase is defined (--account.key-pass).
+func (s *AccountsStorage) writePrivateKey(key string, privateKey crypto.PrivateKey) error {
+	if len(s.keyPass) == 0 {
+		return s.backend.WriteFile(key, certcrypto.PEMEncode(privateKey))
+	}
+
+	keyPEM, err := certcrypto.PEMEncodeEncrypted(privateKey, s.keyPass)
+	if err != nil {
+		return err
+	}
+
+	return s.backend.WriteFile(key, keyPEM)
+}
+
+// loadPrivateKey loads the private key, the encrypted keys are decrypted with the passphrase (--account.key-pass).
+// An unencrypted key is only encrypted if explicitly requested (--account.encrypt-keys).
+func (s *AccountsStorage) loadPrivateKey(key string) (crypto.PrivateKey, error) {
+	keyBytes, err := s.backend.ReadFile(key)
 	if err != nil {
 		return nil, err
 	}
 
 	keyBlock, _ := pem.Decode(keyBytes)
+	if keyBlock == nil {
+		return nil, errors.New("invalid PEM block")
+	}
 
-	switch keyBlock.Type {
-	case "RSA PRIVATE KEY":
-		return x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
-	case "EC PRIVATE KEY":
-		return x509.ParseECPrivateKey(keyBlock.Bytes)
+	encrypted := keyBlock.Type == "ENCRYPTED PRIVATE KEY"
+
+	if encrypted && len(s.keyPass) == 0 {
+		return nil, fmt.Errorf("the private key is encrypted: the passphrase is required (--%s or --%s)", flgAccountKeyPass, flgAccountKeyPassFile)
+	}
+
+	privateKey, err := certcrypto.ParseEncryptedPEMPrivateKey(keyBytes, s.keyPass)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, errors.New("unknown private key type")
+	if encrypted || len(s.keyPass) == 0 {
+		return privateKey, nil
+	}
+
+	if !s.encryptKeys {
+		log.Warnf("The private key %s is not encrypted, use --%s to encrypt it.", s.backend.Location(key), flgAccountEncryptKeys)
+
+		return privateKey, nil
+	}
+
+	err = s.writePrivateKey(key, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encrypt the private key: %w", err)
+	}
+
+	log.Printf("The private key %s has been encrypted.", s.backend.Location(key))
+
+	return privateKey, nil
 }
 
-func tryRecoverRegistration(ctx *cli.Context, privateKey crypto.PrivateKey) (*registration.Resource, error) {
+func tryRecoverRegistration(ctx *cli.Context, server string, privateKey crypto.PrivateKey) (*registration.Resource, error) {
 	// couldn't load account but got a key. Try to look the account up.
 	config := lego.NewConfig(&Account{key: privateKey})
-	config.CADirURL = ctx.String(flgServer)
+	config.CADirURL = server
 	config.UserAgent = getUserAgent(ctx)
 
 	client, err := lego.NewClient(config)
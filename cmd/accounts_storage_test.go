@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"crypto"
+	"path"
+	"testing"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountsStorage_GetPrivateKey_encrypted(t *testing.T) {
+	accountsStorage := &AccountsStorage{
+		backend:  storage.NewFileStorage(t.TempDir()),
+		userID:   "test@example.com",
+		keysPath: path.Join(baseAccountsRootFolderName, "example.com", "test@example.com", baseKeysFolderName),
+		keyPass:  []byte("secret"),
+	}
+
+	privateKey := getPrivateKey(t, accountsStorage)
+
+	keyPEM, err := accountsStorage.backend.ReadFile(accountsStorage.accountKeyPath())
+	require.NoError(t, err)
+
+	assert.Contains(t, string(keyPEM), "ENCRYPTED PRIVATE KEY")
+
+	assert.Equal(t, privateKey, getPrivateKey(t, accountsStorage))
+
+	accountsStorage.keyPass = nil
+
+	_, err = accountsStorage.loadPrivateKey(accountsStorage.accountKeyPath())
+	require.EqualError(t, err, "the private key is encrypted: the passphrase is required (--account.key-pass or --account.key-pass-file)")
+}
+
+func TestAccountsStorage_GetPrivateKey_encryptUnencryptedKey(t *testing.T) {
+	accountsStorage := &AccountsStorage{
+		backend:  storage.NewFileStorage(t.TempDir()),
+		userID:   "test@example.com",
+		keysPath: path.Join(baseAccountsRootFolderName, "example.com", "test@example.com", baseKeysFolderName),
+	}
+
+	privateKey := getPrivateKey(t, accountsStorage)
+
+	keyPEM, err := accountsStorage.backend.ReadFile(accountsStorage.accountKeyPath())
+	require.NoError(t, err)
+
+	assert.Contains(t, string(keyPEM), "EC PRIVATE KEY")
+
+	accountsStorage.keyPass = []byte("secret")
+
+	// the existing key is only encrypted if explicitly requested.
+	assert.Equal(t, privateKey, getPrivateKey(t, accountsStorage))
+
+	keyPEM, err = accountsStorage.backend.ReadFile(accountsStorage.accountKeyPath())
+	require.NoError(t, err)
+
+	assert.Contains(t, string(keyPEM), "EC PRIVATE KEY")
+
+	accountsStorage.encryptKeys = true
+
+	assert.Equal(t, privateKey, getPrivateKey(t, accountsStorage))
+
+	keyPEM, err = accountsStorage.backend.ReadFile(accountsStorage.accountKeyPath())
+	require.NoError(t, err)
+
+	assert.Contains(t, string(keyPEM), "ENCRYPTED PRIVATE KEY")
+}
+
+func TestAccountsStorage_CommitNextPrivateKey(t *testing.T) {
+	accountsStorage := &AccountsStorage{
+		backend:  storage.NewFileStorage(t.TempDir()),
+		userID:   "test@example.com",
+		keysPath: path.Join(baseAccountsRootFolderName, "example.com", "test@example.com", baseKeysFolderName),
+	}
+
+	privateKey := getPrivateKey(t, accountsStorage)
+
+	nextKey, resumed, err := accountsStorage.NextPrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+	assert.False(t, resumed)
+
+	// The current key is unchanged until the commit.
+	assert.Equal(t, privateKey, getPrivateKey(t, accountsStorage))
+
+	require.NoError(t, accountsStorage.CommitNextPrivateKey())
+
+	assert.Equal(t, nextKey, getPrivateKey(t, accountsStorage))
+
+	oldKey, err := accountsStorage.loadPrivateKey(accountsStorage.accountKeyPath() + ".old")
+	require.NoError(t, err)
+	assert.Equal(t, privateKey, oldKey)
+
+	exists, err := accountsStorage.backend.Exists(accountsStorage.accountKeyPath() + ".next")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestAccountsStorage_CommitNextPrivateKey_resume(t *testing.T) {
+	backend := &failingStorage{CertificatesStorage: storage.NewFileStorage(t.TempDir())}
+
+	accountsStorage := &AccountsStorage{
+		backend:  backend,
+		userID:   "test@example.com",
+		keysPath: path.Join(baseAccountsRootFolderName, "example.com", "test@example.com", baseKeysFolderName),
+	}
+
+	privateKey := getPrivateKey(t, accountsStorage)
+
+	nextKey, resumed, err := accountsStorage.NextPrivateKey(certcrypto.EC384)
+	require.NoError(t, err)
+	assert.False(t, resumed)
+
+	backend.key = accountsStorage.accountKeyPath()
+
+	require.EqualError(t, accountsStorage.CommitNextPrivateKey(), "write error")
+
+	backend.key = ""
+
+	// The current key is unchanged, and the next key is kept.
+	assert.Equal(t, privateKey, getPrivateKey(t, accountsStorage))
+
+	resumedKey, resumed, err := accountsStorage.NextPrivateKey(certcrypto.EC384)
+	require.NoError(t, err)
+	assert.True(t, resumed)
+	assert.Equal(t, nextKey, resumedKey)
+
+	require.NoError(t, accountsStorage.CommitNextPrivateKey())
+
+	assert.Equal(t, nextKey, getPrivateKey(t, accountsStorage))
+
+	oldKey, err := accountsStorage.loadPrivateKey(accountsStorage.accountKeyPath() + ".old")
+	require.NoError(t, err)
+	assert.Equal(t, privateKey, oldKey)
+
+	exists, err := accountsStorage.backend.Exists(accountsStorage.accountKeyPath() + ".next")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func getPrivateKey(t *testing.T, accountsStorage *AccountsStorage) crypto.PrivateKey {
+	t.Helper()
+
+	privateKey, err := accountsStorage.GetPrivateKey(certcrypto.EC256)
+	require.NoError(t, err)
+
+	return privateKey
+}
@@ -0,0 +1,503 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli/v2"
+)
+
+// Flag names.
+const (
+	flgCert            = "cert"
+	flgConcurrency     = "concurrency"
+	flgCertConcurrency = "cert.concurrency"
+)
+
+// certificateForbiddenOptions the options that cannot be defined by certificate:
+// the options of the account (defined by the account of the certificate) and the options defining the certificates.
+var certificateForbiddenOptions = []string{flgServer, flgEmail, flgAcceptTOS, flgEAB, flgKID, flgHMAC, flgCert, flgConcurrency, flgCertConcurrency}
+
+// accountOptions the options that can be defined by an account of the configuration file.
+var accountOptions = []string{flgServer, flgEmail, flgAcceptTOS, flgEAB, flgKID, flgHMAC, flgAccountKeyPass, flgAccountKeyPassFile}
+
+// certificateAccount the account of a certificate, defined in the configuration file.
+type certificateAccount struct {
+	name    string
+	options map[string]any
+}
+
+// certificateGroups the values of the cert flag.
+// Each value is a group of options of a certificate, ex: "domains=example.com,www.example.com dns=cloudflare key-type=ec256".
+type certificateGroups []string
+
+func (g *certificateGroups) Set(value string) error {
+	*g = append(*g, value)
+
+	return nil
+}
+
+func (g *certificateGroups) String() string {
+	if g == nil {
+		return ""
+	}
+
+	return strings.Join(*g, "; ")
+}
+
+func createCertificatesFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.GenericFlag{
+			Name: flgCert,
+			Usage: "Define a certificate: a group of options separated by spaces (ex: 'domains=example.com,www.example.com dns=cloudflare key-type=ec256')." +
+				" Can be repeated to handle several certificates with the same account. Overrides the certificates of the configuration file.",
+			Value: &certificateGroups{},
+		},
+		createConcurrencyFlag(),
+	}
+}
+
+// createConcurrencyFlag creates the flag of the maximum number of certificates handled concurrently (run, renew, and daemon commands).
+func createConcurrencyFlag() cli.Flag {
+	return &cli.IntFlag{
+		Name:    flgConcurrency,
+		Aliases: []string{flgCertConcurrency},
+		Usage: "The maximum number of certificates obtained or renewed concurrently (only with several certificates)." +
+			" The certificates validated with the built-in HTTP-01 or TLS-ALPN-01 servers are handled one at a time.",
+		Value: 1,
+	}
+}
+
+// getCertificates returns the options of the certificates defined by the cert flag or by the configuration file.
+// The certificates are ignored if the domains or the CSR are defined by the flags.
+// The accounts of the certificates (account option) are resolved with the accounts of the configuration file.
+func getCertificates(ctx *cli.Context) ([]map[string]any, error) {
+	if isSetByUser(ctx, flgDomains) || isSetByUser(ctx, flgCSR) {
+		return nil, nil
+	}
+
+	config, err := readConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var certificates []map[string]any
+
+	if groups, ok := ctx.Generic(flgCert).(*certificateGroups); ok && len(*groups) > 0 {
+		for _, group := range *groups {
+			certOptions, err := parseCertificateGroup(group)
+			if err != nil {
+				return nil, err
+			}
+
+			certificates = append(certificates, certOptions)
+		}
+	} else if config != nil {
+		certificates = config.Certificates
+	}
+
+	return resolveAccounts(certificates, config)
+}
+
+// resolveAccounts replaces the names of the accounts of the certificates by the accounts of the configuration file.
+func resolveAccounts(certificates []map[string]any, config *Config) ([]map[string]any, error) {
+	resolved := make([]map[string]any, 0, len(certificates))
+
+	for i, certOptions := range certificates {
+		value, ok := certOptions[configAccountKey]
+		if !ok {
+			resolved = append(resolved, certOptions)
+			continue
+		}
+
+		name := fmt.Sprint(value)
+
+		var options map[string]any
+		if config != nil {
+			options, ok = config.Accounts[name]
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("certificate %d: unknown account %q", i, name)
+		}
+
+		certOptions = maps.Clone(certOptions)
+		certOptions[configAccountKey] = &certificateAccount{name: name, options: options}
+
+		resolved = append(resolved, certOptions)
+	}
+
+	return resolved, nil
+}
+
+// parseCertificateGroup parses the options of a certificate defined by the cert flag.
+// An option without value is a boolean option (ex: "http").
+func parseCertificateGroup(group string) (map[string]any, error) {
+	certOptions := make(map[string]any)
+
+	for _, field := range strings.Fields(group) {
+		name, value, found := strings.Cut(field, "=")
+		if name == "" {
+			return nil, fmt.Errorf("cert: invalid option %q in %q", field, group)
+		}
+
+		if !found {
+			value = "true"
+		}
+
+		certOptions[name] = value
+	}
+
+	if len(certOptions) == 0 {
+		return nil, errors.New("cert: empty certificate definition")
+	}
+
+	return certOptions, nil
+}
+
+// runCertificates runs the function for each certificate with the options of the certificate:
+// at most concurrency certificates are handled concurrently, and the certificates sharing domains are handled one after the other.
+// A failure does not stop the other certificates: all the errors are returned (partialSuccessError if some certificates succeeded),
+// and a summary of the certificates is logged (or written as JSON).
+func runCertificates(ctx *cli.Context, certificates []map[string]any, fn func(certCtx *cli.Context) error) error {
+	// The contexts are created before the goroutines: the creation of the flags is not concurrency-safe.
+	contexts := make([]*cli.Context, 0, len(certificates))
+
+	for i, options := range certificates {
+		certCtx, err := newCertificateContext(ctx, options)
+		if err != nil {
+			return fmt.Errorf("certificate %d: %w", i, err)
+		}
+
+		contexts = append(contexts, certCtx)
+	}
+
+	sem := make(chan struct{}, max(ctx.Int(flgConcurrency), 1))
+	errs := make([]error, len(contexts))
+
+	// The built-in servers of the certificates listen on the same ports: these certificates are handled one at a time.
+	var serversMu sync.Mutex
+
+	locks := &domainLocks{}
+
+	var wg sync.WaitGroup
+
+	for i, certCtx := range contexts {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// The locks are always taken in the same order (built-in servers, domains, concurrency slot): they cannot deadlock.
+			if usesBuiltinServer(certCtx) {
+				serversMu.Lock()
+				defer serversMu.Unlock()
+			}
+
+			unlock := locks.lock(certCtx.StringSlice(flgDomains))
+			defer unlock()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			errs[i] = fn(certCtx)
+		}()
+	}
+
+	wg.Wait()
+
+	err := printCertificatesSummary(ctx, newCertificatesSummary(contexts, errs))
+	if err != nil {
+		return err
+	}
+
+	var failures []error
+
+	for i, errC := range errs {
+		if errC != nil {
+			failures = append(failures, fmt.Errorf("[%s] %w", certificateName(contexts[i]), errC))
+		}
+	}
+
+	err = errors.Join(failures...)
+
+	// Some certificates succeeded.
+	if err != nil && len(failures) < len(contexts) {
+		return &partialSuccessError{err: err}
+	}
+
+	return err
+}
+
+// domainLocks serializes the certificates sharing domains:
+// the same challenge is never solved concurrently, and the authorizations of a certificate can be reused by the next one.
+type domainLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// lock locks the domains, in a sorted order, and returns the function unlocking them.
+func (l *domainLocks) lock(domains []string) func() {
+	names := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		names = append(names, strings.ToLower(strings.TrimPrefix(domain, "*.")))
+	}
+
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	mutexes := make([]*sync.Mutex, 0, len(names))
+
+	l.mu.Lock()
+
+	if l.locks == nil {
+		l.locks = make(map[string]*sync.Mutex)
+	}
+
+	for _, name := range names {
+		if l.locks[name] == nil {
+			l.locks[name] = &sync.Mutex{}
+		}
+
+		mutexes = append(mutexes, l.locks[name])
+	}
+
+	l.mu.Unlock()
+
+	for _, mu := range mutexes {
+		mu.Lock()
+	}
+
+	return func() {
+		for i := len(mutexes) - 1; i >= 0; i-- {
+			mutexes[i].Unlock()
+		}
+	}
+}
+
+// usesBuiltinServer checks if the challenges of the certificate use the built-in HTTP-01 or TLS-ALPN-01 servers.
+func usesBuiltinServer(ctx *cli.Context) bool {
+	return (ctx.Bool(flgHTTP) && httpProviderName(ctx) == "server") || (ctx.Bool(flgTLS) && tlsProviderName(ctx) == "server")
+}
+
+// certificatesSummary the outcome of the certificates handled by a command.
+type certificatesSummary struct {
+	Total     int                  `json:"total"`
+	Succeeded []string             `json:"succeeded"`
+	Failed    []certificateFailure `json:"failed"`
+}
+
+// certificateFailure a certificate that failed.
+type certificateFailure struct {
+	Certificate string `json:"certificate"`
+	Error       string `json:"error"`
+}
+
+func newCertificatesSummary(contexts []*cli.Context, errs []error) *certificatesSummary {
+	summary := &certificatesSummary{
+		Total:     len(contexts),
+		Succeeded: []string{},
+		Failed:    []certificateFailure{},
+	}
+
+	for i, certCtx := range contexts {
+		if errs[i] == nil {
+			summary.Succeeded = append(summary.Succeeded, certificateName(certCtx))
+			continue
+		}
+
+		summary.Failed = append(summary.Failed, certificateFailure{Certificate: certificateName(certCtx), Error: errs[i].Error()})
+	}
+
+	return summary
+}
+
+// printCertificatesSummary logs the summary, or writes it as JSON (after the results of the certificates).
+func printCertificatesSummary(ctx *cli.Context, summary *certificatesSummary) error {
+	if isJSONOutput(ctx) {
+		return printJSON(struct {
+			Summary *certificatesSummary `json:"summary"`
+		}{Summary: summary})
+	}
+
+	log.Infof("%d certificate(s): %d succeeded, %d failed", summary.Total, len(summary.Succeeded), len(summary.Failed))
+
+	for _, failure := range summary.Failed {
+		log.WithDomain(failure.Certificate).Warnf("failed: %s", strings.ReplaceAll(failure.Error, "\n", " "))
+	}
+
+	return nil
+}
+
+// newCertificateContext creates the context of a certificate:
+// the options of the certificate (and of its account) are defined on top of the options of the command.
+// The options defined by a flag or an environment variable are not overridden.
+// The options of the other commands (ex: renew-hook for the run command) are ignored.
+func newCertificateContext(ctx *cli.Context, certOptions map[string]any) (*cli.Context, error) {
+	if !hasAnyKey(certOptions, flgDomains, flgDomainsFile, flgCSR) {
+		return nil, fmt.Errorf("the option %q, %q, or %q is required", flgDomains, flgDomainsFile, flgCSR)
+	}
+
+	set := flag.NewFlagSet(ctx.Command.Name, flag.ContinueOnError)
+	set.SetOutput(io.Discard)
+
+	var args []string
+
+	addOption := func(f cli.Flag, name string, values []string) error {
+		if set.Lookup(name) == nil {
+			if err := f.Apply(set); err != nil {
+				return fmt.Errorf("option %q: %w", name, err)
+			}
+		}
+
+		for _, value := range values {
+			args = append(args, fmt.Sprintf("--%s=%s", name, value))
+		}
+
+		return nil
+	}
+
+	for _, name := range sortedKeys(certOptions) {
+		if name == configAccountKey {
+			account, ok := certOptions[name].(*certificateAccount)
+			if !ok {
+				return nil, fmt.Errorf("unknown account %q", certOptions[name])
+			}
+
+			for _, option := range sortedKeys(account.options) {
+				if isSetByUser(ctx, option) {
+					continue
+				}
+
+				values, err := configValues(account.options[option])
+				if err != nil {
+					return nil, fmt.Errorf("account %q: option %q: %w", account.name, option, err)
+				}
+
+				if err = addOption(findFlag(ctx.App.Flags, option), option, values); err != nil {
+					return nil, fmt.Errorf("account %q: %w", account.name, err)
+				}
+			}
+
+			continue
+		}
+
+		if slices.Contains(certificateForbiddenOptions, name) {
+			return nil, fmt.Errorf("the option %q cannot be defined by certificate", name)
+		}
+
+		f := findFlag(ctx.App.Flags, name)
+		if f == nil {
+			f = findFlag(ctx.Command.Flags, name)
+		}
+
+		if f == nil {
+			if isCommandFlag(ctx.App.Commands, name) {
+				continue
+			}
+
+			return nil, fmt.Errorf("unknown option %q", name)
+		}
+
+		if isSetByUser(ctx, name) {
+			continue
+		}
+
+		values, err := configValues(certOptions[name])
+		if err != nil {
+			return nil, fmt.Errorf("option %q: %w", name, err)
+		}
+
+		if name == flgDomainsFile && !isSetByUser(ctx, flgDomains) {
+			if len(values) != 1 {
+				return nil, fmt.Errorf("option %q: a single file is expected", name)
+			}
+
+			domains, err := readDomainsFile(values[0])
+			if err != nil {
+				return nil, err
+			}
+
+			if err = addOption(findFlag(ctx.App.Flags, flgDomains), flgDomains, domains); err != nil {
+				return nil, err
+			}
+		}
+
+		if err = addOption(f, name, values); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := set.Parse(args); err != nil {
+		return nil, err
+	}
+
+	certCtx := cli.NewContext(ctx.App, set, ctx)
+	certCtx.Command = ctx.Command
+
+	// The certificates can be handled concurrently: each certificate records its own rate-limit responses.
+	withThrottlingRecorder(certCtx)
+
+	return certCtx, nil
+}
+
+// accountsCache sets up the accounts of the certificates, each account (server and email) is set up once.
+type accountsCache struct {
+	setup func(ctx *cli.Context) (*Account, error)
+
+	mu       sync.Mutex
+	accounts map[string]accountResult
+}
+
+// accountResult the result of the setup of an account.
+type accountResult struct {
+	account *Account
+	err     error
+}
+
+func newAccountsCache(setup func(ctx *cli.Context) (*Account, error)) *accountsCache {
+	return &accountsCache{setup: setup, accounts: make(map[string]accountResult)}
+}
+
+// get returns the account of the certificate.
+// A failure of the setup of an account is returned for all its certificates.
+func (c *accountsCache) get(ctx *cli.Context) (*Account, error) {
+	key := ctx.String(flgServer) + " " + ctx.String(flgEmail)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result, ok := c.accounts[key]
+	if !ok {
+		result.account, result.err = c.setup(ctx)
+		c.accounts[key] = result
+	}
+
+	return result.account, result.err
+}
+
+func hasAnyKey(certOptions map[string]any, names ...string) bool {
+	for _, name := range names {
+		if _, ok := certOptions[name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// certificateName returns the name of a certificate in the messages: the first domain, or the path of the CSR.
+func certificateName(ctx *cli.Context) string {
+	if domains := ctx.StringSlice(flgDomains); len(domains) > 0 {
+		return domains[0]
+	}
+
+	return ctx.String(flgCSR)
+}
@@ -0,0 +1,382 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func Test_parseCertificateGroup(t *testing.T) {
+	certificate, err := parseCertificateGroup("domains=example.com,www.example.com dns=cloudflare http  key-type=ec256")
+	require.NoError(t, err)
+
+	expected := map[string]any{
+		"domains":  "example.com,www.example.com",
+		"dns":      "cloudflare",
+		"http":     "true",
+		"key-type": "ec256",
+	}
+
+	assert.Equal(t, expected, certificate)
+
+	_, err = parseCertificateGroup("  ")
+	require.EqualError(t, err, "cert: empty certificate definition")
+
+	_, err = parseCertificateGroup("=example.com")
+	require.EqualError(t, err, `cert: invalid option "=example.com" in "=example.com"`)
+}
+
+func Test_newCertificateContext(t *testing.T) {
+	type certificateOptions struct {
+		Domains   []string
+		KeyType   string
+		DNS       string
+		RenewHook string
+		Days      int
+	}
+
+	var results []certificateOptions
+
+	app := cli.NewApp()
+	app.Flags = CreateFlags(t.TempDir())
+	app.Commands = CreateCommands()
+
+	command := app.Command("renew")
+	command.Before = nil
+	command.Action = func(ctx *cli.Context) error {
+		certificates, err := getCertificates(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, certificate := range certificates {
+			certCtx, err := newCertificateContext(ctx, certificate)
+			if err != nil {
+				return err
+			}
+
+			results = append(results, certificateOptions{
+				Domains:   certCtx.StringSlice(flgDomains),
+				KeyType:   certCtx.String(flgKeyType),
+				DNS:       certCtx.String(flgDNS),
+				RenewHook: certCtx.String(flgRenewHook),
+				Days:      certCtx.Int(flgDays),
+			})
+		}
+
+		return nil
+	}
+
+	domainsFile := filepath.Join(t.TempDir(), "domains.txt")
+	require.NoError(t, os.WriteFile(domainsFile, []byte("example.net\n# comment\nwww.example.net\n"), 0o600))
+
+	err := app.Run([]string{
+		"lego", "--key-type", "rsa4096",
+		"renew", "--days", "45",
+		"--cert", "domains=example.com,www.example.com dns=cloudflare key-type=ec256 days=10 renew-hook=./reload.sh",
+		"--cert", "domains=example.org http run-hook=./unused.sh",
+		"--cert", "domains-file=" + domainsFile + " http",
+	})
+	require.NoError(t, err)
+
+	expected := []certificateOptions{
+		{
+			Domains:   []string{"example.com", "www.example.com"},
+			KeyType:   "rsa4096",
+			DNS:       "cloudflare",
+			RenewHook: "./reload.sh",
+			Days:      45,
+		},
+		{
+			Domains: []string{"example.org"},
+			KeyType: "rsa4096",
+			Days:    45,
+		},
+		{
+			Domains: []string{"example.net", "www.example.net"},
+			KeyType: "rsa4096",
+			Days:    45,
+		},
+	}
+
+	assert.Equal(t, expected, results)
+}
+
+func Test_newCertificateContext_account(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "lego.yaml")
+	require.NoError(t, os.WriteFile(config, []byte(`
+accounts:
+  staging:
+    email: staging@example.com
+    server: https://acme-staging-v02.api.letsencrypt.org/directory
+certificates:
+  - domains: [example.com]
+    account: staging
+  - domains: [example.org]
+`), 0o600))
+
+	var results [][2]string
+
+	app := cli.NewApp()
+	app.Flags = CreateFlags(t.TempDir())
+	app.Commands = CreateCommands()
+
+	command := app.Command("run")
+	command.Before = nil
+	command.Action = func(ctx *cli.Context) error {
+		certificates, err := getCertificates(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, certificate := range certificates {
+			certCtx, err := newCertificateContext(ctx, certificate)
+			if err != nil {
+				return err
+			}
+
+			results = append(results, [2]string{certCtx.String(flgEmail), certCtx.String(flgServer)})
+		}
+
+		return nil
+	}
+
+	err := app.Run([]string{"lego", "--config", config, "--email", "you@example.com", "run"})
+	require.NoError(t, err)
+
+	expected := [][2]string{
+		// the options defined by the flags override the options of the account.
+		{"you@example.com", "https://acme-staging-v02.api.letsencrypt.org/directory"},
+		{"you@example.com", "https://acme-v02.api.letsencrypt.org/directory"},
+	}
+
+	assert.Equal(t, expected, results)
+
+	err = app.Run([]string{"lego", "--config", config, "run", "--cert", "domains=example.net account=production"})
+	require.EqualError(t, err, `certificate 0: unknown account "production"`)
+}
+
+func Test_accountsCache(t *testing.T) {
+	var calls int
+
+	accounts := newAccountsCache(func(ctx *cli.Context) (*Account, error) {
+		calls++
+
+		if ctx.String(flgEmail) == "fail@example.com" {
+			return nil, errors.New("oops")
+		}
+
+		return &Account{Email: ctx.String(flgEmail)}, nil
+	})
+
+	for range 2 {
+		account, err := accounts.get(newTestContext(t, "--email", "a@example.com"))
+		require.NoError(t, err)
+		assert.Equal(t, "a@example.com", account.Email)
+
+		_, err = accounts.get(newTestContext(t, "--email", "fail@example.com"))
+		require.EqualError(t, err, "oops")
+	}
+
+	assert.Equal(t, 2, calls)
+}
+
+func Test_newCertificateContext_errors(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		group    string
+		expected string
+	}{
+		{
+			desc:     "missing domains",
+			group:    "http",
+			expected: `the option "domains", "domains-file", or "csr" is required`,
+		},
+		{
+			desc:     "account option",
+			group:    "domains=example.com email=test@example.com",
+			expected: `the option "email" cannot be defined by certificate`,
+		},
+		{
+			desc:     "unknown option",
+			group:    "domains=example.com foo=bar",
+			expected: `unknown option "foo"`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			app := cli.NewApp()
+			app.Flags = CreateFlags(t.TempDir())
+			app.Commands = CreateCommands()
+
+			command := app.Command("run")
+			command.Before = nil
+			command.Action = func(ctx *cli.Context) error {
+				certificates, err := getCertificates(ctx)
+				if err != nil {
+					return err
+				}
+
+				_, err = newCertificateContext(ctx, certificates[0])
+
+				return err
+			}
+
+			err := app.Run([]string{"lego", "run", "--cert", test.group})
+			require.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func Test_runCertificates_concurrency(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		challenge string
+		domain    string
+		args      []string
+		expected  int
+	}{
+		{desc: "default", challenge: "dns=manual", expected: 1},
+		{desc: "concurrency", challenge: "dns=manual", args: []string{"--concurrency", "2"}, expected: 2},
+		{desc: "all the certificates", challenge: "dns=manual", args: []string{"--cert.concurrency", "4"}, expected: 4},
+		{desc: "shared domain", challenge: "dns=manual", domain: "shared.example.com", args: []string{"--cert.concurrency", "2"}, expected: 1},
+		{desc: "webroot", challenge: "http http.webroot=/var/www", args: []string{"--concurrency", "2"}, expected: 2},
+		{desc: "built-in HTTP server", challenge: "http", args: []string{"--cert.concurrency", "2"}, expected: 1},
+		{desc: "built-in TLS server", challenge: "tls tls.port=:8443", args: []string{"--cert.concurrency", "2"}, expected: 1},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			var (
+				mu        sync.Mutex
+				inFlight  int
+				maxFlight int
+				released  bool
+			)
+
+			// The certificates wait until the expected number of certificates are handled concurrently:
+			// the test would block if fewer certificates were handled concurrently.
+			full := make(chan struct{})
+
+			app := cli.NewApp()
+			app.Flags = CreateFlags(t.TempDir())
+			app.Commands = CreateCommands()
+
+			command := app.Command("run")
+			command.Before = nil
+			command.Action = func(ctx *cli.Context) error {
+				certificates, err := getCertificates(ctx)
+				if err != nil {
+					return err
+				}
+
+				return runCertificates(ctx, certificates, func(_ *cli.Context) error {
+					mu.Lock()
+					inFlight++
+					maxFlight = max(maxFlight, inFlight)
+
+					if inFlight == test.expected && !released {
+						released = true
+						close(full)
+					}
+					mu.Unlock()
+
+					<-full
+
+					mu.Lock()
+					inFlight--
+					mu.Unlock()
+
+					return nil
+				})
+			}
+
+			args := []string{"lego", "run"}
+			for _, domain := range []string{"a.example.com", "b.example.com", "c.example.com", "d.example.com"} {
+				if test.domain != "" {
+					domain += "," + test.domain
+				}
+
+				args = append(args, "--cert", "domains="+domain+" "+test.challenge)
+			}
+
+			err := app.Run(append(args, test.args...))
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, maxFlight)
+		})
+	}
+}
+
+func Test_runCertificates_partialSuccess(t *testing.T) {
+	app := cli.NewApp()
+	app.Flags = CreateFlags(t.TempDir())
+	app.Commands = CreateCommands()
+
+	command := app.Command("run")
+	command.Before = nil
+	command.Action = func(ctx *cli.Context) error {
+		certificates, err := getCertificates(ctx)
+		if err != nil {
+			return err
+		}
+
+		return runCertificates(ctx, certificates, func(certCtx *cli.Context) error {
+			if certificateName(certCtx) == "b.example.com" {
+				return errors.New("oops")
+			}
+
+			return nil
+		})
+	}
+
+	err := app.Run([]string{"lego", "run", "--cert", "domains=a.example.com http", "--cert", "domains=b.example.com http"})
+	require.EqualError(t, err, "[b.example.com] oops")
+
+	assert.Equal(t, ExitCodePartialSuccess, ExitCode(err))
+}
+
+func Test_newCertificatesSummary(t *testing.T) {
+	contexts := []*cli.Context{
+		newTestContext(t, "-d", "a.example.com"),
+		newTestContext(t, "-d", "b.example.com"),
+		newTestContext(t, "-d", "c.example.com"),
+	}
+
+	summary := newCertificatesSummary(contexts, []error{nil, errors.New("oops"), nil})
+
+	expected := &certificatesSummary{
+		Total:     3,
+		Succeeded: []string{"a.example.com", "c.example.com"},
+		Failed:    []certificateFailure{{Certificate: "b.example.com", Error: "oops"}},
+	}
+
+	assert.Equal(t, expected, summary)
+}
+
+func Test_domainLocks(t *testing.T) {
+	locks := &domainLocks{}
+
+	unlock := locks.lock([]string{"b.example.com", "*.A.example.com", "a.example.com"})
+
+	assert.Len(t, locks.locks, 2)
+
+	// The certificates sharing a domain wait.
+	assert.False(t, locks.locks["a.example.com"].TryLock())
+	assert.False(t, locks.locks["b.example.com"].TryLock())
+
+	// The certificates without shared domains are not blocked.
+	locks.lock([]string{"c.example.org"})()
+
+	unlock()
+
+	assert.True(t, locks.locks["a.example.com"].TryLock())
+	assert.True(t, locks.locks["b.example.com"].TryLock())
+}
@@ -5,11 +5,12 @@ import (
 	"crypto"
 	"crypto/x509"
 	"encoding/json"
-	"encoding/pem"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"path/filepath"
+	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -17,9 +18,10 @@ import (
 	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/go-acme/lego/v4/certificate"
 	"github.com/go-acme/lego/v4/log"
+	"github.com/go-acme/lego/v4/storage"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ocsp"
 	"golang.org/x/net/idna"
-	"software.sslmate.com/src/go-pkcs12"
 )
 
 const (
@@ -34,8 +36,64 @@ const (
 	pemExt      = ".pem"
 	pfxExt      = ".pfx"
 	resourceExt = ".json"
+	leafExt     = ".leaf.crt"
+	chainExt    = ".chain.crt"
+	rootExt     = ".root.crt"
+	derExt      = ".der"
+	p7bExt      = ".p7b"
+	haproxyExt  = ".haproxy.pem"
+	pinsExt     = ".pins.txt"
+	tlsaExt     = ".tlsa"
+	ocspExt     = ".ocsp"
+	bakExt      = ".bak"
 )
 
+// certificateFile a file of a certificate, identified by its extension.
+type certificateFile struct {
+	ext  string
+	data []byte
+}
+
+// resourceMetadata the content of the JSON file of a certificate: the resource and its manifest.
+type resourceMetadata struct {
+	*certificate.Resource
+
+	Manifest *certificate.Manifest `json:"manifest"`
+
+	// KeyRenewals the number of renewals done with the private key of the certificate (used by the key management policy).
+	KeyRenewals int `json:"keyRenewals"`
+}
+
+// keyStoreExts the extensions of the Java keystore and truststore files, by format.
+var keyStoreExts = map[certcrypto.KeyStoreFormat][2]string{
+	certcrypto.KeyStoreFormatJKS:    {".keystore.jks", ".truststore.jks"},
+	certcrypto.KeyStoreFormatPKCS12: {".keystore.p12", ".truststore.p12"},
+}
+
+// Output formats (additional certificate files).
+const (
+	outputLeaf    = "leaf"
+	outputChain   = "chain"
+	outputRoot    = "root"
+	outputDER     = "der"
+	outputPKCS7   = "p7b"
+	outputHAProxy = "haproxy"
+	outputPins    = "pins"
+	outputTLSA    = "tlsa"
+)
+
+// outputFormatExts the extensions of the files of the output formats.
+var outputFormatExts = map[string]string{
+	outputLeaf:    leafExt,
+	outputChain:   chainExt,
+	outputRoot:    rootExt,
+	outputDER:     derExt,
+	outputPKCS7:   p7bExt,
+	outputHAProxy: haproxyExt,
+	outputPins:    pinsExt,
+	outputTLSA:    tlsaExt,
+}
+
 // CertificatesStorage a certificates' storage.
 //
 // rootPath:
@@ -49,126 +107,254 @@ const (
 //	./.lego/archives/
 //	     │      └── archived certificates directory
 //	     └── "path" option
+//
+// The paths are relative to the storage backend (the "path" option is only used by the file storage).
 type CertificatesStorage struct {
+	backend     storage.CertificatesStorage
 	rootPath    string
 	archivePath string
 	pem         bool
 	pfx         bool
 	pfxPassword string
 	pfxFormat   string
+	keyPass     []byte
+	outputs     []string
+	ocspStaple  bool
+	keyStore    bool
+	keyStoreOpt certcrypto.KeyStoreOptions
+	trustAlias  string
+	challenges  []certificate.ManifestChallenge
 	filename    string // Deprecated
 }
 
 // NewCertificatesStorage create a new certificates storage.
 func NewCertificatesStorage(ctx *cli.Context) *CertificatesStorage {
+	certsStorage, err := newCertificatesStorage(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return certsStorage
+}
+
+// newCertificatesStorage creates a new certificates storage, the invalid formats are returned as errors.
+func newCertificatesStorage(ctx *cli.Context) (*CertificatesStorage, error) {
 	pfxFormat := ctx.String(flgPFXFormat)
 
-	switch pfxFormat {
-	case "DES", "RC2", "SHA256":
+	switch certcrypto.PFXFormat(pfxFormat) {
+	case certcrypto.PFXFormatDES, certcrypto.PFXFormatRC2, certcrypto.PFXFormatSHA256:
 	default:
-		log.Fatalf("Invalid PFX format: %s", pfxFormat)
+		return nil, fmt.Errorf("invalid PFX format: %s", pfxFormat)
+	}
+
+	outputs := ctx.StringSlice(flgOutputFormat)
+
+	for _, output := range outputs {
+		if _, ok := outputFormatExts[output]; !ok {
+			return nil, fmt.Errorf("invalid output format: %s", output)
+		}
+	}
+
+	keyStoreFormat := certcrypto.KeyStoreFormat(ctx.String(flgKeyStoreFormat))
+
+	if _, ok := keyStoreExts[keyStoreFormat]; !ok {
+		return nil, fmt.Errorf("invalid keystore format: %s", keyStoreFormat)
 	}
 
 	return &CertificatesStorage{
-		rootPath:    filepath.Join(ctx.String(flgPath), baseCertificatesFolderName),
-		archivePath: filepath.Join(ctx.String(flgPath), baseArchivesFolderName),
+		backend:     setupStorage(ctx),
+		rootPath:    baseCertificatesFolderName,
+		archivePath: baseArchivesFolderName,
 		pem:         ctx.Bool(flgPEM),
 		pfx:         ctx.Bool(flgPFX),
 		pfxPassword: ctx.String(flgPFXPass),
 		pfxFormat:   pfxFormat,
-		filename:    ctx.String(flgFilename),
-	}
+		keyPass:     getPassphrase(ctx, flgKeyPass, flgKeyPassFile),
+		outputs:     outputs,
+		ocspStaple:  ctx.Bool(flgOCSPStaple),
+		keyStore:    ctx.Bool(flgKeyStore),
+		keyStoreOpt: certcrypto.KeyStoreOptions{
+			Format:      keyStoreFormat,
+			Password:    ctx.String(flgKeyStorePass),
+			KeyPassword: ctx.String(flgKeyStoreKeyPass),
+			Alias:       ctx.String(flgKeyStoreAlias),
+		},
+		trustAlias: ctx.String(flgKeyStoreTrustAlias),
+		challenges: enabledChallenges(ctx),
+		filename:   ctx.String(flgFilename),
+	}, nil
 }
 
-func (s *CertificatesStorage) CreateRootFolder() {
-	err := createNonExistingFolder(s.rootPath)
+// ListCertificates returns the paths of the certificate files (`.crt`), the issuer certificates excluded.
+func (s *CertificatesStorage) ListCertificates() ([]string, error) {
+	keys, err := s.backend.List(s.rootPath)
 	if err != nil {
-		log.Fatalf("Could not check/create path: %v", err)
+		return nil, err
 	}
-}
 
-func (s *CertificatesStorage) CreateArchiveFolder() {
-	err := createNonExistingFolder(s.archivePath)
-	if err != nil {
-		log.Fatalf("Could not check/create path: %v", err)
+	var certificates []string
+
+	for _, key := range keys {
+		if path.Dir(key) != s.rootPath || !strings.HasSuffix(key, certExt) {
+			continue
+		}
+
+		// the issuer certificates and the output files.
+		if slices.ContainsFunc([]string{issuerExt, leafExt, chainExt, rootExt}, func(ext string) bool { return strings.HasSuffix(key, ext) }) {
+			continue
+		}
+
+		certificates = append(certificates, key)
 	}
-}
 
-func (s *CertificatesStorage) GetRootPath() string {
-	return s.rootPath
+	return certificates, nil
 }
 
+// SaveResource saves the files of the certificate, the process exits on failure.
 func (s *CertificatesStorage) SaveResource(certRes *certificate.Resource) {
+	err := s.saveResource(certRes)
+	if err != nil {
+		Exit(err)
+	}
+}
+
+// saveResource saves the files of the certificate.
+// The failures of the storage are wrapped in a storageError.
+func (s *CertificatesStorage) saveResource(certRes *certificate.Resource) error {
 	domain := certRes.Domain
 
 	// We store the certificate, private key and metadata in different files
 	// as web servers would not be able to work with a combined file.
-	err := s.WriteFile(domain, certExt, certRes.Certificate)
-	if err != nil {
-		log.Fatalf("Unable to save Certificate for domain %s\n\t%v", domain, err)
-	}
+	files := []certificateFile{{ext: certExt, data: certRes.Certificate}}
 
 	if certRes.IssuerCertificate != nil {
-		err = s.WriteFile(domain, issuerExt, certRes.IssuerCertificate)
-		if err != nil {
-			log.Fatalf("Unable to save IssuerCertificate for domain %s\n\t%v", domain, err)
-		}
+		files = append(files, certificateFile{ext: issuerExt, data: certRes.IssuerCertificate})
 	}
 
 	// if we were given a CSR, we don't know the private key
 	if certRes.PrivateKey != nil {
-		err = s.WriteCertificateFiles(domain, certRes)
+		keyFiles, err := s.encodeCertificateFiles(domain, certRes)
 		if err != nil {
-			log.Fatalf("Unable to save PrivateKey for domain %s\n\t%v", domain, err)
+			return fmt.Errorf("unable to encode PrivateKey for domain %s\n\t%w", domain, err)
 		}
-	} else if s.pem || s.pfx {
-		// we don't have the private key; can't write the .pem or .pfx file
-		log.Fatalf("Unable to save PEM or PFX without private key for domain %s. Are you using a CSR?", domain)
+
+		files = append(files, keyFiles...)
+	} else if s.pem || s.pfx || s.keyStore || slices.Contains(s.outputs, outputHAProxy) {
+		// we don't have the private key; can't write the .pem, .pfx, or keystore file
+		return fmt.Errorf("unable to save PEM, PFX, or keystore without private key for domain %s. Are you using a CSR?", domain)
 	}
 
-	jsonBytes, err := json.MarshalIndent(certRes, "", "\t")
+	outputFiles, err := s.encodeOutputFiles(domain, certRes)
 	if err != nil {
-		log.Fatalf("Unable to marshal CertResource for domain %s\n\t%v", domain, err)
+		return fmt.Errorf("unable to encode the output files for domain %s\n\t%w", domain, err)
 	}
 
-	err = s.WriteFile(domain, resourceExt, jsonBytes)
+	files = append(files, outputFiles...)
+
+	manifest, err := certificate.NewManifest(certRes)
 	if err != nil {
-		log.Fatalf("Unable to save CertResource for domain %s\n\t%v", domain, err)
+		return fmt.Errorf("unable to create the manifest for domain %s\n\t%w", domain, err)
 	}
+
+	for i, chlg := range manifest.Challenges {
+		manifest.Challenges[i].Provider = solvedChallengeProvider(s.challenges, chlg)
+	}
+
+	metadata := resourceMetadata{
+		Resource:    certRes,
+		Manifest:    manifest,
+		KeyRenewals: s.countKeyRenewals(domain, certRes),
+	}
+
+	jsonBytes, err := json.MarshalIndent(metadata, "", "\t")
+	if err != nil {
+		return fmt.Errorf("unable to marshal CertResource for domain %s\n\t%w", domain, err)
+	}
+
+	files = append(files, certificateFile{ext: resourceExt, data: jsonBytes})
+
+	err = s.WriteFiles(domain, files)
+	if err != nil {
+		return &storageError{err: fmt.Errorf("unable to save the certificate files for domain %s\n\t%w", domain, err)}
+	}
+
+	return nil
 }
 
 func (s *CertificatesStorage) ReadResource(domain string) certificate.Resource {
+	resource, err := s.readResource(domain)
+	if err != nil {
+		Exit(&storageError{err: err})
+	}
+
+	return resource
+}
+
+func (s *CertificatesStorage) readResource(domain string) (certificate.Resource, error) {
 	raw, err := s.ReadFile(domain, resourceExt)
 	if err != nil {
-		log.Fatalf("Error while loading the meta data for domain %s\n\t%v", domain, err)
+		return certificate.Resource{}, fmt.Errorf("error while loading the meta data for domain %s\n\t%w", domain, err)
 	}
 
 	var resource certificate.Resource
 	if err = json.Unmarshal(raw, &resource); err != nil {
-		log.Fatalf("Error while marshaling the meta data for domain %s\n\t%v", domain, err)
+		return certificate.Resource{}, fmt.Errorf("error while marshaling the meta data for domain %s\n\t%w", domain, err)
 	}
 
-	return resource
+	return resource, nil
 }
 
-func (s *CertificatesStorage) ExistsFile(domain, extension string) bool {
-	filePath := s.GetFileName(domain, extension)
+// ReadKeyRenewals returns the number of renewals done with the current private key of the certificate.
+func (s *CertificatesStorage) ReadKeyRenewals(domain string) int {
+	raw, err := s.ReadFile(domain, resourceExt)
+	if err != nil {
+		return 0
+	}
 
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return false
-	} else if err != nil {
+	var metadata resourceMetadata
+	if err = json.Unmarshal(raw, &metadata); err != nil {
+		return 0
+	}
+
+	return metadata.KeyRenewals
+}
+
+// countKeyRenewals returns the number of renewals done with the private key of the new certificate:
+// the counter of the previous certificate is incremented if the public key is unchanged.
+func (s *CertificatesStorage) countKeyRenewals(domain string, certRes *certificate.Resource) int {
+	previous, err := s.ReadCertificate(domain, certExt)
+	if err != nil {
+		return 0
+	}
+
+	current, err := certcrypto.ParsePEMCertificate(certRes.Certificate)
+	if err != nil {
+		return 0
+	}
+
+	publicKey, ok := current.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !publicKey.Equal(previous[0].PublicKey) {
+		return 0
+	}
+
+	return s.ReadKeyRenewals(domain) + 1
+}
+
+func (s *CertificatesStorage) ExistsFile(domain, extension string) bool {
+	exists, err := s.backend.Exists(s.getKey(sanitizedDomain(domain), extension))
+	if err != nil {
 		log.Fatal(err)
 	}
-	return true
+	return exists
 }
 
 func (s *CertificatesStorage) ReadFile(domain, extension string) ([]byte, error) {
-	return os.ReadFile(s.GetFileName(domain, extension))
+	return s.backend.ReadFile(s.getKey(sanitizedDomain(domain), extension))
 }
 
+// GetFileName returns the location of the file in the storage (ex: the path of the file on the disk).
 func (s *CertificatesStorage) GetFileName(domain, extension string) string {
-	filename := sanitizedDomain(domain) + extension
-	return filepath.Join(s.rootPath, filename)
+	return s.backend.Location(s.getKey(sanitizedDomain(domain), extension))
 }
 
 func (s *CertificatesStorage) ReadCertificate(domain, extension string) ([]*x509.Certificate, error) {
@@ -181,112 +367,412 @@ func (s *CertificatesStorage) ReadCertificate(domain, extension string) ([]*x509
 	return certcrypto.ParsePEMBundle(content)
 }
 
+// ReadPrivateKey reads the private key of the domain, the encrypted private keys are decrypted with the key passphrase.
+func (s *CertificatesStorage) ReadPrivateKey(domain string) (crypto.PrivateKey, error) {
+	content, err := s.ReadFile(domain, keyExt)
+	if err != nil {
+		return nil, err
+	}
+
+	return certcrypto.ParseEncryptedPEMPrivateKey(content, s.keyPass)
+}
+
 func (s *CertificatesStorage) WriteFile(domain, extension string, data []byte) error {
-	var baseFileName string
-	if s.filename != "" {
-		baseFileName = s.filename
-	} else {
-		baseFileName = sanitizedDomain(domain)
+	return s.backend.WriteFile(s.getKey(s.getBaseFileName(domain), extension), data)
+}
+
+// WriteFiles writes the files of a certificate:
+// the previous versions of the files are kept as `.bak` files, and they are restored if a write fails.
+// The private key is written first, so a server watching the certificate file picks up the new private key with it.
+// With the file storage, each file is written atomically (temporary file, fsync, and rename),
+// but the files are written one by one: a reader can see a mix of previous and new files during the write.
+// The storages implementing storage.MultiFileWriter (ex: Kubernetes) write all the files at once.
+func (s *CertificatesStorage) WriteFiles(domain string, files []certificateFile) error {
+	baseFileName := s.getBaseFileName(domain)
+
+	files = slices.Clone(files)
+	slices.SortStableFunc(files, func(a, b certificateFile) int {
+		switch {
+		case a.ext == keyExt && b.ext != keyExt:
+			return -1
+		case a.ext != keyExt && b.ext == keyExt:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	previous := make(map[string][]byte)
+
+	for _, file := range files {
+		key := s.getKey(baseFileName, file.ext)
+
+		data, err := s.backend.ReadFile(key)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("unable to read the previous %s file: %w", file.ext, err)
+		}
+
+		err = s.backend.WriteFile(key+bakExt, data)
+		if err != nil {
+			return fmt.Errorf("unable to back up the %s file: %w", file.ext, err)
+		}
+
+		previous[file.ext] = data
 	}
 
-	filePath := filepath.Join(s.rootPath, baseFileName+extension)
+	if writer, ok := s.backend.(storage.MultiFileWriter); ok {
+		data := make(map[string][]byte, len(files))
+		for _, file := range files {
+			data[s.getKey(baseFileName, file.ext)] = file.data
+		}
+
+		err := writer.WriteFiles(data)
+		if err != nil {
+			return errors.Join(
+				fmt.Errorf("unable to save the certificate files: %w", err),
+				s.rollback(baseFileName, files, previous),
+			)
+		}
+
+		return nil
+	}
+
+	for i, file := range files {
+		err := s.backend.WriteFile(s.getKey(baseFileName, file.ext), file.data)
+		if err != nil {
+			return errors.Join(
+				fmt.Errorf("unable to save %s file: %w", file.ext, err),
+				s.rollback(baseFileName, files[:i+1], previous),
+			)
+		}
+	}
+
+	return nil
+}
+
+// rollback restores the previous versions of the files, and removes the files without previous version.
+func (s *CertificatesStorage) rollback(baseFileName string, files []certificateFile, previous map[string][]byte) error {
+	var errs []error
+
+	for _, file := range files {
+		key := s.getKey(baseFileName, file.ext)
+
+		var err error
+		if data, ok := previous[file.ext]; ok {
+			err = s.backend.WriteFile(key, data)
+		} else {
+			err = s.backend.Remove(key)
+		}
+
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("unable to roll back the %s file: %w", file.ext, err))
+		}
+	}
 
-	return os.WriteFile(filePath, data, filePerm)
+	return errors.Join(errs...)
 }
 
 func (s *CertificatesStorage) WriteCertificateFiles(domain string, certRes *certificate.Resource) error {
-	err := s.WriteFile(domain, keyExt, certRes.PrivateKey)
+	files, err := s.encodeCertificateFiles(domain, certRes)
 	if err != nil {
-		return fmt.Errorf("unable to save key file: %w", err)
+		return err
 	}
 
+	return s.WriteFiles(domain, files)
+}
+
+// WriteOutputFiles writes the files of the output formats (leaf, chain, root, DER, PKCS#7, HAProxy).
+func (s *CertificatesStorage) WriteOutputFiles(domain string, certRes *certificate.Resource) error {
+	files, err := s.encodeOutputFiles(domain, certRes)
+	if err != nil {
+		return err
+	}
+
+	return s.WriteFiles(domain, files)
+}
+
+// encodeCertificateFiles encodes the files containing the private key (.key, .pem, .pfx, and the Java keystore).
+func (s *CertificatesStorage) encodeCertificateFiles(domain string, certRes *certificate.Resource) ([]certificateFile, error) {
+	keyPEM, err := s.encodePrivateKey(certRes.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encrypt private key: %w", err)
+	}
+
+	files := []certificateFile{{ext: keyExt, data: keyPEM}}
+
 	if s.pem {
-		err = s.WriteFile(domain, pemExt, bytes.Join([][]byte{certRes.Certificate, certRes.PrivateKey}, nil))
+		files = append(files, certificateFile{ext: pemExt, data: bytes.Join([][]byte{certRes.Certificate, keyPEM}, nil)})
+	}
+
+	if s.pfx {
+		pfxBytes, err := certRes.PFX(s.pfxPassword, certcrypto.PFXFormat(s.pfxFormat))
 		if err != nil {
-			return fmt.Errorf("unable to save PEM file: %w", err)
+			return nil, fmt.Errorf("unable to encode PFX data for domain %s: %w", domain, err)
 		}
+
+		files = append(files, certificateFile{ext: pfxExt, data: pfxBytes})
 	}
 
-	if s.pfx {
-		err = s.WritePFXFile(domain, certRes)
+	if s.keyStore {
+		keyStoreFiles, err := s.encodeKeyStoreFiles(domain, certRes)
 		if err != nil {
-			return fmt.Errorf("unable to save PFX file: %w", err)
+			return nil, fmt.Errorf("unable to encode keystore files: %w", err)
 		}
+
+		files = append(files, keyStoreFiles...)
 	}
 
-	return nil
+	return files, nil
 }
 
-func (s *CertificatesStorage) WritePFXFile(domain string, certRes *certificate.Resource) error {
-	certPemBlock, _ := pem.Decode(certRes.Certificate)
-	if certPemBlock == nil {
-		return fmt.Errorf("unable to parse Certificate for domain %s", domain)
+// encodeOutputFiles encodes the files of the output formats (leaf, chain, root, DER, PKCS#7, HAProxy).
+func (s *CertificatesStorage) encodeOutputFiles(domain string, certRes *certificate.Resource) ([]certificateFile, error) {
+	if len(s.outputs) == 0 {
+		return nil, nil
+	}
+
+	chain, err := certcrypto.ParseCertificateChain(certRes.Certificate, certRes.IssuerCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the certificate chain: %w", err)
+	}
+
+	var files []certificateFile
+
+	for _, output := range s.outputs {
+		var data []byte
+
+		switch output {
+		case outputLeaf:
+			data = certcrypto.PEMEncodeCertificates([]*x509.Certificate{chain.Leaf})
+
+		case outputChain:
+			if len(chain.Intermediates) == 0 {
+				log.WithDomain(domain).Warnf("The certificate chain is empty, the %s file is not written.", chainExt)
+				continue
+			}
+
+			data = certcrypto.PEMEncodeCertificates(chain.Intermediates)
+
+		case outputRoot:
+			if chain.Root == nil {
+				log.WithDomain(domain).Warnf("The root certificate is not provided by the CA, the %s file is not written.", rootExt)
+				continue
+			}
+
+			data = certcrypto.PEMEncodeCertificates([]*x509.Certificate{chain.Root})
+
+		case outputDER:
+			data = chain.Leaf.Raw
+
+		case outputPKCS7:
+			data, err = certcrypto.EncodePKCS7(chain.Certificates())
+			if err != nil {
+				return nil, err
+			}
+
+		case outputHAProxy:
+			keyPEM, err := s.encodePrivateKey(certRes.PrivateKey)
+			if err != nil {
+				return nil, fmt.Errorf("unable to encrypt private key: %w", err)
+			}
+
+			certificates := append([]*x509.Certificate{chain.Leaf}, chain.Intermediates...)
+
+			data = bytes.Join([][]byte{certcrypto.PEMEncodeCertificates(certificates), keyPEM}, nil)
+
+		case outputPins:
+			data = encodePins(chain)
+
+		case outputTLSA:
+			data = encodeTLSARecords(chain)
+			if len(data) == 0 {
+				log.WithDomain(domain).Warnf("The certificate has no domain usable in a TLSA record, the %s file is not written.", tlsaExt)
+				continue
+			}
+		}
+
+		files = append(files, certificateFile{ext: outputFormatExts[output], data: data})
 	}
 
-	cert, err := x509.ParseCertificate(certPemBlock.Bytes)
+	return files, nil
+}
+
+// encodePrivateKey encrypts the private key if a key passphrase is defined.
+func (s *CertificatesStorage) encodePrivateKey(keyPEM []byte) ([]byte, error) {
+	if len(s.keyPass) == 0 {
+		return keyPEM, nil
+	}
+
+	privateKey, err := certcrypto.ParsePEMPrivateKey(keyPEM)
 	if err != nil {
-		return fmt.Errorf("unable to load Certificate for domain %s: %w", domain, err)
+		return nil, err
 	}
 
-	certChain, err := getCertificateChain(certRes)
+	return certcrypto.PEMEncodeEncrypted(privateKey, s.keyPass)
+}
+
+func (s *CertificatesStorage) WritePFXFile(domain string, certRes *certificate.Resource) error {
+	pfxBytes, err := certRes.PFX(s.pfxPassword, certcrypto.PFXFormat(s.pfxFormat))
 	if err != nil {
-		return fmt.Errorf("unable to get certificate chain for domain %s: %w", domain, err)
+		return fmt.Errorf("unable to encode PFX data for domain %s: %w", domain, err)
 	}
 
-	keyPemBlock, _ := pem.Decode(certRes.PrivateKey)
-	if keyPemBlock == nil {
-		return fmt.Errorf("unable to parse PrivateKey for domain %s", domain)
+	return s.WriteFile(domain, pfxExt, pfxBytes)
+}
+
+// UpdateOCSPStaple fetches the OCSP response of the certificate and writes it (DER) in the .ocsp file.
+// Unless force is true, the existing response is kept until it needs a refresh (see certificate.NeedsOCSPRefresh).
+// It returns true if the file has been written.
+func (s *CertificatesStorage) UpdateOCSPStaple(domain string, checker *certificate.OCSPChecker, force bool) (bool, error) {
+	certPEM, err := s.ReadFile(domain, certExt)
+	if err != nil {
+		return false, err
 	}
 
-	var privateKey crypto.Signer
-	var keyErr error
+	certificates, err := certcrypto.ParsePEMBundle(certPEM)
+	if err != nil {
+		return false, err
+	}
 
-	switch keyPemBlock.Type {
-	case "RSA PRIVATE KEY":
-		privateKey, keyErr = x509.ParsePKCS1PrivateKey(keyPemBlock.Bytes)
-		if keyErr != nil {
-			return fmt.Errorf("unable to load RSA PrivateKey for domain %s: %w", domain, keyErr)
+	if !force && s.ExistsFile(domain, ocspExt) {
+		raw, errR := s.ReadFile(domain, ocspExt)
+		if errR != nil {
+			return false, errR
 		}
-	case "EC PRIVATE KEY":
-		privateKey, keyErr = x509.ParseECPrivateKey(keyPemBlock.Bytes)
-		if keyErr != nil {
-			return fmt.Errorf("unable to load EC PrivateKey for domain %s: %w", domain, keyErr)
+
+		// The signature has been verified when the response has been fetched.
+		response, errP := ocsp.ParseResponse(raw, nil)
+		if errP == nil && !certificate.NeedsOCSPRefresh(response, certificates[0], time.Now()) {
+			return false, nil
 		}
-	default:
-		return fmt.Errorf("unsupported PrivateKey type '%s' for domain %s", keyPemBlock.Type, domain)
 	}
 
-	encoder, err := getPFXEncoder(s.pfxFormat)
+	bundle := certPEM
+
+	// The certificate is not bundled (--no-bundle): the issuer certificate avoids a request to the CA.
+	if len(certificates) == 1 && s.ExistsFile(domain, issuerExt) {
+		issuerPEM, errR := s.ReadFile(domain, issuerExt)
+		if errR != nil {
+			return false, errR
+		}
+
+		bundle = bytes.Join([][]byte{certPEM, issuerPEM}, nil)
+	}
+
+	raw, response, err := checker.Check(bundle)
 	if err != nil {
-		return fmt.Errorf("PFX encoder: %w", err)
+		return false, fmt.Errorf("unable to get the OCSP response: %w", err)
+	}
+
+	if response.Status != ocsp.Good {
+		log.WithDomain(domain).Warnf("The OCSP status of the certificate is not good (%d).", response.Status)
 	}
 
-	pfxBytes, err := encoder.Encode(privateKey, cert, certChain, s.pfxPassword)
+	err = s.WriteFile(domain, ocspExt, raw)
 	if err != nil {
-		return fmt.Errorf("unable to encode PFX data for domain %s: %w", domain, err)
+		return false, err
 	}
 
-	return s.WriteFile(domain, pfxExt, pfxBytes)
+	return true, nil
 }
 
+// WriteKeyStoreFiles writes the Java keystore (private key, certificate, and chain) and the Java truststore (chain).
+func (s *CertificatesStorage) WriteKeyStoreFiles(domain string, certRes *certificate.Resource) error {
+	files, err := s.encodeKeyStoreFiles(domain, certRes)
+	if err != nil {
+		return err
+	}
+
+	return s.WriteFiles(domain, files)
+}
+
+// encodeKeyStoreFiles encodes the Java keystore (private key, certificate, and chain) and the Java truststore (chain).
+func (s *CertificatesStorage) encodeKeyStoreFiles(domain string, certRes *certificate.Resource) ([]certificateFile, error) {
+	keyStoreExt, trustStoreExt := s.keyStoreExts()
+
+	keyStore, err := certRes.KeyStore(s.keyStoreOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []certificateFile{{ext: keyStoreExt, data: keyStore}}
+
+	chain, err := certcrypto.ParseCertificateChain(certRes.Certificate, certRes.IssuerCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the certificate chain: %w", err)
+	}
+
+	if len(chain.Intermediates) == 0 && chain.Root == nil {
+		log.WithDomain(domain).Warnf("The certificate chain is empty, the %s file is not written.", trustStoreExt)
+		return files, nil
+	}
+
+	trustOpt := s.keyStoreOpt
+	trustOpt.Alias = s.trustAlias
+
+	trustStore, err := certRes.TrustStore(trustOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(files, certificateFile{ext: trustStoreExt, data: trustStore}), nil
+}
+
+// keyStoreExts returns the extensions of the keystore and truststore files.
+func (s *CertificatesStorage) keyStoreExts() (string, string) {
+	exts := keyStoreExts[s.keyStoreOpt.Format]
+	return exts[0], exts[1]
+}
+
+// MoveToArchive moves the files of the certificate to the archive.
 func (s *CertificatesStorage) MoveToArchive(domain string) error {
-	baseFilename := filepath.Join(s.rootPath, sanitizedDomain(domain))
+	return s.archive(domain, true)
+}
+
+// CopyToArchive copies the files of the certificate to the archive, the files stay in place.
+func (s *CertificatesStorage) CopyToArchive(domain string) error {
+	return s.archive(domain, false)
+}
+
+func (s *CertificatesStorage) archive(domain string, remove bool) error {
+	baseFilename := sanitizedDomain(domain)
 
-	matches, err := filepath.Glob(baseFilename + ".*")
+	keys, err := s.backend.List(s.rootPath)
 	if err != nil {
 		return err
 	}
 
-	for _, oldFile := range matches {
-		if strings.TrimSuffix(oldFile, filepath.Ext(oldFile)) != baseFilename && oldFile != baseFilename+issuerExt {
+	for _, oldKey := range keys {
+		if path.Dir(oldKey) != s.rootPath {
 			continue
 		}
 
+		name := path.Base(oldKey)
+		if !isCertificateFile(name, baseFilename) {
+			continue
+		}
+
+		data, err := s.backend.ReadFile(oldKey)
+		if err != nil {
+			return err
+		}
+
 		date := strconv.FormatInt(time.Now().Unix(), 10)
-		filename := date + "." + filepath.Base(oldFile)
-		newFile := filepath.Join(s.archivePath, filename)
 
-		err = os.Rename(oldFile, newFile)
+		err = s.backend.WriteFile(path.Join(s.archivePath, date+"."+name), data)
+		if err != nil {
+			return err
+		}
+
+		if !remove {
+			continue
+		}
+
+		err = s.backend.Remove(oldKey)
 		if err != nil {
 			return err
 		}
@@ -295,40 +781,66 @@ func (s *CertificatesStorage) MoveToArchive(domain string) error {
 	return nil
 }
 
-func getCertificateChain(certRes *certificate.Resource) ([]*x509.Certificate, error) {
-	chainCertPemBlock, rest := pem.Decode(certRes.IssuerCertificate)
-	if chainCertPemBlock == nil {
-		return nil, errors.New("unable to parse Issuer Certificate")
+// isCertificateFile checks if the file is one of the files of the certificate (`<baseFilename><ext>`).
+func isCertificateFile(name, baseFilename string) bool {
+	// the previous versions of the files (see WriteFiles).
+	name = strings.TrimSuffix(name, bakExt)
+
+	if strings.TrimSuffix(name, path.Ext(name)) == baseFilename || name == baseFilename+issuerExt {
+		return true
 	}
 
-	var certChain []*x509.Certificate
-	for chainCertPemBlock != nil {
-		chainCert, err := x509.ParseCertificate(chainCertPemBlock.Bytes)
-		if err != nil {
-			return nil, fmt.Errorf("unable to parse Chain Certificate: %w", err)
+	for _, ext := range outputFormatExts {
+		if name == baseFilename+ext {
+			return true
+		}
+	}
+
+	for _, exts := range keyStoreExts {
+		if name == baseFilename+exts[0] || name == baseFilename+exts[1] {
+			return true
 		}
+	}
+
+	return false
+}
 
-		certChain = append(certChain, chainCert)
-		chainCertPemBlock, rest = pem.Decode(rest) // Try decoding the next pem block
+// getBaseFileName returns the base name of the files of the domain (the deprecated "filename" option overrides it).
+func (s *CertificatesStorage) getBaseFileName(domain string) string {
+	if s.filename != "" {
+		return s.filename
 	}
 
-	return certChain, nil
+	return sanitizedDomain(domain)
 }
 
-func getPFXEncoder(pfxFormat string) (*pkcs12.Encoder, error) {
-	var encoder *pkcs12.Encoder
-	switch pfxFormat {
-	case "SHA256":
-		encoder = pkcs12.Modern2023
-	case "DES":
-		encoder = pkcs12.LegacyDES
-	case "RC2":
-		encoder = pkcs12.LegacyRC2
-	default:
-		return nil, fmt.Errorf("invalid PFX format: %s", pfxFormat)
+// getKey returns the key of a certificate file in the storage.
+func (s *CertificatesStorage) getKey(baseFileName, extension string) string {
+	return path.Join(s.rootPath, baseFileName+extension)
+}
+
+// getPassphrase gets a passphrase from the flags (the value or the file).
+func getPassphrase(ctx *cli.Context, passFlag, fileFlag string) []byte {
+	if ctx.IsSet(passFlag) && ctx.IsSet(fileFlag) {
+		log.Fatalf("--%s and --%s are mutually exclusive", passFlag, fileFlag)
+	}
+
+	filename := ctx.String(fileFlag)
+	if filename == "" {
+		return []byte(ctx.String(passFlag))
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		log.Fatalf("Could not read the passphrase file: %v", err)
+	}
+
+	passphrase := bytes.TrimRight(content, "\r\n")
+	if len(passphrase) == 0 {
+		log.Fatalf("The passphrase file %s is empty", filename)
 	}
 
-	return encoder, nil
+	return passphrase
 }
 
 // sanitizedDomain Make sure no funny chars are in the cert names (like wildcards ;)).
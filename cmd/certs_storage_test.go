@@ -1,37 +1,53 @@
 package cmd
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 func TestCertificatesStorage_MoveToArchive(t *testing.T) {
 	domain := "example.com"
 
-	storage := CertificatesStorage{
-		rootPath:    t.TempDir(),
-		archivePath: t.TempDir(),
-	}
+	certsStorage, rootPath, archivePath := newTestCertificatesStorage(t)
 
-	domainFiles := generateTestFiles(t, storage.rootPath, domain)
+	domainFiles := generateTestFiles(t, rootPath, domain)
 
-	err := storage.MoveToArchive(domain)
+	err := certsStorage.MoveToArchive(domain)
 	require.NoError(t, err)
 
 	for _, file := range domainFiles {
 		assert.NoFileExists(t, file)
 	}
 
-	root, err := os.ReadDir(storage.rootPath)
+	root, err := os.ReadDir(rootPath)
 	require.NoError(t, err)
 	require.Empty(t, root)
 
-	archive, err := os.ReadDir(storage.archivePath)
+	archive, err := os.ReadDir(archivePath)
 	require.NoError(t, err)
 
 	require.Len(t, archive, len(domainFiles))
@@ -41,25 +57,22 @@ func TestCertificatesStorage_MoveToArchive(t *testing.T) {
 func TestCertificatesStorage_MoveToArchive_noFileRelatedToDomain(t *testing.T) {
 	domain := "example.com"
 
-	storage := CertificatesStorage{
-		rootPath:    t.TempDir(),
-		archivePath: t.TempDir(),
-	}
+	certsStorage, rootPath, archivePath := newTestCertificatesStorage(t)
 
-	domainFiles := generateTestFiles(t, storage.rootPath, "example.org")
+	domainFiles := generateTestFiles(t, rootPath, "example.org")
 
-	err := storage.MoveToArchive(domain)
+	err := certsStorage.MoveToArchive(domain)
 	require.NoError(t, err)
 
 	for _, file := range domainFiles {
 		assert.FileExists(t, file)
 	}
 
-	root, err := os.ReadDir(storage.rootPath)
+	root, err := os.ReadDir(rootPath)
 	require.NoError(t, err)
 	assert.Len(t, root, len(domainFiles))
 
-	archive, err := os.ReadDir(storage.archivePath)
+	archive, err := os.ReadDir(archivePath)
 	require.NoError(t, err)
 
 	assert.Empty(t, archive)
@@ -68,15 +81,12 @@ func TestCertificatesStorage_MoveToArchive_noFileRelatedToDomain(t *testing.T) {
 func TestCertificatesStorage_MoveToArchive_ambiguousDomain(t *testing.T) {
 	domain := "example.com"
 
-	storage := CertificatesStorage{
-		rootPath:    t.TempDir(),
-		archivePath: t.TempDir(),
-	}
+	certsStorage, rootPath, archivePath := newTestCertificatesStorage(t)
 
-	domainFiles := generateTestFiles(t, storage.rootPath, domain)
-	otherDomainFiles := generateTestFiles(t, storage.rootPath, domain+".example.org")
+	domainFiles := generateTestFiles(t, rootPath, domain)
+	otherDomainFiles := generateTestFiles(t, rootPath, domain+".example.org")
 
-	err := storage.MoveToArchive(domain)
+	err := certsStorage.MoveToArchive(domain)
 	require.NoError(t, err)
 
 	for _, file := range domainFiles {
@@ -87,17 +97,448 @@ func TestCertificatesStorage_MoveToArchive_ambiguousDomain(t *testing.T) {
 		assert.FileExists(t, file)
 	}
 
-	root, err := os.ReadDir(storage.rootPath)
+	root, err := os.ReadDir(rootPath)
 	require.NoError(t, err)
 	require.Len(t, root, len(otherDomainFiles))
 
-	archive, err := os.ReadDir(storage.archivePath)
+	archive, err := os.ReadDir(archivePath)
 	require.NoError(t, err)
 
 	require.Len(t, archive, len(domainFiles))
 	assert.Regexp(t, `\d+\.`+regexp.QuoteMeta(domain), archive[0].Name())
 }
 
+func TestCertificatesStorage_WriteCertificateFiles_encryptedKey(t *testing.T) {
+	domain := "example.com"
+
+	certsStorage, _, _ := newTestCertificatesStorage(t)
+	certsStorage.pem = true
+	certsStorage.keyPass = []byte("secret")
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.EC256)
+	require.NoError(t, err)
+
+	certRes := &certificate.Resource{
+		Domain:      domain,
+		Certificate: []byte("-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----\n"),
+		PrivateKey:  certcrypto.PEMEncode(privateKey),
+	}
+
+	err = certsStorage.WriteCertificateFiles(domain, certRes)
+	require.NoError(t, err)
+
+	keyPEM, err := certsStorage.ReadFile(domain, keyExt)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(keyPEM), "ENCRYPTED PRIVATE KEY")
+
+	pemFile, err := certsStorage.ReadFile(domain, pemExt)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(pemFile), string(keyPEM))
+
+	key, err := certsStorage.ReadPrivateKey(domain)
+	require.NoError(t, err)
+
+	assert.Equal(t, privateKey, key)
+}
+
+func TestCertificatesStorage_WriteOutputFiles(t *testing.T) {
+	domain := "example.com"
+
+	certsStorage, rootPath, _ := newTestCertificatesStorage(t)
+	certsStorage.outputs = []string{outputLeaf, outputChain, outputRoot, outputDER, outputPKCS7, outputHAProxy}
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := certcrypto.GeneratePemCert(privateKey.(*rsa.PrivateKey), domain, nil)
+	require.NoError(t, err)
+
+	certRes := &certificate.Resource{
+		Domain:      domain,
+		Certificate: certPEM,
+		PrivateKey:  certcrypto.PEMEncode(privateKey),
+	}
+
+	err = certsStorage.WriteOutputFiles(domain, certRes)
+	require.NoError(t, err)
+
+	// no intermediate and no root certificates.
+	assert.NoFileExists(t, filepath.Join(rootPath, domain+chainExt))
+	assert.NoFileExists(t, filepath.Join(rootPath, domain+rootExt))
+
+	leaf, err := certsStorage.ReadFile(domain, leafExt)
+	require.NoError(t, err)
+
+	assert.Equal(t, certPEM, leaf)
+
+	der, err := certsStorage.ReadFile(domain, derExt)
+	require.NoError(t, err)
+
+	cert, err := certcrypto.ParsePEMCertificate(certPEM)
+	require.NoError(t, err)
+
+	assert.Equal(t, cert.Raw, der)
+
+	assert.FileExists(t, filepath.Join(rootPath, domain+p7bExt))
+
+	haproxy, err := certsStorage.ReadFile(domain, haproxyExt)
+	require.NoError(t, err)
+
+	assert.Equal(t, string(certPEM)+string(certRes.PrivateKey), string(haproxy))
+}
+
+func TestCertificatesStorage_SaveResource_manifest(t *testing.T) {
+	domain := "example.com"
+
+	certsStorage, _, _ := newTestCertificatesStorage(t)
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := certcrypto.GeneratePemCert(privateKey.(*rsa.PrivateKey), domain, nil)
+	require.NoError(t, err)
+
+	certRes := &certificate.Resource{
+		Domain:      domain,
+		CertURL:     "https://example.com/cert/1",
+		OrderURL:    "https://example.com/order/1",
+		Certificate: certPEM,
+		PrivateKey:  certcrypto.PEMEncode(privateKey),
+	}
+
+	certsStorage.SaveResource(certRes)
+
+	raw, err := certsStorage.ReadFile(domain, resourceExt)
+	require.NoError(t, err)
+
+	var metadata resourceMetadata
+	require.NoError(t, json.Unmarshal(raw, &metadata))
+
+	require.NotNil(t, metadata.Manifest)
+	assert.Equal(t, "https://example.com/order/1", metadata.Manifest.OrderURL)
+	assert.Contains(t, metadata.Manifest.SANs, domain)
+	assert.Len(t, metadata.Manifest.Chain, 1)
+
+	resource := certsStorage.ReadResource(domain)
+	assert.Equal(t, "https://example.com/order/1", resource.OrderURL)
+}
+
+func TestCertificatesStorage_SaveResource_keyRenewals(t *testing.T) {
+	domain := "example.com"
+
+	certsStorage, _, _ := newTestCertificatesStorage(t)
+
+	createResource := func(privateKey *rsa.PrivateKey) *certificate.Resource {
+		certPEM, err := certcrypto.GeneratePemCert(privateKey, domain, nil)
+		require.NoError(t, err)
+
+		return &certificate.Resource{Domain: domain, Certificate: certPEM, PrivateKey: certcrypto.PEMEncode(privateKey)}
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, certsStorage.ReadKeyRenewals(domain))
+
+	certsStorage.SaveResource(createResource(privateKey))
+	assert.Equal(t, 0, certsStorage.ReadKeyRenewals(domain))
+
+	certsStorage.SaveResource(createResource(privateKey))
+	assert.Equal(t, 1, certsStorage.ReadKeyRenewals(domain))
+
+	certsStorage.SaveResource(createResource(privateKey))
+	assert.Equal(t, 2, certsStorage.ReadKeyRenewals(domain))
+
+	newPrivateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	certsStorage.SaveResource(createResource(newPrivateKey))
+	assert.Equal(t, 0, certsStorage.ReadKeyRenewals(domain))
+}
+
+func TestCertificatesStorage_WriteFiles(t *testing.T) {
+	domain := "example.com"
+
+	certsStorage, rootPath, _ := newTestCertificatesStorage(t)
+
+	err := certsStorage.WriteFiles(domain, []certificateFile{{ext: certExt, data: []byte("cert1")}, {ext: keyExt, data: []byte("key1")}})
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, filepath.Join(rootPath, domain+certExt+bakExt))
+
+	err = certsStorage.WriteFiles(domain, []certificateFile{{ext: certExt, data: []byte("cert2")}, {ext: keyExt, data: []byte("key2")}})
+	require.NoError(t, err)
+
+	assertFileContent(t, filepath.Join(rootPath, domain+certExt), "cert2")
+	assertFileContent(t, filepath.Join(rootPath, domain+keyExt), "key2")
+	assertFileContent(t, filepath.Join(rootPath, domain+certExt+bakExt), "cert1")
+	assertFileContent(t, filepath.Join(rootPath, domain+keyExt+bakExt), "key1")
+}
+
+// recordingStorage records the keys of the written files.
+type recordingStorage struct {
+	storage.CertificatesStorage
+
+	keys []string
+}
+
+func (s *recordingStorage) WriteFile(key string, data []byte) error {
+	s.keys = append(s.keys, key)
+
+	return s.CertificatesStorage.WriteFile(key, data)
+}
+
+func TestCertificatesStorage_WriteFiles_keyFirst(t *testing.T) {
+	domain := "example.com"
+
+	certsStorage, _, _ := newTestCertificatesStorage(t)
+
+	backend := &recordingStorage{CertificatesStorage: certsStorage.backend}
+	certsStorage.backend = backend
+
+	err := certsStorage.WriteFiles(domain, []certificateFile{
+		{ext: certExt, data: []byte("cert1")},
+		{ext: issuerExt, data: []byte("issuer1")},
+		{ext: keyExt, data: []byte("key1")},
+	})
+	require.NoError(t, err)
+
+	expected := []string{
+		certsStorage.getKey(domain, keyExt),
+		certsStorage.getKey(domain, certExt),
+		certsStorage.getKey(domain, issuerExt),
+	}
+	assert.Equal(t, expected, backend.keys)
+}
+
+func TestCertificatesStorage_WriteFiles_rollback(t *testing.T) {
+	domain := "example.com"
+
+	certsStorage, rootPath, _ := newTestCertificatesStorage(t)
+
+	err := certsStorage.WriteFiles(domain, []certificateFile{{ext: certExt, data: []byte("cert1")}, {ext: keyExt, data: []byte("key1")}})
+	require.NoError(t, err)
+
+	certsStorage.backend = &failingStorage{
+		CertificatesStorage: certsStorage.backend,
+		key:                 certsStorage.getKey(domain, resourceExt),
+	}
+
+	err = certsStorage.WriteFiles(domain, []certificateFile{
+		{ext: certExt, data: []byte("cert2")},
+		{ext: keyExt, data: []byte("key2")},
+		{ext: issuerExt, data: []byte("issuer2")},
+		{ext: resourceExt, data: []byte("json2")},
+	})
+	require.EqualError(t, err, "unable to save .json file: write error")
+
+	assertFileContent(t, filepath.Join(rootPath, domain+certExt), "cert1")
+	assertFileContent(t, filepath.Join(rootPath, domain+keyExt), "key1")
+	assert.NoFileExists(t, filepath.Join(rootPath, domain+issuerExt))
+	assert.NoFileExists(t, filepath.Join(rootPath, domain+resourceExt))
+}
+
+func TestCertificatesStorage_WriteKeyStoreFiles(t *testing.T) {
+	domain := "example.com"
+
+	certsStorage, rootPath, _ := newTestCertificatesStorage(t)
+	certsStorage.keyStore = true
+	certsStorage.keyStoreOpt = certcrypto.KeyStoreOptions{
+		Format:   certcrypto.KeyStoreFormatPKCS12,
+		Password: "changeit",
+		Alias:    "lego",
+	}
+	certsStorage.trustAlias = "ca"
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := certcrypto.GeneratePemCert(privateKey.(*rsa.PrivateKey), domain, nil)
+	require.NoError(t, err)
+
+	issuerKey, err := certcrypto.GeneratePrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+
+	issuerPEM, err := certcrypto.GeneratePemCert(issuerKey.(*rsa.PrivateKey), "ca.example.com", nil)
+	require.NoError(t, err)
+
+	certRes := &certificate.Resource{
+		Domain:            domain,
+		Certificate:       certPEM,
+		IssuerCertificate: issuerPEM,
+		PrivateKey:        certcrypto.PEMEncode(privateKey),
+	}
+
+	err = certsStorage.WriteKeyStoreFiles(domain, certRes)
+	require.NoError(t, err)
+
+	keyStore, err := certsStorage.ReadFile(domain, ".keystore.p12")
+	require.NoError(t, err)
+
+	key, cert, _, err := pkcs12.DecodeChain(keyStore, "changeit")
+	require.NoError(t, err)
+
+	assert.Equal(t, privateKey, key)
+	assert.Equal(t, []string{domain}, cert.DNSNames)
+
+	trustStore, err := certsStorage.ReadFile(domain, ".truststore.p12")
+	require.NoError(t, err)
+
+	certs, err := pkcs12.DecodeTrustStore(trustStore, "changeit")
+	require.NoError(t, err)
+
+	require.Len(t, certs, 1)
+	assert.Equal(t, []string{"ca.example.com"}, certs[0].DNSNames)
+
+	assert.NoFileExists(t, filepath.Join(rootPath, domain+".keystore.jks"))
+}
+
+func TestCertificatesStorage_UpdateOCSPStaple(t *testing.T) {
+	domain := "example.com"
+
+	issuerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	issuerTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	issuerDER, err := x509.CreateCertificate(rand.Reader, issuerTemplate, issuerTemplate, issuerKey.Public(), issuerKey)
+	require.NoError(t, err)
+
+	issuer, err := x509.ParseCertificate(issuerDER)
+	require.NoError(t, err)
+
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+
+		raw, errR := ocsp.CreateResponse(issuer, issuer, ocsp.Response{
+			Status:       ocsp.Good,
+			SerialNumber: big.NewInt(42),
+			ThisUpdate:   time.Now().Add(-time.Minute),
+			NextUpdate:   time.Now().Add(time.Hour),
+		}, issuerKey)
+		if errR != nil {
+			http.Error(w, errR.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		_, _ = w.Write(raw)
+	}))
+	t.Cleanup(server.Close)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		OCSPServer:   []string{server.URL},
+	}
+
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, issuer, leafKey.Public(), issuerKey)
+	require.NoError(t, err)
+
+	certsStorage, _, _ := newTestCertificatesStorage(t)
+
+	err = certsStorage.WriteFile(domain, certExt, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER}))
+	require.NoError(t, err)
+
+	err = certsStorage.WriteFile(domain, issuerExt, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: issuerDER}))
+	require.NoError(t, err)
+
+	checker := certificate.NewOCSPChecker(server.Client())
+
+	updated, err := certsStorage.UpdateOCSPStaple(domain, checker, false)
+	require.NoError(t, err)
+
+	assert.True(t, updated)
+	assert.EqualValues(t, 1, calls.Load())
+
+	raw, err := certsStorage.ReadFile(domain, ocspExt)
+	require.NoError(t, err)
+
+	response, err := ocsp.ParseResponse(raw, issuer)
+	require.NoError(t, err)
+
+	assert.Equal(t, ocsp.Good, response.Status)
+
+	// the response is still fresh.
+	updated, err = certsStorage.UpdateOCSPStaple(domain, checker, false)
+	require.NoError(t, err)
+
+	assert.False(t, updated)
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestCertificatesStorage_ListCertificates(t *testing.T) {
+	certsStorage, rootPath, _ := newTestCertificatesStorage(t)
+
+	generateTestFiles(t, rootPath, "example.com")
+	generateTestFiles(t, rootPath, "_.example.org")
+
+	certificates, err := certsStorage.ListCertificates()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"certificates/_.example.org.crt", "certificates/example.com.crt"}, certificates)
+}
+
+// failingStorage a storage backend failing to write a specific file.
+type failingStorage struct {
+	storage.CertificatesStorage
+
+	key string
+}
+
+func (s *failingStorage) WriteFile(key string, data []byte) error {
+	if key == s.key {
+		return errors.New("write error")
+	}
+
+	return s.CertificatesStorage.WriteFile(key, data)
+}
+
+func assertFileContent(t *testing.T, filename, expected string) {
+	t.Helper()
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, string(data))
+}
+
+func newTestCertificatesStorage(t *testing.T) (*CertificatesStorage, string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	certsStorage := &CertificatesStorage{
+		backend:     storage.NewFileStorage(dir),
+		rootPath:    baseCertificatesFolderName,
+		archivePath: baseArchivesFolderName,
+	}
+
+	rootPath := filepath.Join(dir, baseCertificatesFolderName)
+	archivePath := filepath.Join(dir, baseArchivesFolderName)
+
+	for _, p := range []string{rootPath, archivePath} {
+		require.NoError(t, os.MkdirAll(p, 0o700))
+	}
+
+	return certsStorage, rootPath, archivePath
+}
+
 func generateTestFiles(t *testing.T, dir, domain string) []string {
 	t.Helper()
 
@@ -113,3 +554,24 @@ func generateTestFiles(t *testing.T, dir, domain string) []string {
 
 	return filenames
 }
+
+func TestCertificatesStorage_CopyToArchive(t *testing.T) {
+	domain := "example.com"
+
+	certsStorage, rootPath, archivePath := newTestCertificatesStorage(t)
+
+	domainFiles := generateTestFiles(t, rootPath, domain)
+
+	err := certsStorage.CopyToArchive(domain)
+	require.NoError(t, err)
+
+	for _, file := range domainFiles {
+		assert.FileExists(t, file)
+	}
+
+	archive, err := os.ReadDir(archivePath)
+	require.NoError(t, err)
+
+	require.Len(t, archive, len(domainFiles))
+	assert.Regexp(t, `\d+\.`+regexp.QuoteMeta(domain), archive[0].Name())
+}
@@ -8,7 +8,15 @@ func CreateCommands() []*cli.Command {
 		createRun(),
 		createRevoke(),
 		createRenew(),
+		createDaemon(),
 		createDNSHelp(),
 		createList(),
+		createCert(),
+		createAccount(),
+		createInit(),
+		createSelftest(),
+		createProviders(),
+		createHistory(),
+		createCompletion(),
 	}
 }
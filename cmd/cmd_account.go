@@ -0,0 +1,421 @@
+package cmd
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/go-acme/lego/v4/registration"
+	"github.com/urfave/cli/v2"
+)
+
+// Flag names.
+const (
+	flgContact               = "contact"
+	flgArchivePassphrase     = "passphrase"
+	flgArchivePassphraseFile = "passphrase-file"
+	flgDeactivatePrevious    = "deactivate-previous"
+)
+
+// Environment variables names.
+const (
+	envArchivePassphrase     = "LEGO_ACCOUNT_ARCHIVE_PASSWORD"
+	envArchivePassphraseFile = "LEGO_ACCOUNT_ARCHIVE_PASSWORD_FILE"
+)
+
+func createAccount() *cli.Command {
+	return &cli.Command{
+		Name:  "account",
+		Usage: "Manage the account.",
+		Subcommands: []*cli.Command{
+			{
+				Name: "update",
+				Usage: "Update the contacts of the account on the CA, without registering again." +
+					" By default, the contact is the email defined by --email.",
+				Action: updateAccount,
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name: flgContact,
+						Usage: "Contact of the account (ex: an email address, or a mailto: URL)." +
+							" Can be specified multiple times.",
+					},
+				},
+			},
+			{
+				Name: "keychange",
+				Usage: "Replace the key of the account by a new key (account key rollover, RFC 8555 section 7.3.5)." +
+					" The account and the certificates are kept, the previous key is kept as '<email>.key.old'.",
+				Action: keyChangeAccount,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name: flgKeyType,
+						Usage: "The type of the new key. Supported: rsa2048, rsa3072, rsa4096, rsa8192, ec256, ec384." +
+							" (default: the global --" + flgKeyType + " option)",
+					},
+				},
+			},
+			{
+				Name: "rebind",
+				Usage: "Replace the account by a new account bound to the External Account Binding defined by --kid and --hmac" +
+					" (ex: after a rotation of the HMAC key by the CA). The new account has a new key, the previous key is kept as '<email>.key.old'.",
+				Action: rebindAccount,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  flgDeactivatePrevious,
+						Usage: "Deactivate the previous account on the CA, once the new account is saved.",
+					},
+				},
+			},
+			{
+				Name: "export",
+				Usage: "Export the account (private key and registration) defined by --email and --server" +
+					" to an archive encrypted with a passphrase.",
+				ArgsUsage: "<file>",
+				Action:    exportAccount,
+				Flags:     createArchivePassphraseFlags(),
+			},
+			{
+				Name: "import",
+				Usage: "Import an account from an archive created by the export command." +
+					" The email and the server of the account are read from the archive.",
+				ArgsUsage: "<file>",
+				Action:    importAccount,
+				Flags:     createArchivePassphraseFlags(),
+			},
+		},
+	}
+}
+
+func createArchivePassphraseFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    flgArchivePassphrase,
+			Usage:   "The passphrase used to encrypt the account archive (AES-256-GCM).",
+			EnvVars: []string{envArchivePassphrase},
+		},
+		&cli.StringFlag{
+			Name:    flgArchivePassphraseFile,
+			Usage:   "The path to a file containing the passphrase used to encrypt the account archive (see --" + flgArchivePassphrase + ").",
+			EnvVars: []string{envArchivePassphraseFile},
+		},
+	}
+}
+
+func updateAccount(ctx *cli.Context) error {
+	accountsStorage := NewAccountsStorage(ctx)
+
+	account, keyType, err := setupAccount(ctx, accountsStorage)
+	if err != nil {
+		return err
+	}
+
+	if account.Registration == nil {
+		log.Fatalf("Account %s is not registered. Use 'run' to register a new account.\n", account.Email)
+	}
+
+	contacts := ctx.StringSlice(flgContact)
+	if len(contacts) == 0 {
+		contacts = []string{getEmail(ctx)}
+	}
+
+	client, err := createAccountClient(ctx, account, keyType)
+	if err != nil {
+		return err
+	}
+
+	reg, err := client.Registration.UpdateContacts(contacts)
+	if err != nil {
+		log.Fatalf("Could not update the contacts of the account %s: %v", account.Email, err)
+	}
+
+	account.Registration = reg
+
+	err = accountsStorage.Save(account)
+	if err != nil {
+		log.Fatalf("Could not save the account file %s: %v", account.Email, err)
+	}
+
+	log.Printf("The contacts of the account %s have been updated: %s", account.Email, strings.Join(reg.Body.Contact, ", "))
+
+	return nil
+}
+
+func keyChangeAccount(ctx *cli.Context) error {
+	// The flags of the command shadow the global flags: the global options are read from the parent context.
+	return changeAccountKey(ctx.Lineage()[1], getNewAccountKeyType(ctx))
+}
+
+// changeAccountKey replaces the key of the account by a new key of the given type (RFC 8555 key-change).
+// The new key is stored next to the current key, and moves into place only after the key-change succeeded.
+// An interrupted key change is resumed with the same new key.
+func changeAccountKey(ctx *cli.Context, newKeyType certcrypto.KeyType) error {
+	accountsStorage := NewAccountsStorage(ctx)
+
+	account, keyType, err := setupAccount(ctx, accountsStorage)
+	if err != nil {
+		return err
+	}
+
+	if account.Registration == nil {
+		log.Fatalf("Account %s is not registered. Use 'run' to register a new account.\n", account.Email)
+	}
+
+	newKey, resumed, err := accountsStorage.NextPrivateKey(newKeyType)
+	if err != nil {
+		log.Fatalf("Could not generate the new key of the account %s: %v", account.Email, err)
+	}
+
+	if !resumed || !isAccountKey(ctx, account, newKey) {
+		if resumed {
+			log.Printf("Resuming the interrupted key change of the account %s.", account.Email)
+		}
+
+		client, err := createAccountClient(ctx, account, keyType)
+		if err != nil {
+			return err
+		}
+
+		err = client.Registration.ChangeAccountKey(newKey)
+		if err != nil {
+			log.Fatalf("Could not change the key of the account %s: %v", account.Email, err)
+		}
+	}
+
+	err = accountsStorage.CommitNextPrivateKey()
+	if err != nil {
+		fatalStoragef("The key of the account %s has been changed, but the new key could not be moved into place (run the command again): %w", account.Email, err)
+	}
+
+	log.Printf("The key of the account %s has been changed.", account.Email)
+
+	return nil
+}
+
+// rebindAccount replaces the account by a new account bound to the External Account Binding defined by --kid and --hmac.
+// The binding of an existing account cannot be replaced: the server returns the account of a known key
+// without processing the binding (RFC 8555 section 7.3.1), so the new account is registered with a new key.
+// The new key is stored next to the current key until the new account is saved: an interrupted rebinding is resumed with the same new key.
+func rebindAccount(ctx *cli.Context) error {
+	kid := ctx.String(flgKID)
+	hmacEncoded := ctx.String(flgHMAC)
+
+	if kid == "" || hmacEncoded == "" {
+		return fmt.Errorf("requires the options --%s and --%s", flgKID, flgHMAC)
+	}
+
+	accountsStorage := NewAccountsStorage(ctx)
+
+	account, keyType, err := setupAccount(ctx, accountsStorage)
+	if err != nil {
+		return err
+	}
+
+	if account.Registration == nil {
+		return fmt.Errorf("account %s is not registered: use 'run' to register a new account", account.Email)
+	}
+
+	newKey, _, err := accountsStorage.NextPrivateKey(keyType)
+	if err != nil {
+		return fmt.Errorf("could not generate the new key of the account %s: %w", account.Email, err)
+	}
+
+	client, err := createClient(ctx, &Account{Email: account.Email, key: newKey}, keyType, ctx.String(flgServer))
+	if err != nil {
+		return fmt.Errorf("could not create client: %w", err)
+	}
+
+	reg, err := client.Registration.RegisterWithExternalAccountBinding(registration.RegisterEABOptions{
+		TermsOfServiceAgreed: true,
+		Kid:                  kid,
+		HmacEncoded:          hmacEncoded,
+	})
+	if err != nil {
+		return fmt.Errorf("could not register the new account of %s: %w", account.Email, err)
+	}
+
+	// The new account has already been saved by an interrupted rebinding.
+	resumed := reg.URI == account.Registration.URI
+	if resumed {
+		log.Printf("Resuming the interrupted rebinding of the account %s.", account.Email)
+	}
+
+	previous := &Account{Email: account.Email, Registration: account.Registration, key: account.key}
+
+	account.Registration = reg
+
+	err = accountsStorage.Save(account)
+	if err != nil {
+		return &storageError{err: fmt.Errorf("could not save the new account of %s (run the command again): %w", account.Email, err)}
+	}
+
+	err = accountsStorage.CommitNextPrivateKey()
+	if err != nil {
+		return &storageError{err: fmt.Errorf("the new account of %s has been saved, but the new key could not be moved into place (run the command again): %w", account.Email, err)}
+	}
+
+	log.Printf("The account %s has been replaced by a new account bound to the external account %s: %s", account.Email, kid, reg.URI)
+
+	if !ctx.Bool(flgDeactivatePrevious) {
+		return nil
+	}
+
+	if resumed {
+		log.Warnf("The previous account of %s is unknown (interrupted rebinding): it has not been deactivated.", account.Email)
+		return nil
+	}
+
+	previousClient, err := createClient(ctx, previous, keyType, ctx.String(flgServer))
+	if err != nil {
+		return fmt.Errorf("could not create client: %w", err)
+	}
+
+	err = previousClient.Registration.DeleteRegistration()
+	if err != nil {
+		return fmt.Errorf("the new account of %s is used, but the previous account %s could not be deactivated: %w", account.Email, previous.Registration.URI, err)
+	}
+
+	log.Printf("The previous account %s has been deactivated.", previous.Registration.URI)
+
+	return nil
+}
+
+// isAccountKey checks if the server already knows the account by the key
+// (the key change of an interrupted rollover has succeeded).
+func isAccountKey(ctx *cli.Context, account *Account, privateKey crypto.PrivateKey) bool {
+	reg, err := tryRecoverRegistration(ctx, ctx.String(flgServer), privateKey)
+
+	return err == nil && reg.URI == account.Registration.URI
+}
+
+// getNewAccountKeyType returns the key type defined by the keychange command, or by the global option.
+func getNewAccountKeyType(ctx *cli.Context) certcrypto.KeyType {
+	if slices.Contains(ctx.LocalFlagNames(), flgKeyType) {
+		return getKeyType(ctx)
+	}
+
+	return getKeyType(ctx.Lineage()[1])
+}
+
+func exportAccount(ctx *cli.Context) error {
+	filename, passphrase, err := getArchiveArguments(ctx)
+	if err != nil {
+		return err
+	}
+
+	accountsStorage := NewAccountsStorage(ctx)
+
+	exists, err := accountsStorage.ExistsAccountFilePath()
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf("account %s not found in %s", accountsStorage.GetUserID(), accountsStorage.GetRootUserPath())
+	}
+
+	privateKey, err := accountsStorage.loadPrivateKey(accountsStorage.accountKeyPath())
+	if err != nil {
+		return fmt.Errorf("could not load the private key of the account %s: %w", accountsStorage.GetUserID(), err)
+	}
+
+	account, err := accountsStorage.LoadAccount(privateKey)
+	if err != nil {
+		return err
+	}
+
+	archive, err := encodeAccountArchive(&accountArchive{
+		Server:  ctx.String(flgServer),
+		Account: account,
+		Key:     string(certcrypto.PEMEncode(privateKey)),
+	}, passphrase)
+	if err != nil {
+		return fmt.Errorf("could not create the account archive: %w", err)
+	}
+
+	err = os.WriteFile(filename, archive, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not write the account archive: %w", err)
+	}
+
+	log.Printf("The account %s has been exported to %s", account.Email, filename)
+
+	return nil
+}
+
+func importAccount(ctx *cli.Context) error {
+	filename, passphrase, err := getArchiveArguments(ctx)
+	if err != nil {
+		return err
+	}
+
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("could not read the account archive: %w", err)
+	}
+
+	archive, err := decodeAccountArchive(raw, passphrase)
+	if err != nil {
+		return err
+	}
+
+	privateKey, err := certcrypto.ParsePEMPrivateKey([]byte(archive.Key))
+	if err != nil {
+		return fmt.Errorf("invalid account archive: %w", err)
+	}
+
+	// The email and the server of the archive are used, unless they are explicitly defined with different values.
+	for name, value := range map[string]string{flgEmail: archive.Account.Email, flgServer: archive.Server} {
+		if ctx.IsSet(name) && ctx.String(name) != value {
+			return fmt.Errorf("the archive contains the account %s on %s: it does not match --%s %s",
+				archive.Account.Email, archive.Server, name, ctx.String(name))
+		}
+
+		if err = ctx.Set(name, value); err != nil {
+			return err
+		}
+	}
+
+	accountsStorage := NewAccountsStorage(ctx)
+
+	exists, err := accountsStorage.ExistsAccountFilePath()
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return fmt.Errorf("the account %s already exists in %s", archive.Account.Email, accountsStorage.GetRootUserPath())
+	}
+
+	err = accountsStorage.writePrivateKey(accountsStorage.accountKeyPath(), privateKey)
+	if err != nil {
+		return fmt.Errorf("could not save the private key of the account %s: %w", archive.Account.Email, err)
+	}
+
+	err = accountsStorage.Save(archive.Account)
+	if err != nil {
+		return fmt.Errorf("could not save the account %s: %w", archive.Account.Email, err)
+	}
+
+	log.Printf("The account %s has been imported to %s", archive.Account.Email, accountsStorage.GetRootUserPath())
+
+	return nil
+}
+
+// getArchiveArguments returns the path of the archive (argument), and the passphrase of the archive.
+func getArchiveArguments(ctx *cli.Context) (string, []byte, error) {
+	if ctx.NArg() != 1 {
+		return "", nil, errors.New("the path of the account archive is required")
+	}
+
+	passphrase := getPassphrase(ctx, flgArchivePassphrase, flgArchivePassphraseFile)
+	if len(passphrase) == 0 {
+		return "", nil, fmt.Errorf("a passphrase is required to encrypt the account archive: use --%s or --%s", flgArchivePassphrase, flgArchivePassphraseFile)
+	}
+
+	return ctx.Args().First(), passphrase, nil
+}
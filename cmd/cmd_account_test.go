@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/go-acme/lego/v4/registration"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func newAccountTestContext(t *testing.T, name string, globalArgs []string, args ...string) *cli.Context {
+	t.Helper()
+
+	parent := newTestContext(t, globalArgs...)
+
+	var command *cli.Command
+
+	for _, c := range createAccount().Subcommands {
+		if c.Name == name {
+			command = c
+		}
+	}
+
+	require.NotNil(t, command)
+
+	set := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+
+	for _, f := range command.Flags {
+		require.NoError(t, f.Apply(set))
+	}
+
+	require.NoError(t, set.Parse(args))
+
+	return cli.NewContext(parent.App, set, parent)
+}
+
+func Test_getNewAccountKeyType(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		globalArgs []string
+		args       []string
+		expected   certcrypto.KeyType
+	}{
+		{
+			desc:     "default",
+			expected: certcrypto.EC256,
+		},
+		{
+			desc:       "global option",
+			globalArgs: []string{"--key-type", "rsa4096"},
+			expected:   certcrypto.RSA4096,
+		},
+		{
+			desc:     "command option",
+			args:     []string{"--key-type", "ec384"},
+			expected: certcrypto.EC384,
+		},
+		{
+			desc:       "command option and global option",
+			globalArgs: []string{"--key-type", "rsa4096"},
+			args:       []string{"--key-type", "rsa2048"},
+			expected:   certcrypto.RSA2048,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := newAccountTestContext(t, "keychange", test.globalArgs, test.args...)
+
+			assert.Equal(t, test.expected, getNewAccountKeyType(ctx))
+		})
+	}
+}
+
+func Test_rebindAccount(t *testing.T) {
+	mux, apiURL := tester.SetupFakeAPI(t)
+
+	var eab, deactivated bool
+
+	mux.HandleFunc("/account", func(w http.ResponseWriter, r *http.Request) {
+		var account acme.Account
+		readJWSPayload(t, r, &account)
+
+		eab = account.ExternalAccountBinding != nil
+
+		w.Header().Set("Location", apiURL+"/account/2")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(acme.Account{Status: acme.StatusValid})
+	})
+
+	mux.HandleFunc("/account/1", func(w http.ResponseWriter, r *http.Request) {
+		var account acme.Account
+		readJWSPayload(t, r, &account)
+
+		deactivated = account.Status == acme.StatusDeactivated
+
+		_ = json.NewEncoder(w).Encode(account)
+	})
+
+	ctx := newAccountTestContext(t, "rebind",
+		[]string{"--server", apiURL + "/dir", "--email", "test@example.com", "--kid", "kid", "--hmac", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY"},
+		"--deactivate-previous")
+
+	accountsStorage := NewAccountsStorage(ctx)
+
+	account, _, err := setupAccount(ctx, accountsStorage)
+	require.NoError(t, err)
+
+	account.Registration = &registration.Resource{URI: apiURL + "/account/1", Body: acme.Account{Status: acme.StatusValid}}
+	require.NoError(t, accountsStorage.Save(account))
+
+	previousKey, err := accountsStorage.backend.ReadFile(accountsStorage.accountKeyPath())
+	require.NoError(t, err)
+
+	err = rebindAccount(ctx)
+	require.NoError(t, err)
+
+	assert.True(t, eab, "the new account must be bound to the external account")
+	assert.True(t, deactivated, "the previous account must be deactivated")
+
+	newKey, err := accountsStorage.backend.ReadFile(accountsStorage.accountKeyPath())
+	require.NoError(t, err)
+
+	oldKey, err := accountsStorage.backend.ReadFile(accountsStorage.accountKeyPath() + ".old")
+	require.NoError(t, err)
+
+	assert.Equal(t, previousKey, oldKey)
+	assert.NotEqual(t, previousKey, newKey)
+
+	account, _, err = setupAccount(ctx, accountsStorage)
+	require.NoError(t, err)
+
+	assert.Equal(t, apiURL+"/account/2", account.Registration.URI)
+}
+
+func Test_rebindAccount_missingEAB(t *testing.T) {
+	ctx := newAccountTestContext(t, "rebind", []string{"--email", "test@example.com", "--kid", "kid"})
+
+	err := rebindAccount(ctx)
+	require.EqualError(t, err, "requires the options --kid and --hmac")
+}
+
+func readJWSPayload(t *testing.T, r *http.Request, payload any) {
+	t.Helper()
+
+	body, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+
+	jws, err := jose.ParseSigned(string(body), []jose.SignatureAlgorithm{jose.RS256, jose.ES256, jose.ES384})
+	require.NoError(t, err)
+
+	require.NoError(t, json.Unmarshal(jws.UnsafePayloadWithoutVerification(), payload))
+}
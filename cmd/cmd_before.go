@@ -6,13 +6,46 @@ import (
 )
 
 func Before(ctx *cli.Context) error {
+	if err := setupLogger(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := setupOutput(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	config, err := readConfig(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if config != nil {
+		if err = applyConfigOptions(ctx, config); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err = applyDomainsFile(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err = parseDeployers(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	if err = checkNetworkOptions(ctx); err != nil {
+		log.Fatal(err)
+	}
+
 	if ctx.String(flgPath) == "" {
 		log.Fatalf("Could not determine current working directory. Please pass --%s.", flgPath)
 	}
 
-	err := createNonExistingFolder(ctx.String(flgPath))
-	if err != nil {
-		log.Fatalf("Could not check/create path: %v", err)
+	if ctx.String(flgStorage) == storageFile {
+		err := createNonExistingFolder(ctx.String(flgPath))
+		if err != nil {
+			log.Fatalf("Could not check/create path: %v", err)
+		}
 	}
 
 	if ctx.String(flgServer) == "" {
@@ -0,0 +1,372 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/lego"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ocsp"
+)
+
+// Flag names.
+const (
+	flgInspectOffline = "offline"
+	flgInspectRoots   = "roots"
+)
+
+// oidSCTList the OID of the extension containing the signed certificate timestamps (RFC 6962).
+var oidSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+func createCert() *cli.Command {
+	return &cli.Command{
+		Name:  "cert",
+		Usage: "Manage the certificates.",
+		Subcommands: []*cli.Command{
+			{
+				Name: "inspect",
+				Usage: "Display the details of a certificate: chain validation, OCSP status, SCTs, renewal information (ARI)," +
+					" days remaining, and the challenges used to obtain it.",
+				ArgsUsage:    "<domain|path>",
+				Action:       inspectCertificate,
+				BashComplete: completeCommandStoredDomains,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  flgInspectOffline,
+						Usage: "Do not query the OCSP responder and the ACME server (OCSP status and renewal information).",
+					},
+					&cli.StringFlag{
+						Name:  flgInspectRoots,
+						Usage: "Path to the PEM root certificates used to validate the chain. By default, the system roots are used.",
+					},
+				},
+			},
+		},
+	}
+}
+
+func inspectCertificate(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("a certificate is required: the name of a stored certificate (domain), or the path to a PEM file")
+	}
+
+	certs, manifest, location, err := readInspectedCertificate(ctx, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	result, err := newInspectResult(certs, manifest, location, time.Now())
+	if err != nil {
+		return err
+	}
+
+	roots, err := inspectRoots(ctx)
+	if err != nil {
+		return err
+	}
+
+	result.Chain = verifyChain(certs, roots, time.Now())
+
+	if !ctx.Bool(flgInspectOffline) {
+		result.OCSP = checkOCSPStatus(certs)
+		result.ARI = fetchRenewalInfo(ctx, certs[0])
+	}
+
+	if isJSONOutput(ctx) {
+		return printJSON(result)
+	}
+
+	printInspectResult(result)
+
+	return nil
+}
+
+// readInspectedCertificate reads a certificate from a PEM file, or from the storage (with its manifest).
+func readInspectedCertificate(ctx *cli.Context, source string) ([]*x509.Certificate, *certificate.Manifest, string, error) {
+	if fi, err := os.Stat(source); err == nil && !fi.IsDir() {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, nil, "", err
+		}
+
+		certs, err := certcrypto.ParsePEMBundle(data)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("%s: %w", source, err)
+		}
+
+		return certs, nil, source, nil
+	}
+
+	certsStorage := NewCertificatesStorage(ctx)
+
+	if !certsStorage.ExistsFile(source, certExt) {
+		return nil, nil, "", fmt.Errorf("no certificate found for %s", source)
+	}
+
+	certs, err := certsStorage.ReadCertificate(source, certExt)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("%s: %w", source, err)
+	}
+
+	// The certificate is not stored as a bundle (--no-bundle).
+	if len(certs) == 1 && certsStorage.ExistsFile(source, issuerExt) {
+		issuers, err := certsStorage.ReadCertificate(source, issuerExt)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("%s: %w", source, err)
+		}
+
+		certs = append(certs, issuers...)
+	}
+
+	var manifest *certificate.Manifest
+
+	if raw, err := certsStorage.ReadFile(source, resourceExt); err == nil {
+		var metadata resourceMetadata
+		if err = json.Unmarshal(raw, &metadata); err == nil {
+			manifest = metadata.Manifest
+		}
+	}
+
+	return certs, manifest, certsStorage.GetFileName(source, certExt), nil
+}
+
+// newInspectResult creates the result of the cert inspect command from the certificate and its manifest (optional).
+func newInspectResult(certs []*x509.Certificate, manifest *certificate.Manifest, location string, now time.Time) (*inspectResult, error) {
+	leaf := certs[0]
+
+	scts, err := parseSCTs(leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &inspectResult{
+		Path:          location,
+		Subject:       leaf.Subject.String(),
+		Issuer:        issuerName(leaf),
+		Serial:        hex.EncodeToString(leaf.SerialNumber.Bytes()),
+		KeyType:       keyTypeName(leaf.PublicKey),
+		SANs:          subjectAltNames(leaf),
+		NotBefore:     leaf.NotBefore,
+		NotAfter:      leaf.NotAfter,
+		DaysRemaining: int(leaf.NotAfter.Sub(now).Hours() / 24),
+		SCTs:          scts,
+	}
+
+	if manifest != nil {
+		result.Profile = manifest.Profile
+		result.Challenges = manifest.Challenges
+	}
+
+	return result, nil
+}
+
+// inspectRoots returns the root certificates defined by the roots option, nil for the system roots.
+func inspectRoots(ctx *cli.Context) (*x509.CertPool, error) {
+	if !ctx.IsSet(flgInspectRoots) {
+		return nil, nil
+	}
+
+	return lego.CreateCertPool([]string{ctx.String(flgInspectRoots)}, false)
+}
+
+// verifyChain validates the chain of the leaf certificate, the other certificates of the bundle are used as intermediates.
+func verifyChain(certs []*x509.Certificate, roots *x509.CertPool, now time.Time) inspectChainResult {
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	chains, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return inspectChainResult{Error: err.Error()}
+	}
+
+	chain := chains[0]
+
+	return inspectChainResult{Valid: true, Root: chain[len(chain)-1].Subject.String()}
+}
+
+// checkOCSPStatus requests the OCSP status of the certificate (the errors are part of the result).
+func checkOCSPStatus(certs []*x509.Certificate) *inspectOCSPResult {
+	if len(certs[0].OCSPServer) == 0 {
+		return &inspectOCSPResult{Status: "unavailable", Error: "no OCSP server in the certificate"}
+	}
+
+	bundle := &bytes.Buffer{}
+	for _, cert := range certs {
+		bundle.Write(certcrypto.PEMEncode(certcrypto.DERCertificateBytes(cert.Raw)))
+	}
+
+	_, resp, err := certificate.NewOCSPChecker(&http.Client{Timeout: 30 * time.Second}).Check(bundle.Bytes())
+	if err != nil {
+		return &inspectOCSPResult{Status: "unavailable", Error: err.Error()}
+	}
+
+	result := &inspectOCSPResult{
+		ThisUpdate: resp.ThisUpdate,
+		NextUpdate: resp.NextUpdate,
+	}
+
+	switch resp.Status {
+	case ocsp.Good:
+		result.Status = "good"
+	case ocsp.Revoked:
+		result.Status = "revoked"
+		result.RevokedAt = &resp.RevokedAt
+	default:
+		result.Status = "unknown"
+	}
+
+	return result
+}
+
+// fetchRenewalInfo requests the renewal information (ARI) of the certificate to the ACME server (the errors are part of the result).
+// The request is not authenticated: a temporary key is used, the account is not needed.
+func fetchRenewalInfo(ctx *cli.Context, cert *x509.Certificate) *inspectARIResult {
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.EC256)
+	if err != nil {
+		return &inspectARIResult{Error: err.Error()}
+	}
+
+	client, err := createClient(ctx, &Account{key: privateKey}, certcrypto.EC256, ctx.String(flgServer))
+	if err != nil {
+		return &inspectARIResult{Error: err.Error()}
+	}
+
+	info, err := client.Certificate.GetRenewalInfo(certificate.RenewalInfoRequest{Cert: cert})
+	if err != nil {
+		return &inspectARIResult{Error: err.Error()}
+	}
+
+	return &inspectARIResult{
+		Start:          info.SuggestedWindow.Start.UTC(),
+		End:            info.SuggestedWindow.End.UTC(),
+		ExplanationURL: info.ExplanationURL,
+	}
+}
+
+// parseSCTs extracts the signed certificate timestamps embedded in a certificate (RFC 6962, section 3.3).
+func parseSCTs(cert *x509.Certificate) ([]inspectSCTResult, error) {
+	var raw []byte
+
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidSCTList) {
+			continue
+		}
+
+		if _, err := asn1.Unmarshal(ext.Value, &raw); err != nil {
+			return nil, fmt.Errorf("SCT list: %w", err)
+		}
+	}
+
+	if raw == nil {
+		return nil, nil
+	}
+
+	if len(raw) < 2 || int(binary.BigEndian.Uint16(raw)) != len(raw)-2 {
+		return nil, errors.New("SCT list: invalid length")
+	}
+
+	data := raw[2:]
+
+	var scts []inspectSCTResult
+
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, errors.New("SCT list: invalid SCT length")
+		}
+
+		size := int(binary.BigEndian.Uint16(data))
+		data = data[2:]
+
+		// version (1 byte), log ID (32 bytes), timestamp (8 bytes).
+		if size < 41 || len(data) < size {
+			return nil, errors.New("SCT list: invalid SCT")
+		}
+
+		sct := data[:size]
+		data = data[size:]
+
+		scts = append(scts, inspectSCTResult{
+			Version:   int(sct[0]) + 1,
+			LogID:     base64.StdEncoding.EncodeToString(sct[1:33]),
+			Timestamp: time.UnixMilli(int64(binary.BigEndian.Uint64(sct[33:41]))).UTC(),
+		})
+	}
+
+	return scts, nil
+}
+
+func printInspectResult(result *inspectResult) {
+	fmt.Println("Certificate:", result.Path)
+	fmt.Println("  Subject:", result.Subject)
+	fmt.Println("  Issuer:", result.Issuer)
+	fmt.Println("  Serial:", result.Serial)
+	fmt.Println("  Key Type:", result.KeyType)
+	fmt.Println("  Domains:", strings.Join(result.SANs, ", "))
+	fmt.Println("  Not Before:", result.NotBefore)
+	fmt.Println("  Expiry Date:", result.NotAfter, expiryStatus(result.NotAfter, time.Now()))
+
+	if result.Profile != "" {
+		fmt.Println("  Profile:", result.Profile)
+	}
+
+	if result.Chain.Valid {
+		fmt.Println("  Chain: valid, root:", result.Chain.Root)
+	} else {
+		fmt.Println("  Chain: invalid:", result.Chain.Error)
+	}
+
+	if result.OCSP != nil {
+		switch {
+		case result.OCSP.Error != "":
+			fmt.Printf("  OCSP: %s (%s)\n", result.OCSP.Status, result.OCSP.Error)
+		case result.OCSP.RevokedAt != nil:
+			fmt.Printf("  OCSP: %s at %s\n", result.OCSP.Status, result.OCSP.RevokedAt)
+		default:
+			fmt.Printf("  OCSP: %s (next update: %s)\n", result.OCSP.Status, result.OCSP.NextUpdate)
+		}
+	}
+
+	if result.ARI != nil {
+		if result.ARI.Error != "" {
+			fmt.Println("  Renewal Information (ARI): unavailable:", result.ARI.Error)
+		} else {
+			fmt.Printf("  Renewal Information (ARI): %s - %s\n", result.ARI.Start, result.ARI.End)
+		}
+	}
+
+	fmt.Println("  SCTs:", len(result.SCTs))
+	for _, sct := range result.SCTs {
+		fmt.Printf("    - Log ID: %s, Timestamp: %s\n", sct.LogID, sct.Timestamp)
+	}
+
+	if len(result.Challenges) > 0 {
+		fmt.Println("  Challenges:")
+		for _, chlg := range result.Challenges {
+			if chlg.Domain != "" {
+				fmt.Printf("    - %s: %s (%s)\n", chlg.Type, chlg.Provider, chlg.Domain)
+			} else {
+				fmt.Printf("    - %s: %s\n", chlg.Type, chlg.Provider)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseSCTs(t *testing.T) {
+	timestamp := time.Date(2024, time.November, 2, 10, 0, 0, 0, time.UTC)
+
+	sct := make([]byte, 41)
+	sct[0] = 0 // v1
+	copy(sct[1:33], make([]byte, 32))
+	binary.BigEndian.PutUint64(sct[33:41], uint64(timestamp.UnixMilli()))
+	// extensions (empty) and signature are ignored.
+	sct = append(sct, 0, 0)
+
+	list := binary.BigEndian.AppendUint16(nil, uint16(len(sct)))
+	list = append(list, sct...)
+	list = append(binary.BigEndian.AppendUint16(nil, uint16(len(list))), list...)
+
+	value, err := asn1.Marshal(list)
+	require.NoError(t, err)
+
+	cert := &x509.Certificate{
+		Extensions: []pkix.Extension{{Id: oidSCTList, Value: value}},
+	}
+
+	scts, err := parseSCTs(cert)
+	require.NoError(t, err)
+
+	expected := []inspectSCTResult{{
+		Version:   1,
+		LogID:     "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+		Timestamp: timestamp,
+	}}
+
+	assert.Equal(t, expected, scts)
+}
+
+func Test_parseSCTs_none(t *testing.T) {
+	scts, err := parseSCTs(&x509.Certificate{})
+	require.NoError(t, err)
+
+	assert.Empty(t, scts)
+}
+
+func Test_verifyChain(t *testing.T) {
+	now := time.Now()
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	require.NoError(t, err)
+
+	root, err := x509.ParseCertificate(rootDER)
+	require.NoError(t, err)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		DNSNames:     []string{"example.com"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+	}
+
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, root, &leafKey.PublicKey, rootKey)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(leafDER)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	result := verifyChain([]*x509.Certificate{leaf}, roots, now)
+	assert.Equal(t, inspectChainResult{Valid: true, Root: "CN=Test Root"}, result)
+
+	result = verifyChain([]*x509.Certificate{leaf}, roots, now.Add(2*time.Hour))
+	assert.False(t, result.Valid)
+	assert.NotEmpty(t, result.Error)
+
+	result = verifyChain([]*x509.Certificate{leaf}, x509.NewCertPool(), now)
+	assert.False(t, result.Valid)
+	assert.NotEmpty(t, result.Error)
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/urfave/cli/v2"
+)
+
+// Shells supported by the completion command.
+const (
+	shellBash = "bash"
+	shellZsh  = "zsh"
+	shellFish = "fish"
+)
+
+// The completion scripts call the application with the --generate-bash-completion flag (see BashComplete).
+// The name of the application replaces `__PROG__`.
+const bashCompletionScript = `# bash completion for __PROG__
+# Usage: source <(__PROG__ completion bash)
+
+___PROG___bash_autocomplete() {
+  local cur words cword requestComp opts
+  COMPREPLY=()
+  cur="${COMP_WORDS[COMP_CWORD]}"
+  words=("${COMP_WORDS[@]:0:$COMP_CWORD}")
+
+  if [[ "$cur" == "-"* ]]; then
+    requestComp="${words[*]} ${cur} --generate-bash-completion"
+  else
+    requestComp="${words[*]} --generate-bash-completion"
+  fi
+
+  opts=$(eval "${requestComp}" 2>/dev/null)
+  COMPREPLY=($(compgen -W "${opts}" -- "${cur}"))
+
+  return 0
+}
+
+complete -o bashdefault -o default -o nospace -F ___PROG___bash_autocomplete __PROG__
+`
+
+const zshCompletionScript = `#compdef __PROG__
+# zsh completion for __PROG__
+# Usage: source <(__PROG__ completion zsh)
+
+___PROG___zsh_autocomplete() {
+  local -a opts
+  local cur
+  cur=${words[-1]}
+
+  if [[ "$cur" == "-"* ]]; then
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} ${cur} --generate-bash-completion 2>/dev/null)}")
+  else
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} --generate-bash-completion 2>/dev/null)}")
+  fi
+
+  if [[ "${opts[1]}" != "" ]]; then
+    _describe 'values' opts
+  else
+    _files
+  fi
+}
+
+compdef ___PROG___zsh_autocomplete __PROG__
+`
+
+// fishDynamicCompletions the completions of the dynamic values, added to the static completions of the commands and the flags.
+const fishDynamicCompletions = `
+# dynamic values: DNS providers and stored certificates
+complete -c __PROG__ -f -l dns -a '(__PROG__ --dns --generate-bash-completion 2>/dev/null)'
+complete -c __PROG__ -f -l domains -s d -a '(__PROG__ --domains --generate-bash-completion 2>/dev/null)'
+complete -c __PROG__ -n '__fish_seen_subcommand_from inspect' -f -a '(__PROG__ cert inspect --generate-bash-completion 2>/dev/null)'
+`
+
+func createCompletion() *cli.Command {
+	return &cli.Command{
+		Name:      "completion",
+		Usage:     "Generate the shell completion script (bash, zsh, or fish)",
+		ArgsUsage: "<bash|zsh|fish>",
+		Description: "The completion includes the commands, the flags, the DNS providers (--dns), and the domains of the stored certificates (--domains).\n\n" +
+			"   bash: source <(lego completion bash)\n" +
+			"   zsh:  source <(lego completion zsh)\n" +
+			"   fish: lego completion fish | source",
+		Action: completion,
+	}
+}
+
+func completion(ctx *cli.Context) error {
+	script, err := completionScript(ctx.App, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(ctx.App.Writer, script)
+
+	return err
+}
+
+func completionScript(app *cli.App, shell string) (string, error) {
+	var script string
+
+	switch shell {
+	case shellBash:
+		script = bashCompletionScript
+
+	case shellZsh:
+		script = zshCompletionScript
+
+	case shellFish:
+		static, err := app.ToFishCompletion()
+		if err != nil {
+			return "", err
+		}
+
+		script = static + fishDynamicCompletions
+
+	default:
+		return "", fmt.Errorf("unsupported shell %q: supported shells: %s, %s, %s", shell, shellBash, shellZsh, shellFish)
+	}
+
+	return strings.ReplaceAll(script, "__PROG__", app.Name), nil
+}
+
+// BashComplete completes the values of the options with dynamic values (the DNS providers and the stored certificates),
+// and the commands and the flags otherwise.
+func BashComplete(ctx *cli.Context) {
+	switch completionLastArg() {
+	case "--" + flgDNS:
+		completeDNSProviders(ctx)
+
+	case "--" + flgDomains, "-d":
+		completeStoredDomains(ctx)
+
+	default:
+		cli.DefaultAppComplete(ctx)
+	}
+}
+
+// completeCommandStoredDomains completes the arguments of a command with the domains of the stored certificates,
+// and the flags of the command.
+func completeCommandStoredDomains(ctx *cli.Context) {
+	if strings.HasPrefix(completionLastArg(), "-") {
+		cli.DefaultCompleteWithFlags(ctx.Command)(ctx)
+		return
+	}
+
+	completeStoredDomains(ctx)
+}
+
+// completeStoredDomains prints the names (main domains) of the stored certificates.
+func completeStoredDomains(ctx *cli.Context) {
+	certsStorage := NewCertificatesStorage(ctx)
+
+	keys, err := certsStorage.ListCertificates()
+	if err != nil {
+		return
+	}
+
+	for _, key := range keys {
+		data, err := certsStorage.backend.ReadFile(key)
+		if err != nil {
+			continue
+		}
+
+		cert, err := certcrypto.ParsePEMCertificate(data)
+		if err != nil {
+			continue
+		}
+
+		domain, err := certcrypto.GetCertificateMainDomain(cert)
+		if err != nil {
+			continue
+		}
+
+		_, _ = fmt.Fprintln(ctx.App.Writer, domain)
+	}
+}
+
+func completeDNSProviders(ctx *cli.Context) {
+	for _, provider := range dnsProviderCatalog() {
+		_, _ = fmt.Fprintln(ctx.App.Writer, provider.Code)
+	}
+}
+
+// completionLastArg returns the argument before the --generate-bash-completion flag.
+// The arguments of the application cannot be used: the value of a flag is missing during the completion of this value.
+func completionLastArg() string {
+	if len(os.Args) < 3 {
+		return ""
+	}
+
+	return os.Args[len(os.Args)-2]
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func newCompletionTestApp(t *testing.T, output *bytes.Buffer) *cli.App {
+	t.Helper()
+
+	app := cli.NewApp()
+	app.Name = "lego"
+	app.EnableBashCompletion = true
+	app.Flags = CreateFlags(t.TempDir())
+	app.BashComplete = BashComplete
+	app.Commands = CreateCommands()
+	app.Writer = output
+
+	return app
+}
+
+func Test_completionScript(t *testing.T) {
+	app := newCompletionTestApp(t, &bytes.Buffer{})
+
+	for _, shell := range []string{shellBash, shellZsh, shellFish} {
+		t.Run(shell, func(t *testing.T) {
+			script, err := completionScript(app, shell)
+			require.NoError(t, err)
+
+			assert.NotContains(t, script, "__PROG__")
+			assert.Contains(t, script, "lego")
+			assert.Contains(t, script, "--generate-bash-completion")
+		})
+	}
+
+	_, err := completionScript(app, "powershell")
+	require.EqualError(t, err, `unsupported shell "powershell": supported shells: bash, zsh, fish`)
+}
+
+func TestBashComplete(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		args     []string
+		contains []string
+		excludes []string
+	}{
+		{
+			desc:     "commands",
+			args:     []string{"lego"},
+			contains: []string{"run", "renew", "completion"},
+		},
+		{
+			desc:     "DNS providers",
+			args:     []string{"lego", "--dns"},
+			contains: []string{"manual", "exec"},
+			excludes: []string{"run"},
+		},
+		{
+			desc:     "flags",
+			args:     []string{"lego", "--dn"},
+			contains: []string{"--dns"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			args := append(test.args, "--generate-bash-completion")
+
+			originalArgs := os.Args
+			os.Args = args
+
+			t.Cleanup(func() { os.Args = originalArgs })
+
+			var output bytes.Buffer
+
+			err := newCompletionTestApp(t, &output).Run(args)
+			require.NoError(t, err)
+
+			lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+
+			for _, value := range test.contains {
+				assert.Contains(t, lines, value)
+			}
+
+			for _, value := range test.excludes {
+				assert.NotContains(t, lines, value)
+			}
+		})
+	}
+}
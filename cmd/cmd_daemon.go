@@ -0,0 +1,369 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/go-acme/lego/v4/platform/systemd"
+	"github.com/urfave/cli/v2"
+)
+
+// Flag names.
+const (
+	flgDaemonInterval      = "interval"
+	flgDaemonRetryDelay    = "retry-delay"
+	flgDaemonJitter        = "jitter"
+	flgDaemonStatusAddress = "status-address"
+	flgDaemonStatusSocket  = "status-systemd-socket"
+)
+
+func createDaemon() *cli.Command {
+	return &cli.Command{
+		Name: "daemon",
+		Usage: "Keep running and renew the stored certificates when they are inside their renewal window" +
+			" (renewal information (ARI) of the CA, or the lifetime of the certificates).",
+		Action: daemon,
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  flgDaemonInterval,
+				Usage: "The maximum interval between two checks of a certificate.",
+				Value: 6 * time.Hour,
+			},
+			&cli.DurationFlag{
+				Name:  flgDaemonRetryDelay,
+				Usage: "The delay before retrying a failed renewal.",
+				Value: 10 * time.Minute,
+			},
+			&cli.DurationFlag{
+				Name: flgDaemonJitter,
+				Usage: "The maximum random delay subtracted from the renewal time of a certificate, when the CA does not provide renewal information (ARI)." +
+					" Spreads the renewals of the certificates issued at the same time.",
+				Value: time.Hour,
+			},
+			createConcurrencyFlag(),
+			&cli.IntFlag{
+				Name: flgDays,
+				Usage: "The number of days left on a certificate to renew it, when the CA does not provide renewal information (ARI)." +
+					" By default, the certificates are renewed after 2/3 of their lifetime (1/2 for the certificates valid less than 10 days).",
+			},
+			&cli.StringFlag{
+				Name:  flgDaemonStatusAddress,
+				Usage: "The address of the health, status, and metrics HTTP endpoints (/healthz, /status, /metrics). Disabled if empty. (ex: ':9119')",
+			},
+			&cli.StringFlag{
+				Name: flgDaemonStatusSocket,
+				Usage: "Use the socket passed by systemd with this name (FileDescriptorName= of the socket unit) for the status endpoints," +
+					" instead of listening on --status-address (socket activation).",
+			},
+			&cli.StringFlag{
+				Name: flgKeyPolicy,
+				Usage: "The key management policy of the renewals:" +
+					" 'always-new' (a new private key for each renewal), 'reuse-existing' (reuse the current private key)," +
+					" or 'rotate' (reuse the current private key, and use a new private key every N renewals).",
+				Value: string(certcrypto.KeyPolicyAlwaysNew),
+			},
+			&cli.IntFlag{
+				Name:  flgKeyPolicyRotateEvery,
+				Usage: "The number of renewals between two key rotations (only with the 'rotate' key management policy).",
+				Value: 3,
+			},
+			&cli.BoolFlag{
+				Name:  flgNoBundle,
+				Usage: "Do not create a certificate bundle by adding the issuers certificate to the new certificate.",
+			},
+			&cli.BoolFlag{
+				Name:  flgMustStaple,
+				Usage: "Include the OCSP must staple TLS extension in the CSR and generated certificate.",
+			},
+			createPreferredChainFlag(),
+			&cli.BoolFlag{
+				Name:  flgAlwaysDeactivateAuthorizations,
+				Usage: "Force the authorizations to be relinquished even if the certificate request was successful.",
+			},
+			&cli.StringFlag{
+				Name:  flgRenewHook,
+				Usage: "Define a hook. The hook is executed after each renewal of a certificate.",
+			},
+			&cli.DurationFlag{
+				Name:  flgRenewHookTimeout,
+				Usage: "Define the timeout for the hook execution.",
+				Value: 2 * time.Minute,
+			},
+		},
+	}
+}
+
+func daemon(ctx *cli.Context) error {
+	account, keyType, err := setupAccount(ctx, NewAccountsStorage(ctx))
+	if err != nil {
+		return err
+	}
+
+	if account.Registration == nil {
+		log.Fatalf("Account %s is not registered. Use 'run' to register a new account.\n", account.Email)
+	}
+
+	certsStorage := NewCertificatesStorage(ctx)
+
+	keyPolicy := getKeyPolicy(ctx)
+
+	resources, err := loadDaemonResources(certsStorage, keyPolicy)
+	if err != nil {
+		return err
+	}
+
+	if len(resources) == 0 {
+		log.Fatal("No certificates found. Use 'run' to obtain the certificates.")
+	}
+
+	client := setupClient(ctx, account, keyType)
+
+	notifier := newNotifier(ctx)
+
+	options := certificate.AutoRenewerOptions{
+		RenewOptions: certificate.RenewOptions{
+			Bundle:                         !ctx.Bool(flgNoBundle),
+			MustStaple:                     ctx.Bool(flgMustStaple),
+			PreferredChain:                 ctx.String(flgPreferredChain),
+			AlwaysDeactivateAuthorizations: ctx.Bool(flgAlwaysDeactivateAuthorizations),
+		},
+		RenewBefore:   time.Duration(ctx.Int(flgDays)) * 24 * time.Hour,
+		CheckInterval: ctx.Duration(flgDaemonInterval),
+		RetryDelay:    ctx.Duration(flgDaemonRetryDelay),
+		Jitter:        ctx.Duration(flgDaemonJitter),
+		Concurrency:   ctx.Int(flgConcurrency),
+		OnEvent: func(event certificate.AutoRenewEvent) {
+			if event.Err != nil {
+				legoMetrics.observeFailure(notifyEventRenew)
+				notifier.Notify(newFailureNotification(notifyEventRenew, event.Domain, event.Err))
+				notifySystemd(fmt.Sprintf("STATUS=managing %d certificates, the renewal of %s failed", len(resources), event.Domain))
+				return
+			}
+
+			legoMetrics.observeSuccess(notifyEventRenew)
+			notifier.Notify(newSuccessNotification(notifyEventRenew, event.Resource))
+			notifySystemd(fmt.Sprintf("STATUS=managing %d certificates, %s renewed", len(resources), event.Domain))
+
+			refreshOCSPStaple(ctx, certsStorage, event.Domain, true)
+
+			meta := map[string]string{hookEnvAccountEmail: account.Email}
+
+			addPathToMetadata(meta, event.Domain, event.Resource, certsStorage)
+
+			err := deployCertificate(ctx, event.Resource, meta)
+			if err != nil {
+				log.WithDomain(event.Domain).Warnf("The deployment failed: %v", err)
+			}
+
+			hookCtx := newHookContext(notifyEventRenew, event.Resource, meta)
+
+			err = launchHook(ctx.String(flgRenewHook), ctx.Duration(flgRenewHookTimeout), meta, hookCtx)
+			if err != nil {
+				log.WithDomain(event.Domain).Warnf("The renew hook failed: %v", err)
+			}
+		},
+	}
+
+	renewer := certificate.NewAutoRenewer(client.Certificate, &daemonStorage{certsStorage: certsStorage, keyPolicy: keyPolicy}, resources, options)
+
+	runCtx, stop := signal.NotifyContext(ctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	statusListener, err := newDaemonStatusListener(ctx)
+	if err != nil {
+		return err
+	}
+
+	if statusListener != nil {
+		server := &http.Server{Handler: newDaemonStatusHandler(renewer, legoMetrics.handler(certsStorage)), ReadHeaderTimeout: 10 * time.Second}
+
+		go func() {
+			log.Infof("daemon: status and metrics endpoints listening on %s", statusListener.Addr())
+
+			errS := server.Serve(statusListener)
+			if errS != nil && !errors.Is(errS, http.ErrServerClosed) {
+				log.Fatalf("daemon: status endpoints: %v", errS)
+			}
+		}()
+
+		defer func() { _ = server.Shutdown(context.Background()) }()
+	}
+
+	log.Infof("daemon: managing %d certificates", len(resources))
+
+	notifySystemd(fmt.Sprintf("%s\nSTATUS=managing %d certificates", systemd.Ready, len(resources)))
+
+	go runSystemdWatchdog(runCtx)
+
+	defer notifySystemd(systemd.Stopping)
+
+	err = renewer.Run(runCtx)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	log.Infof("daemon: stopped")
+
+	return nil
+}
+
+// newDaemonStatusListener returns the listener of the status endpoints: the socket passed by systemd, or the status address.
+// It returns nil if the status endpoints are disabled.
+func newDaemonStatusListener(ctx *cli.Context) (net.Listener, error) {
+	if name := ctx.String(flgDaemonStatusSocket); name != "" {
+		return systemd.ListenerByName(name)
+	}
+
+	if addr := ctx.String(flgDaemonStatusAddress); addr != "" {
+		return net.Listen("tcp", addr)
+	}
+
+	return nil, nil
+}
+
+// loadDaemonResources loads the stored certificates managed by the daemon.
+// The private key is only loaded if the key management policy reuses it for the next renewal.
+func loadDaemonResources(certsStorage *CertificatesStorage, keyPolicy certcrypto.KeyPolicy) ([]*certificate.Resource, error) {
+	keys, err := certsStorage.ListCertificates()
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []*certificate.Resource
+
+	for _, key := range keys {
+		data, err := certsStorage.backend.ReadFile(key)
+		if err != nil {
+			return nil, err
+		}
+
+		// the base name of the files of the certificate (sanitized domain).
+		domain := strings.TrimSuffix(path.Base(key), certExt)
+
+		if !certsStorage.ExistsFile(domain, keyExt) {
+			log.WithDomain(domain).Warnf("The private key is missing (CSR): the certificate is not managed by the daemon.")
+			continue
+		}
+
+		res := certsStorage.ReadResource(domain)
+		res.Certificate = data
+
+		res.IssuerCertificate, err = certsStorage.ReadFile(domain, issuerExt)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		if keyPolicy.ShouldReuse(certsStorage.ReadKeyRenewals(domain)) {
+			privateKey, err := certsStorage.ReadPrivateKey(domain)
+			if err != nil {
+				return nil, fmt.Errorf("[%s] private key: %w", domain, err)
+			}
+
+			res.PrivateKey = certcrypto.PEMEncode(privateKey)
+		}
+
+		resources = append(resources, &res)
+	}
+
+	return resources, nil
+}
+
+// daemonStorage saves the certificates renewed by the daemon.
+type daemonStorage struct {
+	certsStorage *CertificatesStorage
+	keyPolicy    certcrypto.KeyPolicy
+}
+
+// Save saves the renewed certificate, a failure is reported to the renewer (the renewal is retried) instead of stopping the daemon.
+func (s *daemonStorage) Save(res *certificate.Resource) error {
+	err := s.certsStorage.saveResource(res)
+	if err != nil {
+		return err
+	}
+
+	// The private key is kept for the next renewal only if the key management policy reuses it.
+	if !s.keyPolicy.ShouldReuse(s.certsStorage.ReadKeyRenewals(res.Domain)) {
+		res.PrivateKey = nil
+	}
+
+	return nil
+}
+
+// daemonCertificateStatus the status of a certificate, exposed by the status endpoint.
+type daemonCertificateStatus struct {
+	Domain      string     `json:"domain"`
+	NotAfter    time.Time  `json:"notAfter"`
+	NextCheck   *time.Time `json:"nextCheck,omitempty"`
+	LastRenewal *time.Time `json:"lastRenewal,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+}
+
+// newDaemonStatusHandler creates the handler of the health, status, and metrics endpoints:
+//   - /healthz: 200 if none of the managed certificates has expired, 503 otherwise.
+//   - /status: the status of the managed certificates (JSON).
+//   - /metrics: the Prometheus metrics.
+func newDaemonStatusHandler(renewer *certificate.AutoRenewer, metricsHandler http.Handler) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.Handle("/metrics", metricsHandler)
+
+	mux.HandleFunc("/healthz", func(rw http.ResponseWriter, _ *http.Request) {
+		now := time.Now()
+
+		for _, status := range renewer.Status() {
+			if status.NotAfter.Before(now) {
+				http.Error(rw, fmt.Sprintf("the certificate %s has expired", status.Domain), http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		_, _ = fmt.Fprintln(rw, "ok")
+	})
+
+	mux.HandleFunc("/status", func(rw http.ResponseWriter, _ *http.Request) {
+		var statuses []daemonCertificateStatus
+
+		for _, status := range renewer.Status() {
+			s := daemonCertificateStatus{
+				Domain:      status.Domain,
+				NotAfter:    status.NotAfter,
+				NextCheck:   nonZeroTime(status.NextCheck),
+				LastRenewal: nonZeroTime(status.LastRenewal),
+			}
+
+			if status.LastError != nil {
+				s.LastError = status.LastError.Error()
+			}
+
+			statuses = append(statuses, s)
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+
+		_ = json.NewEncoder(rw).Encode(statuses)
+	})
+
+	return mux
+}
+
+func nonZeroTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
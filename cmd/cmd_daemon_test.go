@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_loadDaemonResources(t *testing.T) {
+	certsStorage, _, _ := newTestCertificatesStorage(t)
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+
+	for _, domain := range []string{"example.com", "example.org"} {
+		certPEM, errG := certcrypto.GeneratePemCert(privateKey.(*rsa.PrivateKey), domain, nil)
+		require.NoError(t, errG)
+
+		certsStorage.SaveResource(&certificate.Resource{
+			Domain:      domain,
+			CertURL:     "https://" + domain + "/cert",
+			Certificate: certPEM,
+			PrivateKey:  certcrypto.PEMEncode(privateKey),
+		})
+	}
+
+	testCases := []struct {
+		desc       string
+		keyPolicy  certcrypto.KeyPolicy
+		privateKey bool
+	}{
+		{
+			desc:      "always new",
+			keyPolicy: certcrypto.KeyPolicy{Mode: certcrypto.KeyPolicyAlwaysNew},
+		},
+		{
+			desc:       "reuse existing",
+			keyPolicy:  certcrypto.KeyPolicy{Mode: certcrypto.KeyPolicyReuseExisting},
+			privateKey: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			resources, err := loadDaemonResources(certsStorage, test.keyPolicy)
+			require.NoError(t, err)
+
+			require.Len(t, resources, 2)
+
+			for _, res := range resources {
+				assert.NotEmpty(t, res.Certificate)
+				assert.Equal(t, "https://"+res.Domain+"/cert", res.CertURL)
+				assert.Equal(t, test.privateKey, res.PrivateKey != nil)
+			}
+		})
+	}
+}
+
+func Test_daemonStorage_Save_error(t *testing.T) {
+	certsStorage, _, _ := newTestCertificatesStorage(t)
+
+	certsStorage.backend = &failingStorage{
+		CertificatesStorage: certsStorage.backend,
+		key:                 certsStorage.getKey("example.com", certExt),
+	}
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := certcrypto.GeneratePemCert(privateKey.(*rsa.PrivateKey), "example.com", nil)
+	require.NoError(t, err)
+
+	s := &daemonStorage{certsStorage: certsStorage}
+
+	err = s.Save(&certificate.Resource{
+		Domain:      "example.com",
+		Certificate: certPEM,
+		PrivateKey:  certcrypto.PEMEncode(privateKey),
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, ExitCodeStorageError, ExitCode(err))
+}
+
+func Test_newDaemonStatusHandler(t *testing.T) {
+	certsStorage, _, _ := newTestCertificatesStorage(t)
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := certcrypto.GeneratePemCert(privateKey.(*rsa.PrivateKey), "example.com", nil)
+	require.NoError(t, err)
+
+	renewer := certificate.NewAutoRenewer(nil, &daemonStorage{certsStorage: certsStorage}, []*certificate.Resource{
+		{Domain: "example.com", Certificate: certPEM},
+	}, certificate.AutoRenewerOptions{})
+
+	handler := newDaemonStatusHandler(renewer, legoMetrics.handler(certsStorage))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", http.NoBody))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", http.NoBody))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var statuses []daemonCertificateStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &statuses))
+
+	require.Len(t, statuses, 1)
+
+	assert.Equal(t, "example.com", statuses[0].Domain)
+	assert.True(t, statuses[0].NotAfter.After(time.Now()))
+	assert.Nil(t, statuses[0].NextCheck)
+	assert.Nil(t, statuses[0].LastRenewal)
+	assert.Empty(t, statuses[0].LastError)
+}
@@ -3,13 +3,18 @@ package cmd
 import (
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/urfave/cli/v2"
 )
 
-const flgCode = "code"
+// Flag names.
+const (
+	flgCode = "code"
+	flgJSON = "json"
+)
 
 func createDNSHelp() *cli.Command {
 	return &cli.Command{
@@ -22,12 +27,22 @@ func createDNSHelp() *cli.Command {
 				Aliases: []string{"c"},
 				Usage:   fmt.Sprintf("DNS code: %s", allDNSCodes()),
 			},
+			&cli.BoolFlag{
+				Name: flgJSON,
+				Usage: "Display the DNS providers (or the DNS provider defined by --code) as JSON:" +
+					" name, documentation, and environment variables (description, default value).",
+			},
 		},
 	}
 }
 
 func dnsHelp(ctx *cli.Context) error {
 	code := ctx.String(flgCode)
+
+	if ctx.Bool(flgJSON) || isJSONOutput(ctx) {
+		return printDNSProviders(strings.ToLower(code))
+	}
+
 	if code == "" {
 		w := tabwriter.NewWriter(ctx.App.Writer, 0, 0, 2, ' ', 0)
 		ew := &errWriter{w: w}
@@ -53,6 +68,36 @@ func dnsHelp(ctx *cli.Context) error {
 	return displayDNSHelp(ctx.App.Writer, strings.ToLower(code))
 }
 
+// printDNSProviders prints the catalog of the DNS providers as JSON, or only the provider with this code (or alias).
+func printDNSProviders(code string) error {
+	catalog := dnsProviderCatalog()
+
+	slices.SortFunc(catalog, func(a, b dnsProviderResult) int {
+		return strings.Compare(a.Code, b.Code)
+	})
+
+	if code == "" {
+		return printJSON(catalog)
+	}
+
+	provider, err := findDNSProvider(catalog, code)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(provider)
+}
+
+func findDNSProvider(catalog []dnsProviderResult, code string) (dnsProviderResult, error) {
+	for _, provider := range catalog {
+		if provider.Code == code || slices.Contains(provider.Aliases, code) {
+			return provider, nil
+		}
+	}
+
+	return dnsProviderResult{}, fmt.Errorf("%q is not yet supported", code)
+}
+
 type errWriter struct {
 	w   io.Writer
 	err error
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_dnsProviderCatalog(t *testing.T) {
+	catalog := dnsProviderCatalog()
+
+	var codes []string
+	for _, provider := range catalog {
+		codes = append(codes, provider.Code)
+	}
+
+	assert.ElementsMatch(t, strings.Split(allDNSCodes(), ", "), codes)
+}
+
+func Test_findDNSProvider(t *testing.T) {
+	catalog := dnsProviderCatalog()
+
+	provider, err := findDNSProvider(catalog, "alidns")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Alibaba Cloud DNS", provider.Name)
+	assert.Equal(t, "https://go-acme.github.io/lego/dns/alidns", provider.Documentation)
+	assert.Contains(t, provider.Credentials, dnsProviderEnvVar{Name: "ALICLOUD_ACCESS_KEY", Description: "Access key ID"})
+	assert.Contains(t, provider.Additional, dnsProviderEnvVar{Name: "ALICLOUD_HTTP_TIMEOUT", Description: "API request timeout in seconds", Default: "10"})
+
+	// alias
+	provider, err = findDNSProvider(catalog, "acmedns")
+	require.NoError(t, err)
+
+	assert.Equal(t, "acme-dns", provider.Code)
+
+	_, err = findDNSProvider(catalog, "foo")
+	require.EqualError(t, err, `"foo" is not yet supported`)
+}
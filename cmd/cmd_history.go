@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Flag names.
+const (
+	flgHistoryDomain   = "domain"
+	flgHistorySince    = "since"
+	flgHistoryFailures = "failures"
+	flgHistoryLimit    = "limit"
+)
+
+func createHistory() *cli.Command {
+	return &cli.Command{
+		Name:  "history",
+		Usage: "Display the history of the issuance attempts (obtain, renew): outcome, errors, ACME orders, rate limits.",
+		Description: "The run, renew, and daemon commands record each attempt in the history file (see --history.file).\n" +
+			"   The attempts are displayed from the most recent.",
+		Action: history,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  flgHistoryDomain,
+				Usage: "Display only the attempts of this certificate (name or domain).",
+			},
+			&cli.StringFlag{
+				Name:  flgHistorySince,
+				Usage: "Display only the attempts within this duration (ex: 7d, 24h).",
+			},
+			&cli.BoolFlag{
+				Name:  flgHistoryFailures,
+				Usage: "Display only the failed attempts.",
+			},
+			&cli.IntFlag{
+				Name:  flgHistoryLimit,
+				Usage: "The maximum number of attempts to display (0 means no limit).",
+				Value: 20,
+			},
+		},
+	}
+}
+
+func history(ctx *cli.Context) error {
+	filter := historyFilter{
+		Domain:   ctx.String(flgHistoryDomain),
+		Failures: ctx.Bool(flgHistoryFailures),
+		Limit:    ctx.Int(flgHistoryLimit),
+	}
+
+	if ctx.IsSet(flgHistorySince) {
+		since, err := parseDaysDuration(flgHistorySince, ctx.String(flgHistorySince))
+		if err != nil {
+			return err
+		}
+
+		filter.Since = time.Now().Add(-since)
+	}
+
+	entries, err := newHistoryStore(ctx).Query(filter)
+	if err != nil {
+		return err
+	}
+
+	if isJSONOutput(ctx) {
+		if entries == nil {
+			entries = []historyEntry{}
+		}
+
+		return printJSON(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No issuance attempts were found.")
+		return nil
+	}
+
+	for _, entry := range entries {
+		printHistoryEntry(entry)
+	}
+
+	return nil
+}
+
+func printHistoryEntry(entry historyEntry) {
+	fmt.Printf("%s  %-6s  %s  %s\n", entry.Time.Local().Format(time.DateTime), entry.Event, entry.Certificate, entry.Outcome)
+
+	if len(entry.Domains) > 0 {
+		fmt.Printf("    Domains: %s\n", strings.Join(entry.Domains, ", "))
+	}
+
+	if entry.Server != "" {
+		fmt.Printf("    Server: %s\n", entry.Server)
+	}
+
+	if entry.Detail != "" {
+		fmt.Printf("    Detail: %s\n", entry.Detail)
+	}
+
+	if entry.OrderURL != "" {
+		fmt.Printf("    Order: %s\n", entry.OrderURL)
+	}
+
+	if entry.CertURL != "" {
+		fmt.Printf("    Certificate URL: %s\n", entry.CertURL)
+	}
+
+	for _, limit := range entry.RateLimits {
+		fmt.Printf("    Rate limit: %d %s (retry after %s)\n", limit.StatusCode, limit.URL, limit.RetryAfter)
+	}
+
+	if entry.Error != "" {
+		fmt.Printf("    Error: %s\n", strings.ReplaceAll(entry.Error, "\n", "\n    "))
+	}
+}
@@ -0,0 +1,511 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/http01"
+	"github.com/go-acme/lego/v4/challenge/tlsalpn01"
+	"github.com/go-acme/lego/v4/lego"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/go-acme/lego/v4/providers/dns"
+	"github.com/go-acme/lego/v4/providers/http/webroot"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// Flag names.
+const (
+	flgInitFile      = "file"
+	flgInitForce     = "force"
+	flgInitSkipCheck = "skip-check"
+)
+
+// initCA a CA proposed by the init command.
+type initCA struct {
+	name      string
+	directory string
+	eab       bool
+}
+
+var initCAs = []initCA{
+	{name: "letsencrypt", directory: lego.LEDirectoryProduction},
+	{name: "letsencrypt-staging", directory: lego.LEDirectoryStaging},
+	{name: "zerossl", directory: "https://acme.zerossl.com/v2/DV90", eab: true},
+	{name: "google", directory: "https://dv.acme-v02.api.pki.goog/directory", eab: true},
+}
+
+var initKeyTypes = []string{"ec256", "ec384", "rsa2048", "rsa3072", "rsa4096", "rsa8192"}
+
+func createInit() *cli.Command {
+	return &cli.Command{
+		Name:  "init",
+		Usage: "Create a configuration file interactively (CA, account, domains, challenge), and check the challenge",
+		Action: func(ctx *cli.Context) error {
+			return initConfig(ctx, ctx.App.Reader, ctx.App.Writer)
+		},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  flgInitFile,
+				Usage: "The configuration file to create (YAML).",
+				Value: "lego.yaml",
+			},
+			&cli.BoolFlag{
+				Name:  flgInitForce,
+				Usage: "Overwrite the configuration file if it already exists.",
+			},
+			&cli.BoolFlag{
+				Name:  flgInitSkipCheck,
+				Usage: "Do not check the challenge (Present then CleanUp of a fake challenge) before writing the configuration file.",
+			},
+		},
+	}
+}
+
+// initAnswers the answers of the init command.
+type initAnswers struct {
+	server    string
+	eab       bool
+	kid       string
+	hmac      string
+	email     string
+	acceptTOS bool
+	keyType   string
+	domains   []string
+	challenge challenge.Type
+	// httpPort the address of the built-in HTTP-01 or TLS-ALPN-01 server (ex: ":80").
+	httpPort string
+	webroot  string
+	dns      string
+}
+
+func initConfig(ctx *cli.Context, r io.Reader, w io.Writer) error {
+	filename := ctx.String(flgInitFile)
+
+	if _, err := os.Stat(filename); err == nil && !ctx.Bool(flgInitForce) {
+		return fmt.Errorf("the file %s already exists, use --%s to overwrite it", filename, flgInitForce)
+	}
+
+	p := &prompter{reader: bufio.NewReader(r), writer: w}
+
+	answers, err := askInitAnswers(p)
+	if err != nil {
+		return err
+	}
+
+	if !ctx.Bool(flgInitSkipCheck) {
+		p.println()
+		p.println("Checking the challenge...")
+
+		err = checkInitChallenge(answers)
+		if err != nil {
+			return fmt.Errorf("the challenge check failed (use --%s to write the configuration anyway): %w", flgInitSkipCheck, err)
+		}
+
+		p.println("The challenge has been presented and cleaned up successfully.")
+	}
+
+	raw, err := yaml.Marshal(answers.config())
+	if err != nil {
+		return err
+	}
+
+	// The file can contain the EAB HMAC key.
+	err = os.WriteFile(filename, raw, 0o600)
+	if err != nil {
+		return err
+	}
+
+	p.println()
+	p.printf("The configuration has been written to %s, to obtain the certificate:\n", filename)
+	p.printf("\tlego --config %s run\n", filename)
+
+	if answers.dns != "" && answers.dns != "manual" {
+		p.println()
+		p.printf("The credentials of the DNS provider are not saved: define the environment variables before running lego (lego dnshelp -c %s).\n", answers.dns)
+	}
+
+	return p.err
+}
+
+func askInitAnswers(p *prompter) (*initAnswers, error) {
+	answers := &initAnswers{}
+
+	var names []string
+	for _, ca := range initCAs {
+		names = append(names, ca.name)
+	}
+
+	p.println("Certificate Authority: " + strings.Join(names, ", ") + ", or the URL of an ACME directory.")
+
+	ca, err := p.ask("CA", "letsencrypt", func(value string) error {
+		if slices.Contains(names, value) || strings.HasPrefix(value, "https://") {
+			return nil
+		}
+
+		return errors.New("unknown CA")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	answers.server = ca
+
+	for _, c := range initCAs {
+		if c.name == ca {
+			answers.server = c.directory
+			answers.eab = c.eab
+		}
+	}
+
+	if !answers.eab && strings.HasPrefix(ca, "https://") {
+		answers.eab, err = p.confirm("Does the CA require an External Account Binding (EAB)?", false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if answers.eab {
+		answers.kid, err = p.ask("EAB key identifier", "", required)
+		if err != nil {
+			return nil, err
+		}
+
+		answers.hmac, err = p.ask("EAB HMAC key", "", required)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	answers.email, err = p.ask("Email address of the account", "", func(value string) error {
+		_, errP := mail.ParseAddress(value)
+		return errP
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	answers.acceptTOS, err = p.confirm("Do you accept the terms of service of the CA?", true)
+	if err != nil {
+		return nil, err
+	}
+
+	answers.keyType, err = p.ask("Key type ("+strings.Join(initKeyTypes, ", ")+")", "ec256", oneOf(initKeyTypes...))
+	if err != nil {
+		return nil, err
+	}
+
+	domains, err := p.ask("Domains (separated by commas)", "", required)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, domain := range strings.Split(domains, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			answers.domains = append(answers.domains, domain)
+		}
+	}
+
+	err = askInitChallenge(p, answers)
+	if err != nil {
+		return nil, err
+	}
+
+	return answers, nil
+}
+
+func askInitChallenge(p *prompter, answers *initAnswers) error {
+	wildcard := slices.ContainsFunc(answers.domains, func(domain string) bool { return strings.HasPrefix(domain, "*.") })
+
+	defaultChallenge := "http"
+	if wildcard {
+		p.println("The wildcard domains require the DNS-01 challenge.")
+
+		defaultChallenge = "dns"
+	}
+
+	chlg, err := p.ask("Challenge (http, tls, dns)", defaultChallenge, oneOf("http", "tls", "dns"))
+	if err != nil {
+		return err
+	}
+
+	switch chlg {
+	case "http":
+		answers.challenge = challenge.HTTP01
+
+		answers.webroot, err = p.ask("Webroot of an existing web server (empty to use the built-in server)", "", nil)
+		if err != nil || answers.webroot != "" {
+			return err
+		}
+
+		answers.httpPort, err = p.ask("Address of the built-in server", ":80", validateInitAddress)
+		return err
+
+	case "tls":
+		answers.challenge = challenge.TLSALPN01
+
+		answers.httpPort, err = p.ask("Address of the built-in server", ":443", validateInitAddress)
+		return err
+
+	default:
+		answers.challenge = challenge.DNS01
+
+		return askInitDNSProvider(p, answers)
+	}
+}
+
+func askInitDNSProvider(p *prompter, answers *initAnswers) error {
+	catalog := dnsProviderCatalog()
+
+	code, err := p.ask("DNS provider code (see 'lego dnshelp')", "", func(value string) error {
+		_, errF := findDNSProvider(catalog, value)
+		return errF
+	})
+	if err != nil {
+		return err
+	}
+
+	provider, _ := findDNSProvider(catalog, code)
+
+	answers.dns = provider.Code
+
+	if len(provider.Credentials) == 0 {
+		return nil
+	}
+
+	p.println()
+	p.printf("The credentials of %s are read from environment variables (only some of them are required, see %s).\n", provider.Name, provider.Documentation)
+	p.println("The values are only used to check the challenge: they are not written in the configuration file.")
+
+	for _, env := range provider.Credentials {
+		if os.Getenv(env.Name) != "" {
+			p.printf("%s is already defined.\n", env.Name)
+			continue
+		}
+
+		value, err := p.ask(fmt.Sprintf("%s (%s)", env.Name, env.Description), "", nil)
+		if err != nil {
+			return err
+		}
+
+		if value != "" {
+			if err = os.Setenv(env.Name, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// config returns the content of the configuration file: the keys are the names of the options.
+func (a *initAnswers) config() yaml.MapSlice {
+	config := yaml.MapSlice{{Key: flgServer, Value: a.server}}
+
+	if a.eab {
+		config = append(config,
+			yaml.MapItem{Key: flgEAB, Value: true},
+			yaml.MapItem{Key: flgKID, Value: a.kid},
+			yaml.MapItem{Key: flgHMAC, Value: a.hmac},
+		)
+	}
+
+	config = append(config,
+		yaml.MapItem{Key: flgEmail, Value: a.email},
+		yaml.MapItem{Key: flgAcceptTOS, Value: a.acceptTOS},
+		yaml.MapItem{Key: flgKeyType, Value: a.keyType},
+		yaml.MapItem{Key: flgDomains, Value: a.domains},
+	)
+
+	switch a.challenge {
+	case challenge.HTTP01:
+		config = append(config, yaml.MapItem{Key: flgHTTP, Value: true})
+
+		if a.webroot != "" {
+			config = append(config, yaml.MapItem{Key: flgHTTPWebroot, Value: a.webroot})
+		} else if a.httpPort != ":80" {
+			config = append(config, yaml.MapItem{Key: flgHTTPPort, Value: a.httpPort})
+		}
+
+	case challenge.TLSALPN01:
+		config = append(config, yaml.MapItem{Key: flgTLS, Value: true})
+
+		if a.httpPort != ":443" {
+			config = append(config, yaml.MapItem{Key: flgTLSPort, Value: a.httpPort})
+		}
+
+	default:
+		config = append(config, yaml.MapItem{Key: flgDNS, Value: a.dns})
+	}
+
+	return config
+}
+
+// checkInitChallenge presents then cleans up a fake challenge for the first domain,
+// to check the credentials of the DNS provider, the permissions on the webroot, or the port of the built-in server.
+func checkInitChallenge(answers *initAnswers) error {
+	if answers.dns == "manual" {
+		return nil
+	}
+
+	provider, err := newInitProvider(answers)
+	if err != nil {
+		return err
+	}
+
+	token := make([]byte, 16)
+	if _, err = rand.Read(token); err != nil {
+		return err
+	}
+
+	domain := strings.TrimPrefix(answers.domains[0], "*.")
+	keyAuth := hex.EncodeToString(token) + ".lego-init-check"
+
+	log.WithDomain(domain).Infof("Presenting a fake %s challenge", answers.challenge)
+
+	err = provider.Present(domain, hex.EncodeToString(token), keyAuth)
+	if err != nil {
+		return fmt.Errorf("present: %w", err)
+	}
+
+	err = provider.CleanUp(domain, hex.EncodeToString(token), keyAuth)
+	if err != nil {
+		return fmt.Errorf("cleanup: %w", err)
+	}
+
+	return nil
+}
+
+func newInitProvider(answers *initAnswers) (challenge.Provider, error) {
+	switch answers.challenge {
+	case challenge.HTTP01:
+		if answers.webroot != "" {
+			return webroot.NewHTTPProvider(answers.webroot)
+		}
+
+		host, port, err := net.SplitHostPort(answers.httpPort)
+		if err != nil {
+			return nil, err
+		}
+
+		return http01.NewProviderServer(host, port), nil
+
+	case challenge.TLSALPN01:
+		host, port, err := net.SplitHostPort(answers.httpPort)
+		if err != nil {
+			return nil, err
+		}
+
+		return tlsalpn01.NewProviderServer(host, port), nil
+
+	default:
+		return dns.NewDNSChallengeProviderByName(answers.dns)
+	}
+}
+
+func validateInitAddress(value string) error {
+	if !strings.Contains(value, ":") {
+		return errors.New("supported: interface:port or :port")
+	}
+
+	_, _, err := net.SplitHostPort(value)
+
+	return err
+}
+
+func required(value string) error {
+	if value == "" {
+		return errors.New("a value is required")
+	}
+
+	return nil
+}
+
+func oneOf(values ...string) func(string) error {
+	return func(value string) error {
+		if !slices.Contains(values, value) {
+			return fmt.Errorf("supported: %s", strings.Join(values, ", "))
+		}
+
+		return nil
+	}
+}
+
+// prompter asks the questions of the init command.
+type prompter struct {
+	reader *bufio.Reader
+	writer io.Writer
+	err    error
+}
+
+// ask asks a question until the answer is valid, the default value is used if the answer is empty.
+func (p *prompter) ask(question, defaultValue string, validate func(string) error) (string, error) {
+	for {
+		if defaultValue != "" {
+			p.printf("%s [%s]: ", question, defaultValue)
+		} else {
+			p.printf("%s: ", question)
+		}
+
+		line, err := p.reader.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			return "", fmt.Errorf("could not read the answer: %w", err)
+		}
+
+		value := strings.TrimSpace(line)
+		if value == "" {
+			value = defaultValue
+		}
+
+		if validate == nil {
+			return value, nil
+		}
+
+		errV := validate(value)
+		if errV == nil {
+			return value, nil
+		}
+
+		p.printf("Invalid value: %v\n", errV)
+
+		if errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("invalid value %q: %w", value, errV)
+		}
+	}
+}
+
+func (p *prompter) confirm(question string, defaultValue bool) (bool, error) {
+	def := "n"
+	if defaultValue {
+		def = "y"
+	}
+
+	value, err := p.ask(question+" (y/n)", def, oneOf("y", "Y", "n", "N", "yes", "no"))
+	if err != nil {
+		return false, err
+	}
+
+	return strings.HasPrefix(strings.ToLower(value), "y"), nil
+}
+
+func (p *prompter) println(a ...any) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintln(p.writer, a...)
+	}
+}
+
+func (p *prompter) printf(format string, a ...any) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.writer, format, a...)
+	}
+}
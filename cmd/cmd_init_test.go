@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-acme/lego/v4/lego"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func newInitTestContext(t *testing.T, args ...string) *cli.Context {
+	t.Helper()
+
+	command := createInit()
+
+	set := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+
+	for _, f := range command.Flags {
+		require.NoError(t, f.Apply(set))
+	}
+
+	require.NoError(t, set.Parse(args))
+
+	return cli.NewContext(cli.NewApp(), set, nil)
+}
+
+func Test_initConfig_webroot(t *testing.T) {
+	dir := t.TempDir()
+
+	filename := filepath.Join(dir, "lego.yaml")
+	webrootDir := filepath.Join(dir, "www")
+	require.NoError(t, os.Mkdir(webrootDir, 0o755))
+
+	answers := []string{
+		"",                             // CA
+		"invalid",                      // email (invalid)
+		"you@example.com",              // email
+		"y",                            // TOS
+		"rsa1024",                      // key type (invalid)
+		"",                             // key type
+		"example.com, www.example.com", // domains
+		"",                             // challenge
+		webrootDir,                     // webroot
+	}
+
+	ctx := newInitTestContext(t, "--file", filename)
+
+	var output bytes.Buffer
+
+	err := initConfig(ctx, strings.NewReader(strings.Join(answers, "\n")+"\n"), &output)
+	require.NoError(t, err)
+
+	assert.Contains(t, output.String(), "Invalid value: mail: missing '@' or angle-addr")
+	assert.Contains(t, output.String(), "Invalid value: supported: ec256, ec384, rsa2048, rsa3072, rsa4096, rsa8192")
+	assert.Contains(t, output.String(), "lego --config "+filename+" run")
+
+	// The fake challenge has been cleaned up.
+	entries, err := os.ReadDir(filepath.Join(webrootDir, ".well-known", "acme-challenge"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	raw, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	config, err := parseConfig(filename, raw)
+	require.NoError(t, err)
+
+	expected := map[string]any{
+		flgServer:      lego.LEDirectoryProduction,
+		flgEmail:       "you@example.com",
+		flgAcceptTOS:   true,
+		flgKeyType:     "ec256",
+		flgDomains:     []any{"example.com", "www.example.com"},
+		flgHTTP:        true,
+		flgHTTPWebroot: webrootDir,
+	}
+	assert.Equal(t, expected, config.Options)
+}
+
+func Test_initConfig_eab(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "lego.yaml")
+
+	answers := []string{
+		"zerossl",
+		"kid-123",
+		"hmac-456",
+		"you@example.com",
+		"",
+		"ec384",
+		"*.example.com",
+		"",       // challenge: dns by default
+		"manual", // DNS provider
+	}
+
+	ctx := newInitTestContext(t, "--file", filename)
+
+	err := initConfig(ctx, strings.NewReader(strings.Join(answers, "\n")+"\n"), &bytes.Buffer{})
+	require.NoError(t, err)
+
+	raw, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	expected := `server: https://acme.zerossl.com/v2/DV90
+eab: true
+kid: kid-123
+hmac: hmac-456
+email: you@example.com
+accept-tos: true
+key-type: ec384
+domains:
+- '*.example.com'
+dns: manual
+`
+	assert.Equal(t, expected, string(raw))
+
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func Test_initConfig_existingFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "lego.yaml")
+
+	require.NoError(t, os.WriteFile(filename, []byte("email: you@example.com\n"), 0o600))
+
+	ctx := newInitTestContext(t, "--file", filename)
+
+	err := initConfig(ctx, strings.NewReader(""), &bytes.Buffer{})
+	require.EqualError(t, err, "the file "+filename+" already exists, use --force to overwrite it")
+}
+
+func Test_initConfig_endOfInput(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "lego.yaml")
+
+	ctx := newInitTestContext(t, "--file", filename)
+
+	err := initConfig(ctx, strings.NewReader("letsencrypt\n"), &bytes.Buffer{})
+	require.EqualError(t, err, "could not read the answer: EOF")
+
+	assert.NoFileExists(t, filename)
+}
@@ -1,20 +1,26 @@
 package cmd
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"net/url"
-	"os"
-	"path/filepath"
+	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/urfave/cli/v2"
 )
 
 const (
-	flgAccounts = "accounts"
-	flgNames    = "names"
+	flgAccounts       = "accounts"
+	flgNames          = "names"
+	flgExpiringWithin = "expiring-within"
 )
 
 func createList() *cli.Command {
@@ -33,6 +39,10 @@ func createList() *cli.Command {
 				Aliases: []string{"n"},
 				Usage:   "Display certificate common names only.",
 			},
+			&cli.StringFlag{
+				Name:  flgExpiringWithin,
+				Usage: "Display only the certificates expiring within this duration (ex: 30d, 72h). The expired certificates are included.",
+			},
 			// fake email, needed by NewAccountsStorage
 			&cli.StringFlag{
 				Name:   flgEmail,
@@ -44,6 +54,10 @@ func createList() *cli.Command {
 }
 
 func list(ctx *cli.Context) error {
+	if isJSONOutput(ctx) {
+		return listJSON(ctx)
+	}
+
 	if ctx.Bool(flgAccounts) && !ctx.Bool(flgNames) {
 		if err := listAccount(ctx); err != nil {
 			return err
@@ -53,17 +67,33 @@ func list(ctx *cli.Context) error {
 	return listCertificates(ctx)
 }
 
-func listCertificates(ctx *cli.Context) error {
-	certsStorage := NewCertificatesStorage(ctx)
+func listJSON(ctx *cli.Context) error {
+	certificates, err := readFilteredCertificatesInfo(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := listResult{Certificates: certificates}
+
+	if ctx.Bool(flgAccounts) {
+		result.Accounts, err = readAccountsInfo(NewAccountsStorage(ctx))
+		if err != nil {
+			return err
+		}
+	}
+
+	return printJSON(result)
+}
 
-	matches, err := filepath.Glob(filepath.Join(certsStorage.GetRootPath(), "*.crt"))
+func listCertificates(ctx *cli.Context) error {
+	certificates, err := readFilteredCertificatesInfo(ctx)
 	if err != nil {
 		return err
 	}
 
 	names := ctx.Bool(flgNames)
 
-	if len(matches) == 0 {
+	if len(certificates) == 0 {
 		if !names {
 			fmt.Println("No certificates found.")
 		}
@@ -74,76 +104,215 @@ func listCertificates(ctx *cli.Context) error {
 		fmt.Println("Found the following certs:")
 	}
 
-	for _, filename := range matches {
-		if strings.HasSuffix(filename, issuerExt) {
-			continue
+	for _, info := range certificates {
+		if names {
+			fmt.Println(info.Name)
+		} else {
+			fmt.Println("  Certificate Name:", info.Name)
+			fmt.Println("    Domains:", strings.Join(info.Domains, ", "))
+			fmt.Println("    Key Type:", info.KeyType)
+			fmt.Println("    Issuer:", info.Issuer)
+			fmt.Println("    Expiry Date:", info.NotAfter, expiryStatus(info.NotAfter, time.Now()))
+			fmt.Println("    Certificate Path:", info.Path)
+			fmt.Println()
 		}
+	}
+
+	return nil
+}
+
+func listAccount(ctx *cli.Context) error {
+	accounts, err := readAccountsInfo(NewAccountsStorage(ctx))
+	if err != nil {
+		return err
+	}
+
+	if len(accounts) == 0 {
+		fmt.Println("No accounts found.")
+		return nil
+	}
+
+	fmt.Println("Found the following accounts:")
+	for _, info := range accounts {
+		fmt.Println("  Email:", info.Email)
+		fmt.Println("  Server:", info.Server)
+		fmt.Println("  Path:", info.Path)
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func readCertificatesInfo(certsStorage *CertificatesStorage) ([]listCertificateResult, error) {
+	matches, err := certsStorage.ListCertificates()
+	if err != nil {
+		return nil, err
+	}
 
-		data, err := os.ReadFile(filename)
+	certificates := make([]listCertificateResult, 0, len(matches))
+
+	for _, key := range matches {
+		data, err := certsStorage.backend.ReadFile(key)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		pCert, err := certcrypto.ParsePEMCertificate(data)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		name, err := certcrypto.GetCertificateMainDomain(pCert)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		if names {
-			fmt.Println(name)
-		} else {
-			fmt.Println("  Certificate Name:", name)
-			fmt.Println("    Domains:", strings.Join(pCert.DNSNames, ", "))
-			fmt.Println("    Expiry Date:", pCert.NotAfter)
-			fmt.Println("    Certificate Path:", filename)
-			fmt.Println()
-		}
+		certificates = append(certificates, listCertificateResult{
+			Name:      name,
+			Domains:   subjectAltNames(pCert),
+			KeyType:   keyTypeName(pCert.PublicKey),
+			Issuer:    issuerName(pCert),
+			NotBefore: pCert.NotBefore,
+			NotAfter:  pCert.NotAfter,
+			Path:      certsStorage.backend.Location(key),
+		})
 	}
 
-	return nil
+	return certificates, nil
 }
 
-func listAccount(ctx *cli.Context) error {
-	accountsStorage := NewAccountsStorage(ctx)
+// readFilteredCertificatesInfo reads the stored certificates, filtered by the expiring-within option.
+func readFilteredCertificatesInfo(ctx *cli.Context) ([]listCertificateResult, error) {
+	certificates, err := readCertificatesInfo(NewCertificatesStorage(ctx))
+	if err != nil {
+		return nil, err
+	}
 
-	matches, err := filepath.Glob(filepath.Join(accountsStorage.GetRootPath(), "*", "*", "*.json"))
+	if !ctx.IsSet(flgExpiringWithin) {
+		return certificates, nil
+	}
+
+	within, err := parseExpiringWithin(ctx.String(flgExpiringWithin))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if len(matches) == 0 {
-		fmt.Println("No accounts found.")
-		return nil
+	return filterExpiringCertificates(certificates, time.Now().Add(within)), nil
+}
+
+// filterExpiringCertificates keeps the certificates expiring before the deadline (including the expired certificates).
+func filterExpiringCertificates(certificates []listCertificateResult, deadline time.Time) []listCertificateResult {
+	filtered := make([]listCertificateResult, 0, len(certificates))
+
+	for _, info := range certificates {
+		if info.NotAfter.Before(deadline) {
+			filtered = append(filtered, info)
+		}
 	}
 
-	fmt.Println("Found the following accounts:")
-	for _, filename := range matches {
-		data, err := os.ReadFile(filename)
+	return filtered
+}
+
+// parseExpiringWithin parses the value of the expiring-within option.
+func parseExpiringWithin(value string) (time.Duration, error) {
+	return parseDaysDuration(flgExpiringWithin, value)
+}
+
+// parseDaysDuration parses the value of an option as a duration, in days (ex: 30d) or as a Go duration (ex: 72h).
+func parseDaysDuration(name, value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid value for --%s: %q", name, value)
+		}
+
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid value for --%s: %q", name, value)
+	}
+
+	return d, nil
+}
+
+// expiryStatus describes the remaining validity of a certificate.
+func expiryStatus(notAfter, now time.Time) string {
+	if !notAfter.After(now) {
+		return "(EXPIRED)"
+	}
+
+	return fmt.Sprintf("(VALID: %d days)", int(notAfter.Sub(now).Hours()/24))
+}
+
+// subjectAltNames returns the DNS names and the IP addresses of a certificate.
+func subjectAltNames(cert *x509.Certificate) []string {
+	sans := make([]string, 0, len(cert.DNSNames)+len(cert.IPAddresses))
+
+	sans = append(sans, cert.DNSNames...)
+
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	return sans
+}
+
+// keyTypeName returns the name of the key type of a public key, with the same format as the key-type option.
+func keyTypeName(publicKey any) string {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("rsa%d", key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return fmt.Sprintf("ec%d", key.Curve.Params().BitSize)
+	case ed25519.PublicKey:
+		return "ed25519"
+	default:
+		return "unknown"
+	}
+}
+
+// issuerName returns the common name of the issuer of a certificate, or its full distinguished name.
+func issuerName(cert *x509.Certificate) string {
+	if cert.Issuer.CommonName != "" {
+		return cert.Issuer.CommonName
+	}
+
+	return cert.Issuer.String()
+}
+
+func readAccountsInfo(accountsStorage *AccountsStorage) ([]listAccountResult, error) {
+	matches, err := accountsStorage.ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]listAccountResult, 0, len(matches))
+
+	for _, key := range matches {
+		data, err := accountsStorage.backend.ReadFile(key)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		var account Account
 		err = json.Unmarshal(data, &account)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		uri, err := url.Parse(account.Registration.URI)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		fmt.Println("  Email:", account.Email)
-		fmt.Println("  Server:", uri.Host)
-		fmt.Println("  Path:", filepath.Dir(filename))
-		fmt.Println()
+		accounts = append(accounts, listAccountResult{
+			Email:  account.Email,
+			Server: uri.Host,
+			Path:   accountsStorage.backend.Location(path.Dir(key)),
+		})
 	}
 
-	return nil
+	return accounts, nil
 }
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseExpiringWithin(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{value: "30d", expected: 30 * 24 * time.Hour},
+		{value: "0d", expected: 0},
+		{value: "72h", expected: 72 * time.Hour},
+		{value: "90m", expected: 90 * time.Minute},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.value, func(t *testing.T) {
+			t.Parallel()
+
+			d, err := parseExpiringWithin(test.value)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, d)
+		})
+	}
+}
+
+func Test_parseExpiringWithin_error(t *testing.T) {
+	testCases := []string{"", "d", "-1d", "30days", "-2h", "abc"}
+
+	for _, value := range testCases {
+		t.Run(value, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parseExpiringWithin(value)
+			require.Error(t, err)
+		})
+	}
+}
+
+func Test_filterExpiringCertificates(t *testing.T) {
+	now := time.Now()
+
+	certificates := []listCertificateResult{
+		{Name: "expired.example.com", NotAfter: now.Add(-24 * time.Hour)},
+		{Name: "soon.example.com", NotAfter: now.Add(10 * 24 * time.Hour)},
+		{Name: "later.example.com", NotAfter: now.Add(60 * 24 * time.Hour)},
+	}
+
+	filtered := filterExpiringCertificates(certificates, now.Add(30*24*time.Hour))
+
+	require.Len(t, filtered, 2)
+	assert.Equal(t, "expired.example.com", filtered[0].Name)
+	assert.Equal(t, "soon.example.com", filtered[1].Name)
+}
+
+func Test_keyTypeName(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	assert.Equal(t, "rsa2048", keyTypeName(&rsaKey.PublicKey))
+	assert.Equal(t, "ec384", keyTypeName(&ecKey.PublicKey))
+	assert.Equal(t, "ed25519", keyTypeName(edKey))
+	assert.Equal(t, "unknown", keyTypeName(nil))
+}
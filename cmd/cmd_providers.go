@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/providers/dns"
+	"github.com/urfave/cli/v2"
+)
+
+// Flag names.
+const (
+	flgProviderTestDomain = "domain"
+	flgProviderTestDryRun = "dry-run"
+)
+
+// providerTestToken the token of the throwaway challenge of the providers test command.
+const providerTestToken = "lego-providers-test"
+
+// providerTestResult the diagnostic summary of the providers test command.
+type providerTestResult struct {
+	Provider      string               `json:"provider"`
+	Name          string               `json:"name"`
+	Domain        string               `json:"domain"`
+	DryRun        bool                 `json:"dryRun"`
+	Documentation string               `json:"documentation"`
+	Credentials   []providerTestEnvVar `json:"credentials,omitempty"`
+	Steps         []selftestStep       `json:"steps"`
+}
+
+// providerTestEnvVar an environment variable of the credentials of a provider, the value is never displayed.
+type providerTestEnvVar struct {
+	Name string `json:"name"`
+	Set  bool   `json:"set"`
+}
+
+func createProviders() *cli.Command {
+	return &cli.Command{
+		Name:  "providers",
+		Usage: "Diagnose the DNS providers.",
+		Subcommands: []*cli.Command{
+			{
+				Name: "test",
+				Usage: "Check the credentials and the permissions of a DNS provider:" +
+					" a throwaway TXT record (_acme-challenge.<domain>) is created then deleted.",
+				Description: "The credentials are read from the environment variables, like for the '--dns' option (see 'lego dnshelp').\n" +
+					"   In dry-run mode, the provider is only created (the options and the credentials are parsed), and the zone of the domain is resolved.",
+				Action: testProvider,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    flgCode,
+						Aliases: []string{"c"},
+						Usage:   "DNS provider code. (default: the global --" + flgDNS + " option)",
+					},
+					&cli.StringFlag{
+						Name:  flgProviderTestDomain,
+						Usage: "The domain of the TXT record. The record is created in the zone of this domain.",
+					},
+					&cli.BoolFlag{
+						Name:  flgProviderTestDryRun,
+						Usage: "Do not create the TXT record.",
+					},
+				},
+			},
+		},
+	}
+}
+
+func testProvider(ctx *cli.Context) error {
+	code := ctx.String(flgCode)
+	if code == "" {
+		code = ctx.String(flgDNS)
+	}
+
+	if code == "" {
+		return fmt.Errorf("no DNS provider: use --%s (see 'lego dnshelp')", flgCode)
+	}
+
+	domain := ctx.String(flgProviderTestDomain)
+	if domain == "" {
+		return fmt.Errorf("--%s is required", flgProviderTestDomain)
+	}
+
+	result, err := runProviderTest(ctx, code, domain)
+	if err != nil {
+		return err
+	}
+
+	if isJSONOutput(ctx) {
+		err = printJSON(result)
+		if err != nil {
+			return err
+		}
+	} else {
+		printProviderTestResult(result)
+	}
+
+	for _, step := range result.Steps {
+		if step.Status == selftestFailed {
+			return fmt.Errorf("provider test failed: %s: %w", step.Name, step.err)
+		}
+	}
+
+	return nil
+}
+
+// runProviderTest runs the steps of the test of a DNS provider, the steps after a failure are skipped.
+func runProviderTest(ctx *cli.Context, code, domain string) (*providerTestResult, error) {
+	info, err := findDNSProvider(dnsProviderCatalog(), code)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Code == "manual" {
+		return nil, errors.New("the manual DNS provider cannot be tested")
+	}
+
+	result := &providerTestResult{
+		Provider:      info.Code,
+		Name:          info.Name,
+		Domain:        domain,
+		DryRun:        ctx.Bool(flgProviderTestDryRun),
+		Documentation: info.Documentation,
+		Credentials:   checkCredentials(info.Credentials),
+	}
+
+	steps := &selftestSteps{}
+
+	var provider challenge.Provider
+
+	steps.run("provider", func() (string, error) {
+		provider, err = dns.NewDNSChallengeProviderByName(code)
+		if err != nil {
+			return "", err
+		}
+
+		return info.Name, nil
+	})
+
+	keyAuth, err := newProviderTestKeyAuth()
+	if err != nil {
+		return nil, err
+	}
+
+	challengeInfo := dns01.GetChallengeInfo(domain, keyAuth)
+
+	steps.run("zone", func() (string, error) {
+		zone, err := findZone(ctx, challengeInfo.EffectiveFQDN)
+		if err != nil {
+			return "", err
+		}
+
+		return zone, nil
+	})
+
+	if result.DryRun {
+		steps.skip("present")
+		steps.skip("cleanup")
+
+		result.Steps = steps.steps
+
+		return result, nil
+	}
+
+	steps.run("present", func() (string, error) {
+		err := provider.Present(domain, providerTestToken, keyAuth)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("TXT record %s created", challengeInfo.EffectiveFQDN), nil
+	})
+
+	steps.run("cleanup", func() (string, error) {
+		err := provider.CleanUp(domain, providerTestToken, keyAuth)
+		if err != nil {
+			return "", fmt.Errorf("the TXT record %s must be deleted manually: %w", challengeInfo.EffectiveFQDN, err)
+		}
+
+		return fmt.Sprintf("TXT record %s deleted", challengeInfo.EffectiveFQDN), nil
+	})
+
+	result.Steps = steps.steps
+
+	return result, nil
+}
+
+// findZone finds the zone of the FQDN, with the resolvers defined by the dns.resolvers option.
+func findZone(ctx *cli.Context, fqdn string) (string, error) {
+	servers := ctx.StringSlice(flgDNSResolvers)
+	if len(servers) == 0 {
+		return dns01.FindZoneByFqdn(fqdn)
+	}
+
+	return dns01.FindZoneByFqdnCustom(fqdn, dns01.ParseNameservers(servers))
+}
+
+// checkCredentials returns the environment variables of the credentials, and if they are defined (directly or with the _FILE suffix).
+func checkCredentials(envVars []dnsProviderEnvVar) []providerTestEnvVar {
+	var credentials []providerTestEnvVar
+
+	for _, envVar := range envVars {
+		credentials = append(credentials, providerTestEnvVar{
+			Name: envVar.Name,
+			Set:  os.Getenv(envVar.Name) != "" || os.Getenv(envVar.Name+"_FILE") != "",
+		})
+	}
+
+	return credentials
+}
+
+// newProviderTestKeyAuth creates a random key authorization: the value of the TXT record cannot be a valid challenge.
+func newProviderTestKeyAuth() (string, error) {
+	raw := make([]byte, 32)
+
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+
+	return providerTestToken + "." + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func printProviderTestResult(result *providerTestResult) {
+	mode := "live"
+	if result.DryRun {
+		mode = "dry-run"
+	}
+
+	fmt.Printf("Test of the DNS provider %s (%s) for %s (%s):\n", result.Provider, result.Name, result.Domain, mode)
+
+	printSteps(result.Steps)
+
+	if len(result.Credentials) > 0 {
+		fmt.Println("Credentials:")
+
+		for _, envVar := range result.Credentials {
+			status := "not set"
+			if envVar.Set {
+				status = "set"
+			}
+
+			fmt.Printf("  %s: %s\n", envVar.Name, status)
+		}
+	}
+
+	fmt.Println("Documentation:", result.Documentation)
+}
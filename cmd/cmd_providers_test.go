@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func newProviderTestContext(t *testing.T, globalArgs []string, args ...string) *cli.Context {
+	t.Helper()
+
+	parent := newTestContext(t, globalArgs...)
+
+	command := createProviders().Subcommands[0]
+
+	set := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+
+	for _, f := range command.Flags {
+		require.NoError(t, f.Apply(set))
+	}
+
+	require.NoError(t, set.Parse(args))
+
+	return cli.NewContext(parent.App, set, parent)
+}
+
+func Test_runProviderTest_missingCredentials(t *testing.T) {
+	t.Setenv("EXEC_PATH", "")
+
+	ctx := newProviderTestContext(t, nil, "--domain", "example.com")
+
+	result, err := runProviderTest(ctx, "exec", "example.com")
+	require.NoError(t, err)
+
+	expected := map[string]string{
+		"provider": selftestFailed,
+		"zone":     selftestSkipped,
+		"present":  selftestSkipped,
+		"cleanup":  selftestSkipped,
+	}
+	assert.Equal(t, expected, stepStatuses(result.Steps))
+	assert.Contains(t, result.Steps[0].Detail, "EXEC_PATH")
+	assert.Equal(t, "https://go-acme.github.io/lego/dns/exec", result.Documentation)
+}
+
+func Test_runProviderTest_dryRun(t *testing.T) {
+	t.Setenv("EXEC_PATH", "")
+
+	ctx := newProviderTestContext(t, nil, "--domain", "example.com", "--dry-run")
+
+	result, err := runProviderTest(ctx, "exec", "example.com")
+	require.NoError(t, err)
+
+	assert.True(t, result.DryRun)
+
+	expected := map[string]string{
+		"provider": selftestFailed,
+		"zone":     selftestSkipped,
+		"present":  selftestSkipped,
+		"cleanup":  selftestSkipped,
+	}
+	assert.Equal(t, expected, stepStatuses(result.Steps))
+}
+
+func Test_checkCredentials(t *testing.T) {
+	t.Setenv("LEGO_TEST_API_KEY", "")
+	t.Setenv("LEGO_TEST_API_SECRET", "")
+	t.Setenv("LEGO_TEST_API_SECRET_FILE", "/run/secrets/api_secret")
+	t.Setenv("LEGO_TEST_API_USER", "user")
+
+	envVars := []dnsProviderEnvVar{
+		{Name: "LEGO_TEST_API_KEY"},
+		{Name: "LEGO_TEST_API_SECRET"},
+		{Name: "LEGO_TEST_API_USER"},
+	}
+
+	expected := []providerTestEnvVar{
+		{Name: "LEGO_TEST_API_KEY"},
+		{Name: "LEGO_TEST_API_SECRET", Set: true},
+		{Name: "LEGO_TEST_API_USER", Set: true},
+	}
+
+	assert.Equal(t, expected, checkCredentials(envVars))
+}
+
+func Test_runProviderTest_errors(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		code        string
+		expectedErr string
+	}{
+		{
+			desc:        "unknown provider",
+			code:        "unknown",
+			expectedErr: `"unknown" is not yet supported`,
+		},
+		{
+			desc:        "manual provider",
+			code:        "manual",
+			expectedErr: "the manual DNS provider cannot be tested",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := newProviderTestContext(t, nil, "--domain", "example.com")
+
+			_, err := runProviderTest(ctx, test.code, "example.com")
+			require.EqualError(t, err, test.expectedErr)
+		})
+	}
+}
+
+func Test_testProvider_missingOptions(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		globalArgs  []string
+		args        []string
+		expectedErr string
+	}{
+		{
+			desc:        "no provider",
+			args:        []string{"--domain", "example.com"},
+			expectedErr: "no DNS provider: use --code (see 'lego dnshelp')",
+		},
+		{
+			desc:        "no domain",
+			globalArgs:  []string{"--dns", "exec"},
+			expectedErr: "--domain is required",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := newProviderTestContext(t, test.globalArgs, test.args...)
+
+			err := testProvider(ctx)
+			require.EqualError(t, err, test.expectedErr)
+		})
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"crypto"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"math/rand"
 	"os"
 	"slices"
+	"sync"
 	"time"
 
 	"github.com/go-acme/lego/v4/acme/api"
@@ -24,18 +26,35 @@ const (
 	flgARIDisable             = "ari-disable"
 	flgARIWaitToRenewDuration = "ari-wait-to-renew-duration"
 	flgReuseKey               = "reuse-key"
+	flgKeyPolicy              = "key-policy"
+	flgKeyPolicyRotateEvery   = "key-policy.rotate-every"
 	flgRenewHook              = "renew-hook"
 	flgRenewHookTimeout       = "renew-hook-timeout"
+	flgRenewHookOnNoop        = "renew-hook-on-noop"
 	flgNoRandomSleep          = "no-random-sleep"
+	flgRenewJitter            = "renew-jitter"
 	flgForceCertDomains       = "force-cert-domains"
 )
 
+// renewalRandomSleep the random delay before the first renewal of an invocation.
+var renewalRandomSleep sync.Once
+
 func createRenew() *cli.Command {
 	return &cli.Command{
 		Name:   "renew",
 		Usage:  "Renew a certificate",
 		Action: renew,
+		After:  writeMetricsTextfile,
 		Before: func(ctx *cli.Context) error {
+			certificates, err := getCertificates(ctx)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if len(certificates) > 0 {
+				return nil
+			}
+
 			// we require either domains or csr, but not both
 			hasDomains := len(ctx.StringSlice(flgDomains)) > 0
 			hasCsr := ctx.String(flgCSR) != ""
@@ -50,11 +69,12 @@ func createRenew() *cli.Command {
 			}
 			return nil
 		},
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.IntFlag{
 				Name:  flgDays,
 				Value: 30,
-				Usage: "The number of days left on a certificate to renew it.",
+				Usage: "The number of days left on a certificate to renew it." +
+					" When the CA provides renewal information (ARI), the renewal information decides, unless this option is explicitly defined.",
 			},
 			&cli.BoolFlag{
 				Name:  flgARIDisable,
@@ -66,7 +86,19 @@ func createRenew() *cli.Command {
 			},
 			&cli.BoolFlag{
 				Name:  flgReuseKey,
-				Usage: "Used to indicate you want to reuse your current private key for the new certificate.",
+				Usage: fmt.Sprintf("(deprecated) use --%s=%s instead.", flgKeyPolicy, certcrypto.KeyPolicyReuseExisting),
+			},
+			&cli.StringFlag{
+				Name: flgKeyPolicy,
+				Usage: "The key management policy of the renewals:" +
+					" 'always-new' (a new private key for each renewal), 'reuse-existing' (reuse the current private key)," +
+					" or 'rotate' (reuse the current private key, and use a new private key every N renewals).",
+				Value: string(certcrypto.KeyPolicyAlwaysNew),
+			},
+			&cli.IntFlag{
+				Name:  flgKeyPolicyRotateEvery,
+				Usage: "The number of renewals between two key rotations (only with the 'rotate' key management policy).",
+				Value: 3,
 			},
 			&cli.BoolFlag{
 				Name:  flgNoBundle,
@@ -87,10 +119,10 @@ func createRenew() *cli.Command {
 				Usage:  "Set the notAfter field in the certificate (RFC3339 format)",
 				Layout: time.RFC3339,
 			},
+			createPreferredChainFlag(),
 			&cli.StringFlag{
-				Name: flgPreferredChain,
-				Usage: "If the CA offers multiple certificate chains, prefer the chain with an issuer matching this Subject Common Name." +
-					" If no match, the default offered chain will be used.",
+				Name:  flgProfile,
+				Usage: "If the CA offers multiple certificate profiles (draft-aaron-acme-profiles), choose this one.",
 			},
 			&cli.StringFlag{
 				Name:  flgAlwaysDeactivateAuthorizations,
@@ -105,42 +137,112 @@ func createRenew() *cli.Command {
 				Usage: "Define the timeout for the hook execution.",
 				Value: 2 * time.Minute,
 			},
+			&cli.BoolFlag{
+				Name: flgRenewHookOnNoop,
+				Usage: "Also execute the renew hook when the certificate is not renewed (the event of the hook context is 'noop')," +
+					" ex: to send a heartbeat to a monitoring system.",
+			},
 			&cli.BoolFlag{
 				Name: flgNoRandomSleep,
 				Usage: "Do not add a random sleep before the renewal." +
 					" We do not recommend using this flag if you are doing your renewals in an automated way.",
 			},
+			&cli.DurationFlag{
+				Name: flgRenewJitter,
+				Usage: "The maximum random sleep before the renewal (only for the non-interactive renewals)." +
+					" A larger window spreads the renewals triggered by a cron job at a fixed time.",
+				Value: 8 * time.Minute,
+			},
 			&cli.BoolFlag{
 				Name:  flgForceCertDomains,
 				Usage: "Check and ensure that the cert's domain list matches those passed in the domains argument.",
 			},
-		},
+		}, createCertificatesFlags()...),
 	}
 }
 
 func renew(ctx *cli.Context) error {
-	account, keyType := setupAccount(ctx, NewAccountsStorage(ctx))
+	withThrottlingRecorder(ctx)
+
+	certificates, err := getCertificates(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(certificates) > 0 {
+		accounts := newAccountsCache(func(accountCtx *cli.Context) (*Account, error) {
+			accountsStorage, err := createAccountsStorage(accountCtx, accountCtx.String(flgServer))
+			if err != nil {
+				return nil, err
+			}
+
+			account, _, err := setupAccount(accountCtx, accountsStorage)
+			if err != nil {
+				return nil, err
+			}
+
+			if account.Registration == nil {
+				return nil, fmt.Errorf("account %s is not registered, use 'run' to register a new account", account.Email)
+			}
+
+			return account, nil
+		})
+
+		return runCertificates(ctx, certificates, func(certCtx *cli.Context) error {
+			account, err := accounts.get(certCtx)
+			if err != nil {
+				return err
+			}
+
+			keyType, err := parseKeyType(certCtx)
+			if err != nil {
+				return err
+			}
+
+			return renewCertificate(certCtx, account, keyType)
+		})
+	}
+
+	account, keyType, err := setupAccount(ctx, NewAccountsStorage(ctx))
+	if err != nil {
+		return err
+	}
 
 	if account.Registration == nil {
 		log.Fatalf("Account %s is not registered. Use 'run' to register a new account.\n", account.Email)
 	}
 
-	certsStorage := NewCertificatesStorage(ctx)
+	return renewCertificate(ctx, account, keyType)
+}
+
+// renewCertificate renews a certificate if needed.
+func renewCertificate(ctx *cli.Context, account *Account, keyType certcrypto.KeyType) error {
+	certsStorage, err := newCertificatesStorage(ctx)
+	if err != nil {
+		reportFailure(ctx, notifyEventRenew, certificateName(ctx), err)
+		return err
+	}
 
 	bundle := !ctx.Bool(flgNoBundle)
 
 	meta := map[string]string{hookEnvAccountEmail: account.Email}
 
-	// CSR
+	var certRes *certificate.Resource
+
 	if ctx.IsSet(flgCSR) {
-		return renewForCSR(ctx, account, keyType, certsStorage, bundle, meta)
+		// CSR
+		certRes, err = renewForCSR(ctx, account, keyType, certsStorage, bundle, meta)
+	} else {
+		// Domains
+		certRes, err = renewForDomains(ctx, account, keyType, certsStorage, bundle, meta)
 	}
 
-	// Domains
-	return renewForDomains(ctx, account, keyType, certsStorage, bundle, meta)
+	reportOutcome(ctx, notifyEventRenew, certificateName(ctx), certRes, err)
+
+	return err
 }
 
-func renewForDomains(ctx *cli.Context, account *Account, keyType certcrypto.KeyType, certsStorage *CertificatesStorage, bundle bool, meta map[string]string) error {
+func renewForDomains(ctx *cli.Context, account *Account, keyType certcrypto.KeyType, certsStorage *CertificatesStorage, bundle bool, meta map[string]string) (*certificate.Resource, error) {
 	domains := ctx.StringSlice(flgDomains)
 	domain := domains[0]
 
@@ -149,33 +251,40 @@ func renewForDomains(ctx *cli.Context, account *Account, keyType certcrypto.KeyT
 	// as web servers would not be able to work with a combined file.
 	certificates, err := certsStorage.ReadCertificate(domain, certExt)
 	if err != nil {
-		log.Fatalf("Error while loading the certificate for domain %s\n\t%v", domain, err)
+		return nil, &storageError{err: fmt.Errorf("error while loading the certificate for domain %s: %w", domain, err)}
 	}
 
 	cert := certificates[0]
+	if cert.IsCA {
+		return nil, fmt.Errorf("[%s] certificate bundle starts with a CA certificate", domain)
+	}
 
 	var ariRenewalTime *time.Time
+	var renewalInfo *certificate.RenewalInfoResponse
 	var replacesCertID string
 
 	var client *lego.Client
 
 	if !ctx.Bool(flgARIDisable) {
-		client = setupClient(ctx, account, keyType)
+		client, err = setupCertificateClient(ctx, account, keyType)
+		if err != nil {
+			return nil, err
+		}
 
-		ariRenewalTime = getARIRenewalTime(ctx, cert, domain, client)
+		ariRenewalTime, renewalInfo = getARIRenewalTime(ctx, cert, domain, client)
 		if ariRenewalTime != nil {
 			now := time.Now().UTC()
 
 			// Figure out if we need to sleep before renewing.
 			if ariRenewalTime.After(now) {
-				log.Infof("[%s] Sleeping %s until renewal time %s", domain, ariRenewalTime.Sub(now), ariRenewalTime)
+				log.WithDomain(domain).Infof("Sleeping %s until renewal time %s", ariRenewalTime.Sub(now), ariRenewalTime)
 				time.Sleep(ariRenewalTime.Sub(now))
 			}
 		}
 
 		replacesCertID, err = certificate.MakeARICertID(cert)
 		if err != nil {
-			log.Fatalf("Error while construction the ARI CertID for domain %s\n\t%v", domain, err)
+			return nil, fmt.Errorf("error while constructing the ARI CertID for domain %s: %w", domain, err)
 		}
 	}
 
@@ -183,43 +292,42 @@ func renewForDomains(ctx *cli.Context, account *Account, keyType certcrypto.KeyT
 
 	certDomains := certcrypto.ExtractDomains(cert)
 
-	if ariRenewalTime == nil && !needRenewal(cert, domain, ctx.Int(flgDays)) &&
-		(!forceDomains || slices.Equal(certDomains, domains)) {
-		return nil
+	renew := shouldRenew(ctx, cert, domain, ariRenewalTime, renewalInfo != nil)
+
+	if !renew && (!forceDomains || slices.Equal(certDomains, domains)) {
+		refreshOCSPStaple(ctx, certsStorage, domain, false)
+		return nil, notRenewed(ctx, certsStorage, domain, cert, meta)
 	}
 
 	if client == nil {
-		client = setupClient(ctx, account, keyType)
+		client, err = setupCertificateClient(ctx, account, keyType)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// This is just meant to be informal for the user.
 	timeLeft := cert.NotAfter.Sub(time.Now().UTC())
-	log.Infof("[%s] acme: Trying renewal with %d hours remaining", domain, int(timeLeft.Hours()))
+	log.WithDomain(domain).Infof("acme: Trying renewal with %d hours remaining", int(timeLeft.Hours()))
 
 	var privateKey crypto.PrivateKey
-	if ctx.Bool(flgReuseKey) {
-		keyBytes, errR := certsStorage.ReadFile(domain, keyExt)
-		if errR != nil {
-			log.Fatalf("Error while loading the private key for domain %s\n\t%v", domain, errR)
-		}
 
-		privateKey, errR = certcrypto.ParsePEMPrivateKey(keyBytes)
+	keyPolicy := getKeyPolicy(ctx)
+	keyRenewals := certsStorage.ReadKeyRenewals(domain)
+
+	if keyPolicy.ShouldReuse(keyRenewals) {
+		log.WithDomain(domain).Infof("key policy %s: reusing the private key (already used for %d renewals)", keyPolicy, keyRenewals)
+
+		var errR error
+		privateKey, errR = certsStorage.ReadPrivateKey(domain)
 		if errR != nil {
-			return errR
+			return nil, &storageError{err: fmt.Errorf("error while loading the private key for domain %s: %w", domain, errR)}
 		}
+	} else {
+		log.WithDomain(domain).Infof("key policy %s: using a new private key (the previous key was used for %d renewals)", keyPolicy, keyRenewals)
 	}
 
-	// https://github.com/go-acme/lego/issues/1656
-	// https://github.com/certbot/certbot/blob/284023a1b7672be2bd4018dd7623b3b92197d4b0/certbot/certbot/_internal/renewal.py#L435-L440
-	if !isatty.IsTerminal(os.Stdout.Fd()) && !ctx.Bool(flgNoRandomSleep) {
-		// https://github.com/certbot/certbot/blob/284023a1b7672be2bd4018dd7623b3b92197d4b0/certbot/certbot/_internal/renewal.py#L472
-		const jitter = 8 * time.Minute
-		rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
-		sleepTime := time.Duration(rnd.Int63n(int64(jitter)))
-
-		log.Infof("renewal: random delay of %s", sleepTime)
-		time.Sleep(sleepTime)
-	}
+	randomSleep(ctx)
 
 	renewalDomains := domains
 	if !forceDomains {
@@ -234,6 +342,7 @@ func renewForDomains(ctx *cli.Context, account *Account, keyType certcrypto.KeyT
 		NotAfter:                       getTime(ctx, flgNotAfter),
 		Bundle:                         bundle,
 		PreferredChain:                 ctx.String(flgPreferredChain),
+		Profile:                        ctx.String(flgProfile),
 		AlwaysDeactivateAuthorizations: ctx.Bool(flgAlwaysDeactivateAuthorizations),
 	}
 
@@ -241,27 +350,49 @@ func renewForDomains(ctx *cli.Context, account *Account, keyType certcrypto.KeyT
 		request.ReplacesCertID = replacesCertID
 	}
 
-	certRes, err := client.Certificate.Obtain(request)
+	started := time.Now()
+
+	certRes, err := setupFailover(ctx, client, keyType).Obtain(request)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	certsStorage.SaveResource(certRes)
+	err = certsStorage.saveResource(certRes)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshOCSPStaple(ctx, certsStorage, domain, true)
 
 	addPathToMetadata(meta, domain, certRes, certsStorage)
 
-	return launchHook(ctx.String(flgRenewHook), ctx.Duration(flgRenewHookTimeout), meta)
+	if err = printCertificateResult(ctx, certRes, meta); err != nil {
+		return nil, err
+	}
+
+	if err = deployCertificate(ctx, certRes, meta); err != nil {
+		return nil, err
+	}
+
+	reason := renewalReason(ariRenewalTime)
+	if !renew {
+		reason = renewalReasonDomains
+	}
+
+	hookCtx := newHookContext(notifyEventRenew, certRes, meta).withRenewal(reason, renewalInfo).withTimings(started)
+
+	return certRes, launchHook(ctx.String(flgRenewHook), ctx.Duration(flgRenewHookTimeout), meta, hookCtx)
 }
 
-func renewForCSR(ctx *cli.Context, account *Account, keyType certcrypto.KeyType, certsStorage *CertificatesStorage, bundle bool, meta map[string]string) error {
+func renewForCSR(ctx *cli.Context, account *Account, keyType certcrypto.KeyType, certsStorage *CertificatesStorage, bundle bool, meta map[string]string) (*certificate.Resource, error) {
 	csr, err := readCSRFile(ctx.String(flgCSR))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	domain, err := certcrypto.GetCSRMainDomain(csr)
 	if err != nil {
-		log.Fatalf("Error: %v", err)
+		return nil, err
 	}
 
 	// load the cert resource from files.
@@ -269,47 +400,60 @@ func renewForCSR(ctx *cli.Context, account *Account, keyType certcrypto.KeyType,
 	// as web servers would not be able to work with a combined file.
 	certificates, err := certsStorage.ReadCertificate(domain, certExt)
 	if err != nil {
-		log.Fatalf("Error while loading the certificate for domain %s\n\t%v", domain, err)
+		return nil, &storageError{err: fmt.Errorf("error while loading the certificate for domain %s: %w", domain, err)}
 	}
 
 	cert := certificates[0]
+	if cert.IsCA {
+		return nil, fmt.Errorf("[%s] certificate bundle starts with a CA certificate", domain)
+	}
 
 	var ariRenewalTime *time.Time
+	var renewalInfo *certificate.RenewalInfoResponse
 	var replacesCertID string
 
 	var client *lego.Client
 
 	if !ctx.Bool(flgARIDisable) {
-		client = setupClient(ctx, account, keyType)
+		client, err = setupCertificateClient(ctx, account, keyType)
+		if err != nil {
+			return nil, err
+		}
 
-		ariRenewalTime = getARIRenewalTime(ctx, cert, domain, client)
+		ariRenewalTime, renewalInfo = getARIRenewalTime(ctx, cert, domain, client)
 		if ariRenewalTime != nil {
 			now := time.Now().UTC()
 
 			// Figure out if we need to sleep before renewing.
 			if ariRenewalTime.After(now) {
-				log.Infof("[%s] Sleeping %s until renewal time %s", domain, ariRenewalTime.Sub(now), ariRenewalTime)
+				log.WithDomain(domain).Infof("Sleeping %s until renewal time %s", ariRenewalTime.Sub(now), ariRenewalTime)
 				time.Sleep(ariRenewalTime.Sub(now))
 			}
 		}
 
 		replacesCertID, err = certificate.MakeARICertID(cert)
 		if err != nil {
-			log.Fatalf("Error while construction the ARI CertID for domain %s\n\t%v", domain, err)
+			return nil, fmt.Errorf("error while constructing the ARI CertID for domain %s: %w", domain, err)
 		}
 	}
 
-	if ariRenewalTime == nil && !needRenewal(cert, domain, ctx.Int(flgDays)) {
-		return nil
+	if !shouldRenew(ctx, cert, domain, ariRenewalTime, renewalInfo != nil) {
+		refreshOCSPStaple(ctx, certsStorage, domain, false)
+		return nil, notRenewed(ctx, certsStorage, domain, cert, meta)
 	}
 
 	if client == nil {
-		client = setupClient(ctx, account, keyType)
+		client, err = setupCertificateClient(ctx, account, keyType)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// This is just meant to be informal for the user.
 	timeLeft := cert.NotAfter.Sub(time.Now().UTC())
-	log.Infof("[%s] acme: Trying renewal with %d hours remaining", domain, int(timeLeft.Hours()))
+	log.WithDomain(domain).Infof("acme: Trying renewal with %d hours remaining", int(timeLeft.Hours()))
+
+	randomSleep(ctx)
 
 	request := certificate.ObtainForCSRRequest{
 		CSR:                            csr,
@@ -317,6 +461,7 @@ func renewForCSR(ctx *cli.Context, account *Account, keyType certcrypto.KeyType,
 		NotAfter:                       getTime(ctx, flgNotAfter),
 		Bundle:                         bundle,
 		PreferredChain:                 ctx.String(flgPreferredChain),
+		Profile:                        ctx.String(flgProfile),
 		AlwaysDeactivateAuthorizations: ctx.Bool(flgAlwaysDeactivateAuthorizations),
 	}
 
@@ -324,28 +469,127 @@ func renewForCSR(ctx *cli.Context, account *Account, keyType certcrypto.KeyType,
 		request.ReplacesCertID = replacesCertID
 	}
 
-	certRes, err := client.Certificate.ObtainForCSR(request)
+	started := time.Now()
+
+	certRes, err := setupFailover(ctx, client, keyType).ObtainForCSR(request)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+
+	err = certsStorage.saveResource(certRes)
+	if err != nil {
+		return nil, err
 	}
 
-	certsStorage.SaveResource(certRes)
+	refreshOCSPStaple(ctx, certsStorage, domain, true)
 
 	addPathToMetadata(meta, domain, certRes, certsStorage)
 
-	return launchHook(ctx.String(flgRenewHook), ctx.Duration(flgRenewHookTimeout), meta)
+	if err = printCertificateResult(ctx, certRes, meta); err != nil {
+		return nil, err
+	}
+
+	if err = deployCertificate(ctx, certRes, meta); err != nil {
+		return nil, err
+	}
+
+	hookCtx := newHookContext(notifyEventRenew, certRes, meta).
+		withRenewal(renewalReason(ariRenewalTime), renewalInfo).
+		withTimings(started)
+
+	return certRes, launchHook(ctx.String(flgRenewHook), ctx.Duration(flgRenewHookTimeout), meta, hookCtx)
 }
 
-func needRenewal(x509Cert *x509.Certificate, domain string, days int) bool {
-	if x509Cert.IsCA {
-		log.Fatalf("[%s] Certificate bundle starts with a CA certificate", domain)
+// randomSleep sleeps a random duration (up to renew-jitter) before the first renewal of an invocation,
+// to spread the load of the automated renewals on the CA.
+func randomSleep(ctx *cli.Context) {
+	// https://github.com/go-acme/lego/issues/1656
+	// https://github.com/certbot/certbot/blob/284023a1b7672be2bd4018dd7623b3b92197d4b0/certbot/certbot/_internal/renewal.py#L435-L440
+	if isatty.IsTerminal(os.Stdout.Fd()) || ctx.Bool(flgNoRandomSleep) {
+		return
 	}
 
+	// The random delay is shared by the certificates of an invocation.
+	renewalRandomSleep.Do(func() {
+		// https://github.com/certbot/certbot/blob/284023a1b7672be2bd4018dd7623b3b92197d4b0/certbot/certbot/_internal/renewal.py#L472
+		jitter := ctx.Duration(flgRenewJitter)
+		if jitter <= 0 {
+			return
+		}
+
+		rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+		sleepTime := time.Duration(rnd.Int63n(int64(jitter)))
+
+		log.Infof("renewal: random delay of %s", sleepTime)
+		time.Sleep(sleepTime)
+	})
+}
+
+// notRenewed handles a certificate that does not need to be renewed:
+// the attempt is recorded in the history, the result is printed, and the renew hook is executed with the noop event if renew-hook-on-noop is defined.
+func notRenewed(ctx *cli.Context, certsStorage *CertificatesStorage, domain string, cert *x509.Certificate, meta map[string]string) error {
+	recordNotDueHistory(ctx, domain, cert)
+
+	err := printNotRenewedResult(ctx, domain, cert)
+	if err != nil {
+		return err
+	}
+
+	if !ctx.Bool(flgRenewHookOnNoop) {
+		return nil
+	}
+
+	certRes := &certificate.Resource{Domain: domain}
+
+	certRes.Certificate, err = certsStorage.ReadFile(domain, certExt)
+	if err != nil {
+		return err
+	}
+
+	if certsStorage.ExistsFile(domain, issuerExt) {
+		certRes.IssuerCertificate, err = certsStorage.ReadFile(domain, issuerExt)
+		if err != nil {
+			return err
+		}
+	}
+
+	addPathToMetadata(meta, domain, certRes, certsStorage)
+
+	hookCtx := newHookContext(hookEventNoop, certRes, meta)
+
+	return launchHook(ctx.String(flgRenewHook), ctx.Duration(flgRenewHookTimeout), meta, hookCtx)
+}
+
+// shouldRenew returns true if the certificate must be renewed.
+// The renewal information (ARI) of the CA decides when it is available,
+// the number of days left is only used when ARI is unavailable (or disabled), or when the days option is explicitly defined.
+func shouldRenew(ctx *cli.Context, cert *x509.Certificate, domain string, ariRenewalTime *time.Time, ariAvailable bool) bool {
+	if ariRenewalTime != nil {
+		return true
+	}
+
+	if ariAvailable && !ctx.IsSet(flgDays) {
+		return false
+	}
+
+	return needRenewal(cert, domain, ctx.Int(flgDays))
+}
+
+// renewalReason returns the reason of a renewal decided by shouldRenew.
+func renewalReason(ariRenewalTime *time.Time) string {
+	if ariRenewalTime != nil {
+		return renewalReasonARI
+	}
+
+	return renewalReasonExpiration
+}
+
+func needRenewal(x509Cert *x509.Certificate, domain string, days int) bool {
 	if days >= 0 {
 		notAfter := int(time.Until(x509Cert.NotAfter).Hours() / 24.0)
 		if notAfter > days {
-			log.Printf("[%s] The certificate expires in %d days, the number of days defined to perform the renewal is %d: no renewal.",
-				domain, notAfter, days)
+			log.WithDomain(domain).Printf("The certificate expires in %d days, the number of days defined to perform the renewal is %d: no renewal.",
+				notAfter, days)
 			return false
 		}
 	}
@@ -354,35 +598,39 @@ func needRenewal(x509Cert *x509.Certificate, domain string, days int) bool {
 }
 
 // getARIRenewalTime checks if the certificate needs to be renewed using the renewalInfo endpoint.
-func getARIRenewalTime(ctx *cli.Context, cert *x509.Certificate, domain string, client *lego.Client) *time.Time {
-	if cert.IsCA {
-		log.Fatalf("[%s] Certificate bundle starts with a CA certificate", domain)
-	}
-
+// The renewal information is nil if it is unavailable.
+func getARIRenewalTime(ctx *cli.Context, cert *x509.Certificate, domain string, client *lego.Client) (*time.Time, *certificate.RenewalInfoResponse) {
 	renewalInfo, err := client.Certificate.GetRenewalInfo(certificate.RenewalInfoRequest{Cert: cert})
 	if err != nil {
 		if errors.Is(err, api.ErrNoARI) {
 			// The server does not advertise a renewal info endpoint.
-			log.Warnf("[%s] acme: %v", domain, err)
-			return nil
+			log.WithDomain(domain).Warnf("acme: %v", err)
+			return nil, nil
 		}
-		log.Warnf("[%s] acme: calling renewal info endpoint: %v", domain, err)
-		return nil
+		log.WithDomain(domain).Warnf("acme: calling renewal info endpoint: %v", err)
+		return nil, nil
 	}
 
 	now := time.Now().UTC()
+
+	if renewalInfo.SuggestedWindow.End.Before(now) {
+		// The suggested window is in the past: the certificate should be replaced immediately (ex: revocation).
+		log.WithDomain(domain).Infof("acme: renewalInfo endpoint indicates that the certificate must be replaced")
+	}
+
 	renewalTime := renewalInfo.ShouldRenewAt(now, ctx.Duration(flgARIWaitToRenewDuration))
 	if renewalTime == nil {
-		log.Infof("[%s] acme: renewalInfo endpoint indicates that renewal is not needed", domain)
-		return nil
+		log.WithDomain(domain).Infof("acme: renewalInfo endpoint indicates that renewal is not needed (suggested window: %s - %s)",
+			renewalInfo.SuggestedWindow.Start.UTC(), renewalInfo.SuggestedWindow.End.UTC())
+		return nil, renewalInfo
 	}
-	log.Infof("[%s] acme: renewalInfo endpoint indicates that renewal is needed", domain)
+	log.WithDomain(domain).Infof("acme: renewalInfo endpoint indicates that renewal is needed")
 
 	if renewalInfo.ExplanationURL != "" {
-		log.Infof("[%s] acme: renewalInfo endpoint provided an explanation: %s", domain, renewalInfo.ExplanationURL)
+		log.WithDomain(domain).Infof("acme: renewalInfo endpoint provided an explanation: %s", renewalInfo.ExplanationURL)
 	}
 
-	return renewalTime
+	return renewalTime, renewalInfo
 }
 
 func merge(prevDomains, nextDomains []string) []string {
@@ -396,3 +644,37 @@ func merge(prevDomains, nextDomains []string) []string {
 
 	return prevDomains
 }
+
+// refreshOCSPStaple writes the OCSP staple file (.ocsp) of the certificate, or refreshes it if needed (see --ocsp-staple).
+// The errors are only logged: the OCSP responders must not prevent the issuance or the renewal of the certificates.
+func refreshOCSPStaple(ctx *cli.Context, certsStorage *CertificatesStorage, domain string, force bool) {
+	if !certsStorage.ocspStaple {
+		return
+	}
+
+	updated, err := certsStorage.UpdateOCSPStaple(domain, setupOCSPChecker(ctx), force)
+	if err != nil {
+		log.WithDomain(domain).Warnf("Unable to update the OCSP staple: %v", err)
+		return
+	}
+
+	if updated {
+		log.WithDomain(domain).Infof("The OCSP staple has been updated.")
+	}
+}
+
+// getKeyPolicy returns the key management policy of the renewals (the deprecated "reuse-key" option overrides it).
+func getKeyPolicy(ctx *cli.Context) certcrypto.KeyPolicy {
+	if ctx.Bool(flgReuseKey) {
+		log.Printf("The flag '%s' is deprecated use '--%s=%s' instead.", flgReuseKey, flgKeyPolicy, certcrypto.KeyPolicyReuseExisting)
+
+		return certcrypto.KeyPolicy{Mode: certcrypto.KeyPolicyReuseExisting}
+	}
+
+	keyPolicy, err := certcrypto.NewKeyPolicy(certcrypto.KeyPolicyMode(ctx.String(flgKeyPolicy)), ctx.Int(flgKeyPolicyRotateEvery))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return keyPolicy
+}
@@ -1,11 +1,20 @@
 package cmd
 
 import (
+	"crypto/rsa"
 	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
+	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
 )
 
 func Test_merge(t *testing.T) {
@@ -114,3 +123,122 @@ func Test_needRenewal(t *testing.T) {
 		})
 	}
 }
+
+func Test_shouldRenew(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		desc           string
+		args           []string
+		notAfter       time.Time
+		ariRenewalTime *time.Time
+		ariAvailable   bool
+		expected       bool
+	}{
+		{
+			desc:           "ARI: renewal needed",
+			notAfter:       now.Add(60 * 24 * time.Hour),
+			ariRenewalTime: &now,
+			ariAvailable:   true,
+			expected:       true,
+		},
+		{
+			desc:         "ARI: renewal not needed, inside the default days",
+			notAfter:     now.Add(20 * 24 * time.Hour),
+			ariAvailable: true,
+			expected:     false,
+		},
+		{
+			desc:         "ARI: renewal not needed, inside the explicit days",
+			args:         []string{"--days", "45"},
+			notAfter:     now.Add(40 * 24 * time.Hour),
+			ariAvailable: true,
+			expected:     true,
+		},
+		{
+			desc:     "ARI unavailable: inside the default days",
+			notAfter: now.Add(20 * 24 * time.Hour),
+			expected: true,
+		},
+		{
+			desc:     "ARI unavailable: outside the default days",
+			notAfter: now.Add(40 * 24 * time.Hour),
+			expected: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			set := flag.NewFlagSet("renew", flag.ContinueOnError)
+
+			for _, f := range createRenew().Flags {
+				require.NoError(t, f.Apply(set))
+			}
+
+			require.NoError(t, set.Parse(test.args))
+
+			ctx := cli.NewContext(cli.NewApp(), set, nil)
+
+			actual := shouldRenew(ctx, &x509.Certificate{NotAfter: test.notAfter}, "foo.com", test.ariRenewalTime, test.ariAvailable)
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func Test_notRenewed_hookOnNoop(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook is a shell script")
+	}
+
+	certsStorage, _, _ := newTestCertificatesStorage(t)
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := certcrypto.GeneratePemCert(privateKey.(*rsa.PrivateKey), "example.com", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, certsStorage.WriteFile("example.com", certExt, certPEM))
+
+	cert, err := certcrypto.ParsePEMCertificate(certPEM)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+
+	script := filepath.Join(dir, "hook.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\ncat > \"$HOOK_OUTPUT\"\n"), 0o700))
+
+	output := filepath.Join(dir, "context.json")
+
+	set := flag.NewFlagSet("renew", flag.ContinueOnError)
+
+	for _, f := range append(CreateFlags(t.TempDir()), createRenew().Flags...) {
+		require.NoError(t, f.Apply(set))
+	}
+
+	ctx := cli.NewContext(cli.NewApp(), set, nil)
+
+	meta := map[string]string{"HOOK_OUTPUT": output, hookEnvAccountEmail: "you@example.com"}
+
+	// Without renew-hook-on-noop, the hook is not executed.
+	require.NoError(t, set.Parse([]string{"--renew-hook", script}))
+	require.NoError(t, notRenewed(ctx, certsStorage, "example.com", cert, meta))
+	assert.NoFileExists(t, output)
+
+	require.NoError(t, set.Parse([]string{"--renew-hook", script, "--renew-hook-on-noop"}))
+	require.NoError(t, notRenewed(ctx, certsStorage, "example.com", cert, meta))
+
+	raw, err := os.ReadFile(output)
+	require.NoError(t, err)
+
+	var actual hookContext
+	require.NoError(t, json.Unmarshal(raw, &actual))
+
+	assert.Equal(t, hookEventNoop, actual.Event)
+	assert.Equal(t, "you@example.com", actual.Account)
+	assert.Equal(t, certcrypto.ExtractDomains(cert), actual.Domains)
+	assert.Equal(t, cert.NotAfter.UTC(), actual.NotAfter.UTC())
+	assert.Empty(t, actual.Reason)
+	assert.Equal(t, certsStorage.GetFileName("example.com", certExt), actual.Files["certificate"])
+}
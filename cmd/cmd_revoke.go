@@ -1,17 +1,47 @@
 package cmd
 
 import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
 	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/lego"
 	"github.com/go-acme/lego/v4/log"
 	"github.com/urfave/cli/v2"
 )
 
 // Flag names.
 const (
-	flgKeep   = "keep"
-	flgReason = "reason"
+	flgKeep     = "keep"
+	flgReason   = "reason"
+	flgMatch    = "match"
+	flgCertFile = "cert-file"
+	flgReplaced = "ari-replaced"
 )
 
+// revocationReasons the revocation reasons, by name.
+// https://www.rfc-editor.org/rfc/rfc5280.html#section-5.3.1
+var revocationReasons = map[string]uint{
+	"unspecified":          acme.CRLReasonUnspecified,
+	"keyCompromise":        acme.CRLReasonKeyCompromise,
+	"cACompromise":         acme.CRLReasonCACompromise,
+	"affiliationChanged":   acme.CRLReasonAffiliationChanged,
+	"superseded":           acme.CRLReasonSuperseded,
+	"cessationOfOperation": acme.CRLReasonCessationOfOperation,
+	"certificateHold":      acme.CRLReasonCertificateHold,
+	"removeFromCRL":        acme.CRLReasonRemoveFromCRL,
+	"privilegeWithdrawn":   acme.CRLReasonPrivilegeWithdrawn,
+	"aACompromise":         acme.CRLReasonAACompromise,
+}
+
 func createRevoke() *cli.Command {
 	return &cli.Command{
 		Name:   "revoke",
@@ -23,62 +53,438 @@ func createRevoke() *cli.Command {
 				Aliases: []string{"k"},
 				Usage:   "Keep the certificates after the revocation instead of archiving them.",
 			},
-			&cli.UintFlag{
+			&cli.StringFlag{
 				Name: flgReason,
-				Usage: "Identifies the reason for the certificate revocation." +
+				Usage: "Identifies the reason for the certificate revocation, by name or by code." +
 					" See https://www.rfc-editor.org/rfc/rfc5280.html#section-5.3.1." +
 					" Valid values are:" +
 					" 0 (unspecified), 1 (keyCompromise), 2 (cACompromise), 3 (affiliationChanged)," +
 					" 4 (superseded), 5 (cessationOfOperation), 6 (certificateHold), 8 (removeFromCRL)," +
 					" 9 (privilegeWithdrawn), or 10 (aACompromise).",
-				Value: acme.CRLReasonUnspecified,
+				Value: "unspecified",
+			},
+			&cli.StringSliceFlag{
+				Name: flgMatch,
+				Usage: "Revoke all the stored certificates with a domain matching this pattern (ex: '*.example.com', '*' for all the certificates)." +
+					" Can be specified multiple times.",
+			},
+			&cli.StringSliceFlag{
+				Name: flgCertFile,
+				Usage: "Revoke the certificate of this PEM file, instead of a stored certificate (the file is not archived)." +
+					" Can be specified multiple times.",
+			},
+			&cli.BoolFlag{
+				Name: flgReplaced,
+				Usage: "Replace the stored certificates before their revocation: the previous certificate is archived (--keep is ignored)," +
+					" a new certificate is obtained with the options of the previous certificate (the order is marked with the ARI 'replaces' field), then the previous certificate is revoked." +
+					" The certificates obtained with a CSR require --csr. The default reason is superseded. Requires the challenge options.",
 			},
 		},
 	}
 }
 
 func revoke(ctx *cli.Context) error {
-	account, keyType := setupAccount(ctx, NewAccountsStorage(ctx))
+	withThrottlingRecorder(ctx)
+
+	reason, err := parseRevocationReason(ctx.String(flgReason))
+	if err != nil {
+		return err
+	}
+
+	certsStorage := NewCertificatesStorage(ctx)
+
+	domains, err := getRevokedDomains(ctx, certsStorage)
+	if err != nil {
+		return err
+	}
+
+	files := ctx.StringSlice(flgCertFile)
+
+	if len(domains) == 0 && len(files) == 0 {
+		return fmt.Errorf("no certificate to revoke: use --%s, --%s, or --%s", flgDomains, flgMatch, flgCertFile)
+	}
+
+	replace := ctx.Bool(flgReplaced)
+
+	if replace {
+		if len(files) > 0 {
+			return fmt.Errorf("--%s cannot be used with --%s: only the stored certificates can be replaced", flgReplaced, flgCertFile)
+		}
+
+		if !ctx.IsSet(flgReason) {
+			reason = acme.CRLReasonSuperseded
+		}
+	}
+
+	account, keyType, err := setupAccount(ctx, NewAccountsStorage(ctx))
+	if err != nil {
+		return err
+	}
 
 	if account.Registration == nil {
 		log.Fatalf("Account %s is not registered. Use 'run' to register a new account.\n", account.Email)
 	}
 
-	client := newClient(ctx, account, keyType)
+	var client *lego.Client
+	if replace {
+		client = setupClient(ctx, account, keyType)
+	} else {
+		client, err = createAccountClient(ctx, account, keyType)
+		if err != nil {
+			return err
+		}
+	}
+
+	var results []revokeResult
 
-	certsStorage := NewCertificatesStorage(ctx)
-	certsStorage.CreateRootFolder()
+	var errs []error
 
-	for _, domain := range ctx.StringSlice(flgDomains) {
-		log.Printf("Trying to revoke certificate for domain %s", domain)
+	for _, domain := range domains {
+		var result revokeResult
+
+		if replace {
+			result, err = replaceStoredCertificate(ctx, client, account, keyType, certsStorage, domain, reason)
+		} else {
+			result, err = revokeStoredCertificate(ctx, client, certsStorage, domain, reason)
+		}
 
-		certBytes, err := certsStorage.ReadFile(domain, certExt)
 		if err != nil {
-			log.Fatalf("Error while revoking the certificate for domain %s\n\t%v", domain, err)
+			log.Warnf("Error while revoking the certificate for domain %s\n\t%v", domain, err)
+			errs = append(errs, fmt.Errorf("[%s] %w", domain, err))
 		}
 
-		reason := ctx.Uint(flgReason)
+		results = append(results, result)
+	}
 
-		err = client.Certificate.RevokeWithReason(certBytes, &reason)
+	for _, filename := range files {
+		result, err := revokeCertificateFile(client, filename, reason)
 		if err != nil {
-			log.Fatalf("Error while revoking the certificate for domain %s\n\t%v", domain, err)
+			log.Warnf("Error while revoking the certificate %s\n\t%v", filename, err)
+			errs = append(errs, fmt.Errorf("[%s] %w", filename, err))
 		}
 
-		log.Println("Certificate was revoked.")
+		results = append(results, result)
+	}
 
-		if ctx.Bool(flgKeep) {
-			return nil
+	if isJSONOutput(ctx) {
+		if err := printJSON(results); err != nil {
+			return err
 		}
+	}
 
-		certsStorage.CreateArchiveFolder()
+	return errors.Join(errs...)
+}
 
-		err = certsStorage.MoveToArchive(domain)
-		if err != nil {
-			return err
+func revokeStoredCertificate(ctx *cli.Context, client *lego.Client, certsStorage *CertificatesStorage, domain string, reason uint) (revokeResult, error) {
+	result := revokeResult{Domain: domain}
+
+	log.Printf("Trying to revoke certificate for domain %s", domain)
+
+	certBytes, err := certsStorage.ReadFile(domain, certExt)
+	if err != nil {
+		return result, err
+	}
+
+	err = client.Certificate.RevokeWithReason(certBytes, &reason)
+	if err != nil {
+		return result, err
+	}
+
+	log.Println("Certificate was revoked.")
+
+	result.Revoked = true
+
+	if ctx.Bool(flgKeep) {
+		return result, nil
+	}
+
+	err = certsStorage.MoveToArchive(domain)
+	if err != nil {
+		return result, err
+	}
+
+	log.Println("Certificate was archived for domain:", domain)
+
+	result.Archived = true
+
+	return result, nil
+}
+
+// replaceStoredCertificate obtains a replacement of a stored certificate (the order is marked with the ARI replaces field),
+// with the issuance options of the stored certificate, stores the replacement, then revokes the previous certificate.
+// The previous certificate is archived (copied) before obtaining the replacement, and revoked only if the replacement succeeded.
+func replaceStoredCertificate(ctx *cli.Context, client *lego.Client, account *Account, keyType certcrypto.KeyType,
+	certsStorage *CertificatesStorage, domain string, reason uint,
+) (revokeResult, error) {
+	result := revokeResult{Domain: domain}
+
+	log.Printf("Trying to replace the certificate for domain %s", domain)
+
+	certBytes, err := certsStorage.ReadFile(domain, certExt)
+	if err != nil {
+		return result, err
+	}
+
+	certificates, err := certcrypto.ParsePEMBundle(certBytes)
+	if err != nil {
+		return result, err
+	}
+
+	request, csr, err := newReplacementRequest(ctx, certsStorage, domain, certificates)
+	if err != nil {
+		return result, err
+	}
+
+	// The files of the previous certificate stay in place until the replacement is saved:
+	// if the archiving fails, no certificate is obtained.
+	err = certsStorage.CopyToArchive(domain)
+	if err != nil {
+		return result, &storageError{err: err}
+	}
+
+	result.Archived = true
+
+	var certRes *certificate.Resource
+
+	failover := setupFailover(ctx, client, keyType)
+
+	if csr != nil {
+		certRes, err = failover.ObtainForCSR(certificate.ObtainForCSRRequest{
+			CSR:            csr,
+			Bundle:         request.Bundle,
+			PreferredChain: request.PreferredChain,
+			Profile:        request.Profile,
+			ReplacesCertID: request.ReplacesCertID,
+		})
+	} else {
+		certRes, err = failover.Obtain(request)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("could not obtain the replacement certificate: %w", err)
+	} else {
+		err = storeReplacement(ctx, account, certsStorage, domain, certRes, &result)
+	}
+
+	reportOutcome(ctx, notifyEventRenew, domain, certRes, err)
+
+	if err != nil {
+		return result, err
+	}
+
+	err = client.Certificate.RevokeWithReason(certBytes, &reason)
+	if err != nil {
+		return result, err
+	}
+
+	log.Println("Certificate was revoked.")
+
+	result.Revoked = true
+
+	return result, nil
+}
+
+// newReplacementRequest creates the request of the replacement of a stored certificate, with the issuance options of the certificate:
+// the domains, the key type (with a new private key), the OCSP must-staple extension, the bundle, the chain, and the profile.
+// A certificate without stored private key was obtained with a CSR: it's replaced with the CSR of the --csr option.
+func newReplacementRequest(ctx *cli.Context, certsStorage *CertificatesStorage, domain string, certificates []*x509.Certificate,
+) (certificate.ObtainRequest, *x509.CertificateRequest, error) {
+	cert := certificates[0]
+
+	replacesCertID, err := certificate.MakeARICertID(cert)
+	if err != nil {
+		return certificate.ObtainRequest{}, nil, fmt.Errorf("error while constructing the ARI CertID: %w", err)
+	}
+
+	request := certificate.ObtainRequest{
+		Domains:        certcrypto.ExtractDomains(cert),
+		MustStaple:     certcrypto.HasMustStaple(cert),
+		Bundle:         len(certificates) > 1,
+		PreferredChain: storedChainIssuer(certsStorage, domain, certificates),
+		ReplacesCertID: replacesCertID,
+	}
+
+	if certsStorage.ExistsFile(domain, resourceExt) {
+		resource, errR := certsStorage.readResource(domain)
+		if errR != nil {
+			return certificate.ObtainRequest{}, nil, &storageError{err: errR}
+		}
+
+		request.Profile = resource.Profile
+	}
+
+	if !certsStorage.ExistsFile(domain, keyExt) {
+		if !ctx.IsSet(flgCSR) {
+			return certificate.ObtainRequest{}, nil, fmt.Errorf("the certificate was obtained with a CSR (no private key is stored): use --%s to replace it", flgCSR)
 		}
 
-		log.Println("Certificate was archived for domain:", domain)
+		csr, errC := readCSRFile(ctx.String(flgCSR))
+		if errC != nil {
+			return certificate.ObtainRequest{}, nil, errC
+		}
+
+		if !slices.Equal(sortedDomains(certcrypto.ExtractDomainsCSR(csr)), sortedDomains(request.Domains)) {
+			return certificate.ObtainRequest{}, nil, fmt.Errorf("the domains of the CSR %s do not match the domains of the certificate", ctx.String(flgCSR))
+		}
+
+		return request, csr, nil
+	}
+
+	storedKey, err := certsStorage.ReadPrivateKey(domain)
+	if err != nil {
+		return certificate.ObtainRequest{}, nil, &storageError{err: fmt.Errorf("error while loading the private key for domain %s: %w", domain, err)}
+	}
+
+	keyType, err := certcrypto.GetKeyType(storedKey)
+	if err != nil {
+		return certificate.ObtainRequest{}, nil, fmt.Errorf("unable to use the key type of the certificate: %w", err)
+	}
+
+	// The private key may be compromised: the replacement uses a new private key.
+	request.PrivateKey, err = certcrypto.GeneratePrivateKey(keyType)
+	if err != nil {
+		return certificate.ObtainRequest{}, nil, err
+	}
+
+	return request, nil, nil
+}
+
+// storedChainIssuer returns the issuer of the top certificate of the stored chain:
+// the replacement is obtained with the same chain (see the preferred chain option).
+func storedChainIssuer(certsStorage *CertificatesStorage, domain string, certificates []*x509.Certificate) string {
+	chain := certificates
+
+	issuers, err := certsStorage.ReadCertificate(domain, issuerExt)
+	if err == nil && len(issuers) > 0 {
+		chain = issuers
+	}
+
+	return chain[len(chain)-1].Issuer.CommonName
+}
+
+func sortedDomains(domains []string) []string {
+	domains = slices.Clone(domains)
+	slices.Sort(domains)
+
+	return domains
+}
+
+// storeReplacement saves and deploys the replacement.
+func storeReplacement(ctx *cli.Context, account *Account, certsStorage *CertificatesStorage, domain string,
+	certRes *certificate.Resource, result *revokeResult,
+) error {
+	err := certsStorage.saveResource(certRes)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Certificate was replaced for domain:", domain)
+
+	result.Replaced = true
+
+	meta := map[string]string{hookEnvAccountEmail: account.Email}
+	addPathToMetadata(meta, certRes.Domain, certRes, certsStorage)
+
+	return deployCertificate(ctx, certRes, meta)
+}
+
+func revokeCertificateFile(client *lego.Client, filename string, reason uint) (revokeResult, error) {
+	result := revokeResult{Path: filename}
+
+	log.Printf("Trying to revoke the certificate %s", filename)
+
+	certBytes, err := os.ReadFile(filename)
+	if err != nil {
+		return result, err
+	}
+
+	cert, err := certcrypto.ParsePEMCertificate(certBytes)
+	if err != nil {
+		return result, err
+	}
+
+	result.Domain, err = certcrypto.GetCertificateMainDomain(cert)
+	if err != nil {
+		return result, err
+	}
+
+	err = client.Certificate.RevokeWithReason(certBytes, &reason)
+	if err != nil {
+		return result, err
+	}
+
+	log.Println("Certificate was revoked.")
+
+	result.Revoked = true
+
+	return result, nil
+}
+
+// getRevokedDomains returns the stored certificates to revoke: the domains option,
+// and the certificates with a domain matching one of the patterns of the match option.
+func getRevokedDomains(ctx *cli.Context, certsStorage *CertificatesStorage) ([]string, error) {
+	domains := slices.Clone(ctx.StringSlice(flgDomains))
+
+	patterns := ctx.StringSlice(flgMatch)
+	if len(patterns) == 0 {
+		return domains, nil
+	}
+
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	certificates, err := readCertificatesInfo(certsStorage)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range matchCertificates(certificates, patterns) {
+		if !slices.Contains(domains, info.Name) {
+			domains = append(domains, info.Name)
+		}
+	}
+
+	return domains, nil
+}
+
+// matchCertificates returns the certificates with a name or a domain matching one of the patterns.
+// The patterns must be valid (path.Match).
+func matchCertificates(certificates []listCertificateResult, patterns []string) []listCertificateResult {
+	var matches []listCertificateResult
+
+	for _, info := range certificates {
+		if matchAny(patterns, append([]string{info.Name}, info.Domains...)) {
+			matches = append(matches, info)
+		}
+	}
+
+	return matches
+}
+
+func matchAny(patterns, names []string) bool {
+	for _, pattern := range patterns {
+		for _, name := range names {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parseRevocationReason parses a revocation reason: a name (ex: keyCompromise, case-insensitive) or a code (ex: 1).
+func parseRevocationReason(value string) (uint, error) {
+	code, err := strconv.ParseUint(value, 10, 32)
+
+	for name, reason := range revocationReasons {
+		if strings.EqualFold(name, value) || err == nil && uint(code) == reason {
+			return reason, nil
+		}
 	}
 
-	return nil
+	return 0, fmt.Errorf("invalid revocation reason %q: see https://www.rfc-editor.org/rfc/rfc5280.html#section-5.3.1", value)
 }
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func Test_parseRevocationReason(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected uint
+	}{
+		{value: "unspecified", expected: acme.CRLReasonUnspecified},
+		{value: "keyCompromise", expected: acme.CRLReasonKeyCompromise},
+		{value: "KEYCOMPROMISE", expected: acme.CRLReasonKeyCompromise},
+		{value: "superseded", expected: acme.CRLReasonSuperseded},
+		{value: "4", expected: acme.CRLReasonSuperseded},
+		{value: "0", expected: acme.CRLReasonUnspecified},
+		{value: "10", expected: acme.CRLReasonAACompromise},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.value, func(t *testing.T) {
+			t.Parallel()
+
+			reason, err := parseRevocationReason(test.value)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, reason)
+		})
+	}
+}
+
+func Test_parseRevocationReason_error(t *testing.T) {
+	testCases := []string{"", "7", "11", "-1", "compromised"}
+
+	for _, value := range testCases {
+		t.Run(value, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parseRevocationReason(value)
+			require.Error(t, err)
+		})
+	}
+}
+
+func Test_matchCertificates(t *testing.T) {
+	certificates := []listCertificateResult{
+		{Name: "example.com", Domains: []string{"example.com", "www.example.com"}},
+		{Name: "*.example.org", Domains: []string{"*.example.org"}},
+		{Name: "example.net", Domains: []string{"example.net"}},
+	}
+
+	testCases := []struct {
+		desc     string
+		patterns []string
+		expected []string
+	}{
+		{
+			desc:     "all",
+			patterns: []string{"*"},
+			expected: []string{"example.com", "*.example.org", "example.net"},
+		},
+		{
+			desc:     "subdomain",
+			patterns: []string{"www.*"},
+			expected: []string{"example.com"},
+		},
+		{
+			desc:     "wildcard certificate",
+			patterns: []string{"*.example.org"},
+			expected: []string{"*.example.org"},
+		},
+		{
+			desc:     "multiple patterns",
+			patterns: []string{"example.net", "*.example.com"},
+			expected: []string{"example.com", "example.net"},
+		},
+		{
+			desc:     "no match",
+			patterns: []string{"example.io"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var names []string
+			for _, info := range matchCertificates(certificates, test.patterns) {
+				names = append(names, info.Name)
+			}
+
+			assert.Equal(t, test.expected, names)
+		})
+	}
+}
+
+func Test_revoke_replacedCertFile(t *testing.T) {
+	app := cli.NewApp()
+	app.Flags = CreateFlags(t.TempDir())
+	app.Commands = CreateCommands()
+
+	err := app.Run([]string{"lego", "--email", "you@example.com", "revoke", "--ari-replaced", "--cert-file", "example.com.crt"})
+	require.EqualError(t, err, "--ari-replaced cannot be used with --cert-file: only the stored certificates can be replaced")
+}
+
+// writeTestReplacedCertificate writes a certificate signed by a test CA, with its issuer, its private key (EC384), and its resource.
+func writeTestReplacedCertificate(t *testing.T, rootPath, domain string) {
+	t.Helper()
+
+	caKey, err := certcrypto.GeneratePrivateKey(certcrypto.EC256)
+	require.NoError(t, err)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.(crypto.Signer).Public(), caKey)
+	require.NoError(t, err)
+
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.EC384)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain, "www." + domain},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		// OCSP must-staple.
+		ExtraExtensions: []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}, Value: []byte{0x30, 0x03, 0x02, 0x01, 0x05}}},
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, privateKey.(crypto.Signer).Public(), caKey)
+	require.NoError(t, err)
+
+	issuerPEM := certcrypto.PEMEncode(certcrypto.DERCertificateBytes(caDER))
+	certPEM := append(certcrypto.PEMEncode(certcrypto.DERCertificateBytes(certDER)), issuerPEM...)
+
+	files := map[string][]byte{
+		certExt:     certPEM,
+		issuerExt:   issuerPEM,
+		keyExt:      certcrypto.PEMEncode(privateKey),
+		resourceExt: []byte(`{"domain":"` + domain + `","profile":"shortlived"}`),
+	}
+
+	for ext, data := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(rootPath, domain+ext), data, 0o600))
+	}
+}
+
+func Test_newReplacementRequest(t *testing.T) {
+	certsStorage, rootPath, _ := newTestCertificatesStorage(t)
+
+	writeTestReplacedCertificate(t, rootPath, "example.com")
+
+	certificates, err := certsStorage.ReadCertificate("example.com", certExt)
+	require.NoError(t, err)
+
+	request, csr, err := newReplacementRequest(newTestContext(t), certsStorage, "example.com", certificates)
+	require.NoError(t, err)
+
+	assert.Nil(t, csr)
+	assert.Equal(t, []string{"example.com", "www.example.com"}, request.Domains)
+	assert.True(t, request.MustStaple)
+	assert.True(t, request.Bundle)
+	assert.Equal(t, "Test Root CA", request.PreferredChain)
+	assert.Equal(t, "shortlived", request.Profile)
+	assert.NotEmpty(t, request.ReplacesCertID)
+
+	// A new private key, of the same type.
+	storedKey, err := certsStorage.ReadPrivateKey("example.com")
+	require.NoError(t, err)
+
+	assert.NotEqual(t, storedKey, request.PrivateKey)
+
+	keyType, err := certcrypto.GetKeyType(request.PrivateKey)
+	require.NoError(t, err)
+	assert.Equal(t, certcrypto.EC384, keyType)
+}
+
+func Test_newReplacementRequest_csr(t *testing.T) {
+	certsStorage, rootPath, _ := newTestCertificatesStorage(t)
+
+	writeTestReplacedCertificate(t, rootPath, "example.com")
+
+	// A certificate obtained with a CSR has no private key.
+	require.NoError(t, os.Remove(filepath.Join(rootPath, "example.com"+keyExt)))
+
+	certificates, err := certsStorage.ReadCertificate("example.com", certExt)
+	require.NoError(t, err)
+
+	_, _, err = newReplacementRequest(newTestContext(t), certsStorage, "example.com", certificates)
+	require.EqualError(t, err, "the certificate was obtained with a CSR (no private key is stored): use --csr to replace it")
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.EC256)
+	require.NoError(t, err)
+
+	csrFile := filepath.Join(t.TempDir(), "example.com.csr")
+
+	csrDER, err := certcrypto.GenerateCSR(privateKey, "example.com", []string{"www.example.com"}, false)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(csrFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER}), 0o600))
+
+	request, csr, err := newReplacementRequest(newTestContext(t, "--csr", csrFile), certsStorage, "example.com", certificates)
+	require.NoError(t, err)
+
+	require.NotNil(t, csr)
+	assert.Equal(t, "example.com", csr.Subject.CommonName)
+	assert.Nil(t, request.PrivateKey)
+	assert.NotEmpty(t, request.ReplacesCertID)
+
+	// The CSR must have the domains of the certificate.
+	csrDER, err = certcrypto.GenerateCSR(privateKey, "example.com", nil, false)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(csrFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER}), 0o600))
+
+	_, _, err = newReplacementRequest(newTestContext(t, "--csr", csrFile), certsStorage, "example.com", certificates)
+	require.ErrorContains(t, err, "do not match the domains of the certificate")
+}
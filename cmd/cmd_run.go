@@ -2,11 +2,14 @@ package cmd
 
 import (
 	"bufio"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/go-acme/lego/v4/certificate"
 	"github.com/go-acme/lego/v4/lego"
 	"github.com/go-acme/lego/v4/log"
@@ -21,9 +24,13 @@ const (
 	flgNotBefore                      = "not-before"
 	flgNotAfter                       = "not-after"
 	flgPreferredChain                 = "preferred-chain"
+	flgProfile                        = "profile"
 	flgAlwaysDeactivateAuthorizations = "always-deactivate-authorizations"
 	flgRunHook                        = "run-hook"
 	flgRunHookTimeout                 = "run-hook-timeout"
+	flgCSRWatch                       = "csr-watch"
+	flgCSRWatchInterval               = "csr-watch-interval"
+	flgCSRWatchDelete                 = "csr-watch-delete"
 )
 
 func createRun() *cli.Command {
@@ -31,9 +38,27 @@ func createRun() *cli.Command {
 		Name:  "run",
 		Usage: "Register an account, then create and install a certificate",
 		Before: func(ctx *cli.Context) error {
+			certificates, err := getCertificates(ctx)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if len(certificates) > 0 {
+				return nil
+			}
+
 			// we require either domains or csr, but not both
 			hasDomains := len(ctx.StringSlice(flgDomains)) > 0
 			hasCsr := ctx.String(flgCSR) != ""
+
+			if ctx.IsSet(flgCSRWatch) {
+				if hasDomains || hasCsr {
+					log.Fatalf("--%s cannot be used with --domains/-d or --csr/-c", flgCSRWatch)
+				}
+
+				return nil
+			}
+
 			if hasDomains && hasCsr {
 				log.Fatal("Please specify either --domains/-d or --csr/-c, but not both")
 			}
@@ -43,7 +68,8 @@ func createRun() *cli.Command {
 			return nil
 		},
 		Action: run,
-		Flags: []cli.Flag{
+		After:  writeMetricsTextfile,
+		Flags: append([]cli.Flag{
 			&cli.BoolFlag{
 				Name:  flgNoBundle,
 				Usage: "Do not create a certificate bundle by adding the issuers certificate to the new certificate.",
@@ -63,10 +89,10 @@ func createRun() *cli.Command {
 				Usage:  "Set the notAfter field in the certificate (RFC3339 format)",
 				Layout: time.RFC3339,
 			},
+			createPreferredChainFlag(),
 			&cli.StringFlag{
-				Name: flgPreferredChain,
-				Usage: "If the CA offers multiple certificate chains, prefer the chain with an issuer matching this Subject Common Name." +
-					" If no match, the default offered chain will be used.",
+				Name:  flgProfile,
+				Usage: "If the CA offers multiple certificate profiles (draft-aaron-acme-profiles), choose this one.",
 			},
 			&cli.StringFlag{
 				Name:  flgAlwaysDeactivateAuthorizations,
@@ -81,7 +107,21 @@ func createRun() *cli.Command {
 				Usage: "Define the timeout for the hook execution.",
 				Value: 2 * time.Minute,
 			},
-		},
+			&cli.StringFlag{
+				Name: flgCSRWatch,
+				Usage: "Watch a directory for CSR files ('*.csr'), and obtain a certificate for each new CSR until the process is stopped." +
+					" The certificate is written next to the CSR ('<name>.crt', '<name>.issuer.crt'), or the error ('<name>.error').",
+			},
+			&cli.DurationFlag{
+				Name:  flgCSRWatchInterval,
+				Usage: "The interval between two scans of the CSR directory.",
+				Value: 10 * time.Second,
+			},
+			&cli.BoolFlag{
+				Name:  flgCSRWatchDelete,
+				Usage: "Delete the CSR files after obtaining the certificates.",
+			},
+		}, createCertificatesFlags()...),
 	}
 }
 
@@ -96,38 +136,145 @@ private keys obtained from Let's Encrypt so making regular
 backups of this folder is ideal.
 `
 
+// createPreferredChainFlag creates the flag of the preferred chain (run, renew, and daemon commands).
+func createPreferredChainFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name: flgPreferredChain,
+		Usage: "If the CA offers multiple certificate chains, prefer the chain with an issuer matching this Subject Common Name," +
+			" the SHA-256 fingerprint of a certificate of the chain ('sha256:<hex>', the root is usually not part of the chain)," +
+			" or the Authority Key Identifier of the top certificate of the chain, i.e. the key ID of the root ('aki:<hex>')." +
+			" If no match, the default offered chain will be used.",
+		Action: func(_ *cli.Context, value string) error {
+			return certificate.ValidatePreferredChain(value)
+		},
+	}
+}
+
 func run(ctx *cli.Context) error {
-	accountsStorage := NewAccountsStorage(ctx)
+	withThrottlingRecorder(ctx)
 
-	account, keyType := setupAccount(ctx, accountsStorage)
+	certificates, err := getCertificates(ctx)
+	if err != nil {
+		return err
+	}
 
-	client := setupClient(ctx, account, keyType)
+	if len(certificates) > 0 && !ctx.IsSet(flgCSRWatch) {
+		accounts := newAccountsCache(func(accountCtx *cli.Context) (*Account, error) {
+			account, _, _, err := setupRunAccount(accountCtx)
 
-	if account.Registration == nil {
-		reg, err := register(ctx, client)
-		if err != nil {
-			log.Fatalf("Could not complete registration\n\t%v", err)
-		}
+			return account, err
+		})
 
-		account.Registration = reg
-		if err = accountsStorage.Save(account); err != nil {
-			log.Fatal(err)
-		}
+		return runCertificates(ctx, certificates, func(certCtx *cli.Context) error {
+			account, err := accounts.get(certCtx)
+			if err != nil {
+				reportFailure(certCtx, notifyEventObtain, certificateName(certCtx), err)
+				return err
+			}
+
+			certKeyType, err := parseKeyType(certCtx)
+			if err != nil {
+				reportFailure(certCtx, notifyEventObtain, certificateName(certCtx), err)
+				return err
+			}
+
+			certClient, err := setupCertificateClient(certCtx, account, certKeyType)
+			if err != nil {
+				reportFailure(certCtx, notifyEventObtain, certificateName(certCtx), err)
+				return err
+			}
+
+			return runCertificate(certCtx, account, certClient, certKeyType)
+		})
+	}
 
+	account, client, keyType, err := setupRunAccount(ctx)
+	if err != nil {
+		Exit(err)
+	}
+
+	if ctx.IsSet(flgCSRWatch) {
+		setupChallenges(ctx, client)
+
+		return watchCSRDirectory(ctx, account, setupFailover(ctx, client, keyType))
+	}
+
+	setupChallenges(ctx, client)
+
+	return runCertificate(ctx, account, client, keyType)
+}
+
+// setupRunAccount loads the account, and registers it if needed.
+func setupRunAccount(ctx *cli.Context) (*Account, *lego.Client, certcrypto.KeyType, error) {
+	accountsStorage, err := createAccountsStorage(ctx, ctx.String(flgServer))
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	account, keyType, err := setupAccount(ctx, accountsStorage)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	client, err := createAccountClient(ctx, account, keyType)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if account.Registration != nil {
+		return account, client, keyType, nil
+	}
+
+	reg, err := register(ctx, client)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("could not complete registration: %w", err)
+	}
+
+	account.Registration = reg
+	if err = accountsStorage.Save(account); err != nil {
+		return nil, nil, "", &storageError{err: err}
+	}
+
+	if isJSONOutput(ctx) {
+		log.Printf(rootPathWarningMessage, accountsStorage.GetRootPath())
+	} else {
 		fmt.Printf(rootPathWarningMessage, accountsStorage.GetRootPath())
 	}
 
-	certsStorage := NewCertificatesStorage(ctx)
-	certsStorage.CreateRootFolder()
+	return account, client, keyType, nil
+}
+
+// runCertificate obtains a certificate, then saves and deploys it.
+// The outcome is reported once all the steps are done.
+func runCertificate(ctx *cli.Context, account *Account, client *lego.Client, keyType certcrypto.KeyType) error {
+	cert, err := obtainAndSaveCertificate(ctx, account, client, keyType)
+
+	reportOutcome(ctx, notifyEventObtain, certificateName(ctx), cert, err)
+
+	return err
+}
+
+func obtainAndSaveCertificate(ctx *cli.Context, account *Account, client *lego.Client, keyType certcrypto.KeyType) (*certificate.Resource, error) {
+	certsStorage, err := newCertificatesStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
 
-	cert, err := obtainCertificate(ctx, client)
+	cert, err := obtainCertificate(ctx, setupFailover(ctx, client, keyType))
 	if err != nil {
 		// Make sure to return a non-zero exit code if ObtainSANCertificate returned at least one error.
-		// Due to us not returning partial certificate we can just exit here instead of at the end.
-		log.Fatalf("Could not obtain certificates:\n\t%v", err)
+		// Due to us not returning partial certificate we can just return here instead of at the end.
+		return nil, fmt.Errorf("could not obtain certificates: %w", err)
 	}
 
-	certsStorage.SaveResource(cert)
+	err = certsStorage.saveResource(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshOCSPStaple(ctx, certsStorage, cert.Domain, true)
 
 	meta := map[string]string{
 		hookEnvAccountEmail: account.Email,
@@ -135,13 +282,23 @@ func run(ctx *cli.Context) error {
 
 	addPathToMetadata(meta, cert.Domain, cert, certsStorage)
 
-	return launchHook(ctx.String(flgRunHook), ctx.Duration(flgRunHookTimeout), meta)
+	if err = printCertificateResult(ctx, cert, meta); err != nil {
+		return nil, err
+	}
+
+	if err = deployCertificate(ctx, cert, meta); err != nil {
+		return nil, err
+	}
+
+	hookCtx := newHookContext(notifyEventObtain, cert, meta).withTimings(started)
+
+	return cert, launchHook(ctx.String(flgRunHook), ctx.Duration(flgRunHookTimeout), meta, hookCtx)
 }
 
-func handleTOS(ctx *cli.Context, client *lego.Client) bool {
+func handleTOS(ctx *cli.Context, client *lego.Client) (bool, error) {
 	// Check for a global accept override
 	if ctx.Bool(flgAcceptTOS) {
-		return true
+		return true, nil
 	}
 
 	reader := bufio.NewReader(os.Stdin)
@@ -151,15 +308,15 @@ func handleTOS(ctx *cli.Context, client *lego.Client) bool {
 		fmt.Println("Do you accept the TOS? Y/n")
 		text, err := reader.ReadString('\n')
 		if err != nil {
-			log.Fatalf("Could not read from console: %v", err)
+			return false, fmt.Errorf("could not read from console: %w", err)
 		}
 
 		text = strings.Trim(text, "\r\n")
 		switch text {
 		case "", "y", "Y":
-			return true
+			return true, nil
 		case "n", "N":
-			return false
+			return false, nil
 		default:
 			fmt.Println("Your input was invalid. Please answer with one of Y/y, n/N or by pressing enter.")
 		}
@@ -167,9 +324,13 @@ func handleTOS(ctx *cli.Context, client *lego.Client) bool {
 }
 
 func register(ctx *cli.Context, client *lego.Client) (*registration.Resource, error) {
-	accepted := handleTOS(ctx, client)
+	accepted, err := handleTOS(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
 	if !accepted {
-		log.Fatal("You did not accept the TOS. Unable to proceed.")
+		return nil, errors.New("you did not accept the TOS: unable to proceed")
 	}
 
 	if ctx.Bool(flgEAB) {
@@ -177,7 +338,7 @@ func register(ctx *cli.Context, client *lego.Client) (*registration.Resource, er
 		hmacEncoded := ctx.String(flgHMAC)
 
 		if kid == "" || hmacEncoded == "" {
-			log.Fatalf("Requires arguments --%s and --%s.", flgKID, flgHMAC)
+			return nil, fmt.Errorf("requires arguments --%s and --%s", flgKID, flgHMAC)
 		}
 
 		return client.Registration.RegisterWithExternalAccountBinding(registration.RegisterEABOptions{
@@ -190,7 +351,7 @@ func register(ctx *cli.Context, client *lego.Client) (*registration.Resource, er
 	return client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
 }
 
-func obtainCertificate(ctx *cli.Context, client *lego.Client) (*certificate.Resource, error) {
+func obtainCertificate(ctx *cli.Context, failover *lego.Failover) (*certificate.Resource, error) {
 	bundle := !ctx.Bool(flgNoBundle)
 
 	domains := ctx.StringSlice(flgDomains)
@@ -201,6 +362,7 @@ func obtainCertificate(ctx *cli.Context, client *lego.Client) (*certificate.Reso
 			Bundle:                         bundle,
 			MustStaple:                     ctx.Bool(flgMustStaple),
 			PreferredChain:                 ctx.String(flgPreferredChain),
+			Profile:                        ctx.String(flgProfile),
 			AlwaysDeactivateAuthorizations: ctx.Bool(flgAlwaysDeactivateAuthorizations),
 		}
 
@@ -214,7 +376,7 @@ func obtainCertificate(ctx *cli.Context, client *lego.Client) (*certificate.Reso
 			request.NotAfter = *notAfter
 		}
 
-		return client.Certificate.Obtain(request)
+		return failover.Obtain(request)
 	}
 
 	// read the CSR
@@ -223,15 +385,20 @@ func obtainCertificate(ctx *cli.Context, client *lego.Client) (*certificate.Reso
 		return nil, err
 	}
 
-	// obtain a certificate for this CSR
+	return obtainCertificateForCSR(ctx, failover, csr)
+}
+
+// obtainCertificateForCSR obtains a certificate for a CSR.
+func obtainCertificateForCSR(ctx *cli.Context, failover *lego.Failover, csr *x509.CertificateRequest) (*certificate.Resource, error) {
 	request := certificate.ObtainForCSRRequest{
 		CSR:                            csr,
 		NotBefore:                      getTime(ctx, flgNotBefore),
 		NotAfter:                       getTime(ctx, flgNotAfter),
-		Bundle:                         bundle,
+		Bundle:                         !ctx.Bool(flgNoBundle),
 		PreferredChain:                 ctx.String(flgPreferredChain),
+		Profile:                        ctx.String(flgProfile),
 		AlwaysDeactivateAuthorizations: ctx.Bool(flgAlwaysDeactivateAuthorizations),
 	}
 
-	return client.Certificate.ObtainForCSR(request)
+	return failover.ObtainForCSR(request)
 }
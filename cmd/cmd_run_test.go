@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_setupRunAccount_errors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+
+	testCases := []struct {
+		desc        string
+		args        []string
+		setup       func(t *testing.T, accountsStorage *AccountsStorage)
+		expectedErr string
+	}{
+		{
+			desc:        "missing email",
+			args:        []string{"--server", server.URL + "/dir"},
+			expectedErr: "you have to pass an account (email address) to the program using --email or -m",
+		},
+		{
+			desc:        "unsupported key type",
+			args:        []string{"--server", server.URL + "/dir", "--email", "test@example.com", "--key-type", "ec512"},
+			expectedErr: "unsupported KeyType: ec512",
+		},
+		{
+			desc: "invalid account key",
+			args: []string{"--server", server.URL + "/dir", "--email", "test@example.com"},
+			setup: func(t *testing.T, accountsStorage *AccountsStorage) {
+				t.Helper()
+
+				require.NoError(t, accountsStorage.backend.WriteFile(accountsStorage.accountKeyPath(), []byte("invalid")))
+			},
+			expectedErr: "could not load the private key from file",
+		},
+		{
+			desc:        "invalid proxy",
+			args:        []string{"--server", server.URL + "/dir", "--email", "test@example.com", "--proxy", "http://"},
+			expectedErr: "could not create client: invalid value for --proxy: the host is missing",
+		},
+		{
+			desc:        "invalid server",
+			args:        []string{"--server", server.URL + "/dir", "--email", "test@example.com"},
+			expectedErr: "could not create client: get directory at '" + server.URL + "/dir'",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := newTestContext(t, test.args...)
+
+			if test.setup != nil {
+				accountsStorage, err := createAccountsStorage(ctx, ctx.String(flgServer))
+				require.NoError(t, err)
+
+				test.setup(t, accountsStorage)
+			}
+
+			_, _, _, err := setupRunAccount(ctx)
+			require.ErrorContains(t, err, test.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,416 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/lego"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/go-acme/lego/v4/registration"
+	"github.com/urfave/cli/v2"
+)
+
+// Flag names.
+const (
+	flgSelftestPebble  = "pebble"
+	flgSelftestStaging = "staging"
+	flgSelftestDomain  = "domain"
+	flgSelftestMock    = "mock"
+)
+
+// Statuses of the steps of the selftest command.
+const (
+	selftestOK      = "ok"
+	selftestFailed  = "failed"
+	selftestSkipped = "skipped"
+)
+
+// selftestResult the diagnostic summary of the selftest command.
+type selftestResult struct {
+	Server string         `json:"server"`
+	Domain string         `json:"domain"`
+	Mock   bool           `json:"mock"`
+	Steps  []selftestStep `json:"steps"`
+}
+
+// selftestStep a step of the selftest command.
+type selftestStep struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Duration string `json:"duration,omitempty"`
+
+	err error
+}
+
+func createSelftest() *cli.Command {
+	return &cli.Command{
+		Name:  "selftest",
+		Usage: "Check the configuration with a full issuance against a local Pebble server (or a staging server)",
+		Description: "The issuance uses a throwaway account and a throwaway domain, the certificate is not stored.\n" +
+			"   By default, a local Pebble server (https://github.com/letsencrypt/pebble) is launched: the pebble binary must be available.\n" +
+			"   In mock mode, the configured challenge provider is created (the options and the credentials are checked), but the challenges are not published.",
+		Action: selftest,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  flgSelftestPebble,
+				Usage: "The Pebble binary (name or path).",
+				Value: "pebble",
+			},
+			&cli.BoolFlag{
+				Name:  flgSelftestStaging,
+				Usage: "Use the server defined by the --server option (ex: a staging environment) instead of a local Pebble server.",
+			},
+			&cli.StringFlag{
+				Name:  flgSelftestDomain,
+				Usage: "The domain of the certificate. Use a domain that you control with --staging and --mock=false.",
+				Value: "lego-selftest.example.com",
+			},
+			&cli.BoolFlag{
+				Name:  flgSelftestMock,
+				Usage: "The challenges are not published by the provider, and the DNS propagation is not checked.",
+				Value: true,
+			},
+		},
+	}
+}
+
+func selftest(ctx *cli.Context) error {
+	result := runSelftest(ctx)
+
+	if isJSONOutput(ctx) {
+		err := printJSON(result)
+		if err != nil {
+			return err
+		}
+	} else {
+		printSelftestResult(result)
+	}
+
+	for _, step := range result.Steps {
+		if step.Status == selftestFailed {
+			return fmt.Errorf("selftest failed: %s: %w", step.Name, step.err)
+		}
+	}
+
+	return nil
+}
+
+// selftestSteps runs the steps of a diagnostic, the steps after a failure are skipped.
+type selftestSteps struct {
+	steps  []selftestStep
+	failed bool
+}
+
+func (s *selftestSteps) run(name string, fn func() (string, error)) {
+	if s.failed {
+		s.steps = append(s.steps, selftestStep{Name: name, Status: selftestSkipped})
+		return
+	}
+
+	started := time.Now()
+
+	detail, err := fn()
+
+	step := selftestStep{Name: name, Status: selftestOK, Detail: detail, Duration: time.Since(started).Round(time.Millisecond).String(), err: err}
+	if err != nil {
+		step.Status = selftestFailed
+		step.Detail = err.Error()
+		s.failed = true
+	}
+
+	s.steps = append(s.steps, step)
+}
+
+// skip adds a skipped step.
+func (s *selftestSteps) skip(name string) {
+	s.steps = append(s.steps, selftestStep{Name: name, Status: selftestSkipped})
+}
+
+// runSelftest runs the steps of the selftest, the steps after a failure are skipped.
+func runSelftest(ctx *cli.Context) *selftestResult {
+	result := &selftestResult{
+		Domain: ctx.String(flgSelftestDomain),
+		Mock:   ctx.Bool(flgSelftestMock),
+	}
+
+	steps := &selftestSteps{}
+
+	var rootCAs *x509.CertPool
+
+	var server *pebble
+
+	defer func() {
+		if server != nil {
+			server.Stop()
+		}
+	}()
+
+	steps.run("server", func() (string, error) {
+		if ctx.Bool(flgSelftestStaging) {
+			result.Server = ctx.String(flgServer)
+			if result.Server == lego.LEDirectoryProduction {
+				return "", fmt.Errorf("use a staging server with --%s (ex: --%s %s)", flgSelftestStaging, flgServer, lego.LEDirectoryStaging)
+			}
+
+			return result.Server, nil
+		}
+
+		var err error
+
+		server, err = startPebble(ctx.Context, ctx.String(flgSelftestPebble), 30*time.Second)
+		if err != nil {
+			return "", err
+		}
+
+		result.Server = server.DirectoryURL
+		rootCAs = server.RootCAs
+
+		return "pebble " + server.DirectoryURL, nil
+	})
+
+	var client *lego.Client
+
+	keyType := getKeyType(ctx)
+
+	steps.run("client", func() (string, error) {
+		privateKey, err := certcrypto.GeneratePrivateKey(keyType)
+		if err != nil {
+			return "", err
+		}
+
+		client, err = newSelftestClient(ctx, &Account{Email: ctx.String(flgEmail), key: privateKey}, keyType, result.Server, rootCAs)
+		if err != nil {
+			return "", err
+		}
+
+		return "directory " + result.Server, nil
+	})
+
+	var recorder *mockProvider
+
+	steps.run("challenge", func() (string, error) {
+		var err error
+
+		recorder, err = setupSelftestChallenges(ctx, client, result.Mock)
+		if err != nil {
+			return "", err
+		}
+
+		var challenges []string
+		for _, c := range enabledChallenges(ctx) {
+			challenges = append(challenges, fmt.Sprintf("%s (%s)", c.Type, c.Provider))
+		}
+
+		return strings.Join(challenges, ", "), nil
+	})
+
+	steps.run("account", func() (string, error) {
+		reg, err := registerSelftest(ctx, client)
+		if err != nil {
+			return "", err
+		}
+
+		return reg.URI, nil
+	})
+
+	steps.run("certificate", func() (string, error) {
+		certRes, err := client.Certificate.Obtain(certificate.ObtainRequest{Domains: []string{result.Domain}, Bundle: true})
+		if err != nil {
+			return "", err
+		}
+
+		cert, err := certcrypto.ParsePEMCertificate(certRes.Certificate)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("issued by %q, expires on %s", cert.Issuer.CommonName, cert.NotAfter.Format(time.RFC3339)), nil
+	})
+
+	if recorder == nil {
+		result.Steps = steps.steps
+
+		return result
+	}
+
+	steps.run("cleanup", func() (string, error) {
+		presented, cleaned := recorder.counts()
+		if presented != cleaned {
+			return "", fmt.Errorf("%d challenge(s) presented, %d cleaned up", presented, cleaned)
+		}
+
+		return fmt.Sprintf("%d challenge(s) presented and cleaned up", presented), nil
+	})
+
+	result.Steps = steps.steps
+
+	return result
+}
+
+// newSelftestClient creates a client from the options,
+// the certificate of the HTTPS listener of the server is trusted if rootCAs is defined (local Pebble server).
+func newSelftestClient(ctx *cli.Context, account registration.User, keyType certcrypto.KeyType, server string, rootCAs *x509.CertPool) (*lego.Client, error) {
+	config, err := newClientConfig(ctx, account, keyType, server)
+	if err != nil {
+		return nil, err
+	}
+
+	if rootCAs != nil {
+		transport, ok := config.HTTPClient.Transport.(*http.Transport)
+		if !ok {
+			return nil, errors.New("unexpected HTTP transport")
+		}
+
+		tr := transport.Clone()
+		tr.TLSClientConfig.RootCAs = rootCAs
+		config.HTTPClient.Transport = tr
+	}
+
+	return newLegoClient(config)
+}
+
+// setupSelftestChallenges defines the challenges of the client.
+// In mock mode, the providers are created but the challenges are handled by a mockProvider.
+func setupSelftestChallenges(ctx *cli.Context, client *lego.Client, mock bool) (*mockProvider, error) {
+	if !ctx.Bool(flgHTTP) && !ctx.Bool(flgTLS) && !isDNSChallenge(ctx) {
+		return nil, fmt.Errorf("no challenge selected: use `--%s`, `--%s`, or `--%s`", flgHTTP, flgTLS, flgDNS)
+	}
+
+	if !mock {
+		setupChallenges(ctx, client)
+		return nil, nil
+	}
+
+	recorder := &mockProvider{}
+
+	if ctx.Bool(flgHTTP) {
+		// validates the options of the provider.
+		_, err := setupHTTPProvider(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		err = client.Challenge.SetHTTP01Provider(recorder)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if ctx.Bool(flgTLS) {
+		// validates the options of the provider.
+		_, err := setupTLSProvider(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		err = client.Challenge.SetTLSALPN01Provider(recorder)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if isDNSChallenge(ctx) {
+		_, err := setupDNSProvider(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		skipPropagation := dns01.WrapPreCheck(func(_, _, _ string, _ dns01.PreCheckFunc) (bool, error) {
+			return true, nil
+		})
+
+		if ctx.Bool(flgDNSAccountLabel) {
+			err = client.Challenge.SetDNSAccount01Provider(recorder, skipPropagation)
+		} else {
+			err = client.Challenge.SetDNS01Provider(recorder, skipPropagation)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return recorder, nil
+}
+
+// registerSelftest registers the throwaway account.
+// The TOS of the local Pebble server are accepted.
+func registerSelftest(ctx *cli.Context, client *lego.Client) (*registration.Resource, error) {
+	if ctx.Bool(flgSelftestStaging) {
+		return register(ctx, client)
+	}
+
+	return client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
+}
+
+func printSelftestResult(result *selftestResult) {
+	mode := "mock"
+	if !result.Mock {
+		mode = "live"
+	}
+
+	fmt.Printf("Self-test of %s (%s challenges):\n", result.Domain, mode)
+
+	printSteps(result.Steps)
+}
+
+func printSteps(steps []selftestStep) {
+	for _, step := range steps {
+		line := fmt.Sprintf("  [%s] %s", step.Status, step.Name)
+
+		if step.Status != selftestSkipped {
+			line += " (" + step.Duration + ")"
+		}
+
+		if step.Detail != "" {
+			line += ": " + step.Detail
+		}
+
+		fmt.Println(line)
+	}
+}
+
+// mockProvider a challenge provider that only records the calls (selftest mock mode).
+type mockProvider struct {
+	mu        sync.Mutex
+	presented int
+	cleaned   int
+}
+
+func (p *mockProvider) Present(domain, _, _ string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	log.WithDomain(domain).Infof("selftest: challenge presented (mock)")
+
+	p.presented++
+
+	return nil
+}
+
+func (p *mockProvider) CleanUp(domain, _, _ string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	log.WithDomain(domain).Infof("selftest: challenge cleaned up (mock)")
+
+	p.cleaned++
+
+	return nil
+}
+
+func (p *mockProvider) counts() (int, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.presented, p.cleaned
+}
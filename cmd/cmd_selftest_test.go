@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/lego"
+	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func newSelftestTestContext(t *testing.T, globalArgs []string, args ...string) *cli.Context {
+	t.Helper()
+
+	parent := newTestContext(t, globalArgs...)
+
+	command := createSelftest()
+
+	set := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+
+	for _, f := range command.Flags {
+		require.NoError(t, f.Apply(set))
+	}
+
+	require.NoError(t, set.Parse(args))
+
+	return cli.NewContext(parent.App, set, parent)
+}
+
+func stepStatuses(steps []selftestStep) map[string]string {
+	statuses := make(map[string]string)
+	for _, step := range steps {
+		statuses[step.Name] = step.Status
+	}
+
+	return statuses
+}
+
+func Test_runSelftest_pebbleNotFound(t *testing.T) {
+	ctx := newSelftestTestContext(t, []string{"--http"}, "--pebble", "lego-pebble-not-found")
+
+	result := runSelftest(ctx)
+
+	expected := map[string]string{
+		"server":      selftestFailed,
+		"client":      selftestSkipped,
+		"challenge":   selftestSkipped,
+		"account":     selftestSkipped,
+		"certificate": selftestSkipped,
+	}
+	assert.Equal(t, expected, stepStatuses(result.Steps))
+	assert.Contains(t, result.Steps[0].Detail, "pebble binary not found")
+	assert.True(t, result.Mock)
+}
+
+func Test_runSelftest_stagingProduction(t *testing.T) {
+	ctx := newSelftestTestContext(t, []string{"--http", "--server", lego.LEDirectoryProduction}, "--staging")
+
+	result := runSelftest(ctx)
+
+	require.NotEmpty(t, result.Steps)
+	assert.Equal(t, selftestFailed, result.Steps[0].Status)
+	assert.Contains(t, result.Steps[0].Detail, "use a staging server")
+}
+
+func Test_runSelftest_staging(t *testing.T) {
+	_, apiURL := tester.SetupFakeAPI(t)
+
+	ctx := newSelftestTestContext(t, []string{"--http", "--accept-tos", "--server", apiURL + "/dir"}, "--staging")
+
+	result := runSelftest(ctx)
+
+	expected := map[string]string{
+		"server":      selftestOK,
+		"client":      selftestOK,
+		"challenge":   selftestOK,
+		"account":     selftestFailed, // the fake API does not support the registration.
+		"certificate": selftestSkipped,
+		"cleanup":     selftestSkipped,
+	}
+	assert.Equal(t, expected, stepStatuses(result.Steps))
+	assert.Equal(t, apiURL+"/dir", result.Server)
+	assert.Equal(t, "http-01 (server)", result.Steps[2].Detail)
+}
+
+func Test_setupSelftestChallenges(t *testing.T) {
+	_, apiURL := tester.SetupFakeAPI(t)
+
+	testCases := []struct {
+		desc         string
+		args         []string
+		mock         bool
+		expectedMock bool
+		expectedErr  string
+	}{
+		{
+			desc:        "no challenge",
+			mock:        true,
+			expectedErr: "no challenge selected: use `--http`, `--tls`, or `--dns`",
+		},
+		{
+			desc:         "HTTP-01 mock",
+			args:         []string{"--http"},
+			mock:         true,
+			expectedMock: true,
+		},
+		{
+			desc:         "DNS-01 mock",
+			args:         []string{"--dns", "manual"},
+			mock:         true,
+			expectedMock: true,
+		},
+		{
+			desc:        "DNS-01 mock unknown provider",
+			args:        []string{"--dns", "unknown"},
+			mock:        true,
+			expectedErr: "unrecognized DNS provider: unknown",
+		},
+		{
+			desc: "HTTP-01 live",
+			args: []string{"--http"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := newTestContext(t, test.args...)
+
+			privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.EC256)
+			require.NoError(t, err)
+
+			client, err := createClient(ctx, &Account{key: privateKey}, certcrypto.EC256, apiURL+"/dir")
+			require.NoError(t, err)
+
+			recorder, err := setupSelftestChallenges(ctx, client, test.mock)
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedMock, recorder != nil)
+		})
+	}
+}
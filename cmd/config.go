@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// configCertificatesKey the key of the list of the certificates in the configuration file.
+const configCertificatesKey = "certificates"
+
+// configAccountsKey the key of the accounts (by name) in the configuration file.
+const configAccountsKey = "accounts"
+
+// configAccountKey the option of a certificate defining the name of its account.
+const configAccountKey = "account"
+
+// configOptionsMetadataKey the key of the names of the options set by the configuration file, in the metadata of the application.
+const configOptionsMetadataKey = "lego.config.options"
+
+// Config the configuration file (YAML or TOML).
+// The keys are the names of the options (flags), ex:
+//
+//	email: you@example.com
+//	key-type: ec256
+//	certificates:
+//	  - domains: [example.com, www.example.com]
+//	    dns: cloudflare
+//	    renew-hook: ./reload.sh
+//	  - domains: [example.org]
+//	    key-type: rsa4096
+//	    http: true
+//	    account: staging
+//	accounts:
+//	  staging:
+//	    email: staging@example.com
+//	    server: https://acme-staging-v02.api.letsencrypt.org/directory
+type Config struct {
+	// Options the values of the global options.
+	Options map[string]any
+	// Certificates the options of each certificate (global options and options of the run and renew commands, except the account options).
+	Certificates []map[string]any
+	// Accounts the options of the accounts, by name (only the account options).
+	Accounts map[string]map[string]any
+}
+
+// readConfig reads the configuration file defined by the config option, if any.
+func readConfig(ctx *cli.Context) (*Config, error) {
+	filename := ctx.String(flgConfig)
+	if filename == "" {
+		return nil, nil
+	}
+
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	return parseConfig(filename, raw)
+}
+
+// parseConfig parses a configuration file, the format (YAML or TOML) is based on the extension of the file.
+func parseConfig(filename string, raw []byte) (*Config, error) {
+	var values map[string]any
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".toml":
+		err := toml.Unmarshal(raw, &values)
+		if err != nil {
+			return nil, fmt.Errorf("config: invalid TOML file %s: %w", filename, err)
+		}
+
+	default:
+		err := yaml.Unmarshal(raw, &values)
+		if err != nil {
+			return nil, fmt.Errorf("config: invalid YAML file %s: %w", filename, err)
+		}
+	}
+
+	config := &Config{Options: make(map[string]any)}
+
+	for key, value := range values {
+		if key == configAccountsKey {
+			accounts, err := parseConfigAccounts(value)
+			if err != nil {
+				return nil, fmt.Errorf("config: %s: %w", filename, err)
+			}
+
+			config.Accounts = accounts
+
+			continue
+		}
+
+		if key != configCertificatesKey {
+			config.Options[key] = value
+			continue
+		}
+
+		entries, ok := value.([]any)
+		if !ok {
+			// TOML array of tables.
+			tables, okT := value.([]map[string]any)
+			if !okT {
+				return nil, fmt.Errorf("config: %s: the certificates must be a list", filename)
+			}
+
+			for _, table := range tables {
+				entries = append(entries, table)
+			}
+		}
+
+		for i, entry := range entries {
+			certificate, err := toStringMap(entry)
+			if err != nil {
+				return nil, fmt.Errorf("config: %s: certificate %d: %w", filename, i, err)
+			}
+
+			config.Certificates = append(config.Certificates, certificate)
+		}
+	}
+
+	return config, nil
+}
+
+// parseConfigAccounts parses the accounts: a mapping of the names of the accounts to their options.
+func parseConfigAccounts(value any) (map[string]map[string]any, error) {
+	entries, err := toStringMap(value)
+	if err != nil {
+		return nil, errors.New("the accounts must be a mapping of names to options")
+	}
+
+	accounts := make(map[string]map[string]any, len(entries))
+
+	for name, entry := range entries {
+		options, err := toStringMap(entry)
+		if err != nil {
+			return nil, fmt.Errorf("account %q: %w", name, err)
+		}
+
+		for option := range options {
+			if !slices.Contains(accountOptions, option) {
+				return nil, fmt.Errorf("account %q: the option %q cannot be defined by account", name, option)
+			}
+		}
+
+		accounts[name] = options
+	}
+
+	return accounts, nil
+}
+
+// applyConfigOptions sets the global options defined in the configuration file.
+// The options defined by a flag or an environment variable are not overridden.
+func applyConfigOptions(ctx *cli.Context, config *Config) error {
+	for _, name := range sortedKeys(config.Options) {
+		if findFlag(ctx.App.Flags, name) == nil {
+			return fmt.Errorf("config: unknown option %q", name)
+		}
+
+		if ctx.IsSet(name) {
+			continue
+		}
+
+		values, err := configValues(config.Options[name])
+		if err != nil {
+			return fmt.Errorf("config: option %q: %w", name, err)
+		}
+
+		for _, value := range values {
+			if err = ctx.Set(name, value); err != nil {
+				return fmt.Errorf("config: option %q: %w", name, err)
+			}
+		}
+
+		configOptionNames(ctx)[name] = struct{}{}
+	}
+
+	return nil
+}
+
+// configOptionNames returns the names of the options set by the configuration file.
+func configOptionNames(ctx *cli.Context) map[string]struct{} {
+	names, ok := ctx.App.Metadata[configOptionsMetadataKey].(map[string]struct{})
+	if !ok {
+		names = make(map[string]struct{})
+		ctx.App.Metadata[configOptionsMetadataKey] = names
+	}
+
+	return names
+}
+
+// isSetByUser returns true if the option is defined by a flag or an environment variable (and not by the configuration file).
+func isSetByUser(ctx *cli.Context, name string) bool {
+	_, fromConfig := configOptionNames(ctx)[name]
+
+	return ctx.IsSet(name) && !fromConfig
+}
+
+// configValues converts a value of the configuration file to the values of a flag:
+// a list is a repeated flag, a mapping is a repeated flag with "key=value" values (ex: dns.route).
+func configValues(value any) ([]string, error) {
+	switch v := value.(type) {
+	case []any:
+		var values []string
+		for _, item := range v {
+			s, err := configValues(item)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, s...)
+		}
+
+		return values, nil
+
+	case map[any]any, map[string]any:
+		m, err := toStringMap(v)
+		if err != nil {
+			return nil, err
+		}
+
+		var values []string
+		for _, key := range sortedKeys(m) {
+			items, err := configValues(m[key])
+			if err != nil {
+				return nil, err
+			}
+
+			for _, item := range items {
+				values = append(values, key+"="+item)
+			}
+		}
+
+		return values, nil
+
+	default:
+		return []string{fmt.Sprint(v)}, nil
+	}
+}
+
+func toStringMap(value any) (map[string]any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		return v, nil
+
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = item
+		}
+
+		return m, nil
+
+	default:
+		return nil, fmt.Errorf("invalid value: %v", v)
+	}
+}
+
+func findFlag(flags []cli.Flag, name string) cli.Flag {
+	for _, flag := range flags {
+		if slices.Contains(flag.Names(), name) {
+			return flag
+		}
+	}
+
+	return nil
+}
+
+func isCommandFlag(commands []*cli.Command, name string) bool {
+	return slices.ContainsFunc(commands, func(command *cli.Command) bool {
+		return findFlag(command.Flags, name) != nil
+	})
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
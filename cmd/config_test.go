@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseConfig(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		filename string
+		raw      string
+	}{
+		{
+			desc:     "YAML",
+			filename: "lego.yaml",
+			raw: `
+email: you@example.com
+key-type: ec256
+certificates:
+  - domains: [example.com, www.example.com]
+    dns: cloudflare
+  - domains: [example.org]
+    http: true
+`,
+		},
+		{
+			desc:     "TOML",
+			filename: "lego.toml",
+			raw: `
+email = "you@example.com"
+key-type = "ec256"
+
+[[certificates]]
+domains = ["example.com", "www.example.com"]
+dns = "cloudflare"
+
+[[certificates]]
+domains = ["example.org"]
+http = true
+`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := parseConfig(test.filename, []byte(test.raw))
+			require.NoError(t, err)
+
+			assert.Equal(t, map[string]any{"email": "you@example.com", "key-type": "ec256"}, config.Options)
+
+			require.Len(t, config.Certificates, 2)
+
+			domains, err := configValues(config.Certificates[0][flgDomains])
+			require.NoError(t, err)
+			assert.Equal(t, []string{"example.com", "www.example.com"}, domains)
+
+			dns, err := configValues(config.Certificates[0][flgDNS])
+			require.NoError(t, err)
+			assert.Equal(t, []string{"cloudflare"}, dns)
+
+			http, err := configValues(config.Certificates[1][flgHTTP])
+			require.NoError(t, err)
+			assert.Equal(t, []string{"true"}, http)
+		})
+	}
+}
+
+func Test_parseConfig_accounts(t *testing.T) {
+	raw := `
+email: you@example.com
+accounts:
+  staging:
+    email: staging@example.com
+    server: https://acme-staging-v02.api.letsencrypt.org/directory
+certificates:
+  - domains: [example.com]
+    account: staging
+`
+
+	config, err := parseConfig("lego.yaml", []byte(raw))
+	require.NoError(t, err)
+
+	expected := map[string]map[string]any{
+		"staging": {
+			"email":  "staging@example.com",
+			"server": "https://acme-staging-v02.api.letsencrypt.org/directory",
+		},
+	}
+
+	assert.Equal(t, expected, config.Accounts)
+	assert.Equal(t, map[string]any{"email": "you@example.com"}, config.Options)
+}
+
+func Test_parseConfig_error(t *testing.T) {
+	_, err := parseConfig("lego.yaml", []byte("certificates: example.com"))
+	require.EqualError(t, err, "config: lego.yaml: the certificates must be a list")
+
+	_, err = parseConfig("lego.yaml", []byte("certificates: [example.com]"))
+	require.EqualError(t, err, "config: lego.yaml: certificate 0: invalid value: example.com")
+
+	_, err = parseConfig("lego.yaml", []byte("accounts: [staging]"))
+	require.EqualError(t, err, "config: lego.yaml: the accounts must be a mapping of names to options")
+
+	_, err = parseConfig("lego.yaml", []byte("accounts: {staging: {dns: cloudflare}}"))
+	require.EqualError(t, err, `config: lego.yaml: account "staging": the option "dns" cannot be defined by account`)
+}
+
+func Test_configValues(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		value    any
+		expected []string
+	}{
+		{
+			desc:     "scalar",
+			value:    45,
+			expected: []string{"45"},
+		},
+		{
+			desc:     "list",
+			value:    []any{"example.com", "www.example.com"},
+			expected: []string{"example.com", "www.example.com"},
+		},
+		{
+			desc:     "mapping",
+			value:    map[any]any{"example.org": "cloudflare", "example.com": "route53"},
+			expected: []string{"example.com=route53", "example.org=cloudflare"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			values, err := configValues(test.value)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, values)
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/lego"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/go-acme/lego/v4/storage"
+	"github.com/urfave/cli/v2"
+)
+
+// Extensions of the files of the CSR watch mode.
+const (
+	csrWatchExt       = ".csr"
+	csrWatchCertExt   = ".crt"
+	csrWatchIssuerExt = ".issuer.crt"
+	csrWatchErrorExt  = ".error"
+)
+
+// csrWatchSettleDelay the CSR files modified during this delay are processed by the next scan (the file may be partially written).
+const csrWatchSettleDelay = time.Second
+
+// Retries of the CSR files after a transient error (ex: network, storage):
+// the delay between two attempts is doubled after each attempt, and the error file is written after the last attempt.
+const (
+	csrWatchMaxAttempts   = 5
+	csrWatchRetryDelay    = time.Minute
+	csrWatchMaxRetryDelay = time.Hour
+)
+
+// csrWatcher obtains the certificates of the CSR files of a directory.
+type csrWatcher struct {
+	dir    string
+	delete bool
+
+	// obtain obtains the certificate of a CSR.
+	obtain func(csr *x509.CertificateRequest) (*certificate.Resource, error)
+	// done is called after the processing of a CSR file (certRes is nil if err is not nil).
+	// It is not called for the attempts which are retried.
+	done func(csrPath string, certRes *certificate.Resource, err error)
+
+	// retries the pending retries, by CSR path.
+	retries map[string]*csrRetry
+}
+
+// csrRetry the retry state of a CSR file.
+type csrRetry struct {
+	// modTime the modification time of the CSR file, a modified CSR file is a new CSR.
+	modTime  time.Time
+	attempts int
+	next     time.Time
+}
+
+// permanentError an error which is not fixed by retrying (ex: an invalid CSR).
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// watchCSRDirectory scans the CSR directory at each interval until the process is stopped (SIGINT, SIGTERM).
+func watchCSRDirectory(ctx *cli.Context, account *Account, failover *lego.Failover) error {
+	dir := ctx.String(flgCSRWatch)
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("CSR directory: %w", err)
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("CSR directory: %s is not a directory", dir)
+	}
+
+	watcher := &csrWatcher{
+		dir:    dir,
+		delete: ctx.Bool(flgCSRWatchDelete),
+		obtain: func(csr *x509.CertificateRequest) (*certificate.Resource, error) {
+			return obtainCertificateForCSR(ctx, failover, csr)
+		},
+		done: func(csrPath string, certRes *certificate.Resource, err error) {
+			if err != nil {
+				log.Warnf("csr-watch: %s: %v", csrPath, err)
+				reportFailure(ctx, notifyEventObtain, csrPath, err)
+
+				return
+			}
+
+			log.WithDomain(certRes.Domain).Infof("csr-watch: certificate obtained for %s", csrPath)
+			reportSuccess(ctx, notifyEventObtain, certRes)
+
+			base := csrWatchBasePath(csrPath)
+
+			meta := map[string]string{
+				hookEnvAccountEmail: account.Email,
+				hookEnvCertDomain:   certRes.Domain,
+				hookEnvCertPath:     base + csrWatchCertExt,
+			}
+
+			if certRes.IssuerCertificate != nil {
+				meta[hookEnvIssuerCertKeyPath] = base + csrWatchIssuerExt
+			}
+
+			hookCtx := newHookContext(notifyEventObtain, certRes, meta)
+
+			errH := launchHook(ctx.String(flgRunHook), ctx.Duration(flgRunHookTimeout), meta, hookCtx)
+			if errH != nil {
+				log.WithDomain(certRes.Domain).Warnf("csr-watch: the run hook failed: %v", errH)
+			}
+		},
+	}
+
+	runCtx, stop := signal.NotifyContext(ctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	interval := ctx.Duration(flgCSRWatchInterval)
+
+	log.Infof("csr-watch: watching %s [interval: %s]", dir, interval)
+
+	err = watcher.Run(runCtx, interval)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	log.Infof("csr-watch: stopped")
+
+	return nil
+}
+
+// Run scans the directory at each interval until the context is done.
+func (w *csrWatcher) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := w.Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan processes the CSR files that have not been processed yet.
+// A CSR file is processed if the certificate file or the error file is more recent than the CSR file.
+func (w *csrWatcher) Scan(ctx context.Context) error {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return fmt.Errorf("could not read the CSR directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !entry.Type().IsRegular() || !strings.EqualFold(filepath.Ext(entry.Name()), csrWatchExt) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		if time.Since(info.ModTime()) < csrWatchSettleDelay {
+			continue
+		}
+
+		csrPath := filepath.Join(w.dir, entry.Name())
+
+		if w.isProcessed(csrPath, info.ModTime()) {
+			continue
+		}
+
+		retry, ok := w.retries[csrPath]
+		if ok && retry.modTime.Equal(info.ModTime()) && time.Now().Before(retry.next) {
+			continue
+		}
+
+		certRes, err := w.process(csrPath)
+		if err != nil && w.scheduleRetry(csrPath, info.ModTime(), err) {
+			continue
+		}
+
+		delete(w.retries, csrPath)
+
+		if err != nil {
+			errW := writeCSRWatchFile(csrWatchBasePath(csrPath)+csrWatchErrorExt, []byte(err.Error()+"\n"))
+			if errW != nil {
+				return errW
+			}
+		}
+
+		w.done(csrPath, certRes, err)
+	}
+
+	return nil
+}
+
+// scheduleRetry schedules a new attempt for a transient error, and returns false if the error is final.
+func (w *csrWatcher) scheduleRetry(csrPath string, modTime time.Time, err error) bool {
+	if isPermanentCSRError(err) {
+		return false
+	}
+
+	retry, ok := w.retries[csrPath]
+	if !ok || !retry.modTime.Equal(modTime) {
+		retry = &csrRetry{modTime: modTime}
+	}
+
+	retry.attempts++
+
+	if retry.attempts >= csrWatchMaxAttempts {
+		return false
+	}
+
+	delay := min(csrWatchRetryDelay<<(retry.attempts-1), csrWatchMaxRetryDelay)
+	retry.next = time.Now().Add(delay)
+
+	if w.retries == nil {
+		w.retries = make(map[string]*csrRetry)
+	}
+
+	w.retries[csrPath] = retry
+
+	log.Warnf("csr-watch: %s: %v (attempt %d/%d, next attempt in %s)", csrPath, err, retry.attempts, csrWatchMaxAttempts, delay)
+
+	return true
+}
+
+// isPermanentCSRError returns true if the error is not fixed by retrying:
+// the CSR cannot be read, or the CA rejects the CSR or its identifiers.
+func isPermanentCSRError(err error) bool {
+	var permErr *permanentError
+	if errors.As(err, &permErr) {
+		return true
+	}
+
+	var problem *acme.ProblemDetails
+	if !errors.As(err, &problem) {
+		return false
+	}
+
+	switch problem.Type {
+	case acme.BadCSRErr, acme.CAAErr, acme.RejectedIdentifierErr, acme.UnsupportedIdentifierErr:
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *csrWatcher) process(csrPath string) (*certificate.Resource, error) {
+	csr, err := readCSRFile(csrPath)
+	if err != nil {
+		return nil, &permanentError{err: fmt.Errorf("could not read the CSR: %w", err)}
+	}
+
+	certRes, err := w.obtain(csr)
+	if err != nil {
+		return nil, fmt.Errorf("could not obtain the certificate: %w", err)
+	}
+
+	base := csrWatchBasePath(csrPath)
+
+	if certRes.IssuerCertificate != nil {
+		err = writeCSRWatchFile(base+csrWatchIssuerExt, certRes.IssuerCertificate)
+		if err != nil {
+			return nil, &storageError{err: err}
+		}
+	}
+
+	// The certificate file is written last: it marks the CSR as processed.
+	err = writeCSRWatchFile(base+csrWatchCertExt, certRes.Certificate)
+	if err != nil {
+		return nil, &storageError{err: err}
+	}
+
+	_ = os.Remove(base + csrWatchErrorExt)
+
+	if w.delete {
+		err = os.Remove(csrPath)
+		if err != nil {
+			log.Warnf("csr-watch: could not delete %s: %v", csrPath, err)
+		}
+	}
+
+	return certRes, nil
+}
+
+func (w *csrWatcher) isProcessed(csrPath string, modTime time.Time) bool {
+	base := csrWatchBasePath(csrPath)
+
+	for _, ext := range []string{csrWatchCertExt, csrWatchErrorExt} {
+		info, err := os.Stat(base + ext)
+		if err == nil && !info.ModTime().Before(modTime) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// csrWatchBasePath returns the path of the CSR file without its extension.
+func csrWatchBasePath(csrPath string) string {
+	return strings.TrimSuffix(csrPath, filepath.Ext(csrPath))
+}
+
+// writeCSRWatchFile writes a file atomically: the readers of the directory never see a partial file.
+// The certificates are public: the files are readable by the other users (ex: the services of the appliances).
+func writeCSRWatchFile(filename string, data []byte) error {
+	return storage.WriteFileAtomic(filename, data, 0o644, nil)
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCSR(t *testing.T, filename, domain string, modTime time.Time) {
+	t.Helper()
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.EC256)
+	require.NoError(t, err)
+
+	csr, err := certcrypto.GenerateCSR(privateKey, domain, nil, false)
+	require.NoError(t, err)
+
+	err = os.WriteFile(filename, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}), 0o600)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Chtimes(filename, modTime, modTime))
+}
+
+func newTestCSRWatcher(dir string, processed map[string]error) *csrWatcher {
+	return &csrWatcher{
+		dir: dir,
+		obtain: func(csr *x509.CertificateRequest) (*certificate.Resource, error) {
+			if csr.Subject.CommonName == "fail.example.com" {
+				return nil, errors.New("oops")
+			}
+
+			return &certificate.Resource{
+				Domain:            csr.Subject.CommonName,
+				Certificate:       []byte("cert " + csr.Subject.CommonName),
+				IssuerCertificate: []byte("issuer"),
+			}, nil
+		},
+		done: func(csrPath string, _ *certificate.Resource, err error) {
+			processed[filepath.Base(csrPath)] = err
+		},
+	}
+}
+
+func Test_csrWatcher_Scan(t *testing.T) {
+	dir := t.TempDir()
+
+	past := time.Now().Add(-time.Minute)
+
+	writeTestCSR(t, filepath.Join(dir, "a.csr"), "a.example.com", past)
+	writeTestCSR(t, filepath.Join(dir, "fail.csr"), "fail.example.com", past)
+	writeTestCSR(t, filepath.Join(dir, "recent.csr"), "recent.example.com", time.Now())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.csr"), []byte("invalid"), 0o600))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "invalid.csr"), past, past))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("not a CSR"), 0o600))
+
+	processed := make(map[string]error)
+
+	watcher := newTestCSRWatcher(dir, processed)
+
+	err := watcher.Scan(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, processed, 2)
+	assert.NoError(t, processed["a.csr"])
+	assert.ErrorContains(t, processed["invalid.csr"], "could not read the CSR")
+	assert.FileExists(t, filepath.Join(dir, "invalid.error"))
+
+	cert, err := os.ReadFile(filepath.Join(dir, "a.crt"))
+	require.NoError(t, err)
+	assert.Equal(t, "cert a.example.com", string(cert))
+
+	assert.FileExists(t, filepath.Join(dir, "a.issuer.crt"))
+	assert.FileExists(t, filepath.Join(dir, "a.csr"))
+	assert.NoFileExists(t, filepath.Join(dir, "a.error"))
+
+	// The transient errors are retried, the error file is written after the last attempt.
+	failPath := filepath.Join(dir, "fail.csr")
+
+	for attempt := 1; attempt < csrWatchMaxAttempts; attempt++ {
+		assert.NoFileExists(t, filepath.Join(dir, "fail.error"))
+		assert.NotContains(t, processed, "fail.csr")
+
+		require.Contains(t, watcher.retries, failPath)
+		assert.Equal(t, attempt, watcher.retries[failPath].attempts)
+
+		// The retry is not due yet.
+		err = watcher.Scan(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, attempt, watcher.retries[failPath].attempts)
+
+		watcher.retries[failPath].next = time.Time{}
+
+		err = watcher.Scan(context.Background())
+		require.NoError(t, err)
+	}
+
+	assert.NotContains(t, watcher.retries, failPath)
+	assert.EqualError(t, processed["fail.csr"], "could not obtain the certificate: oops")
+
+	errContent, err := os.ReadFile(filepath.Join(dir, "fail.error"))
+	require.NoError(t, err)
+	assert.Equal(t, "could not obtain the certificate: oops\n", string(errContent))
+
+	// The processed CSRs are not processed again.
+	clear(processed)
+
+	err = watcher.Scan(context.Background())
+	require.NoError(t, err)
+
+	assert.Empty(t, processed)
+
+	// A CSR file modified after its processing is processed again.
+	older := past.Add(-time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "fail.error"), older, older))
+
+	writeTestCSR(t, filepath.Join(dir, "fail.csr"), "b.example.com", past)
+
+	err = watcher.Scan(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, processed, 1)
+	require.NoError(t, processed["fail.csr"])
+
+	assert.FileExists(t, filepath.Join(dir, "fail.crt"))
+	assert.NoFileExists(t, filepath.Join(dir, "fail.error"))
+}
+
+func Test_csrWatcher_Scan_delete(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestCSR(t, filepath.Join(dir, "a.csr"), "a.example.com", time.Now().Add(-time.Minute))
+
+	processed := make(map[string]error)
+
+	watcher := newTestCSRWatcher(dir, processed)
+	watcher.delete = true
+
+	err := watcher.Scan(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, processed, 1)
+	assert.NoFileExists(t, filepath.Join(dir, "a.csr"))
+	assert.FileExists(t, filepath.Join(dir, "a.crt"))
+
+	info, err := os.Stat(filepath.Join(dir, "a.crt"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+}
+
+func Test_isPermanentCSRError(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		err      error
+		expected bool
+	}{
+		{
+			desc: "transient error",
+			err:  errors.New("connection refused"),
+		},
+		{
+			desc:     "invalid CSR",
+			err:      &permanentError{err: errors.New("could not read the CSR")},
+			expected: true,
+		},
+		{
+			desc:     "rejected identifier",
+			err:      fmt.Errorf("could not obtain the certificate: %w", &acme.ProblemDetails{Type: acme.RejectedIdentifierErr}),
+			expected: true,
+		},
+		{
+			desc: "rate limited",
+			err:  fmt.Errorf("could not obtain the certificate: %w", &acme.ProblemDetails{Type: acme.RateLimitedErr}),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, isPermanentCSRError(test.err))
+		})
+	}
+}
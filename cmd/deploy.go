@@ -0,0 +1,595 @@
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/go-acme/lego/v4/storage"
+	"github.com/go-acme/lego/v4/storage/kubernetes"
+	"github.com/go-acme/lego/v4/storage/vault"
+	"github.com/urfave/cli/v2"
+)
+
+// Deployer types.
+const (
+	deployFile       = "file"
+	deployHAProxy    = "haproxy"
+	deployKubernetes = "kubernetes"
+	deployVault      = "vault"
+)
+
+const (
+	defaultDeployReloadTimeout = 2 * time.Minute
+	defaultHAProxyTimeout      = 30 * time.Second
+)
+
+// deployer deploys a certificate after its issuance.
+type deployer interface {
+	Deploy(certRes *certificate.Resource, meta map[string]string) error
+}
+
+// deployerOptions the options of the deployers, by type.
+var deployerOptions = map[string][]string{
+	deployFile:       {"cert", "key", "issuer", "leaf", "chain", "fullchain", "combined", "der", "mode", "owner", "group", "reload", "timeout"},
+	deployHAProxy:    {"socket", "cert", "persist", "timeout"},
+	deployKubernetes: {"secret", "namespace", "kubeconfig"},
+	deployVault:      {"path", "mount", "address", "namespace"},
+}
+
+// parseDeployers parses the values of the deploy option: a type and options separated by semicolons
+// (the commas are the separator of the values of the option),
+// ex: "file:cert=/etc/nginx/tls/example.com.crt;key=/etc/nginx/tls/example.com.key;reload=systemctl reload nginx".
+func parseDeployers(ctx *cli.Context) ([]deployer, error) {
+	var deployers []deployer
+
+	for _, value := range ctx.StringSlice(flgDeploy) {
+		d, err := parseDeployer(ctx, value)
+		if err != nil {
+			return nil, err
+		}
+
+		deployers = append(deployers, d)
+	}
+
+	return deployers, nil
+}
+
+func parseDeployer(ctx *cli.Context, value string) (deployer, error) {
+	kind, options, err := parseDeployValue(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deployer %q: %w", value, err)
+	}
+
+	d, err := newDeployer(ctx, kind, options)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deployer %q: %w", value, err)
+	}
+
+	return d, nil
+}
+
+func parseDeployValue(value string) (string, map[string]string, error) {
+	kind, raw, _ := strings.Cut(value, ":")
+
+	allowed, ok := deployerOptions[kind]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported type %q", kind)
+	}
+
+	options := map[string]string{}
+
+	for _, option := range strings.Split(raw, ";") {
+		if strings.TrimSpace(option) == "" {
+			continue
+		}
+
+		k, v, found := strings.Cut(option, "=")
+		if !found {
+			return "", nil, fmt.Errorf("option %q: missing value", option)
+		}
+
+		k = strings.TrimSpace(k)
+
+		if !slices.Contains(allowed, k) {
+			return "", nil, fmt.Errorf("unsupported option %q, supported options: %s", k, strings.Join(allowed, ", "))
+		}
+
+		options[k] = strings.TrimSpace(v)
+	}
+
+	return kind, options, nil
+}
+
+func newDeployer(ctx *cli.Context, kind string, options map[string]string) (deployer, error) {
+	switch kind {
+	case deployFile:
+		return newFileDeployer(options)
+	case deployHAProxy:
+		return newHAProxyDeployer(options)
+	case deployKubernetes:
+		return newKubernetesDeployer(ctx, options)
+	case deployVault:
+		return newVaultDeployer(ctx, options)
+	default:
+		return nil, fmt.Errorf("unsupported type %q", kind)
+	}
+}
+
+// deployCertificate runs the deployers, all the deployers are executed even if one of them fails.
+func deployCertificate(ctx *cli.Context, certRes *certificate.Resource, meta map[string]string) error {
+	var errs []error
+
+	for _, value := range ctx.StringSlice(flgDeploy) {
+		d, err := parseDeployer(ctx, value)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		kind, _, _ := strings.Cut(value, ":")
+
+		err = d.Deploy(certRes, meta)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("deploy %s: %w", kind, err))
+			continue
+		}
+
+		log.WithDomain(certRes.Domain).Infof("The certificate has been deployed (%s).", kind)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Artifacts of the file deployer, in the order of the writes.
+// The private key is written first: the services reloading the certificate when it changes must find the matching key.
+var deployFileArtifacts = []string{
+	"key",       // the private key.
+	"combined",  // the full chain and the private key (ex: HAProxy).
+	"cert",      // the certificate as obtained (with the issuer certificate, unless --no-bundle is used).
+	"issuer",    // the issuer certificate.
+	"leaf",      // the leaf certificate only.
+	"chain",     // the intermediate certificates only.
+	"fullchain", // the leaf and the intermediate certificates.
+	"der",       // the leaf certificate (DER).
+}
+
+// deployFileTemplateData the data of the templates of the paths of the file deployer (ex: "/etc/tls/{{.Domain}}/{{.NotAfter}}/cert.pem").
+type deployFileTemplateData struct {
+	// Domain the main domain, sanitized as the names of the files of the storage (ex: "_.example.com" for "*.example.com").
+	Domain string
+	// NotBefore the start of the validity of the certificate (YYYY-MM-DD).
+	NotBefore string
+	// NotAfter the end of the validity of the certificate (YYYY-MM-DD).
+	NotAfter string
+	// Serial the serial number of the certificate (hexadecimal).
+	Serial string
+}
+
+// fileDeployer copies the certificate and the private key to files, then runs a reload command.
+type fileDeployer struct {
+	paths     map[string]*template.Template
+	templated bool
+	mode      os.FileMode
+	uid       int
+	gid       int
+	reload    string
+	timeout   time.Duration
+}
+
+func newFileDeployer(options map[string]string) (*fileDeployer, error) {
+	d := &fileDeployer{
+		paths:   make(map[string]*template.Template),
+		mode:    0o600,
+		uid:     -1,
+		gid:     -1,
+		reload:  options["reload"],
+		timeout: defaultDeployReloadTimeout,
+	}
+
+	for _, artifact := range deployFileArtifacts {
+		value := options[artifact]
+		if value == "" {
+			continue
+		}
+
+		tmpl, err := template.New(artifact).Option("missingkey=error").Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path template %q: %w", value, err)
+		}
+
+		d.paths[artifact] = tmpl
+		d.templated = d.templated || strings.Contains(value, "{{")
+	}
+
+	if len(d.paths) == 0 {
+		return nil, fmt.Errorf("at least one of the options '%s' is required", strings.Join(deployFileArtifacts, "', '"))
+	}
+
+	if value := options["mode"]; value != "" {
+		mode, err := strconv.ParseUint(value, 8, 32)
+		if err != nil || mode > 0o777 {
+			return nil, fmt.Errorf("invalid mode %q: must be an octal permission (ex: 0640)", value)
+		}
+
+		d.mode = os.FileMode(mode)
+	}
+
+	var err error
+
+	if value := options["owner"]; value != "" {
+		d.uid, err = lookupID(value, func(name string) (string, error) {
+			u, errU := user.Lookup(name)
+			if errU != nil {
+				return "", errU
+			}
+
+			return u.Uid, nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid owner: %w", err)
+		}
+	}
+
+	if value := options["group"]; value != "" {
+		d.gid, err = lookupID(value, func(name string) (string, error) {
+			g, errG := user.LookupGroup(name)
+			if errG != nil {
+				return "", errG
+			}
+
+			return g.Gid, nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid group: %w", err)
+		}
+	}
+
+	if value := options["timeout"]; value != "" {
+		d.timeout, err = time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+
+	return d, nil
+}
+
+func (d *fileDeployer) Deploy(certRes *certificate.Resource, meta map[string]string) error {
+	data, err := newDeployFileTemplateData(certRes)
+	if err != nil && d.templated {
+		return fmt.Errorf("template data: %w", err)
+	}
+
+	for _, artifact := range deployFileArtifacts {
+		tmpl, ok := d.paths[artifact]
+		if !ok {
+			continue
+		}
+
+		content, err := encodeDeployFileArtifact(artifact, certRes)
+		if err != nil {
+			return fmt.Errorf("%s: %w", artifact, err)
+		}
+
+		if artifact == "issuer" && content == nil {
+			continue
+		}
+
+		var filename strings.Builder
+
+		err = tmpl.Execute(&filename, data)
+		if err != nil {
+			return fmt.Errorf("%s: path: %w", artifact, err)
+		}
+
+		mode := d.mode
+		if artifact != "key" && artifact != "combined" {
+			// The certificates are public.
+			mode |= 0o444
+		}
+
+		err = os.MkdirAll(filepath.Dir(filename.String()), 0o755)
+		if err != nil {
+			return err
+		}
+
+		err = d.writeFile(filename.String(), content, mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.reload == "" {
+		return nil
+	}
+
+	err = launchHook(d.reload, d.timeout, meta, nil)
+	if err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+
+	return nil
+}
+
+func newDeployFileTemplateData(certRes *certificate.Resource) (*deployFileTemplateData, error) {
+	data := &deployFileTemplateData{Domain: sanitizedDomain(certRes.Domain)}
+
+	cert, err := certcrypto.ParsePEMCertificate(certRes.Certificate)
+	if err != nil {
+		return data, err
+	}
+
+	data.NotBefore = cert.NotBefore.UTC().Format(time.DateOnly)
+	data.NotAfter = cert.NotAfter.UTC().Format(time.DateOnly)
+	data.Serial = fmt.Sprintf("%x", cert.SerialNumber)
+
+	return data, nil
+}
+
+// encodeDeployFileArtifact returns the content of an artifact of the file deployer.
+// The content of the issuer artifact is nil if the CA does not provide the issuer certificate.
+func encodeDeployFileArtifact(artifact string, certRes *certificate.Resource) ([]byte, error) {
+	switch artifact {
+	case "key":
+		return certRes.PrivateKey, nil
+	case "cert":
+		return certRes.Certificate, nil
+	case "issuer":
+		return certRes.IssuerCertificate, nil
+	}
+
+	chain, err := certcrypto.ParseCertificateChain(certRes.Certificate, certRes.IssuerCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the certificate chain: %w", err)
+	}
+
+	fullchain := certcrypto.PEMEncodeCertificates(append([]*x509.Certificate{chain.Leaf}, chain.Intermediates...))
+
+	switch artifact {
+	case "combined":
+		if len(certRes.PrivateKey) == 0 {
+			return nil, errors.New("no private key")
+		}
+
+		return bytes.Join([][]byte{fullchain, certRes.PrivateKey}, nil), nil
+	case "leaf":
+		return certcrypto.PEMEncodeCertificates([]*x509.Certificate{chain.Leaf}), nil
+	case "chain":
+		if len(chain.Intermediates) == 0 {
+			return nil, errors.New("the certificate chain is empty")
+		}
+
+		return certcrypto.PEMEncodeCertificates(chain.Intermediates), nil
+	case "fullchain":
+		return fullchain, nil
+	case "der":
+		return chain.Leaf.Raw, nil
+	default:
+		return nil, fmt.Errorf("unsupported artifact %q", artifact)
+	}
+}
+
+// writeFile writes the file atomically (see [storage.WriteFileAtomic]), with the permissions and the owner of the deployer.
+func (d *fileDeployer) writeFile(filename string, data []byte, mode os.FileMode) error {
+	if len(data) == 0 {
+		return fmt.Errorf("no content for %s", filename)
+	}
+
+	return storage.WriteFileAtomic(filename, data, mode, func(f *os.File) error {
+		if d.uid == -1 && d.gid == -1 {
+			return nil
+		}
+
+		return f.Chown(d.uid, d.gid)
+	})
+}
+
+// lookupID returns a numeric ID, or the ID of the user or of the group with this name.
+func lookupID(value string, lookup func(string) (string, error)) (int, error) {
+	id, err := strconv.Atoi(value)
+	if err == nil {
+		return id, nil
+	}
+
+	raw, err := lookup(value)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(raw)
+}
+
+// haproxyDeployer updates a certificate of HAProxy without reload, through the Runtime API.
+// https://docs.haproxy.org/3.0/management.html#9.3-set%20ssl%20cert
+type haproxyDeployer struct {
+	socket  string
+	cert    string
+	persist bool
+	timeout time.Duration
+}
+
+func newHAProxyDeployer(options map[string]string) (*haproxyDeployer, error) {
+	d := &haproxyDeployer{
+		socket:  options["socket"],
+		cert:    options["cert"],
+		timeout: defaultHAProxyTimeout,
+	}
+
+	if d.socket == "" || d.cert == "" {
+		return nil, errors.New("the options 'socket' and 'cert' are required")
+	}
+
+	var err error
+
+	if value := options["persist"]; value != "" {
+		d.persist, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid persist: %w", err)
+		}
+	}
+
+	if value := options["timeout"]; value != "" {
+		d.timeout, err = time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+
+	return d, nil
+}
+
+func (d *haproxyDeployer) Deploy(certRes *certificate.Resource, _ map[string]string) error {
+	if len(certRes.PrivateKey) == 0 {
+		return errors.New("the private key is required")
+	}
+
+	// HAProxy format: the certificate chain followed by the private key.
+	payload := bytes.Join([][]byte{bytes.TrimSpace(certRes.Certificate), bytes.TrimSpace(certRes.PrivateKey)}, []byte("\n"))
+
+	// The payload is terminated by an empty line.
+	payload = bytes.ReplaceAll(payload, []byte("\n\n"), []byte("\n"))
+
+	response, err := d.command(fmt.Sprintf("set ssl cert %s <<\n%s\n\n", d.cert, payload))
+	if err != nil {
+		return err
+	}
+
+	if !strings.Contains(response, "Transaction") {
+		return fmt.Errorf("set ssl cert %s: %s", d.cert, response)
+	}
+
+	response, err = d.command(fmt.Sprintf("commit ssl cert %s\n", d.cert))
+	if err != nil {
+		return err
+	}
+
+	if !strings.Contains(response, "Success!") {
+		return fmt.Errorf("commit ssl cert %s: %s", d.cert, response)
+	}
+
+	if !d.persist {
+		return nil
+	}
+
+	// The file is used when HAProxy is restarted.
+	file := &fileDeployer{uid: -1, gid: -1}
+
+	return file.writeFile(d.cert, append(payload, '\n'), 0o600)
+}
+
+// command sends a command to the Runtime API (one connection by command), and returns the response.
+func (d *haproxyDeployer) command(cmd string) (string, error) {
+	network := "tcp"
+	if filepath.IsAbs(d.socket) {
+		network = "unix"
+	}
+
+	conn, err := net.DialTimeout(network, d.socket, d.timeout)
+	if err != nil {
+		return "", fmt.Errorf("haproxy runtime API: %w", err)
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	_ = conn.SetDeadline(time.Now().Add(d.timeout))
+
+	_, err = io.WriteString(conn, cmd)
+	if err != nil {
+		return "", fmt.Errorf("haproxy runtime API: %w", err)
+	}
+
+	response, err := io.ReadAll(conn)
+	if err != nil {
+		return "", fmt.Errorf("haproxy runtime API: %w", err)
+	}
+
+	return strings.TrimSpace(string(response)), nil
+}
+
+// kubernetesDeployer writes the certificate in a "kubernetes.io/tls" secret.
+type kubernetesDeployer struct {
+	secret string
+	config *kubernetes.Config
+}
+
+func newKubernetesDeployer(ctx *cli.Context, options map[string]string) (*kubernetesDeployer, error) {
+	d := &kubernetesDeployer{
+		secret: options["secret"],
+		config: &kubernetes.Config{
+			Kubeconfig: cmp.Or(options["kubeconfig"], ctx.String(flgStorageK8sKubeconfig)),
+			Namespace:  options["namespace"],
+		},
+	}
+
+	if d.secret == "" {
+		return nil, errors.New("the option 'secret' is required")
+	}
+
+	return d, nil
+}
+
+func (d *kubernetesDeployer) Deploy(certRes *certificate.Resource, _ map[string]string) error {
+	client, err := kubernetes.NewStorage(d.config)
+	if err != nil {
+		return err
+	}
+
+	return client.WriteTLSSecret(d.secret, certRes.Certificate, certRes.PrivateKey)
+}
+
+// vaultDeployer writes the certificate in a secret of a Vault KV secrets engine (version 2).
+type vaultDeployer struct {
+	path   string
+	config *vault.Config
+}
+
+func newVaultDeployer(ctx *cli.Context, options map[string]string) (*vaultDeployer, error) {
+	config := vault.NewDefaultConfig()
+	config.Address = cmp.Or(options["address"], ctx.String(flgStorageVaultAddress))
+	config.Token = ctx.String(flgStorageVaultToken)
+	config.Namespace = cmp.Or(options["namespace"], ctx.String(flgStorageVaultNamespace))
+	config.MountPath = cmp.Or(options["mount"], vault.DefaultMountPath)
+	config.BasePath = ""
+
+	d := &vaultDeployer{path: options["path"], config: config}
+
+	if d.path == "" {
+		return nil, errors.New("the option 'path' is required")
+	}
+
+	return d, nil
+}
+
+func (d *vaultDeployer) Deploy(certRes *certificate.Resource, _ map[string]string) error {
+	client, err := vault.NewStorage(d.config)
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]string{
+		"certificate": string(certRes.Certificate),
+		"private_key": string(certRes.PrivateKey),
+	}
+
+	if certRes.IssuerCertificate != nil {
+		fields["issuing_ca"] = string(certRes.IssuerCertificate)
+	}
+
+	return client.WriteSecret(d.path, fields)
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// applyDomainsFile adds the domains of the file defined by the domains-file option to the domains option.
+func applyDomainsFile(ctx *cli.Context) error {
+	filename := ctx.String(flgDomainsFile)
+	if filename == "" {
+		return nil
+	}
+
+	domains, err := readDomainsFile(filename)
+	if err != nil {
+		return err
+	}
+
+	// The domains of a file defined by the configuration file are also options of the configuration file.
+	if names := configOptionNames(ctx); !isSetByUser(ctx, flgDomainsFile) {
+		names[flgDomains] = struct{}{}
+	}
+
+	for _, domain := range domains {
+		if err = ctx.Set(flgDomains, domain); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readDomainsFile reads the domains of a file ("-" for the standard input).
+func readDomainsFile(filename string) ([]string, error) {
+	if filename == "-" {
+		return parseDomains(os.Stdin)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("domains file: %w", err)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	domains, err := parseDomains(file)
+	if err != nil {
+		return nil, fmt.Errorf("domains file %s: %w", filename, err)
+	}
+
+	return domains, nil
+}
+
+// parseDomains parses a list of domains: one domain per line, the blank lines and the comments (starting with #) are ignored.
+func parseDomains(r io.Reader) ([]string, error) {
+	var domains []string
+
+	scanner := bufio.NewScanner(r)
+
+	for i := 1; scanner.Scan(); i++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.ContainsAny(line, " \t,") {
+			return nil, fmt.Errorf("line %d: one domain per line is expected: %q", i, line)
+		}
+
+		domains = append(domains, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return domains, nil
+}
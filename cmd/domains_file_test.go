@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseDomains(t *testing.T) {
+	content := `
+# the main domain
+example.com
+
+www.example.com   # the www subdomain
+	*.example.org
+192.0.2.1
+`
+
+	domains, err := parseDomains(strings.NewReader(content))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"example.com", "www.example.com", "*.example.org", "192.0.2.1"}, domains)
+}
+
+func Test_parseDomains_error(t *testing.T) {
+	_, err := parseDomains(strings.NewReader("example.com\nexample.org www.example.org\n"))
+	require.EqualError(t, err, `line 2: one domain per line is expected: "example.org www.example.org"`)
+}
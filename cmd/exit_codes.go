@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/log"
+)
+
+// Exit codes.
+// The wrappers and the monitoring systems can use them to know the class of a failure.
+const (
+	// ExitCodeError a failure not covered by the other exit codes.
+	ExitCodeError = 1
+	// ExitCodeAuthFailure the CA rejected the account (ex: unknown account, external account binding required).
+	ExitCodeAuthFailure = 10
+	// ExitCodeRateLimited a rate limit of the CA has been exceeded.
+	ExitCodeRateLimited = 11
+	// ExitCodeValidationFailed the CA could not validate a challenge.
+	ExitCodeValidationFailed = 12
+	// ExitCodePropagationTimeout the TXT record of a DNS-01 challenge was not propagated before the propagation timeout.
+	ExitCodePropagationTimeout = 13
+	// ExitCodeStorageError the accounts or the certificates could not be read or written.
+	ExitCodeStorageError = 14
+	// ExitCodePartialSuccess some certificates have been obtained or renewed, some have failed (several certificates).
+	ExitCodePartialSuccess = 15
+)
+
+// authProblemTypes the problem types of the account failures.
+var authProblemTypes = []string{
+	acme.UnauthorizedErr,
+	acme.AccountDoesNotExistErr,
+	acme.ExternalAccountRequiredErr,
+	acme.BadPublicKeyErr,
+	acme.BadSignatureAlgorithmErr,
+	acme.UserActionRequiredErr,
+}
+
+// storageError an error of the storage of the accounts or the certificates.
+type storageError struct {
+	err error
+}
+
+func (e *storageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *storageError) Unwrap() error {
+	return e.err
+}
+
+// fatalStoragef logs a failure of the storage and exits with ExitCodeStorageError.
+func fatalStoragef(format string, args ...any) {
+	Exit(&storageError{err: fmt.Errorf(format, args...)})
+}
+
+// partialSuccessError the errors of the certificates that failed, when other certificates succeeded.
+type partialSuccessError struct {
+	err error
+}
+
+func (e *partialSuccessError) Error() string {
+	return e.err.Error()
+}
+
+func (e *partialSuccessError) Unwrap() error {
+	return e.err
+}
+
+// ExitCode returns the exit code of an error returned by a command.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var partialErr *partialSuccessError
+	if errors.As(err, &partialErr) {
+		return ExitCodePartialSuccess
+	}
+
+	problems := acme.ProblemsByIdentifier(err)
+
+	var rateLimitErr *certificate.RateLimitError
+	if errors.As(err, &rateLimitErr) || hasProblem(problems, func(identifier string, problem *acme.ProblemDetails) bool {
+		return problem.Type == acme.RateLimitedErr
+	}) {
+		return ExitCodeRateLimited
+	}
+
+	if errors.Is(err, dns01.ErrPropagationTimeout) {
+		return ExitCodePropagationTimeout
+	}
+
+	// The problems related to an identifier are the failures of the validation of the challenges.
+	if hasProblem(problems, func(identifier string, _ *acme.ProblemDetails) bool { return identifier != "" }) {
+		return ExitCodeValidationFailed
+	}
+
+	if hasProblem(problems, func(identifier string, problem *acme.ProblemDetails) bool {
+		return identifier == "" && slices.Contains(authProblemTypes, problem.Type)
+	}) {
+		return ExitCodeAuthFailure
+	}
+
+	var storageErr *storageError
+	if errors.As(err, &storageErr) {
+		return ExitCodeStorageError
+	}
+
+	return ExitCodeError
+}
+
+func hasProblem(problems map[string]*acme.ProblemDetails, fn func(identifier string, problem *acme.ProblemDetails) bool) bool {
+	for identifier, problem := range problems {
+		if fn(identifier, problem) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Exit logs the error and exits with the exit code of the error (see ExitCode).
+func Exit(err error) {
+	if logger, ok := log.Logger.(log.AttrLogger); ok {
+		logger.LogAttrs(log.LevelFatal, err.Error())
+	} else {
+		log.Print(err)
+	}
+
+	os.Exit(ExitCode(err))
+}
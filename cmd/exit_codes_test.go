@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		err      error
+		expected int
+	}{
+		{
+			desc:     "no error",
+			expected: 0,
+		},
+		{
+			desc:     "generic error",
+			err:      errors.New("oops"),
+			expected: ExitCodeError,
+		},
+		{
+			desc:     "account unauthorized",
+			err:      fmt.Errorf("could not complete registration: %w", &acme.ProblemDetails{Type: acme.UnauthorizedErr}),
+			expected: ExitCodeAuthFailure,
+		},
+		{
+			desc:     "external account required",
+			err:      &acme.ProblemDetails{Type: acme.ExternalAccountRequiredErr},
+			expected: ExitCodeAuthFailure,
+		},
+		{
+			desc:     "rate limited by the CA",
+			err:      fmt.Errorf("could not obtain certificates: %w", &acme.ProblemDetails{Type: acme.RateLimitedErr}),
+			expected: ExitCodeRateLimited,
+		},
+		{
+			desc:     "rate limited by the scheduler",
+			err:      &certificate.RateLimitError{Limit: "certificatesPerDomain"},
+			expected: ExitCodeRateLimited,
+		},
+		{
+			desc: "validation failed",
+			err: errors.Join(
+				&acme.IdentifierError{Identifier: "example.com", Err: &acme.ProblemDetails{Type: acme.UnauthorizedErr}},
+			),
+			expected: ExitCodeValidationFailed,
+		},
+		{
+			desc: "validation failed (subproblems)",
+			err: &acme.ProblemDetails{
+				Type:        acme.CompoundErr,
+				SubProblems: []acme.SubProblem{{Type: acme.DNSErr, Identifier: acme.Identifier{Value: "example.com"}}},
+			},
+			expected: ExitCodeValidationFailed,
+		},
+		{
+			desc:     "propagation timeout",
+			err:      &acme.IdentifierError{Identifier: "example.com", Err: fmt.Errorf("wrapped: %w", dns01.ErrPropagationTimeout)},
+			expected: ExitCodePropagationTimeout,
+		},
+		{
+			desc:     "storage error",
+			err:      &storageError{err: errors.New("permission denied")},
+			expected: ExitCodeStorageError,
+		},
+		{
+			desc:     "partial success",
+			err:      &partialSuccessError{err: &acme.ProblemDetails{Type: acme.RateLimitedErr}},
+			expected: ExitCodePartialSuccess,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, ExitCode(test.err))
+		})
+	}
+}
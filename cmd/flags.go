@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/go-acme/lego/v4/certificate"
 	"github.com/go-acme/lego/v4/lego"
+	"github.com/go-acme/lego/v4/storage/vault"
 	"github.com/urfave/cli/v2"
 	"software.sslmate.com/src/go-pkcs12"
 )
@@ -13,7 +15,11 @@ import (
 // Flag names.
 const (
 	flgDomains                  = "domains"
+	flgDomainsFile              = "domains-file"
 	flgServer                   = "server"
+	flgFallbackServer           = "fallback-server"
+	flgFallbackKID              = "fallback-kid"
+	flgFallbackHMAC             = "fallback-hmac"
 	flgAcceptTOS                = "accept-tos"
 	flgEmail                    = "email"
 	flgCSR                      = "csr"
@@ -23,16 +29,65 @@ const (
 	flgKeyType                  = "key-type"
 	flgFilename                 = "filename"
 	flgPath                     = "path"
+	flgStorage                  = "storage"
+	flgStorageVaultAddress      = "storage.vault-address"
+	flgStorageVaultToken        = "storage.vault-token"
+	flgStorageVaultNamespace    = "storage.vault-namespace"
+	flgStorageVaultMount        = "storage.vault-mount"
+	flgStorageVaultPath         = "storage.vault-path"
+	flgStorageS3Bucket          = "storage.s3-bucket"
+	flgStorageS3Prefix          = "storage.s3-prefix"
+	flgStorageS3Region          = "storage.s3-region"
+	flgStorageS3Endpoint        = "storage.s3-endpoint"
+	flgStorageS3SSE             = "storage.s3-sse"
+	flgStorageS3KMSKeyID        = "storage.s3-kms-key-id"
+	flgStorageS3Versioning      = "storage.s3-require-versioning"
+	flgStorageK8sKubeconfig     = "storage.kubernetes-kubeconfig"
+	flgStorageK8sNamespace      = "storage.kubernetes-namespace"
+	flgStorageK8sSecretPrefix   = "storage.kubernetes-secret-prefix"
 	flgHTTP                     = "http"
 	flgHTTPPort                 = "http.port"
 	flgHTTPProxyHeader          = "http.proxy-header"
+	flgHTTPPortRedirect         = "http.port-redirect"
+	flgHTTPSystemdSocket        = "http.systemd-socket"
+	flgHTTPStrict               = "http.strict"
+	flgHTTPRateLimit            = "http.rate-limit"
+	flgHTTPRateBurst            = "http.rate-burst"
+	flgHTTPAuditLog             = "http.audit-log"
 	flgHTTPWebroot              = "http.webroot"
+	flgHTTPWebrootSelfCheck     = "http.webroot-self-check"
 	flgHTTPMemcachedHost        = "http.memcached-host"
 	flgHTTPS3Bucket             = "http.s3-bucket"
+	flgHTTPRedisHost            = "http.redis-host"
+	flgHTTPRedisMasterName      = "http.redis-master-name"
+	flgHTTPRedisUsername        = "http.redis-username"
+	flgHTTPRedisPassword        = "http.redis-password"
+	flgHTTPRedisDB              = "http.redis-db"
+	flgHTTPRedisTLS             = "http.redis-tls"
+	flgHTTPRedisKeyPrefix       = "http.redis-key-prefix"
+	flgHTTPEtcdEndpoints        = "http.etcd-endpoints"
+	flgHTTPEtcdPrefix           = "http.etcd-prefix"
+	flgHTTPEtcdUsername         = "http.etcd-username"
+	flgHTTPEtcdPassword         = "http.etcd-password"
+	flgHTTPConsulAddress        = "http.consul-address"
+	flgHTTPConsulPrefix         = "http.consul-prefix"
+	flgHTTPConsulToken          = "http.consul-token"
 	flgTLS                      = "tls"
 	flgTLSPort                  = "tls.port"
+	flgTLSReusePort             = "tls.reuse-port"
+	flgTLSDrainTimeout          = "tls.drain-timeout"
+	flgTLSSystemdSocket         = "tls.systemd-socket"
+	flgTLSSNIRouter             = "tls.sni-router"
+	flgTLSSNIRouterAddress      = "tls.sni-router-address"
+	flgTLSHAProxyCrtList        = "tls.haproxy-crt-list"
+	flgTLSStoreDir              = "tls.store-dir"
+	flgTLSRedisHost             = "tls.redis-host"
+	flgTLSRedisPassword         = "tls.redis-password"
+	flgTLSRedisKeyPrefix        = "tls.redis-key-prefix"
 	flgDNS                      = "dns"
+	flgDNSRoute                 = "dns.route"
 	flgDNSDisableCP             = "dns.disable-cp"
+	flgDNSAccountLabel          = "dns.account-label"
 	flgDNSPropagationWait       = "dns.propagation-wait"
 	flgDNSPropagationDisableANS = "dns.propagation-disable-ans"
 	flgDNSPropagationRNS        = "dns.propagation-rns"
@@ -44,29 +99,102 @@ const (
 	flgPFX                      = "pfx"
 	flgPFXPass                  = "pfx.pass"
 	flgPFXFormat                = "pfx.format"
+	flgOutputFormat             = "output-format"
+	flgOCSPStaple               = "ocsp-staple"
+	flgKeyStore                 = "keystore"
+	flgKeyStoreFormat           = "keystore.format"
+	flgKeyStorePass             = "keystore.pass"
+	flgKeyStoreKeyPass          = "keystore.key-pass"
+	flgKeyStoreAlias            = "keystore.alias"
+	flgKeyStoreTrustAlias       = "keystore.truststore-alias"
+	flgKeyPass                  = "key.pass"
+	flgKeyPassFile              = "key.pass-file"
+	flgAccountKeyPass           = "account.key-pass"
+	flgAccountKeyPassFile       = "account.key-pass-file"
+	flgAccountEncryptKeys       = "account.encrypt-keys"
 	flgCertTimeout              = "cert.timeout"
 	flgOverallRequestLimit      = "overall-request-limit"
+	flgCAAPreCheck              = "caa-precheck"
 	flgUserAgent                = "user-agent"
+	flgOutput                   = "output"
+	flgLogFormat                = "log-format"
+	flgLogLevel                 = "log-level"
+	flgQuiet                    = "quiet"
+	flgConfig                   = "config"
+	flgNotifyWebhook            = "notify.webhook"
+	flgNotifySMTPAddress        = "notify.smtp-address"
+	flgNotifySMTPUsername       = "notify.smtp-username"
+	flgNotifySMTPPassword       = "notify.smtp-password"
+	flgNotifySMTPFrom           = "notify.smtp-from"
+	flgNotifySMTPTo             = "notify.smtp-to"
+	flgMetricsTextfile          = "metrics.textfile"
+	flgDeploy                   = "deploy"
+	flgHistoryFile              = "history.file"
+	flgHistoryDisable           = "history.disable"
+	flgHistoryMaxEntries        = "history.max-entries"
+	flgProxy                    = "proxy"
+	flgCACert                   = "cacert"
 )
 
 const (
-	envEAB         = "LEGO_EAB"
-	envEABHMAC     = "LEGO_EAB_HMAC"
-	envEABKID      = "LEGO_EAB_KID"
-	envEmail       = "LEGO_EMAIL"
-	envPath        = "LEGO_PATH"
-	envPFX         = "LEGO_PFX"
-	envPFXFormat   = "LEGO_PFX_FORMAT"
-	envPFXPassword = "LEGO_PFX_PASSWORD"
-	envServer      = "LEGO_SERVER"
+	envEAB             = "LEGO_EAB"
+	envEABHMAC         = "LEGO_EAB_HMAC"
+	envEABKID          = "LEGO_EAB_KID"
+	envEmail           = "LEGO_EMAIL"
+	envPath            = "LEGO_PATH"
+	envStorage         = "LEGO_STORAGE"
+	envVaultAddress    = "VAULT_ADDR"
+	envVaultToken      = "VAULT_TOKEN"
+	envVaultNamespace  = "VAULT_NAMESPACE"
+	envKubeconfig      = "KUBECONFIG"
+	envRedisPass       = "LEGO_REDIS_PASSWORD"
+	envEtcdUser        = "LEGO_ETCD_USERNAME"
+	envEtcdPass        = "LEGO_ETCD_PASSWORD"
+	envConsulToken     = "CONSUL_HTTP_TOKEN"
+	envPFX             = "LEGO_PFX"
+	envPFXFormat       = "LEGO_PFX_FORMAT"
+	envPFXPassword     = "LEGO_PFX_PASSWORD"
+	envOutputFormat    = "LEGO_OUTPUT_FORMAT"
+	envOCSPStaple      = "LEGO_OCSP_STAPLE"
+	envKeyStore        = "LEGO_KEYSTORE"
+	envKeyStoreFormat  = "LEGO_KEYSTORE_FORMAT"
+	envKeyStorePass    = "LEGO_KEYSTORE_PASSWORD"
+	envKeyStoreKeyPass = "LEGO_KEYSTORE_KEY_PASSWORD"
+	envKeyPassword     = "LEGO_KEY_PASSWORD"
+	envKeyPasswordFile = "LEGO_KEY_PASSWORD_FILE"
+	envAccountKeyPass  = "LEGO_ACCOUNT_KEY_PASSWORD"
+	envAccountKeyFile  = "LEGO_ACCOUNT_KEY_PASSWORD_FILE"
+	envServer          = "LEGO_SERVER"
+	envFallbackServer  = "LEGO_FALLBACK_SERVER"
+	envFallbackEABKID  = "LEGO_FALLBACK_EAB_KID"
+	envFallbackEABHMAC = "LEGO_FALLBACK_EAB_HMAC"
+	envOutput          = "LEGO_OUTPUT"
+	envLogFormat       = "LEGO_LOG_FORMAT"
+	envLogLevel        = "LEGO_LOG_LEVEL"
+	envConfig          = "LEGO_CONFIG"
+	envNotifyWebhook   = "LEGO_NOTIFY_WEBHOOK"
+	envNotifySMTPPass  = "LEGO_NOTIFY_SMTP_PASSWORD"
+	envProxy           = "LEGO_PROXY"
+	envCACert          = "LEGO_CACERT"
 )
 
 func CreateFlags(defaultPath string) []cli.Flag {
 	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    flgConfig,
+			EnvVars: []string{envConfig},
+			Usage: "Configuration file (YAML, or TOML with the .toml extension) defining the options and the certificates (domains, challenges, key type, hooks)." +
+				" The flags and the environment variables override the configuration file.",
+		},
 		&cli.StringSliceFlag{
 			Name:    flgDomains,
 			Aliases: []string{"d"},
-			Usage:   "Add a domain to the process. Can be specified multiple times.",
+			Usage:   "Add a domain (or an IP address) to the process. Can be specified multiple times.",
+		},
+		&cli.StringFlag{
+			Name: flgDomainsFile,
+			Usage: "Add the domains (or IP addresses) of a file to the process, one domain per line ('-' for the standard input)." +
+				" The blank lines and the comments (starting with #) are ignored.",
 		},
 		&cli.StringFlag{
 			Name:    flgServer,
@@ -75,6 +203,22 @@ func CreateFlags(defaultPath string) []cli.Flag {
 			Usage:   "CA hostname (and optionally :port). The server certificate must be trusted in order to avoid further modifications to the client.",
 			Value:   lego.LEDirectoryProduction,
 		},
+		&cli.StringSliceFlag{
+			Name:    flgFallbackServer,
+			EnvVars: []string{envFallbackServer},
+			Usage: "Fallback CA directory URL, used when the CA (--server) is unavailable (persistent server or network errors)." +
+				" Each CA has its own account, registered the first time the CA is used. Can be specified multiple times, the CAs are tried in order.",
+		},
+		&cli.StringFlag{
+			Name:    flgFallbackKID,
+			EnvVars: []string{envFallbackEABKID},
+			Usage:   "Key identifier used for the External Account Binding with the fallback CAs requiring it.",
+		},
+		&cli.StringFlag{
+			Name:    flgFallbackHMAC,
+			EnvVars: []string{envFallbackEABHMAC},
+			Usage:   "MAC key used for the External Account Binding with the fallback CAs requiring it. Should be in Base64 URL Encoding without padding format.",
+		},
 		&cli.BoolFlag{
 			Name:    flgAcceptTOS,
 			Aliases: []string{"a"},
@@ -122,6 +266,79 @@ func CreateFlags(defaultPath string) []cli.Flag {
 			Usage:   "Directory to use for storing the data.",
 			Value:   defaultPath,
 		},
+		&cli.StringFlag{
+			Name:    flgStorage,
+			EnvVars: []string{envStorage},
+			Usage:   "The storage of the certificates and accounts. Supported: file, vault, s3, kubernetes.",
+			Value:   storageFile,
+		},
+		&cli.StringFlag{
+			Name:    flgStorageVaultAddress,
+			EnvVars: []string{envVaultAddress},
+			Usage:   "The address of the Vault server (storage 'vault').",
+		},
+		&cli.StringFlag{
+			Name:    flgStorageVaultToken,
+			EnvVars: []string{envVaultToken},
+			Usage:   "The Vault token (storage 'vault').",
+		},
+		&cli.StringFlag{
+			Name:    flgStorageVaultNamespace,
+			EnvVars: []string{envVaultNamespace},
+			Usage:   "The Vault Enterprise namespace (storage 'vault').",
+		},
+		&cli.StringFlag{
+			Name:  flgStorageVaultMount,
+			Usage: "The mount path of the KV secrets engine (version 2) (storage 'vault').",
+			Value: vault.DefaultMountPath,
+		},
+		&cli.StringFlag{
+			Name:  flgStorageVaultPath,
+			Usage: "The base path of the secrets in the KV secrets engine (storage 'vault').",
+			Value: vault.DefaultBasePath,
+		},
+		&cli.StringFlag{
+			Name:  flgStorageS3Bucket,
+			Usage: "The name of the bucket (storage 's3'). The credentials are loaded from the AWS default sources (environment variables, shared configuration, etc.).",
+		},
+		&cli.StringFlag{
+			Name:  flgStorageS3Prefix,
+			Usage: "The prefix of the object keys (storage 's3').",
+		},
+		&cli.StringFlag{
+			Name:  flgStorageS3Region,
+			Usage: "The region of the bucket, overrides the region of the AWS configuration (storage 's3').",
+		},
+		&cli.StringFlag{
+			Name:  flgStorageS3Endpoint,
+			Usage: "A custom endpoint, for the S3 compatible object storages (ex: MinIO) (storage 's3').",
+		},
+		&cli.StringFlag{
+			Name:  flgStorageS3SSE,
+			Usage: "The server-side encryption: AES256, aws:kms, or empty to disable it (storage 's3').",
+			Value: "AES256",
+		},
+		&cli.StringFlag{
+			Name:  flgStorageS3KMSKeyID,
+			Usage: "The ID of the KMS key used by the 'aws:kms' server-side encryption (storage 's3').",
+		},
+		&cli.BoolFlag{
+			Name:  flgStorageS3Versioning,
+			Usage: "Ensure that the versioning is enabled on the bucket, so the previous versions of the files are kept (storage 's3').",
+		},
+		&cli.StringFlag{
+			Name:    flgStorageK8sKubeconfig,
+			EnvVars: []string{envKubeconfig},
+			Usage:   "The path of a kubeconfig file, the in-cluster configuration is used if empty (storage 'kubernetes').",
+		},
+		&cli.StringFlag{
+			Name:  flgStorageK8sNamespace,
+			Usage: "The namespace of the secrets, the namespace of the pod or of the kubeconfig context is used if empty (storage 'kubernetes').",
+		},
+		&cli.StringFlag{
+			Name:  flgStorageK8sSecretPrefix,
+			Usage: "The prefix of the names of the secrets (storage 'kubernetes').",
+		},
 		&cli.BoolFlag{
 			Name:  flgHTTP,
 			Usage: "Use the HTTP-01 challenge to solve challenges. Can be mixed with other types of challenges.",
@@ -137,9 +354,41 @@ func CreateFlags(defaultPath string) []cli.Flag {
 			Value: "Host",
 		},
 		&cli.StringFlag{
+			Name: flgHTTPPortRedirect,
+			Usage: "Install a redirect rule from the port 80 to the port defined by --http.port while solving HTTP-01 based challenges (Linux only)." +
+				" Supported: iptables, ip6tables (iptables and ip6tables), nftables.",
+		},
+		&cli.StringFlag{
+			Name: flgHTTPSystemdSocket,
+			Usage: "Use the socket passed by systemd with this name (FileDescriptorName= of the socket unit) for the HTTP-01 challenge server," +
+				" instead of listening on --http.port (socket activation).",
+		},
+		&cli.BoolFlag{
+			Name:  flgHTTPStrict,
+			Usage: "Reject the requests that don't match the HTTP-01 challenge (unexpected path, method, or domain) instead of answering them with a dummy body.",
+		},
+		&cli.Float64Flag{
+			Name:  flgHTTPRateLimit,
+			Usage: "Limit the number of requests per second to the challenge path answered by the HTTP-01 challenge server. 0 disables the limit.",
+		},
+		&cli.IntFlag{
+			Name:  flgHTTPRateBurst,
+			Usage: "Set the burst size of the rate limit of the HTTP-01 challenge server.",
+			Value: 10,
+		},
+		&cli.BoolFlag{
+			Name:  flgHTTPAuditLog,
+			Usage: "Log every request received by the HTTP-01 challenge server.",
+		},
+		&cli.StringSliceFlag{
 			Name: flgHTTPWebroot,
 			Usage: "Set the webroot folder to use for HTTP-01 based challenges to write directly to the .well-known/acme-challenge file." +
-				" This disables the built-in server and expects the given directory to be publicly served with access to .well-known/acme-challenge",
+				" This disables the built-in server and expects the given directory to be publicly served with access to .well-known/acme-challenge." +
+				" Can be specified multiple times: the file is written in all the folders.",
+		},
+		&cli.BoolFlag{
+			Name:  flgHTTPWebrootSelfCheck,
+			Usage: "Check that the challenge file written in the webroot folder(s) is reachable through HTTP before asking the CA to validate it.",
 		},
 		&cli.StringSliceFlag{
 			Name:  flgHTTPMemcachedHost,
@@ -149,6 +398,67 @@ func CreateFlags(defaultPath string) []cli.Flag {
 			Name:  flgHTTPS3Bucket,
 			Usage: "Set the S3 bucket name to use for HTTP-01 based challenges. Challenges will be written to the S3 bucket.",
 		},
+		&cli.StringSliceFlag{
+			Name: flgHTTPRedisHost,
+			Usage: "Set the Redis host(s) to use for HTTP-01 based challenges. Challenges will be written to Redis." +
+				" Several hosts target a cluster, or the sentinels when a master name is set.",
+		},
+		&cli.StringFlag{
+			Name:  flgHTTPRedisMasterName,
+			Usage: "Set the Redis sentinel master name to use for HTTP-01 based challenges.",
+		},
+		&cli.StringFlag{
+			Name:  flgHTTPRedisUsername,
+			Usage: "Set the Redis username to use for HTTP-01 based challenges.",
+		},
+		&cli.StringFlag{
+			Name:    flgHTTPRedisPassword,
+			Usage:   "Set the Redis password to use for HTTP-01 based challenges.",
+			EnvVars: []string{envRedisPass},
+		},
+		&cli.IntFlag{
+			Name:  flgHTTPRedisDB,
+			Usage: "Set the Redis database to use for HTTP-01 based challenges.",
+		},
+		&cli.BoolFlag{
+			Name:  flgHTTPRedisTLS,
+			Usage: "Use TLS to connect to Redis for HTTP-01 based challenges.",
+		},
+		&cli.StringFlag{
+			Name:  flgHTTPRedisKeyPrefix,
+			Usage: "Set the prefix of the Redis keys used for HTTP-01 based challenges.",
+		},
+		&cli.StringSliceFlag{
+			Name:  flgHTTPEtcdEndpoints,
+			Usage: "Set the etcd endpoint(s) to use for HTTP-01 based challenges. Challenges will be written to etcd. Supported: scheme://host:port.",
+		},
+		&cli.StringFlag{
+			Name:  flgHTTPEtcdPrefix,
+			Usage: "Set the prefix of the etcd keys used for HTTP-01 based challenges.",
+		},
+		&cli.StringFlag{
+			Name:    flgHTTPEtcdUsername,
+			Usage:   "Set the etcd username to use for HTTP-01 based challenges.",
+			EnvVars: []string{envEtcdUser},
+		},
+		&cli.StringFlag{
+			Name:    flgHTTPEtcdPassword,
+			Usage:   "Set the etcd password to use for HTTP-01 based challenges.",
+			EnvVars: []string{envEtcdPass},
+		},
+		&cli.StringFlag{
+			Name:  flgHTTPConsulAddress,
+			Usage: "Set the Consul agent address to use for HTTP-01 based challenges. Challenges will be written to Consul KV. Supported: scheme://host:port.",
+		},
+		&cli.StringFlag{
+			Name:  flgHTTPConsulPrefix,
+			Usage: "Set the prefix of the Consul keys used for HTTP-01 based challenges.",
+		},
+		&cli.StringFlag{
+			Name:    flgHTTPConsulToken,
+			Usage:   "Set the Consul ACL token to use for HTTP-01 based challenges.",
+			EnvVars: []string{envConsulToken},
+		},
 		&cli.BoolFlag{
 			Name:  flgTLS,
 			Usage: "Use the TLS-ALPN-01 challenge to solve challenges. Can be mixed with other types of challenges.",
@@ -158,10 +468,67 @@ func CreateFlags(defaultPath string) []cli.Flag {
 			Usage: "Set the port and interface to use for TLS-ALPN-01 based challenges to listen on. Supported: interface:port or :port.",
 			Value: ":443",
 		},
+		&cli.BoolFlag{
+			Name:  flgTLSReusePort,
+			Usage: "Enable the SO_REUSEPORT socket option on the TLS-ALPN-01 challenge server (Linux, macOS, and BSD only).",
+		},
+		&cli.DurationFlag{
+			Name:  flgTLSDrainTimeout,
+			Usage: "Set the maximum time to wait for the in-flight connections to complete before stopping the TLS-ALPN-01 challenge server.",
+		},
+		&cli.StringFlag{
+			Name: flgTLSSystemdSocket,
+			Usage: "Use the socket passed by systemd with this name (FileDescriptorName= of the socket unit) for the TLS-ALPN-01 challenge server," +
+				" instead of listening on --tls.port (socket activation).",
+		},
+		&cli.StringFlag{
+			Name:  flgTLSSNIRouter,
+			Usage: "Push the TLS-ALPN-01 challenge certificates to an external SNI router instead of starting a server. Supported: haproxy, envoy, webhook.",
+		},
+		&cli.StringFlag{
+			Name: flgTLSSNIRouterAddress,
+			Usage: "Set the address of the SNI router:" +
+				" the runtime API socket for haproxy (unix socket path or tcp://host:port), the SDS directory for envoy, the URL for webhook.",
+		},
+		&cli.StringFlag{
+			Name:  flgTLSHAProxyCrtList,
+			Usage: "Set the HAProxy crt-list where the TLS-ALPN-01 challenge certificates are added.",
+		},
+		&cli.StringFlag{
+			Name: flgTLSStoreDir,
+			Usage: "Write the TLS-ALPN-01 challenge certificates into a shared directory instead of starting a server." +
+				" The TLS servers read the certificates from this directory.",
+		},
+		&cli.StringSliceFlag{
+			Name: flgTLSRedisHost,
+			Usage: "Write the TLS-ALPN-01 challenge certificates into Redis instead of starting a server." +
+				" The TLS servers read the certificates from Redis. Can be specified multiple times.",
+		},
+		&cli.StringFlag{
+			Name:    flgTLSRedisPassword,
+			Usage:   "Set the Redis password to use for TLS-ALPN-01 based challenges.",
+			EnvVars: []string{envRedisPass},
+		},
+		&cli.StringFlag{
+			Name:  flgTLSRedisKeyPrefix,
+			Usage: "Set the prefix of the Redis keys used for TLS-ALPN-01 based challenges. (default: lego:tls-alpn-01:)",
+		},
 		&cli.StringFlag{
 			Name:  flgDNS,
 			Usage: "Solve a DNS-01 challenge using the specified provider. Can be mixed with other types of challenges. Run 'lego dnshelp' for help on usage.",
 		},
+		&cli.StringSliceFlag{
+			Name: flgDNSRoute,
+			Usage: "Route the DNS-01 challenges of a domain (and its subdomains) to a DNS provider: 'example.org=cloudflare'." +
+				" Can be repeated, the most specific domain is used. The domains without route use the provider defined by '--dns'.",
+		},
+		&cli.BoolFlag{
+			Name: flgDNSAccountLabel,
+			Usage: "Solve a DNS-ACCOUNT-01 challenge instead of a DNS-01 challenge:" +
+				" the TXT record is created on an account-scoped label (_<label>._acme-challenge.<domain>)," +
+				" so several ACME accounts can validate the same domain." +
+				" Only the DNS providers supporting this challenge can be used (ex: exec, manual).",
+		},
 		&cli.BoolFlag{
 			Name:  flgDNSDisableCP,
 			Usage: fmt.Sprintf("(deprecated) use %s instead.", flgDNSPropagationDisableANS),
@@ -193,6 +560,18 @@ func CreateFlags(defaultPath string) []cli.Flag {
 			Name:  flgTLSSkipVerify,
 			Usage: "Skip the TLS verification of the ACME server.",
 		},
+		&cli.StringFlag{
+			Name:    flgProxy,
+			EnvVars: []string{envProxy},
+			Usage: "The HTTP proxy of the requests to the ACME server, ex: 'http://proxy.example.com:3128'." +
+				" The hosts defined by the NO_PROXY environment variable are not proxied.",
+		},
+		&cli.StringSliceFlag{
+			Name:    flgCACert,
+			EnvVars: []string{envCACert},
+			Usage: "A PEM file of CA certificates trusted by the requests to the ACME server," +
+				" in addition to the system roots (or to the LEGO_CA_CERTIFICATES certificates). Can be specified multiple times.",
+		},
 		&cli.IntFlag{
 			Name:  flgDNSTimeout,
 			Usage: "Set the DNS timeout value to a specific value in seconds. Used only when performing authoritative name server queries.",
@@ -219,6 +598,73 @@ func CreateFlags(defaultPath string) []cli.Flag {
 			Value:   "RC2",
 			EnvVars: []string{envPFXFormat},
 		},
+		&cli.StringSliceFlag{
+			Name:    flgOutputFormat,
+			Usage:   "Generate additional certificate files. Supported: leaf (.leaf.crt), chain (.chain.crt, intermediates), root (.root.crt, if provided by the CA), der (.der, leaf), p7b (.p7b, PKCS#7), haproxy (.haproxy.pem, certificate, chain and private key), pins (.pins.txt, SPKI pins of the leaf and the intermediates), tlsa (.tlsa, suggested DANE TLSA records for the port 443). Can be specified multiple times.",
+			EnvVars: []string{envOutputFormat},
+		},
+		&cli.BoolFlag{
+			Name:    flgOCSPStaple,
+			Usage:   "Generate an additional .ocsp file (DER OCSP response) for the servers configured with a static OCSP stapling. The file is refreshed by the renew command when half of its validity period has elapsed.",
+			EnvVars: []string{envOCSPStaple},
+		},
+		&cli.BoolFlag{
+			Name:    flgKeyStore,
+			Usage:   "Generate an additional Java keystore (.keystore.jks or .keystore.p12, private key, certificate and chain) and an additional Java truststore (.truststore.jks or .truststore.p12, chain).",
+			EnvVars: []string{envKeyStore},
+		},
+		&cli.StringFlag{
+			Name:    flgKeyStoreFormat,
+			Usage:   "The format of the Java keystore and truststore. Supported: JKS, PKCS12.",
+			Value:   string(certcrypto.KeyStoreFormatJKS),
+			EnvVars: []string{envKeyStoreFormat},
+		},
+		&cli.StringFlag{
+			Name:    flgKeyStorePass,
+			Usage:   "The password of the Java keystore and truststore.",
+			Value:   "changeit",
+			EnvVars: []string{envKeyStorePass},
+		},
+		&cli.StringFlag{
+			Name:    flgKeyStoreKeyPass,
+			Usage:   "The password of the private key inside the Java keystore (JKS only). Default: the password of the keystore.",
+			EnvVars: []string{envKeyStoreKeyPass},
+		},
+		&cli.StringFlag{
+			Name:  flgKeyStoreAlias,
+			Usage: "The alias of the private key entry inside the Java keystore (JKS only).",
+			Value: "lego",
+		},
+		&cli.StringFlag{
+			Name:  flgKeyStoreTrustAlias,
+			Usage: "The prefix of the aliases of the certificate entries inside the Java truststore (<prefix>-1, <prefix>-2, etc.).",
+			Value: "ca",
+		},
+		&cli.StringFlag{
+			Name:    flgKeyPass,
+			Usage:   "The passphrase used to encrypt the private key files (.key and .pem) with PKCS#8 and AES-256.",
+			EnvVars: []string{envKeyPassword},
+		},
+		&cli.StringFlag{
+			Name:    flgKeyPassFile,
+			Usage:   "The path to a file containing the passphrase used to encrypt the private key files (see --" + flgKeyPass + ").",
+			EnvVars: []string{envKeyPasswordFile},
+		},
+		&cli.StringFlag{
+			Name: flgAccountKeyPass,
+			Usage: "The passphrase used to encrypt the new account private keys with PKCS#8 and AES-256." +
+				" The existing unencrypted account keys are only encrypted with --" + flgAccountEncryptKeys + ".",
+			EnvVars: []string{envAccountKeyPass},
+		},
+		&cli.StringFlag{
+			Name:    flgAccountKeyPassFile,
+			Usage:   "The path to a file containing the passphrase used to encrypt the account private keys (see --" + flgAccountKeyPass + ").",
+			EnvVars: []string{envAccountKeyFile},
+		},
+		&cli.BoolFlag{
+			Name:  flgAccountEncryptKeys,
+			Usage: "Encrypt the existing unencrypted account private keys when they are loaded (requires --" + flgAccountKeyPass + " or --" + flgAccountKeyPassFile + ").",
+		},
 		&cli.IntFlag{
 			Name:  flgCertTimeout,
 			Usage: "Set the certificate timeout value to a specific value in seconds. Only used when obtaining certificates.",
@@ -229,10 +675,90 @@ func CreateFlags(defaultPath string) []cli.Flag {
 			Usage: "ACME overall requests limit.",
 			Value: certificate.DefaultOverallRequestLimit,
 		},
+		&cli.BoolFlag{
+			Name:  flgCAAPreCheck,
+			Usage: "Check the CAA records of the domains against the CAA identities of the CA before creating an order.",
+		},
 		&cli.StringFlag{
 			Name:  flgUserAgent,
 			Usage: "Add to the user-agent sent to the CA to identify an application embedding lego-cli",
 		},
+		&cli.StringFlag{
+			Name: flgOutput,
+			Usage: "The output mode of the commands: 'text' or 'json'." +
+				" With 'json', the results of the run, renew, list, and revoke commands are written as JSON on the standard output," +
+				" and the logs (including the errors) are written as JSON lines on the standard error.",
+			EnvVars: []string{envOutput},
+			Value:   outputModeText,
+		},
+		&cli.StringFlag{
+			Name: flgLogFormat,
+			Usage: "The format of the logs, written on the standard error: 'text' or 'json' (JSON lines: time, level, message)." +
+				" (default: 'json' with '--output json', otherwise 'text')",
+			EnvVars: []string{envLogFormat},
+		},
+		&cli.StringFlag{
+			Name:    flgLogLevel,
+			Usage:   "The minimum level of the logs: 'debug', 'info', 'warn', or 'error'.",
+			EnvVars: []string{envLogLevel},
+			Value:   logLevelInfo,
+		},
+		&cli.BoolFlag{
+			Name:  flgQuiet,
+			Usage: "Only log the errors (same as '--log-level error').",
+		},
+		&cli.StringFlag{
+			Name:    flgNotifyWebhook,
+			EnvVars: []string{envNotifyWebhook},
+			Usage: "Send a notification (JSON POST request) to this URL after each obtained, renewed, or failed certificate:" +
+				" event, domains, expiration date, error.",
+		},
+		&cli.StringFlag{
+			Name:  flgNotifySMTPAddress,
+			Usage: "Send a notification email after each obtained, renewed, or failed certificate, using this SMTP server (host:port).",
+		},
+		&cli.StringFlag{
+			Name:  flgNotifySMTPUsername,
+			Usage: "The username of the SMTP server (PLAIN authentication).",
+		},
+		&cli.StringFlag{
+			Name:    flgNotifySMTPPassword,
+			EnvVars: []string{envNotifySMTPPass},
+			Usage:   "The password of the SMTP server.",
+		},
+		&cli.StringFlag{
+			Name:  flgNotifySMTPFrom,
+			Usage: "The sender of the notification emails.",
+		},
+		&cli.StringSliceFlag{
+			Name:  flgNotifySMTPTo,
+			Usage: "The recipients of the notification emails. Can be specified multiple times.",
+		},
+		&cli.StringFlag{
+			Name: flgMetricsTextfile,
+			Usage: "Write the Prometheus metrics of the run and renew commands in this file, at the end of the command" +
+				" (format of the textfile collector of the node exporter).",
+		},
+		&cli.StringSliceFlag{
+			Name: flgDeploy,
+			Usage: "Deploy the certificate after its issuance (run, renew, and daemon commands): a type and options separated by semicolons" +
+				" (ex: 'file:cert=/etc/nginx/tls/example.com.crt;key=/etc/nginx/tls/example.com.key;reload=systemctl reload nginx')." +
+				" The paths of the file type are templates (ex: 'file:fullchain=/etc/tls/{{.Domain}}/fullchain.pem')." +
+				" Supported types: file, haproxy, kubernetes, vault. Can be specified multiple times.",
+		},
+		&cli.StringFlag{
+			Name:  flgHistoryFile,
+			Usage: "The file of the history of the issuance attempts (default: '<path>/history.db').",
+		},
+		&cli.BoolFlag{
+			Name:  flgHistoryDisable,
+			Usage: "Do not record the issuance attempts in the history.",
+		},
+		&cli.IntFlag{
+			Name:  flgHistoryMaxEntries,
+			Usage: "The maximum number of entries of the history, the oldest entries are removed (0 means no limit).",
+			Value: 1000,
+		},
 	}
 }
 
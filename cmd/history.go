@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme/api"
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli/v2"
+	bolt "go.etcd.io/bbolt"
+)
+
+const historyFilename = "history.db"
+
+var historyBucket = []byte("history")
+
+// Outcomes of the issuance attempts.
+const (
+	historyOutcomeSuccess = "success"
+	historyOutcomeFailure = "failure"
+	historyOutcomeNotDue  = "not-due"
+)
+
+// historyEntry an issuance attempt (obtain or renew).
+type historyEntry struct {
+	ID          uint64              `json:"id"`
+	Time        time.Time           `json:"time"`
+	Event       string              `json:"event"`
+	Certificate string              `json:"certificate"`
+	Domains     []string            `json:"domains,omitempty"`
+	Server      string              `json:"server,omitempty"`
+	Outcome     string              `json:"outcome"`
+	Detail      string              `json:"detail,omitempty"`
+	Error       string              `json:"error,omitempty"`
+	OrderURL    string              `json:"orderURL,omitempty"`
+	CertURL     string              `json:"certURL,omitempty"`
+	RateLimits  []historyThrottling `json:"rateLimits,omitempty"`
+}
+
+// historyThrottling a rate-limit response (429 or 503, with a Retry-After header) observed during an issuance attempt.
+type historyThrottling struct {
+	Time       time.Time `json:"time"`
+	URL        string    `json:"url"`
+	StatusCode int       `json:"statusCode"`
+	RetryAfter string    `json:"retryAfter"`
+}
+
+// throttlingRecorderKey the key of the recorder of the rate-limit responses in the context of a command or of a certificate.
+type throttlingRecorderKey struct{}
+
+// withThrottlingRecorder attaches a new recorder of the rate-limit responses to the context:
+// the clients created with this context record their rate-limit responses in it,
+// so the entry of a certificate only contains the rate-limit responses of its own order.
+func withThrottlingRecorder(ctx *cli.Context) {
+	ctx.Context = context.WithValue(ctx.Context, throttlingRecorderKey{}, &throttlingRecorder{})
+}
+
+// getThrottlingRecorder returns the recorder of the rate-limit responses of the context, or nil.
+func getThrottlingRecorder(ctx *cli.Context) *throttlingRecorder {
+	recorder, _ := ctx.Context.Value(throttlingRecorderKey{}).(*throttlingRecorder)
+
+	return recorder
+}
+
+// throttlingRecorder the rate-limit responses observed since the last recorded attempt.
+// The attempts sharing a recorder are sequential (ex: the CSR files of the watch mode).
+type throttlingRecorder struct {
+	mu     sync.Mutex
+	events []historyThrottling
+}
+
+func (r *throttlingRecorder) observe(event api.ThrottleEvent) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, historyThrottling{
+		Time:       time.Now().UTC(),
+		URL:        event.URL,
+		StatusCode: event.StatusCode,
+		RetryAfter: event.RetryAfter.String(),
+	})
+}
+
+// drain returns the observed events, and forgets them.
+func (r *throttlingRecorder) drain() []historyThrottling {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := r.events
+	r.events = nil
+
+	return events
+}
+
+// historyFilter the criteria of a history query.
+type historyFilter struct {
+	// Domain matches the name of the certificate or one of its domains.
+	Domain string
+	Since  time.Time
+	// Failures only returns the failed attempts.
+	Failures bool
+	// Limit is the maximum number of entries (the most recent entries), 0 means no limit.
+	Limit int
+}
+
+func (f historyFilter) match(entry historyEntry) bool {
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+
+	if f.Failures && entry.Outcome != historyOutcomeFailure {
+		return false
+	}
+
+	if f.Domain == "" || entry.Certificate == f.Domain {
+		return true
+	}
+
+	for _, domain := range entry.Domains {
+		if domain == f.Domain {
+			return true
+		}
+	}
+
+	return false
+}
+
+// historyStore the history of the issuance attempts, stored in a bbolt database.
+// The database is opened only during an operation: several lego processes can share the same file.
+type historyStore struct {
+	filename   string
+	maxEntries int
+}
+
+func newHistoryStore(ctx *cli.Context) *historyStore {
+	filename := ctx.String(flgHistoryFile)
+	if filename == "" {
+		filename = filepath.Join(ctx.String(flgPath), historyFilename)
+	}
+
+	return &historyStore{
+		filename:   filename,
+		maxEntries: ctx.Int(flgHistoryMaxEntries),
+	}
+}
+
+// Add stores an entry, the oldest entries are removed when the maximum number of entries is reached.
+func (s *historyStore) Add(entry historyEntry) error {
+	err := os.MkdirAll(filepath.Dir(s.filename), 0o700)
+	if err != nil {
+		return err
+	}
+
+	db, err := bolt.Open(s.filename, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return fmt.Errorf("could not open the history file: %w", err)
+	}
+
+	defer func() { _ = db.Close() }()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(historyBucket)
+		if err != nil {
+			return err
+		}
+
+		entry.ID, err = bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		value, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+
+		err = bucket.Put(historyKey(entry.ID), value)
+		if err != nil {
+			return err
+		}
+
+		return s.prune(bucket, entry.ID)
+	})
+}
+
+// prune removes the oldest entries: the IDs are sequences, the entries older than the last maxEntries entries are removed.
+func (s *historyStore) prune(bucket *bolt.Bucket, lastID uint64) error {
+	if s.maxEntries <= 0 || lastID <= uint64(s.maxEntries) {
+		return nil
+	}
+
+	threshold := lastID - uint64(s.maxEntries)
+
+	var keys [][]byte
+
+	cursor := bucket.Cursor()
+
+	for k, _ := cursor.First(); k != nil && binary.BigEndian.Uint64(k) <= threshold; k, _ = cursor.Next() {
+		keys = append(keys, k)
+	}
+
+	for _, key := range keys {
+		err := bucket.Delete(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Query returns the entries matching the filter, the most recent first.
+func (s *historyStore) Query(filter historyFilter) ([]historyEntry, error) {
+	_, err := os.Stat(s.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	db, err := bolt.Open(s.filename, 0o600, &bolt.Options{Timeout: 5 * time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("could not open the history file: %w", err)
+	}
+
+	defer func() { _ = db.Close() }()
+
+	var entries []historyEntry
+
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(historyBucket)
+		if bucket == nil {
+			return nil
+		}
+
+		cursor := bucket.Cursor()
+
+		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+			var entry historyEntry
+
+			err := json.Unmarshal(v, &entry)
+			if err != nil {
+				return fmt.Errorf("invalid history entry %d: %w", binary.BigEndian.Uint64(k), err)
+			}
+
+			if !filter.match(entry) {
+				continue
+			}
+
+			entries = append(entries, entry)
+
+			if filter.Limit > 0 && len(entries) >= filter.Limit {
+				return nil
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// historyKey the keys are big-endian sequences: the entries are sorted by insertion order.
+func historyKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+
+	return key
+}
+
+// newHistoryEntry creates an entry of the history, with the rate-limit responses observed by the clients of the context since the last entry.
+func newHistoryEntry(ctx *cli.Context, event, name, outcome string) historyEntry {
+	return historyEntry{
+		Time:        time.Now().UTC(),
+		Event:       event,
+		Certificate: name,
+		Server:      ctx.String(flgServer),
+		Outcome:     outcome,
+		RateLimits:  getThrottlingRecorder(ctx).drain(),
+	}
+}
+
+// recordHistory stores an entry of the history, the errors are only logged: the history must not change the outcome of the commands.
+func recordHistory(ctx *cli.Context, entry historyEntry) {
+	if ctx.Bool(flgHistoryDisable) {
+		return
+	}
+
+	err := newHistoryStore(ctx).Add(entry)
+	if err != nil {
+		log.WithDomain(entry.Certificate).Warnf("Unable to record the history: %v", err)
+	}
+}
+
+// recordSuccessHistory records an obtained or renewed certificate.
+func recordSuccessHistory(ctx *cli.Context, event string, certRes *certificate.Resource) {
+	entry := newHistoryEntry(ctx, event, certRes.Domain, historyOutcomeSuccess)
+	entry.OrderURL = certRes.OrderURL
+	entry.CertURL = certRes.CertURL
+
+	cert, err := certcrypto.ParsePEMCertificate(certRes.Certificate)
+	if err == nil {
+		entry.Domains = certcrypto.ExtractDomains(cert)
+		entry.Detail = "expires on " + cert.NotAfter.UTC().Format(time.RFC3339)
+	}
+
+	recordHistory(ctx, entry)
+}
+
+// recordFailureHistory records a failed certificate, with the URL of the order if it was created.
+func recordFailureHistory(ctx *cli.Context, event, name string, err error) {
+	entry := newHistoryEntry(ctx, event, name, historyOutcomeFailure)
+	entry.Domains = ctx.StringSlice(flgDomains)
+	entry.Error = err.Error()
+
+	var orderErr *certificate.OrderError
+	if errors.As(err, &orderErr) {
+		entry.OrderURL = orderErr.URL
+	}
+
+	recordHistory(ctx, entry)
+}
+
+// recordNotDueHistory records a certificate that did not need to be renewed.
+func recordNotDueHistory(ctx *cli.Context, name string, cert *x509.Certificate) {
+	entry := newHistoryEntry(ctx, notifyEventRenew, name, historyOutcomeNotDue)
+	entry.Domains = certcrypto.ExtractDomains(cert)
+	entry.Detail = "expires on " + cert.NotAfter.UTC().Format(time.RFC3339)
+
+	recordHistory(ctx, entry)
+}
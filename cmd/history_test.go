@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme/api"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_historyStore(t *testing.T) {
+	store := &historyStore{filename: filepath.Join(t.TempDir(), "sub", historyFilename), maxEntries: 3}
+
+	entries, err := store.Query(historyFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	now := time.Now().UTC()
+
+	for _, entry := range []historyEntry{
+		{Time: now.Add(-72 * time.Hour), Certificate: "a.example.com", Outcome: historyOutcomeSuccess},
+		{Time: now.Add(-48 * time.Hour), Certificate: "a.example.com", Outcome: historyOutcomeFailure},
+		{Time: now.Add(-24 * time.Hour), Certificate: "b.example.com", Domains: []string{"b.example.com", "c.example.com"}, Outcome: historyOutcomeNotDue},
+		{Time: now, Certificate: "a.example.com", Outcome: historyOutcomeSuccess},
+	} {
+		require.NoError(t, store.Add(entry))
+	}
+
+	testCases := []struct {
+		desc     string
+		filter   historyFilter
+		expected []uint64
+	}{
+		{
+			desc:     "all (the oldest entry is pruned)",
+			expected: []uint64{4, 3, 2},
+		},
+		{
+			desc:     "limit",
+			filter:   historyFilter{Limit: 2},
+			expected: []uint64{4, 3},
+		},
+		{
+			desc:     "certificate name",
+			filter:   historyFilter{Domain: "a.example.com"},
+			expected: []uint64{4, 2},
+		},
+		{
+			desc:     "domain",
+			filter:   historyFilter{Domain: "c.example.com"},
+			expected: []uint64{3},
+		},
+		{
+			desc:     "since",
+			filter:   historyFilter{Since: now.Add(-36 * time.Hour)},
+			expected: []uint64{4, 3},
+		},
+		{
+			desc:     "failures",
+			filter:   historyFilter{Failures: true},
+			expected: []uint64{2},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			entries, err := store.Query(test.filter)
+			require.NoError(t, err)
+
+			var ids []uint64
+			for _, entry := range entries {
+				ids = append(ids, entry.ID)
+			}
+
+			assert.Equal(t, test.expected, ids)
+		})
+	}
+}
+
+func Test_recordFailureHistory(t *testing.T) {
+	ctx := newTestContext(t, "--path", t.TempDir(), "-d", "example.com", "-d", "example.org")
+
+	withThrottlingRecorder(ctx)
+
+	getThrottlingRecorder(ctx).observe(api.ThrottleEvent{URL: "https://example.com/acme/new-order", StatusCode: 429, RetryAfter: time.Hour})
+
+	err := errors.Join(errors.New("oops"), &certificate.OrderError{URL: "https://example.com/acme/order/123", Err: errors.New("invalid")})
+
+	recordFailureHistory(ctx, notifyEventRenew, "example.com", err)
+
+	entries, err := newHistoryStore(ctx).Query(historyFilter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	entry := entries[0]
+	assert.Equal(t, notifyEventRenew, entry.Event)
+	assert.Equal(t, "example.com", entry.Certificate)
+	assert.Equal(t, []string{"example.com", "example.org"}, entry.Domains)
+	assert.Equal(t, historyOutcomeFailure, entry.Outcome)
+	assert.Equal(t, "oops\ninvalid", entry.Error)
+	assert.Equal(t, "https://example.com/acme/order/123", entry.OrderURL)
+
+	require.Len(t, entry.RateLimits, 1)
+	assert.Equal(t, 429, entry.RateLimits[0].StatusCode)
+	assert.Equal(t, "1h0m0s", entry.RateLimits[0].RetryAfter)
+
+	// The rate-limit responses are recorded only once.
+	assert.Empty(t, getThrottlingRecorder(ctx).drain())
+}
+
+func Test_recordSuccessHistory(t *testing.T) {
+	dir := t.TempDir()
+
+	ctxA := newTestContext(t, "--path", dir)
+	withThrottlingRecorder(ctxA)
+
+	ctxB := newTestContext(t, "--path", dir)
+	withThrottlingRecorder(ctxB)
+
+	// The rate-limit responses are recorded by the context of the certificate that received them.
+	getThrottlingRecorder(ctxB).observe(api.ThrottleEvent{URL: "https://example.com/acme/order/456", StatusCode: 503, RetryAfter: time.Minute})
+
+	recordSuccessHistory(ctxA, notifyEventObtain, &certificate.Resource{
+		Domain:   "a.example.com",
+		OrderURL: "https://example.com/acme/order/123",
+		CertURL:  "https://example.com/acme/cert/123",
+	})
+
+	entries, err := newHistoryStore(ctxA).Query(historyFilter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	entry := entries[0]
+	assert.Equal(t, "a.example.com", entry.Certificate)
+	assert.Equal(t, historyOutcomeSuccess, entry.Outcome)
+	assert.Equal(t, "https://example.com/acme/order/123", entry.OrderURL)
+	assert.Equal(t, "https://example.com/acme/cert/123", entry.CertURL)
+	assert.Empty(t, entry.RateLimits)
+
+	require.Len(t, getThrottlingRecorder(ctxB).drain(), 1)
+}
+
+func Test_recordHistory_disabled(t *testing.T) {
+	ctx := newTestContext(t, "--path", t.TempDir(), "--history.disable")
+
+	recordFailureHistory(ctx, notifyEventObtain, "example.com", errors.New("oops"))
+
+	assert.NoFileExists(t, filepath.Join(ctx.String(flgPath), historyFilename))
+}
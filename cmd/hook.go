@@ -1,28 +1,144 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/go-acme/lego/v4/certificate"
 )
 
 const (
-	hookEnvAccountEmail      = "LEGO_ACCOUNT_EMAIL"
-	hookEnvCertDomain        = "LEGO_CERT_DOMAIN"
-	hookEnvCertPath          = "LEGO_CERT_PATH"
-	hookEnvCertKeyPath       = "LEGO_CERT_KEY_PATH"
-	hookEnvIssuerCertKeyPath = "LEGO_ISSUER_CERT_PATH"
-	hookEnvCertPEMPath       = "LEGO_CERT_PEM_PATH"
-	hookEnvCertPFXPath       = "LEGO_CERT_PFX_PATH"
+	hookEnvAccountEmail       = "LEGO_ACCOUNT_EMAIL"
+	hookEnvCertDomain         = "LEGO_CERT_DOMAIN"
+	hookEnvCertPath           = "LEGO_CERT_PATH"
+	hookEnvCertKeyPath        = "LEGO_CERT_KEY_PATH"
+	hookEnvIssuerCertKeyPath  = "LEGO_ISSUER_CERT_PATH"
+	hookEnvCertPEMPath        = "LEGO_CERT_PEM_PATH"
+	hookEnvCertPFXPath        = "LEGO_CERT_PFX_PATH"
+	hookEnvCertOCSPPath       = "LEGO_CERT_OCSP_PATH"
+	hookEnvCertKeyStorePath   = "LEGO_CERT_KEYSTORE_PATH"
+	hookEnvCertTrustStorePath = "LEGO_CERT_TRUSTSTORE_PATH"
+	// hookEnvCertSPKIPin the SPKI pin of the leaf certificate (pins output format).
+	hookEnvCertSPKIPin = "LEGO_CERT_SPKI_PIN"
 )
 
-func launchHook(hook string, timeout time.Duration, meta map[string]string) error {
+// hookEnvOutputPaths the environment variables of the paths of the output files, by output format.
+var hookEnvOutputPaths = map[string]string{
+	outputLeaf:    "LEGO_CERT_LEAF_PATH",
+	outputChain:   "LEGO_CERT_CHAIN_PATH",
+	outputRoot:    "LEGO_CERT_ROOT_PATH",
+	outputDER:     "LEGO_CERT_DER_PATH",
+	outputPKCS7:   "LEGO_CERT_P7B_PATH",
+	outputHAProxy: "LEGO_CERT_HAPROXY_PATH",
+	outputPins:    "LEGO_CERT_PINS_PATH",
+	outputTLSA:    "LEGO_CERT_TLSA_PATH",
+}
+
+// hookEventNoop the event of the renew hook when the certificate is not renewed (see renew-hook-on-noop).
+const hookEventNoop = "noop"
+
+// Renewal reasons (hook context).
+const (
+	renewalReasonARI        = "ari"
+	renewalReasonExpiration = "expiration"
+	renewalReasonDomains    = "domains"
+)
+
+// hookContext the JSON document written on the standard input of the hooks.
+type hookContext struct {
+	Event     string    `json:"event"`
+	Account   string    `json:"account"`
+	Domain    string    `json:"domain"`
+	Domains   []string  `json:"domains"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	CertURL   string    `json:"certUrl,omitempty"`
+	// Reason why the certificate has been renewed: ari, expiration, or domains (renew command only).
+	Reason string `json:"reason,omitempty"`
+	// ARI the renewal information of the previous certificate (renew command only, if provided by the CA).
+	ARI     *hookARI          `json:"ari,omitempty"`
+	Files   map[string]string `json:"files"`
+	Timings *hookTimings      `json:"timings,omitempty"`
+}
+
+type hookARI struct {
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	ExplanationURL string    `json:"explanationUrl,omitempty"`
+}
+
+type hookTimings struct {
+	Started         time.Time `json:"started"`
+	Finished        time.Time `json:"finished"`
+	DurationSeconds float64   `json:"durationSeconds"`
+}
+
+// newHookContext creates the context of a hook from the certificate and the metadata of the hook (environment variables).
+func newHookContext(event string, certRes *certificate.Resource, meta map[string]string) *hookContext {
+	hookCtx := &hookContext{
+		Event:   event,
+		Account: meta[hookEnvAccountEmail],
+		Domain:  certRes.Domain,
+		CertURL: certRes.CertURL,
+		Files:   make(map[string]string),
+	}
+
+	cert, err := certcrypto.ParsePEMCertificate(certRes.Certificate)
+	if err == nil {
+		hookCtx.Domains = certcrypto.ExtractDomains(cert)
+		hookCtx.NotBefore = cert.NotBefore
+		hookCtx.NotAfter = cert.NotAfter
+	}
+
+	for env, value := range meta {
+		if name, ok := resultFileNames[env]; ok {
+			hookCtx.Files[name] = value
+		}
+	}
+
+	return hookCtx
+}
+
+// withRenewal adds the information of the renewal: the reason, and the renewal information (ARI) of the previous certificate.
+func (h *hookContext) withRenewal(reason string, renewalInfo *certificate.RenewalInfoResponse) *hookContext {
+	h.Reason = reason
+
+	if renewalInfo != nil {
+		h.ARI = &hookARI{
+			Start:          renewalInfo.SuggestedWindow.Start.UTC(),
+			End:            renewalInfo.SuggestedWindow.End.UTC(),
+			ExplanationURL: renewalInfo.ExplanationURL,
+		}
+	}
+
+	return h
+}
+
+// withTimings adds the duration of the issuance of the certificate.
+func (h *hookContext) withTimings(started time.Time) *hookContext {
+	finished := time.Now()
+
+	h.Timings = &hookTimings{
+		Started:         started.UTC(),
+		Finished:        finished.UTC(),
+		DurationSeconds: finished.Sub(started).Seconds(),
+	}
+
+	return h
+}
+
+// launchHook executes a hook: the metadata are defined as environment variables,
+// and the context (optional) is written as JSON on the standard input.
+func launchHook(hook string, timeout time.Duration, meta map[string]string, hookCtx *hookContext) error {
 	if hook == "" {
 		return nil
 	}
@@ -35,10 +151,19 @@ func launchHook(hook string, timeout time.Duration, meta map[string]string) erro
 	cmdCtx := exec.CommandContext(ctxCmd, parts[0], parts[1:]...)
 	cmdCtx.Env = append(os.Environ(), metaToEnv(meta)...)
 
+	if hookCtx != nil {
+		input, err := json.Marshal(hookCtx)
+		if err != nil {
+			return err
+		}
+
+		cmdCtx.Stdin = bytes.NewReader(input)
+	}
+
 	output, err := cmdCtx.CombinedOutput()
 
 	if len(output) > 0 {
-		fmt.Println(string(output))
+		_, _ = fmt.Fprintln(hookOutput, string(output))
 	}
 
 	if errors.Is(ctxCmd.Err(), context.DeadlineExceeded) {
@@ -74,4 +199,31 @@ func addPathToMetadata(meta map[string]string, domain string, certRes *certifica
 	if certsStorage.pfx {
 		meta[hookEnvCertPFXPath] = certsStorage.GetFileName(domain, pfxExt)
 	}
+
+	if certsStorage.ocspStaple && certsStorage.ExistsFile(domain, ocspExt) {
+		meta[hookEnvCertOCSPPath] = certsStorage.GetFileName(domain, ocspExt)
+	}
+
+	if certsStorage.keyStore {
+		keyStoreExt, trustStoreExt := certsStorage.keyStoreExts()
+
+		meta[hookEnvCertKeyStorePath] = certsStorage.GetFileName(domain, keyStoreExt)
+
+		if certsStorage.ExistsFile(domain, trustStoreExt) {
+			meta[hookEnvCertTrustStorePath] = certsStorage.GetFileName(domain, trustStoreExt)
+		}
+	}
+
+	for _, output := range certsStorage.outputs {
+		if certsStorage.ExistsFile(domain, outputFormatExts[output]) {
+			meta[hookEnvOutputPaths[output]] = certsStorage.GetFileName(domain, outputFormatExts[output])
+		}
+	}
+
+	if slices.Contains(certsStorage.outputs, outputPins) {
+		cert, err := certcrypto.ParsePEMCertificate(certRes.Certificate)
+		if err == nil {
+			meta[hookEnvCertSPKIPin] = spkiPin(cert)
+		}
+	}
 }
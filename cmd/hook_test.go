@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_launchHook_context(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook is a shell script")
+	}
+
+	dir := t.TempDir()
+
+	script := filepath.Join(dir, "hook.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\ncat > \"$HOOK_OUTPUT\"\n"), 0o700))
+
+	output := filepath.Join(dir, "context.json")
+
+	meta := map[string]string{
+		"HOOK_OUTPUT":       output,
+		hookEnvAccountEmail: "you@example.com",
+		hookEnvCertPath:     "/tmp/example.com.crt",
+		hookEnvCertKeyPath:  "/tmp/example.com.key",
+	}
+
+	certRes := &certificate.Resource{
+		Domain:  "example.com",
+		CertURL: "https://example.com/cert/1",
+	}
+
+	renewalInfo := &certificate.RenewalInfoResponse{}
+	renewalInfo.SuggestedWindow.Start = time.Date(2024, time.November, 1, 0, 0, 0, 0, time.UTC)
+	renewalInfo.SuggestedWindow.End = time.Date(2024, time.November, 2, 0, 0, 0, 0, time.UTC)
+
+	hookCtx := newHookContext(notifyEventRenew, certRes, meta).
+		withRenewal(renewalReasonARI, renewalInfo).
+		withTimings(time.Now().Add(-time.Minute))
+
+	err := launchHook(script, time.Minute, meta, hookCtx)
+	require.NoError(t, err)
+
+	raw, err := os.ReadFile(output)
+	require.NoError(t, err)
+
+	var actual hookContext
+	require.NoError(t, json.Unmarshal(raw, &actual))
+
+	assert.Equal(t, notifyEventRenew, actual.Event)
+	assert.Equal(t, "you@example.com", actual.Account)
+	assert.Equal(t, "example.com", actual.Domain)
+	assert.Equal(t, "https://example.com/cert/1", actual.CertURL)
+	assert.Equal(t, renewalReasonARI, actual.Reason)
+	assert.Equal(t, &hookARI{Start: renewalInfo.SuggestedWindow.Start, End: renewalInfo.SuggestedWindow.End}, actual.ARI)
+	assert.Equal(t, map[string]string{"certificate": "/tmp/example.com.crt", "key": "/tmp/example.com.key"}, actual.Files)
+
+	require.NotNil(t, actual.Timings)
+	assert.InDelta(t, 60, actual.Timings.DurationSeconds, 5)
+}
@@ -9,7 +9,6 @@ import (
 	"runtime"
 
 	"github.com/go-acme/lego/v4/cmd"
-	"github.com/go-acme/lego/v4/log"
 	"github.com/urfave/cli/v2"
 )
 
@@ -35,10 +34,12 @@ func main() {
 
 	app.Before = cmd.Before
 
+	app.BashComplete = cmd.BashComplete
+
 	app.Commands = cmd.CreateCommands()
 
 	err = app.Run(os.Args)
 	if err != nil {
-		log.Fatal(err)
+		cmd.Exit(err)
 	}
 }
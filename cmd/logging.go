@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli/v2"
+)
+
+// Log formats.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// Log levels.
+const (
+	logLevelDebug = "debug"
+	logLevelInfo  = "info"
+	logLevelWarn  = "warn"
+	logLevelError = "error"
+)
+
+// setupLogger replaces the logger (used by the CLI and the library) by a slog logger
+// configured by the log-format, log-level, and quiet options.
+func setupLogger(ctx *cli.Context) error {
+	handler, err := newLogHandler(ctx, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	log.Logger = log.NewSlogLogger(slog.New(handler))
+
+	return nil
+}
+
+// newLogHandler creates the slog handler of the logs.
+// The text format is the format of the standard logger: "2006/01/02 15:04:05 LEVEL message".
+// The JSON format writes JSON lines: {"time":"...","level":"info","message":"..."}.
+func newLogHandler(ctx *cli.Context, w io.Writer) (slog.Handler, error) {
+	level, err := getLogLevel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	format := ctx.String(flgLogFormat)
+	if format == "" && isJSONOutput(ctx) {
+		format = logFormatJSON
+	}
+
+	switch format {
+	case logFormatText, "":
+		return newTextLogHandler(w, level), nil
+
+	case logFormatJSON:
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{
+			Level:       level,
+			ReplaceAttr: replaceJSONLogAttr,
+		}), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported log format: %s", format)
+	}
+}
+
+// getLogLevel returns the minimum level of the logs: the quiet option overrides the log-level option.
+func getLogLevel(ctx *cli.Context) (slog.Level, error) {
+	if ctx.Bool(flgQuiet) {
+		return slog.LevelError, nil
+	}
+
+	switch strings.ToLower(ctx.String(flgLogLevel)) {
+	case logLevelDebug:
+		return slog.LevelDebug, nil
+	case logLevelInfo, "":
+		return slog.LevelInfo, nil
+	case logLevelWarn:
+		return slog.LevelWarn, nil
+	case logLevelError:
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unsupported log level: %s", ctx.String(flgLogLevel))
+	}
+}
+
+// replaceJSONLogAttr renames the message attribute, lowercases the level (the messages without level are info or error entries),
+// and writes the time in UTC.
+func replaceJSONLogAttr(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return attr
+	}
+
+	switch attr.Key {
+	case slog.MessageKey:
+		attr.Key = "message"
+
+	case slog.LevelKey:
+		level, ok := attr.Value.Any().(slog.Level)
+		if !ok {
+			break
+		}
+
+		switch level {
+		case log.LevelPrint:
+			level = slog.LevelInfo
+		case log.LevelFatal:
+			level = slog.LevelError
+		}
+
+		attr.Value = slog.StringValue(strings.ToLower(level.String()))
+
+	case slog.TimeKey:
+		attr.Value = slog.TimeValue(attr.Value.Time().UTC())
+	}
+
+	return attr
+}
+
+// textLogHandler a slog handler writing the entries in the format of the standard logger,
+// the level and the domain are prefixes of the message: "2006/01/02 15:04:05 [WARN] [example.com] message key=value".
+type textLogHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Leveler
+	attrs []slog.Attr
+}
+
+func newTextLogHandler(w io.Writer, level slog.Leveler) *textLogHandler {
+	return &textLogHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *textLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *textLogHandler) Handle(_ context.Context, record slog.Record) error {
+	buf := &bytes.Buffer{}
+
+	buf.WriteString(record.Time.Format("2006/01/02 15:04:05 "))
+
+	// The messages without level (ex: log.Println, log.Fatal) are written without level, as the standard logger does.
+	if record.Level != log.LevelPrint && record.Level != log.LevelFatal {
+		_, _ = fmt.Fprintf(buf, "[%s] ", record.Level)
+	}
+
+	attrs := slices.Clip(h.attrs)
+
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+
+	buf.WriteString(log.FormatAttrs(record.Message, attrs))
+	buf.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, err := h.w.Write(buf.Bytes())
+
+	return err
+}
+
+func (h *textLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &textLogHandler{mu: h.mu, w: h.w, level: h.level, attrs: append(slices.Clip(h.attrs), attrs...)}
+}
+
+// WithGroup the groups are not supported: the attributes are not qualified.
+func (h *textLogHandler) WithGroup(_ string) slog.Handler {
+	return h
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/go-acme/lego/v4/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_newLogHandler_json(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	handler, err := newLogHandler(newTestContext(t, "--output", "json"), buf)
+	require.NoError(t, err)
+
+	logger := log.NewSlogLogger(slog.New(handler))
+
+	logger.Printf("[DEBUG] %s %s", "GET", "https://example.com/dir")
+	logger.Printf("[INFO] [%s] acme: Obtaining bundled SAN certificate", "example.com")
+	logger.Printf("[WARN] [%s] Unable to update the OCSP staple", "example.com")
+	logger.Println("Certificate was revoked.")
+	logger.LogAttrs(slog.LevelInfo, "acme: Obtaining bundled SAN certificate", slog.String(log.DomainKey, "example.com"))
+	logger.LogAttrs(log.LevelFatal, "Could not obtain certificates:\n\terror")
+
+	type entry struct {
+		Level   string `json:"level"`
+		Message string `json:"message"`
+		Domain  string `json:"domain,omitempty"`
+	}
+
+	var entries []entry
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e entry
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+
+		entries = append(entries, e)
+	}
+
+	expected := []entry{
+		{Level: "info", Message: "[example.com] acme: Obtaining bundled SAN certificate"},
+		{Level: "warn", Message: "[example.com] Unable to update the OCSP staple"},
+		{Level: "info", Message: "Certificate was revoked."},
+		{Level: "info", Message: "acme: Obtaining bundled SAN certificate", Domain: "example.com"},
+		{Level: "error", Message: "Could not obtain certificates:\n\terror"},
+	}
+
+	assert.Equal(t, expected, entries)
+}
+
+func Test_newLogHandler_text(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		args     []string
+		expected []string
+	}{
+		{
+			desc:     "default",
+			expected: []string{"Certificate was revoked.", "[WARN] [example.com] Unable to update the OCSP staple", "Could not obtain certificates"},
+		},
+		{
+			desc: "debug",
+			args: []string{"--log-level", "debug"},
+			expected: []string{
+				"[DEBUG] GET https://example.com/dir", "Certificate was revoked.",
+				"[WARN] [example.com] Unable to update the OCSP staple", "Could not obtain certificates",
+			},
+		},
+		{
+			desc:     "warn",
+			args:     []string{"--log-level", "WARN"},
+			expected: []string{"[WARN] [example.com] Unable to update the OCSP staple", "Could not obtain certificates"},
+		},
+		{
+			desc:     "quiet",
+			args:     []string{"--quiet", "--log-level", "debug"},
+			expected: []string{"Could not obtain certificates"},
+		},
+		{
+			desc:     "text format with JSON output",
+			args:     []string{"--output", "json", "--log-format", "text"},
+			expected: []string{"Certificate was revoked.", "[WARN] [example.com] Unable to update the OCSP staple", "Could not obtain certificates"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			handler, err := newLogHandler(newTestContext(t, test.args...), buf)
+			require.NoError(t, err)
+
+			logger := log.NewSlogLogger(slog.New(handler))
+
+			logger.Printf("[DEBUG] %s %s", "GET", "https://example.com/dir")
+			logger.Println("Certificate was revoked.")
+			logger.LogAttrs(slog.LevelWarn, "Unable to update the OCSP staple", slog.String(log.DomainKey, "example.com"))
+			logger.LogAttrs(log.LevelFatal, "Could not obtain certificates")
+
+			var messages []string
+
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				// Removes the date and the time: "2006/01/02 15:04:05 ".
+				messages = append(messages, line[20:])
+			}
+
+			assert.Equal(t, test.expected, messages)
+		})
+	}
+}
+
+func Test_newLogHandler_errors(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		args        []string
+		expectedErr string
+	}{
+		{
+			desc:        "unsupported format",
+			args:        []string{"--log-format", "xml"},
+			expectedErr: "unsupported log format: xml",
+		},
+		{
+			desc:        "unsupported level",
+			args:        []string{"--log-level", "trace"},
+			expectedErr: "unsupported log level: trace",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			_, err := newLogHandler(newTestContext(t, test.args...), &bytes.Buffer{})
+			require.EqualError(t, err, test.expectedErr)
+		})
+	}
+}
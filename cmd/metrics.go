@@ -0,0 +1,336 @@
+package cmd
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/urfave/cli/v2"
+)
+
+const metricsNamespace = "lego"
+
+// legoMetrics the metrics of the commands.
+var legoMetrics = newMetrics()
+
+type metrics struct {
+	registry *prometheus.Registry
+
+	certificatesObtained prometheus.Counter
+	certificatesRenewed  prometheus.Counter
+	certificatesFailures *prometheus.CounterVec
+	challengeDuration    *prometheus.HistogramVec
+	acmeRequestDuration  *prometheus.HistogramVec
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		certificatesObtained: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "certificates_obtained_total",
+			Help:      "The number of obtained certificates.",
+		}),
+		certificatesRenewed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "certificates_renewed_total",
+			Help:      "The number of renewed certificates.",
+		}),
+		certificatesFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "certificates_failures_total",
+			Help:      "The number of failed certificates, by event (obtain, renew).",
+		}, []string{"event"}),
+		challengeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "challenge_duration_seconds",
+			Help:      "The duration of the challenges (from the creation to the cleanup of the challenge), by challenge type and provider.",
+			Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600},
+		}, []string{"type", "provider"}),
+		acmeRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "acme_request_duration_seconds",
+			Help:      "The duration of the requests to the ACME server, by HTTP method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+	}
+
+	m.registry.MustRegister(m.certificatesObtained, m.certificatesRenewed, m.certificatesFailures, m.challengeDuration, m.acmeRequestDuration)
+
+	return m
+}
+
+// observeSuccess updates the metrics of an obtained or renewed certificate.
+func (m *metrics) observeSuccess(event string) {
+	switch event {
+	case notifyEventObtain:
+		m.certificatesObtained.Inc()
+	case notifyEventRenew:
+		m.certificatesRenewed.Inc()
+	}
+}
+
+// observeFailure updates the metrics of a failed certificate.
+func (m *metrics) observeFailure(event string) {
+	m.certificatesFailures.WithLabelValues(event).Inc()
+}
+
+// gatherer returns the gatherer of the metrics of the commands and of the stored certificates.
+func (m *metrics) gatherer(certsStorage *CertificatesStorage) prometheus.Gatherer {
+	certsRegistry := prometheus.NewRegistry()
+	certsRegistry.MustRegister(&certificatesCollector{certsStorage: certsStorage})
+
+	return prometheus.Gatherers{m.registry, certsRegistry}
+}
+
+// handler returns the handler of the metrics endpoint.
+func (m *metrics) handler(certsStorage *CertificatesStorage) http.Handler {
+	return promhttp.HandlerFor(m.gatherer(certsStorage), promhttp.HandlerOpts{})
+}
+
+// isMetricsEnabled returns true if the metrics are exposed (metrics.textfile option, or status-address option of the daemon).
+func isMetricsEnabled(ctx *cli.Context) bool {
+	return ctx.String(flgMetricsTextfile) != "" || ctx.String(flgDaemonStatusAddress) != ""
+}
+
+// writeMetricsTextfile writes the metrics in the file defined by the metrics.textfile option (node exporter textfile collector format).
+func writeMetricsTextfile(ctx *cli.Context) error {
+	filename := ctx.String(flgMetricsTextfile)
+	if filename == "" {
+		return nil
+	}
+
+	err := prometheus.WriteToTextfile(filename, legoMetrics.gatherer(NewCertificatesStorage(ctx)))
+	if err != nil {
+		log.Warnf("Unable to write the metrics: %v", err)
+	}
+
+	return nil
+}
+
+// instrumentTransport measures the duration of the requests to the ACME server.
+func instrumentTransport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	return promhttp.InstrumentRoundTripperDuration(legoMetrics.acmeRequestDuration, rt)
+}
+
+// certificatesCollector collects the number of days before the expiration of the stored certificates.
+type certificatesCollector struct {
+	certsStorage *CertificatesStorage
+}
+
+var certificateExpiryDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(metricsNamespace, "", "certificate_expiry_days"),
+	"The number of days before the expiration of the stored certificates.",
+	[]string{"domain"}, nil,
+)
+
+func (c *certificatesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- certificateExpiryDesc
+}
+
+func (c *certificatesCollector) Collect(ch chan<- prometheus.Metric) {
+	keys, err := c.certsStorage.ListCertificates()
+	if err != nil {
+		log.Warnf("metrics: unable to list the certificates: %v", err)
+		return
+	}
+
+	for _, key := range keys {
+		data, err := c.certsStorage.backend.ReadFile(key)
+		if err != nil {
+			continue
+		}
+
+		cert, err := certcrypto.ParsePEMCertificate(data)
+		if err != nil {
+			continue
+		}
+
+		domain := strings.TrimSuffix(path.Base(key), certExt)
+
+		ch <- prometheus.MustNewConstMetric(certificateExpiryDesc, prometheus.GaugeValue, time.Until(cert.NotAfter).Hours()/24, domain)
+	}
+}
+
+// instrumentedProvider measures the duration of the challenges of a provider.
+type instrumentedProvider struct {
+	challenge.Provider
+
+	observer prometheus.Observer
+
+	mu      sync.Mutex
+	started map[string]time.Time
+}
+
+func (p *instrumentedProvider) Present(domain, token, keyAuth string) error {
+	p.mu.Lock()
+	p.started[domain+token] = time.Now()
+	p.mu.Unlock()
+
+	return p.Provider.Present(domain, token, keyAuth)
+}
+
+func (p *instrumentedProvider) CleanUp(domain, token, keyAuth string) error {
+	p.mu.Lock()
+	started, ok := p.started[domain+token]
+	delete(p.started, domain+token)
+	p.mu.Unlock()
+
+	if ok {
+		p.observer.Observe(time.Since(started).Seconds())
+	}
+
+	return p.Provider.CleanUp(domain, token, keyAuth)
+}
+
+// PresentAccount is only used by the dns-account-01 challenge.
+func (p *instrumentedProvider) PresentAccount(label, domain, token, keyAuth string) error {
+	provider, ok := p.Provider.(challenge.AccountProvider)
+	if !ok {
+		return dns01.ErrAccountChallengeNotSupported
+	}
+
+	p.mu.Lock()
+	p.started[domain+token] = time.Now()
+	p.mu.Unlock()
+
+	return provider.PresentAccount(label, domain, token, keyAuth)
+}
+
+// CleanUpAccount is only used by the dns-account-01 challenge.
+func (p *instrumentedProvider) CleanUpAccount(label, domain, token, keyAuth string) error {
+	provider, ok := p.Provider.(challenge.AccountProvider)
+	if !ok {
+		return dns01.ErrAccountChallengeNotSupported
+	}
+
+	p.mu.Lock()
+	started, ok := p.started[domain+token]
+	delete(p.started, domain+token)
+	p.mu.Unlock()
+
+	if ok {
+		p.observer.Observe(time.Since(started).Seconds())
+	}
+
+	return provider.CleanUpAccount(label, domain, token, keyAuth)
+}
+
+// instrumentedBatchProvider measures the duration of the challenges of a batch provider.
+type instrumentedBatchProvider struct {
+	*instrumentedProvider
+
+	batch challenge.BatchProvider
+}
+
+func (p *instrumentedBatchProvider) PresentBatch(challenges []challenge.BatchChallenge) error {
+	started := time.Now()
+
+	p.mu.Lock()
+	for _, chlg := range challenges {
+		p.started[chlg.Domain+chlg.Token] = started
+	}
+	p.mu.Unlock()
+
+	return p.batch.PresentBatch(challenges)
+}
+
+func (p *instrumentedBatchProvider) CleanUpBatch(challenges []challenge.BatchChallenge) error {
+	p.mu.Lock()
+	for _, chlg := range challenges {
+		if started, ok := p.started[chlg.Domain+chlg.Token]; ok {
+			p.observer.Observe(time.Since(started).Seconds())
+		}
+
+		delete(p.started, chlg.Domain+chlg.Token)
+	}
+	p.mu.Unlock()
+
+	return p.batch.CleanUpBatch(challenges)
+}
+
+type timeoutProvider interface {
+	Timeout() (timeout, interval time.Duration)
+}
+
+type sequentialProvider interface {
+	Sequential() time.Duration
+}
+
+// instrumentProvider measures the duration of the challenges of a provider (only if the metrics are enabled).
+// The optional interfaces of the provider (timeout, sequential, batch) are preserved.
+func instrumentProvider(ctx *cli.Context, provider challenge.Provider, chlgType challenge.Type, name string) challenge.Provider {
+	if !isMetricsEnabled(ctx) {
+		return provider
+	}
+
+	if name == "" {
+		// the name of the package of the provider (ex: webroot, http01).
+		t := reflect.TypeOf(provider)
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		name = path.Base(t.PkgPath())
+	}
+
+	p := &instrumentedProvider{
+		Provider: provider,
+		observer: legoMetrics.challengeDuration.WithLabelValues(chlgType.String(), name),
+		started:  make(map[string]time.Time),
+	}
+
+	timeout, isTimeout := provider.(timeoutProvider)
+	sequential, isSequential := provider.(sequentialProvider)
+
+	// The batch operations are not used with the sequential providers.
+	if batch, isBatch := provider.(challenge.BatchProvider); isBatch && !isSequential {
+		bp := &instrumentedBatchProvider{instrumentedProvider: p, batch: batch}
+
+		if isTimeout {
+			return struct {
+				*instrumentedBatchProvider
+				timeoutProvider
+			}{bp, timeout}
+		}
+
+		return bp
+	}
+
+	switch {
+	case isTimeout && isSequential:
+		return struct {
+			*instrumentedProvider
+			timeoutProvider
+			sequentialProvider
+		}{p, timeout, sequential}
+
+	case isTimeout:
+		return struct {
+			*instrumentedProvider
+			timeoutProvider
+		}{p, timeout}
+
+	case isSequential:
+		return struct {
+			*instrumentedProvider
+			sequentialProvider
+		}{p, sequential}
+
+	default:
+		return p
+	}
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDNSProvider struct{}
+
+func (fakeDNSProvider) Present(_, _, _ string) error { return nil }
+
+func (fakeDNSProvider) CleanUp(_, _, _ string) error { return nil }
+
+func (fakeDNSProvider) Sequential() time.Duration { return time.Minute }
+
+func Test_instrumentProvider(t *testing.T) {
+	provider := fakeDNSProvider{}
+
+	ctx := newTestContext(t)
+
+	assert.Equal(t, provider, instrumentProvider(ctx, provider, challenge.DNS01, "fake"))
+
+	ctx = newTestContext(t, "--metrics.textfile", filepath.Join(t.TempDir(), "lego.prom"))
+
+	instrumented := instrumentProvider(ctx, provider, challenge.DNS01, "fake")
+
+	_, ok := instrumented.(challenge.ProviderTimeout)
+	assert.False(t, ok)
+
+	p, ok := instrumented.(sequentialProvider)
+	require.True(t, ok, "the sequential interface must be preserved")
+
+	assert.Equal(t, time.Minute, p.Sequential())
+
+	require.NoError(t, instrumented.Present("example.com", "token", "keyAuth"))
+	require.NoError(t, instrumented.CleanUp("example.com", "token", "keyAuth"))
+
+	metric := &dto.Metric{}
+	require.NoError(t, legoMetrics.challengeDuration.WithLabelValues("dns-01", "fake").(prometheus.Histogram).Write(metric))
+
+	assert.Equal(t, uint64(1), metric.GetHistogram().GetSampleCount())
+}
+
+type fakeBatchDNSProvider struct {
+	presented []challenge.BatchChallenge
+}
+
+func (*fakeBatchDNSProvider) Present(_, _, _ string) error { return nil }
+
+func (*fakeBatchDNSProvider) CleanUp(_, _, _ string) error { return nil }
+
+func (*fakeBatchDNSProvider) Timeout() (timeout, interval time.Duration) {
+	return time.Hour, time.Minute
+}
+
+func (p *fakeBatchDNSProvider) PresentBatch(challenges []challenge.BatchChallenge) error {
+	p.presented = challenges
+	return nil
+}
+
+func (*fakeBatchDNSProvider) CleanUpBatch(_ []challenge.BatchChallenge) error { return nil }
+
+func Test_instrumentProvider_batch(t *testing.T) {
+	provider := &fakeBatchDNSProvider{}
+
+	ctx := newTestContext(t, "--metrics.textfile", filepath.Join(t.TempDir(), "lego.prom"))
+
+	instrumented := instrumentProvider(ctx, provider, challenge.DNS01, "fake-batch")
+
+	p, ok := instrumented.(challenge.BatchProvider)
+	require.True(t, ok, "the batch interface must be preserved")
+
+	_, ok = instrumented.(challenge.ProviderTimeout)
+	assert.True(t, ok, "the timeout interface must be preserved")
+
+	// The challenges of the wrapped provider are presented with the batch operations.
+	assert.True(t, dns01.NewChallenge(nil, nil, instrumented).Batch())
+
+	challenges := []challenge.BatchChallenge{
+		{Domain: "example.com", Token: "a", KeyAuth: "keyAuth"},
+		{Domain: "example.org", Token: "b", KeyAuth: "keyAuth"},
+	}
+
+	require.NoError(t, p.PresentBatch(challenges))
+	require.NoError(t, p.CleanUpBatch(challenges))
+
+	assert.Equal(t, challenges, provider.presented)
+
+	metric := &dto.Metric{}
+	require.NoError(t, legoMetrics.challengeDuration.WithLabelValues("dns-01", "fake-batch").(prometheus.Histogram).Write(metric))
+
+	assert.Equal(t, uint64(2), metric.GetHistogram().GetSampleCount())
+}
+
+func Test_writeMetricsTextfile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "lego.prom")
+
+	path := t.TempDir()
+
+	ctx := newTestContext(t, "--path", path, "--metrics.textfile", filename)
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := certcrypto.GeneratePemCert(privateKey.(*rsa.PrivateKey), "example.com", nil)
+	require.NoError(t, err)
+
+	NewCertificatesStorage(ctx).SaveResource(&certificate.Resource{
+		Domain:      "example.com",
+		Certificate: certPEM,
+		PrivateKey:  certcrypto.PEMEncode(privateKey),
+	})
+
+	legoMetrics.observeSuccess(notifyEventObtain)
+
+	require.NoError(t, writeMetricsTextfile(ctx))
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(data), "lego_certificates_obtained_total")
+	assert.Contains(t, string(data), `lego_certificate_expiry_days{domain="example.com"}`)
+
+}
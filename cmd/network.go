@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// checkNetworkOptions checks the proxy and the CA certificates options before running a command.
+func checkNetworkOptions(ctx *cli.Context) error {
+	if !ctx.IsSet(flgProxy) && !ctx.IsSet(flgCACert) {
+		return nil
+	}
+
+	return configureTransport(ctx, &http.Transport{})
+}
+
+// configureTransport applies the proxy and the CA certificates options to the transport of the ACME client.
+// The other transports (ex: the DNS providers) are not modified: they use the standard environment variables (HTTPS_PROXY, etc.).
+func configureTransport(ctx *cli.Context, transport *http.Transport) error {
+	if ctx.IsSet(flgProxy) {
+		proxy, err := parseProxy(ctx.String(flgProxy))
+		if err != nil {
+			return err
+		}
+
+		proxyFunc := (&httpproxy.Config{
+			HTTPProxy:  proxy.String(),
+			HTTPSProxy: proxy.String(),
+			NoProxy:    getEnvAny("NO_PROXY", "no_proxy"),
+		}).ProxyFunc()
+
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	if ctx.IsSet(flgCACert) {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+
+		pool, err := appendCACertificates(transport.TLSClientConfig.RootCAs, ctx.StringSlice(flgCACert))
+		if err != nil {
+			return fmt.Errorf("invalid value for --%s: %w", flgCACert, err)
+		}
+
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	return nil
+}
+
+// appendCACertificates returns a copy of the pool with the CA certificates of the files.
+// The pool defined by LEGO_CA_CERTIFICATES is kept, and the system roots are used if there is no pool.
+func appendCACertificates(pool *x509.CertPool, files []string) (*x509.CertPool, error) {
+	if pool == nil {
+		var err error
+
+		pool, err = x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+	} else {
+		pool = pool.Clone()
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %q: %w", file, err)
+		}
+
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificate found in %q", file)
+		}
+	}
+
+	return pool, nil
+}
+
+// parseProxy parses the URL of the proxy, the scheme is optional (ex: proxy.example.com:3128).
+func parseProxy(value string) (*url.URL, error) {
+	if !strings.Contains(value, "://") {
+		value = "http://" + value
+	}
+
+	proxy, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for --%s: %w", flgProxy, err)
+	}
+
+	switch {
+	case proxy.Host == "":
+		return nil, fmt.Errorf("invalid value for --%s: the host is missing", flgProxy)
+	case proxy.Scheme != "http" && proxy.Scheme != "https" && proxy.Scheme != "socks5":
+		return nil, fmt.Errorf("invalid value for --%s: unsupported scheme %q", flgProxy, proxy.Scheme)
+	}
+
+	return proxy, nil
+}
+
+func getEnvAny(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
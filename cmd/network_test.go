@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_configureTransport_proxy(t *testing.T) {
+	t.Setenv("NO_PROXY", "internal.example.com")
+
+	ctx := newTestContext(t, "--proxy", "proxy.example.com:3128")
+
+	transport := &http.Transport{}
+
+	require.NoError(t, configureTransport(ctx, transport))
+
+	testCases := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://acme-v02.api.letsencrypt.org/directory", expected: "http://proxy.example.com:3128"},
+		{url: "http://api.example.org/zones", expected: "http://proxy.example.com:3128"},
+		{url: "https://internal.example.com/directory"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.url, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, test.url, http.NoBody)
+			require.NoError(t, err)
+
+			proxy, err := transport.Proxy(req)
+			require.NoError(t, err)
+
+			if test.expected == "" {
+				assert.Nil(t, proxy)
+				return
+			}
+
+			require.NotNil(t, proxy)
+			assert.Equal(t, test.expected, proxy.String())
+		})
+	}
+}
+
+func writeTestCA(t *testing.T, commonName string) (string, *x509.Certificate) {
+	t.Helper()
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := certcrypto.GeneratePemCert(privateKey.(*rsa.PrivateKey), commonName, nil)
+	require.NoError(t, err)
+
+	cert, err := certcrypto.ParsePEMCertificate(certPEM)
+	require.NoError(t, err)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, certPEM, 0o600))
+
+	return caFile, cert
+}
+
+func Test_configureTransport_caCert(t *testing.T) {
+	caFile, caCert := writeTestCA(t, "ca.example.com")
+
+	ctx := newTestContext(t, "--cacert", caFile)
+
+	transport := &http.Transport{}
+
+	require.NoError(t, configureTransport(ctx, transport))
+
+	require.NotNil(t, transport.TLSClientConfig)
+	require.NotNil(t, transport.TLSClientConfig.RootCAs)
+	assert.Nil(t, transport.Proxy)
+
+	_, err := caCert.Verify(x509.VerifyOptions{Roots: transport.TLSClientConfig.RootCAs})
+	require.NoError(t, err)
+}
+
+func Test_configureTransport_caCert_existingPool(t *testing.T) {
+	caFile, caCert := writeTestCA(t, "ca.example.com")
+	_, envCert := writeTestCA(t, "env.example.com")
+
+	// The pool defined by LEGO_CA_CERTIFICATES.
+	envPool := x509.NewCertPool()
+	envPool.AddCert(envCert)
+
+	ctx := newTestContext(t, "--cacert", caFile)
+
+	transport := &http.Transport{TLSClientConfig: &tls.Config{RootCAs: envPool}}
+
+	require.NoError(t, configureTransport(ctx, transport))
+
+	for _, cert := range []*x509.Certificate{caCert, envCert} {
+		_, err := cert.Verify(x509.VerifyOptions{Roots: transport.TLSClientConfig.RootCAs})
+		require.NoError(t, err)
+	}
+
+	// The original pool is not modified.
+	_, err := caCert.Verify(x509.VerifyOptions{Roots: envPool})
+	require.Error(t, err)
+}
+
+func Test_configureTransport_errors(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		args        []string
+		expectedErr string
+	}{
+		{
+			desc:        "proxy without host",
+			args:        []string{"--proxy", "http://"},
+			expectedErr: "invalid value for --proxy: the host is missing",
+		},
+		{
+			desc:        "proxy unsupported scheme",
+			args:        []string{"--proxy", "ftp://proxy.example.com"},
+			expectedErr: `invalid value for --proxy: unsupported scheme "ftp"`,
+		},
+		{
+			desc:        "CA file not found",
+			args:        []string{"--cacert", filepath.Join(t.TempDir(), "missing.pem")},
+			expectedErr: "invalid value for --cacert: error reading",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := newTestContext(t, test.args...)
+
+			err := configureTransport(ctx, &http.Transport{})
+			require.ErrorContains(t, err, test.expectedErr)
+		})
+	}
+}
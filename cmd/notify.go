@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli/v2"
+)
+
+// Notification events.
+const (
+	notifyEventObtain = "obtain"
+	notifyEventRenew  = "renew"
+)
+
+// notification the payload of the notifications (webhook and email).
+type notification struct {
+	Event    string     `json:"event"`
+	Success  bool       `json:"success"`
+	Domain   string     `json:"domain"`
+	Domains  []string   `json:"domains,omitempty"`
+	NotAfter *time.Time `json:"notAfter,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	Time     time.Time  `json:"time"`
+}
+
+// newSuccessNotification creates the notification of an obtained or renewed certificate.
+func newSuccessNotification(event string, certRes *certificate.Resource) notification {
+	n := notification{
+		Event:   event,
+		Success: true,
+		Domain:  certRes.Domain,
+		Time:    time.Now().UTC(),
+	}
+
+	cert, err := certcrypto.ParsePEMCertificate(certRes.Certificate)
+	if err == nil {
+		n.Domains = certcrypto.ExtractDomains(cert)
+		n.NotAfter = &cert.NotAfter
+	}
+
+	return n
+}
+
+// newFailureNotification creates the notification of a failed certificate.
+func newFailureNotification(event, domain string, err error) notification {
+	return notification{
+		Event:  event,
+		Domain: domain,
+		Error:  err.Error(),
+		Time:   time.Now().UTC(),
+	}
+}
+
+// notifier sends the notifications to a webhook and/or by email.
+type notifier struct {
+	webhook    string
+	httpClient *http.Client
+
+	smtpAddress  string
+	smtpAuth     smtp.Auth
+	smtpFrom     string
+	smtpTo       []string
+	smtpSendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// newNotifier creates a notifier from the notify options, returns nil if the notifications are disabled.
+func newNotifier(ctx *cli.Context) *notifier {
+	n := &notifier{
+		webhook:      ctx.String(flgNotifyWebhook),
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		smtpAddress:  ctx.String(flgNotifySMTPAddress),
+		smtpFrom:     ctx.String(flgNotifySMTPFrom),
+		smtpTo:       ctx.StringSlice(flgNotifySMTPTo),
+		smtpSendMail: smtp.SendMail,
+	}
+
+	if n.webhook == "" && n.smtpAddress == "" {
+		return nil
+	}
+
+	if n.smtpAddress != "" {
+		if n.smtpFrom == "" || len(n.smtpTo) == 0 {
+			log.Fatalf("The notification emails require the options --%s and --%s.", flgNotifySMTPFrom, flgNotifySMTPTo)
+		}
+
+		if username := ctx.String(flgNotifySMTPUsername); username != "" {
+			host, _, err := net.SplitHostPort(n.smtpAddress)
+			if err != nil {
+				log.Fatalf("Invalid SMTP address %s: %v", n.smtpAddress, err)
+			}
+
+			n.smtpAuth = smtp.PlainAuth("", username, ctx.String(flgNotifySMTPPassword), host)
+		}
+	}
+
+	return n
+}
+
+// Notify sends the notification, the errors are only logged: the notifications must not change the outcome of the commands.
+func (n *notifier) Notify(event notification) {
+	if n == nil {
+		return
+	}
+
+	if n.webhook != "" {
+		if err := n.sendWebhook(event); err != nil {
+			log.WithDomain(event.Domain).Warnf("Unable to send the webhook notification: %v", err)
+		}
+	}
+
+	if n.smtpAddress != "" {
+		if err := n.sendEmail(event); err != nil {
+			log.WithDomain(event.Domain).Warnf("Unable to send the notification email: %v", err)
+		}
+	}
+}
+
+func (n *notifier) sendWebhook(event notification) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "lego-cli")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (n *notifier) sendEmail(event notification) error {
+	return n.smtpSendMail(n.smtpAddress, n.smtpAuth, n.smtpFrom, n.smtpTo, n.emailMessage(event))
+}
+
+// emailMessage creates the notification email (text/plain).
+func (n *notifier) emailMessage(event notification) []byte {
+	outcome := "succeeded"
+	if !event.Success {
+		outcome = "failed"
+	}
+
+	msg := &bytes.Buffer{}
+
+	_, _ = fmt.Fprintf(msg, "From: %s\r\n", n.smtpFrom)
+	_, _ = fmt.Fprintf(msg, "To: %s\r\n", strings.Join(n.smtpTo, ", "))
+	_, _ = fmt.Fprintf(msg, "Subject: lego: %s of %s %s\r\n", event.Event, event.Domain, outcome)
+	_, _ = fmt.Fprintf(msg, "Date: %s\r\n", event.Time.Format(time.RFC1123Z))
+	_, _ = fmt.Fprint(msg, "MIME-Version: 1.0\r\n")
+	_, _ = fmt.Fprint(msg, "Content-Type: text/plain; charset=utf-8\r\n")
+	_, _ = fmt.Fprint(msg, "\r\n")
+
+	_, _ = fmt.Fprintf(msg, "The %s of the certificate %s %s.\r\n\r\n", event.Event, event.Domain, outcome)
+
+	if len(event.Domains) > 0 {
+		_, _ = fmt.Fprintf(msg, "Domains: %s\r\n", strings.Join(event.Domains, ", "))
+	}
+
+	if event.NotAfter != nil {
+		_, _ = fmt.Fprintf(msg, "Expiration: %s\r\n", event.NotAfter.UTC().Format(time.RFC3339))
+	}
+
+	if event.Error != "" {
+		_, _ = fmt.Fprintf(msg, "Error: %s\r\n", strings.ReplaceAll(event.Error, "\n", "\r\n"))
+	}
+
+	return msg.Bytes()
+}
+
+// reportSuccess updates the metrics and the history, and sends the notification of an obtained or renewed certificate.
+func reportSuccess(ctx *cli.Context, event string, certRes *certificate.Resource) {
+	legoMetrics.observeSuccess(event)
+	recordSuccessHistory(ctx, event, certRes)
+
+	newNotifier(ctx).Notify(newSuccessNotification(event, certRes))
+}
+
+// reportFailure updates the metrics and the history, and sends the notification of a failed certificate.
+func reportFailure(ctx *cli.Context, event, domain string, err error) {
+	if err == nil {
+		return
+	}
+
+	legoMetrics.observeFailure(event)
+	recordFailureHistory(ctx, event, domain, err)
+
+	newNotifier(ctx).Notify(newFailureNotification(event, domain, err))
+}
+
+// reportOutcome reports the final outcome of a certificate, once all the steps (save, deploy, hook) are done:
+// a failure if one of the steps failed, even after the certificate was saved,
+// a success if a certificate was obtained, and nothing if the certificate was not renewed.
+func reportOutcome(ctx *cli.Context, event, domain string, certRes *certificate.Resource, err error) {
+	switch {
+	case err != nil:
+		reportFailure(ctx, event, domain, err)
+	case certRes != nil:
+		reportSuccess(ctx, event, certRes)
+	}
+}
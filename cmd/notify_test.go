@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_notifier_webhook(t *testing.T) {
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := certcrypto.GeneratePemCert(privateKey.(*rsa.PrivateKey), "example.com", nil)
+	require.NoError(t, err)
+
+	var received []notification
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+		var n notification
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&n))
+
+		received = append(received, n)
+	}))
+	t.Cleanup(server.Close)
+
+	n := &notifier{webhook: server.URL, httpClient: server.Client()}
+
+	n.Notify(newSuccessNotification(notifyEventRenew, &certificate.Resource{Domain: "example.com", Certificate: certPEM}))
+	n.Notify(newFailureNotification(notifyEventObtain, "example.org", errors.New("boom")))
+
+	require.Len(t, received, 2)
+
+	assert.Equal(t, notifyEventRenew, received[0].Event)
+	assert.True(t, received[0].Success)
+	assert.Equal(t, "example.com", received[0].Domain)
+	assert.Contains(t, received[0].Domains, "example.com")
+	require.NotNil(t, received[0].NotAfter)
+	assert.Empty(t, received[0].Error)
+
+	assert.Equal(t, notifyEventObtain, received[1].Event)
+	assert.False(t, received[1].Success)
+	assert.Equal(t, "example.org", received[1].Domain)
+	assert.Nil(t, received[1].NotAfter)
+	assert.Equal(t, "boom", received[1].Error)
+}
+
+func Test_notifier_email(t *testing.T) {
+	var (
+		addr string
+		to   []string
+		msg  string
+	)
+
+	n := &notifier{
+		smtpAddress: "smtp.example.com:587",
+		smtpFrom:    "lego@example.com",
+		smtpTo:      []string{"admin@example.com", "ops@example.com"},
+		smtpSendMail: func(a string, _ smtp.Auth, _ string, t []string, m []byte) error {
+			addr, to, msg = a, t, string(m)
+			return nil
+		},
+	}
+
+	n.Notify(newFailureNotification(notifyEventRenew, "example.com", errors.New("acme: error: 429")))
+
+	assert.Equal(t, "smtp.example.com:587", addr)
+	assert.Equal(t, []string{"admin@example.com", "ops@example.com"}, to)
+
+	assert.Contains(t, msg, "From: lego@example.com\r\n")
+	assert.Contains(t, msg, "To: admin@example.com, ops@example.com\r\n")
+	assert.Contains(t, msg, "Subject: lego: renew of example.com failed\r\n")
+	assert.Contains(t, msg, "\r\n\r\nThe renew of the certificate example.com failed.\r\n")
+	assert.Contains(t, msg, "Error: acme: error: 429\r\n")
+}
+
+func Test_reportOutcome(t *testing.T) {
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := certcrypto.GeneratePemCert(privateKey.(*rsa.PrivateKey), "example.com", nil)
+	require.NoError(t, err)
+
+	certRes := &certificate.Resource{Domain: "example.com", Certificate: certPEM}
+
+	testCases := []struct {
+		desc     string
+		certRes  *certificate.Resource
+		err      error
+		expected []bool
+	}{
+		{
+			desc:     "success",
+			certRes:  certRes,
+			expected: []bool{true},
+		},
+		{
+			desc:     "failure after the certificate was saved",
+			certRes:  certRes,
+			err:      errors.New("deploy: boom"),
+			expected: []bool{false},
+		},
+		{
+			desc:     "failure",
+			err:      errors.New("boom"),
+			expected: []bool{false},
+		},
+		{
+			desc: "not renewed",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			var received []bool
+
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				var n notification
+				assert.NoError(t, json.NewDecoder(req.Body).Decode(&n))
+
+				received = append(received, n.Success)
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := newTestContext(t, "--path", t.TempDir(), "--notify.webhook", server.URL, "--domains", "example.com")
+
+			reportOutcome(ctx, notifyEventRenew, "example.com", test.certRes, test.err)
+
+			assert.Equal(t, test.expected, received)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/urfave/cli/v2"
+)
+
+// Output modes.
+const (
+	outputModeText = "text"
+	outputModeJSON = "json"
+)
+
+// hookOutput the writer of the output of the hooks (the standard error with the JSON output mode).
+var hookOutput io.Writer = os.Stdout
+
+// resultFileNames the names of the files in the JSON results, by environment variable of the hooks.
+var resultFileNames = map[string]string{
+	hookEnvCertPath:           "certificate",
+	hookEnvCertKeyPath:        "key",
+	hookEnvIssuerCertKeyPath:  "issuer",
+	hookEnvCertPEMPath:        "pem",
+	hookEnvCertPFXPath:        "pfx",
+	hookEnvCertOCSPPath:       "ocsp",
+	hookEnvCertKeyStorePath:   "keystore",
+	hookEnvCertTrustStorePath: "truststore",
+}
+
+func init() {
+	for output, env := range hookEnvOutputPaths {
+		resultFileNames[env] = output
+	}
+}
+
+// certificateResult the JSON result of the run and renew commands.
+type certificateResult struct {
+	Domain   string    `json:"domain"`
+	Domains  []string  `json:"domains"`
+	NotAfter time.Time `json:"notAfter"`
+	// Renewed is false if the certificate has not been renewed (renew command only).
+	Renewed bool              `json:"renewed"`
+	CertURL string            `json:"certUrl,omitempty"`
+	Files   map[string]string `json:"files,omitempty"`
+}
+
+// revokeResult the JSON result of the revoke command.
+type revokeResult struct {
+	Domain string `json:"domain"`
+	// Path the certificate file (cert-file option only).
+	Path     string `json:"path,omitempty"`
+	Revoked  bool   `json:"revoked"`
+	Archived bool   `json:"archived"`
+	// Replaced a replacement certificate has been stored (ari-replaced option only).
+	Replaced bool `json:"replaced,omitempty"`
+}
+
+// listResult the JSON result of the list command.
+type listResult struct {
+	Certificates []listCertificateResult `json:"certificates"`
+	Accounts     []listAccountResult     `json:"accounts,omitempty"`
+}
+
+type listCertificateResult struct {
+	Name      string    `json:"name"`
+	Domains   []string  `json:"domains"`
+	KeyType   string    `json:"keyType"`
+	Issuer    string    `json:"issuer"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	Path      string    `json:"path"`
+}
+
+type listAccountResult struct {
+	Email  string `json:"email"`
+	Server string `json:"server"`
+	Path   string `json:"path"`
+}
+
+// dnsProviderResult the JSON result of the dnshelp command: a DNS provider and its environment variables.
+type dnsProviderResult struct {
+	Code          string              `json:"code"`
+	Name          string              `json:"name"`
+	Aliases       []string            `json:"aliases,omitempty"`
+	Since         string              `json:"since,omitempty"`
+	URL           string              `json:"url,omitempty"`
+	Description   string              `json:"description,omitempty"`
+	Documentation string              `json:"documentation"`
+	Credentials   []dnsProviderEnvVar `json:"credentials,omitempty"`
+	Additional    []dnsProviderEnvVar `json:"additional,omitempty"`
+}
+
+type dnsProviderEnvVar struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     string `json:"default,omitempty"`
+}
+
+// inspectResult the result of the cert inspect command.
+type inspectResult struct {
+	Path          string             `json:"path"`
+	Subject       string             `json:"subject"`
+	Issuer        string             `json:"issuer"`
+	Serial        string             `json:"serial"`
+	KeyType       string             `json:"keyType"`
+	SANs          []string           `json:"sans"`
+	NotBefore     time.Time          `json:"notBefore"`
+	NotAfter      time.Time          `json:"notAfter"`
+	DaysRemaining int                `json:"daysRemaining"`
+	Profile       string             `json:"profile,omitempty"`
+	Chain         inspectChainResult `json:"chain"`
+	// OCSP and ARI are not defined with the offline option.
+	OCSP       *inspectOCSPResult              `json:"ocsp,omitempty"`
+	ARI        *inspectARIResult               `json:"ari,omitempty"`
+	SCTs       []inspectSCTResult              `json:"scts"`
+	Challenges []certificate.ManifestChallenge `json:"challenges,omitempty"`
+}
+
+type inspectChainResult struct {
+	Valid bool   `json:"valid"`
+	Root  string `json:"root,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type inspectOCSPResult struct {
+	// Status good, revoked, unknown, or unavailable (the OCSP responder cannot be used).
+	Status     string     `json:"status"`
+	ThisUpdate time.Time  `json:"thisUpdate,omitempty"`
+	NextUpdate time.Time  `json:"nextUpdate,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+type inspectARIResult struct {
+	Start          time.Time `json:"start,omitempty"`
+	End            time.Time `json:"end,omitempty"`
+	ExplanationURL string    `json:"explanationUrl,omitempty"`
+	Error          string    `json:"error,omitempty"`
+}
+
+type inspectSCTResult struct {
+	Version   int       `json:"version"`
+	LogID     string    `json:"logId"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// setupOutput configures the output of the hooks for the output mode.
+func setupOutput(ctx *cli.Context) error {
+	switch ctx.String(flgOutput) {
+	case outputModeText, "":
+		return nil
+
+	case outputModeJSON:
+		hookOutput = os.Stderr
+
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported output mode: %s", ctx.String(flgOutput))
+	}
+}
+
+func isJSONOutput(ctx *cli.Context) bool {
+	return ctx.String(flgOutput) == outputModeJSON
+}
+
+// printJSON writes a JSON result on the standard output.
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+// newCertificateResult creates the JSON result of a certificate from the metadata of the hooks.
+func newCertificateResult(certRes *certificate.Resource, meta map[string]string) (*certificateResult, error) {
+	cert, err := certcrypto.ParsePEMCertificate(certRes.Certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &certificateResult{
+		Domain:   certRes.Domain,
+		Domains:  certcrypto.ExtractDomains(cert),
+		NotAfter: cert.NotAfter,
+		Renewed:  true,
+		CertURL:  certRes.CertURL,
+		Files:    make(map[string]string),
+	}
+
+	for env, value := range meta {
+		if name, ok := resultFileNames[env]; ok {
+			result.Files[name] = value
+		}
+	}
+
+	return result, nil
+}
+
+// printCertificateResult writes the JSON result of an issued certificate (JSON output mode only).
+func printCertificateResult(ctx *cli.Context, certRes *certificate.Resource, meta map[string]string) error {
+	if !isJSONOutput(ctx) {
+		return nil
+	}
+
+	result, err := newCertificateResult(certRes, meta)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(result)
+}
+
+// printNotRenewedResult writes the JSON result of a certificate that does not need a renewal (JSON output mode only).
+func printNotRenewedResult(ctx *cli.Context, domain string, cert *x509.Certificate) error {
+	if !isJSONOutput(ctx) {
+		return nil
+	}
+
+	return printJSON(certificateResult{
+		Domain:   domain,
+		Domains:  certcrypto.ExtractDomains(cert),
+		NotAfter: cert.NotAfter,
+	})
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"crypto/rsa"
+	"testing"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_newCertificateResult(t *testing.T) {
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := certcrypto.GeneratePemCert(privateKey.(*rsa.PrivateKey), "example.com", nil)
+	require.NoError(t, err)
+
+	certRes := &certificate.Resource{
+		Domain:      "example.com",
+		CertURL:     "https://example.com/cert/1",
+		Certificate: certPEM,
+	}
+
+	meta := map[string]string{
+		hookEnvAccountEmail:               "test@example.com",
+		hookEnvCertDomain:                 "example.com",
+		hookEnvCertPath:                   "/tmp/example.com.crt",
+		hookEnvCertKeyPath:                "/tmp/example.com.key",
+		hookEnvOutputPaths[outputHAProxy]: "/tmp/example.com.haproxy.pem",
+	}
+
+	result, err := newCertificateResult(certRes, meta)
+	require.NoError(t, err)
+
+	assert.Equal(t, "example.com", result.Domain)
+	assert.Contains(t, result.Domains, "example.com")
+	assert.True(t, result.Renewed)
+	assert.Equal(t, "https://example.com/cert/1", result.CertURL)
+
+	expectedFiles := map[string]string{
+		"certificate": "/tmp/example.com.crt",
+		"key":         "/tmp/example.com.key",
+		outputHAProxy: "/tmp/example.com.haproxy.pem",
+	}
+
+	assert.Equal(t, expectedFiles, result.Files)
+}
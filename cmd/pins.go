@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+)
+
+// tlsaPort the port of the TLSA records suggested by the tlsa output format (HTTPS).
+const tlsaPort = 443
+
+// spkiPin returns the SHA-256 hash of the SubjectPublicKeyInfo of the certificate, encoded in base64 (RFC 7469 pin-sha256).
+func spkiPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// encodePins encodes the SPKI pins of the leaf and the intermediate certificates, one pin by line:
+//
+//	pin-sha256="<base64>" # leaf: CN=example.com
+func encodePins(chain *certcrypto.CertificateChain) []byte {
+	buf := &bytes.Buffer{}
+
+	_, _ = fmt.Fprintf(buf, "pin-sha256=%q # leaf: %s\n", spkiPin(chain.Leaf), chain.Leaf.Subject)
+
+	for _, intermediate := range chain.Intermediates {
+		_, _ = fmt.Fprintf(buf, "pin-sha256=%q # intermediate: %s\n", spkiPin(intermediate), intermediate.Subject)
+	}
+
+	return buf.Bytes()
+}
+
+// encodeTLSARecords encodes the suggested TLSA records (RFC 6698, DANE) of the domains of the certificate, in the zone file format:
+// a DANE-EE record (3 1 1) for the public key of the leaf certificate,
+// and a DANE-TA record (2 1 1) for the public key of each intermediate certificate.
+// The wildcard domains and the IP addresses are ignored.
+func encodeTLSARecords(chain *certcrypto.CertificateChain) []byte {
+	buf := &bytes.Buffer{}
+
+	for _, domain := range certcrypto.ExtractDomains(chain.Leaf) {
+		if strings.HasPrefix(domain, "*.") || net.ParseIP(domain) != nil {
+			continue
+		}
+
+		name := fmt.Sprintf("_%d._tcp.%s.", tlsaPort, strings.TrimSuffix(domain, "."))
+
+		_, _ = fmt.Fprintf(buf, "%s IN TLSA 3 1 1 %s\n", name, spkiSHA256Hex(chain.Leaf))
+
+		for _, intermediate := range chain.Intermediates {
+			_, _ = fmt.Fprintf(buf, "%s IN TLSA 2 1 1 %s\n", name, spkiSHA256Hex(intermediate))
+		}
+	}
+
+	return buf.Bytes()
+}
+
+func spkiSHA256Hex(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"net"
+	"testing"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generateTestCertificate(t *testing.T, domain string, sans []string) *x509.Certificate {
+	t.Helper()
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.RSA2048)
+	require.NoError(t, err)
+
+	certPEM, err := certcrypto.GeneratePemCert(privateKey.(*rsa.PrivateKey), domain, nil)
+	require.NoError(t, err)
+
+	cert, err := certcrypto.ParsePEMCertificate(certPEM)
+	require.NoError(t, err)
+
+	// The common name of the generated certificate is not the domain.
+	cert.Subject = pkix.Name{CommonName: domain}
+
+	for _, san := range sans {
+		if ip := net.ParseIP(san); ip != nil {
+			cert.IPAddresses = append(cert.IPAddresses, ip)
+		} else {
+			cert.DNSNames = append(cert.DNSNames, san)
+		}
+	}
+
+	return cert
+}
+
+func Test_encodePins(t *testing.T) {
+	leaf := generateTestCertificate(t, "example.com", nil)
+	intermediate := generateTestCertificate(t, "Test Intermediate", nil)
+
+	leafSum := sha256.Sum256(leaf.RawSubjectPublicKeyInfo)
+	intermediateSum := sha256.Sum256(intermediate.RawSubjectPublicKeyInfo)
+
+	chain := &certcrypto.CertificateChain{Leaf: leaf, Intermediates: []*x509.Certificate{intermediate}}
+
+	expected := `pin-sha256="` + base64.StdEncoding.EncodeToString(leafSum[:]) + `" # leaf: CN=example.com` + "\n" +
+		`pin-sha256="` + base64.StdEncoding.EncodeToString(intermediateSum[:]) + `" # intermediate: CN=Test Intermediate` + "\n"
+
+	assert.Equal(t, expected, string(encodePins(chain)))
+}
+
+func Test_encodeTLSARecords(t *testing.T) {
+	leaf := generateTestCertificate(t, "example.com", []string{"www.example.com", "*.example.org", "192.0.2.1"})
+	intermediate := generateTestCertificate(t, "Test Intermediate", nil)
+
+	leafSum := sha256.Sum256(leaf.RawSubjectPublicKeyInfo)
+	intermediateSum := sha256.Sum256(intermediate.RawSubjectPublicKeyInfo)
+
+	chain := &certcrypto.CertificateChain{Leaf: leaf, Intermediates: []*x509.Certificate{intermediate}}
+
+	expected := "_443._tcp.example.com. IN TLSA 3 1 1 " + hex.EncodeToString(leafSum[:]) + "\n" +
+		"_443._tcp.example.com. IN TLSA 2 1 1 " + hex.EncodeToString(intermediateSum[:]) + "\n" +
+		"_443._tcp.www.example.com. IN TLSA 3 1 1 " + hex.EncodeToString(leafSum[:]) + "\n" +
+		"_443._tcp.www.example.com. IN TLSA 2 1 1 " + hex.EncodeToString(intermediateSum[:]) + "\n"
+
+	assert.Equal(t, expected, string(encodeTLSARecords(chain)))
+}
+
+func Test_encodeTLSARecords_wildcardOnly(t *testing.T) {
+	chain := &certcrypto.CertificateChain{Leaf: generateTestCertificate(t, "*.example.com", nil)}
+
+	assert.Empty(t, encodeTLSARecords(chain))
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// pebble a local ACME test server (https://github.com/letsencrypt/pebble), launched by the selftest command.
+// The validations of the challenges always succeed (PEBBLE_VA_ALWAYS_VALID).
+type pebble struct {
+	// DirectoryURL the URL of the ACME directory.
+	DirectoryURL string
+	// RootCAs the certificate of the HTTPS listener of the server.
+	RootCAs *x509.CertPool
+
+	cmd    *exec.Cmd
+	exited chan struct{}
+	output *bytes.Buffer
+	dir    string
+}
+
+// startPebble launches the Pebble binary with a generated configuration, then waits for the ACME directory.
+func startPebble(ctx context.Context, binary string, timeout time.Duration) (*pebble, error) {
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return nil, fmt.Errorf("pebble binary not found (https://github.com/letsencrypt/pebble): %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "lego-selftest")
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pebble{dir: dir, output: &bytes.Buffer{}}
+
+	configPath, err := p.writeConfig()
+	if err != nil {
+		p.Stop()
+		return nil, err
+	}
+
+	p.cmd = exec.CommandContext(ctx, path, "-config", configPath)
+	p.cmd.Env = append(os.Environ(),
+		"PEBBLE_VA_ALWAYS_VALID=1",
+		"PEBBLE_VA_NOSLEEP=1",
+		"PEBBLE_WFE_NONCEREJECT=0",
+		"PEBBLE_AUTHZREUSE=0",
+	)
+	p.cmd.Stdout = p.output
+	p.cmd.Stderr = p.output
+
+	err = p.cmd.Start()
+	if err != nil {
+		p.Stop()
+		return nil, fmt.Errorf("could not start pebble: %w", err)
+	}
+
+	p.exited = make(chan struct{})
+
+	go func() {
+		_ = p.cmd.Wait()
+		close(p.exited)
+	}()
+
+	err = p.waitReady(timeout)
+	if err != nil {
+		p.Stop()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Stop kills the server and removes its configuration.
+func (p *pebble) Stop() {
+	if p.exited != nil {
+		_ = p.cmd.Process.Kill()
+		<-p.exited
+	}
+
+	_ = os.RemoveAll(p.dir)
+}
+
+func (p *pebble) writeConfig() (string, error) {
+	listenAddress, err := freeLocalAddress()
+	if err != nil {
+		return "", err
+	}
+
+	managementAddress, err := freeLocalAddress()
+	if err != nil {
+		return "", err
+	}
+
+	certPEM, keyPEM, err := generateLocalhostCertificate()
+	if err != nil {
+		return "", err
+	}
+
+	p.RootCAs = x509.NewCertPool()
+	p.RootCAs.AppendCertsFromPEM(certPEM)
+	p.DirectoryURL = "https://" + listenAddress + "/dir"
+
+	certPath := filepath.Join(p.dir, "cert.pem")
+	keyPath := filepath.Join(p.dir, "key.pem")
+
+	err = os.WriteFile(certPath, certPEM, 0o600)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.WriteFile(keyPath, keyPEM, 0o600)
+	if err != nil {
+		return "", err
+	}
+
+	config := map[string]any{
+		"pebble": map[string]any{
+			"listenAddress":           listenAddress,
+			"managementListenAddress": managementAddress,
+			"certificate":             certPath,
+			"privateKey":              keyPath,
+			"httpPort":                5002,
+			"tlsPort":                 5001,
+			"ocspResponderURL":        "",
+		},
+	}
+
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+
+	configPath := filepath.Join(p.dir, "pebble-config.json")
+
+	return configPath, os.WriteFile(configPath, raw, 0o600)
+}
+
+func (p *pebble) waitReady(timeout time.Duration) error {
+	client := &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: p.RootCAs, MinVersion: tls.VersionTLS12}},
+	}
+
+	timeUp := time.After(timeout)
+
+	for {
+		resp, err := client.Get(p.DirectoryURL)
+		if err == nil {
+			_ = resp.Body.Close()
+
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+
+			err = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+
+		select {
+		case <-p.exited:
+			return fmt.Errorf("pebble has stopped: %s", strings.TrimSpace(p.output.String()))
+		case <-timeUp:
+			return fmt.Errorf("pebble is not ready after %s: %w", timeout, err)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// freeLocalAddress returns a local address with a free port.
+func freeLocalAddress() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+
+	defer func() { _ = listener.Close() }()
+
+	return listener.Addr().String(), nil
+}
+
+// generateLocalhostCertificate generates a self-signed certificate for the HTTPS listener of Pebble.
+func generateLocalhostCertificate() ([]byte, []byte, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: "lego selftest"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, privateKey.Public(), privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	return certPEM, keyPEM, nil
+}
@@ -3,55 +3,127 @@ package cmd
 import (
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/go-acme/lego/v4/acme/api"
 	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
 	"github.com/go-acme/lego/v4/lego"
 	"github.com/go-acme/lego/v4/log"
 	"github.com/go-acme/lego/v4/registration"
+	"github.com/go-acme/lego/v4/storage"
+	"github.com/go-acme/lego/v4/storage/kubernetes"
+	"github.com/go-acme/lego/v4/storage/s3"
+	"github.com/go-acme/lego/v4/storage/vault"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/urfave/cli/v2"
 )
 
-const filePerm os.FileMode = 0o600
-
 // setupClient creates a new client with challenge settings.
 func setupClient(ctx *cli.Context, account *Account, keyType certcrypto.KeyType) *lego.Client {
-	client := newClient(ctx, account, keyType)
+	client, err := createAccountClient(ctx, account, keyType)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	setupChallenges(ctx, client)
 
 	return client
 }
 
-func setupAccount(ctx *cli.Context, accountsStorage *AccountsStorage) (*Account, certcrypto.KeyType) {
-	keyType := getKeyType(ctx)
-	privateKey := accountsStorage.GetPrivateKey(keyType)
+// setupCertificateClient creates the client of a certificate:
+// the errors of the client and of the challenges are returned, they must not stop the other certificates.
+func setupCertificateClient(ctx *cli.Context, account *Account, keyType certcrypto.KeyType) (*lego.Client, error) {
+	client, err := createAccountClient(ctx, account, keyType)
+	if err != nil {
+		return nil, err
+	}
+
+	err = configureChallenges(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// setupAccount loads the account, or creates a new account (not registered) with a new key.
+// The errors are returned: the account of a certificate must not stop the other certificates.
+func setupAccount(ctx *cli.Context, accountsStorage *AccountsStorage) (*Account, certcrypto.KeyType, error) {
+	keyType, err := parseKeyType(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	privateKey, err := accountsStorage.GetPrivateKey(keyType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	exists, err := accountsStorage.ExistsAccountFilePath()
+	if err != nil {
+		return nil, "", &storageError{err: err}
+	}
+
+	if !exists {
+		return &Account{Email: accountsStorage.GetUserID(), key: privateKey}, keyType, nil
+	}
+
+	account, err := accountsStorage.LoadAccount(privateKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return account, keyType, nil
+}
+
+// createAccountClient creates a client for the server defined by the options,
+// and checks that the External Account Binding is defined if the server requires it.
+func createAccountClient(ctx *cli.Context, acc registration.User, keyType certcrypto.KeyType) (*lego.Client, error) {
+	client, err := createClient(ctx, acc, keyType, ctx.String(flgServer))
+	if err != nil {
+		return nil, fmt.Errorf("could not create client: %w", err)
+	}
+
+	if client.GetExternalAccountRequired() && !ctx.IsSet(flgEAB) {
+		return nil, fmt.Errorf("server requires External Account Binding. Use --%s with --%s and --%s", flgEAB, flgKID, flgHMAC)
+	}
+
+	return client, nil
+}
 
-	var account *Account
-	if accountsStorage.ExistsAccountFilePath() {
-		account = accountsStorage.LoadAccount(privateKey)
-	} else {
-		account = &Account{Email: accountsStorage.GetUserID(), key: privateKey}
+func createClient(ctx *cli.Context, acc registration.User, keyType certcrypto.KeyType, server string) (*lego.Client, error) {
+	config, err := newClientConfig(ctx, acc, keyType, server)
+	if err != nil {
+		return nil, err
 	}
 
-	return account, keyType
+	return newLegoClient(config)
 }
 
-func newClient(ctx *cli.Context, acc registration.User, keyType certcrypto.KeyType) *lego.Client {
+// newClientConfig creates the configuration of a client from the options.
+func newClientConfig(ctx *cli.Context, acc registration.User, keyType certcrypto.KeyType, server string) (*lego.Config, error) {
 	config := lego.NewConfig(acc)
-	config.CADirURL = ctx.String(flgServer)
+	config.CADirURL = server
 
 	config.Certificate = lego.CertificateConfig{
 		KeyType:             keyType,
 		Timeout:             time.Duration(ctx.Int(flgCertTimeout)) * time.Second,
 		OverallRequestLimit: ctx.Int(flgOverallRequestLimit),
+		CAAPreCheck:         ctx.Bool(flgCAAPreCheck),
 	}
 	config.UserAgent = getUserAgent(ctx)
+	recorder := getThrottlingRecorder(ctx)
+	config.Throttling.Notify = func(event api.ThrottleEvent) {
+		log.Warnf("The ACME server is throttling the requests (%d %s): retry after %s", event.StatusCode, event.URL, event.RetryAfter)
+		recorder.observe(event)
+	}
+	config.Throttling.Context = ctx.Context
 
 	if ctx.IsSet(flgHTTPTimeout) {
 		config.HTTPClient.Timeout = time.Duration(ctx.Int(flgHTTPTimeout)) * time.Second
@@ -66,44 +138,157 @@ func newClient(ctx *cli.Context, acc registration.User, keyType certcrypto.KeyTy
 		}
 	}
 
+	if ctx.IsSet(flgProxy) || ctx.IsSet(flgCACert) {
+		defaultTransport, ok := config.HTTPClient.Transport.(*http.Transport)
+		if ok {
+			tr := defaultTransport.Clone()
+
+			err := configureTransport(ctx, tr)
+			if err != nil {
+				return nil, err
+			}
+
+			config.HTTPClient.Transport = tr
+		}
+	}
+
+	if isMetricsEnabled(ctx) {
+		config.HTTPClient.Transport = instrumentTransport(config.HTTPClient.Transport)
+	}
+
+	return config, nil
+}
+
+// retryLogger writes the logs of the retries of the requests with the current logger:
+// the logger can be replaced after the creation of the client.
+type retryLogger struct{}
+
+func (retryLogger) Printf(format string, args ...any) {
+	log.Printf(format, args...)
+}
+
+// newLegoClient creates a client, the requests to the CA are retried on the transient failures.
+func newLegoClient(config *lego.Config) (*lego.Client, error) {
 	retryClient := retryablehttp.NewClient()
 	retryClient.RetryMax = 5
 	retryClient.HTTPClient = config.HTTPClient
+	retryClient.Logger = retryLogger{}
 
 	config.HTTPClient = retryClient.StandardClient()
 
-	client, err := lego.NewClient(config)
+	return lego.NewClient(config)
+}
+
+// setupFailover creates the Failover used to obtain the certificates:
+// the client of the CA (--server), then the clients of the fallback CAs (--fallback-server).
+func setupFailover(ctx *cli.Context, client *lego.Client, keyType certcrypto.KeyType) *lego.Failover {
+	failover, err := lego.NewFailover(client)
 	if err != nil {
-		log.Fatalf("Could not create client: %v", err)
+		log.Fatal(err)
 	}
 
-	if client.GetExternalAccountRequired() && !ctx.IsSet(flgEAB) {
-		log.Fatalf("Server requires External Account Binding. Use --%s with --%s and --%s.", flgEAB, flgKID, flgHMAC)
+	// The accounts of the fallback CAs are registered only when the fallback CAs are used.
+	for _, server := range ctx.StringSlice(flgFallbackServer) {
+		failover.AddFallback(server, func() (*lego.Client, error) {
+			return setupFallbackClient(ctx, server, keyType)
+		})
 	}
 
-	return client
+	return failover
+}
+
+// setupFallbackClient creates the client of a fallback CA,
+// the account of the fallback CA is registered if needed.
+func setupFallbackClient(ctx *cli.Context, server string, keyType certcrypto.KeyType) (*lego.Client, error) {
+	accountsStorage, err := createAccountsStorage(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+
+	account, _, err := setupAccount(ctx, accountsStorage)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := createClient(ctx, account, keyType, server)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Registration == nil {
+		reg, err := registerFallback(ctx, client)
+		if err != nil {
+			return nil, fmt.Errorf("could not complete registration: %w", err)
+		}
+
+		account.Registration = reg
+		if err = accountsStorage.Save(account); err != nil {
+			return nil, err
+		}
+	}
+
+	setupChallenges(ctx, client)
+
+	return client, nil
+}
+
+func registerFallback(ctx *cli.Context, client *lego.Client) (*registration.Resource, error) {
+	accepted, err := handleTOS(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if !accepted {
+		return nil, errors.New("the TOS are not accepted")
+	}
+
+	if !client.GetExternalAccountRequired() {
+		return client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: accepted})
+	}
+
+	kid := ctx.String(flgFallbackKID)
+	hmacEncoded := ctx.String(flgFallbackHMAC)
+
+	if kid == "" || hmacEncoded == "" {
+		return nil, fmt.Errorf("the server requires External Account Binding: use --%s and --%s", flgFallbackKID, flgFallbackHMAC)
+	}
+
+	return client.Registration.RegisterWithExternalAccountBinding(registration.RegisterEABOptions{
+		TermsOfServiceAgreed: accepted,
+		Kid:                  kid,
+		HmacEncoded:          hmacEncoded,
+	})
 }
 
 // getKeyType the type from which private keys should be generated.
 func getKeyType(ctx *cli.Context) certcrypto.KeyType {
+	keyType, err := parseKeyType(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return keyType
+}
+
+// parseKeyType returns the type from which private keys should be generated, or an error if the type is not supported.
+func parseKeyType(ctx *cli.Context) (certcrypto.KeyType, error) {
 	keyType := ctx.String(flgKeyType)
 	switch strings.ToUpper(keyType) {
 	case "RSA2048":
-		return certcrypto.RSA2048
+		return certcrypto.RSA2048, nil
 	case "RSA3072":
-		return certcrypto.RSA3072
+		return certcrypto.RSA3072, nil
 	case "RSA4096":
-		return certcrypto.RSA4096
+		return certcrypto.RSA4096, nil
 	case "RSA8192":
-		return certcrypto.RSA8192
+		return certcrypto.RSA8192, nil
 	case "EC256":
-		return certcrypto.EC256
+		return certcrypto.EC256, nil
 	case "EC384":
-		return certcrypto.EC384
+		return certcrypto.EC384, nil
 	}
 
-	log.Fatalf("Unsupported KeyType: %s", keyType)
-	return ""
+	return "", fmt.Errorf("unsupported KeyType: %s", keyType)
 }
 
 func getEmail(ctx *cli.Context) string {
@@ -118,6 +303,80 @@ func getUserAgent(ctx *cli.Context) string {
 	return strings.TrimSpace(fmt.Sprintf("%s lego-cli/%s", ctx.String(flgUserAgent), ctx.App.Version))
 }
 
+// Storage types.
+const (
+	storageFile  = "file"
+	storageVault = "vault"
+	storageS3    = "s3"
+	storageK8s   = "kubernetes"
+)
+
+func setupStorage(ctx *cli.Context) storage.CertificatesStorage {
+	switch ctx.String(flgStorage) {
+	case storageFile:
+		return storage.NewFileStorage(ctx.String(flgPath))
+
+	case storageVault:
+		config := vault.NewDefaultConfig()
+		config.Address = ctx.String(flgStorageVaultAddress)
+		config.Token = ctx.String(flgStorageVaultToken)
+		config.Namespace = ctx.String(flgStorageVaultNamespace)
+		config.MountPath = ctx.String(flgStorageVaultMount)
+		config.BasePath = ctx.String(flgStorageVaultPath)
+
+		backend, err := vault.NewStorage(config)
+		if err != nil {
+			log.Fatalf("Could not create the storage: %v", err)
+		}
+
+		return backend
+
+	case storageS3:
+		config := s3.NewDefaultConfig()
+		config.Bucket = ctx.String(flgStorageS3Bucket)
+		config.Prefix = ctx.String(flgStorageS3Prefix)
+		config.Region = ctx.String(flgStorageS3Region)
+		config.Endpoint = ctx.String(flgStorageS3Endpoint)
+		config.ServerSideEncryption = ctx.String(flgStorageS3SSE)
+		config.KMSKeyID = ctx.String(flgStorageS3KMSKeyID)
+		config.RequireVersioning = ctx.Bool(flgStorageS3Versioning)
+
+		backend, err := s3.NewStorage(config)
+		if err != nil {
+			log.Fatalf("Could not create the storage: %v", err)
+		}
+
+		return backend
+
+	case storageK8s:
+		backend, err := kubernetes.NewStorage(&kubernetes.Config{
+			Kubeconfig:   ctx.String(flgStorageK8sKubeconfig),
+			Namespace:    ctx.String(flgStorageK8sNamespace),
+			SecretPrefix: ctx.String(flgStorageK8sSecretPrefix),
+		})
+		if err != nil {
+			log.Fatalf("Could not create the storage: %v", err)
+		}
+
+		return backend
+
+	default:
+		log.Fatalf("Invalid storage: %s", ctx.String(flgStorage))
+		return nil
+	}
+}
+
+// setupOCSPChecker creates the OCSP checker used to write the OCSP staple files.
+func setupOCSPChecker(ctx *cli.Context) *certificate.OCSPChecker {
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+
+	if ctx.IsSet(flgHTTPTimeout) {
+		httpClient.Timeout = time.Duration(ctx.Int(flgHTTPTimeout)) * time.Second
+	}
+
+	return certificate.NewOCSPChecker(httpClient)
+}
+
 func createNonExistingFolder(path string) error {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return os.MkdirAll(path, 0o700)
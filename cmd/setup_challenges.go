@@ -1,119 +1,372 @@
 package cmd
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
+	"net/url"
 	"strings"
 	"time"
 
+	"github.com/go-acme/lego/v4/certificate"
 	"github.com/go-acme/lego/v4/challenge"
 	"github.com/go-acme/lego/v4/challenge/dns01"
 	"github.com/go-acme/lego/v4/challenge/http01"
 	"github.com/go-acme/lego/v4/challenge/tlsalpn01"
 	"github.com/go-acme/lego/v4/lego"
 	"github.com/go-acme/lego/v4/log"
+	"github.com/go-acme/lego/v4/platform/config/env"
+	"github.com/go-acme/lego/v4/platform/systemd"
 	"github.com/go-acme/lego/v4/providers/dns"
+	"github.com/go-acme/lego/v4/providers/http/consul"
+	"github.com/go-acme/lego/v4/providers/http/etcd"
 	"github.com/go-acme/lego/v4/providers/http/memcached"
+	"github.com/go-acme/lego/v4/providers/http/redis"
 	"github.com/go-acme/lego/v4/providers/http/s3"
 	"github.com/go-acme/lego/v4/providers/http/webroot"
+	tlsredis "github.com/go-acme/lego/v4/providers/tls/redis"
+	"github.com/go-acme/lego/v4/providers/tls/snirouter"
 	"github.com/urfave/cli/v2"
 )
 
 func setupChallenges(ctx *cli.Context, client *lego.Client) {
-	if !ctx.Bool(flgHTTP) && !ctx.Bool(flgTLS) && !ctx.IsSet(flgDNS) {
-		log.Fatalf("No challenge selected. You must specify at least one challenge: `--%s`, `--%s`, `--%s`.", flgHTTP, flgTLS, flgDNS)
+	err := configureChallenges(ctx, client)
+	if err != nil {
+		log.Fatal(err)
 	}
+}
 
-	if ctx.Bool(flgHTTP) {
-		err := client.Challenge.SetHTTP01Provider(setupHTTPProvider(ctx))
-		if err != nil {
-			log.Fatal(err)
-		}
+// configureChallenges defines the challenges of the client from the options.
+func configureChallenges(ctx *cli.Context, client *lego.Client) error {
+	if !ctx.Bool(flgHTTP) && !ctx.Bool(flgTLS) && !isDNSChallenge(ctx) {
+		return fmt.Errorf("no challenge selected: you must specify at least one challenge: `--%s`, `--%s`, `--%s`", flgHTTP, flgTLS, flgDNS)
 	}
 
-	if ctx.Bool(flgTLS) {
-		err := client.Challenge.SetTLSALPN01Provider(setupTLSProvider(ctx))
-		if err != nil {
-			log.Fatal(err)
+	if !ctx.Bool(flgHTTP) && !ctx.Bool(flgTLS) {
+		// RFC 8738: the IP address identifiers cannot be validated with DNS based challenges.
+		for _, domain := range ctx.StringSlice(flgDomains) {
+			if net.ParseIP(domain) != nil {
+				return fmt.Errorf("the IP address %s can only be validated with the HTTP-01 or TLS-ALPN-01 challenges: use `--%s` or `--%s`", domain, flgHTTP, flgTLS)
+			}
 		}
 	}
 
-	if ctx.IsSet(flgDNS) {
-		err := setupDNS(ctx, client)
+	if ctx.Bool(flgHTTP) {
+		provider, err := setupHTTPProvider(ctx)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
-	}
-}
 
-//nolint:gocyclo // the complexity is expected.
-func setupHTTPProvider(ctx *cli.Context) challenge.Provider {
-	switch {
-	case ctx.IsSet(flgHTTPWebroot):
-		ps, err := webroot.NewHTTPProvider(ctx.String(flgHTTPWebroot))
+		err = client.Challenge.SetHTTP01Provider(instrumentProvider(ctx, provider, challenge.HTTP01, ""))
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
-		return ps
-	case ctx.IsSet(flgHTTPMemcachedHost):
-		ps, err := memcached.NewMemcachedProvider(ctx.StringSlice(flgHTTPMemcachedHost))
+	}
+
+	if ctx.Bool(flgTLS) {
+		provider, err := setupTLSProvider(ctx)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
-		return ps
-	case ctx.IsSet(flgHTTPS3Bucket):
-		ps, err := s3.NewHTTPProvider(ctx.String(flgHTTPS3Bucket))
+
+		err = client.Challenge.SetTLSALPN01Provider(instrumentProvider(ctx, provider, challenge.TLSALPN01, ""))
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
-		return ps
-	case ctx.IsSet(flgHTTPPort):
-		iface := ctx.String(flgHTTPPort)
-		if !strings.Contains(iface, ":") {
-			log.Fatalf("The --%s switch only accepts interface:port or :port for its argument.", flgHTTPPort)
+	}
+
+	if isDNSChallenge(ctx) {
+		return setupDNS(ctx, client)
+	}
+
+	return nil
+}
+
+// providerOption a challenge provider selected by an option.
+type providerOption struct {
+	// flag the option selecting the provider.
+	flag string
+	// name the name of the provider (ex: in the manifests of the certificates).
+	name string
+
+	create func(ctx *cli.Context) (challenge.Provider, error)
+}
+
+// selectProvider returns the first provider selected by an option, or the default provider.
+func selectProvider(ctx *cli.Context, options []providerOption, defaultOption providerOption) providerOption {
+	for _, option := range options {
+		if ctx.IsSet(option.flag) {
+			return option
 		}
+	}
+
+	return defaultOption
+}
+
+// httpProviders the HTTP-01 providers, by order of precedence.
+var httpProviders = []providerOption{
+	{flag: flgHTTPWebroot, name: "webroot", create: createWebrootProvider},
+	{flag: flgHTTPMemcachedHost, name: "memcached", create: createMemcachedProvider},
+	{flag: flgHTTPRedisHost, name: "redis", create: createRedisProvider},
+	{flag: flgHTTPEtcdEndpoints, name: "etcd", create: createEtcdProvider},
+	{flag: flgHTTPConsulAddress, name: "consul", create: createConsulProvider},
+	{flag: flgHTTPS3Bucket, name: "s3", create: createS3Provider},
+	{flag: flgHTTPSystemdSocket, name: "server", create: createHTTPSystemdServer},
+	{flag: flgHTTPPort, name: "server", create: createHTTPServer},
+}
+
+// httpServerProvider the built-in HTTP server, the default HTTP-01 provider.
+var httpServerProvider = providerOption{name: "server", create: createHTTPServer}
+
+func setupHTTPProvider(ctx *cli.Context) (challenge.Provider, error) {
+	return selectProvider(ctx, httpProviders, httpServerProvider).create(ctx)
+}
+
+// httpProviderName returns the name of the HTTP-01 provider defined by the options.
+func httpProviderName(ctx *cli.Context) string {
+	return selectProvider(ctx, httpProviders, httpServerProvider).name
+}
+
+func createWebrootProvider(ctx *cli.Context) (challenge.Provider, error) {
+	config := webroot.NewDefaultConfig()
+	config.Paths = ctx.StringSlice(flgHTTPWebroot)
+	config.SelfCheck = ctx.Bool(flgHTTPWebrootSelfCheck)
+
+	return webroot.NewHTTPProviderConfig(config)
+}
+
+func createMemcachedProvider(ctx *cli.Context) (challenge.Provider, error) {
+	return memcached.NewMemcachedProvider(ctx.StringSlice(flgHTTPMemcachedHost))
+}
+
+func createRedisProvider(ctx *cli.Context) (challenge.Provider, error) {
+	return redis.NewHTTPProviderConfig(createRedisConfig(ctx))
+}
+
+func createEtcdProvider(ctx *cli.Context) (challenge.Provider, error) {
+	config := etcd.NewDefaultConfig()
+	config.Endpoints = ctx.StringSlice(flgHTTPEtcdEndpoints)
+	config.Prefix = ctx.String(flgHTTPEtcdPrefix)
+	config.Username = ctx.String(flgHTTPEtcdUsername)
+	config.Password = ctx.String(flgHTTPEtcdPassword)
+
+	return etcd.NewHTTPProviderConfig(config)
+}
+
+func createConsulProvider(ctx *cli.Context) (challenge.Provider, error) {
+	config := consul.NewDefaultConfig()
+	config.Address = ctx.String(flgHTTPConsulAddress)
+	config.Prefix = ctx.String(flgHTTPConsulPrefix)
+	config.Token = ctx.String(flgHTTPConsulToken)
+
+	return consul.NewHTTPProviderConfig(config)
+}
+
+func createS3Provider(ctx *cli.Context) (challenge.Provider, error) {
+	return s3.NewHTTPProvider(ctx.String(flgHTTPS3Bucket))
+}
+
+func createHTTPSystemdServer(ctx *cli.Context) (challenge.Provider, error) {
+	listener, err := systemd.ListenerByName(ctx.String(flgHTTPSystemdSocket))
+	if err != nil {
+		return nil, err
+	}
+
+	return configureHTTPServer(ctx, http01.NewProviderServerWithListener(listener)), nil
+}
+
+func createHTTPServer(ctx *cli.Context) (challenge.Provider, error) {
+	if !ctx.IsSet(flgHTTPPort) {
+		return configureHTTPServer(ctx, http01.NewProviderServer("", "")), nil
+	}
+
+	iface := ctx.String(flgHTTPPort)
+	if !strings.Contains(iface, ":") {
+		return nil, fmt.Errorf("the --%s switch only accepts interface:port or :port for its argument", flgHTTPPort)
+	}
 
-		host, port, err := net.SplitHostPort(iface)
+	host, port, err := net.SplitHostPort(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := configureHTTPServer(ctx, http01.NewProviderServer(host, port))
+
+	if ctx.IsSet(flgHTTPPortRedirect) {
+		redirector, err := createPortRedirector(ctx.String(flgHTTPPortRedirect))
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 
-		srv := http01.NewProviderServer(host, port)
-		if header := ctx.String(flgHTTPProxyHeader); header != "" {
-			srv.SetProxyHeader(header)
-		}
-		return srv
-	case ctx.Bool(flgHTTP):
-		srv := http01.NewProviderServer("", "")
-		if header := ctx.String(flgHTTPProxyHeader); header != "" {
-			srv.SetProxyHeader(header)
-		}
-		return srv
+		srv.SetPortRedirect(redirector, 80)
+	}
+
+	return srv, nil
+}
+
+func configureHTTPServer(ctx *cli.Context, srv *http01.ProviderServer) *http01.ProviderServer {
+	if header := ctx.String(flgHTTPProxyHeader); header != "" {
+		srv.SetProxyHeader(header)
+	}
+
+	srv.SetStrictValidation(ctx.Bool(flgHTTPStrict))
+	srv.SetRateLimit(ctx.Float64(flgHTTPRateLimit), ctx.Int(flgHTTPRateBurst))
+
+	if ctx.Bool(flgHTTPAuditLog) {
+		srv.SetAuditLogger(func(attempt http01.ValidationAttempt) {
+			log.WithDomain(attempt.Domain).Infof("http-01 validation attempt: remote=%s method=%s host=%q path=%q user-agent=%q matched=%t status=%d",
+				attempt.RemoteAddr, attempt.Method, attempt.Host, attempt.Path, attempt.UserAgent, attempt.Matched, attempt.StatusCode)
+		})
+	}
+
+	return srv
+}
+
+func createPortRedirector(name string) (http01.PortRedirector, error) {
+	switch strings.ToLower(name) {
+	case "iptables":
+		return http01.NewIPTablesRedirector(false), nil
+	case "ip6tables":
+		return http01.NewIPTablesRedirector(true), nil
+	case "nftables":
+		return http01.NewNFTablesRedirector(), nil
 	default:
-		log.Fatal("Invalid HTTP challenge options.")
-		return nil
+		return nil, fmt.Errorf("invalid port redirect: %s. Supported: iptables, ip6tables, nftables", name)
 	}
 }
 
-func setupTLSProvider(ctx *cli.Context) challenge.Provider {
-	switch {
-	case ctx.IsSet(flgTLSPort):
-		iface := ctx.String(flgTLSPort)
-		if !strings.Contains(iface, ":") {
-			log.Fatalf("The --%s switch only accepts interface:port or :port for its argument.", flgTLSPort)
+func createRedisConfig(ctx *cli.Context) *redis.Config {
+	config := redis.NewDefaultConfig()
+	config.Addrs = ctx.StringSlice(flgHTTPRedisHost)
+	config.MasterName = ctx.String(flgHTTPRedisMasterName)
+	config.Username = ctx.String(flgHTTPRedisUsername)
+	config.Password = ctx.String(flgHTTPRedisPassword)
+	config.DB = ctx.Int(flgHTTPRedisDB)
+	config.KeyPrefix = ctx.String(flgHTTPRedisKeyPrefix)
+
+	if ctx.Bool(flgHTTPRedisTLS) {
+		config.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	return config
+}
+
+// tlsProviders the TLS-ALPN-01 providers, by order of precedence.
+var tlsProviders = []providerOption{
+	{flag: flgTLSSNIRouter, name: "snirouter", create: createSNIRouterProvider},
+	{flag: flgTLSStoreDir, name: "store", create: createTLSStoreProvider},
+	{flag: flgTLSRedisHost, name: "redis", create: createTLSRedisProvider},
+	{flag: flgTLSSystemdSocket, name: "server", create: createTLSSystemdServer},
+	{flag: flgTLSPort, name: "server", create: createTLSServer},
+}
+
+// tlsServerProvider the built-in TLS server, the default TLS-ALPN-01 provider.
+var tlsServerProvider = providerOption{name: "server", create: createTLSServer}
+
+func setupTLSProvider(ctx *cli.Context) (challenge.Provider, error) {
+	return selectProvider(ctx, tlsProviders, tlsServerProvider).create(ctx)
+}
+
+// tlsProviderName returns the name of the TLS-ALPN-01 provider defined by the options.
+func tlsProviderName(ctx *cli.Context) string {
+	return selectProvider(ctx, tlsProviders, tlsServerProvider).name
+}
+
+func createSNIRouterProvider(ctx *cli.Context) (challenge.Provider, error) {
+	backend, err := createSNIRouterBackend(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return snirouter.NewTLSProvider(backend)
+}
+
+func createTLSStoreProvider(ctx *cli.Context) (challenge.Provider, error) {
+	storage, err := tlsalpn01.NewFileCertStorage(ctx.String(flgTLSStoreDir))
+	if err != nil {
+		return nil, err
+	}
+
+	return tlsalpn01.NewSharedCertStore(storage), nil
+}
+
+func createTLSRedisProvider(ctx *cli.Context) (challenge.Provider, error) {
+	config := tlsredis.NewDefaultConfig()
+	config.Addrs = ctx.StringSlice(flgTLSRedisHost)
+	config.Password = ctx.String(flgTLSRedisPassword)
+
+	if ctx.IsSet(flgTLSRedisKeyPrefix) {
+		config.KeyPrefix = ctx.String(flgTLSRedisKeyPrefix)
+	}
+
+	storage, err := tlsredis.NewStorageConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return tlsalpn01.NewSharedCertStore(storage), nil
+}
+
+func createTLSSystemdServer(ctx *cli.Context) (challenge.Provider, error) {
+	listener, err := systemd.ListenerByName(ctx.String(flgTLSSystemdSocket))
+	if err != nil {
+		return nil, err
+	}
+
+	return configureTLSServer(ctx, tlsalpn01.NewProviderServerWithListener(listener)), nil
+}
+
+func createTLSServer(ctx *cli.Context) (challenge.Provider, error) {
+	if !ctx.IsSet(flgTLSPort) {
+		return configureTLSServer(ctx, tlsalpn01.NewProviderServer("", "")), nil
+	}
+
+	iface := ctx.String(flgTLSPort)
+	if !strings.Contains(iface, ":") {
+		return nil, fmt.Errorf("the --%s switch only accepts interface:port or :port for its argument", flgTLSPort)
+	}
+
+	host, port, err := net.SplitHostPort(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	return configureTLSServer(ctx, tlsalpn01.NewProviderServer(host, port)), nil
+}
+
+func configureTLSServer(ctx *cli.Context, srv *tlsalpn01.ProviderServer) *tlsalpn01.ProviderServer {
+	srv.SetReusePort(ctx.Bool(flgTLSReusePort))
+	srv.SetDrainTimeout(ctx.Duration(flgTLSDrainTimeout))
+
+	return srv
+}
+
+func createSNIRouterBackend(ctx *cli.Context) (snirouter.Backend, error) {
+	address := ctx.String(flgTLSSNIRouterAddress)
+	if address == "" {
+		return nil, fmt.Errorf("the --%s option is required by --%s", flgTLSSNIRouterAddress, flgTLSSNIRouter)
+	}
+
+	switch strings.ToLower(ctx.String(flgTLSSNIRouter)) {
+	case "haproxy":
+		network := "unix"
+		if strings.HasPrefix(address, "tcp://") {
+			network = "tcp"
+			address = strings.TrimPrefix(address, "tcp://")
 		}
 
-		host, port, err := net.SplitHostPort(iface)
+		return snirouter.NewHAProxy(network, address, ctx.String(flgTLSHAProxyCrtList)), nil
+	case "envoy":
+		return snirouter.NewEnvoy(address), nil
+	case "webhook":
+		endpoint, err := url.Parse(address)
 		if err != nil {
-			log.Fatal(err)
+			return nil, fmt.Errorf("invalid SNI router webhook URL: %w", err)
 		}
 
-		return tlsalpn01.NewProviderServer(host, port)
-	case ctx.Bool(flgTLS):
-		return tlsalpn01.NewProviderServer("", "")
+		return snirouter.NewWebhook(endpoint), nil
 	default:
-		log.Fatal("Invalid HTTP challenge options.")
-		return nil
+		return nil, fmt.Errorf("invalid SNI router: %s. Supported: haproxy, envoy, webhook", ctx.String(flgTLSSNIRouter))
 	}
 }
 
@@ -128,14 +381,14 @@ func setupDNS(ctx *cli.Context, client *lego.Client) error {
 		return fmt.Errorf("'%s' cannot be negative", flgDNSPropagationWait)
 	}
 
-	provider, err := dns.NewDNSChallengeProviderByName(ctx.String(flgDNS))
+	provider, err := setupDNSProvider(ctx)
 	if err != nil {
 		return err
 	}
 
 	servers := ctx.StringSlice(flgDNSResolvers)
 
-	err = client.Challenge.SetDNS01Provider(provider,
+	opts := []dns01.ChallengeOption{
 		dns01.CondOption(len(servers) > 0,
 			dns01.AddRecursiveNameservers(dns01.ParseNameservers(ctx.StringSlice(flgDNSResolvers)))),
 
@@ -152,9 +405,136 @@ func setupDNS(ctx *cli.Context, client *lego.Client) error {
 
 		dns01.CondOption(ctx.IsSet(flgDNSTimeout),
 			dns01.AddDNSTimeout(time.Duration(ctx.Int(flgDNSTimeout))*time.Second)),
-	)
+	}
+
+	providerName := ctx.String(flgDNS)
+	if ctx.IsSet(flgDNSRoute) {
+		providerName = "router"
+	}
+
+	if ctx.Bool(flgDNSAccountLabel) {
+		if _, ok := provider.(challenge.AccountProvider); !ok {
+			return fmt.Errorf("%s: %w", providerName, dns01.ErrAccountChallengeNotSupported)
+		}
+
+		return client.Challenge.SetDNSAccount01Provider(instrumentProvider(ctx, provider, challenge.DNSAccount01, providerName), opts...)
+	}
+
+	return client.Challenge.SetDNS01Provider(instrumentProvider(ctx, provider, challenge.DNS01, providerName), opts...)
+}
+
+// enabledChallenges describes the enabled challenges and their providers,
+// the DNS routes are described by their domain (empty for the default DNS provider).
+func enabledChallenges(ctx *cli.Context) []certificate.ManifestChallenge {
+	var challenges []certificate.ManifestChallenge
+
+	if ctx.Bool(flgHTTP) {
+		challenges = append(challenges, certificate.ManifestChallenge{Type: challenge.HTTP01.String(), Provider: httpProviderName(ctx)})
+	}
+
+	if ctx.Bool(flgTLS) {
+		challenges = append(challenges, certificate.ManifestChallenge{Type: challenge.TLSALPN01.String(), Provider: tlsProviderName(ctx)})
+	}
+
+	if !isDNSChallenge(ctx) {
+		return challenges
+	}
+
+	chlgType := challenge.DNS01
+	if ctx.Bool(flgDNSAccountLabel) {
+		chlgType = challenge.DNSAccount01
+	}
+
+	if name := ctx.String(flgDNS); name != "" {
+		challenges = append(challenges, certificate.ManifestChallenge{Type: chlgType.String(), Provider: name})
+	}
+
+	for _, value := range ctx.StringSlice(flgDNSRoute) {
+		domain, name, _ := strings.Cut(value, "=")
+
+		challenges = append(challenges, certificate.ManifestChallenge{Type: chlgType.String(), Provider: name, Domain: domain})
+	}
+
+	return challenges
+}
+
+// solvedChallengeProvider returns the name of the provider of a solved challenge, from the enabled challenges:
+// the DNS route of the longest domain matching the validated domain, or the default provider of the challenge type.
+func solvedChallengeProvider(enabled []certificate.ManifestChallenge, solved certificate.ManifestChallenge) string {
+	var provider string
+
+	routes := make(map[string]string)
+
+	for _, chlg := range enabled {
+		if chlg.Type != solved.Type {
+			continue
+		}
+
+		if chlg.Domain == "" {
+			provider = chlg.Provider
+			continue
+		}
+
+		routes[chlg.Domain] = chlg.Provider
+	}
+
+	if _, name, ok := env.MatchDomain(routes, solved.Domain); ok {
+		return name
+	}
+
+	return provider
+}
+
+func isDNSChallenge(ctx *cli.Context) bool {
+	return ctx.IsSet(flgDNS) || ctx.IsSet(flgDNSRoute)
+}
+
+// setupDNSProvider creates the DNS provider defined by the dns option,
+// or a router of the DNS providers by domain if routes are defined by the dns.route option.
+func setupDNSProvider(ctx *cli.Context) (challenge.Provider, error) {
+	providers := make(map[string]challenge.Provider)
+
+	var defaultProvider challenge.Provider
+
+	if name := ctx.String(flgDNS); name != "" {
+		provider, err := dns.NewDNSChallengeProviderByName(name)
+		if err != nil {
+			return nil, err
+		}
+
+		providers[name] = provider
+		defaultProvider = provider
+	}
+
+	values := ctx.StringSlice(flgDNSRoute)
+	if len(values) == 0 {
+		return defaultProvider, nil
+	}
+
+	var routes []dns01.Route
+
+	for _, value := range values {
+		domain, name, ok := strings.Cut(value, "=")
+		if !ok || domain == "" || name == "" {
+			return nil, fmt.Errorf("invalid DNS route %q: the format is 'domain=provider'", value)
+		}
+
+		provider, ok := providers[name]
+		if !ok {
+			var err error
+
+			provider, err = dns.NewDNSChallengeProviderByName(name)
+			if err != nil {
+				return nil, fmt.Errorf("DNS route %q: %w", value, err)
+			}
+
+			providers[name] = provider
+		}
+
+		routes = append(routes, dns01.Route{Domain: domain, Provider: provider})
+	}
 
-	return err
+	return dns01.NewRouter(routes, defaultProvider)
 }
 
 func checkPropagationExclusiveOptions(ctx *cli.Context) error {
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func newTestContext(t *testing.T, args ...string) *cli.Context {
+	t.Helper()
+
+	app := cli.NewApp()
+	app.Flags = CreateFlags(t.TempDir())
+
+	set := flag.NewFlagSet("lego", flag.ContinueOnError)
+
+	for _, f := range app.Flags {
+		require.NoError(t, f.Apply(set))
+	}
+
+	require.NoError(t, set.Parse(args))
+
+	return cli.NewContext(app, set, nil)
+}
+
+func Test_setupDNSProvider(t *testing.T) {
+	t.Setenv("EXEC_PATH", "/bin/true")
+
+	testCases := []struct {
+		desc   string
+		args   []string
+		assert func(t *testing.T, provider any)
+	}{
+		{
+			desc: "provider",
+			args: []string{"--dns", "manual"},
+			assert: func(t *testing.T, provider any) {
+				t.Helper()
+
+				assert.IsType(t, &dns01.DNSProviderManual{}, provider)
+			},
+		},
+		{
+			desc: "routes",
+			args: []string{"--dns", "exec", "--dns.route", "example.org=manual", "--dns.route", "example.net=exec"},
+			assert: func(t *testing.T, provider any) {
+				t.Helper()
+
+				_, ok := provider.(interface{ Sequential() time.Duration })
+				assert.True(t, ok, "the manual provider is sequential")
+			},
+		},
+		{
+			desc: "routes without default provider",
+			args: []string{"--dns.route", "example.org=exec"},
+			assert: func(t *testing.T, provider any) {
+				t.Helper()
+
+				err := provider.(challenge.Provider).Present("example.com", "", "")
+				require.EqualError(t, err, "router: no DNS provider for the domain example.com")
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			provider, err := setupDNSProvider(newTestContext(t, test.args...))
+			require.NoError(t, err)
+
+			test.assert(t, provider)
+		})
+	}
+}
+
+func Test_setupDNSProvider_error(t *testing.T) {
+	_, err := setupDNSProvider(newTestContext(t, "--dns.route", "example.org"))
+	require.EqualError(t, err, `invalid DNS route "example.org": the format is 'domain=provider'`)
+}
+
+func Test_configureChallenges_error(t *testing.T) {
+	_, apiURL := tester.SetupFakeAPI(t)
+
+	testCases := []struct {
+		desc     string
+		args     []string
+		expected string
+	}{
+		{
+			desc:     "no challenge",
+			args:     []string{"-d", "example.com"},
+			expected: "no challenge selected: you must specify at least one challenge: `--http`, `--tls`, `--dns`",
+		},
+		{
+			desc:     "IP address with DNS-01",
+			args:     []string{"-d", "192.0.2.1", "--dns", "manual"},
+			expected: "the IP address 192.0.2.1 can only be validated with the HTTP-01 or TLS-ALPN-01 challenges: use `--http` or `--tls`",
+		},
+		{
+			desc:     "unknown DNS provider",
+			args:     []string{"-d", "example.com", "--dns", "unknown"},
+			expected: "unrecognized DNS provider: unknown",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := newTestContext(t, test.args...)
+
+			privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.EC256)
+			require.NoError(t, err)
+
+			client, err := createClient(ctx, &Account{key: privateKey}, certcrypto.EC256, apiURL+"/dir")
+			require.NoError(t, err)
+
+			err = configureChallenges(ctx, client)
+			require.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func Test_enabledChallenges(t *testing.T) {
+	ctx := newTestContext(t, "--http", "--http.webroot", "/var/www", "--dns", "gandi", "--dns.route", "example.com=cloudflare")
+
+	expected := []certificate.ManifestChallenge{
+		{Type: "http-01", Provider: "webroot"},
+		{Type: "dns-01", Provider: "gandi"},
+		{Type: "dns-01", Provider: "cloudflare", Domain: "example.com"},
+	}
+
+	assert.Equal(t, expected, enabledChallenges(ctx))
+}
+
+func Test_solvedChallengeProvider(t *testing.T) {
+	enabled := []certificate.ManifestChallenge{
+		{Type: "http-01", Provider: "webroot"},
+		{Type: "dns-01", Provider: "gandi"},
+		{Type: "dns-01", Provider: "cloudflare", Domain: "example.com"},
+		{Type: "dns-01", Provider: "route53", Domain: "*.aws.example.com"},
+	}
+
+	testCases := []struct {
+		desc     string
+		solved   certificate.ManifestChallenge
+		expected string
+	}{
+		{
+			desc:     "http-01",
+			solved:   certificate.ManifestChallenge{Type: "http-01", Domain: "example.org"},
+			expected: "webroot",
+		},
+		{
+			desc:     "default DNS provider",
+			solved:   certificate.ManifestChallenge{Type: "dns-01", Domain: "example.org"},
+			expected: "gandi",
+		},
+		{
+			desc:     "route",
+			solved:   certificate.ManifestChallenge{Type: "dns-01", Domain: "*.www.example.com"},
+			expected: "cloudflare",
+		},
+		{
+			desc:     "longest route",
+			solved:   certificate.ManifestChallenge{Type: "dns-01", Domain: "api.aws.example.com"},
+			expected: "route53",
+		},
+		{
+			desc:   "challenge not enabled",
+			solved: certificate.ManifestChallenge{Type: "tls-alpn-01", Domain: "example.com"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			assert.Equal(t, test.expected, solvedChallengeProvider(enabled, test.solved))
+		})
+	}
+}
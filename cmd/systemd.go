@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-acme/lego/v4/log"
+	"github.com/go-acme/lego/v4/platform/systemd"
+)
+
+// notifySystemd sends a notification to systemd (services with Type=notify), a failure is only logged.
+func notifySystemd(state string) {
+	if err := systemd.Notify(state); err != nil {
+		log.Warnf("daemon: %v", err)
+	}
+}
+
+// runSystemdWatchdog sends the keep-alive notifications until the context is done (services with WatchdogSec=).
+func runSystemdWatchdog(ctx context.Context) {
+	interval, err := systemd.WatchdogInterval()
+	if err != nil {
+		log.Warnf("daemon: %v", err)
+		return
+	}
+
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			notifySystemd(systemd.Watchdog)
+		}
+	}
+}
@@ -66,6 +66,21 @@ func TestChallengeHTTP_Run(t *testing.T) {
 	}
 }
 
+func TestSelftest(t *testing.T) {
+	loader.CleanLegoFiles()
+
+	output, err := load.RunLego(
+		"--http",
+		"selftest")
+
+	if len(output) > 0 {
+		fmt.Fprintf(os.Stdout, "%s\n", output)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestChallengeTLS_Run_Domains(t *testing.T) {
 	loader.CleanLegoFiles()
 
@@ -56,6 +56,9 @@ func generate() error {
 		{"lego", "help", "renew"},
 		{"lego", "help", "revoke"},
 		{"lego", "help", "list"},
+		{"lego", "account", "help", "update"},
+		{"lego", "account", "help", "keychange"},
+		{"lego", "providers", "help", "test"},
 		{"lego", "dnshelp"},
 	} {
 		content, err := run(app, args)
@@ -13,6 +13,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"strings"
 	"text/template"
@@ -37,6 +38,9 @@ const (
 	readmeTemplate = "templates/readme.md.tmpl"
 )
 
+// defaultPattern the default value in the description of an environment variable, ex: "API request timeout in seconds (Default: 10)".
+var defaultPattern = regexp.MustCompile(`\s*\(Default:\s*(.*?)\)\s*$`)
+
 const (
 	startLine = "<!-- START DNS PROVIDERS LIST -->"
 	endLine   = "<!-- END DNS PROVIDERS LIST -->"
@@ -100,6 +104,17 @@ func generateCLIHelp(models *descriptors.Providers) error {
 			"safe": func(src string) string {
 				return strings.ReplaceAll(src, "`", "'")
 			},
+			"trim": strings.TrimSpace,
+			"envDescription": func(src string) string {
+				return defaultPattern.ReplaceAllString(src, "")
+			},
+			"envDefault": func(src string) string {
+				if m := defaultPattern.FindStringSubmatch(src); m != nil {
+					return m[1]
+				}
+
+				return ""
+			},
 		}).ParseFS(templateFS, cliTemplate),
 	).Execute(b, models)
 	if err != nil {
@@ -0,0 +1,51 @@
+// Package sharedlistener implements a listener used by a server without being owned by it.
+package sharedlistener
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+type deadliner interface {
+	SetDeadline(t time.Time) error
+}
+
+// Listener wraps a listener owned by someone else (ex: a socket passed by systemd):
+// closing it interrupts the pending Accept calls, but the underlying listener stays open, so it can be used again.
+type Listener struct {
+	net.Listener
+	closed atomic.Bool
+}
+
+// New wraps the listener, the listener must support deadlines (ex: *net.TCPListener, *net.UnixListener).
+func New(l net.Listener) *Listener {
+	if d, ok := l.(deadliner); ok {
+		// Clears the deadline set by the Close of a previous wrapper.
+		_ = d.SetDeadline(time.Time{})
+	}
+
+	return &Listener{Listener: l}
+}
+
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil && l.closed.Load() {
+		return nil, net.ErrClosed
+	}
+
+	return conn, err
+}
+
+// Close interrupts the pending Accept calls, without closing the underlying listener.
+func (l *Listener) Close() error {
+	if l.closed.Swap(true) {
+		return nil
+	}
+
+	if d, ok := l.Listener.(deadliner); ok {
+		return d.SetDeadline(time.Now())
+	}
+
+	return nil
+}
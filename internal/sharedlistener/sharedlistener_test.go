@@ -0,0 +1,49 @@
+package sharedlistener
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListener(t *testing.T) {
+	underlying, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = underlying.Close() })
+
+	for range 2 {
+		l := New(underlying)
+
+		accepted := make(chan error, 1)
+
+		go func() {
+			conn, errA := l.Accept()
+			if errA == nil {
+				_ = conn.Close()
+			}
+
+			accepted <- errA
+		}()
+
+		conn, err := net.Dial("tcp", underlying.Addr().String())
+		require.NoError(t, err)
+
+		_ = conn.Close()
+
+		require.NoError(t, <-accepted)
+
+		go func() {
+			_, errA := l.Accept()
+			accepted <- errA
+		}()
+
+		require.NoError(t, l.Close())
+
+		err = <-accepted
+		assert.True(t, errors.Is(err, net.ErrClosed), "unexpected error: %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/url"
 
+	"github.com/go-acme/lego/v4/acme"
 	"github.com/go-acme/lego/v4/acme/api"
 	"github.com/go-acme/lego/v4/certificate"
 	"github.com/go-acme/lego/v4/challenge/resolver"
@@ -45,15 +46,39 @@ func NewClient(config *Config) (*Client, error) {
 		kid = reg.URI
 	}
 
-	core, err := api.New(config.HTTPClient, config.UserAgent, config.CADirURL, kid, privateKey)
+	httpClient := api.WithMiddlewares(config.HTTPClient, config.Middlewares...)
+
+	core, err := api.New(httpClient, config.UserAgent, config.CADirURL, kid, privateKey)
 	if err != nil {
 		return nil, err
 	}
 
+	if config.Throttling.MaxRetryAfter > 0 {
+		core.SetMaxRetryAfter(config.Throttling.MaxRetryAfter)
+	}
+
+	if config.Throttling.Notify != nil {
+		core.SetThrottleHook(config.Throttling.Notify)
+	}
+
+	if config.Throttling.Context != nil {
+		core.SetThrottleContext(config.Throttling.Context)
+	}
+
+	if config.NoncePoolSize > 0 {
+		core.SetNoncePoolSize(config.NoncePoolSize)
+	}
+
 	solversManager := resolver.NewSolversManager(core)
 
 	prober := resolver.NewProber(solversManager)
-	certifier := certificate.NewCertifier(core, prober, certificate.CertifierOptions{KeyType: config.Certificate.KeyType, Timeout: config.Certificate.Timeout, OverallRequestLimit: config.Certificate.OverallRequestLimit})
+	certifier := certificate.NewCertifier(core, prober, certificate.CertifierOptions{
+		KeyType:             config.Certificate.KeyType,
+		Timeout:             config.Certificate.Timeout,
+		OverallRequestLimit: config.Certificate.OverallRequestLimit,
+		SCTPolicy:           config.Certificate.SCTPolicy,
+		CAAPreCheck:         config.Certificate.CAAPreCheck,
+	})
 
 	return &Client{
 		Certificate:  certifier,
@@ -68,7 +93,33 @@ func (c *Client) GetToSURL() string {
 	return c.core.GetDirectory().Meta.TermsOfService
 }
 
+// GetProfiles returns the issuance profiles advertised by the Directory (name and description).
+func (c *Client) GetProfiles() map[string]string {
+	return c.core.GetDirectory().Meta.Profiles
+}
+
+// GetDirectoryMeta returns the metadata of the Directory (terms of service, website, CAA identities, profiles, etc.).
+// The Directory is fetched once, when the client is created.
+func (c *Client) GetDirectoryMeta() acme.Meta {
+	return c.core.GetDirectory().Meta
+}
+
+// GetCAAIdentities returns the CAA identities of the CA (the issuer domain names expected in the CAA records).
+func (c *Client) GetCAAIdentities() []string {
+	return c.core.GetDirectory().Meta.CaaIdentities
+}
+
 // GetExternalAccountRequired returns the External Account Binding requirement of the Directory.
 func (c *Client) GetExternalAccountRequired() bool {
 	return c.core.GetDirectory().Meta.ExternalAccountRequired
 }
+
+// GetDirectoryURL returns the URL of the Directory.
+func (c *Client) GetDirectoryURL() string {
+	return c.core.GetDirectoryURL()
+}
+
+// Probe checks the availability of the ACME server by fetching the Directory.
+func (c *Client) Probe() error {
+	return c.core.Probe()
+}
@@ -1,6 +1,7 @@
 package lego
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -11,7 +12,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/go-acme/lego/v4/acme/api"
 	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
 	"github.com/go-acme/lego/v4/registration"
 )
 
@@ -41,15 +44,38 @@ const (
 )
 
 type Config struct {
-	CADirURL    string
-	User        registration.User
+	CADirURL string
+	User     registration.User
+	// UserAgent is prepended to the User-Agent of the ACME requests (ex: "my-tool/1.2.3"), see WithUserAgent.
 	UserAgent   string
 	HTTPClient  *http.Client
 	Certificate CertificateConfig
+	Throttling  ThrottlingConfig
+
+	// NoncePoolSize is the size of the pool of nonces prefetched in the background
+	// (reduces the latency when issuing a lot of certificates).
+	// 0 disables the prefetching.
+	NoncePoolSize int
+
+	// Middlewares wrap the round-trips of the HTTP requests sent to the ACME server
+	// (ex: metrics, retries, audit, tracing spans), the first middleware is the outermost.
+	Middlewares []api.Middleware
+}
+
+// ConfigOption configures a Config.
+type ConfigOption func(config *Config)
+
+// WithUserAgent appends a product identifier (ex: "my-tool/1.2.3") to the User-Agent of the ACME requests.
+// Several CAs require it to identify the clients for support and debugging.
+// The characters not allowed in a product token are removed.
+func WithUserAgent(product, version string) ConfigOption {
+	return func(config *Config) {
+		config.UserAgent = appendUserAgent(config.UserAgent, product, version)
+	}
 }
 
-func NewConfig(user registration.User) *Config {
-	return &Config{
+func NewConfig(user registration.User, options ...ConfigOption) *Config {
+	config := &Config{
 		CADirURL:   LEDirectoryProduction,
 		User:       user,
 		HTTPClient: createDefaultHTTPClient(),
@@ -57,6 +83,42 @@ func NewConfig(user registration.User) *Config {
 			KeyType: certcrypto.RSA2048,
 			Timeout: 30 * time.Second,
 		},
+		Throttling: ThrottlingConfig{
+			MaxRetryAfter: api.DefaultMaxRetryAfter,
+		},
+	}
+
+	for _, option := range options {
+		option(config)
+	}
+
+	return config
+}
+
+// appendUserAgent appends the product token ("product/version") to the User-Agent.
+func appendUserAgent(userAgent, product, version string) string {
+	product = strings.Map(userAgentToken, product)
+	if product == "" {
+		return userAgent
+	}
+
+	version = strings.Map(userAgentToken, version)
+	if version != "" {
+		product += "/" + version
+	}
+
+	return strings.TrimSpace(userAgent + " " + product)
+}
+
+// userAgentToken removes the characters not allowed in a token (RFC 9110 Section 5.6.2).
+func userAgentToken(r rune) rune {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return r
+	case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		return r
+	default:
+		return -1
 	}
 }
 
@@ -64,6 +126,25 @@ type CertificateConfig struct {
 	KeyType             certcrypto.KeyType
 	Timeout             time.Duration
 	OverallRequestLimit int
+	// SCTPolicy verifies the SCTs embedded in the issued certificates (Certificate Transparency), if defined.
+	SCTPolicy *certificate.SCTPolicy
+	// CAAPreCheck checks the CAA records of the domains against the CAA identities of the CA before creating an order.
+	CAAPreCheck bool
+}
+
+// ThrottlingConfig defines the behavior of the client when the ACME server throttles it
+// (HTTP 429 or 503 with a Retry-After header).
+type ThrottlingConfig struct {
+	// MaxRetryAfter is the maximum delay, cumulated over the retries, honored for a request:
+	// the requests are paused, then retried.
+	// If the server requests a longer delay, the error is returned immediately.
+	MaxRetryAfter time.Duration
+
+	// Notify is called each time the server throttles the client.
+	Notify func(event api.ThrottleEvent)
+
+	// Context interrupts the pauses requested by the server when it is done (ex: on shutdown).
+	Context context.Context
 }
 
 // createDefaultHTTPClient Creates an HTTP client with a reasonable timeout value
@@ -34,6 +34,49 @@ func TestNewClient(t *testing.T) {
 	assert.NotNil(t, client)
 }
 
+func TestWithUserAgent(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		options  []ConfigOption
+		expected string
+	}{
+		{
+			desc:     "product and version",
+			options:  []ConfigOption{WithUserAgent("my-tool", "1.2.3")},
+			expected: "my-tool/1.2.3",
+		},
+		{
+			desc:     "no version",
+			options:  []ConfigOption{WithUserAgent("my-tool", "")},
+			expected: "my-tool",
+		},
+		{
+			desc:     "multiple products",
+			options:  []ConfigOption{WithUserAgent("my-tool", "1.2.3"), WithUserAgent("my-plugin", "0.1")},
+			expected: "my-tool/1.2.3 my-plugin/0.1",
+		},
+		{
+			desc:     "invalid characters",
+			options:  []ConfigOption{WithUserAgent("my tool (beta)", "1.2.3/4")},
+			expected: "mytoolbeta/1.2.34",
+		},
+		{
+			desc:    "empty product",
+			options: []ConfigOption{WithUserAgent("", "1.2.3")},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := NewConfig(mockUser{}, test.options...)
+
+			assert.Equal(t, test.expected, config.UserAgent)
+		})
+	}
+}
+
 type mockUser struct {
 	email      string
 	regres     *registration.Resource
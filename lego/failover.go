@@ -0,0 +1,168 @@
+package lego
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/log"
+)
+
+// Failover obtains certificates from an ordered list of CAs (ex: a primary CA and a fallback CA with EAB).
+//
+// Each CA is represented by its own Client, so each CA has its own account (User).
+// The CAs are tried in order:
+// a CA is skipped if its health probe fails (the directory cannot be fetched),
+// and the next CA is used if the CA is unavailable (network errors, or server errors after the retries).
+// The other errors (ex: a challenge failure) are returned immediately.
+type Failover struct {
+	cas []*failoverCA
+}
+
+// NewFailover creates a new Failover, the first client is the primary CA.
+func NewFailover(clients ...*Client) (*Failover, error) {
+	if len(clients) == 0 {
+		return nil, errors.New("failover: at least one client must be provided")
+	}
+
+	f := &Failover{}
+
+	for i, client := range clients {
+		if client == nil {
+			return nil, fmt.Errorf("failover: the client %d is nil", i)
+		}
+
+		f.cas = append(f.cas, &failoverCA{name: client.GetDirectoryURL(), client: client})
+	}
+
+	return f, nil
+}
+
+// AddFallback adds a fallback CA, tried after the previous CAs.
+// The client is created by the function only when the CA is used for the first time
+// (ex: the account is registered on the fallback CA only if the previous CAs are unavailable).
+// If the function fails, it is called again the next time the CA is used.
+func (f *Failover) AddFallback(name string, create func() (*Client, error)) {
+	f.cas = append(f.cas, &failoverCA{name: name, create: create})
+}
+
+// Obtain tries to obtain a single certificate using all domains passed into it (see certificate.Certifier.Obtain).
+// The ReplacesCertID is only sent to the primary CA: a certificate issued by another CA cannot be replaced.
+func (f *Failover) Obtain(request certificate.ObtainRequest) (*certificate.Resource, error) {
+	return f.do(func(index int, client *Client) (*certificate.Resource, error) {
+		if index > 0 {
+			request.ReplacesCertID = ""
+		}
+
+		return client.Certificate.Obtain(request)
+	})
+}
+
+// ObtainForCSR tries to obtain a certificate matching the CSR passed into it (see certificate.Certifier.ObtainForCSR).
+// The ReplacesCertID is only sent to the primary CA: a certificate issued by another CA cannot be replaced.
+func (f *Failover) ObtainForCSR(request certificate.ObtainForCSRRequest) (*certificate.Resource, error) {
+	return f.do(func(index int, client *Client) (*certificate.Resource, error) {
+		if index > 0 {
+			request.ReplacesCertID = ""
+		}
+
+		return client.Certificate.ObtainForCSR(request)
+	})
+}
+
+func (f *Failover) do(obtain func(index int, client *Client) (*certificate.Resource, error)) (*certificate.Resource, error) {
+	var errs []error
+
+	for i, ca := range f.cas {
+		client, err := ca.get()
+		if err != nil {
+			log.Warnf("failover: the CA %s cannot be used: %v", ca.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", ca.name, err))
+
+			continue
+		}
+
+		if err = client.Probe(); err != nil {
+			log.Warnf("failover: the CA %s is unavailable: %v", ca.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", ca.name, err))
+
+			continue
+		}
+
+		cert, err := obtain(i, client)
+		if err == nil {
+			return cert, nil
+		}
+
+		if !isUnavailable(err) {
+			return nil, err
+		}
+
+		log.Warnf("failover: the CA %s is unavailable: %v", ca.name, err)
+		errs = append(errs, fmt.Errorf("%s: %w", ca.name, err))
+	}
+
+	return nil, fmt.Errorf("failover: all the CAs are unavailable: %w", errors.Join(errs...))
+}
+
+// failoverCA a CA of a Failover, the client is created on first use.
+type failoverCA struct {
+	name   string
+	create func() (*Client, error)
+
+	mu     sync.Mutex
+	client *Client
+}
+
+func (ca *failoverCA) get() (*Client, error) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	if ca.client != nil {
+		return ca.client, nil
+	}
+
+	client, err := ca.create()
+	if err != nil {
+		return nil, err
+	}
+
+	ca.client = client
+
+	return client, nil
+}
+
+// isUnavailable checks if the error is related to the availability of the CA:
+// a server error (5xx) or a network error, anywhere in the tree of the wrapped errors
+// (ex: the error of one of the domains of an order).
+func isUnavailable(err error) bool {
+	switch e := err.(type) {
+	case nil:
+		return false
+
+	case *acme.ProblemDetails:
+		return e.HTTPStatus >= http.StatusInternalServerError
+
+	case net.Error:
+		return true
+
+	case interface{ Unwrap() []error }:
+		for _, child := range e.Unwrap() {
+			if isUnavailable(child) {
+				return true
+			}
+		}
+
+		return false
+
+	case interface{ Unwrap() error }:
+		return isUnavailable(e.Unwrap())
+
+	default:
+		return false
+	}
+}
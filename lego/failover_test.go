@@ -0,0 +1,195 @@
+package lego
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/go-acme/lego/v4/registration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailover_Obtain(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		primaryStatus    int
+		expectedFallback int32
+		expectedStatus   int
+	}{
+		{
+			desc:             "primary unavailable",
+			primaryStatus:    http.StatusServiceUnavailable,
+			expectedFallback: 1,
+			expectedStatus:   http.StatusForbidden,
+		},
+		{
+			desc:           "primary error",
+			primaryStatus:  http.StatusBadRequest,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			primary, _ := newFailoverClient(t, test.primaryStatus)
+			fallback, fallbackCalls := newFailoverClient(t, http.StatusForbidden)
+
+			failover, err := NewFailover(primary, fallback)
+			require.NoError(t, err)
+
+			_, err = failover.Obtain(certificate.ObtainRequest{Domains: []string{"example.com"}, ReplacesCertID: "foo"})
+			require.Error(t, err)
+
+			var problem *acme.ProblemDetails
+			require.ErrorAs(t, err, &problem)
+
+			assert.Equal(t, test.expectedStatus, problem.HTTPStatus)
+			assert.Equal(t, test.expectedFallback, fallbackCalls.Load())
+		})
+	}
+}
+
+func TestFailover_AddFallback(t *testing.T) {
+	primary, _ := newFailoverClient(t, http.StatusBadRequest)
+
+	failover, err := NewFailover(primary)
+	require.NoError(t, err)
+
+	var created atomic.Int32
+
+	failover.AddFallback("fallback", func() (*Client, error) {
+		created.Add(1)
+		return nil, errors.New("registration error")
+	})
+
+	// The primary CA is available: the fallback client is not created.
+	_, err = failover.Obtain(certificate.ObtainRequest{Domains: []string{"example.com"}})
+	require.Error(t, err)
+
+	assert.Zero(t, created.Load())
+}
+
+func TestFailover_AddFallback_unavailable(t *testing.T) {
+	primary, _ := newFailoverClient(t, http.StatusServiceUnavailable)
+	fallback, fallbackCalls := newFailoverClient(t, http.StatusForbidden)
+
+	failover, err := NewFailover(primary)
+	require.NoError(t, err)
+
+	var created atomic.Int32
+
+	failover.AddFallback("fallback", func() (*Client, error) {
+		if created.Add(1) == 1 {
+			return nil, errors.New("registration error")
+		}
+
+		return fallback, nil
+	})
+
+	_, err = failover.Obtain(certificate.ObtainRequest{Domains: []string{"example.com"}})
+	require.ErrorContains(t, err, "fallback: registration error")
+
+	// The creation of the client is retried.
+	_, err = failover.Obtain(certificate.ObtainRequest{Domains: []string{"example.com"}})
+
+	var problem *acme.ProblemDetails
+	require.ErrorAs(t, err, &problem)
+	assert.Equal(t, http.StatusForbidden, problem.HTTPStatus)
+
+	assert.EqualValues(t, 2, created.Load())
+	assert.EqualValues(t, 1, fallbackCalls.Load())
+}
+
+func Test_isUnavailable(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		err      error
+		expected bool
+	}{
+		{
+			desc: "client error",
+			err:  &acme.ProblemDetails{HTTPStatus: http.StatusForbidden},
+		},
+		{
+			desc:     "server error",
+			err:      &acme.ProblemDetails{HTTPStatus: http.StatusServiceUnavailable},
+			expected: true,
+		},
+		{
+			desc:     "wrapped server error",
+			err:      fmt.Errorf("order: %w", &acme.NonceError{ProblemDetails: &acme.ProblemDetails{HTTPStatus: http.StatusInternalServerError}}),
+			expected: true,
+		},
+		{
+			desc: "server error of a domain",
+			err: fmt.Errorf("error: one or more domains had a problem:\n%w", errors.Join(
+				&acme.IdentifierError{Identifier: "a.example.com", Err: &acme.ProblemDetails{HTTPStatus: http.StatusForbidden}},
+				&acme.IdentifierError{Identifier: "b.example.com", Err: &acme.ProblemDetails{HTTPStatus: http.StatusBadGateway}},
+			)),
+			expected: true,
+		},
+		{
+			desc:     "network error",
+			err:      fmt.Errorf("request: %w", &url.Error{Op: "Get", URL: "https://example.com", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}),
+			expected: true,
+		},
+		{
+			desc: "other error",
+			err:  errors.New("challenge failed"),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, isUnavailable(test.err))
+		})
+	}
+}
+
+func TestNewFailover_noClient(t *testing.T) {
+	_, err := NewFailover()
+	require.Error(t, err)
+}
+
+// newFailoverClient creates a client for a CA responding to the new order requests with the status.
+func newFailoverClient(t *testing.T, status int) (*Client, *atomic.Int32) {
+	t.Helper()
+
+	mux, apiURL := tester.SetupFakeAPI(t)
+
+	calls := &atomic.Int32{}
+
+	mux.HandleFunc("/newOrder", func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(status)
+
+		_ = tester.WriteJSONResponse(w, acme.ProblemDetails{Type: "urn:ietf:params:acme:error:serverInternal", HTTPStatus: status})
+	})
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	config := NewConfig(mockUser{email: "test@test.com", regres: new(registration.Resource), privatekey: key})
+	config.CADirURL = apiURL + "/dir"
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	return client, calls
+}
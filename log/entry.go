@@ -0,0 +1,82 @@
+package log
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// DomainKey the key of the domain attribute of the entries.
+const DomainKey = "domain"
+
+// AttrLogger a logger which writes the attributes of the entries as structured attributes (ex: SlogLogger).
+type AttrLogger interface {
+	LogAttrs(level slog.Level, msg string, attrs ...slog.Attr)
+}
+
+// Entry a log entry with attributes (ex: the domain of a certificate).
+// If the Logger is an AttrLogger, the attributes are structured attributes of the entry.
+// Otherwise, the attributes are written in the message: the domain is the prefix of the message ("[INFO] [example.com] message"),
+// and the other attributes are appended to the message ("key=value").
+type Entry struct {
+	attrs []slog.Attr
+}
+
+// With creates an entry with the attributes.
+func With(attrs ...slog.Attr) Entry {
+	return Entry{attrs: attrs}
+}
+
+// WithDomain creates an entry with the domain attribute.
+func WithDomain(domain string) Entry {
+	return With(slog.String(DomainKey, domain))
+}
+
+// Infof writes an info entry.
+func (e Entry) Infof(format string, args ...interface{}) {
+	e.logf(slog.LevelInfo, "[INFO] ", format, args...)
+}
+
+// Warnf writes a warning entry.
+func (e Entry) Warnf(format string, args ...interface{}) {
+	e.logf(slog.LevelWarn, "[WARN] ", format, args...)
+}
+
+// Printf writes an entry without level (see LevelPrint).
+func (e Entry) Printf(format string, args ...interface{}) {
+	e.logf(LevelPrint, "", format, args...)
+}
+
+func (e Entry) logf(level slog.Level, prefix, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+
+	if logger, ok := Logger.(AttrLogger); ok {
+		logger.LogAttrs(level, msg, e.attrs...)
+		return
+	}
+
+	Logger.Print(prefix + FormatAttrs(msg, e.attrs))
+}
+
+// FormatAttrs writes the attributes in the message:
+// the domain is the prefix of the message ("[example.com] message"), the other attributes are appended to the message ("key=value").
+func FormatAttrs(msg string, attrs []slog.Attr) string {
+	var domain string
+
+	suffix := &strings.Builder{}
+
+	for _, attr := range attrs {
+		if attr.Key == DomainKey {
+			domain = attr.Value.String()
+			continue
+		}
+
+		_, _ = fmt.Fprintf(suffix, " %s=%v", attr.Key, attr.Value)
+	}
+
+	if domain != "" {
+		msg = "[" + domain + "] " + msg
+	}
+
+	return msg + suffix.String()
+}
@@ -0,0 +1,64 @@
+package log
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntry_stdLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	setLogger(t, log.New(buf, "", 0))
+
+	WithDomain("example.com").Infof("acme: Trying to solve %s", "HTTP-01")
+	WithDomain("example.com").Warnf("acme: cleaning up failed: %v", "error")
+	With(slog.String(DomainKey, "example.com"), slog.Int("attempts", 2)).Printf("The certificate has been deployed.")
+
+	expected := `[INFO] [example.com] acme: Trying to solve HTTP-01
+[WARN] [example.com] acme: cleaning up failed: error
+[example.com] The certificate has been deployed. attempts=2
+`
+
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestEntry_attrLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	handler := slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return attr
+		},
+	})
+
+	setLogger(t, NewSlogLogger(slog.New(handler)))
+
+	WithDomain("example.com").Infof("acme: Trying to solve %s", "HTTP-01")
+	WithDomain("example.com").Warnf("acme: cleaning up failed: %v", "error")
+	With(slog.String(DomainKey, "example.com"), slog.Int("attempts", 2)).Printf("The certificate has been deployed.")
+
+	expected := `level=INFO msg="acme: Trying to solve HTTP-01" domain=example.com
+level=WARN msg="acme: cleaning up failed: error" domain=example.com
+level=INFO+1 msg="The certificate has been deployed." domain=example.com attempts=2
+`
+
+	assert.Equal(t, expected, buf.String())
+}
+
+func setLogger(t *testing.T, logger StdLogger) {
+	t.Helper()
+
+	previous := Logger
+	Logger = logger
+
+	t.Cleanup(func() { Logger = previous })
+}
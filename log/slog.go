@@ -0,0 +1,98 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Levels of the messages written without level prefix (ex: log.Println, log.Fatal):
+// the standard logger writes these messages without level, the text formats should do the same.
+const (
+	// LevelPrint the level of the messages without level prefix, between the info and the warning levels.
+	LevelPrint = slog.LevelInfo + 1
+	// LevelFatal the level of the fatal messages, above the error level.
+	LevelFatal = slog.LevelError + 1
+)
+
+// levelPrefixes the prefixes of the messages, and their levels.
+var levelPrefixes = []struct {
+	prefix string
+	level  slog.Level
+}{
+	{prefix: "[DEBUG] ", level: slog.LevelDebug},
+	{prefix: "[INFO] ", level: slog.LevelInfo},
+	{prefix: "[WARN] ", level: slog.LevelWarn},
+	{prefix: "[ERR] ", level: slog.LevelError},
+	{prefix: "[ERROR] ", level: slog.LevelError},
+}
+
+// SlogLogger a StdLogger that writes the entries to a slog.Logger.
+// The level of an entry is extracted from the prefix of the message ("[DEBUG] ", "[INFO] ", "[WARN] ", "[ERR] "),
+// the messages without prefix have the LevelPrint level.
+// The fatal entries have the LevelFatal level, then the program exits.
+// The attributes of the entries created with With are structured attributes (see AttrLogger).
+type SlogLogger struct {
+	logger *slog.Logger
+	exit   func(code int)
+}
+
+// NewSlogLogger creates a new SlogLogger.
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	return &SlogLogger{logger: logger, exit: os.Exit}
+}
+
+// Fatal writes a fatal entry, then exits.
+func (l *SlogLogger) Fatal(args ...interface{}) {
+	l.LogAttrs(LevelFatal, fmt.Sprint(args...))
+	l.exit(1)
+}
+
+// Fatalln writes a fatal entry, then exits.
+func (l *SlogLogger) Fatalln(args ...interface{}) {
+	l.LogAttrs(LevelFatal, fmt.Sprintln(args...))
+	l.exit(1)
+}
+
+// Fatalf writes a fatal entry, then exits.
+func (l *SlogLogger) Fatalf(format string, args ...interface{}) {
+	l.LogAttrs(LevelFatal, fmt.Sprintf(format, args...))
+	l.exit(1)
+}
+
+// Print writes an entry.
+func (l *SlogLogger) Print(args ...interface{}) {
+	l.write(fmt.Sprint(args...))
+}
+
+// Println writes an entry.
+func (l *SlogLogger) Println(args ...interface{}) {
+	l.write(fmt.Sprintln(args...))
+}
+
+// Printf writes an entry.
+func (l *SlogLogger) Printf(format string, args ...interface{}) {
+	l.write(fmt.Sprintf(format, args...))
+}
+
+// LogAttrs writes an entry with structured attributes, without exiting.
+func (l *SlogLogger) LogAttrs(level slog.Level, message string, attrs ...slog.Attr) {
+	l.logger.LogAttrs(context.Background(), level, strings.TrimSpace(message), attrs...)
+}
+
+func (l *SlogLogger) write(message string) {
+	level := LevelPrint
+
+	for _, p := range levelPrefixes {
+		if strings.HasPrefix(message, p.prefix) {
+			level = p.level
+			message = strings.TrimPrefix(message, p.prefix)
+
+			break
+		}
+	}
+
+	l.LogAttrs(level, message)
+}
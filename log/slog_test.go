@@ -0,0 +1,50 @@
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	handler := slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return attr
+		},
+	})
+
+	var exitCode int
+
+	logger := NewSlogLogger(slog.New(handler))
+	logger.exit = func(code int) { exitCode = code }
+
+	logger.Printf("[DEBUG] %s %s", "GET", "https://example.com/dir")
+	logger.Printf("[INFO] [%s] acme: Obtaining bundled SAN certificate", "example.com")
+	logger.Printf("[WARN] [%s] Unable to update the OCSP staple", "example.com")
+	logger.Printf("[ERR] %s request failed", "POST")
+	logger.Println("Certificate was revoked.")
+	logger.LogAttrs(slog.LevelInfo, "acme: Obtaining bundled SAN certificate", slog.String(DomainKey, "example.com"))
+	logger.Fatalf("Could not obtain certificates: %v", "error")
+
+	assert.Equal(t, 1, exitCode)
+
+	expected := `level=DEBUG msg="GET https://example.com/dir"
+level=INFO msg="[example.com] acme: Obtaining bundled SAN certificate"
+level=WARN msg="[example.com] Unable to update the OCSP staple"
+level=ERROR msg="POST request failed"
+level=INFO+1 msg="Certificate was revoked."
+level=INFO msg="acme: Obtaining bundled SAN certificate" domain=example.com
+level=ERROR+1 msg="Could not obtain certificates: error"
+`
+
+	assert.Equal(t, expected, buf.String())
+}
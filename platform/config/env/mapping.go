@@ -0,0 +1,67 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseDomainMapping parses values by domain: "example.com=value1;example.org=value2".
+// The errors don't contain the values: a value can be a secret (ex: an API token).
+func ParseDomainMapping(value string) (map[string]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	mapping := make(map[string]string)
+
+	for i, raw := range strings.Split(value, ";") {
+		domain, v, ok := strings.Cut(raw, "=")
+
+		domain = strings.TrimSpace(domain)
+		v = strings.TrimSpace(v)
+
+		if !ok || domain == "" {
+			return nil, fmt.Errorf("invalid entry %d: the format is 'domain=value'", i+1)
+		}
+
+		if v == "" {
+			return nil, fmt.Errorf("missing value for the domain %q", domain)
+		}
+
+		mapping[domain] = v
+	}
+
+	return mapping, nil
+}
+
+// MatchDomain returns the longest domain of the mapping matching the domain (the domain itself or a parent domain), and its value.
+// The domains are compared with NormalizeDomain.
+func MatchDomain[T any](mapping map[string]T, domain string) (string, T, bool) {
+	name := NormalizeDomain(domain)
+
+	var match, normalizedMatch string
+
+	for d := range mapping {
+		nd := NormalizeDomain(d)
+
+		if (name == nd || strings.HasSuffix(name, "."+nd)) && len(nd) > len(normalizedMatch) {
+			match, normalizedMatch = d, nd
+		}
+	}
+
+	if normalizedMatch == "" {
+		var zero T
+		return "", zero, false
+	}
+
+	return match, mapping[match], true
+}
+
+// NormalizeDomain returns the domain in lower case, without the wildcard prefix and without the trailing dot.
+func NormalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimPrefix(domain, "*.")
+
+	return strings.TrimSuffix(domain, ".")
+}
@@ -0,0 +1,117 @@
+package env
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDomainMapping(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		value       string
+		expected    map[string]string
+		expectedErr string
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc:     "one domain",
+			value:    "example.com=abc",
+			expected: map[string]string{"example.com": "abc"},
+		},
+		{
+			desc:     "multiple domains",
+			value:    " example.com = abc ; example.org=def",
+			expected: map[string]string{"example.com": "abc", "example.org": "def"},
+		},
+		{
+			desc:        "missing separator",
+			value:       "example.com=abc;secret",
+			expectedErr: "invalid entry 2: the format is 'domain=value'",
+		},
+		{
+			desc:        "missing domain",
+			value:       "=secret",
+			expectedErr: "invalid entry 1: the format is 'domain=value'",
+		},
+		{
+			desc:        "missing value",
+			value:       "example.com=abc;example.org=",
+			expectedErr: `missing value for the domain "example.org"`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			mapping, err := ParseDomainMapping(test.value)
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, mapping)
+		})
+	}
+}
+
+func TestMatchDomain(t *testing.T) {
+	mapping := map[string]int{
+		"example.com":         1,
+		"Sub.Example.com.":    2,
+		"*.other.example.org": 3,
+	}
+
+	testCases := []struct {
+		desc          string
+		domain        string
+		expectedMatch string
+		expected      int
+	}{
+		{
+			desc:          "exact",
+			domain:        "example.com",
+			expectedMatch: "example.com",
+			expected:      1,
+		},
+		{
+			desc:          "subdomain",
+			domain:        "_acme-challenge.www.example.com.",
+			expectedMatch: "example.com",
+			expected:      1,
+		},
+		{
+			desc:          "longest domain",
+			domain:        "_acme-challenge.a.sub.example.com.",
+			expectedMatch: "Sub.Example.com.",
+			expected:      2,
+		},
+		{
+			desc:          "wildcard",
+			domain:        "*.other.example.org",
+			expectedMatch: "*.other.example.org",
+			expected:      3,
+		},
+		{
+			desc:   "suffix without dot",
+			domain: "notexample.com",
+		},
+		{
+			desc:   "no match",
+			domain: "example.net",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			match, value, ok := MatchDomain(mapping, test.domain)
+
+			assert.Equal(t, test.expectedMatch != "", ok)
+			assert.Equal(t, test.expectedMatch, match)
+			assert.Equal(t, test.expected, value)
+		})
+	}
+}
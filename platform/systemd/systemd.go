@@ -0,0 +1,186 @@
+// Package systemd implements the systemd socket activation and the service notifications (sd_notify).
+package systemd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables defined by systemd.
+// https://www.freedesktop.org/software/systemd/man/latest/sd_listen_fds.html
+// https://www.freedesktop.org/software/systemd/man/latest/sd_notify.html
+const (
+	envListenPID     = "LISTEN_PID"
+	envListenFDs     = "LISTEN_FDS"
+	envListenFDNames = "LISTEN_FDNAMES"
+	envNotifySocket  = "NOTIFY_SOCKET"
+	envWatchdogUSec  = "WATCHDOG_USEC"
+	envWatchdogPID   = "WATCHDOG_PID"
+)
+
+// listenFDsStart the first file descriptor passed by systemd (SD_LISTEN_FDS_START).
+const listenFDsStart = 3
+
+// Notification states.
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+	Watchdog = "WATCHDOG=1"
+)
+
+// Listener a socket passed by systemd (socket activation).
+type Listener struct {
+	// Name the name of the socket (FileDescriptorName= of the socket unit, the name of the socket unit by default).
+	Name string
+	net.Listener
+}
+
+var (
+	listenersOnce sync.Once
+	listeners     []Listener
+	listenersErr  error
+)
+
+// Listeners returns the sockets passed by systemd.
+// The environment variables are read (then removed, so they are not inherited by the child processes) only once.
+func Listeners() ([]Listener, error) {
+	listenersOnce.Do(func() {
+		listeners, listenersErr = readListeners()
+	})
+
+	return listeners, listenersErr
+}
+
+// ListenerByName returns the socket passed by systemd with this name.
+func ListenerByName(name string) (net.Listener, error) {
+	all, err := Listeners()
+	if err != nil {
+		return nil, err
+	}
+
+	var found net.Listener
+
+	for _, l := range all {
+		if l.Name != name {
+			continue
+		}
+
+		if found != nil {
+			return nil, fmt.Errorf("systemd: several sockets named %q", name)
+		}
+
+		found = l.Listener
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("systemd: no socket named %q (socket activation)", name)
+	}
+
+	return found, nil
+}
+
+func readListeners() ([]Listener, error) {
+	defer func() {
+		_ = os.Unsetenv(envListenPID)
+		_ = os.Unsetenv(envListenFDs)
+		_ = os.Unsetenv(envListenFDNames)
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv(envListenPID))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	count, err := strconv.Atoi(os.Getenv(envListenFDs))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+
+	names := strings.Split(os.Getenv(envListenFDNames), ":")
+
+	var result []Listener
+
+	for i := range count {
+		fd := listenFDsStart + i
+
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		file := os.NewFile(uintptr(fd), name)
+
+		// net.FileListener duplicates the file descriptor.
+		l, err := net.FileListener(file)
+
+		_ = file.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("systemd: socket %d (%s): %w", fd, name, err)
+		}
+
+		result = append(result, Listener{Name: name, Listener: l})
+	}
+
+	return result, nil
+}
+
+// Notify sends a notification to the service manager (ex: READY=1), several states are separated by new lines.
+// Nothing is sent if the process is not a notify service (NOTIFY_SOCKET is not defined).
+func Notify(state string) error {
+	socket := os.Getenv(envNotifySocket)
+	if socket == "" {
+		return nil
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("systemd: notify: %w", err)
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	_, err = conn.Write([]byte(state))
+	if err != nil {
+		return fmt.Errorf("systemd: notify: %w", err)
+	}
+
+	return nil
+}
+
+// WatchdogInterval returns the interval of the keep-alive notifications (WATCHDOG=1): half of the watchdog timeout.
+// It returns 0 if the watchdog is not enabled for the process.
+func WatchdogInterval() (time.Duration, error) {
+	value := os.Getenv(envWatchdogUSec)
+	if value == "" {
+		return 0, nil
+	}
+
+	if rawPID := os.Getenv(envWatchdogPID); rawPID != "" {
+		pid, err := strconv.Atoi(rawPID)
+		if err != nil {
+			return 0, fmt.Errorf("systemd: invalid %s: %w", envWatchdogPID, err)
+		}
+
+		if pid != os.Getpid() {
+			return 0, nil
+		}
+	}
+
+	usec, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("systemd: invalid %s: %w", envWatchdogUSec, err)
+	}
+
+	if usec <= 0 {
+		return 0, errors.New("systemd: invalid " + envWatchdogUSec + ": must be positive")
+	}
+
+	return time.Duration(usec) * time.Microsecond / 2, nil
+}
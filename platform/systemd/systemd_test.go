@@ -0,0 +1,153 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotify(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix datagram sockets")
+	}
+
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	t.Setenv(envNotifySocket, socket)
+
+	require.NoError(t, Notify(Ready+"\nSTATUS=running"))
+
+	buf := make([]byte, 1024)
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, "READY=1\nSTATUS=running", string(buf[:n]))
+}
+
+func TestNotify_noSocket(t *testing.T) {
+	t.Setenv(envNotifySocket, "")
+
+	require.NoError(t, Notify(Ready))
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		usec     string
+		pid      string
+		expected time.Duration
+	}{
+		{
+			desc: "disabled",
+		},
+		{
+			desc:     "enabled",
+			usec:     "30000000",
+			expected: 15 * time.Second,
+		},
+		{
+			desc:     "current process",
+			usec:     "30000000",
+			pid:      strconv.Itoa(os.Getpid()),
+			expected: 15 * time.Second,
+		},
+		{
+			desc: "other process",
+			usec: "30000000",
+			pid:  "1",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Setenv(envWatchdogUSec, test.usec)
+			t.Setenv(envWatchdogPID, test.pid)
+
+			interval, err := WatchdogInterval()
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, interval)
+		})
+	}
+}
+
+func TestWatchdogInterval_error(t *testing.T) {
+	t.Setenv(envWatchdogUSec, "abc")
+	t.Setenv(envWatchdogPID, "")
+
+	_, err := WatchdogInterval()
+	require.Error(t, err)
+}
+
+func Test_readListeners_otherProcess(t *testing.T) {
+	t.Setenv(envListenPID, "1")
+	t.Setenv(envListenFDs, "1")
+	t.Setenv(envListenFDNames, "http")
+
+	listeners, err := readListeners()
+	require.NoError(t, err)
+
+	assert.Empty(t, listeners)
+
+	// the variables are not inherited by the child processes.
+	assert.Empty(t, os.Getenv(envListenFDs))
+}
+
+func TestListeners(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("socket activation")
+	}
+
+	// The sockets are passed to a child process (the test binary): the file descriptors start at 3.
+	if os.Getenv("LEGO_TEST_SYSTEMD_CHILD") == "1" {
+		t.Setenv(envListenPID, strconv.Itoa(os.Getpid()))
+
+		listener, err := ListenerByName("http")
+		require.NoError(t, err)
+
+		assert.Equal(t, os.Getenv("LEGO_TEST_SYSTEMD_ADDR"), listener.Addr().String())
+
+		_, err = ListenerByName("tls")
+		require.EqualError(t, err, `systemd: no socket named "tls" (socket activation)`)
+
+		return
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	file, err := listener.(*net.TCPListener).File()
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = file.Close() })
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestListeners$")
+	cmd.ExtraFiles = []*os.File{file}
+	cmd.Env = append(os.Environ(),
+		"LEGO_TEST_SYSTEMD_CHILD=1",
+		"LEGO_TEST_SYSTEMD_ADDR="+listener.Addr().String(),
+		envListenFDs+"=1",
+		envListenFDNames+"=http",
+	)
+
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
@@ -27,27 +27,27 @@ func SetupFakeAPI(t *testing.T) (*http.ServeMux, string) {
 			NewNonceURL:   server.URL + "/nonce",
 			NewAccountURL: server.URL + "/account",
 			NewOrderURL:   server.URL + "/newOrder",
+			NewAuthzURL:   server.URL + "/newAuthz",
 			RevokeCertURL: server.URL + "/revokeCert",
 			KeyChangeURL:  server.URL + "/keyChange",
 			RenewalInfo:   server.URL + "/renewalInfo",
 		})
-
-		mux.HandleFunc("/nonce", func(w http.ResponseWriter, r *http.Request) {
-			if r.Method != http.MethodHead {
-				http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
-				return
-			}
-
-			w.Header().Set("Replay-Nonce", "12345")
-			w.Header().Set("Retry-After", "0")
-		})
-
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 	})
 
+	mux.HandleFunc("/nonce", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Replay-Nonce", "12345")
+		w.Header().Set("Retry-After", "0")
+	})
+
 	return mux, server.URL
 }
 
@@ -1,12 +1,16 @@
 package wait
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/go-acme/lego/v4/log"
 )
 
+// ErrTimeout is returned (wrapped) by For when the time limit is exceeded.
+var ErrTimeout = errors.New("time limit exceeded")
+
 // For polls the given function 'f', once every 'interval', up to 'timeout'.
 func For(msg string, timeout, interval time.Duration, f func() (bool, error)) error {
 	log.Infof("Wait for %s [timeout: %s, interval: %s]", msg, timeout, interval)
@@ -17,9 +21,9 @@ func For(msg string, timeout, interval time.Duration, f func() (bool, error)) er
 		select {
 		case <-timeUp:
 			if lastErr == nil {
-				return fmt.Errorf("%s: time limit exceeded", msg)
+				return fmt.Errorf("%s: %w", msg, ErrTimeout)
 			}
-			return fmt.Errorf("%s: time limit exceeded: last error: %w", msg, lastErr)
+			return fmt.Errorf("%s: %w: last error: %w", msg, ErrTimeout, lastErr)
 		default:
 		}
 
@@ -1,6 +1,7 @@
 package wait
 
 import (
+	"errors"
 	"testing"
 	"time"
 )
@@ -18,7 +19,7 @@ func TestForTimeout(t *testing.T) {
 	case <-timeout:
 		t.Fatal("timeout exceeded")
 	case err := <-c:
-		if err == nil {
+		if !errors.Is(err, ErrTimeout) {
 			t.Errorf("expected timeout error; got %v", err)
 		}
 		t.Logf("%v", err)
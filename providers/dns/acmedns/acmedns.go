@@ -6,11 +6,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/go-acme/lego/v4/challenge"
 	"github.com/go-acme/lego/v4/challenge/dns01"
 	"github.com/go-acme/lego/v4/platform/config/env"
 	"github.com/go-acme/lego/v4/providers/dns/acmedns/internal"
+	"github.com/miekg/dns"
 	"github.com/nrdcg/goacmedns"
 	"github.com/nrdcg/goacmedns/storage"
 )
@@ -30,6 +33,34 @@ const (
 	// EnvStorageBaseURL  is the environment variable name for the ACME-DNS JSON account data.
 	// The URL to the storage server.
 	EnvStorageBaseURL = envNamespace + "STORAGE_BASE_URL"
+
+	// EnvStorageRedisURL is the environment variable name for the URL of the Redis server of the accounts.
+	EnvStorageRedisURL = envNamespace + "STORAGE_REDIS_URL"
+	// EnvStorageRedisKey is the environment variable name for the key of the Redis hash of the accounts.
+	EnvStorageRedisKey = envNamespace + "STORAGE_REDIS_KEY"
+
+	// EnvStorageSQLDriver is the environment variable name for the SQL driver (postgres, mysql).
+	EnvStorageSQLDriver = envNamespace + "STORAGE_SQL_DRIVER"
+	// EnvStorageSQLDSN is the environment variable name for the data source name of the SQL database of the accounts.
+	EnvStorageSQLDSN = envNamespace + "STORAGE_SQL_DSN"
+	// EnvStorageSQLTable is the environment variable name for the SQL table of the accounts.
+	EnvStorageSQLTable = envNamespace + "STORAGE_SQL_TABLE"
+
+	// EnvStorageVaultAddr is the environment variable name for the address of the Vault server.
+	EnvStorageVaultAddr = envNamespace + "STORAGE_VAULT_ADDR"
+	// EnvStorageVaultToken is the environment variable name for the Vault token.
+	EnvStorageVaultToken = envNamespace + "STORAGE_VAULT_TOKEN"
+	// EnvStorageVaultMount is the environment variable name for the mount of the KV secrets engine (version 2).
+	EnvStorageVaultMount = envNamespace + "STORAGE_VAULT_MOUNT"
+	// EnvStorageVaultPath is the environment variable name for the path of the accounts in the KV secrets engine.
+	EnvStorageVaultPath = envNamespace + "STORAGE_VAULT_PATH"
+
+	// EnvAllowList is the environment variable name for the source networks (CIDR) allowed to update the TXT records of the registered accounts.
+	EnvAllowList = envNamespace + "ALLOWLIST"
+	// EnvAutoRegistration is the environment variable name to enable the registration of the accounts.
+	EnvAutoRegistration = envNamespace + "AUTO_REGISTRATION"
+	// EnvCheckCNAME is the environment variable name to check the CNAME of a domain before the first use of its account.
+	EnvCheckCNAME = envNamespace + "CHECK_CNAME"
 )
 
 var _ challenge.Provider = (*DNSProvider)(nil)
@@ -45,47 +76,121 @@ type acmeDNSClient interface {
 	RegisterAccount(ctx context.Context, allowFrom []string) (goacmedns.Account, error)
 }
 
+// Config is used to configure the creation of the DNSProvider.
+type Config struct {
+	APIBase string
+
+	// AllowList the source networks (CIDR) allowed to update the TXT records of the registered accounts.
+	AllowList []string
+	// AutoRegistration registers an account when a domain has no account.
+	AutoRegistration bool
+	// CheckCNAME checks the CNAME of a domain before the first use of its account.
+	CheckCNAME bool
+
+	// Storages of the accounts (only one).
+
+	StoragePath    string
+	StorageBaseURL string
+
+	StorageRedisURL string
+	StorageRedisKey string
+
+	StorageSQLDriver string
+	StorageSQLDSN    string
+	StorageSQLTable  string
+
+	StorageVaultAddr  string
+	StorageVaultToken string
+	StorageVaultMount string
+	StorageVaultPath  string
+}
+
+// NewDefaultConfig returns a default configuration for the DNSProvider.
+func NewDefaultConfig() *Config {
+	return &Config{
+		AutoRegistration:  env.GetOrDefaultBool(EnvAutoRegistration, true),
+		CheckCNAME:        env.GetOrDefaultBool(EnvCheckCNAME, false),
+		StorageRedisKey:   env.GetOrDefaultString(EnvStorageRedisKey, internal.DefaultRedisKey),
+		StorageSQLDriver:  env.GetOrDefaultString(EnvStorageSQLDriver, "postgres"),
+		StorageSQLTable:   env.GetOrDefaultString(EnvStorageSQLTable, internal.DefaultSQLTable),
+		StorageVaultAddr:  env.GetOrDefaultString(EnvStorageVaultAddr, env.GetOrDefaultString("VAULT_ADDR", "")),
+		StorageVaultMount: env.GetOrDefaultString(EnvStorageVaultMount, internal.DefaultVaultMount),
+	}
+}
+
 // DNSProvider implements the challenge.Provider interface.
 type DNSProvider struct {
 	client  acmeDNSClient
 	storage goacmedns.Storage
+
+	allowList        []string
+	autoRegistration bool
+	checkCNAME       bool
+
+	// verified the domains with a valid CNAME.
+	verified sync.Map
+
+	getChallengeInfo func(domain, keyAuth string) dns01.ChallengeInfo
 }
 
-// NewDNSProvider creates an ACME-DNS provider using file based account storage.
-// Its configuration is loaded from the environment by reading EnvAPIBase and EnvStoragePath.
+// NewDNSProvider creates an ACME-DNS provider.
+// Its configuration is loaded from the environment by reading EnvAPIBase, and the variables of one storage.
 func NewDNSProvider() (*DNSProvider, error) {
 	values, err := env.Get(EnvAPIBase)
 	if err != nil {
 		return nil, fmt.Errorf("acme-dns: %w", err)
 	}
 
-	storagePath := env.GetOrFile(EnvStoragePath)
-	storageBaseURL := env.GetOrFile(EnvStorageBaseURL)
+	config := NewDefaultConfig()
+	config.APIBase = values[EnvAPIBase]
 
-	if storagePath == "" && storageBaseURL == "" {
-		return nil, fmt.Errorf("acme-dns: %s or %s environment variables not set", EnvStoragePath, EnvStorageBaseURL)
+	allowList := env.GetOrDefaultString(EnvAllowList, "")
+	if allowList != "" {
+		for _, cidr := range strings.Split(allowList, ",") {
+			config.AllowList = append(config.AllowList, strings.TrimSpace(cidr))
+		}
 	}
 
-	if storagePath != "" && storageBaseURL != "" {
-		return nil, fmt.Errorf("acme-dns: %s or %s environment variables cannot be used at the same time", EnvStoragePath, EnvStorageBaseURL)
+	config.StoragePath = env.GetOrFile(EnvStoragePath)
+	config.StorageBaseURL = env.GetOrFile(EnvStorageBaseURL)
+	config.StorageRedisURL = env.GetOrFile(EnvStorageRedisURL)
+	config.StorageSQLDSN = env.GetOrFile(EnvStorageSQLDSN)
+	config.StorageVaultPath = env.GetOrDefaultString(EnvStorageVaultPath, "")
+
+	config.StorageVaultToken = env.GetOrFile(EnvStorageVaultToken)
+	if config.StorageVaultToken == "" {
+		config.StorageVaultToken = env.GetOrDefaultString("VAULT_TOKEN", "")
 	}
 
-	var st goacmedns.Storage
-	if storagePath != "" {
-		st = storage.NewFile(values[EnvStoragePath], 0o600)
-	} else {
-		st, err = internal.NewHTTPStorage(storageBaseURL)
-		if err != nil {
-			return nil, fmt.Errorf("acme-dns: new HTTP storage: %w", err)
-		}
+	return NewDNSProviderConfig(config)
+}
+
+// NewDNSProviderConfig creates an ACME-DNS provider from a configuration.
+func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
+	if config == nil {
+		return nil, errors.New("acme-dns: the configuration of the DNS provider is nil")
+	}
+
+	st, err := newStorage(context.Background(), config)
+	if err != nil {
+		return nil, fmt.Errorf("acme-dns: %w", err)
 	}
 
-	client, err := goacmedns.NewClient(values[EnvAPIBase])
+	client, err := goacmedns.NewClient(config.APIBase)
 	if err != nil {
 		return nil, fmt.Errorf("acme-dns: %w", err)
 	}
 
-	return NewDNSProviderClient(client, st)
+	provider, err := NewDNSProviderClient(client, st)
+	if err != nil {
+		return nil, err
+	}
+
+	provider.allowList = config.AllowList
+	provider.autoRegistration = config.AutoRegistration
+	provider.checkCNAME = config.CheckCNAME
+
+	return provider, nil
 }
 
 // NewDNSProviderClient creates an ACME-DNS DNSProvider with the given acmeDNSClient and [goacmedns.Storage].
@@ -99,8 +204,10 @@ func NewDNSProviderClient(client acmeDNSClient, storage goacmedns.Storage) (*DNS
 	}
 
 	return &DNSProvider{
-		client:  client,
-		storage: storage,
+		client:           client,
+		storage:          storage,
+		autoRegistration: true,
+		getChallengeInfo: dns01.GetChallengeInfo,
 	}, nil
 }
 
@@ -130,22 +237,39 @@ func (e ErrCNAMERequired) Error() string {
 		e.Domain, e.Domain, e.FQDN, e.Target)
 }
 
+// ErrAccountNotFound is returned by Present when the Domain indicated had no
+// existing ACME-DNS account in the Storage, and the automatic registration is disabled.
+type ErrAccountNotFound struct {
+	// The Domain that is being issued for.
+	Domain string
+}
+
+// Error returns a descriptive message for the ErrAccountNotFound instance.
+func (e ErrAccountNotFound) Error() string {
+	return fmt.Sprintf("acme-dns: no account for %q, and the automatic registration is disabled", e.Domain)
+}
+
 // Present creates a TXT record to fulfill the DNS-01 challenge.
 // If there is an existing account for the domain in the provider's storage
 // then it will be used to set the challenge response TXT record with the ACME-DNS server and issuance will continue.
 // If there is not an account for the given domain present in the DNSProvider storage
 // one will be created and registered with the ACME DNS server and an ErrCNAMERequired error is returned.
 // This will halt issuance and indicate to the user that a one-time manual setup is required for the domain.
+// If the automatic registration is disabled, an ErrAccountNotFound error is returned instead.
 func (d *DNSProvider) Present(domain, _, keyAuth string) error {
 	ctx := context.Background()
 
 	// Compute the challenge response FQDN and TXT value for the domain based on the keyAuth.
-	info := dns01.GetChallengeInfo(domain, keyAuth)
+	info := d.getChallengeInfo(domain, keyAuth)
 
 	// Check if credentials were previously saved for this domain.
 	account, err := d.storage.Fetch(ctx, domain)
 	if err != nil {
 		if errors.Is(err, storage.ErrDomainNotFound) {
+			if !d.autoRegistration {
+				return ErrAccountNotFound{Domain: domain}
+			}
+
 			// The account did not exist.
 			// Create a new one and return an error indicating the required one-time manual CNAME setup.
 			return d.register(ctx, domain, info.FQDN)
@@ -155,6 +279,13 @@ func (d *DNSProvider) Present(domain, _, keyAuth string) error {
 		return err
 	}
 
+	if d.checkCNAME {
+		err = d.verifyCNAME(domain, info, account)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Update the acme-dns TXT record.
 	return d.client.UpdateTXTRecord(ctx, account, info.Value)
 }
@@ -172,8 +303,7 @@ func (d *DNSProvider) CleanUp(_, _, _ string) error {
 // the one-time manual CNAME setup required to complete setup of the ACME-DNS hook for the domain.
 // If any other error occurs it is returned as-is.
 func (d *DNSProvider) register(ctx context.Context, domain, fqdn string) error {
-	// TODO(@cpu): Read CIDR whitelists from the environment
-	newAcct, err := d.client.RegisterAccount(ctx, nil)
+	newAcct, err := d.client.RegisterAccount(ctx, d.allowList)
 	if err != nil {
 		return err
 	}
@@ -197,3 +327,23 @@ func (d *DNSProvider) register(ctx context.Context, domain, fqdn string) error {
 		Target: newAcct.FullDomain,
 	}
 }
+
+// verifyCNAME checks that the challenge FQDN of the domain is an alias of the full domain of the account (before the first use of the account).
+// The effective FQDN of the challenge follows the CNAME of the FQDN.
+func (d *DNSProvider) verifyCNAME(domain string, info dns01.ChallengeInfo, account goacmedns.Account) error {
+	if _, ok := d.verified.Load(domain); ok {
+		return nil
+	}
+
+	if !strings.EqualFold(info.EffectiveFQDN, dns.Fqdn(account.FullDomain)) {
+		return ErrCNAMERequired{
+			Domain: domain,
+			FQDN:   info.FQDN,
+			Target: account.FullDomain,
+		}
+	}
+
+	d.verified.Store(domain, struct{}{})
+
+	return nil
+}
@@ -2,8 +2,11 @@ package acmedns
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/platform/tester"
 	"github.com/nrdcg/goacmedns"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,6 +19,140 @@ const (
 	egKeyAuth = "⚷"
 )
 
+var envTest = tester.NewEnvTest(
+	EnvAPIBase,
+	EnvStoragePath,
+	EnvStorageBaseURL,
+	EnvStorageRedisURL,
+	EnvStorageRedisKey,
+	EnvStorageSQLDriver,
+	EnvStorageSQLDSN,
+	EnvStorageSQLTable,
+	EnvStorageVaultAddr,
+	EnvStorageVaultToken,
+	EnvStorageVaultMount,
+	EnvStorageVaultPath,
+	EnvAllowList,
+	EnvAutoRegistration,
+	EnvCheckCNAME,
+	"VAULT_ADDR",
+	"VAULT_TOKEN",
+)
+
+func TestNewDNSProvider(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		envVars  map[string]string
+		expected string
+	}{
+		{
+			desc: "success (file)",
+			envVars: map[string]string{
+				EnvAPIBase:     "https://auth.example.com",
+				EnvStoragePath: filepath.Join(t.TempDir(), "accounts.json"),
+			},
+		},
+		{
+			desc: "success (HTTP)",
+			envVars: map[string]string{
+				EnvAPIBase:        "https://auth.example.com",
+				EnvStorageBaseURL: "https://storage.example.com",
+			},
+		},
+		{
+			desc: "success (Redis)",
+			envVars: map[string]string{
+				EnvAPIBase:         "https://auth.example.com",
+				EnvStorageRedisURL: "redis://localhost:6379/0",
+			},
+		},
+		{
+			desc: "success (Vault)",
+			envVars: map[string]string{
+				EnvAPIBase:          "https://auth.example.com",
+				EnvStorageVaultPath: "lego/acme-dns",
+				"VAULT_ADDR":        "https://vault.example.com",
+				"VAULT_TOKEN":       "secret",
+			},
+		},
+		{
+			desc: "missing API base",
+			envVars: map[string]string{
+				EnvStoragePath: filepath.Join(t.TempDir(), "accounts.json"),
+			},
+			expected: "acme-dns: some credentials information are missing: ACME_DNS_API_BASE",
+		},
+		{
+			desc: "missing storage",
+			envVars: map[string]string{
+				EnvAPIBase: "https://auth.example.com",
+			},
+			expected: "acme-dns: one storage is required: ACME_DNS_STORAGE_PATH, ACME_DNS_STORAGE_BASE_URL, " +
+				"ACME_DNS_STORAGE_REDIS_URL, ACME_DNS_STORAGE_SQL_DSN, ACME_DNS_STORAGE_VAULT_PATH",
+		},
+		{
+			desc: "several storages",
+			envVars: map[string]string{
+				EnvAPIBase:         "https://auth.example.com",
+				EnvStorageBaseURL:  "https://storage.example.com",
+				EnvStorageRedisURL: "redis://localhost:6379/0",
+			},
+			expected: "acme-dns: only one storage can be used at the same time: ACME_DNS_STORAGE_PATH, ACME_DNS_STORAGE_BASE_URL, " +
+				"ACME_DNS_STORAGE_REDIS_URL, ACME_DNS_STORAGE_SQL_DSN, ACME_DNS_STORAGE_VAULT_PATH",
+		},
+		{
+			desc: "invalid Redis URL",
+			envVars: map[string]string{
+				EnvAPIBase:         "https://auth.example.com",
+				EnvStorageRedisURL: "http://localhost:6379",
+			},
+			expected: "acme-dns: new Redis storage: redis: invalid URL scheme: http",
+		},
+		{
+			desc: "missing Vault address",
+			envVars: map[string]string{
+				EnvAPIBase:          "https://auth.example.com",
+				EnvStorageVaultPath: "lego/acme-dns",
+			},
+			expected: "acme-dns: new Vault storage: the address of the Vault server is missing",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			defer envTest.RestoreEnv()
+			envTest.ClearEnv()
+
+			envTest.Apply(test.envVars)
+
+			p, err := NewDNSProvider()
+
+			if test.expected == "" {
+				require.NoError(t, err)
+				require.NotNil(t, p)
+				require.NotNil(t, p.storage)
+			} else {
+				require.EqualError(t, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestNewDNSProviderConfig(t *testing.T) {
+	config := NewDefaultConfig()
+	config.APIBase = "https://auth.example.com"
+	config.StoragePath = filepath.Join(t.TempDir(), "accounts.json")
+	config.AllowList = []string{"192.168.0.0/24"}
+	config.CheckCNAME = true
+
+	p, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"192.168.0.0/24"}, p.allowList)
+	assert.True(t, p.autoRegistration)
+	assert.True(t, p.checkCNAME)
+}
+
 // TestPresent tests that the ACME-DNS Present function for updating a DNS-01
 // challenge response TXT record works as expected.
 func TestPresent(t *testing.T) {
@@ -152,3 +289,73 @@ func TestRegister(t *testing.T) {
 		})
 	}
 }
+
+func TestPresent_autoRegistrationDisabled(t *testing.T) {
+	dp, err := NewDNSProviderClient(mockClient{egTestAccount}, mockStorage{make(map[string]goacmedns.Account)})
+	require.NoError(t, err)
+
+	dp.autoRegistration = false
+
+	err = dp.Present(egDomain, "foo", egKeyAuth)
+	assert.Equal(t, ErrAccountNotFound{Domain: egDomain}, err)
+}
+
+func TestPresent_checkCNAME(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		effectiveFQDN string
+		expectedError error
+	}{
+		{
+			desc:          "CNAME to the account",
+			effectiveFQDN: "Acme-DNS." + egDomain + ".",
+		},
+		{
+			desc:          "missing CNAME",
+			effectiveFQDN: egFQDN,
+			expectedError: ErrCNAMERequired{
+				Domain: egDomain,
+				FQDN:   egFQDN,
+				Target: egTestAccount.FullDomain,
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			client := mockUpdateClient{
+				mockClient{egTestAccount},
+				make(map[goacmedns.Account]string),
+			}
+
+			dp, err := NewDNSProviderClient(client, mockStorage{map[string]goacmedns.Account{egDomain: egTestAccount}})
+			require.NoError(t, err)
+
+			dp.checkCNAME = true
+
+			dp.getChallengeInfo = func(domain, keyAuth string) dns01.ChallengeInfo {
+				info := dns01.GetChallengeInfo(domain, keyAuth)
+				info.EffectiveFQDN = test.effectiveFQDN
+
+				return info
+			}
+
+			err = dp.Present(egDomain, "foo", egKeyAuth)
+			if test.expectedError != nil {
+				assert.Equal(t, test.expectedError, err)
+				assert.Empty(t, client.records)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, client.records, 1)
+
+			// The CNAME is checked only once.
+			test.effectiveFQDN = egFQDN
+
+			err = dp.Present(egDomain, "foo", egKeyAuth)
+			require.NoError(t, err)
+		})
+	}
+}
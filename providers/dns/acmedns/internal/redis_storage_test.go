@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRedisTest(t *testing.T) (*RedisStorage, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+
+	st, err := NewRedisStorage("redis://"+server.Addr(), "")
+	require.NoError(t, err)
+
+	return st, server
+}
+
+func TestRedisStorage_Put(t *testing.T) {
+	st, server := setupRedisTest(t)
+
+	account := goacmedns.Account{
+		FullDomain: "foo.example.com",
+		SubDomain:  "foo",
+		Username:   "user",
+		Password:   "secret",
+		ServerURL:  "https://example.com",
+	}
+
+	err := st.Put(context.Background(), "example.com", account)
+	require.NoError(t, err)
+
+	expected := `{"fulldomain":"foo.example.com","subdomain":"foo","username":"user","password":"secret","server_url":"https://example.com"}`
+
+	assert.JSONEq(t, expected, server.HGet(DefaultRedisKey, "example.com"))
+}
+
+func TestRedisStorage_Fetch(t *testing.T) {
+	st, server := setupRedisTest(t)
+
+	server.HSet(DefaultRedisKey, "example.com",
+		`{"fulldomain":"foo.example.com","subdomain":"foo","username":"user","password":"secret","server_url":"https://example.com"}`)
+
+	account, err := st.Fetch(context.Background(), "example.com")
+	require.NoError(t, err)
+
+	expected := goacmedns.Account{
+		FullDomain: "foo.example.com",
+		SubDomain:  "foo",
+		Username:   "user",
+		Password:   "secret",
+		ServerURL:  "https://example.com",
+	}
+
+	assert.Equal(t, expected, account)
+}
+
+func TestRedisStorage_Fetch_notFound(t *testing.T) {
+	st, _ := setupRedisTest(t)
+
+	_, err := st.Fetch(context.Background(), "example.com")
+	require.ErrorIs(t, err, storage.ErrDomainNotFound)
+}
+
+func TestRedisStorage_FetchAll(t *testing.T) {
+	st, server := setupRedisTest(t)
+
+	server.HSet(DefaultRedisKey, "example.com", `{"fulldomain":"foo.example.com","subdomain":"foo","username":"user","password":"secret"}`)
+	server.HSet(DefaultRedisKey, "example.org", `{"fulldomain":"bar.example.org","subdomain":"bar","username":"user","password":"secret"}`)
+
+	accounts, err := st.FetchAll(context.Background())
+	require.NoError(t, err)
+
+	expected := map[string]goacmedns.Account{
+		"example.com": {
+			FullDomain: "foo.example.com",
+			SubDomain:  "foo",
+			Username:   "user",
+			Password:   "secret",
+		},
+		"example.org": {
+			FullDomain: "bar.example.org",
+			SubDomain:  "bar",
+			Username:   "user",
+			Password:   "secret",
+		},
+	}
+
+	assert.Equal(t, expected, accounts)
+}
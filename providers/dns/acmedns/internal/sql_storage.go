@@ -0,0 +1,185 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+// DefaultSQLTable the default table of the accounts.
+const DefaultSQLTable = "acme_dns_accounts"
+
+var tableNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+var _ goacmedns.Storage = (*SQLStorage)(nil)
+
+// SQLStorage is an implementation of [acmedns.Storage] with a SQL database.
+type SQLStorage struct {
+	db    *sql.DB
+	table string
+
+	// numbered placeholders ($1, $2, ...) instead of question marks.
+	numbered bool
+}
+
+// NewSQLStorage creates a new [SQLStorage], and creates the table of the accounts if it doesn't exist.
+// The driver must be registered (database/sql).
+func NewSQLStorage(ctx context.Context, driver, dsn, table string) (*SQLStorage, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := NewSQLStorageDB(ctx, db, driver, table)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// NewSQLStorageDB creates a new [SQLStorage] with a database,
+// and creates the table of the accounts if it doesn't exist.
+func NewSQLStorageDB(ctx context.Context, db *sql.DB, driver, table string) (*SQLStorage, error) {
+	if table == "" {
+		table = DefaultSQLTable
+	}
+
+	if !tableNameRe.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name: %q", table)
+	}
+
+	s := &SQLStorage{
+		db:       db,
+		table:    table,
+		numbered: driver == "postgres" || driver == "pgx",
+	}
+
+	_, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+table+" ("+
+		"domain VARCHAR(255) NOT NULL PRIMARY KEY, "+
+		"full_domain VARCHAR(255) NOT NULL, "+
+		"sub_domain VARCHAR(255) NOT NULL, "+
+		"username VARCHAR(255) NOT NULL, "+
+		"password VARCHAR(255) NOT NULL, "+
+		"server_url VARCHAR(255) NOT NULL)")
+	if err != nil {
+		return nil, fmt.Errorf("create table %s: %w", table, err)
+	}
+
+	return s, nil
+}
+
+func (s *SQLStorage) Save(_ context.Context) error {
+	return nil
+}
+
+// Put replaces the account of the domain (in a transaction: the upsert is not portable).
+func (s *SQLStorage) Put(ctx context.Context, domain string, account goacmedns.Account) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sql: begin transaction: %w", err)
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, s.query("DELETE FROM %s WHERE domain = ?"), domain)
+	if err != nil {
+		return fmt.Errorf("sql: delete account: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		s.query("INSERT INTO %s (domain, full_domain, sub_domain, username, password, server_url) VALUES (?, ?, ?, ?, ?, ?)"),
+		domain, account.FullDomain, account.SubDomain, account.Username, account.Password, account.ServerURL)
+	if err != nil {
+		return fmt.Errorf("sql: insert account: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("sql: commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLStorage) Fetch(ctx context.Context, domain string) (goacmedns.Account, error) {
+	row := s.db.QueryRowContext(ctx,
+		s.query("SELECT full_domain, sub_domain, username, password, server_url FROM %s WHERE domain = ?"), domain)
+
+	var account goacmedns.Account
+
+	err := row.Scan(&account.FullDomain, &account.SubDomain, &account.Username, &account.Password, &account.ServerURL)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return goacmedns.Account{}, storage.ErrDomainNotFound
+		}
+
+		return goacmedns.Account{}, fmt.Errorf("sql: fetch account: %w", err)
+	}
+
+	return account, nil
+}
+
+func (s *SQLStorage) FetchAll(ctx context.Context) (map[string]goacmedns.Account, error) {
+	rows, err := s.db.QueryContext(ctx,
+		s.query("SELECT domain, full_domain, sub_domain, username, password, server_url FROM %s"))
+	if err != nil {
+		return nil, fmt.Errorf("sql: fetch accounts: %w", err)
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	mapping := make(map[string]goacmedns.Account)
+
+	for rows.Next() {
+		var domain string
+
+		var account goacmedns.Account
+
+		err = rows.Scan(&domain, &account.FullDomain, &account.SubDomain, &account.Username, &account.Password, &account.ServerURL)
+		if err != nil {
+			return nil, fmt.Errorf("sql: fetch accounts: %w", err)
+		}
+
+		mapping[domain] = account
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("sql: fetch accounts: %w", err)
+	}
+
+	return mapping, nil
+}
+
+// query sets the table, and the placeholders of the driver.
+func (s *SQLStorage) query(format string) string {
+	query := fmt.Sprintf(format, s.table)
+
+	if !s.numbered {
+		return query
+	}
+
+	var b strings.Builder
+
+	var n int
+
+	for _, c := range query {
+		if c != '?' {
+			b.WriteRune(c)
+			continue
+		}
+
+		n++
+		_, _ = fmt.Fprintf(&b, "$%d", n)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSQLTest(t *testing.T, driver string) (*SQLStorage, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = db.Close()
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS acme_dns_accounts (")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	st, err := NewSQLStorageDB(context.Background(), db, driver, "")
+	require.NoError(t, err)
+
+	return st, mock
+}
+
+func TestNewSQLStorageDB_invalidTable(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, err = NewSQLStorageDB(context.Background(), db, "mysql", "accounts; DROP TABLE users")
+	require.EqualError(t, err, `invalid table name: "accounts; DROP TABLE users"`)
+}
+
+func TestSQLStorage_Put(t *testing.T) {
+	testCases := []struct {
+		driver string
+		delete string
+		insert string
+	}{
+		{
+			driver: "mysql",
+			delete: "DELETE FROM acme_dns_accounts WHERE domain = ?",
+			insert: "INSERT INTO acme_dns_accounts (domain, full_domain, sub_domain, username, password, server_url) VALUES (?, ?, ?, ?, ?, ?)",
+		},
+		{
+			driver: "postgres",
+			delete: "DELETE FROM acme_dns_accounts WHERE domain = $1",
+			insert: "INSERT INTO acme_dns_accounts (domain, full_domain, sub_domain, username, password, server_url) VALUES ($1, $2, $3, $4, $5, $6)",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.driver, func(t *testing.T) {
+			st, mock := setupSQLTest(t, test.driver)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(test.delete)).
+				WithArgs("example.com").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(test.insert)).
+				WithArgs("example.com", "foo.example.com", "foo", "user", "secret", "https://example.com").
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			account := goacmedns.Account{
+				FullDomain: "foo.example.com",
+				SubDomain:  "foo",
+				Username:   "user",
+				Password:   "secret",
+				ServerURL:  "https://example.com",
+			}
+
+			err := st.Put(context.Background(), "example.com", account)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSQLStorage_Fetch(t *testing.T) {
+	st, mock := setupSQLTest(t, "postgres")
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT full_domain, sub_domain, username, password, server_url FROM acme_dns_accounts WHERE domain = $1")).
+		WithArgs("example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"full_domain", "sub_domain", "username", "password", "server_url"}).
+			AddRow("foo.example.com", "foo", "user", "secret", "https://example.com"))
+
+	account, err := st.Fetch(context.Background(), "example.com")
+	require.NoError(t, err)
+
+	expected := goacmedns.Account{
+		FullDomain: "foo.example.com",
+		SubDomain:  "foo",
+		Username:   "user",
+		Password:   "secret",
+		ServerURL:  "https://example.com",
+	}
+
+	assert.Equal(t, expected, account)
+}
+
+func TestSQLStorage_Fetch_notFound(t *testing.T) {
+	st, mock := setupSQLTest(t, "mysql")
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT full_domain, sub_domain, username, password, server_url FROM acme_dns_accounts WHERE domain = ?")).
+		WithArgs("example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"full_domain", "sub_domain", "username", "password", "server_url"}))
+
+	_, err := st.Fetch(context.Background(), "example.com")
+	require.ErrorIs(t, err, storage.ErrDomainNotFound)
+}
+
+func TestSQLStorage_FetchAll(t *testing.T) {
+	st, mock := setupSQLTest(t, "mysql")
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT domain, full_domain, sub_domain, username, password, server_url FROM acme_dns_accounts")).
+		WillReturnRows(sqlmock.NewRows([]string{"domain", "full_domain", "sub_domain", "username", "password", "server_url"}).
+			AddRow("example.com", "foo.example.com", "foo", "user", "secret", "").
+			AddRow("example.org", "bar.example.org", "bar", "user", "secret", ""))
+
+	accounts, err := st.FetchAll(context.Background())
+	require.NoError(t, err)
+
+	expected := map[string]goacmedns.Account{
+		"example.com": {
+			FullDomain: "foo.example.com",
+			SubDomain:  "foo",
+			Username:   "user",
+			Password:   "secret",
+		},
+		"example.org": {
+			FullDomain: "bar.example.org",
+			SubDomain:  "bar",
+			Username:   "user",
+			Password:   "secret",
+		},
+	}
+
+	assert.Equal(t, expected, accounts)
+}
@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/providers/dns/internal/errutils"
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+// DefaultVaultMount the default mount of the KV secrets engine.
+const DefaultVaultMount = "secret"
+
+var _ goacmedns.Storage = (*VaultStorage)(nil)
+
+// VaultStorage is an implementation of [acmedns.Storage] with the KV secrets engine (version 2) of Vault.
+// An account is a secret: <mount>/data/<path>/<domain>.
+type VaultStorage struct {
+	client  *http.Client
+	baseURL *url.URL
+	token   string
+	mount   string
+	path    string
+}
+
+// NewVaultStorage creates a new [VaultStorage].
+func NewVaultStorage(addr, token, mount, path string) (*VaultStorage, error) {
+	endpoint, err := url.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if mount == "" {
+		mount = DefaultVaultMount
+	}
+
+	return &VaultStorage{
+		client:  &http.Client{Timeout: 2 * time.Minute},
+		baseURL: endpoint,
+		token:   token,
+		mount:   strings.Trim(mount, "/"),
+		path:    strings.Trim(path, "/"),
+	}, nil
+}
+
+func (s *VaultStorage) Save(_ context.Context) error {
+	return nil
+}
+
+func (s *VaultStorage) Put(ctx context.Context, domain string, account goacmedns.Account) error {
+	endpoint := s.baseURL.JoinPath("v1", s.mount, "data", s.path, domain)
+
+	req, err := newJSONRequest(ctx, http.MethodPost, endpoint, vaultSecret{Data: account})
+	if err != nil {
+		return err
+	}
+
+	return s.do(req, nil)
+}
+
+func (s *VaultStorage) Fetch(ctx context.Context, domain string) (goacmedns.Account, error) {
+	endpoint := s.baseURL.JoinPath("v1", s.mount, "data", s.path, domain)
+
+	req, err := newJSONRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return goacmedns.Account{}, err
+	}
+
+	var result vaultResponse[vaultSecret]
+
+	err = s.do(req, &result)
+	if err != nil {
+		return goacmedns.Account{}, err
+	}
+
+	return result.Data.Data, nil
+}
+
+func (s *VaultStorage) FetchAll(ctx context.Context) (map[string]goacmedns.Account, error) {
+	endpoint := s.baseURL.JoinPath("v1", s.mount, "metadata", s.path)
+
+	req, err := newJSONRequest(ctx, "LIST", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result vaultResponse[vaultKeys]
+
+	err = s.do(req, &result)
+	if err != nil {
+		if errors.Is(err, storage.ErrDomainNotFound) {
+			return map[string]goacmedns.Account{}, nil
+		}
+
+		return nil, err
+	}
+
+	mapping := make(map[string]goacmedns.Account, len(result.Data.Keys))
+
+	for _, domain := range result.Data.Keys {
+		// The folders are not accounts.
+		if strings.HasSuffix(domain, "/") {
+			continue
+		}
+
+		account, err := s.Fetch(ctx, domain)
+		if err != nil {
+			return nil, fmt.Errorf("fetch account %s: %w", domain, err)
+		}
+
+		mapping[domain] = account
+	}
+
+	return mapping, nil
+}
+
+func (s *VaultStorage) do(req *http.Request, result any) error {
+	req.Header.Set("X-Vault-Token", s.token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errutils.NewHTTPDoError(req, err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return storage.ErrDomainNotFound
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return errutils.NewUnexpectedResponseStatusCodeError(req, resp)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errutils.NewReadResponseError(req, resp.StatusCode, err)
+	}
+
+	err = json.Unmarshal(raw, result)
+	if err != nil {
+		return errutils.NewUnmarshalError(req, resp.StatusCode, raw, err)
+	}
+
+	return nil
+}
+
+type vaultResponse[T any] struct {
+	Data T `json:"data"`
+}
+
+type vaultSecret struct {
+	Data goacmedns.Account `json:"data"`
+}
+
+type vaultKeys struct {
+	Keys []string `json:"keys"`
+}
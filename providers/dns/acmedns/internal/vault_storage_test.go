@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupVaultTest(t *testing.T) (*VaultStorage, *http.ServeMux) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	st, err := NewVaultStorage(server.URL, "secret-token", "", "/lego/acme-dns/")
+	require.NoError(t, err)
+
+	st.client = server.Client()
+
+	return st, mux
+}
+
+func checkVaultToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Vault-Token") != "secret-token" {
+			http.Error(rw, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+
+		next(rw, req)
+	}
+}
+
+func TestVaultStorage_Put(t *testing.T) {
+	st, mux := setupVaultTest(t)
+
+	mux.HandleFunc("POST /v1/secret/data/lego/acme-dns/example.com", checkVaultToken(func(rw http.ResponseWriter, req *http.Request) {
+		raw, err := io.ReadAll(req.Body)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		expected := `{"data":{"fulldomain":"foo.example.com","subdomain":"foo","username":"user","password":"secret","server_url":"https://example.com"}}`
+
+		if string(raw) != expected+"\n" && string(raw) != expected {
+			http.Error(rw, "invalid body: "+string(raw), http.StatusBadRequest)
+			return
+		}
+
+		_, _ = rw.Write([]byte(`{"data":{"version":1}}`))
+	}))
+
+	account := goacmedns.Account{
+		FullDomain: "foo.example.com",
+		SubDomain:  "foo",
+		Username:   "user",
+		Password:   "secret",
+		ServerURL:  "https://example.com",
+	}
+
+	err := st.Put(context.Background(), "example.com", account)
+	require.NoError(t, err)
+}
+
+func TestVaultStorage_Fetch(t *testing.T) {
+	st, mux := setupVaultTest(t)
+
+	mux.HandleFunc("GET /v1/secret/data/lego/acme-dns/example.com", checkVaultToken(func(rw http.ResponseWriter, _ *http.Request) {
+		_, _ = rw.Write([]byte(`{"data":{"data":{"fulldomain":"foo.example.com","subdomain":"foo","username":"user","password":"secret","server_url":"https://example.com"},"metadata":{"version":1}}}`))
+	}))
+
+	account, err := st.Fetch(context.Background(), "example.com")
+	require.NoError(t, err)
+
+	expected := goacmedns.Account{
+		FullDomain: "foo.example.com",
+		SubDomain:  "foo",
+		Username:   "user",
+		Password:   "secret",
+		ServerURL:  "https://example.com",
+	}
+
+	assert.Equal(t, expected, account)
+}
+
+func TestVaultStorage_Fetch_notFound(t *testing.T) {
+	st, mux := setupVaultTest(t)
+
+	mux.HandleFunc("GET /v1/secret/data/lego/acme-dns/example.com", checkVaultToken(func(rw http.ResponseWriter, _ *http.Request) {
+		http.Error(rw, `{"errors":[]}`, http.StatusNotFound)
+	}))
+
+	_, err := st.Fetch(context.Background(), "example.com")
+	require.ErrorIs(t, err, storage.ErrDomainNotFound)
+}
+
+func TestVaultStorage_FetchAll(t *testing.T) {
+	st, mux := setupVaultTest(t)
+
+	mux.HandleFunc("LIST /v1/secret/metadata/lego/acme-dns", checkVaultToken(func(rw http.ResponseWriter, _ *http.Request) {
+		_, _ = rw.Write([]byte(`{"data":{"keys":["example.com","folder/"]}}`))
+	}))
+
+	mux.HandleFunc("GET /v1/secret/data/lego/acme-dns/example.com", checkVaultToken(func(rw http.ResponseWriter, _ *http.Request) {
+		_, _ = rw.Write([]byte(`{"data":{"data":{"fulldomain":"foo.example.com","subdomain":"foo","username":"user","password":"secret"}}}`))
+	}))
+
+	accounts, err := st.FetchAll(context.Background())
+	require.NoError(t, err)
+
+	expected := map[string]goacmedns.Account{
+		"example.com": {
+			FullDomain: "foo.example.com",
+			SubDomain:  "foo",
+			Username:   "user",
+			Password:   "secret",
+		},
+	}
+
+	assert.Equal(t, expected, accounts)
+}
+
+func TestVaultStorage_FetchAll_empty(t *testing.T) {
+	st, mux := setupVaultTest(t)
+
+	mux.HandleFunc("LIST /v1/secret/metadata/lego/acme-dns", checkVaultToken(func(rw http.ResponseWriter, _ *http.Request) {
+		http.Error(rw, `{"errors":[]}`, http.StatusNotFound)
+	}))
+
+	accounts, err := st.FetchAll(context.Background())
+	require.NoError(t, err)
+
+	assert.Empty(t, accounts)
+}
@@ -0,0 +1,80 @@
+package acmedns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-acme/lego/v4/providers/dns/acmedns/internal"
+	_ "github.com/go-sql-driver/mysql" // SQL storage: mysql driver.
+	_ "github.com/lib/pq"              // SQL storage: postgres driver.
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+// newStorage creates the storage of the accounts: only one storage can be defined.
+func newStorage(ctx context.Context, config *Config) (goacmedns.Storage, error) {
+	var defined []string
+
+	for name, value := range map[string]string{
+		EnvStoragePath:      config.StoragePath,
+		EnvStorageBaseURL:   config.StorageBaseURL,
+		EnvStorageRedisURL:  config.StorageRedisURL,
+		EnvStorageSQLDSN:    config.StorageSQLDSN,
+		EnvStorageVaultPath: config.StorageVaultPath,
+	} {
+		if value != "" {
+			defined = append(defined, name)
+		}
+	}
+
+	storages := strings.Join([]string{EnvStoragePath, EnvStorageBaseURL, EnvStorageRedisURL, EnvStorageSQLDSN, EnvStorageVaultPath}, ", ")
+
+	switch {
+	case len(defined) == 0:
+		return nil, fmt.Errorf("one storage is required: %s", storages)
+
+	case len(defined) > 1:
+		return nil, fmt.Errorf("only one storage can be used at the same time: %s", storages)
+
+	case config.StoragePath != "":
+		return storage.NewFile(config.StoragePath, 0o600), nil
+
+	case config.StorageBaseURL != "":
+		st, err := internal.NewHTTPStorage(config.StorageBaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("new HTTP storage: %w", err)
+		}
+
+		return st, nil
+
+	case config.StorageRedisURL != "":
+		st, err := internal.NewRedisStorage(config.StorageRedisURL, config.StorageRedisKey)
+		if err != nil {
+			return nil, fmt.Errorf("new Redis storage: %w", err)
+		}
+
+		return st, nil
+
+	case config.StorageSQLDSN != "":
+		st, err := internal.NewSQLStorage(ctx, config.StorageSQLDriver, config.StorageSQLDSN, config.StorageSQLTable)
+		if err != nil {
+			return nil, fmt.Errorf("new SQL storage: %w", err)
+		}
+
+		return st, nil
+
+	default:
+		if config.StorageVaultAddr == "" {
+			return nil, errors.New("new Vault storage: the address of the Vault server is missing")
+		}
+
+		st, err := internal.NewVaultStorage(config.StorageVaultAddr, config.StorageVaultToken, config.StorageVaultMount, config.StorageVaultPath)
+		if err != nil {
+			return nil, fmt.Errorf("new Vault storage: %w", err)
+		}
+
+		return st, nil
+	}
+}
@@ -28,6 +28,7 @@ const (
 	EnvResourceGroup  = envNamespace + "RESOURCE_GROUP"
 	EnvZoneName       = envNamespace + "ZONE_NAME"
 	EnvPrivateZone    = envNamespace + "PRIVATE_ZONE"
+	EnvZoneMapping    = envNamespace + "ZONE_MAPPING"
 
 	EnvTenantID     = envNamespace + "TENANT_ID"
 	EnvClientID     = envNamespace + "CLIENT_ID"
@@ -61,6 +62,10 @@ type Config struct {
 	ResourceGroup  string
 	PrivateZone    bool
 
+	// ZoneMapping the tenants and the subscriptions by zone (ex: example.com), for the zones of other tenants or subscriptions.
+	// The credentials of a zone are the credentials of the authentication method for the tenant of the zone.
+	ZoneMapping map[string]ZoneAccess
+
 	Environment cloud.Configuration
 
 	// optional if using default Azure credentials
@@ -124,6 +129,13 @@ func NewDNSProvider() (*DNSProvider, error) {
 	config.ResourceGroup = env.GetOrFile(EnvResourceGroup)
 	config.PrivateZone = env.GetOrDefaultBool(EnvPrivateZone, false)
 
+	zoneMapping, err := parseZoneMapping(env.GetOrFile(EnvZoneMapping))
+	if err != nil {
+		return nil, fmt.Errorf("azuredns: %s: %w", EnvZoneMapping, err)
+	}
+
+	config.ZoneMapping = zoneMapping
+
 	config.ClientID = env.GetOrFile(EnvClientID)
 	config.ClientSecret = env.GetOrFile(EnvClientSecret)
 	config.TenantID = env.GetOrFile(EnvTenantID)
@@ -207,7 +219,14 @@ func getCredentials(config *Config) (azcore.TokenCredential, error) {
 		return azidentity.NewEnvironmentCredential(&azidentity.EnvironmentCredentialOptions{ClientOptions: clientOptions})
 
 	case "wli":
-		return azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{ClientOptions: clientOptions})
+		// The client ID, the tenant ID, and the federated token file default to the values injected by the AKS workload identity webhook
+		// (AZURE_CLIENT_ID, AZURE_TENANT_ID, and AZURE_FEDERATED_TOKEN_FILE).
+		return azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			ClientOptions: clientOptions,
+			ClientID:      config.ClientID,
+			TenantID:      config.TenantID,
+			TokenFilePath: config.OIDCTokenFilePath,
+		})
 
 	case "msi":
 		cred, err := azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOptions})
@@ -233,7 +252,7 @@ func getCredentials(config *Config) (azcore.TokenCredential, error) {
 		return azidentity.NewClientAssertionCredential(config.TenantID, config.ClientID, getOIDCAssertion(config), &azidentity.ClientAssertionCredentialOptions{ClientOptions: clientOptions})
 
 	default:
-		return azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{ClientOptions: clientOptions})
+		return azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{ClientOptions: clientOptions, TenantID: config.TenantID})
 	}
 }
 
@@ -41,8 +41,9 @@ func getOIDCAssertion(config *Config) func(ctx context.Context) (string, error)
 				return "", fmt.Errorf("azuredns: error retrieving token file with path %s: %w", config.OIDCTokenFilePath, err)
 			}
 
+			// The token file can be used alone (ex: a federated token file rotated by the platform).
 			fileToken := strings.TrimSpace(string(fileTokenRaw))
-			if config.OIDCToken != fileToken {
+			if config.OIDCToken != "" && config.OIDCToken != fileToken {
 				return "", fmt.Errorf("azuredns: token file with path %s does not match token from environment variable", config.OIDCTokenFilePath)
 			}
 
@@ -0,0 +1,55 @@
+package azuredns
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_getOIDCAssertion_tokenFile(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+
+	err := os.WriteFile(tokenFile, []byte("file-token\n"), 0o600)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc        string
+		token       string
+		expected    string
+		expectedErr string
+	}{
+		{
+			desc:     "token file only",
+			expected: "file-token",
+		},
+		{
+			desc:     "same token",
+			token:    "file-token",
+			expected: "file-token",
+		},
+		{
+			desc:        "different tokens",
+			token:       "env-token",
+			expectedErr: "azuredns: token file with path " + tokenFile + " does not match token from environment variable",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			config := &Config{OIDCToken: test.token, OIDCTokenFilePath: tokenFile}
+
+			token, err := getOIDCAssertion(config)(context.Background())
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, token)
+		})
+	}
+}
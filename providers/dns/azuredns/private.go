@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"time"
 
@@ -24,6 +25,9 @@ type DNSProviderPrivate struct {
 	config                *Config
 	credentials           azcore.TokenCredential
 	serviceDiscoveryZones map[string]ServiceDiscoveryZone
+
+	// zoneCredentials the credentials of the zones of the zone mapping.
+	zoneCredentials map[string]azcore.TokenCredential
 }
 
 // NewDNSProviderPrivate creates a DNSProviderPrivate structure.
@@ -33,10 +37,18 @@ func NewDNSProviderPrivate(config *Config, credentials azcore.TokenCredential) (
 		return nil, fmt.Errorf("discover DNS zones: %w", err)
 	}
 
+	mappedZones, zoneCredentials, err := discoverMappedZones(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+
+	maps.Copy(zones, mappedZones)
+
 	return &DNSProviderPrivate{
 		config:                config,
 		credentials:           credentials,
 		serviceDiscoveryZones: zones,
+		zoneCredentials:       zoneCredentials,
 	}, nil
 }
 
@@ -56,7 +68,7 @@ func (d *DNSProviderPrivate) Present(domain, _, keyAuth string) error {
 		return fmt.Errorf("azuredns: %w", err)
 	}
 
-	client, err := newPrivateZoneClient(zone, d.credentials, d.config.Environment)
+	client, err := newPrivateZoneClient(zone, d.getCredentials(zone), d.config.Environment)
 	if err != nil {
 		return fmt.Errorf("azuredns: %w", err)
 	}
@@ -109,7 +121,7 @@ func (d *DNSProviderPrivate) CleanUp(domain, _, keyAuth string) error {
 		return fmt.Errorf("azuredns: %w", err)
 	}
 
-	client, err := newPrivateZoneClient(zone, d.credentials, d.config.Environment)
+	client, err := newPrivateZoneClient(zone, d.getCredentials(zone), d.config.Environment)
 	if err != nil {
 		return fmt.Errorf("azuredns: %w", err)
 	}
@@ -127,6 +139,15 @@ func (d *DNSProviderPrivate) CleanUp(domain, _, keyAuth string) error {
 	return nil
 }
 
+// getCredentials returns the credentials of the zone.
+func (d *DNSProviderPrivate) getCredentials(zone ServiceDiscoveryZone) azcore.TokenCredential {
+	if credentials, ok := d.zoneCredentials[zone.Name]; ok {
+		return credentials
+	}
+
+	return d.credentials
+}
+
 // Checks that azure has a zone for this domain name.
 func (d *DNSProviderPrivate) getHostedZone(fqdn string) (ServiceDiscoveryZone, error) {
 	authZone, err := getZoneName(d.config, fqdn)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"time"
 
@@ -24,6 +25,9 @@ type DNSProviderPublic struct {
 	config                *Config
 	credentials           azcore.TokenCredential
 	serviceDiscoveryZones map[string]ServiceDiscoveryZone
+
+	// zoneCredentials the credentials of the zones of the zone mapping.
+	zoneCredentials map[string]azcore.TokenCredential
 }
 
 // NewDNSProviderPublic creates a DNSProviderPublic structure.
@@ -33,10 +37,18 @@ func NewDNSProviderPublic(config *Config, credentials azcore.TokenCredential) (*
 		return nil, fmt.Errorf("discover DNS zones: %w", err)
 	}
 
+	mappedZones, zoneCredentials, err := discoverMappedZones(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+
+	maps.Copy(zones, mappedZones)
+
 	return &DNSProviderPublic{
 		config:                config,
 		credentials:           credentials,
 		serviceDiscoveryZones: zones,
+		zoneCredentials:       zoneCredentials,
 	}, nil
 }
 
@@ -56,7 +68,7 @@ func (d *DNSProviderPublic) Present(domain, _, keyAuth string) error {
 		return fmt.Errorf("azuredns: %w", err)
 	}
 
-	client, err := newPublicZoneClient(zone, d.credentials, d.config.Environment)
+	client, err := newPublicZoneClient(zone, d.getCredentials(zone), d.config.Environment)
 	if err != nil {
 		return fmt.Errorf("azuredns: %w", err)
 	}
@@ -108,7 +120,7 @@ func (d *DNSProviderPublic) CleanUp(domain, _, keyAuth string) error {
 		return fmt.Errorf("azuredns: %w", err)
 	}
 
-	client, err := newPublicZoneClient(zone, d.credentials, d.config.Environment)
+	client, err := newPublicZoneClient(zone, d.getCredentials(zone), d.config.Environment)
 	if err != nil {
 		return fmt.Errorf("azuredns: %w", err)
 	}
@@ -126,6 +138,15 @@ func (d *DNSProviderPublic) CleanUp(domain, _, keyAuth string) error {
 	return nil
 }
 
+// getCredentials returns the credentials of the zone.
+func (d *DNSProviderPublic) getCredentials(zone ServiceDiscoveryZone) azcore.TokenCredential {
+	if credentials, ok := d.zoneCredentials[zone.Name]; ok {
+		return credentials
+	}
+
+	return d.credentials
+}
+
 // Checks that azure has a zone for this domain name.
 func (d *DNSProviderPublic) getHostedZone(fqdn string) (ServiceDiscoveryZone, error) {
 	authZone, err := getZoneName(d.config, fqdn)
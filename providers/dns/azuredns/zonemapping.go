@@ -0,0 +1,128 @@
+package azuredns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/platform/config/env"
+)
+
+// ZoneAccess the tenant, the subscription, and the resource group of a zone.
+type ZoneAccess struct {
+	// TenantID the tenant of the zone (optional: the tenant of the credentials by default).
+	TenantID string `json:"tenantId"`
+	// SubscriptionID the subscription of the zone.
+	SubscriptionID string `json:"subscriptionId"`
+	// ResourceGroup the resource group of the zone (optional: found by the service discovery by default).
+	ResourceGroup string `json:"resourceGroup"`
+}
+
+// discoverMappedZones finds the zones of the zone mapping, and the credentials of their tenants.
+func discoverMappedZones(ctx context.Context, config *Config) (map[string]ServiceDiscoveryZone, map[string]azcore.TokenCredential, error) {
+	zones := make(map[string]ServiceDiscoveryZone)
+	zoneCredentials := make(map[string]azcore.TokenCredential)
+
+	tenantCredentials := make(map[string]azcore.TokenCredential)
+
+	for name, access := range config.ZoneMapping {
+		name = dns01.UnFqdn(name)
+
+		tenantID := access.TenantID
+		if tenantID == "" {
+			tenantID = config.TenantID
+		}
+
+		credentials, ok := tenantCredentials[tenantID]
+		if !ok {
+			tenantConfig := *config
+			tenantConfig.TenantID = tenantID
+
+			var err error
+
+			credentials, err = getCredentials(&tenantConfig)
+			if err != nil {
+				return nil, nil, fmt.Errorf("zone %s: unable to retrieve valid credentials for the tenant %s: %w", name, tenantID, err)
+			}
+
+			tenantCredentials[tenantID] = credentials
+		}
+
+		zone := ServiceDiscoveryZone{
+			Name:           name,
+			SubscriptionID: access.SubscriptionID,
+			ResourceGroup:  access.ResourceGroup,
+		}
+
+		if zone.ResourceGroup == "" {
+			discoveryConfig := *config
+			discoveryConfig.SubscriptionID = access.SubscriptionID
+
+			discovered, err := discoverDNSZones(ctx, &discoveryConfig, credentials)
+			if err != nil {
+				return nil, nil, fmt.Errorf("zone %s: discover DNS zones: %w", name, err)
+			}
+
+			zone, ok = discovered[name]
+			if !ok {
+				return nil, nil, fmt.Errorf("zone %s: could not find zone (from discovery) in the subscription %s", name, access.SubscriptionID)
+			}
+		}
+
+		zones[name] = zone
+		zoneCredentials[name] = credentials
+	}
+
+	return zones, zoneCredentials, nil
+}
+
+// parseZoneMapping parses the zone mapping: "zone=tenantID:subscriptionID[:resourceGroup];zone2=...", or a JSON object.
+func parseZoneMapping(value string) (map[string]ZoneAccess, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	mapping := make(map[string]ZoneAccess)
+
+	if strings.HasPrefix(value, "{") {
+		err := json.Unmarshal([]byte(value), &mapping)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	} else {
+		rawMapping, err := env.ParseDomainMapping(value)
+		if err != nil {
+			return nil, err
+		}
+
+		for zone, rawAccess := range rawMapping {
+			parts := strings.Split(rawAccess, ":")
+			if len(parts) < 2 || len(parts) > 3 {
+				return nil, fmt.Errorf("invalid zone mapping for the zone %q: the format is 'zone=tenantID:subscriptionID[:resourceGroup]'", zone)
+			}
+
+			access := ZoneAccess{
+				TenantID:       strings.TrimSpace(parts[0]),
+				SubscriptionID: strings.TrimSpace(parts[1]),
+			}
+
+			if len(parts) == 3 {
+				access.ResourceGroup = strings.TrimSpace(parts[2])
+			}
+
+			mapping[zone] = access
+		}
+	}
+
+	for zone, access := range mapping {
+		if zone == "" || access.SubscriptionID == "" {
+			return nil, fmt.Errorf("invalid zone mapping for the zone %q: the zone and the subscription ID are required", zone)
+		}
+	}
+
+	return mapping, nil
+}
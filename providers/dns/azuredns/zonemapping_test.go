@@ -0,0 +1,102 @@
+package azuredns
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseZoneMapping(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		value       string
+		expected    map[string]ZoneAccess
+		expectedErr string
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc:  "tenant and subscription",
+			value: "example.com=tenantA:subA",
+			expected: map[string]ZoneAccess{
+				"example.com": {TenantID: "tenantA", SubscriptionID: "subA"},
+			},
+		},
+		{
+			desc:  "multiple zones",
+			value: "example.com=tenantA:subA:rgA; example.org=:subB",
+			expected: map[string]ZoneAccess{
+				"example.com": {TenantID: "tenantA", SubscriptionID: "subA", ResourceGroup: "rgA"},
+				"example.org": {SubscriptionID: "subB"},
+			},
+		},
+		{
+			desc:  "JSON",
+			value: `{"example.com": {"tenantId": "tenantA", "subscriptionId": "subA", "resourceGroup": "rgA"}}`,
+			expected: map[string]ZoneAccess{
+				"example.com": {TenantID: "tenantA", SubscriptionID: "subA", ResourceGroup: "rgA"},
+			},
+		},
+		{
+			desc:        "missing subscription",
+			value:       "example.com=tenantA",
+			expectedErr: `invalid zone mapping for the zone "example.com": the format is 'zone=tenantID:subscriptionID[:resourceGroup]'`,
+		},
+		{
+			desc:        "empty subscription",
+			value:       "example.com=tenantA:",
+			expectedErr: `invalid zone mapping for the zone "example.com": the zone and the subscription ID are required`,
+		},
+		{
+			desc:        "invalid JSON",
+			value:       `{"example.com": `,
+			expectedErr: "invalid JSON: unexpected end of JSON input",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			mapping, err := parseZoneMapping(test.value)
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, mapping)
+		})
+	}
+}
+
+func Test_discoverMappedZones(t *testing.T) {
+	config := NewDefaultConfig()
+	config.AuthMethod = "env"
+	config.ClientID = "client"
+	config.ClientSecret = "secret"
+	config.TenantID = "tenantA"
+	config.ZoneMapping = map[string]ZoneAccess{
+		"example.com.": {TenantID: "tenantB", SubscriptionID: "subB", ResourceGroup: "rgB"},
+		"example.org":  {TenantID: "tenantB", SubscriptionID: "subC", ResourceGroup: "rgC"},
+		"example.net":  {SubscriptionID: "subD", ResourceGroup: "rgD"},
+	}
+
+	zones, zoneCredentials, err := discoverMappedZones(context.Background(), config)
+	require.NoError(t, err)
+
+	expected := map[string]ServiceDiscoveryZone{
+		"example.com": {Name: "example.com", SubscriptionID: "subB", ResourceGroup: "rgB"},
+		"example.org": {Name: "example.org", SubscriptionID: "subC", ResourceGroup: "rgC"},
+		"example.net": {Name: "example.net", SubscriptionID: "subD", ResourceGroup: "rgD"},
+	}
+
+	assert.Equal(t, expected, zones)
+
+	require.Len(t, zoneCredentials, 3)
+
+	// The zones of the same tenant share the credentials.
+	assert.Same(t, zoneCredentials["example.com"], zoneCredentials["example.org"])
+	assert.NotSame(t, zoneCredentials["example.com"], zoneCredentials["example.net"])
+}
@@ -26,6 +26,7 @@ const (
 	EnvAPIKey       = envNamespace + "API_KEY"
 	EnvDNSAPIToken  = envNamespace + "DNS_API_TOKEN"
 	EnvZoneAPIToken = envNamespace + "ZONE_API_TOKEN"
+	EnvZoneTokens   = envNamespace + "ZONE_TOKENS"
 
 	EnvTTL                = envNamespace + "TTL"
 	EnvPropagationTimeout = envNamespace + "PROPAGATION_TIMEOUT"
@@ -45,6 +46,8 @@ const (
 
 var _ challenge.ProviderTimeout = (*DNSProvider)(nil)
 
+var _ challenge.BatchProvider = (*DNSProvider)(nil)
+
 // Config is used to configure the creation of the DNSProvider.
 type Config struct {
 	AuthEmail string
@@ -53,6 +56,10 @@ type Config struct {
 	AuthToken string
 	ZoneToken string
 
+	// ZoneTokens the API tokens by zone (ex: example.com), with the Zone:Read and DNS:Edit permissions on the zone:
+	// the token of the zone of a challenge is used instead of the other credentials.
+	ZoneTokens map[string]string
+
 	TTL                int
 	PropagationTimeout time.Duration
 	PollingInterval    time.Duration
@@ -76,6 +83,9 @@ type DNSProvider struct {
 	client *metaClient
 	config *Config
 
+	// zoneClients the clients of the zone tokens, by zone.
+	zoneClients map[string]*metaClient
+
 	recordIDs   map[string]string
 	recordIDsMu sync.Mutex
 }
@@ -88,11 +98,19 @@ type DNSProvider struct {
 //
 // For a more paranoid setup, provide CLOUDFLARE_DNS_API_TOKEN and CLOUDFLARE_ZONE_API_TOKEN.
 //
+// For a token per zone, provide CLOUDFLARE_ZONE_TOKENS (ex: "example.com=token1;example.org=token2"),
+// the other credentials are then optional: they are only used for the zones without token.
+//
 // The email and API key should be avoided, if possible.
 // Instead, set up an API token with both Zone:Read and DNS:Edit permission, and pass the CLOUDFLARE_DNS_API_TOKEN environment variable.
 // You can split the Zone:Read and DNS:Edit permissions across multiple API tokens:
 // in this case pass both CLOUDFLARE_ZONE_API_TOKEN and CLOUDFLARE_DNS_API_TOKEN accordingly.
 func NewDNSProvider() (*DNSProvider, error) {
+	zoneTokens, err := env.ParseDomainMapping(env.GetOneWithFallback(EnvZoneTokens, "", env.ParseString, altEnvName(EnvZoneTokens)))
+	if err != nil {
+		return nil, fmt.Errorf("cloudflare: %s: %w", EnvZoneTokens, err)
+	}
+
 	values, err := env.GetWithFallback(
 		[]string{EnvEmail, altEnvEmail},
 		[]string{EnvAPIKey, altEnvName(EnvAPIKey)},
@@ -103,13 +121,14 @@ func NewDNSProvider() (*DNSProvider, error) {
 			[]string{EnvDNSAPIToken, altEnvName(EnvDNSAPIToken)},
 			[]string{EnvZoneAPIToken, altEnvName(EnvZoneAPIToken), EnvDNSAPIToken, altEnvName(EnvDNSAPIToken)},
 		)
-		if errT != nil {
+		if errT != nil && len(zoneTokens) == 0 {
 			//nolint:errorlint
 			return nil, fmt.Errorf("cloudflare: %v or %v", err, errT)
 		}
 	}
 
 	config := NewDefaultConfig()
+	config.ZoneTokens = zoneTokens
 	config.AuthEmail = values[EnvEmail]
 	config.AuthKey = values[EnvAPIKey]
 	config.AuthToken = values[EnvDNSAPIToken]
@@ -128,15 +147,34 @@ func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
 		return nil, fmt.Errorf("cloudflare: invalid TTL, TTL (%d) must be greater than %d", config.TTL, minTTL)
 	}
 
-	client, err := newClient(config)
-	if err != nil {
-		return nil, fmt.Errorf("cloudflare: %w", err)
+	var client *metaClient
+
+	// With zone tokens, the other credentials are optional.
+	if len(config.ZoneTokens) == 0 || config.AuthToken != "" || config.AuthKey != "" || config.AuthEmail != "" {
+		var err error
+
+		client, err = newClient(config)
+		if err != nil {
+			return nil, fmt.Errorf("cloudflare: %w", err)
+		}
+	}
+
+	zoneClients := make(map[string]*metaClient)
+
+	for zone, token := range config.ZoneTokens {
+		zoneClient, err := newClient(&Config{AuthToken: token, HTTPClient: config.HTTPClient})
+		if err != nil {
+			return nil, fmt.Errorf("cloudflare: zone %s: %w", zone, err)
+		}
+
+		zoneClients[env.NormalizeDomain(zone)] = zoneClient
 	}
 
 	return &DNSProvider{
-		client:    client,
-		config:    config,
-		recordIDs: make(map[string]string),
+		client:      client,
+		config:      config,
+		zoneClients: zoneClients,
+		recordIDs:   make(map[string]string),
 	}, nil
 }
 
@@ -155,7 +193,12 @@ func (d *DNSProvider) Present(domain, token, keyAuth string) error {
 		return fmt.Errorf("cloudflare: could not find zone for domain %q: %w", domain, err)
 	}
 
-	zoneID, err := d.client.ZoneIDByName(authZone)
+	client, err := d.getClient(authZone)
+	if err != nil {
+		return fmt.Errorf("cloudflare: %w", err)
+	}
+
+	zoneID, err := client.ZoneIDByName(authZone)
 	if err != nil {
 		return fmt.Errorf("cloudflare: failed to find zone %s: %w", authZone, err)
 	}
@@ -167,7 +210,7 @@ func (d *DNSProvider) Present(domain, token, keyAuth string) error {
 		TTL:     d.config.TTL,
 	}
 
-	response, err := d.client.CreateDNSRecord(context.Background(), zoneID, dnsRecord)
+	response, err := client.CreateDNSRecord(context.Background(), zoneID, dnsRecord)
 	if err != nil {
 		return fmt.Errorf("cloudflare: failed to create TXT record: %w", err)
 	}
@@ -190,7 +233,12 @@ func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 		return fmt.Errorf("cloudflare: could not find zone for domain %q: %w", domain, err)
 	}
 
-	zoneID, err := d.client.ZoneIDByName(authZone)
+	client, err := d.getClient(authZone)
+	if err != nil {
+		return fmt.Errorf("cloudflare: %w", err)
+	}
+
+	zoneID, err := client.ZoneIDByName(authZone)
 	if err != nil {
 		return fmt.Errorf("cloudflare: failed to find zone %s: %w", authZone, err)
 	}
@@ -203,7 +251,7 @@ func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 		return fmt.Errorf("cloudflare: unknown record ID for '%s'", info.EffectiveFQDN)
 	}
 
-	err = d.client.DeleteDNSRecord(context.Background(), zoneID, recordID)
+	err = client.DeleteDNSRecord(context.Background(), zoneID, recordID)
 	if err != nil {
 		log.Printf("cloudflare: failed to delete TXT record: %w", err)
 	}
@@ -216,6 +264,167 @@ func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 	return nil
 }
 
+// PresentBatch creates the TXT records of several challenges:
+// the records of a zone are created in a single operation (batch API).
+func (d *DNSProvider) PresentBatch(challenges []challenge.BatchChallenge) error {
+	zones, err := d.groupByZone(challenges)
+	if err != nil {
+		return fmt.Errorf("cloudflare: %w", err)
+	}
+
+	for i, zone := range zones {
+		err = d.presentZone(zone)
+		if err != nil {
+			// No record must be left: removes the records of the previous zones.
+			for _, created := range zones[:i] {
+				_ = d.cleanUpZone(created)
+			}
+
+			return fmt.Errorf("cloudflare: zone %s: %w", zone.authZone, err)
+		}
+	}
+
+	return nil
+}
+
+// CleanUpBatch removes the TXT records of several challenges:
+// the records of a zone are removed in a single operation (batch API).
+func (d *DNSProvider) CleanUpBatch(challenges []challenge.BatchChallenge) error {
+	zones, err := d.groupByZone(challenges)
+	if err != nil {
+		return fmt.Errorf("cloudflare: %w", err)
+	}
+
+	var errs []error
+
+	for _, zone := range zones {
+		err = d.cleanUpZone(zone)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cloudflare: zone %s: %w", zone.authZone, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d *DNSProvider) presentZone(zone *zoneChallenges) error {
+	batch := batchDNSRecords{}
+
+	for _, info := range zone.infos {
+		batch.Posts = append(batch.Posts, batchDNSRecord{
+			Type:    "TXT",
+			Name:    dns01.UnFqdn(info.EffectiveFQDN),
+			Content: info.Value,
+			TTL:     d.config.TTL,
+		})
+	}
+
+	result, err := zone.client.BatchDNSRecords(context.Background(), zone.zoneID, batch)
+	if err != nil {
+		return fmt.Errorf("failed to create TXT records: %w", err)
+	}
+
+	if len(result.Posts) != len(zone.challenges) {
+		return fmt.Errorf("unexpected number of created records: %d, expected %d", len(result.Posts), len(zone.challenges))
+	}
+
+	d.recordIDsMu.Lock()
+	for i, chlg := range zone.challenges {
+		d.recordIDs[chlg.Token] = result.Posts[i].ID
+	}
+	d.recordIDsMu.Unlock()
+
+	log.Infof("cloudflare: %d new records in the zone %s", len(result.Posts), zone.authZone)
+
+	return nil
+}
+
+func (d *DNSProvider) cleanUpZone(zone *zoneChallenges) error {
+	batch := batchDNSRecords{}
+
+	d.recordIDsMu.Lock()
+	for _, chlg := range zone.challenges {
+		if recordID, ok := d.recordIDs[chlg.Token]; ok {
+			batch.Deletes = append(batch.Deletes, batchDNSRecord{ID: recordID})
+
+			delete(d.recordIDs, chlg.Token)
+		}
+	}
+	d.recordIDsMu.Unlock()
+
+	if len(batch.Deletes) == 0 {
+		return nil
+	}
+
+	_, err := zone.client.BatchDNSRecords(context.Background(), zone.zoneID, batch)
+	if err != nil {
+		return fmt.Errorf("failed to delete TXT records: %w", err)
+	}
+
+	return nil
+}
+
+// zoneChallenges the challenges of a zone.
+type zoneChallenges struct {
+	authZone string
+	zoneID   string
+	client   *metaClient
+
+	challenges []challenge.BatchChallenge
+	infos      []dns01.ChallengeInfo
+}
+
+// groupByZone groups the challenges by zone, in the order of the challenges.
+func (d *DNSProvider) groupByZone(challenges []challenge.BatchChallenge) ([]*zoneChallenges, error) {
+	var zones []*zoneChallenges
+
+	byID := make(map[string]*zoneChallenges)
+
+	for _, chlg := range challenges {
+		info := dns01.GetChallengeInfo(chlg.Domain, chlg.KeyAuth)
+
+		authZone, err := dns01.FindZoneByFqdn(info.EffectiveFQDN)
+		if err != nil {
+			return nil, fmt.Errorf("could not find zone for domain %q: %w", chlg.Domain, err)
+		}
+
+		client, err := d.getClient(authZone)
+		if err != nil {
+			return nil, err
+		}
+
+		zoneID, err := client.ZoneIDByName(authZone)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find zone %s: %w", authZone, err)
+		}
+
+		zone, ok := byID[zoneID]
+		if !ok {
+			zone = &zoneChallenges{authZone: authZone, zoneID: zoneID, client: client}
+			byID[zoneID] = zone
+			zones = append(zones, zone)
+		}
+
+		zone.challenges = append(zone.challenges, chlg)
+		zone.infos = append(zone.infos, info)
+	}
+
+	return zones, nil
+}
+
+// getClient returns the client of the token of the zone, or the default client.
+func (d *DNSProvider) getClient(authZone string) (*metaClient, error) {
+	if client, ok := d.zoneClients[env.NormalizeDomain(authZone)]; ok {
+		return client, nil
+	}
+
+	if d.client == nil {
+		return nil, fmt.Errorf("no API token for the zone %s", dns01.UnFqdn(authZone))
+	}
+
+	return d.client, nil
+}
+
 func altEnvName(v string) string {
 	return strings.ReplaceAll(v, envNamespace, altEnvNamespace)
 }
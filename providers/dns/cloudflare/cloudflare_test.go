@@ -16,10 +16,12 @@ var envTest = tester.NewEnvTest(
 	EnvAPIKey,
 	EnvDNSAPIToken,
 	EnvZoneAPIToken,
+	EnvZoneTokens,
 	altEnvEmail,
 	altEnvName(EnvAPIKey),
 	altEnvName(EnvDNSAPIToken),
-	altEnvName(EnvZoneAPIToken)).
+	altEnvName(EnvZoneAPIToken),
+	altEnvName(EnvZoneTokens)).
 	WithDomain(envDomain)
 
 func TestNewDNSProvider(t *testing.T) {
@@ -48,6 +50,27 @@ func TestNewDNSProvider(t *testing.T) {
 				EnvZoneAPIToken: "abcdef012345",
 			},
 		},
+		{
+			desc: "success zone tokens",
+			envVars: map[string]string{
+				EnvZoneTokens: "example.com=012345abcdef;example.org=abcdef012345",
+			},
+		},
+		{
+			desc: "success zone tokens and API token",
+			envVars: map[string]string{
+				EnvDNSAPIToken: "012345abcdef",
+				EnvZoneTokens:  "example.com=abcdef012345",
+			},
+		},
+		{
+			desc: "invalid zone tokens",
+			envVars: map[string]string{
+				EnvDNSAPIToken: "012345abcdef",
+				EnvZoneTokens:  "example.com",
+			},
+			expected: "cloudflare: CLOUDFLARE_ZONE_TOKENS: invalid entry 1: the format is 'domain=value'",
+		},
 		{
 			desc: "missing credentials",
 			envVars: map[string]string{
@@ -88,7 +111,7 @@ func TestNewDNSProvider(t *testing.T) {
 				require.NoError(t, err)
 				require.NotNil(t, p)
 				assert.NotNil(t, p.config)
-				assert.NotNil(t, p.client)
+				assert.Len(t, p.zoneClients, len(p.config.ZoneTokens))
 			} else {
 				require.EqualError(t, err, test.expected)
 			}
@@ -271,6 +294,39 @@ func TestNewDNSProviderConfig(t *testing.T) {
 	}
 }
 
+func TestDNSProvider_getClient(t *testing.T) {
+	config := NewDefaultConfig()
+	config.ZoneTokens = map[string]string{
+		"example.com":     "abc",
+		"Sub.Example.org": "def",
+	}
+
+	p, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	assert.Nil(t, p.client)
+
+	client, err := p.getClient("example.com.")
+	require.NoError(t, err)
+	assert.Same(t, p.zoneClients["example.com"], client)
+
+	client, err = p.getClient("sub.example.org.")
+	require.NoError(t, err)
+	assert.Same(t, p.zoneClients["sub.example.org"], client)
+
+	_, err = p.getClient("example.net.")
+	require.EqualError(t, err, "no API token for the zone example.net")
+
+	config.AuthToken = "012345abcdef"
+
+	p, err = NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	client, err = p.getClient("example.net.")
+	require.NoError(t, err)
+	assert.Same(t, p.client, client)
+}
+
 func TestLivePresent(t *testing.T) {
 	if !envTest.IsLiveTest() {
 		t.Skip("skipping live test")
@@ -2,6 +2,9 @@ package cloudflare
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"sync"
 
 	"github.com/cloudflare/cloudflare-go"
@@ -71,6 +74,24 @@ func (m *metaClient) DeleteDNSRecord(ctx context.Context, zoneID, recordID strin
 	return m.clientEdit.DeleteDNSRecord(ctx, cloudflare.ZoneIdentifier(zoneID), recordID)
 }
 
+// BatchDNSRecords creates and deletes DNS records in a single operation: the operations of a batch are atomic.
+// https://developers.cloudflare.com/api/resources/dns/subresources/records/methods/batch/
+func (m *metaClient) BatchDNSRecords(ctx context.Context, zoneID string, batch batchDNSRecords) (*batchDNSRecords, error) {
+	resp, err := m.clientEdit.Raw(ctx, http.MethodPost, fmt.Sprintf("/zones/%s/dns_records/batch", zoneID), batch, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result batchDNSRecords
+
+	err = json.Unmarshal(resp.Result, &result)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal the batch response: %w", err)
+	}
+
+	return &result, nil
+}
+
 func (m *metaClient) ZoneIDByName(fdqn string) (string, error) {
 	m.zonesMu.RLock()
 	id := m.zones[fdqn]
@@ -90,3 +111,17 @@ func (m *metaClient) ZoneIDByName(fdqn string) (string, error) {
 	m.zonesMu.Unlock()
 	return id, nil
 }
+
+// batchDNSRecords the operations of a batch, and the result of a batch.
+type batchDNSRecords struct {
+	Deletes []batchDNSRecord `json:"deletes,omitempty"`
+	Posts   []batchDNSRecord `json:"posts,omitempty"`
+}
+
+type batchDNSRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Content string `json:"content,omitempty"`
+	TTL     int    `json:"ttl,omitempty"`
+}
@@ -0,0 +1,139 @@
+package cloudflare
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBatchTest(t *testing.T, handler func(batch batchDNSRecords) (int, batchDNSRecords)) *metaClient {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("POST /zones/zoneA/dns_records/batch", func(rw http.ResponseWriter, req *http.Request) {
+		var batch batchDNSRecords
+
+		err := json.NewDecoder(req.Body).Decode(&batch)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		status, result := handler(batch)
+
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(status)
+
+		_ = json.NewEncoder(rw).Encode(map[string]any{
+			"success": status == http.StatusOK,
+			"errors":  []any{},
+			"result":  result,
+		})
+	})
+
+	client, err := cloudflare.NewWithAPIToken("secret", cloudflare.BaseURL(server.URL))
+	require.NoError(t, err)
+
+	return &metaClient{
+		clientEdit: client,
+		clientRead: client,
+		zones:      make(map[string]string),
+		zonesMu:    &sync.RWMutex{},
+	}
+}
+
+func TestDNSProvider_presentZone(t *testing.T) {
+	var requests []batchDNSRecords
+
+	client := setupBatchTest(t, func(batch batchDNSRecords) (int, batchDNSRecords) {
+		requests = append(requests, batch)
+
+		result := batchDNSRecords{Deletes: batch.Deletes}
+		for i, post := range batch.Posts {
+			post.ID = []string{"id1", "id2"}[i]
+			result.Posts = append(result.Posts, post)
+		}
+
+		return http.StatusOK, result
+	})
+
+	config := NewDefaultConfig()
+	config.AuthToken = "secret"
+
+	p, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	zone := &zoneChallenges{
+		authZone: "example.com.",
+		zoneID:   "zoneA",
+		client:   client,
+		challenges: []challenge.BatchChallenge{
+			{Domain: "example.com", Token: "tokenA", KeyAuth: "keyA"},
+			{Domain: "www.example.com", Token: "tokenB", KeyAuth: "keyB"},
+		},
+		infos: []dns01.ChallengeInfo{
+			{EffectiveFQDN: "_acme-challenge.example.com.", Value: "valueA"},
+			{EffectiveFQDN: "_acme-challenge.www.example.com.", Value: "valueB"},
+		},
+	}
+
+	err = p.presentZone(zone)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"tokenA": "id1", "tokenB": "id2"}, p.recordIDs)
+
+	err = p.cleanUpZone(zone)
+	require.NoError(t, err)
+
+	assert.Empty(t, p.recordIDs)
+
+	expected := []batchDNSRecords{
+		{
+			Posts: []batchDNSRecord{
+				{Type: "TXT", Name: "_acme-challenge.example.com", Content: "valueA", TTL: 120},
+				{Type: "TXT", Name: "_acme-challenge.www.example.com", Content: "valueB", TTL: 120},
+			},
+		},
+		{
+			Deletes: []batchDNSRecord{{ID: "id1"}, {ID: "id2"}},
+		},
+	}
+
+	assert.Equal(t, expected, requests)
+}
+
+func TestDNSProvider_presentZone_error(t *testing.T) {
+	client := setupBatchTest(t, func(batch batchDNSRecords) (int, batchDNSRecords) {
+		return http.StatusBadRequest, batchDNSRecords{}
+	})
+
+	config := NewDefaultConfig()
+	config.AuthToken = "secret"
+
+	p, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	zone := &zoneChallenges{
+		authZone:   "example.com.",
+		zoneID:     "zoneA",
+		client:     client,
+		challenges: []challenge.BatchChallenge{{Domain: "example.com", Token: "tokenA", KeyAuth: "keyA"}},
+		infos:      []dns01.ChallengeInfo{{EffectiveFQDN: "_acme-challenge.example.com.", Value: "valueA"}},
+	}
+
+	err = p.presentZone(zone)
+	require.ErrorContains(t, err, "failed to create TXT records")
+
+	assert.Empty(t, p.recordIDs)
+}
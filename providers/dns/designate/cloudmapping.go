@@ -0,0 +1,51 @@
+package designate
+
+import (
+	"fmt"
+
+	"github.com/go-acme/lego/v4/platform/config/env"
+	"github.com/gophercloud/gophercloud"
+)
+
+// newDomainClients creates the clients of the domains of the cloud mapping (one client by cloud).
+func newDomainClients(cloudMapping map[string]string) (map[string]*gophercloud.ServiceClient, error) {
+	domainClients := make(map[string]*gophercloud.ServiceClient)
+
+	cloudClients := make(map[string]*gophercloud.ServiceClient)
+
+	for domain, cloud := range cloudMapping {
+		domain = env.NormalizeDomain(domain)
+
+		client, ok := cloudClients[cloud]
+		if !ok {
+			opts, region, err := cloudAuthOptions(cloud)
+			if err != nil {
+				return nil, fmt.Errorf("domain %s: cloud %s: %w", domain, cloud, err)
+			}
+
+			client, err = newDNSClient(*opts, region)
+			if err != nil {
+				return nil, fmt.Errorf("domain %s: cloud %s: %w", domain, cloud, err)
+			}
+
+			cloudClients[cloud] = client
+		}
+
+		domainClients[domain] = client
+	}
+
+	return domainClients, nil
+}
+
+// getClient returns the client of the closest domain of the cloud mapping, or the default client.
+func (d *DNSProvider) getClient(domain string) (*gophercloud.ServiceClient, error) {
+	if _, client, ok := env.MatchDomain(d.domainClients, domain); ok {
+		return client, nil
+	}
+
+	if d.client == nil {
+		return nil, fmt.Errorf("no cloud for the domain %s: the domain is not in the cloud mapping, and the default credentials are not defined", env.NormalizeDomain(domain))
+	}
+
+	return d.client, nil
+}
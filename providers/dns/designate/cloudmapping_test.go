@@ -0,0 +1,112 @@
+package designate
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/utils/openstack/clientconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSProvider_getClient(t *testing.T) {
+	defaultClient := &gophercloud.ServiceClient{Type: "default"}
+	clientA := &gophercloud.ServiceClient{Type: "a"}
+	clientB := &gophercloud.ServiceClient{Type: "b"}
+
+	provider := &DNSProvider{
+		client: defaultClient,
+		domainClients: map[string]*gophercloud.ServiceClient{
+			"example.com":     clientA,
+			"sub.example.com": clientB,
+		},
+	}
+
+	testCases := []struct {
+		domain   string
+		expected *gophercloud.ServiceClient
+	}{
+		{domain: "example.com", expected: clientA},
+		{domain: "www.Example.com.", expected: clientA},
+		{domain: "sub.example.com", expected: clientB},
+		{domain: "a.sub.example.com", expected: clientB},
+		{domain: "notexample.com", expected: defaultClient},
+		{domain: "example.org", expected: defaultClient},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.domain, func(t *testing.T) {
+			client, err := provider.getClient(test.domain)
+			require.NoError(t, err)
+
+			assert.Same(t, test.expected, client)
+		})
+	}
+}
+
+func TestDNSProvider_getClient_noDefault(t *testing.T) {
+	provider := &DNSProvider{
+		domainClients: map[string]*gophercloud.ServiceClient{
+			"example.com": {},
+		},
+	}
+
+	_, err := provider.getClient("example.org")
+	require.EqualError(t, err, "no cloud for the domain example.org: the domain is not in the cloud mapping, and the default credentials are not defined")
+}
+
+func TestNewDNSProvider_cloudMapping(t *testing.T) {
+	serverURL := setupTestProvider(t)
+
+	defer envTest.RestoreEnv()
+	envTest.ClearEnv()
+
+	cloud := clientconfig.Cloud{
+		AuthInfo: &clientconfig.AuthInfo{
+			AuthURL:     serverURL + "/v2.0/",
+			Username:    "B",
+			Password:    "C",
+			ProjectName: "E",
+			ProjectID:   "F",
+		},
+		RegionName: "D",
+	}
+
+	envTest.Apply(map[string]string{
+		EnvCloudMapping:       "example.com=cloud-a;example.org=cloud-b;example.net=cloud-a",
+		envOSClientConfigFile: createCloudsYaml(t, map[string]clientconfig.Cloud{"cloud-a": cloud, "cloud-b": cloud}),
+	})
+
+	p, err := NewDNSProvider()
+	require.NoError(t, err)
+
+	assert.Nil(t, p.client)
+	require.Len(t, p.domainClients, 3)
+
+	// The domains of a cloud share the client.
+	assert.Same(t, p.domainClients["example.com"], p.domainClients["example.net"])
+	assert.NotSame(t, p.domainClients["example.com"], p.domainClients["example.org"])
+}
+
+func Test_authOptionsFromEnv_applicationCredential(t *testing.T) {
+	defer envTest.RestoreEnv()
+	envTest.ClearEnv()
+
+	envTest.Apply(map[string]string{
+		EnvAuthURL:       "https://keystone.example.com/v3",
+		EnvAppCredID:     "A",
+		EnvAppCredSecret: "B",
+		EnvProjectID:     "C",
+	})
+
+	opts, err := authOptionsFromEnv()
+	require.NoError(t, err)
+
+	expected := gophercloud.AuthOptions{
+		IdentityEndpoint:            "https://keystone.example.com/v3",
+		ApplicationCredentialID:     "A",
+		ApplicationCredentialSecret: "B",
+	}
+
+	assert.Equal(t, expected, opts)
+}
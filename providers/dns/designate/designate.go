@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"os"
 	"slices"
 	"sync"
 	"time"
@@ -28,7 +27,8 @@ const (
 	EnvPropagationTimeout = envNamespace + "PROPAGATION_TIMEOUT"
 	EnvPollingInterval    = envNamespace + "POLLING_INTERVAL"
 
-	EnvZoneName = envNamespace + "ZONE_NAME"
+	EnvZoneName     = envNamespace + "ZONE_NAME"
+	EnvCloudMapping = envNamespace + "CLOUD_MAPPING"
 
 	envNamespaceClient = "OS_"
 
@@ -53,7 +53,15 @@ type Config struct {
 	PropagationTimeout time.Duration
 	PollingInterval    time.Duration
 	TTL                int
-	opts               gophercloud.AuthOptions
+
+	// RegionName the region of the DNS service (the region of the cloud entry is used with OS_CLOUD).
+	RegionName string
+
+	// CloudMapping the named clouds (clouds.yaml) by domain (ex: example.com), the cloud of a domain is also used for its subdomains.
+	// The other domains use the default credentials.
+	CloudMapping map[string]string
+
+	opts gophercloud.AuthOptions
 }
 
 // NewDefaultConfig returns a default configuration for the DNSProvider.
@@ -63,13 +71,18 @@ func NewDefaultConfig() *Config {
 		TTL:                env.GetOrDefaultInt(EnvTTL, 10),
 		PropagationTimeout: env.GetOrDefaultSecond(EnvPropagationTimeout, 10*time.Minute),
 		PollingInterval:    env.GetOrDefaultSecond(EnvPollingInterval, 10*time.Second),
+		RegionName:         env.GetOrFile(EnvRegionName),
 	}
 }
 
 // DNSProvider implements the challenge.Provider interface.
 type DNSProvider struct {
-	config       *Config
-	client       *gophercloud.ServiceClient
+	config *Config
+	client *gophercloud.ServiceClient
+
+	// domainClients the clients of the domains of the cloud mapping.
+	domainClients map[string]*gophercloud.ServiceClient
+
 	dnsEntriesMu sync.Mutex
 }
 
@@ -77,22 +90,37 @@ type DNSProvider struct {
 // Credentials must be passed in the environment variables:
 // OS_AUTH_URL, OS_USERNAME, OS_PASSWORD, OS_REGION_NAME.
 // Or you can specify OS_CLOUD to read the credentials from the according cloud entry.
+// The application credentials (OS_APPLICATION_CREDENTIAL_*) can be used instead of the username and the password.
+// DESIGNATE_CLOUD_MAPPING defines the cloud entries of some domains.
 func NewDNSProvider() (*DNSProvider, error) {
 	config := NewDefaultConfig()
 
-	val, err := env.Get(EnvCloud)
-	if err == nil {
-		opts, erro := clientconfig.AuthOptions(&clientconfig.ClientOpts{
-			Cloud: val[EnvCloud],
-		})
+	cloudMapping, err := env.ParseDomainMapping(env.GetOrFile(EnvCloudMapping))
+	if err != nil {
+		return nil, fmt.Errorf("designate: %s: %w", EnvCloudMapping, err)
+	}
 
+	config.CloudMapping = cloudMapping
+
+	val, err := env.Get(EnvCloud)
+	switch {
+	case err == nil:
+		opts, region, erro := cloudAuthOptions(val[EnvCloud])
 		if erro != nil {
 			return nil, fmt.Errorf("designate: %w", erro)
 		}
 
 		config.opts = *opts
-	} else {
-		opts, err := openstack.AuthOptionsFromEnv()
+
+		if region != "" {
+			config.RegionName = region
+		}
+
+	case len(config.CloudMapping) > 0 && env.GetOrFile(EnvAuthURL) == "":
+		// Only the domains of the cloud mapping.
+
+	default:
+		opts, err := authOptionsFromEnv()
 		if err != nil {
 			return nil, fmt.Errorf("designate: %w", err)
 		}
@@ -109,19 +137,26 @@ func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
 		return nil, errors.New("designate: the configuration of the DNS provider is nil")
 	}
 
-	provider, err := openstack.AuthenticatedClient(config.opts)
-	if err != nil {
-		return nil, fmt.Errorf("designate: failed to authenticate: %w", err)
+	d := &DNSProvider{config: config}
+
+	// Without default credentials, only the domains of the cloud mapping are managed.
+	if config.opts.IdentityEndpoint != "" || len(config.CloudMapping) == 0 {
+		dnsClient, err := newDNSClient(config.opts, config.RegionName)
+		if err != nil {
+			return nil, fmt.Errorf("designate: %w", err)
+		}
+
+		d.client = dnsClient
 	}
 
-	dnsClient, err := openstack.NewDNSV2(provider, gophercloud.EndpointOpts{
-		Region: os.Getenv("OS_REGION_NAME"),
-	})
+	domainClients, err := newDomainClients(config.CloudMapping)
 	if err != nil {
-		return nil, fmt.Errorf("designate: failed to get DNS provider: %w", err)
+		return nil, fmt.Errorf("designate: %w", err)
 	}
 
-	return &DNSProvider{client: dnsClient, config: config}, nil
+	d.domainClients = domainClients
+
+	return d, nil
 }
 
 // Timeout returns the timeout and interval to use when checking for DNS propagation.
@@ -134,12 +169,17 @@ func (d *DNSProvider) Timeout() (timeout, interval time.Duration) {
 func (d *DNSProvider) Present(domain, token, keyAuth string) error {
 	info := dns01.GetChallengeInfo(domain, keyAuth)
 
+	client, err := d.getClient(domain)
+	if err != nil {
+		return fmt.Errorf("designate: %w", err)
+	}
+
 	zone, err := d.getZoneName(info.EffectiveFQDN)
 	if err != nil {
 		return fmt.Errorf("designate: %w", err)
 	}
 
-	zoneID, err := d.getZoneID(zone)
+	zoneID, err := d.getZoneID(client, zone)
 	if err != nil {
 		return fmt.Errorf("designate: couldn't get zone ID in Present: %w", err)
 	}
@@ -148,7 +188,7 @@ func (d *DNSProvider) Present(domain, token, keyAuth string) error {
 	d.dnsEntriesMu.Lock()
 	defer d.dnsEntriesMu.Unlock()
 
-	existingRecord, err := d.getRecord(zoneID, info.EffectiveFQDN)
+	existingRecord, err := d.getRecord(client, zoneID, info.EffectiveFQDN)
 	if err != nil {
 		return fmt.Errorf("designate: %w", err)
 	}
@@ -159,10 +199,10 @@ func (d *DNSProvider) Present(domain, token, keyAuth string) error {
 			return nil
 		}
 
-		return d.updateRecord(existingRecord, info.Value)
+		return d.updateRecord(client, existingRecord, info.Value)
 	}
 
-	err = d.createRecord(zoneID, info.EffectiveFQDN, info.Value)
+	err = d.createRecord(client, zoneID, info.EffectiveFQDN, info.Value)
 	if err != nil {
 		return fmt.Errorf("designate: %w", err)
 	}
@@ -174,12 +214,17 @@ func (d *DNSProvider) Present(domain, token, keyAuth string) error {
 func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 	info := dns01.GetChallengeInfo(domain, keyAuth)
 
+	client, err := d.getClient(domain)
+	if err != nil {
+		return fmt.Errorf("designate: %w", err)
+	}
+
 	zone, err := d.getZoneName(info.EffectiveFQDN)
 	if err != nil {
 		return fmt.Errorf("designate: %w", err)
 	}
 
-	zoneID, err := d.getZoneID(zone)
+	zoneID, err := d.getZoneID(client, zone)
 	if err != nil {
 		return fmt.Errorf("designate: couldn't get zone ID in CleanUp: %w", err)
 	}
@@ -188,7 +233,7 @@ func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 	d.dnsEntriesMu.Lock()
 	defer d.dnsEntriesMu.Unlock()
 
-	record, err := d.getRecord(zoneID, info.EffectiveFQDN)
+	record, err := d.getRecord(client, zoneID, info.EffectiveFQDN)
 	if err != nil {
 		return fmt.Errorf("designate: couldn't get Record ID in CleanUp: %w", err)
 	}
@@ -198,14 +243,14 @@ func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 		return nil
 	}
 
-	err = recordsets.Delete(d.client, zoneID, record.ID).ExtractErr()
+	err = recordsets.Delete(client, zoneID, record.ID).ExtractErr()
 	if err != nil {
 		return fmt.Errorf("designate: error for %s in CleanUp: %w", info.EffectiveFQDN, err)
 	}
 	return nil
 }
 
-func (d *DNSProvider) createRecord(zoneID, fqdn, value string) error {
+func (d *DNSProvider) createRecord(client *gophercloud.ServiceClient, zoneID, fqdn, value string) error {
 	createOpts := recordsets.CreateOpts{
 		Name:        fqdn,
 		Type:        "TXT",
@@ -214,7 +259,7 @@ func (d *DNSProvider) createRecord(zoneID, fqdn, value string) error {
 		Records:     []string{value},
 	}
 
-	actual, err := recordsets.Create(d.client, zoneID, createOpts).Extract()
+	actual, err := recordsets.Create(client, zoneID, createOpts).Extract()
 	if err != nil {
 		return fmt.Errorf("error for %s in Present while creating record: %w", fqdn, err)
 	}
@@ -226,7 +271,7 @@ func (d *DNSProvider) createRecord(zoneID, fqdn, value string) error {
 	return nil
 }
 
-func (d *DNSProvider) updateRecord(record *recordsets.RecordSet, value string) error {
+func (d *DNSProvider) updateRecord(client *gophercloud.ServiceClient, record *recordsets.RecordSet, value string) error {
 	if slices.Contains(record.Records, value) {
 		log.Printf("skip: the record already exists: %s", value)
 		return nil
@@ -240,12 +285,12 @@ func (d *DNSProvider) updateRecord(record *recordsets.RecordSet, value string) e
 		Records:     values,
 	}
 
-	result := recordsets.Update(d.client, record.ZoneID, record.ID, updateOpts)
+	result := recordsets.Update(client, record.ZoneID, record.ID, updateOpts)
 	return result.Err
 }
 
-func (d *DNSProvider) getZoneID(wanted string) (string, error) {
-	allPages, err := zones.List(d.client, nil).AllPages()
+func (d *DNSProvider) getZoneID(client *gophercloud.ServiceClient, wanted string) (string, error) {
+	allPages, err := zones.List(client, nil).AllPages()
 	if err != nil {
 		return "", err
 	}
@@ -262,8 +307,8 @@ func (d *DNSProvider) getZoneID(wanted string) (string, error) {
 	return "", fmt.Errorf("zone id not found for %s", wanted)
 }
 
-func (d *DNSProvider) getRecord(zoneID, wanted string) (*recordsets.RecordSet, error) {
-	allPages, err := recordsets.ListByZone(d.client, zoneID, nil).AllPages()
+func (d *DNSProvider) getRecord(client *gophercloud.ServiceClient, zoneID, wanted string) (*recordsets.RecordSet, error) {
+	allPages, err := recordsets.ListByZone(client, zoneID, nil).AllPages()
 	if err != nil {
 		return nil, err
 	}
@@ -297,3 +342,52 @@ func (d *DNSProvider) getZoneName(fqdn string) (string, error) {
 
 	return authZone, nil
 }
+
+// authOptionsFromEnv reads the credentials from the environment variables.
+func authOptionsFromEnv() (gophercloud.AuthOptions, error) {
+	opts, err := openstack.AuthOptionsFromEnv()
+	if err != nil {
+		return gophercloud.AuthOptions{}, err
+	}
+
+	// An application credential is bound to a project: Keystone rejects the token requests with a scope.
+	if opts.ApplicationCredentialID != "" || opts.ApplicationCredentialName != "" {
+		opts.TenantID = ""
+		opts.TenantName = ""
+	}
+
+	return opts, nil
+}
+
+// cloudAuthOptions reads the credentials, and the region of a cloud entry (clouds.yaml).
+func cloudAuthOptions(cloud string) (*gophercloud.AuthOptions, string, error) {
+	clientOpts := &clientconfig.ClientOpts{Cloud: cloud}
+
+	opts, err := clientconfig.AuthOptions(clientOpts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	entry, err := clientconfig.GetCloudFromYAML(clientOpts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return opts, entry.RegionName, nil
+}
+
+func newDNSClient(opts gophercloud.AuthOptions, region string) (*gophercloud.ServiceClient, error) {
+	provider, err := openstack.AuthenticatedClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
+
+	dnsClient, err := openstack.NewDNSV2(provider, gophercloud.EndpointOpts{
+		Region: region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DNS provider: %w", err)
+	}
+
+	return dnsClient, nil
+}
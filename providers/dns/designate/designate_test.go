@@ -30,6 +30,7 @@ var envTest = tester.NewEnvTest(
 	EnvTenantName,
 	EnvRegionName,
 	EnvProjectID,
+	EnvCloudMapping,
 	envOSClientConfigFile).
 	WithDomain(envDomain)
 
@@ -100,6 +101,16 @@ func TestNewDNSProvider_fromEnv(t *testing.T) {
 			},
 			expected: "designate: Missing environment variable [OS_APPLICATION_CREDENTIAL_SECRET]",
 		},
+		{
+			desc: "invalid cloud mapping",
+			envVars: map[string]string{
+				EnvAuthURL:      serverURL + "/v2.0/",
+				EnvUsername:     "B",
+				EnvPassword:     "C",
+				EnvCloudMapping: "example.com",
+			},
+			expected: "designate: DESIGNATE_CLOUD_MAPPING: invalid entry 1: the format is 'domain=value'",
+		},
 	}
 
 	for _, test := range testCases {
@@ -196,7 +207,7 @@ func TestNewDNSProvider_fromCloud(t *testing.T) {
 
 			envTest.Apply(map[string]string{
 				EnvCloud:              test.osCloud,
-				envOSClientConfigFile: createCloudsYaml(t, test.osCloud, test.cloud),
+				envOSClientConfigFile: createCloudsYaml(t, map[string]clientconfig.Cloud{test.osCloud: test.cloud}),
 			})
 
 			p, err := NewDNSProvider()
@@ -262,7 +273,7 @@ func TestNewDNSProviderConfig(t *testing.T) {
 }
 
 // createCloudsYaml creates a temporary cloud file for testing purpose.
-func createCloudsYaml(t *testing.T, cloudName string, cloud clientconfig.Cloud) string {
+func createCloudsYaml(t *testing.T, clouds map[string]clientconfig.Cloud) string {
 	t.Helper()
 
 	file, err := os.CreateTemp(t.TempDir(), "lego_test")
@@ -270,13 +281,7 @@ func createCloudsYaml(t *testing.T, cloudName string, cloud clientconfig.Cloud)
 
 	t.Cleanup(func() { _ = file.Close() })
 
-	clouds := clientconfig.Clouds{
-		Clouds: map[string]clientconfig.Cloud{
-			cloudName: cloud,
-		},
-	}
-
-	err = yaml.NewEncoder(file).Encode(&clouds)
+	err = yaml.NewEncoder(file).Encode(&clientconfig.Clouds{Clouds: clouds})
 	require.NoError(t, err)
 
 	return file.Name()
@@ -12,6 +12,7 @@ import (
 
 	"github.com/go-acme/lego/v4/challenge"
 	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/log"
 	"github.com/go-acme/lego/v4/platform/config/env"
 	"github.com/go-acme/lego/v4/providers/dns/digitalocean/internal"
 )
@@ -27,6 +28,9 @@ const (
 	EnvPropagationTimeout = envNamespace + "PROPAGATION_TIMEOUT"
 	EnvPollingInterval    = envNamespace + "POLLING_INTERVAL"
 	EnvHTTPTimeout        = envNamespace + "HTTP_TIMEOUT"
+
+	EnvCleanupStaleAge  = envNamespace + "CLEANUP_STALE_AGE"
+	EnvCleanupStateFile = envNamespace + "CLEANUP_STATE_FILE"
 )
 
 var _ challenge.ProviderTimeout = (*DNSProvider)(nil)
@@ -39,6 +43,11 @@ type Config struct {
 	PropagationTimeout time.Duration
 	PollingInterval    time.Duration
 	HTTPClient         *http.Client
+
+	// StaleRecordAge the age of the stale challenge records removed during the cleanup (disabled if zero).
+	StaleRecordAge time.Duration
+	// StateFile the file of the first time the challenge records have been seen.
+	StateFile string
 }
 
 // NewDefaultConfig returns a default configuration for the DNSProvider.
@@ -51,6 +60,8 @@ func NewDefaultConfig() *Config {
 		HTTPClient: &http.Client{
 			Timeout: env.GetOrDefaultSecond(EnvHTTPTimeout, 30*time.Second),
 		},
+		StaleRecordAge: env.GetOrDefaultSecond(EnvCleanupStaleAge, 0),
+		StateFile:      env.GetOrDefaultString(EnvCleanupStateFile, defaultStateFile()),
 	}
 }
 
@@ -61,6 +72,10 @@ type DNSProvider struct {
 
 	recordIDs   map[string]int
 	recordIDsMu sync.Mutex
+
+	stale *staleRecords
+	// swept the zones already swept.
+	swept sync.Map
 }
 
 // NewDNSProvider returns a DNSProvider instance configured for Digital
@@ -88,6 +103,10 @@ func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
 		return nil, errors.New("digitalocean: credentials missing")
 	}
 
+	if config.StaleRecordAge > 0 && config.StateFile == "" {
+		return nil, errors.New("digitalocean: the state file is required to remove the stale records")
+	}
+
 	client := internal.NewClient(internal.OAuthStaticAccessToken(config.HTTPClient, config.AuthToken))
 
 	if config.BaseURL != "" {
@@ -102,6 +121,7 @@ func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
 		config:    config,
 		client:    client,
 		recordIDs: make(map[string]int),
+		stale:     &staleRecords{path: config.StateFile},
 	}, nil
 }
 
@@ -131,6 +151,13 @@ func (d *DNSProvider) Present(domain, token, keyAuth string) error {
 	d.recordIDs[token] = respData.DomainRecord.ID
 	d.recordIDsMu.Unlock()
 
+	if d.config.StaleRecordAge > 0 {
+		err = d.stale.add(authZone, respData.DomainRecord.ID, time.Now())
+		if err != nil {
+			log.Warnf("digitalocean: %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -143,15 +170,21 @@ func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 		return fmt.Errorf("digitalocean: could not find zone for domain %q: %w", domain, err)
 	}
 
+	ctx := context.Background()
+
 	// get the record's unique ID from when we created it
 	d.recordIDsMu.Lock()
 	recordID, ok := d.recordIDs[token]
 	d.recordIDsMu.Unlock()
 	if !ok {
-		return fmt.Errorf("digitalocean: unknown record ID for '%s'", info.EffectiveFQDN)
+		// The record has been created by another process: the record is found by its name and its value.
+		recordID, err = d.findRecordID(ctx, authZone, info)
+		if err != nil {
+			return fmt.Errorf("digitalocean: %w", err)
+		}
 	}
 
-	err = d.client.RemoveTxtRecord(context.Background(), authZone, recordID)
+	err = d.client.RemoveTxtRecord(ctx, authZone, recordID)
 	if err != nil {
 		return fmt.Errorf("digitalocean: %w", err)
 	}
@@ -161,5 +194,29 @@ func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 	delete(d.recordIDs, token)
 	d.recordIDsMu.Unlock()
 
+	if d.config.StaleRecordAge > 0 {
+		if _, swept := d.swept.LoadOrStore(authZone, struct{}{}); !swept {
+			err = d.sweep(ctx, authZone)
+			if err != nil {
+				log.Warnf("digitalocean: remove the stale records of %s: %v", authZone, err)
+			}
+		}
+	}
+
 	return nil
 }
+
+func (d *DNSProvider) findRecordID(ctx context.Context, authZone string, info dns01.ChallengeInfo) (int, error) {
+	records, err := d.client.GetTxtRecords(ctx, authZone, info.EffectiveFQDN)
+	if err != nil {
+		return 0, fmt.Errorf("get TXT records: %w", err)
+	}
+
+	for _, record := range records {
+		if record.Data == info.Value {
+			return record.ID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown record ID for '%s'", info.EffectiveFQDN)
+}
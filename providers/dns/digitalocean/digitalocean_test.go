@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-acme/lego/v4/platform/tester"
 	"github.com/stretchr/testify/assert"
@@ -77,9 +78,10 @@ func TestNewDNSProvider(t *testing.T) {
 
 func TestNewDNSProviderConfig(t *testing.T) {
 	testCases := []struct {
-		desc      string
-		authToken string
-		expected  string
+		desc           string
+		authToken      string
+		staleRecordAge time.Duration
+		expected       string
 	}{
 		{
 			desc:      "success",
@@ -89,12 +91,23 @@ func TestNewDNSProviderConfig(t *testing.T) {
 			desc:     "missing credentials",
 			expected: "digitalocean: credentials missing",
 		},
+		{
+			desc:           "missing state file",
+			authToken:      "123",
+			staleRecordAge: time.Hour,
+			expected:       "digitalocean: the state file is required to remove the stale records",
+		},
 	}
 
 	for _, test := range testCases {
 		t.Run(test.desc, func(t *testing.T) {
 			config := NewDefaultConfig()
 			config.AuthToken = test.authToken
+			config.StaleRecordAge = test.staleRecordAge
+
+			if test.staleRecordAge > 0 {
+				config.StateFile = ""
+			}
 
 			p, err := NewDNSProviderConfig(config)
 
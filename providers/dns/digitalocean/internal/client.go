@@ -19,6 +19,9 @@ import (
 // DefaultBaseURL default API endpoint.
 const DefaultBaseURL = "https://api.digitalocean.com"
 
+// perPage the maximum number of items by page.
+const perPage = 200
+
 // Client the Digital Ocean API client.
 type Client struct {
 	BaseURL    *url.URL
@@ -53,6 +56,44 @@ func (c *Client) AddTxtRecord(ctx context.Context, zone string, record Record) (
 	return respData, nil
 }
 
+// GetTxtRecords gets the TXT records of the zone (all the pages), only the records of the FQDN if it's not empty.
+func (c *Client) GetTxtRecords(ctx context.Context, zone, fqdn string) ([]Record, error) {
+	var records []Record
+
+	for page := 1; ; page++ {
+		endpoint := c.BaseURL.JoinPath("v2", "domains", dns01.UnFqdn(zone), "records")
+
+		query := endpoint.Query()
+		query.Set("type", "TXT")
+		query.Set("page", strconv.Itoa(page))
+		query.Set("per_page", strconv.Itoa(perPage))
+
+		if fqdn != "" {
+			query.Set("name", dns01.UnFqdn(fqdn))
+		}
+
+		endpoint.RawQuery = query.Encode()
+
+		req, err := newJSONRequest(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result RecordsResponse
+
+		err = c.do(req, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, result.DomainRecords...)
+
+		if result.Links == nil || result.Links.Pages == nil || result.Links.Pages.Next == "" {
+			return records, nil
+		}
+	}
+}
+
 func (c *Client) RemoveTxtRecord(ctx context.Context, zone string, recordID int) error {
 	endpoint := c.BaseURL.JoinPath("v2", "domains", dns01.UnFqdn(zone), "records", strconv.Itoa(recordID))
 
@@ -125,6 +166,11 @@ func parseError(req *http.Request, resp *http.Response) error {
 		return errutils.NewUnexpectedStatusCodeError(req, resp.StatusCode, raw)
 	}
 
+	if resp.StatusCode == http.StatusForbidden {
+		// The custom scopes of the token don't allow the operation.
+		return fmt.Errorf("[status code %d] %w (the token requires the scopes domain:read, domain:create, and domain:delete)", resp.StatusCode, errInfo)
+	}
+
 	return fmt.Errorf("[status code %d] %w", resp.StatusCode, errInfo)
 }
 
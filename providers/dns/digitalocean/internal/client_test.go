@@ -137,3 +137,66 @@ func TestClient_RemoveTxtRecord(t *testing.T) {
 	err := client.RemoveTxtRecord(context.Background(), "example.com", 1234567)
 	require.NoError(t, err)
 }
+
+func TestClient_GetTxtRecords(t *testing.T) {
+	client := setupTest(t, "/v2/domains/example.com/records", func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(rw, fmt.Sprintf("unsupported method: %s", req.Method), http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := req.URL.Query()
+
+		if query.Get("type") != "TXT" || query.Get("per_page") != "200" || query.Get("name") != "" {
+			http.Error(rw, fmt.Sprintf("unexpected query: %s", req.URL.RawQuery), http.StatusBadRequest)
+			return
+		}
+
+		switch query.Get("page") {
+		case "1":
+			writeFixture(rw, "domains-records_GET_page1.json")
+		case "2":
+			writeFixture(rw, "domains-records_GET_page2.json")
+		default:
+			http.Error(rw, fmt.Sprintf("unexpected page: %s", query.Get("page")), http.StatusBadRequest)
+		}
+	})
+
+	records, err := client.GetTxtRecords(context.Background(), "example.com.", "")
+	require.NoError(t, err)
+
+	expected := []Record{
+		{
+			ID:   1234567,
+			Type: "TXT",
+			Name: "_acme-challenge",
+			Data: "w6uP8Tcg6K2QR905Rms8iXTlksL6OD1KOWBxTK7wxPI",
+			TTL:  30,
+		},
+		{
+			ID:   1234568,
+			Type: "TXT",
+			Name: "_acme-challenge.www",
+			Data: "ZpY3ThV8ouIO-vAmBuz6-FYLNnzVeR0QK9ynGwl0CSw",
+			TTL:  30,
+		},
+	}
+
+	assert.Equal(t, expected, records)
+}
+
+func TestClient_GetTxtRecords_forbidden(t *testing.T) {
+	client := setupTest(t, "/v2/domains/example.com/records", func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("name") != "_acme-challenge.example.com" {
+			http.Error(rw, fmt.Sprintf("unexpected query: %s", req.URL.RawQuery), http.StatusBadRequest)
+			return
+		}
+
+		rw.WriteHeader(http.StatusForbidden)
+		_, _ = rw.Write([]byte(`{"id":"Forbidden","message":"You are not authorized to perform this operation"}`))
+	})
+
+	_, err := client.GetTxtRecords(context.Background(), "example.com.", "_acme-challenge.example.com.")
+	require.EqualError(t, err, "[status code 403] Forbidden: You are not authorized to perform this operation "+
+		"(the token requires the scopes domain:read, domain:create, and domain:delete)")
+}
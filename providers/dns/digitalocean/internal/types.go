@@ -7,6 +7,23 @@ type TxtRecordResponse struct {
 	DomainRecord Record `json:"domain_record"`
 }
 
+// RecordsResponse represents a page of records.
+type RecordsResponse struct {
+	DomainRecords []Record `json:"domain_records"`
+	Links         *Links   `json:"links,omitempty"`
+}
+
+type Links struct {
+	Pages *Pages `json:"pages,omitempty"`
+}
+
+type Pages struct {
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
 type Record struct {
 	ID   int    `json:"id,omitempty"`
 	Type string `json:"type,omitempty"`
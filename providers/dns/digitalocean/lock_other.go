@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package digitalocean
+
+// lockFile is not supported on this platform:
+// the updates of the state file are only serialized inside the process.
+func lockFile(_ string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,190 @@
+package digitalocean
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/go-acme/lego/v4/providers/dns/digitalocean/internal"
+	"github.com/go-acme/lego/v4/storage"
+)
+
+// staleRecords the first time the challenge records have been seen, by zone and record ID.
+// DigitalOcean doesn't provide the creation date of the records: the dates are stored in a file shared by the runs.
+// The updates of the file are serialized by a lock file (between the processes) and a mutex (inside the process),
+// and the file is replaced atomically.
+type staleRecords struct {
+	path string
+	mu   sync.Mutex
+}
+
+func (s *staleRecords) add(zone string, id int, date time.Time) error {
+	return s.update(func(seen map[string]time.Time) error {
+		seen[recordKey(zone, id)] = date
+		return nil
+	})
+}
+
+// update loads the dates, calls the function to modify them, and saves the dates, with the state file locked.
+// The dates are saved even if the function returns an error (the function can partially succeed).
+func (s *staleRecords) update(fn func(seen map[string]time.Time) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.MkdirAll(filepath.Dir(s.path), 0o700)
+	if err != nil {
+		return fmt.Errorf("create state file directory: %w", err)
+	}
+
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("lock state file: %w", err)
+	}
+
+	defer unlock()
+
+	seen, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	err = fn(seen)
+
+	return errors.Join(err, s.save(seen))
+}
+
+func (s *staleRecords) load() (map[string]time.Time, error) {
+	seen := make(map[string]time.Time)
+
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return seen, nil
+		}
+
+		return nil, fmt.Errorf("read state file: %w", err)
+	}
+
+	err = json.Unmarshal(raw, &seen)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal state file %s: %w", s.path, err)
+	}
+
+	return seen, nil
+}
+
+func (s *staleRecords) save(seen map[string]time.Time) error {
+	raw, err := json.MarshalIndent(seen, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal state file: %w", err)
+	}
+
+	err = storage.WriteFileAtomic(s.path, raw, 0o600, nil)
+	if err != nil {
+		return fmt.Errorf("write state file: %w", err)
+	}
+
+	return nil
+}
+
+// sweep removes the challenge records of the zone older than the stale record age (the records of the interrupted runs).
+// The age of a record is the age of its first sighting, the records in use by the provider are kept.
+func (d *DNSProvider) sweep(ctx context.Context, authZone string) error {
+	records, err := d.client.GetTxtRecords(ctx, authZone, "")
+	if err != nil {
+		return fmt.Errorf("get TXT records: %w", err)
+	}
+
+	inUse := make(map[int]struct{})
+
+	d.recordIDsMu.Lock()
+	for _, id := range d.recordIDs {
+		inUse[id] = struct{}{}
+	}
+	d.recordIDsMu.Unlock()
+
+	return d.stale.update(func(seen map[string]time.Time) error {
+		return d.sweepRecords(ctx, authZone, records, inUse, seen)
+	})
+}
+
+// sweepRecords removes the stale records, and updates the dates of the first sightings.
+func (d *DNSProvider) sweepRecords(ctx context.Context, authZone string, records []internal.Record, inUse map[int]struct{}, seen map[string]time.Time) error {
+	now := time.Now()
+
+	existing := make(map[string]struct{})
+
+	var errs []error
+
+	for _, record := range records {
+		if record.Type != "TXT" || !isChallengeRecord(record.Name) {
+			continue
+		}
+
+		key := recordKey(authZone, record.ID)
+		existing[key] = struct{}{}
+
+		if _, ok := inUse[record.ID]; ok {
+			continue
+		}
+
+		firstSeen, ok := seen[key]
+		if !ok {
+			seen[key] = now
+			continue
+		}
+
+		if now.Sub(firstSeen) < d.config.StaleRecordAge {
+			continue
+		}
+
+		err := d.client.RemoveTxtRecord(ctx, authZone, record.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("remove record %d: %w", record.ID, err))
+			continue
+		}
+
+		log.Infof("digitalocean: removed the stale record %s (ID %d, first seen %s)", record.Name, record.ID, firstSeen.Format(time.RFC3339))
+
+		delete(seen, key)
+	}
+
+	// Forget the records removed by other means.
+	prefix := dns01.UnFqdn(authZone) + "/"
+
+	for key := range seen {
+		if _, ok := existing[key]; !ok && strings.HasPrefix(key, prefix) {
+			delete(seen, key)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func recordKey(zone string, id int) string {
+	return dns01.UnFqdn(zone) + "/" + strconv.Itoa(id)
+}
+
+// isChallengeRecord reports whether the name (relative to the zone) is the name of a challenge record.
+func isChallengeRecord(name string) bool {
+	return name == "_acme-challenge" || strings.HasPrefix(name, "_acme-challenge.")
+}
+
+func defaultStateFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "lego", "digitalocean-records.json")
+}
@@ -0,0 +1,153 @@
+package digitalocean
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/providers/dns/digitalocean/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSProvider_findRecordID(t *testing.T) {
+	provider, mux := setupTest(t)
+
+	mux.HandleFunc("GET /v2/domains/example.com/records", func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("name") != "_acme-challenge.example.com" {
+			http.Error(rw, "unexpected query: "+req.URL.RawQuery, http.StatusBadRequest)
+			return
+		}
+
+		_ = json.NewEncoder(rw).Encode(internal.RecordsResponse{
+			DomainRecords: []internal.Record{
+				{ID: 1, Type: "TXT", Name: "_acme-challenge", Data: "other"},
+				{ID: 2, Type: "TXT", Name: "_acme-challenge", Data: "value"},
+			},
+		})
+	})
+
+	info := dns01.ChallengeInfo{EffectiveFQDN: "_acme-challenge.example.com.", Value: "value"}
+
+	id, err := provider.findRecordID(context.Background(), "example.com.", info)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, id)
+
+	info.Value = "unknown"
+
+	_, err = provider.findRecordID(context.Background(), "example.com.", info)
+	require.EqualError(t, err, "unknown record ID for '_acme-challenge.example.com.'")
+}
+
+func TestDNSProvider_sweep(t *testing.T) {
+	provider, mux := setupTest(t)
+
+	provider.config.StaleRecordAge = time.Hour
+	provider.stale.path = filepath.Join(t.TempDir(), "state", "records.json")
+
+	mux.HandleFunc("GET /v2/domains/example.com/records", func(rw http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(rw).Encode(internal.RecordsResponse{
+			DomainRecords: []internal.Record{
+				{ID: 1, Type: "TXT", Name: "_acme-challenge", Data: "in use"},
+				{ID: 2, Type: "TXT", Name: "_acme-challenge.www", Data: "stale"},
+				{ID: 3, Type: "TXT", Name: "_acme-challenge", Data: "recent"},
+				{ID: 4, Type: "TXT", Name: "_acme-challenge.api", Data: "unknown"},
+				{ID: 5, Type: "TXT", Name: "@", Data: "v=spf1 -all"},
+			},
+		})
+	})
+
+	var (
+		mu      sync.Mutex
+		deleted []string
+	)
+
+	mux.HandleFunc("DELETE /v2/domains/example.com/records/{id}", func(rw http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		deleted = append(deleted, req.PathValue("id"))
+		mu.Unlock()
+
+		rw.WriteHeader(http.StatusNoContent)
+	})
+
+	provider.recordIDs["token"] = 1
+
+	now := time.Now()
+
+	err := provider.stale.update(func(seen map[string]time.Time) error {
+		seen["example.com/1"] = now.Add(-2 * time.Hour)
+		seen["example.com/2"] = now.Add(-2 * time.Hour)
+		seen["example.com/3"] = now.Add(-time.Minute)
+		seen["example.com/6"] = now.Add(-2 * time.Hour)
+		seen["example.org/123"] = now.Add(-2 * time.Hour)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = provider.sweep(context.Background(), "example.com.")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"2"}, deleted)
+
+	seen, err := provider.stale.load()
+	require.NoError(t, err)
+
+	// The removed records are forgotten, the unknown records are seen for the first time.
+	assert.ElementsMatch(t, []string{"example.com/1", "example.com/3", "example.com/4", "example.org/123"}, keys(seen))
+	assert.WithinDuration(t, now, seen["example.com/4"], time.Minute)
+
+	info, err := os.Stat(provider.stale.path)
+	require.NoError(t, err)
+
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func Test_staleRecords_concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.json")
+
+	// Two instances sharing the state file, as two runs of lego do.
+	stales := []*staleRecords{{path: path}, {path: path}}
+
+	var wg sync.WaitGroup
+
+	for i := range 20 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			assert.NoError(t, stales[i%2].add("example.com.", i, time.Now()))
+		}()
+	}
+
+	wg.Wait()
+
+	seen, err := stales[0].load()
+	require.NoError(t, err)
+
+	assert.Len(t, seen, 20)
+}
+
+func Test_isChallengeRecord(t *testing.T) {
+	assert.True(t, isChallengeRecord("_acme-challenge"))
+	assert.True(t, isChallengeRecord("_acme-challenge.www"))
+	assert.False(t, isChallengeRecord("_acme-challenge-www"))
+	assert.False(t, isChallengeRecord("@"))
+}
+
+func keys[T any](m map[string]T) []string {
+	var result []string
+	for k := range m {
+		result = append(result, k)
+	}
+
+	return result
+}
@@ -2,12 +2,14 @@
 package exec
 
 import (
-	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"sync"
 	"time"
 
 	"github.com/go-acme/lego/v4/challenge"
@@ -20,8 +22,9 @@ import (
 const (
 	envNamespace = "EXEC_"
 
-	EnvPath = envNamespace + "PATH"
-	EnvMode = envNamespace + "MODE"
+	EnvPath           = envNamespace + "PATH"
+	EnvMode           = envNamespace + "MODE"
+	EnvCommandTimeout = envNamespace + "COMMAND_TIMEOUT"
 
 	EnvPropagationTimeout = envNamespace + "PROPAGATION_TIMEOUT"
 	EnvPollingInterval    = envNamespace + "POLLING_INTERVAL"
@@ -32,8 +35,13 @@ var _ challenge.ProviderTimeout = (*DNSProvider)(nil)
 
 // Config Provider configuration.
 type Config struct {
-	Program            string
-	Mode               string
+	Program string
+	// Mode the protocol with the program: "" (FQDN and value as arguments), "RAW" (domain, token, and key authorization as arguments),
+	// or "JSON" (challenge as JSON on stdin, result as JSON on stdout).
+	Mode string
+	// CommandTimeout the maximum duration of a command (0: no timeout).
+	CommandTimeout time.Duration
+
 	PropagationTimeout time.Duration
 	PollingInterval    time.Duration
 	SequenceInterval   time.Duration
@@ -45,12 +53,19 @@ func NewDefaultConfig() *Config {
 		PropagationTimeout: env.GetOrDefaultSecond(EnvPropagationTimeout, dns01.DefaultPropagationTimeout),
 		PollingInterval:    env.GetOrDefaultSecond(EnvPollingInterval, dns01.DefaultPollingInterval),
 		SequenceInterval:   env.GetOrDefaultSecond(EnvSequenceInterval, dns01.DefaultPropagationTimeout),
+		CommandTimeout:     env.GetOrDefaultSecond(EnvCommandTimeout, 0),
 	}
 }
 
 // DNSProvider implements the challenge.Provider interface.
 type DNSProvider struct {
 	config *Config
+
+	// results of the JSON protocol.
+	mu                 sync.Mutex
+	propagationTimeout time.Duration
+	pollingInterval    time.Duration
+	states             map[string]json.RawMessage
 }
 
 // NewDNSProvider returns a new DNS provider which runs the program in the
@@ -75,12 +90,52 @@ func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
 		return nil, errors.New("exec: the configuration is nil")
 	}
 
-	return &DNSProvider{config: config}, nil
+	return &DNSProvider{
+		config: config,
+		states: make(map[string]json.RawMessage),
+	}, nil
 }
 
 // Present creates a TXT record to fulfill the dns-01 challenge.
 func (d *DNSProvider) Present(domain, token, keyAuth string) error {
-	err := d.run(context.Background(), "present", domain, token, keyAuth)
+	return d.present("", domain, token, keyAuth)
+}
+
+// CleanUp removes the TXT record matching the specified parameters.
+func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
+	return d.cleanUp("", domain, token, keyAuth)
+}
+
+// PresentAccount creates a TXT record to fulfill the dns-account-01 challenge.
+// The RAW mode doesn't support the dns-account-01 challenge.
+func (d *DNSProvider) PresentAccount(label, domain, token, keyAuth string) error {
+	if d.config.Mode == "RAW" {
+		return errors.New("exec: the RAW mode doesn't support the dns-account-01 challenge")
+	}
+
+	return d.present(label, domain, token, keyAuth)
+}
+
+// CleanUpAccount removes the TXT record of the dns-account-01 challenge.
+func (d *DNSProvider) CleanUpAccount(label, domain, token, keyAuth string) error {
+	if d.config.Mode == "RAW" {
+		return errors.New("exec: the RAW mode doesn't support the dns-account-01 challenge")
+	}
+
+	return d.cleanUp(label, domain, token, keyAuth)
+}
+
+func (d *DNSProvider) present(label, domain, token, keyAuth string) error {
+	ctx, cancel := d.commandContext()
+	defer cancel()
+
+	var err error
+	if d.config.Mode == "JSON" {
+		err = d.presentJSON(ctx, newRequest("present", label, domain, token, keyAuth))
+	} else {
+		err = d.run(ctx, "present", label, domain, token, keyAuth)
+	}
+
 	if err != nil {
 		return fmt.Errorf("exec: %w", err)
 	}
@@ -88,9 +143,17 @@ func (d *DNSProvider) Present(domain, token, keyAuth string) error {
 	return nil
 }
 
-// CleanUp removes the TXT record matching the specified parameters.
-func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
-	err := d.run(context.Background(), "cleanup", domain, token, keyAuth)
+func (d *DNSProvider) cleanUp(label, domain, token, keyAuth string) error {
+	ctx, cancel := d.commandContext()
+	defer cancel()
+
+	var err error
+	if d.config.Mode == "JSON" {
+		err = d.cleanUpJSON(ctx, newRequest("cleanup", label, domain, token, keyAuth))
+	} else {
+		err = d.run(ctx, "cleanup", label, domain, token, keyAuth)
+	}
+
 	if err != nil {
 		return fmt.Errorf("exec: %w", err)
 	}
@@ -100,8 +163,22 @@ func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 
 // Timeout returns the timeout and interval to use when checking for DNS propagation.
 // Adjusting here to cope with spikes in propagation times.
+// The values suggested by the program (JSON protocol) take precedence over the configuration.
 func (d *DNSProvider) Timeout() (timeout, interval time.Duration) {
-	return d.config.PropagationTimeout, d.config.PollingInterval
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	timeout, interval = d.config.PropagationTimeout, d.config.PollingInterval
+
+	if d.propagationTimeout > 0 {
+		timeout = d.propagationTimeout
+	}
+
+	if d.pollingInterval > 0 {
+		interval = d.pollingInterval
+	}
+
+	return timeout, interval
 }
 
 // Sequential All DNS challenges for this provider will be resolved sequentially.
@@ -110,38 +187,90 @@ func (d *DNSProvider) Sequential() time.Duration {
 	return d.config.SequenceInterval
 }
 
-func (d *DNSProvider) run(ctx context.Context, command, domain, token, keyAuth string) error {
+func (d *DNSProvider) run(ctx context.Context, command, label, domain, token, keyAuth string) error {
 	var args []string
 	if d.config.Mode == "RAW" {
 		args = []string{command, "--", domain, token, keyAuth}
 	} else {
-		info := dns01.GetChallengeInfo(domain, keyAuth)
+		info := challengeInfo(label, domain, keyAuth)
 		args = []string{command, info.EffectiveFQDN, info.Value}
 	}
 
 	cmd := exec.CommandContext(ctx, d.config.Program, args...)
+	// Don't wait for the children of a killed program which still hold the output.
+	cmd.WaitDelay = time.Second
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("create pipe: %w", err)
-	}
+	output := &logWriter{}
+	defer output.Flush()
 
-	cmd.Stderr = cmd.Stdout
+	cmd.Stdout = output
+	cmd.Stderr = output
 
-	err = cmd.Start()
+	err := cmd.Start()
 	if err != nil {
 		return fmt.Errorf("start command: %w", err)
 	}
 
-	scanner := bufio.NewScanner(stdout)
-	for scanner.Scan() {
-		log.Println(scanner.Text())
-	}
-
 	err = cmd.Wait()
 	if err != nil {
-		return fmt.Errorf("wait command: %w", err)
+		return waitError(ctx, err)
 	}
 
 	return nil
 }
+
+func (d *DNSProvider) commandContext() (context.Context, context.CancelFunc) {
+	if d.config.CommandTimeout > 0 {
+		return context.WithTimeout(context.Background(), d.config.CommandTimeout)
+	}
+
+	return context.WithCancel(context.Background())
+}
+
+// logWriter logs the output of the program, line by line.
+type logWriter struct {
+	buf []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	for {
+		line, rest, found := bytes.Cut(w.buf, []byte{'\n'})
+		if !found {
+			break
+		}
+
+		log.Println(string(bytes.TrimSuffix(line, []byte{'\r'})))
+
+		w.buf = rest
+	}
+
+	return len(p), nil
+}
+
+// Flush logs the last line, if any.
+func (w *logWriter) Flush() {
+	if len(w.buf) > 0 {
+		log.Println(string(w.buf))
+		w.buf = nil
+	}
+}
+
+func waitError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("command timed out: %w", err)
+	}
+
+	return fmt.Errorf("wait command: %w", err)
+}
+
+// challengeInfo returns the information of the dns-01 challenge,
+// or of the dns-account-01 challenge when the account label is defined.
+func challengeInfo(label, domain, keyAuth string) dns01.ChallengeInfo {
+	if label == "" {
+		return dns01.GetChallengeInfo(domain, keyAuth)
+	}
+
+	return dns01.GetAccountChallengeInfo(label, domain, keyAuth)
+}
@@ -0,0 +1,148 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// protocolVersion the version of the JSON protocol.
+const protocolVersion = 2
+
+// request the challenge sent to the program on stdin (JSON protocol).
+type request struct {
+	Version int    `json:"version"`
+	Action  string `json:"action"`
+	// Label the account label (dns-account-01 challenge only).
+	Label   string `json:"label,omitempty"`
+	Domain  string `json:"domain"`
+	Token   string `json:"token"`
+	KeyAuth string `json:"keyAuth"`
+	FQDN    string `json:"fqdn"`
+	Value   string `json:"value"`
+
+	// State the state returned by the program when presenting the challenge (cleanup only).
+	State json.RawMessage `json:"state,omitempty"`
+}
+
+// result the result written by the program on stdout (JSON protocol).
+type result struct {
+	// PropagationTimeout the propagation timeout suggested by the program, in seconds.
+	PropagationTimeout int `json:"propagationTimeout,omitempty"`
+	// PollingInterval the polling interval suggested by the program, in seconds.
+	PollingInterval int `json:"pollingInterval,omitempty"`
+	// State an opaque state, handed back to the program at cleanup.
+	State json.RawMessage `json:"state,omitempty"`
+	// Error the error of the program.
+	Error string `json:"error,omitempty"`
+}
+
+func (d *DNSProvider) presentJSON(ctx context.Context, req *request) error {
+	res, err := d.runJSON(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.propagationTimeout = max(d.propagationTimeout, time.Duration(res.PropagationTimeout)*time.Second)
+	d.pollingInterval = max(d.pollingInterval, time.Duration(res.PollingInterval)*time.Second)
+
+	if len(res.State) > 0 {
+		d.states[req.Token] = res.State
+	}
+
+	return nil
+}
+
+func (d *DNSProvider) cleanUpJSON(ctx context.Context, req *request) error {
+	d.mu.Lock()
+	req.State = d.states[req.Token]
+	d.mu.Unlock()
+
+	_, err := d.runJSON(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	delete(d.states, req.Token)
+	d.mu.Unlock()
+
+	return nil
+}
+
+// runJSON runs the program with the action as argument, and the request as JSON on stdin.
+// The stderr of the program is logged, and the stdout is the result.
+func (d *DNSProvider) runJSON(ctx context.Context, req *request) (*result, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, d.config.Program, req.Action)
+	// Don't wait for the children of a killed program which still hold the output.
+	cmd.WaitDelay = time.Second
+
+	cmd.Stdin = bytes.NewReader(payload)
+
+	stdout := new(bytes.Buffer)
+	cmd.Stdout = stdout
+
+	stderr := &logWriter{}
+	defer stderr.Flush()
+
+	cmd.Stderr = stderr
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("start command: %w", err)
+	}
+
+	err = cmd.Wait()
+	if err != nil {
+		return nil, waitError(ctx, err)
+	}
+
+	return parseResult(stdout.Bytes())
+}
+
+func newRequest(action, label, domain, token, keyAuth string) *request {
+	info := challengeInfo(label, domain, keyAuth)
+
+	return &request{
+		Version: protocolVersion,
+		Action:  action,
+		Label:   label,
+		Domain:  domain,
+		Token:   token,
+		KeyAuth: keyAuth,
+		FQDN:    info.EffectiveFQDN,
+		Value:   info.Value,
+	}
+}
+
+func parseResult(raw []byte) (*result, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return &result{}, nil
+	}
+
+	var res result
+
+	err := json.Unmarshal(raw, &res)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal result: %w", err)
+	}
+
+	if res.Error != "" {
+		return nil, errors.New(res.Error)
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,186 @@
+package exec
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createProgram(t *testing.T, script string) string {
+	t.Helper()
+
+	program := filepath.Join(t.TempDir(), "program.sh")
+
+	err := os.WriteFile(program, []byte("#!/bin/sh\n"+script), 0o700)
+	require.NoError(t, err)
+
+	return program
+}
+
+func TestDNSProvider_JSON(t *testing.T) {
+	requests := filepath.Join(t.TempDir(), "requests")
+
+	program := createProgram(t, `
+cat >> "`+requests+`"
+echo >> "`+requests+`"
+echo "log message" >&2
+if [ "$1" = "present" ]; then
+  echo '{"propagationTimeout": 300, "pollingInterval": 10, "state": {"id": "record-1"}}'
+fi
+`)
+
+	provider, err := NewDNSProviderConfig(&Config{
+		Program:            program,
+		Mode:               "JSON",
+		PropagationTimeout: time.Minute,
+		PollingInterval:    time.Second,
+	})
+	require.NoError(t, err)
+
+	err = provider.Present("domain", "token", "keyAuth")
+	require.NoError(t, err)
+
+	timeout, interval := provider.Timeout()
+	assert.Equal(t, 300*time.Second, timeout)
+	assert.Equal(t, 10*time.Second, interval)
+
+	err = provider.CleanUp("domain", "token", "keyAuth")
+	require.NoError(t, err)
+
+	assert.Empty(t, provider.states)
+
+	raw, err := os.ReadFile(requests)
+	require.NoError(t, err)
+
+	var reqs []request
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	for decoder.More() {
+		var req request
+		require.NoError(t, decoder.Decode(&req))
+
+		reqs = append(reqs, req)
+	}
+
+	expected := []request{
+		{
+			Version: 2,
+			Action:  "present",
+			Domain:  "domain",
+			Token:   "token",
+			KeyAuth: "keyAuth",
+			FQDN:    "_acme-challenge.domain.",
+			Value:   "pW9ZKG0xz_PCriK-nCMOjADy9eJcgGWIzkkj2fN4uZM",
+		},
+		{
+			Version: 2,
+			Action:  "cleanup",
+			Domain:  "domain",
+			Token:   "token",
+			KeyAuth: "keyAuth",
+			FQDN:    "_acme-challenge.domain.",
+			Value:   "pW9ZKG0xz_PCriK-nCMOjADy9eJcgGWIzkkj2fN4uZM",
+			State:   json.RawMessage(`{"id":"record-1"}`),
+		},
+	}
+
+	assert.Equal(t, expected, reqs)
+}
+
+func TestDNSProvider_JSON_account(t *testing.T) {
+	requests := filepath.Join(t.TempDir(), "requests")
+
+	program := createProgram(t, `cat >> "`+requests+`"`)
+
+	provider, err := NewDNSProviderConfig(&Config{Program: program, Mode: "JSON"})
+	require.NoError(t, err)
+
+	err = provider.PresentAccount("_ujmmovf2vn55tgye", "domain", "token", "keyAuth")
+	require.NoError(t, err)
+
+	raw, err := os.ReadFile(requests)
+	require.NoError(t, err)
+
+	var req request
+	require.NoError(t, json.Unmarshal(raw, &req))
+
+	expected := request{
+		Version: 2,
+		Action:  "present",
+		Label:   "_ujmmovf2vn55tgye",
+		Domain:  "domain",
+		Token:   "token",
+		KeyAuth: "keyAuth",
+		FQDN:    "_ujmmovf2vn55tgye._acme-challenge.domain.",
+		Value:   "pW9ZKG0xz_PCriK-nCMOjADy9eJcgGWIzkkj2fN4uZM",
+	}
+
+	assert.Equal(t, expected, req)
+}
+
+func TestDNSProvider_PresentAccount_raw(t *testing.T) {
+	provider, err := NewDNSProviderConfig(&Config{Program: "echo", Mode: "RAW"})
+	require.NoError(t, err)
+
+	err = provider.PresentAccount("_ujmmovf2vn55tgye", "domain", "token", "keyAuth")
+	require.EqualError(t, err, "exec: the RAW mode doesn't support the dns-account-01 challenge")
+}
+
+func TestDNSProvider_JSON_error(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		script   string
+		expected string
+	}{
+		{
+			desc:     "error result",
+			script:   `echo '{"error": "zone not found"}'`,
+			expected: "exec: zone not found",
+		},
+		{
+			desc:     "invalid result",
+			script:   `echo 'not JSON'`,
+			expected: "exec: unmarshal result: invalid character 'o' in literal null (expecting 'u')",
+		},
+		{
+			desc:     "exit code",
+			script:   `exit 2`,
+			expected: "exec: wait command: exit status 2",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			provider, err := NewDNSProviderConfig(&Config{
+				Program: createProgram(t, test.script),
+				Mode:    "JSON",
+			})
+			require.NoError(t, err)
+
+			err = provider.Present("domain", "token", "keyAuth")
+			require.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func TestDNSProvider_commandTimeout(t *testing.T) {
+	for _, mode := range []string{"", "RAW", "JSON"} {
+		t.Run(mode, func(t *testing.T) {
+			provider, err := NewDNSProviderConfig(&Config{
+				Program:        createProgram(t, "sleep 10"),
+				Mode:           mode,
+				CommandTimeout: 100 * time.Millisecond,
+			})
+			require.NoError(t, err)
+
+			err = provider.Present("domain", "token", "keyAuth")
+			require.ErrorContains(t, err, "exec: command timed out")
+		})
+	}
+}
@@ -17,9 +17,11 @@ import (
 	"github.com/go-acme/lego/v4/platform/config/env"
 	"github.com/go-acme/lego/v4/platform/wait"
 	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/dns/v1"
 	"google.golang.org/api/googleapi"
+	"google.golang.org/api/impersonate"
 	"google.golang.org/api/option"
 )
 
@@ -27,11 +29,12 @@ import (
 const (
 	envNamespace = "GCE_"
 
-	EnvServiceAccount   = envNamespace + "SERVICE_ACCOUNT"
-	EnvProject          = envNamespace + "PROJECT"
-	EnvZoneID           = envNamespace + "ZONE_ID"
-	EnvAllowPrivateZone = envNamespace + "ALLOW_PRIVATE_ZONE"
-	EnvDebug            = envNamespace + "DEBUG"
+	EnvServiceAccount            = envNamespace + "SERVICE_ACCOUNT"
+	EnvImpersonateServiceAccount = envNamespace + "IMPERSONATE_SERVICE_ACCOUNT"
+	EnvProject                   = envNamespace + "PROJECT"
+	EnvZoneID                    = envNamespace + "ZONE_ID"
+	EnvAllowPrivateZone          = envNamespace + "ALLOW_PRIVATE_ZONE"
+	EnvDebug                     = envNamespace + "DEBUG"
 
 	EnvTTL                = envNamespace + "TTL"
 	EnvPropagationTimeout = envNamespace + "PROPAGATION_TIMEOUT"
@@ -48,6 +51,10 @@ type Config struct {
 	Project            string
 	ZoneID             string
 	AllowPrivateZone   bool
+
+	// ImpersonateServiceAccount the email of a service account impersonated by the credentials.
+	ImpersonateServiceAccount string
+
 	PropagationTimeout time.Duration
 	PollingInterval    time.Duration
 	TTL                int
@@ -60,6 +67,9 @@ func NewDefaultConfig() *Config {
 		Debug:              env.GetOrDefaultBool(EnvDebug, false),
 		ZoneID:             env.GetOrDefaultString(EnvZoneID, ""),
 		AllowPrivateZone:   env.GetOrDefaultBool(EnvAllowPrivateZone, false),
+
+		ImpersonateServiceAccount: env.GetOrDefaultString(EnvImpersonateServiceAccount, ""),
+
 		TTL:                env.GetOrDefaultInt(EnvTTL, dns01.DefaultTTL),
 		PropagationTimeout: env.GetOrDefaultSecond(EnvPropagationTimeout, 180*time.Second),
 		PollingInterval:    env.GetOrDefaultSecond(EnvPollingInterval, 5*time.Second),
@@ -77,6 +87,8 @@ type DNSProvider struct {
 // it can be overridden using the GCE_PROJECT environment variable.
 // A Service Account can be passed in the environment variable: GCE_SERVICE_ACCOUNT
 // or by specifying the keyfile location: GCE_SERVICE_ACCOUNT_FILE.
+// The credentials (a service account key, a workload identity federation configuration, or the Application Default Credentials)
+// can impersonate a service account: GCE_IMPERSONATE_SERVICE_ACCOUNT.
 func NewDNSProvider() (*DNSProvider, error) {
 	// Use a service account file if specified via environment variable.
 	if saKey := env.GetOrFile(EnvServiceAccount); saKey != "" {
@@ -95,13 +107,26 @@ func NewDNSProviderCredentials(project string) (*DNSProvider, error) {
 		return nil, errors.New("googlecloud: project name missing")
 	}
 
+	config := NewDefaultConfig()
+	config.Project = project
+
+	if config.ImpersonateServiceAccount != "" {
+		// The base credentials are the Application Default Credentials.
+		client, err := newImpersonatedClient(context.Background(), config.ImpersonateServiceAccount)
+		if err != nil {
+			return nil, fmt.Errorf("googlecloud: %w", err)
+		}
+
+		config.HTTPClient = client
+
+		return NewDNSProviderConfig(config)
+	}
+
 	client, err := google.DefaultClient(context.Background(), dns.NdevClouddnsReadwriteScope)
 	if err != nil {
 		return nil, fmt.Errorf("googlecloud: unable to get Google Cloud client: %w", err)
 	}
 
-	config := NewDefaultConfig()
-	config.Project = project
 	config.HTTPClient = client
 
 	return NewDNSProviderConfig(config)
@@ -109,6 +134,8 @@ func NewDNSProviderCredentials(project string) (*DNSProvider, error) {
 
 // NewDNSProviderServiceAccountKey uses the supplied service account JSON
 // to return a DNSProvider instance configured for Google Cloud DNS.
+// The JSON can also be a workload identity federation configuration (external account),
+// the project must then be defined with GCE_PROJECT.
 func NewDNSProviderServiceAccountKey(saKey []byte) (*DNSProvider, error) {
 	if len(saKey) == 0 {
 		return nil, errors.New("googlecloud: Service Account is missing")
@@ -124,20 +151,32 @@ func NewDNSProviderServiceAccountKey(saKey []byte) (*DNSProvider, error) {
 		}
 		err := json.Unmarshal(saKey, &datJSON)
 		if err != nil || datJSON.ProjectID == "" {
-			return nil, errors.New("googlecloud: project ID not found in Google Cloud Service Account file")
+			return nil, fmt.Errorf("googlecloud: project ID not found in Google Cloud Service Account file (it can be defined with %s)", EnvProject)
 		}
 		project = datJSON.ProjectID
 	}
 
-	conf, err := google.JWTConfigFromJSON(saKey, dns.NdevClouddnsReadwriteScope)
+	config := NewDefaultConfig()
+	config.Project = project
+
+	if config.ImpersonateServiceAccount != "" {
+		client, err := newImpersonatedClient(context.Background(), config.ImpersonateServiceAccount, option.WithCredentialsJSON(saKey))
+		if err != nil {
+			return nil, fmt.Errorf("googlecloud: %w", err)
+		}
+
+		config.HTTPClient = client
+
+		return NewDNSProviderConfig(config)
+	}
+
+	// Supports the service account keys, and the workload identity federation configurations.
+	creds, err := google.CredentialsFromJSON(context.Background(), saKey, dns.NdevClouddnsReadwriteScope)
 	if err != nil {
 		return nil, fmt.Errorf("googlecloud: unable to acquire config: %w", err)
 	}
-	client := conf.Client(context.Background())
 
-	config := NewDefaultConfig()
-	config.Project = project
-	config.HTTPClient = client
+	config.HTTPClient = oauth2.NewClient(context.Background(), creds.TokenSource)
 
 	return NewDNSProviderConfig(config)
 }
@@ -392,6 +431,20 @@ func mustUnquote(raw string) string {
 	return clean
 }
 
+// newImpersonatedClient creates a client impersonating the service account,
+// the base credentials are defined by the options, or are the Application Default Credentials.
+func newImpersonatedClient(ctx context.Context, serviceAccount string, opts ...option.ClientOption) (*http.Client, error) {
+	ts, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+		TargetPrincipal: serviceAccount,
+		Scopes:          []string{dns.NdevClouddnsReadwriteScope},
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to impersonate the service account %s: %w", serviceAccount, err)
+	}
+
+	return oauth2.NewClient(ctx, ts), nil
+}
+
 func autodetectProjectID(ctx context.Context) string {
 	if pid, err := metadata.ProjectIDWithContext(ctx); err == nil {
 		return pid
@@ -30,7 +30,8 @@ var envTest = tester.NewEnvTest(
 	envServiceAccountFile,
 	envGoogleApplicationCredentials,
 	envMetadataHost,
-	EnvServiceAccount).
+	EnvServiceAccount,
+	EnvImpersonateServiceAccount).
 	WithDomain(envDomain).
 	WithLiveTestExtra(func() bool {
 		_, err := google.DefaultClient(context.Background(), dns.NdevClouddnsReadwriteScope)
@@ -79,6 +80,29 @@ func TestNewDNSProvider(t *testing.T) {
 				EnvServiceAccount: `{"project_id": "A","type": "service_account","client_email": "foo@bar.com","private_key_id": "pki","private_key": "pk","token_uri": "/token","client_secret": "secret","client_id": "C","refresh_token": "D"}`,
 			},
 		},
+		{
+			desc: "success key with impersonation",
+			envVars: map[string]string{
+				EnvProject:                   "",
+				EnvServiceAccount:            `{"project_id": "A","type": "service_account","client_email": "foo@bar.com","private_key_id": "pki","private_key": "pk","token_uri": "/token","client_secret": "secret","client_id": "C","refresh_token": "D"}`,
+				EnvImpersonateServiceAccount: "lego@A.iam.gserviceaccount.com",
+			},
+		},
+		{
+			desc: "success workload identity federation",
+			envVars: map[string]string{
+				EnvProject:        "A",
+				EnvServiceAccount: `{"type": "external_account","audience": "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/provider","subject_token_type": "urn:ietf:params:oauth:token-type:jwt","token_url": "https://sts.googleapis.com/v1/token","credential_source": {"file": "/var/run/secrets/token"}}`,
+			},
+		},
+		{
+			desc: "workload identity federation without project",
+			envVars: map[string]string{
+				EnvProject:        "",
+				EnvServiceAccount: `{"type": "external_account","audience": "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/provider","subject_token_type": "urn:ietf:params:oauth:token-type:jwt","token_url": "https://sts.googleapis.com/v1/token","credential_source": {"file": "/var/run/secrets/token"}}`,
+			},
+			expected: "googlecloud: project ID not found in Google Cloud Service Account file (it can be defined with GCE_PROJECT)",
+		},
 	}
 
 	for _, test := range testCases {
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/go-acme/lego/v4/challenge"
@@ -21,6 +22,9 @@ const (
 	EnvAPIKey    = envNamespace + "API_KEY"
 	EnvAPISecret = envNamespace + "API_SECRET"
 
+	EnvAPIURL  = envNamespace + "API_URL"
+	EnvSandbox = envNamespace + "SANDBOX"
+
 	EnvTTL                = envNamespace + "TTL"
 	EnvPropagationTimeout = envNamespace + "PROPAGATION_TIMEOUT"
 	EnvPollingInterval    = envNamespace + "POLLING_INTERVAL"
@@ -33,6 +37,7 @@ var _ challenge.ProviderTimeout = (*DNSProvider)(nil)
 
 // Config is used to configure the creation of the DNSProvider.
 type Config struct {
+	BaseURL            string
 	APIKey             string
 	APISecret          string
 	PropagationTimeout time.Duration
@@ -43,7 +48,13 @@ type Config struct {
 
 // NewDefaultConfig returns a default configuration for the DNSProvider.
 func NewDefaultConfig() *Config {
+	baseURL := internal.DefaultBaseURL
+	if env.GetOrDefaultBool(EnvSandbox, false) {
+		baseURL = internal.SandboxBaseURL
+	}
+
 	return &Config{
+		BaseURL:            env.GetOrDefaultString(EnvAPIURL, baseURL),
 		TTL:                env.GetOrDefaultInt(EnvTTL, minTTL),
 		PropagationTimeout: env.GetOrDefaultSecond(EnvPropagationTimeout, 120*time.Second),
 		PollingInterval:    env.GetOrDefaultSecond(EnvPollingInterval, dns01.DefaultPollingInterval),
@@ -91,6 +102,14 @@ func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
 
 	client := internal.NewClient(config.APIKey, config.APISecret)
 
+	if config.BaseURL != "" {
+		var err error
+		client.BaseURL, err = url.Parse(config.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("godaddy: %w", err)
+		}
+	}
+
 	if config.HTTPClient != nil {
 		client.HTTPClient = config.HTTPClient
 	}
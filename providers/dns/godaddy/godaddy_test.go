@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -11,7 +12,9 @@ const envDomain = envNamespace + "DOMAIN"
 
 var envTest = tester.NewEnvTest(
 	EnvAPIKey,
-	EnvAPISecret).
+	EnvAPISecret,
+	EnvAPIURL,
+	EnvSandbox).
 	WithDomain(envDomain)
 
 func TestNewDNSProvider(t *testing.T) {
@@ -73,6 +76,52 @@ func TestNewDNSProvider(t *testing.T) {
 	}
 }
 
+func TestNewDNSProvider_baseURL(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		envVars  map[string]string
+		expected string
+	}{
+		{
+			desc: "sandbox",
+			envVars: map[string]string{
+				EnvSandbox: "true",
+			},
+			expected: "https://api.ote-godaddy.com",
+		},
+		{
+			desc: "API URL",
+			envVars: map[string]string{
+				EnvSandbox: "true",
+				EnvAPIURL:  "https://godaddy.example.com",
+			},
+			expected: "https://godaddy.example.com",
+		},
+		{
+			desc:     "production",
+			envVars:  map[string]string{},
+			expected: "https://api.godaddy.com",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			defer envTest.RestoreEnv()
+			envTest.ClearEnv()
+
+			test.envVars[EnvAPIKey] = "123"
+			test.envVars[EnvAPISecret] = "456"
+
+			envTest.Apply(test.envVars)
+
+			p, err := NewDNSProvider()
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, p.client.BaseURL.String())
+		})
+	}
+}
+
 func TestNewDNSProviderConfig(t *testing.T) {
 	testCases := []struct {
 		desc      string
@@ -11,10 +11,19 @@ import (
 	"time"
 
 	"github.com/go-acme/lego/v4/providers/dns/internal/errutils"
+	"github.com/go-acme/lego/v4/providers/dns/internal/ratelimit"
 )
 
-// DefaultBaseURL represents the API endpoint to call.
-const DefaultBaseURL = "https://api.godaddy.com"
+// Default API endpoints.
+const (
+	DefaultBaseURL = "https://api.godaddy.com"
+	// SandboxBaseURL the OTE (Operational Testing Environment) endpoint.
+	SandboxBaseURL = "https://api.ote-godaddy.com"
+)
+
+// The API allows 60 requests per minute, per API key.
+// https://developer.godaddy.com/getstarted#terms
+var rateLimits = []ratelimit.Window{ratelimit.PerMinute(60)}
 
 const authorizationHeader = "Authorization"
 
@@ -22,7 +31,9 @@ type Client struct {
 	apiKey    string
 	apiSecret string
 
-	baseURL    *url.URL
+	rateLimiter *ratelimit.Limiter
+
+	BaseURL    *url.URL
 	HTTPClient *http.Client
 }
 
@@ -30,17 +41,18 @@ func NewClient(apiKey string, apiSecret string) *Client {
 	baseURL, _ := url.Parse(DefaultBaseURL)
 
 	return &Client{
-		apiKey:     apiKey,
-		apiSecret:  apiSecret,
-		baseURL:    baseURL,
-		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+		apiKey:      apiKey,
+		apiSecret:   apiSecret,
+		rateLimiter: ratelimit.Shared("godaddy:"+apiKey, rateLimits...),
+		BaseURL:     baseURL,
+		HTTPClient:  &http.Client{Timeout: 5 * time.Second},
 	}
 }
 
 // GetRecords retrieves DNS Records for the specified Domain.
 // https://developer.godaddy.com/doc/endpoint/domains#/v1/recordGet
 func (c *Client) GetRecords(ctx context.Context, domainZone, rType, recordName string) ([]DNSRecord, error) {
-	endpoint := c.baseURL.JoinPath("v1", "domains", domainZone, "records", rType, recordName)
+	endpoint := c.BaseURL.JoinPath("v1", "domains", domainZone, "records", rType, recordName)
 
 	req, err := newJSONRequest(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
@@ -59,7 +71,7 @@ func (c *Client) GetRecords(ctx context.Context, domainZone, rType, recordName s
 // UpdateTxtRecords replaces all DNS Records for the specified Domain with the specified Type.
 // https://developer.godaddy.com/doc/endpoint/domains#/v1/recordReplaceType
 func (c *Client) UpdateTxtRecords(ctx context.Context, records []DNSRecord, domainZone, recordName string) error {
-	endpoint := c.baseURL.JoinPath("v1", "domains", domainZone, "records", "TXT", recordName)
+	endpoint := c.BaseURL.JoinPath("v1", "domains", domainZone, "records", "TXT", recordName)
 
 	req, err := newJSONRequest(ctx, http.MethodPut, endpoint, records)
 	if err != nil {
@@ -72,7 +84,7 @@ func (c *Client) UpdateTxtRecords(ctx context.Context, records []DNSRecord, doma
 // DeleteTxtRecords deletes all DNS Records for the specified Domain with the specified Type and Name.
 // https://developer.godaddy.com/doc/endpoint/domains#/v1/recordDeleteTypeName
 func (c *Client) DeleteTxtRecords(ctx context.Context, domainZone, recordName string) error {
-	endpoint := c.baseURL.JoinPath("v1", "domains", domainZone, "records", "TXT", recordName)
+	endpoint := c.BaseURL.JoinPath("v1", "domains", domainZone, "records", "TXT", recordName)
 
 	req, err := newJSONRequest(ctx, http.MethodDelete, endpoint, nil)
 	if err != nil {
@@ -85,6 +97,11 @@ func (c *Client) DeleteTxtRecords(ctx context.Context, domainZone, recordName st
 func (c *Client) do(req *http.Request, result any) error {
 	req.Header.Set(authorizationHeader, fmt.Sprintf("sso-key %s:%s", c.apiKey, c.apiSecret))
 
+	err := c.rateLimiter.Wait(req.Context())
+	if err != nil {
+		return fmt.Errorf("rate limiter: %w", err)
+	}
+
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return errutils.NewHTTPDoError(req, err)
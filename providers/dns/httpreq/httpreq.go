@@ -4,11 +4,18 @@ package httpreq
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/go-acme/lego/v4/challenge"
@@ -26,11 +33,22 @@ const (
 	EnvUsername = envNamespace + "USERNAME"
 	EnvPassword = envNamespace + "PASSWORD"
 
+	EnvHMACSecret  = envNamespace + "HMAC_SECRET"
+	EnvTLSCertFile = envNamespace + "TLS_CERT_FILE"
+	EnvTLSKeyFile  = envNamespace + "TLS_KEY_FILE"
+	EnvTLSCAFile   = envNamespace + "TLS_CA_FILE"
+
 	EnvPropagationTimeout = envNamespace + "PROPAGATION_TIMEOUT"
 	EnvPollingInterval    = envNamespace + "POLLING_INTERVAL"
 	EnvHTTPTimeout        = envNamespace + "HTTP_TIMEOUT"
 )
 
+// Headers of the HMAC signature.
+const (
+	TimestampHeader = "X-Lego-Timestamp"
+	SignatureHeader = "X-Lego-Signature"
+)
+
 var _ challenge.ProviderTimeout = (*DNSProvider)(nil)
 
 type message struct {
@@ -46,10 +64,20 @@ type messageRaw struct {
 
 // Config is used to configure the creation of the DNSProvider.
 type Config struct {
-	Endpoint           *url.URL
-	Mode               string
-	Username           string
-	Password           string
+	Endpoint *url.URL
+	Mode     string
+	Username string
+	Password string
+
+	// HMACSecret the shared secret of the HMAC-SHA256 signature of the requests.
+	HMACSecret string
+
+	// TLSCertFile and TLSKeyFile the client certificate (mTLS).
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSCAFile the CA certificates of the server.
+	TLSCAFile string
+
 	PropagationTimeout time.Duration
 	PollingInterval    time.Duration
 	HTTPClient         *http.Client
@@ -69,6 +97,7 @@ func NewDefaultConfig() *Config {
 // DNSProvider implements the challenge.Provider interface.
 type DNSProvider struct {
 	config *Config
+	client *http.Client
 }
 
 // NewDNSProvider returns a DNSProvider instance.
@@ -87,6 +116,10 @@ func NewDNSProvider() (*DNSProvider, error) {
 	config.Mode = env.GetOrFile(EnvMode)
 	config.Username = env.GetOrFile(EnvUsername)
 	config.Password = env.GetOrFile(EnvPassword)
+	config.HMACSecret = env.GetOrFile(EnvHMACSecret)
+	config.TLSCertFile = env.GetOrDefaultString(EnvTLSCertFile, "")
+	config.TLSKeyFile = env.GetOrDefaultString(EnvTLSKeyFile, "")
+	config.TLSCAFile = env.GetOrDefaultString(EnvTLSCAFile, "")
 	config.Endpoint = endpoint
 	return NewDNSProviderConfig(config)
 }
@@ -101,7 +134,18 @@ func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
 		return nil, errors.New("httpreq: the endpoint is missing")
 	}
 
-	return &DNSProvider{config: config}, nil
+	client := config.HTTPClient
+
+	if config.TLSCertFile != "" || config.TLSKeyFile != "" || config.TLSCAFile != "" {
+		var err error
+
+		client, err = newTLSClient(config)
+		if err != nil {
+			return nil, fmt.Errorf("httpreq: %w", err)
+		}
+	}
+
+	return &DNSProvider{config: config, client: client}, nil
 }
 
 // Timeout returns the timeout and interval to use when checking for DNS propagation.
@@ -193,7 +237,14 @@ func (d *DNSProvider) doPost(ctx context.Context, uri string, msg any) error {
 		req.SetBasicAuth(d.config.Username, d.config.Password)
 	}
 
-	resp, err := d.config.HTTPClient.Do(req)
+	if d.config.HMACSecret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, "sha256="+Sign(d.config.HMACSecret, timestamp, reqBody.Bytes()))
+	}
+
+	resp, err := d.client.Do(req)
 	if err != nil {
 		return errutils.NewHTTPDoError(req, err)
 	}
@@ -206,3 +257,62 @@ func (d *DNSProvider) doPost(ctx context.Context, uri string, msg any) error {
 
 	return nil
 }
+
+// Sign computes the HMAC-SHA256 signature (hex) of a request: the timestamp, a dot, and the body.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newTLSClient returns a copy of the HTTP client, with the client certificate and the CA certificates in its transport.
+// The HTTP client of the configuration is not modified.
+func newTLSClient(config *Config) (*http.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.TLSCertFile != "" || config.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if config.TLSCAFile != "" {
+		pem, err := os.ReadFile(config.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate in the CA file %s", config.TLSCAFile)
+		}
+	}
+
+	client := &http.Client{}
+	if config.HTTPClient != nil {
+		c := *config.HTTPClient
+		client = &c
+	}
+
+	var transport *http.Transport
+
+	switch rt := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = rt.Clone()
+	default:
+		return nil, fmt.Errorf("the TLS options cannot be applied to the transport %T of the HTTP client", rt)
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	client.Transport = transport
+
+	return client, nil
+}
@@ -1,19 +1,33 @@
 package httpreq
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"io"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"path"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-var envTest = tester.NewEnvTest(EnvEndpoint, EnvMode, EnvUsername, EnvPassword)
+var envTest = tester.NewEnvTest(EnvEndpoint, EnvMode, EnvUsername, EnvPassword, EnvHMACSecret, EnvTLSCertFile, EnvTLSKeyFile, EnvTLSCAFile)
 
 func TestNewDNSProvider(t *testing.T) {
 	testCases := []struct {
@@ -105,6 +119,7 @@ func TestNewDNSProvider_Present(t *testing.T) {
 		mode          string
 		username      string
 		password      string
+		hmacSecret    string
 		pathPrefix    string
 		handler       http.HandlerFunc
 		expectedError string
@@ -149,6 +164,17 @@ func TestNewDNSProvider_Present(t *testing.T) {
 				fmt.Fprint(rw, "lego")
 			},
 		},
+		{
+			desc:       "HMAC signature",
+			hmacSecret: "secret",
+			handler:    signatureHandler("secret"),
+		},
+		{
+			desc:          "invalid HMAC signature",
+			hmacSecret:    "foo",
+			handler:       signatureHandler("secret"),
+			expectedError: "httpreq: unexpected status code: [status code: 401] body: invalid signature",
+		},
 	}
 
 	for _, test := range testCases {
@@ -166,6 +192,7 @@ func TestNewDNSProvider_Present(t *testing.T) {
 			config.Mode = test.mode
 			config.Username = test.username
 			config.Password = test.password
+			config.HMACSecret = test.hmacSecret
 
 			p, err := NewDNSProviderConfig(config)
 			require.NoError(t, err)
@@ -256,6 +283,138 @@ func TestNewDNSProvider_Cleanup(t *testing.T) {
 	}
 }
 
+func TestNewDNSProvider_Present_mTLS(t *testing.T) {
+	clientCert, clientKey := createClientCertificate(t)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(successHandler))
+
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  x509.NewCertPool(),
+	}
+
+	pemCert, err := os.ReadFile(clientCert)
+	require.NoError(t, err)
+
+	server.TLS.ClientCAs.AppendCertsFromPEM(pemCert)
+
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+
+	err = os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600)
+	require.NoError(t, err)
+
+	config := NewDefaultConfig()
+	config.Endpoint = mustParse(server.URL)
+	config.TLSCAFile = caFile
+
+	p, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	err = p.Present("domain", "token", "key")
+	require.Error(t, err)
+
+	config = NewDefaultConfig()
+	config.Endpoint = mustParse(server.URL)
+	config.TLSCertFile = clientCert
+	config.TLSKeyFile = clientKey
+	config.TLSCAFile = caFile
+
+	p, err = NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	err = p.Present("domain", "token", "key")
+	require.NoError(t, err)
+
+	// The HTTP client of the configuration is not modified.
+	assert.Nil(t, config.HTTPClient.Transport)
+	assert.NotSame(t, config.HTTPClient, p.client)
+}
+
+func TestNewDNSProviderConfig_mTLS_unsupportedTransport(t *testing.T) {
+	clientCert, clientKey := createClientCertificate(t)
+
+	config := NewDefaultConfig()
+	config.Endpoint = mustParse("https://example.com")
+	config.TLSCertFile = clientCert
+	config.TLSKeyFile = clientKey
+	config.HTTPClient.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return http.DefaultTransport.RoundTrip(req)
+	})
+
+	_, err := NewDNSProviderConfig(config)
+	require.EqualError(t, err, "httpreq: the TLS options cannot be applied to the transport httpreq.roundTripperFunc of the HTTP client")
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestSign(t *testing.T) {
+	signature := Sign("secret", "1700000000", []byte(`{"fqdn":"_acme-challenge.domain.","value":"value"}`))
+
+	assert.Equal(t, "8c01f6b561b3edf9352b3d7cb456626c4f165454d884d13b745b7e2c6f2ccb85", signature)
+}
+
+func createClientCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "lego"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, privateKey.Public(), privateKey)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(privateKey)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+
+	certFile := filepath.Join(dir, "client.pem")
+
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(dir, "client.key")
+
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	require.NoError(t, err)
+
+	return certFile, keyFile
+}
+
+func signatureHandler(secret string) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		expected := "sha256=" + Sign(secret, req.Header.Get(TimestampHeader), body)
+
+		if !hmac.Equal([]byte(expected), []byte(req.Header.Get(SignatureHeader))) {
+			http.Error(rw, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		fmt.Fprint(rw, "lego")
+	}
+}
+
 func successHandler(rw http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodPost {
 		http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
@@ -0,0 +1,88 @@
+// Package ratelimit paces the requests of the DNS providers to never exceed the rate limits of their APIs.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Window a maximum number of requests in any window of a period.
+type Window struct {
+	Requests int
+	Period   time.Duration
+}
+
+// PerMinute creates a Window of one minute.
+func PerMinute(requests int) Window {
+	return Window{Requests: requests, Period: time.Minute}
+}
+
+// PerHour creates a Window of one hour.
+func PerHour(requests int) Window {
+	return Window{Requests: requests, Period: time.Hour}
+}
+
+// PerDay creates a Window of one day.
+func PerDay(requests int) Window {
+	return Window{Requests: requests, Period: 24 * time.Hour}
+}
+
+// Limiter paces the requests to never exceed the number of requests of each window.
+type Limiter struct {
+	limiters []*rate.Limiter
+}
+
+// New creates a new Limiter.
+func New(windows ...Window) *Limiter {
+	limiter := &Limiter{}
+
+	for _, w := range windows {
+		limiter.limiters = append(limiter.limiters, newRateLimiter(w))
+	}
+
+	return limiter
+}
+
+// Wait blocks until a request can be sent without exceeding the limits.
+func (l *Limiter) Wait(ctx context.Context) error {
+	for _, limiter := range l.limiters {
+		err := limiter.Wait(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var (
+	shared   = make(map[string]*Limiter)
+	sharedMu sync.Mutex
+)
+
+// Shared returns the Limiter of a key (ex: a provider name and an API key), the Limiter is created on the first call.
+// The rate limits of the APIs are usually per account or per API key:
+// the clients using the same key (ex: the certificates obtained concurrently) share the same budget.
+func Shared(key string, windows ...Window) *Limiter {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+
+	limiter, ok := shared[key]
+	if !ok {
+		limiter = New(windows...)
+		shared[key] = limiter
+	}
+
+	return limiter
+}
+
+// newRateLimiter creates a rate limiter which never exceeds the number of requests in any window of the period:
+// half of the requests can be sent at once, the other half is spread over the period.
+func newRateLimiter(w Window) *rate.Limiter {
+	burst := max(w.Requests/2, 1)
+
+	return rate.NewLimiter(rate.Every(w.Period/time.Duration(max(w.Requests-burst, 1))), burst)
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_newRateLimiter(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		window        Window
+		expectedBurst int
+	}{
+		{
+			desc:          "per minute",
+			window:        PerMinute(20),
+			expectedBurst: 10,
+		},
+		{
+			desc:          "per hour",
+			window:        PerHour(700),
+			expectedBurst: 350,
+		},
+		{
+			desc:          "odd number of requests",
+			window:        PerDay(5),
+			expectedBurst: 2,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			limiter := newRateLimiter(test.window)
+
+			// The burst and the requests spread over the period never exceed the limit.
+			assert.Equal(t, test.expectedBurst, limiter.Burst())
+			assert.LessOrEqual(t, float64(limiter.Burst())+float64(limiter.Limit())*test.window.Period.Seconds(), float64(test.window.Requests)+0.001)
+		})
+	}
+}
+
+func TestLimiter_Wait(t *testing.T) {
+	// The burst of the hourly window is reached before the burst of the window per minute.
+	limiter := New(PerMinute(20), PerHour(6))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	for range 3 {
+		require.NoError(t, limiter.Wait(ctx))
+	}
+
+	require.Error(t, limiter.Wait(ctx))
+}
+
+func TestShared(t *testing.T) {
+	a := Shared("test:a", PerMinute(20))
+
+	assert.Same(t, a, Shared("test:a", PerMinute(20)))
+	assert.NotSame(t, a, Shared("test:b", PerMinute(20)))
+}
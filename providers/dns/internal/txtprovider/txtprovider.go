@@ -0,0 +1,137 @@
+// Package txtprovider implements the common logic of the DNS providers
+// which create a TXT record, and then delete it by its ID.
+package txtprovider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-acme/lego/v4/challenge/dns01"
+)
+
+// Record the TXT record of a challenge.
+type Record struct {
+	// Zone the authoritative zone (FQDN with a trailing dot).
+	Zone string
+	// SubDomain the name of the record relative to the zone.
+	SubDomain string
+	// FQDN the effective FQDN of the record.
+	FQDN string
+	// Value the value of the record.
+	Value string
+}
+
+// Funcs the operations implemented by a DNS provider.
+type Funcs[ID any] struct {
+	// FindZone finds the authoritative zone of an FQDN (optional, the default is [dns01.FindZoneByFqdn]).
+	FindZone func(ctx context.Context, fqdn string) (string, error)
+	// CreateRecord creates the TXT record, and returns its ID.
+	CreateRecord func(ctx context.Context, record Record) (ID, error)
+	// DeleteRecord deletes the TXT record.
+	DeleteRecord func(ctx context.Context, record Record, id ID) error
+}
+
+// Provider presents and cleans up the challenges with the operations of a DNS provider.
+// The IDs of the records are kept between the presentation and the cleanup of a challenge.
+type Provider[ID any] struct {
+	name  string
+	funcs Funcs[ID]
+
+	recordIDs   map[string]ID
+	recordIDsMu sync.Mutex
+}
+
+// New creates a new [Provider].
+// The name of the DNS provider is the prefix of the errors.
+func New[ID any](name string, funcs Funcs[ID]) *Provider[ID] {
+	return &Provider[ID]{
+		name:      name,
+		funcs:     funcs,
+		recordIDs: make(map[string]ID),
+	}
+}
+
+// Present creates a TXT record to fulfill the dns-01 challenge.
+func (p *Provider[ID]) Present(domain, token, keyAuth string) error {
+	ctx := context.Background()
+
+	record, err := p.newRecord(ctx, domain, keyAuth)
+	if err != nil {
+		return err
+	}
+
+	id, err := p.funcs.CreateRecord(ctx, record)
+	if err != nil {
+		return fmt.Errorf("%s: create record: %w", p.name, err)
+	}
+
+	p.recordIDsMu.Lock()
+	p.recordIDs[token] = id
+	p.recordIDsMu.Unlock()
+
+	return nil
+}
+
+// CleanUp removes the TXT record matching the specified parameters.
+func (p *Provider[ID]) CleanUp(domain, token, keyAuth string) error {
+	ctx := context.Background()
+
+	record, err := p.newRecord(ctx, domain, keyAuth)
+	if err != nil {
+		return err
+	}
+
+	p.recordIDsMu.Lock()
+	id, ok := p.recordIDs[token]
+	p.recordIDsMu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%s: unknown record ID for '%s'", p.name, record.FQDN)
+	}
+
+	err = p.funcs.DeleteRecord(ctx, record, id)
+	if err != nil {
+		return fmt.Errorf("%s: delete record: %w", p.name, err)
+	}
+
+	p.recordIDsMu.Lock()
+	delete(p.recordIDs, token)
+	p.recordIDsMu.Unlock()
+
+	return nil
+}
+
+func (p *Provider[ID]) newRecord(ctx context.Context, domain, keyAuth string) (Record, error) {
+	info := dns01.GetChallengeInfo(domain, keyAuth)
+
+	authZone, err := p.findZone(ctx, info.EffectiveFQDN)
+	if err != nil {
+		return Record{}, fmt.Errorf("%s: could not find zone for domain %q: %w", p.name, domain, err)
+	}
+
+	subDomain, err := dns01.ExtractSubDomain(info.EffectiveFQDN, authZone)
+	if err != nil {
+		return Record{}, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	return Record{
+		Zone:      authZone,
+		SubDomain: subDomain,
+		FQDN:      info.EffectiveFQDN,
+		Value:     info.Value,
+	}, nil
+}
+
+func (p *Provider[ID]) findZone(ctx context.Context, fqdn string) (string, error) {
+	if p.funcs.FindZone == nil {
+		return dns01.FindZoneByFqdn(fqdn)
+	}
+
+	zone, err := p.funcs.FindZone(ctx, fqdn)
+	if err != nil {
+		return "", err
+	}
+
+	return dns01.ToFqdn(zone), nil
+}
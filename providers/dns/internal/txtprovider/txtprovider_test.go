@@ -0,0 +1,106 @@
+package txtprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAPI struct {
+	records map[int]Record
+	nextID  int
+}
+
+func newFakeProvider(t *testing.T) (*Provider[int], *fakeAPI) {
+	t.Helper()
+
+	api := &fakeAPI{records: make(map[int]Record)}
+
+	provider := New("fake", Funcs[int]{
+		FindZone: func(_ context.Context, fqdn string) (string, error) {
+			if fqdn == "_acme-challenge.example.org." {
+				return "", errors.New("zone not found")
+			}
+
+			return "example.com", nil
+		},
+		CreateRecord: func(_ context.Context, record Record) (int, error) {
+			if record.SubDomain == "_acme-challenge.fail" {
+				return 0, errors.New("API error")
+			}
+
+			api.nextID++
+			api.records[api.nextID] = record
+
+			return api.nextID, nil
+		},
+		DeleteRecord: func(_ context.Context, record Record, id int) error {
+			if _, ok := api.records[id]; !ok {
+				return errors.New("record not found")
+			}
+
+			delete(api.records, id)
+
+			return nil
+		},
+	})
+
+	return provider, api
+}
+
+func TestProvider(t *testing.T) {
+	provider, api := newFakeProvider(t)
+
+	err := provider.Present("example.com", "token-a", "keyAuth-a")
+	require.NoError(t, err)
+
+	err = provider.Present("sub.example.com", "token-b", "keyAuth-b")
+	require.NoError(t, err)
+
+	expected := map[int]Record{
+		1: {
+			Zone:      "example.com.",
+			SubDomain: "_acme-challenge",
+			FQDN:      "_acme-challenge.example.com.",
+			Value:     "ZT4_J_uUeDI4LsBrpFDUYCx9fQkfFYaah5RvBIsi4Kw",
+		},
+		2: {
+			Zone:      "example.com.",
+			SubDomain: "_acme-challenge.sub",
+			FQDN:      "_acme-challenge.sub.example.com.",
+			Value:     "ca1VqKUb_EoBNIG3vrb9vi4Wte9nK7zbW6wyCamsRqY",
+		},
+	}
+
+	assert.Equal(t, expected, api.records)
+	assert.Equal(t, map[string]int{"token-a": 1, "token-b": 2}, provider.recordIDs)
+
+	err = provider.CleanUp("example.com", "token-a", "keyAuth-a")
+	require.NoError(t, err)
+
+	assert.Len(t, api.records, 1)
+	assert.Equal(t, map[string]int{"token-b": 2}, provider.recordIDs)
+}
+
+func TestProvider_errors(t *testing.T) {
+	provider, _ := newFakeProvider(t)
+
+	err := provider.Present("example.org", "token", "keyAuth")
+	require.EqualError(t, err, `fake: could not find zone for domain "example.org": zone not found`)
+
+	err = provider.Present("fail.example.com", "token", "keyAuth")
+	require.EqualError(t, err, "fake: create record: API error")
+
+	err = provider.CleanUp("example.com", "token", "keyAuth")
+	require.EqualError(t, err, "fake: unknown record ID for '_acme-challenge.example.com.'")
+
+	provider.recordIDs["token"] = 42
+
+	err = provider.CleanUp("example.com", "token", "keyAuth")
+	require.EqualError(t, err, "fake: delete record: record not found")
+
+	assert.Contains(t, provider.recordIDs, "token")
+}
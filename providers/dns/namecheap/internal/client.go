@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/go-acme/lego/v4/providers/dns/internal/errutils"
+	"github.com/go-acme/lego/v4/providers/dns/internal/ratelimit"
 )
 
 // Default API endpoints.
@@ -21,12 +22,18 @@ const (
 	SandboxBaseURL = "https://api.sandbox.namecheap.com/xml.response"
 )
 
+// The API allows 20 requests per minute, 700 per hour, and 8000 per day, per API user.
+// https://www.namecheap.com/support/knowledgebase/article.aspx/9739/63/api-faq/#z
+var rateLimits = []ratelimit.Window{ratelimit.PerMinute(20), ratelimit.PerHour(700), ratelimit.PerDay(8000)}
+
 // Client the API client for Namecheap.
 type Client struct {
 	apiUser  string
 	apiKey   string
 	clientIP string
 
+	rateLimiter *ratelimit.Limiter
+
 	BaseURL    string
 	HTTPClient *http.Client
 }
@@ -34,11 +41,12 @@ type Client struct {
 // NewClient creates a new Client.
 func NewClient(apiUser string, apiKey string, clientIP string) *Client {
 	return &Client{
-		apiUser:    apiUser,
-		apiKey:     apiKey,
-		clientIP:   clientIP,
-		BaseURL:    DefaultBaseURL,
-		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+		apiUser:     apiUser,
+		apiKey:      apiKey,
+		clientIP:    clientIP,
+		rateLimiter: ratelimit.Shared("namecheap:"+apiUser, rateLimits...),
+		BaseURL:     DefaultBaseURL,
+		HTTPClient:  &http.Client{Timeout: 5 * time.Second},
 	}
 }
 
@@ -104,6 +112,11 @@ func (c *Client) SetHosts(ctx context.Context, sld, tld string, hosts []Record)
 }
 
 func (c *Client) do(req *http.Request, result any) error {
+	err := c.rateLimiter.Wait(req.Context())
+	if err != nil {
+		return fmt.Errorf("rate limiter: %w", err)
+	}
+
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return errutils.NewHTTPDoError(req, err)
@@ -2,6 +2,7 @@
 package ovh
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -13,6 +14,8 @@ import (
 	"github.com/go-acme/lego/v4/platform/config/env"
 	"github.com/go-acme/lego/v4/providers/dns/internal/useragent"
 	"github.com/ovh/go-ovh/ovh"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
 )
 
 // OVH API reference:       https://eu.api.ovh.com/
@@ -47,6 +50,13 @@ const (
 // EnvAccessToken Authenticate using Access Token client.
 const EnvAccessToken = envNamespace + "ACCESS_TOKEN"
 
+// The token endpoints by API endpoint (same as the OVH client).
+var oauth2TokenURLs = map[string]string{
+	ovh.OvhEU: "https://www.ovh.com/auth/oauth2/token",
+	ovh.OvhCA: "https://ca.ovh.com/auth/oauth2/token",
+	ovh.OvhUS: "https://us.ovhcloud.com/auth/oauth2/token",
+}
+
 var _ challenge.ProviderTimeout = (*DNSProvider)(nil)
 
 // Record a DNS record.
@@ -59,7 +69,8 @@ type Record struct {
 	Zone      string `json:"zone,omitempty"`
 }
 
-// OAuth2Config the OAuth2 specific configuration.
+// OAuth2Config the OAuth2 specific configuration (client credentials flow).
+// The access tokens are requested, and renewed before they expire, with the HTTP client of the configuration.
 type OAuth2Config struct {
 	ClientID     string
 	ClientSecret string
@@ -109,7 +120,10 @@ type DNSProvider struct {
 
 // NewDNSProvider returns a DNSProvider instance configured for OVH
 // Credentials must be passed in the environment variables:
-// OVH_ENDPOINT (must be either "ovh-eu" or "ovh-ca"), OVH_APPLICATION_KEY, OVH_APPLICATION_SECRET, OVH_CONSUMER_KEY.
+// OVH_ENDPOINT (must be either "ovh-eu", "ovh-ca", or "ovh-us"), and either
+// OVH_APPLICATION_KEY, OVH_APPLICATION_SECRET, OVH_CONSUMER_KEY (application key),
+// OVH_CLIENT_ID, OVH_CLIENT_SECRET (OAuth2 client credentials),
+// or OVH_ACCESS_TOKEN.
 func NewDNSProvider() (*DNSProvider, error) {
 	config := NewDefaultConfig()
 
@@ -277,5 +291,52 @@ func newClient(config *Config) (*ovh.Client, error) {
 
 	client.UserAgent = useragent.Get()
 
+	if config.HTTPClient == nil {
+		return client, nil
+	}
+
+	// The OVH client overrides the timeout of the HTTP client with its own timeout.
+	client.Client = config.HTTPClient
+	client.Timeout = config.HTTPClient.Timeout
+
+	if client.ClientID != "" {
+		err = useOAuth2Transport(client, config.HTTPClient)
+		if err != nil {
+			return nil, fmt.Errorf("new client: %w", err)
+		}
+	}
+
 	return client, nil
 }
+
+// useOAuth2Transport moves the OAuth2 authentication of the OVH client to the transport of the HTTP client.
+// The OVH client requests the access tokens with the default HTTP client (its token source uses [context.Background]),
+// so the tokens are requested by the transport instead, with the HTTP client.
+func useOAuth2Transport(client *ovh.Client, httpClient *http.Client) error {
+	tokenURL, ok := oauth2TokenURLs[client.Endpoint()]
+	if !ok {
+		return fmt.Errorf("OAuth2 authentication is not compatible with the endpoint %q", client.Endpoint())
+	}
+
+	conf := &clientcredentials.Config{
+		ClientID:     client.ClientID,
+		ClientSecret: client.ClientSecret,
+		TokenURL:     tokenURL,
+		Scopes:       []string{"all"},
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, httpClient)
+
+	authClient := *httpClient
+	authClient.Transport = &oauth2.Transport{
+		Source: conf.TokenSource(ctx),
+		Base:   httpClient.Transport,
+	}
+
+	// Without client ID, the OVH client doesn't set the Authorization header itself.
+	client.ClientID = ""
+	client.ClientSecret = ""
+	client.Client = &authClient
+
+	return nil
+}
@@ -1,10 +1,13 @@
 package ovh
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -350,6 +353,58 @@ func TestNewDNSProviderConfig(t *testing.T) {
 	}
 }
 
+func TestNewDNSProviderConfig_httpClient(t *testing.T) {
+	// The OVH client use the same env vars than lego, so it requires to clean them.
+	defer envTest.RestoreEnv()
+	envTest.ClearEnv()
+
+	var requests []string
+
+	config := NewDefaultConfig()
+	config.APIEndpoint = "ovh-eu"
+	config.OAuth2Config = &OAuth2Config{
+		ClientID:     "B",
+		ClientSecret: "C",
+	}
+	config.HTTPClient = &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			requests = append(requests, req.Method+" "+req.URL.String()+" "+req.Header.Get("Authorization"))
+
+			rw := httptest.NewRecorder()
+			rw.Header().Set("Content-Type", "application/json")
+
+			if req.URL.Path == "/auth/oauth2/token" {
+				_, _ = rw.WriteString(`{"access_token":"secret","token_type":"Bearer","expires_in":3600}`)
+			} else {
+				_, _ = rw.WriteString(`[]`)
+			}
+
+			return rw.Result(), nil
+		}),
+	}
+
+	p, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	assert.Equal(t, 30*time.Second, p.client.Timeout)
+
+	var records []int64
+	err = p.client.Get("/domain/zone/example.com/record", &records)
+	require.NoError(t, err)
+
+	err = p.client.Get("/domain/zone/example.com/record", &records)
+	require.NoError(t, err)
+
+	expected := []string{
+		"POST https://www.ovh.com/auth/oauth2/token Basic QjpD",
+		"GET https://eu.api.ovh.com/1.0/domain/zone/example.com/record Bearer secret",
+		"GET https://eu.api.ovh.com/1.0/domain/zone/example.com/record Bearer secret",
+	}
+
+	assert.Equal(t, expected, requests)
+}
+
 func TestLivePresent(t *testing.T) {
 	if !envTest.IsLiveTest() {
 		t.Skip("skipping live test")
@@ -377,3 +432,9 @@ func TestLiveCleanUp(t *testing.T) {
 	err = provider.CleanUp(envTest.GetDomain(), "", "123d==")
 	require.NoError(t, err)
 }
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -20,8 +20,7 @@ import (
 
 // Client the PowerDNS API client.
 type Client struct {
-	serverName string
-	apiKey     string
+	apiKey string
 
 	apiVersion int
 
@@ -30,9 +29,8 @@ type Client struct {
 }
 
 // NewClient creates a new Client.
-func NewClient(host *url.URL, serverName string, apiVersion int, apiKey string) *Client {
+func NewClient(host *url.URL, apiVersion int, apiKey string) *Client {
 	return &Client{
-		serverName: serverName,
 		apiKey:     apiKey,
 		apiVersion: apiVersion,
 		Host:       host,
@@ -81,8 +79,58 @@ func (c *Client) getAPIVersion(ctx context.Context) (int, error) {
 	return latestVersion, err
 }
 
-func (c *Client) GetHostedZone(ctx context.Context, authZone string) (*HostedZone, error) {
-	endpoint := c.joinPath("/", "servers", c.serverName, "zones", dns.Fqdn(authZone))
+// GetServer gets the server information (version, daemon type).
+func (c *Client) GetServer(ctx context.Context, serverID string) (*Server, error) {
+	endpoint := c.joinPath("/", "servers", serverID)
+
+	req, err := newJSONRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var server Server
+	err = json.Unmarshal(result, &server)
+	if err != nil {
+		return nil, err
+	}
+
+	return &server, nil
+}
+
+// GetHostedZone gets the zone with all its RRSets.
+func (c *Client) GetHostedZone(ctx context.Context, serverID, authZone string) (*HostedZone, error) {
+	return c.getHostedZone(ctx, serverID, authZone, nil)
+}
+
+// GetHostedZoneRRSet gets the zone with only the RRSet of the name and the type.
+// The filter is ignored by the servers older than PowerDNS 4.8.
+func (c *Client) GetHostedZoneRRSet(ctx context.Context, serverID, authZone, name, rrType string) (*HostedZone, error) {
+	query := url.Values{}
+	query.Set("rrset_name", name)
+	query.Set("rrset_type", rrType)
+
+	return c.getHostedZone(ctx, serverID, authZone, query)
+}
+
+// GetHostedZoneInfo gets the zone without its RRSets.
+func (c *Client) GetHostedZoneInfo(ctx context.Context, serverID, authZone string) (*HostedZone, error) {
+	query := url.Values{}
+	query.Set("rrsets", "false")
+
+	return c.getHostedZone(ctx, serverID, authZone, query)
+}
+
+func (c *Client) getHostedZone(ctx context.Context, serverID, authZone string, query url.Values) (*HostedZone, error) {
+	endpoint := c.joinPath("/", "servers", serverID, "zones", dns.Fqdn(authZone))
+
+	if len(query) > 0 {
+		endpoint.RawQuery = query.Encode()
+	}
 
 	req, err := newJSONRequest(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
@@ -116,8 +164,8 @@ func (c *Client) GetHostedZone(ctx context.Context, authZone string) (*HostedZon
 	return &zone, nil
 }
 
-func (c *Client) UpdateRecords(ctx context.Context, zone *HostedZone, sets RRSets) error {
-	endpoint := c.joinPath("/", "servers", c.serverName, "zones", zone.ID)
+func (c *Client) UpdateRecords(ctx context.Context, serverID string, zone *HostedZone, sets RRSets) error {
+	endpoint := c.joinPath("/", "servers", serverID, "zones", zone.ID)
 
 	req, err := newJSONRequest(ctx, http.MethodPatch, endpoint, sets)
 	if err != nil {
@@ -132,12 +180,13 @@ func (c *Client) UpdateRecords(ctx context.Context, zone *HostedZone, sets RRSet
 	return nil
 }
 
-func (c *Client) Notify(ctx context.Context, zone *HostedZone) error {
-	if c.apiVersion < 1 || zone.Kind != "Master" && zone.Kind != "Slave" {
+// Notify sends a DNS NOTIFY to the secondaries of a primary zone (the native zones are replicated by the backend).
+func (c *Client) Notify(ctx context.Context, serverID string, zone *HostedZone) error {
+	if c.apiVersion < 1 || !zone.IsPrimary() && !zone.IsSecondary() {
 		return nil
 	}
 
-	endpoint := c.joinPath("/", "servers", c.serverName, "zones", zone.ID, "notify")
+	endpoint := c.joinPath("/", "servers", serverID, "zones", zone.ID, "notify")
 
 	req, err := newJSONRequest(ctx, http.MethodPut, endpoint, nil)
 	if err != nil {
@@ -212,7 +261,10 @@ func newJSONRequest(ctx context.Context, method string, endpoint *url.URL, paylo
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(endpoint.String(), "/"), buf)
+	endpoint.Path = strings.TrimSuffix(endpoint.Path, "/")
+	endpoint.RawPath = strings.TrimSuffix(endpoint.RawPath, "/")
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), buf)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create request: %w", err)
 	}
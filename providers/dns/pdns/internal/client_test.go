@@ -57,7 +57,7 @@ func setupTest(t *testing.T, method, pattern string, status int, file string) *C
 
 	serverURL, _ := url.Parse(server.URL)
 
-	client := NewClient(serverURL, "server", 0, "secret")
+	client := NewClient(serverURL, 0, "secret")
 	client.HTTPClient = server.Client()
 
 	return client
@@ -150,7 +150,7 @@ func TestClient_joinPath(t *testing.T) {
 			host, err := url.Parse(test.baseURL)
 			require.NoError(t, err)
 
-			client := NewClient(host, "test", test.apiVersion, "secret")
+			client := NewClient(host, test.apiVersion, "secret")
 
 			endpoint := client.joinPath(test.uri)
 
@@ -163,7 +163,7 @@ func TestClient_GetHostedZone(t *testing.T) {
 	client := setupTest(t, http.MethodGet, "/api/v1/servers/server/zones/example.org.", http.StatusOK, "zone.json")
 	client.apiVersion = 1
 
-	zone, err := client.GetHostedZone(context.Background(), "example.org.")
+	zone, err := client.GetHostedZone(context.Background(), "server", "example.org.")
 	require.NoError(t, err)
 
 	expected := &HostedZone{
@@ -206,7 +206,7 @@ func TestClient_GetHostedZone_error(t *testing.T) {
 	client := setupTest(t, http.MethodGet, "/api/v1/servers/server/zones/example.org.", http.StatusUnprocessableEntity, "error.json")
 	client.apiVersion = 1
 
-	_, err := client.GetHostedZone(context.Background(), "example.org.")
+	_, err := client.GetHostedZone(context.Background(), "server", "example.org.")
 	require.ErrorAs(t, err, &apiError{})
 }
 
@@ -214,7 +214,7 @@ func TestClient_GetHostedZone_v0(t *testing.T) {
 	client := setupTest(t, http.MethodGet, "/servers/server/zones/example.org.", http.StatusOK, "zone.json")
 	client.apiVersion = 0
 
-	zone, err := client.GetHostedZone(context.Background(), "example.org.")
+	zone, err := client.GetHostedZone(context.Background(), "server", "example.org.")
 	require.NoError(t, err)
 
 	expected := &HostedZone{
@@ -256,7 +256,6 @@ func TestClient_GetHostedZone_v0(t *testing.T) {
 func TestClient_UpdateRecords(t *testing.T) {
 	client := setupTest(t, http.MethodPatch, "/api/v1/servers/localhost/zones/example.org.", http.StatusOK, "zone.json")
 	client.apiVersion = 1
-	client.serverName = "localhost"
 
 	zone := &HostedZone{
 		ID:   "example.org.",
@@ -279,7 +278,7 @@ func TestClient_UpdateRecords(t *testing.T) {
 		}},
 	}
 
-	err := client.UpdateRecords(context.Background(), zone, rrSets)
+	err := client.UpdateRecords(context.Background(), "localhost", zone, rrSets)
 	require.NoError(t, err)
 }
 
@@ -287,7 +286,6 @@ func TestClient_UpdateRecords_NonRootApi(t *testing.T) {
 	client := setupTest(t, http.MethodPatch, "/some/path/api/v1/servers/localhost/zones/example.org.", http.StatusOK, "zone.json")
 	client.Host = client.Host.JoinPath("some", "path")
 	client.apiVersion = 1
-	client.serverName = "localhost"
 
 	zone := &HostedZone{
 		ID:   "example.org.",
@@ -310,14 +308,13 @@ func TestClient_UpdateRecords_NonRootApi(t *testing.T) {
 		}},
 	}
 
-	err := client.UpdateRecords(context.Background(), zone, rrSets)
+	err := client.UpdateRecords(context.Background(), "localhost", zone, rrSets)
 	require.NoError(t, err)
 }
 
 func TestClient_UpdateRecords_v0(t *testing.T) {
 	client := setupTest(t, http.MethodPatch, "/servers/localhost/zones/example.org.", http.StatusOK, "zone.json")
 	client.apiVersion = 0
-	client.serverName = "localhost"
 
 	zone := &HostedZone{
 		ID:   "example.org.",
@@ -340,14 +337,13 @@ func TestClient_UpdateRecords_v0(t *testing.T) {
 		}},
 	}
 
-	err := client.UpdateRecords(context.Background(), zone, rrSets)
+	err := client.UpdateRecords(context.Background(), "localhost", zone, rrSets)
 	require.NoError(t, err)
 }
 
 func TestClient_Notify(t *testing.T) {
 	client := setupTest(t, http.MethodPut, "/api/v1/servers/localhost/zones/example.org./notify", http.StatusOK, "")
 	client.apiVersion = 1
-	client.serverName = "localhost"
 
 	zone := &HostedZone{
 		ID:   "example.org.",
@@ -356,7 +352,7 @@ func TestClient_Notify(t *testing.T) {
 		Kind: "Master",
 	}
 
-	err := client.Notify(context.Background(), zone)
+	err := client.Notify(context.Background(), "localhost", zone)
 	require.NoError(t, err)
 }
 
@@ -364,7 +360,6 @@ func TestClient_Notify_NonRootApi(t *testing.T) {
 	client := setupTest(t, http.MethodPut, "/some/path/api/v1/servers/localhost/zones/example.org./notify", http.StatusOK, "")
 	client.Host = client.Host.JoinPath("some", "path")
 	client.apiVersion = 1
-	client.serverName = "localhost"
 
 	zone := &HostedZone{
 		ID:   "example.org.",
@@ -373,7 +368,7 @@ func TestClient_Notify_NonRootApi(t *testing.T) {
 		Kind: "Master",
 	}
 
-	err := client.Notify(context.Background(), zone)
+	err := client.Notify(context.Background(), "localhost", zone)
 	require.NoError(t, err)
 }
 
@@ -388,7 +383,7 @@ func TestClient_Notify_v0(t *testing.T) {
 		Kind: "Master",
 	}
 
-	err := client.Notify(context.Background(), zone)
+	err := client.Notify(context.Background(), "localhost", zone)
 	require.NoError(t, err)
 }
 
@@ -400,3 +395,117 @@ func TestClient_getAPIVersion(t *testing.T) {
 
 	assert.Equal(t, 4, version)
 }
+
+func TestClient_GetServer(t *testing.T) {
+	client := setupTest(t, http.MethodGet, "/api/v1/servers/server", http.StatusOK, "server.json")
+	client.apiVersion = 1
+
+	server, err := client.GetServer(context.Background(), "server")
+	require.NoError(t, err)
+
+	expected := &Server{
+		ID:         "localhost",
+		DaemonType: "authoritative",
+		Version:    "5.0.0",
+		URL:        "/api/v1/servers/localhost",
+	}
+
+	assert.Equal(t, expected, server)
+}
+
+func TestClient_GetHostedZoneRRSet(t *testing.T) {
+	client := setupTest(t, http.MethodGet, "/api/v1/servers/server/zones/example.org.", http.StatusOK, "zone.json")
+	client.apiVersion = 1
+
+	var query url.Values
+
+	client.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		query = req.URL.Query()
+
+		return http.DefaultTransport.RoundTrip(req)
+	})
+
+	_, err := client.GetHostedZoneRRSet(context.Background(), "server", "example.org.", "_acme-challenge.example.org.", "TXT")
+	require.NoError(t, err)
+
+	expected := url.Values{
+		"rrset_name": {"_acme-challenge.example.org."},
+		"rrset_type": {"TXT"},
+	}
+
+	assert.Equal(t, expected, query)
+}
+
+func TestClient_GetHostedZoneInfo(t *testing.T) {
+	client := setupTest(t, http.MethodGet, "/api/v1/servers/server/zones/example.org.", http.StatusOK, "zone.json")
+	client.apiVersion = 1
+
+	var query url.Values
+
+	client.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		query = req.URL.Query()
+
+		return http.DefaultTransport.RoundTrip(req)
+	})
+
+	_, err := client.GetHostedZoneInfo(context.Background(), "server", "example.org.")
+	require.NoError(t, err)
+
+	assert.Equal(t, url.Values{"rrsets": {"false"}}, query)
+}
+
+func TestClient_GetHostedZone_serverID(t *testing.T) {
+	client := setupTest(t, http.MethodGet, "/api/v1/servers/other/zones/example.org.", http.StatusOK, "zone.json")
+	client.apiVersion = 1
+
+	zone, err := client.GetHostedZone(context.Background(), "other", "example.org.")
+	require.NoError(t, err)
+
+	assert.Equal(t, "example.org.", zone.ID)
+}
+
+func TestClient_Notify_native(t *testing.T) {
+	client := setupTest(t, http.MethodPut, "/api/v1/servers/localhost/zones/example.org./notify", http.StatusInternalServerError, "")
+	client.apiVersion = 1
+
+	zone := &HostedZone{
+		ID:   "example.org.",
+		Name: "example.org.",
+		URL:  "api/v1/servers/localhost/zones/example.org.",
+		Kind: "Native",
+	}
+
+	err := client.Notify(context.Background(), "localhost", zone)
+	require.NoError(t, err)
+}
+
+func TestHostedZone_kind(t *testing.T) {
+	testCases := []struct {
+		kind      string
+		primary   bool
+		secondary bool
+	}{
+		{kind: "Native"},
+		{kind: "Master", primary: true},
+		{kind: "Primary", primary: true},
+		{kind: "Producer", primary: true},
+		{kind: "Slave", secondary: true},
+		{kind: "secondary", secondary: true},
+		{kind: "Consumer", secondary: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.kind, func(t *testing.T) {
+			zone := HostedZone{Kind: test.kind}
+
+			assert.Equal(t, test.primary, zone.IsPrimary())
+			assert.Equal(t, test.secondary, zone.IsSecondary())
+		})
+	}
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -1,5 +1,7 @@
 package internal
 
+import "strings"
+
 type Record struct {
 	Content  string `json:"content"`
 	Disabled bool   `json:"disabled"`
@@ -21,10 +23,29 @@ type HostedZone struct {
 	Records []Record `json:"records"`
 }
 
+// IsPrimary reports whether the zone is a primary zone (the secondaries are notified).
+func (z HostedZone) IsPrimary() bool {
+	switch strings.ToLower(z.Kind) {
+	case "master", "primary", "producer":
+		return true
+	default:
+		return false
+	}
+}
+
+// IsSecondary reports whether the zone is a secondary zone (the records are read-only).
+func (z HostedZone) IsSecondary() bool {
+	switch strings.ToLower(z.Kind) {
+	case "slave", "secondary", "consumer":
+		return true
+	default:
+		return false
+	}
+}
+
 type RRSet struct {
 	Name       string   `json:"name"`
 	Type       string   `json:"type"`
-	Kind       string   `json:"kind"`
 	ChangeType string   `json:"changetype"`
 	Records    []Record `json:"records,omitempty"`
 	TTL        int      `json:"ttl,omitempty"`
@@ -42,6 +63,13 @@ func (a apiError) Error() string {
 	return a.ShortMsg
 }
 
+type Server struct {
+	ID         string `json:"id"`
+	DaemonType string `json:"daemon_type"`
+	Version    string `json:"version"`
+	URL        string `json:"url"`
+}
+
 type apiVersion struct {
 	URL     string `json:"url"`
 	Version int    `json:"version"`
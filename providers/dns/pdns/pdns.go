@@ -2,11 +2,16 @@
 package pdns
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-acme/lego/v4/challenge"
@@ -29,6 +34,7 @@ const (
 	EnvPollingInterval    = envNamespace + "POLLING_INTERVAL"
 	EnvHTTPTimeout        = envNamespace + "HTTP_TIMEOUT"
 	EnvServerName         = envNamespace + "SERVER_NAME"
+	EnvServerMapping      = envNamespace + "SERVER_MAPPING"
 )
 
 var _ challenge.ProviderTimeout = (*DNSProvider)(nil)
@@ -43,6 +49,9 @@ type Config struct {
 	PollingInterval    time.Duration
 	TTL                int
 	HTTPClient         *http.Client
+
+	// ServerMapping the server names by zone (ex: example.com), the server of a zone is also used for its subzones.
+	ServerMapping map[string]string
 }
 
 // NewDefaultConfig returns a default configuration for the DNSProvider.
@@ -63,6 +72,10 @@ func NewDefaultConfig() *Config {
 type DNSProvider struct {
 	config *Config
 	client *internal.Client
+
+	// extend the support of the EXTEND and PRUNE changetypes by server.
+	extend   map[string]bool
+	extendMu sync.Mutex
 }
 
 // NewDNSProvider returns a DNSProvider instance configured for pdns.
@@ -83,6 +96,13 @@ func NewDNSProvider() (*DNSProvider, error) {
 	config.Host = hostURL
 	config.APIKey = values[EnvAPIKey]
 
+	serverMapping, err := env.ParseDomainMapping(env.GetOrFile(EnvServerMapping))
+	if err != nil {
+		return nil, fmt.Errorf("pdns: %s: %w", EnvServerMapping, err)
+	}
+
+	config.ServerMapping = serverMapping
+
 	return NewDNSProviderConfig(config)
 }
 
@@ -100,7 +120,7 @@ func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
 		return nil, errors.New("pdns: API URL missing")
 	}
 
-	client := internal.NewClient(config.Host, config.ServerName, config.APIVersion, config.APIKey)
+	client := internal.NewClient(config.Host, config.APIVersion, config.APIKey)
 
 	if config.APIVersion <= 0 {
 		err := client.SetAPIVersion(context.Background())
@@ -109,7 +129,11 @@ func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
 		}
 	}
 
-	return &DNSProvider{config: config, client: client}, nil
+	return &DNSProvider{
+		config: config,
+		client: client,
+		extend: make(map[string]bool),
+	}, nil
 }
 
 // Timeout returns the timeout and interval to use when checking for DNS propagation.
@@ -127,28 +151,45 @@ func (d *DNSProvider) Present(domain, token, keyAuth string) error {
 		return fmt.Errorf("pdns: could not find zone for domain %q: %w", domain, err)
 	}
 
-	ctx := context.Background()
-
-	zone, err := d.client.GetHostedZone(ctx, authZone)
+	err = d.addRecord(context.Background(), authZone, info)
 	if err != nil {
 		return fmt.Errorf("pdns: %w", err)
 	}
 
-	name := info.EffectiveFQDN
-	if d.client.APIVersion() == 0 {
-		// pre-v1 API wants non-fqdn
-		name = dns01.UnFqdn(info.EffectiveFQDN)
+	return nil
+}
+
+// CleanUp removes the TXT record matching the specified parameters.
+func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
+	info := dns01.GetChallengeInfo(domain, keyAuth)
+
+	authZone, err := dns01.FindZoneByFqdn(info.EffectiveFQDN)
+	if err != nil {
+		return fmt.Errorf("pdns: could not find zone for domain %q: %w", domain, err)
 	}
 
-	// Look for existing records.
-	existingRRSet := findTxtRecord(zone, info.EffectiveFQDN)
+	err = d.removeRecord(context.Background(), authZone, info)
+	if err != nil {
+		return fmt.Errorf("pdns: %w", err)
+	}
 
-	// merge the existing and new records
-	var records []internal.Record
-	if existingRRSet != nil {
-		records = existingRRSet.Records
+	return nil
+}
+
+// addRecord adds the TXT record to the RRSet:
+// with the EXTEND changetype when the server supports it, or by replacing the RRSet with the existing records and the new record.
+func (d *DNSProvider) addRecord(ctx context.Context, authZone string, info dns01.ChallengeInfo) error {
+	serverName := d.getServerName(authZone)
+
+	extend := d.supportsExtend(ctx, serverName)
+
+	zone, err := d.getZone(ctx, serverName, authZone, info.EffectiveFQDN, extend)
+	if err != nil {
+		return err
 	}
 
+	name := d.recordName(info.EffectiveFQDN)
+
 	rec := internal.Record{
 		Content:  "\"" + info.Value + "\"",
 		Disabled: false,
@@ -159,65 +200,165 @@ func (d *DNSProvider) Present(domain, token, keyAuth string) error {
 		TTL:  d.config.TTL,
 	}
 
-	rrSets := internal.RRSets{
-		RRSets: []internal.RRSet{
-			{
-				Name:       name,
-				ChangeType: "REPLACE",
-				Type:       "TXT",
-				Kind:       "Master",
-				TTL:        d.config.TTL,
-				Records:    append(records, rec),
-			},
-		},
+	rrSet := internal.RRSet{
+		Name: name,
+		Type: "TXT",
+		TTL:  d.config.TTL,
 	}
 
-	err = d.client.UpdateRecords(ctx, zone, rrSets)
+	if extend {
+		rrSet.ChangeType = "EXTEND"
+		rrSet.Records = []internal.Record{rec}
+	} else {
+		// merge the existing and new records
+		rrSet.ChangeType = "REPLACE"
+
+		existingRRSet := findTxtRecord(zone, info.EffectiveFQDN)
+		if existingRRSet != nil {
+			if slices.ContainsFunc(existingRRSet.Records, func(r internal.Record) bool { return r.Content == rec.Content }) {
+				return nil
+			}
+
+			rrSet.Records = existingRRSet.Records
+		}
+
+		rrSet.Records = append(rrSet.Records, rec)
+	}
+
+	err = d.client.UpdateRecords(ctx, serverName, zone, internal.RRSets{RRSets: []internal.RRSet{rrSet}})
 	if err != nil {
-		return fmt.Errorf("pdns: %w", err)
+		return err
 	}
 
-	return d.client.Notify(ctx, zone)
+	return d.client.Notify(ctx, serverName, zone)
 }
 
-// CleanUp removes the TXT record matching the specified parameters.
-func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
-	info := dns01.GetChallengeInfo(domain, keyAuth)
+// removeRecord removes the TXT record from the RRSet:
+// with the PRUNE changetype when the server supports it, or by replacing the RRSet with the other records (the empty RRSet is deleted).
+func (d *DNSProvider) removeRecord(ctx context.Context, authZone string, info dns01.ChallengeInfo) error {
+	serverName := d.getServerName(authZone)
 
-	authZone, err := dns01.FindZoneByFqdn(info.EffectiveFQDN)
+	extend := d.supportsExtend(ctx, serverName)
+
+	zone, err := d.getZone(ctx, serverName, authZone, info.EffectiveFQDN, extend)
 	if err != nil {
-		return fmt.Errorf("pdns: could not find zone for domain %q: %w", domain, err)
+		return err
 	}
 
-	ctx := context.Background()
+	content := "\"" + info.Value + "\""
+
+	var rrSet internal.RRSet
+
+	if extend {
+		name := d.recordName(info.EffectiveFQDN)
+
+		rrSet = internal.RRSet{
+			Name:       name,
+			Type:       "TXT",
+			ChangeType: "PRUNE",
+			Records:    []internal.Record{{Content: content, Type: "TXT", Name: name}},
+		}
+	} else {
+		set := findTxtRecord(zone, info.EffectiveFQDN)
+		if set == nil {
+			return fmt.Errorf("no existing record found for %s", info.EffectiveFQDN)
+		}
+
+		records := slices.DeleteFunc(slices.Clone(set.Records), func(r internal.Record) bool { return r.Content == content })
+
+		rrSet = internal.RRSet{
+			Name:       set.Name,
+			Type:       set.Type,
+			ChangeType: "DELETE",
+		}
+
+		if len(records) > 0 {
+			rrSet.ChangeType = "REPLACE"
+			rrSet.Records = records
+			rrSet.TTL = cmp.Or(set.TTL, d.config.TTL)
+		}
+	}
 
-	zone, err := d.client.GetHostedZone(ctx, authZone)
+	err = d.client.UpdateRecords(ctx, serverName, zone, internal.RRSets{RRSets: []internal.RRSet{rrSet}})
 	if err != nil {
-		return fmt.Errorf("pdns: %w", err)
+		return err
 	}
 
-	set := findTxtRecord(zone, info.EffectiveFQDN)
+	return d.client.Notify(ctx, serverName, zone)
+}
 
-	if set == nil {
-		return fmt.Errorf("pdns: no existing record found for %s", info.EffectiveFQDN)
+// getZone gets the zone: only the information of the zone when the records are not merged, or the zone with the TXT RRSet of the FQDN.
+func (d *DNSProvider) getZone(ctx context.Context, serverName, authZone, fqdn string, extend bool) (*internal.HostedZone, error) {
+	var (
+		zone *internal.HostedZone
+		err  error
+	)
+
+	if extend {
+		zone, err = d.client.GetHostedZoneInfo(ctx, serverName, authZone)
+	} else {
+		zone, err = d.client.GetHostedZoneRRSet(ctx, serverName, authZone, fqdn, "TXT")
 	}
 
-	rrSets := internal.RRSets{
-		RRSets: []internal.RRSet{
-			{
-				Name:       set.Name,
-				Type:       set.Type,
-				ChangeType: "DELETE",
-			},
-		},
+	if err != nil {
+		return nil, err
+	}
+
+	if zone.IsSecondary() {
+		return nil, fmt.Errorf("the zone %s is a secondary zone (%s): the records must be updated on the primary server", authZone, zone.Kind)
 	}
 
-	err = d.client.UpdateRecords(ctx, zone, rrSets)
+	return zone, nil
+}
+
+// supportsExtend reports whether the server supports the EXTEND and PRUNE changetypes (PowerDNS 5.0 and later).
+// The result is cached by server.
+func (d *DNSProvider) supportsExtend(ctx context.Context, serverName string) bool {
+	if d.client.APIVersion() < 1 {
+		return false
+	}
+
+	d.extendMu.Lock()
+	defer d.extendMu.Unlock()
+
+	if extend, ok := d.extend[serverName]; ok {
+		return extend
+	}
+
+	server, err := d.client.GetServer(ctx, serverName)
 	if err != nil {
-		return fmt.Errorf("pdns: %w", err)
+		log.Warnf("pdns: failed to get the version of the server %s, the RRSets will be replaced: %v", serverName, err)
+
+		d.extend[serverName] = false
+
+		return false
+	}
+
+	major, _, _ := strings.Cut(server.Version, ".")
+
+	version, err := strconv.Atoi(major)
+
+	d.extend[serverName] = err == nil && version >= 5
+
+	return d.extend[serverName]
+}
+
+// getServerName returns the server name of the closest zone of the server mapping, or the default server name.
+func (d *DNSProvider) getServerName(authZone string) string {
+	if _, serverName, ok := env.MatchDomain(d.config.ServerMapping, authZone); ok {
+		return serverName
+	}
+
+	return d.config.ServerName
+}
+
+func (d *DNSProvider) recordName(fqdn string) string {
+	if d.client.APIVersion() == 0 {
+		// pre-v1 API wants non-fqdn
+		return dns01.UnFqdn(fqdn)
 	}
 
-	return d.client.Notify(ctx, zone)
+	return fqdn
 }
 
 func findTxtRecord(zone *internal.HostedZone, fqdn string) *internal.RRSet {
@@ -1,10 +1,18 @@
 package pdns
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"sync"
 	"testing"
 
+	"github.com/go-acme/lego/v4/challenge/dns01"
 	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/go-acme/lego/v4/providers/dns/pdns/internal"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -12,7 +20,8 @@ const envDomain = envNamespace + "DOMAIN"
 
 var envTest = tester.NewEnvTest(
 	EnvAPIURL,
-	EnvAPIKey).
+	EnvAPIKey,
+	EnvServerMapping).
 	WithDomain(envDomain)
 
 func TestNewDNSProvider(t *testing.T) {
@@ -28,6 +37,15 @@ func TestNewDNSProvider(t *testing.T) {
 				EnvAPIURL: "http://example.com",
 			},
 		},
+		{
+			desc: "invalid server mapping",
+			envVars: map[string]string{
+				EnvAPIKey:        "123",
+				EnvAPIURL:        "http://example.com",
+				EnvServerMapping: "example.com",
+			},
+			expected: "pdns: PDNS_SERVER_MAPPING: invalid entry 1: the format is 'domain=value'",
+		},
 		{
 			desc: "missing credentials",
 			envVars: map[string]string{
@@ -146,6 +164,244 @@ func TestLivePresentAndCleanup(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// fakeServer a PowerDNS API server: the zone example.org. with an existing TXT record for _acme-challenge.example.org.
+type fakeServer struct {
+	version string
+	kind    string
+
+	mu      sync.Mutex
+	queries []url.Values
+	patches map[string][]internal.RRSet
+	notify  int
+}
+
+func setupProviderTest(t *testing.T, version, kind string) (*DNSProvider, *fakeServer) {
+	t.Helper()
+
+	fake := &fakeServer{
+		version: version,
+		kind:    kind,
+		patches: make(map[string][]internal.RRSet),
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("GET /api/v1/servers/{server}", func(rw http.ResponseWriter, req *http.Request) {
+		_ = json.NewEncoder(rw).Encode(internal.Server{ID: req.PathValue("server"), Version: fake.version})
+	})
+
+	mux.HandleFunc("GET /api/v1/servers/{server}/zones/example.org.", func(rw http.ResponseWriter, req *http.Request) {
+		fake.mu.Lock()
+		fake.queries = append(fake.queries, req.URL.Query())
+		fake.mu.Unlock()
+
+		zone := internal.HostedZone{ID: "example.org.", Name: "example.org.", Kind: fake.kind}
+
+		if req.URL.Query().Get("rrsets") != "false" {
+			zone.RRSets = []internal.RRSet{{
+				Name:    "_acme-challenge.example.org.",
+				Type:    "TXT",
+				TTL:     300,
+				Records: []internal.Record{{Content: `"existing"`}},
+			}}
+		}
+
+		_ = json.NewEncoder(rw).Encode(zone)
+	})
+
+	mux.HandleFunc("PATCH /api/v1/servers/{server}/zones/example.org.", func(rw http.ResponseWriter, req *http.Request) {
+		var sets internal.RRSets
+
+		err := json.NewDecoder(req.Body).Decode(&sets)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		fake.mu.Lock()
+		fake.patches[req.PathValue("server")] = append(fake.patches[req.PathValue("server")], sets.RRSets...)
+		fake.mu.Unlock()
+
+		rw.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("PUT /api/v1/servers/{server}/zones/example.org./notify", func(rw http.ResponseWriter, _ *http.Request) {
+		fake.mu.Lock()
+		fake.notify++
+		fake.mu.Unlock()
+	})
+
+	config := NewDefaultConfig()
+	config.APIKey = "secret"
+	config.Host = mustParse(server.URL)
+	config.APIVersion = 1
+	config.TTL = 120
+	config.ServerMapping = map[string]string{"example.org": "other"}
+
+	provider, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	return provider, fake
+}
+
+func TestDNSProvider_addRecord(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		version          string
+		kind             string
+		expectedQuery    url.Values
+		expectedRRSet    internal.RRSet
+		expectedNotified int
+	}{
+		{
+			desc:          "EXTEND",
+			version:       "5.0.0",
+			kind:          "Native",
+			expectedQuery: url.Values{"rrsets": {"false"}},
+			expectedRRSet: internal.RRSet{
+				Name:       "_acme-challenge.example.org.",
+				Type:       "TXT",
+				ChangeType: "EXTEND",
+				TTL:        120,
+				Records: []internal.Record{
+					{Content: `"value"`, Name: "_acme-challenge.example.org.", Type: "TXT", TTL: 120},
+				},
+			},
+		},
+		{
+			desc:          "REPLACE",
+			version:       "4.9.1",
+			kind:          "Master",
+			expectedQuery: url.Values{"rrset_name": {"_acme-challenge.example.org."}, "rrset_type": {"TXT"}},
+			expectedRRSet: internal.RRSet{
+				Name:       "_acme-challenge.example.org.",
+				Type:       "TXT",
+				ChangeType: "REPLACE",
+				TTL:        120,
+				Records: []internal.Record{
+					{Content: `"existing"`},
+					{Content: `"value"`, Name: "_acme-challenge.example.org.", Type: "TXT", TTL: 120},
+				},
+			},
+			expectedNotified: 1,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			provider, fake := setupProviderTest(t, test.version, test.kind)
+
+			info := dns01.ChallengeInfo{EffectiveFQDN: "_acme-challenge.example.org.", Value: "value"}
+
+			err := provider.addRecord(context.Background(), "example.org.", info)
+			require.NoError(t, err)
+
+			assert.Equal(t, []url.Values{test.expectedQuery}, fake.queries)
+			assert.Equal(t, map[string][]internal.RRSet{"other": {test.expectedRRSet}}, fake.patches)
+			assert.Equal(t, test.expectedNotified, fake.notify)
+		})
+	}
+}
+
+func TestDNSProvider_removeRecord(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		version       string
+		value         string
+		expectedRRSet internal.RRSet
+	}{
+		{
+			desc:    "PRUNE",
+			version: "5.0.0",
+			value:   "value",
+			expectedRRSet: internal.RRSet{
+				Name:       "_acme-challenge.example.org.",
+				Type:       "TXT",
+				ChangeType: "PRUNE",
+				Records: []internal.Record{
+					{Content: `"value"`, Name: "_acme-challenge.example.org.", Type: "TXT"},
+				},
+			},
+		},
+		{
+			desc:    "REPLACE with the other records",
+			version: "4.9.1",
+			value:   "value",
+			expectedRRSet: internal.RRSet{
+				Name:       "_acme-challenge.example.org.",
+				Type:       "TXT",
+				ChangeType: "REPLACE",
+				TTL:        300,
+				Records:    []internal.Record{{Content: `"existing"`}},
+			},
+		},
+		{
+			desc:    "DELETE the last record",
+			version: "4.9.1",
+			value:   "existing",
+			expectedRRSet: internal.RRSet{
+				Name:       "_acme-challenge.example.org.",
+				Type:       "TXT",
+				ChangeType: "DELETE",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			provider, fake := setupProviderTest(t, test.version, "Native")
+
+			info := dns01.ChallengeInfo{EffectiveFQDN: "_acme-challenge.example.org.", Value: test.value}
+
+			err := provider.removeRecord(context.Background(), "example.org.", info)
+			require.NoError(t, err)
+
+			assert.Equal(t, map[string][]internal.RRSet{"other": {test.expectedRRSet}}, fake.patches)
+		})
+	}
+}
+
+func TestDNSProvider_addRecord_secondary(t *testing.T) {
+	provider, fake := setupProviderTest(t, "4.9.1", "Slave")
+
+	info := dns01.ChallengeInfo{EffectiveFQDN: "_acme-challenge.example.org.", Value: "value"}
+
+	err := provider.addRecord(context.Background(), "example.org.", info)
+	require.EqualError(t, err, "the zone example.org. is a secondary zone (Slave): the records must be updated on the primary server")
+
+	assert.Empty(t, fake.patches)
+}
+
+func TestDNSProvider_getServerName(t *testing.T) {
+	config := NewDefaultConfig()
+	config.ServerName = "default"
+	config.ServerMapping = map[string]string{
+		"example.com":     "server-a",
+		"sub.example.com": "server-b",
+	}
+
+	provider := &DNSProvider{config: config}
+
+	testCases := []struct {
+		zone     string
+		expected string
+	}{
+		{zone: "example.com.", expected: "server-a"},
+		{zone: "other.Example.com.", expected: "server-a"},
+		{zone: "sub.example.com.", expected: "server-b"},
+		{zone: "notexample.com.", expected: "default"},
+		{zone: "example.org.", expected: "default"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.zone, func(t *testing.T) {
+			assert.Equal(t, test.expected, provider.getServerName(test.zone))
+		})
+	}
+}
+
 func mustParse(rawURL string) *url.URL {
 	u, err := url.Parse(rawURL)
 	if err != nil {
@@ -0,0 +1,295 @@
+package internal
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/crypto"
+	"github.com/jcmturner/gokrb5/v8/gssapi"
+	"github.com/jcmturner/gokrb5/v8/iana/flags"
+	"github.com/jcmturner/gokrb5/v8/iana/keyusage"
+	"github.com/jcmturner/gokrb5/v8/messages"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"github.com/jcmturner/gokrb5/v8/types"
+	"github.com/miekg/dns"
+)
+
+// GSSTSIGAlgorithm the TSIG algorithm name of GSS-TSIG (RFC 3645).
+const GSSTSIGAlgorithm = "gss-tsig."
+
+// tkeyModeGSSAPI the TKEY mode of the GSS-API negotiation (RFC 2930 Section 2.5).
+const tkeyModeGSSAPI = 3
+
+const contextLifetime = time.Hour
+
+type gssContext struct {
+	key        types.EncryptionKey
+	flags      byte
+	seqNum     uint64
+	expiration time.Time
+}
+
+// GSSTSIG negotiates the security contexts of GSS-TSIG (RFC 3645) with Kerberos,
+// and signs the messages with these contexts (implements dns.TsigProvider).
+type GSSTSIG struct {
+	client *client.Client
+
+	mu       sync.Mutex
+	keyNames map[string]string
+	contexts map[string]*gssContext
+}
+
+// NewGSSTSIG creates a new GSSTSIG.
+func NewGSSTSIG(cl *client.Client) *GSSTSIG {
+	return &GSSTSIG{
+		client:   cl,
+		keyNames: make(map[string]string),
+		contexts: make(map[string]*gssContext),
+	}
+}
+
+// KeyName returns the key name of a security context with the nameserver,
+// the context is negotiated if there is no valid context.
+// The host is used to build the service principal name (DNS/host) of the nameserver.
+// The TKEY queries are sent with the client (over TCP if the transport of the client is UDP).
+func (g *GSSTSIG) KeyName(c *dns.Client, nameserver, host string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if keyName, ok := g.keyNames[nameserver]; ok {
+		if time.Until(g.contexts[keyName].expiration) > time.Minute {
+			return keyName, nil
+		}
+
+		delete(g.contexts, keyName)
+		delete(g.keyNames, nameserver)
+	}
+
+	keyName, gssCtx, err := g.negotiate(c, nameserver, host)
+	if err != nil {
+		return "", err
+	}
+
+	g.keyNames[nameserver] = keyName
+	g.contexts[keyName] = gssCtx
+
+	return keyName, nil
+}
+
+// Generate implements dns.TsigProvider.
+func (g *GSSTSIG) Generate(msg []byte, t *dns.TSIG) ([]byte, error) {
+	if dns.CanonicalName(t.Algorithm) != GSSTSIGAlgorithm {
+		return nil, dns.ErrKeyAlg
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	gssCtx, ok := g.contexts[t.Hdr.Name]
+	if !ok {
+		return nil, dns.ErrSecret
+	}
+
+	token := gssapi.MICToken{
+		Flags:     gssCtx.flags,
+		SndSeqNum: gssCtx.seqNum,
+		Payload:   msg,
+	}
+
+	err := token.SetChecksum(gssCtx.key, keyusage.GSSAPI_INITIATOR_SIGN)
+	if err != nil {
+		return nil, err
+	}
+
+	gssCtx.seqNum++
+
+	return token.Marshal()
+}
+
+// Verify implements dns.TsigProvider.
+func (g *GSSTSIG) Verify(msg []byte, t *dns.TSIG) error {
+	if dns.CanonicalName(t.Algorithm) != GSSTSIGAlgorithm {
+		return dns.ErrKeyAlg
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	gssCtx, ok := g.contexts[t.Hdr.Name]
+	if !ok {
+		return dns.ErrSecret
+	}
+
+	mac, err := hex.DecodeString(t.MAC)
+	if err != nil {
+		return err
+	}
+
+	var token gssapi.MICToken
+
+	err = token.Unmarshal(mac, true)
+	if err != nil {
+		return err
+	}
+
+	token.Payload = msg
+
+	_, err = token.Verify(gssCtx.key, keyusage.GSSAPI_ACCEPTOR_SIGN)
+	if err != nil {
+		return dns.ErrSig
+	}
+
+	return nil
+}
+
+// negotiate exchanges the Kerberos tokens with the nameserver through TKEY queries (RFC 3645 Section 3.1).
+func (g *GSSTSIG) negotiate(c *dns.Client, nameserver, host string) (string, *gssContext, error) {
+	tkt, sessionKey, err := g.client.GetServiceTicket("DNS/" + host)
+	if err != nil {
+		return "", nil, fmt.Errorf("get service ticket: %w", err)
+	}
+
+	apReq, err := spnego.NewKRB5TokenAPREQ(g.client, tkt, sessionKey,
+		[]int{gssapi.ContextFlagMutual, gssapi.ContextFlagInteg}, []int{flags.APOptionMutualRequired})
+	if err != nil {
+		return "", nil, fmt.Errorf("create AP-REQ: %w", err)
+	}
+
+	token, err := apReq.Marshal()
+	if err != nil {
+		return "", nil, fmt.Errorf("marshal AP-REQ: %w", err)
+	}
+
+	// The initiator sequence number is defined by the authenticator of the AP-REQ.
+	authenticator, err := decryptAuthenticator(apReq.APReq, sessionKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	keyName := dns.Fqdn(fmt.Sprintf("%d.sig-%s", rand.Uint32(), strings.ToLower(dns.Fqdn(host))))
+
+	now := time.Now()
+	expiration := now.Add(contextLifetime)
+
+	m := new(dns.Msg)
+	m.SetQuestion(keyName, dns.TypeTKEY)
+	m.Extra = append(m.Extra, &dns.TKEY{
+		Hdr:        dns.RR_Header{Name: keyName, Rrtype: dns.TypeTKEY, Class: dns.ClassANY},
+		Algorithm:  GSSTSIGAlgorithm,
+		Mode:       tkeyModeGSSAPI,
+		Inception:  uint32(now.Unix()),
+		Expiration: uint32(expiration.Unix()),
+		KeySize:    uint16(len(token)),
+		Key:        hex.EncodeToString(token),
+	})
+
+	tc := &dns.Client{Net: c.Net, Timeout: c.Timeout, TLSConfig: c.TLSConfig}
+	if tc.Net == "" || tc.Net == "udp" {
+		tc.Net = "tcp"
+	}
+
+	reply, _, err := tc.Exchange(m, nameserver)
+	if err != nil {
+		return "", nil, fmt.Errorf("TKEY query: %w", err)
+	}
+
+	if reply.Rcode != dns.RcodeSuccess {
+		return "", nil, fmt.Errorf("TKEY query: server replied: %s", dns.RcodeToString[reply.Rcode])
+	}
+
+	tkey, err := findTKEY(reply)
+	if err != nil {
+		return "", nil, err
+	}
+
+	gssCtx, err := readAPRep(tkey, sessionKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	gssCtx.seqNum = uint64(authenticator.SeqNumber)
+	gssCtx.expiration = expiration
+
+	return keyName, gssCtx, nil
+}
+
+func findTKEY(reply *dns.Msg) (*dns.TKEY, error) {
+	for _, rr := range reply.Answer {
+		tkey, ok := rr.(*dns.TKEY)
+		if !ok {
+			continue
+		}
+
+		if tkey.Error != dns.RcodeSuccess {
+			return nil, fmt.Errorf("TKEY query: server replied: %s", dns.RcodeToString[int(tkey.Error)])
+		}
+
+		return tkey, nil
+	}
+
+	return nil, errors.New("TKEY query: no TKEY record in the reply")
+}
+
+// readAPRep reads the AP-REP of the acceptor, and returns the security context.
+func readAPRep(tkey *dns.TKEY, sessionKey types.EncryptionKey) (*gssContext, error) {
+	raw, err := hex.DecodeString(tkey.Key)
+	if err != nil {
+		return nil, fmt.Errorf("decode TKEY key: %w", err)
+	}
+
+	var token spnego.KRB5Token
+
+	err = token.Unmarshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal AP-REP: %w", err)
+	}
+
+	if token.IsKRBError() {
+		return nil, fmt.Errorf("KRB-ERROR: %s", token.KRBError.Error())
+	}
+
+	if !token.IsAPRep() {
+		return nil, errors.New("the TKEY key is not an AP-REP")
+	}
+
+	b, err := crypto.DecryptEncPart(token.APRep.EncPart, sessionKey, keyusage.AP_REP_ENCPART)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt AP-REP: %w", err)
+	}
+
+	var part messages.EncAPRepPart
+
+	err = part.Unmarshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal AP-REP encrypted part: %w", err)
+	}
+
+	// The subkey of the acceptor, if any, takes precedence over the session key (RFC 4121 Section 2).
+	if part.Subkey.KeyType != 0 {
+		return &gssContext{key: part.Subkey, flags: gssapi.MICTokenFlagAcceptorSubkey}, nil
+	}
+
+	return &gssContext{key: sessionKey}, nil
+}
+
+func decryptAuthenticator(apReq messages.APReq, sessionKey types.EncryptionKey) (types.Authenticator, error) {
+	var authenticator types.Authenticator
+
+	b, err := crypto.DecryptEncPart(apReq.EncryptedAuthenticator, sessionKey, keyusage.AP_REQ_AUTHENTICATOR)
+	if err != nil {
+		return authenticator, fmt.Errorf("decrypt authenticator: %w", err)
+	}
+
+	err = authenticator.Unmarshal(b)
+	if err != nil {
+		return authenticator, fmt.Errorf("unmarshal authenticator: %w", err)
+	}
+
+	return authenticator, nil
+}
@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/jcmturner/gokrb5/v8/crypto"
+	"github.com/jcmturner/gokrb5/v8/gssapi"
+	"github.com/jcmturner/gokrb5/v8/iana/etypeID"
+	"github.com/jcmturner/gokrb5/v8/iana/keyusage"
+	"github.com/jcmturner/gokrb5/v8/types"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKeyName = "123.sig-dc.example.com."
+
+func setupGSSTSIG(t *testing.T) (*GSSTSIG, types.EncryptionKey) {
+	t.Helper()
+
+	etype, err := crypto.GetEtype(etypeID.AES256_CTS_HMAC_SHA1_96)
+	require.NoError(t, err)
+
+	key, err := types.GenerateEncryptionKey(etype)
+	require.NoError(t, err)
+
+	g := NewGSSTSIG(nil)
+	g.keyNames["dc.example.com:53"] = testKeyName
+	g.contexts[testKeyName] = &gssContext{
+		key:        key,
+		flags:      gssapi.MICTokenFlagAcceptorSubkey,
+		seqNum:     42,
+		expiration: time.Now().Add(time.Hour),
+	}
+
+	return g, key
+}
+
+func TestGSSTSIG_KeyName(t *testing.T) {
+	g, _ := setupGSSTSIG(t)
+
+	keyName, err := g.KeyName(&dns.Client{}, "dc.example.com:53", "dc.example.com")
+	require.NoError(t, err)
+
+	assert.Equal(t, testKeyName, keyName)
+}
+
+func TestGSSTSIG_Generate(t *testing.T) {
+	g, key := setupGSSTSIG(t)
+
+	msg := []byte("message")
+
+	mac, err := g.Generate(msg, newTSIG(testKeyName, GSSTSIGAlgorithm, ""))
+	require.NoError(t, err)
+
+	var token gssapi.MICToken
+
+	err = token.Unmarshal(mac, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(42), token.SndSeqNum)
+	assert.Equal(t, byte(gssapi.MICTokenFlagAcceptorSubkey), token.Flags)
+
+	token.Payload = msg
+
+	ok, err := token.Verify(key, keyusage.GSSAPI_INITIATOR_SIGN)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// the sequence number is incremented for each message.
+	mac, err = g.Generate(msg, newTSIG(testKeyName, GSSTSIGAlgorithm, ""))
+	require.NoError(t, err)
+
+	err = token.Unmarshal(mac, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(43), token.SndSeqNum)
+}
+
+func TestGSSTSIG_Generate_error(t *testing.T) {
+	g, _ := setupGSSTSIG(t)
+
+	_, err := g.Generate([]byte("message"), newTSIG("unknown.", GSSTSIGAlgorithm, ""))
+	require.ErrorIs(t, err, dns.ErrSecret)
+
+	_, err = g.Generate([]byte("message"), newTSIG(testKeyName, dns.HmacSHA256, ""))
+	require.ErrorIs(t, err, dns.ErrKeyAlg)
+}
+
+func TestGSSTSIG_Verify(t *testing.T) {
+	g, key := setupGSSTSIG(t)
+
+	msg := []byte("message")
+
+	token := gssapi.MICToken{
+		Flags:     gssapi.MICTokenFlagSentByAcceptor | gssapi.MICTokenFlagAcceptorSubkey,
+		SndSeqNum: 7,
+		Payload:   msg,
+	}
+
+	err := token.SetChecksum(key, keyusage.GSSAPI_ACCEPTOR_SIGN)
+	require.NoError(t, err)
+
+	mac, err := token.Marshal()
+	require.NoError(t, err)
+
+	err = g.Verify(msg, newTSIG(testKeyName, GSSTSIGAlgorithm, hex.EncodeToString(mac)))
+	require.NoError(t, err)
+
+	err = g.Verify([]byte("altered"), newTSIG(testKeyName, GSSTSIGAlgorithm, hex.EncodeToString(mac)))
+	require.ErrorIs(t, err, dns.ErrSig)
+}
+
+func TestGSSTSIG_Verify_initiator(t *testing.T) {
+	g, _ := setupGSSTSIG(t)
+
+	msg := []byte("message")
+
+	// a token of the initiator is not a valid reply.
+	mac, err := g.Generate(msg, newTSIG(testKeyName, GSSTSIGAlgorithm, ""))
+	require.NoError(t, err)
+
+	err = g.Verify(msg, newTSIG(testKeyName, GSSTSIGAlgorithm, hex.EncodeToString(mac)))
+	require.EqualError(t, err, "unexpected acceptor flag is not set: expecting a token from the acceptor, not in the initiator")
+}
+
+func Test_findTKEY(t *testing.T) {
+	reply := new(dns.Msg)
+	reply.Answer = []dns.RR{
+		&dns.TKEY{Hdr: dns.RR_Header{Name: testKeyName, Rrtype: dns.TypeTKEY}, Algorithm: GSSTSIGAlgorithm, Key: "abcd"},
+	}
+
+	tkey, err := findTKEY(reply)
+	require.NoError(t, err)
+
+	assert.Equal(t, "abcd", tkey.Key)
+}
+
+func Test_findTKEY_error(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		answer   []dns.RR
+		expected string
+	}{
+		{
+			desc:     "no TKEY",
+			expected: "TKEY query: no TKEY record in the reply",
+		},
+		{
+			desc: "TKEY error",
+			answer: []dns.RR{
+				&dns.TKEY{Hdr: dns.RR_Header{Name: testKeyName, Rrtype: dns.TypeTKEY}, Error: dns.RcodeBadKey},
+			},
+			expected: "TKEY query: server replied: BADKEY",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			reply := new(dns.Msg)
+			reply.Answer = test.answer
+
+			_, err := findTKEY(reply)
+			require.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func newTSIG(keyName, algorithm, mac string) *dns.TSIG {
+	return &dns.TSIG{
+		Hdr:       dns.RR_Header{Name: keyName, Rrtype: dns.TypeTSIG, Class: dns.ClassANY},
+		Algorithm: algorithm,
+		MAC:       mac,
+	}
+}
@@ -2,9 +2,12 @@
 package rfc2136
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"net"
+	"os"
 	"strings"
 	"time"
 
@@ -12,6 +15,10 @@ import (
 	"github.com/go-acme/lego/v4/challenge/dns01"
 	"github.com/go-acme/lego/v4/platform/config/env"
 	"github.com/go-acme/lego/v4/providers/dns/rfc2136/internal"
+	"github.com/jcmturner/gokrb5/v8/client"
+	krb5config "github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/keytab"
 	"github.com/miekg/dns"
 )
 
@@ -25,9 +32,19 @@ const (
 	EnvTSIGSecret    = envNamespace + "TSIG_SECRET"
 	EnvTSIGAlgorithm = envNamespace + "TSIG_ALGORITHM"
 
+	EnvGSSKrb5Conf = envNamespace + "GSS_KRB5_CONF"
+	EnvGSSKeytab   = envNamespace + "GSS_KEYTAB"
+	EnvGSSUsername = envNamespace + "GSS_USERNAME"
+	EnvGSSRealm    = envNamespace + "GSS_REALM"
+	EnvGSSCCache   = envNamespace + "GSS_CCACHE"
+
 	EnvNameserver = envNamespace + "NAMESERVER"
 	EnvDNSTimeout = envNamespace + "DNS_TIMEOUT"
 
+	EnvTransport             = envNamespace + "TRANSPORT"
+	EnvTLSCAFile             = envNamespace + "TLS_CA_FILE"
+	EnvTLSInsecureSkipVerify = envNamespace + "TLS_INSECURE_SKIP_VERIFY"
+
 	EnvTTL                = envNamespace + "TTL"
 	EnvPropagationTimeout = envNamespace + "PROPAGATION_TIMEOUT"
 	EnvPollingInterval    = envNamespace + "POLLING_INTERVAL"
@@ -36,16 +53,36 @@ const (
 
 var _ challenge.ProviderTimeout = (*DNSProvider)(nil)
 
+// Transports of the DNS updates.
+const (
+	TransportUDP = "udp"
+	TransportTCP = "tcp"
+	TransportTLS = "tcp-tls"
+)
+
 // Config is used to configure the creation of the DNSProvider.
 type Config struct {
+	// Nameserver an ordered list of nameservers, separated by commas:
+	// the update is sent to the next nameserver when a nameserver fails.
 	Nameserver string
 
+	Transport             string
+	TLSCAFile             string
+	TLSInsecureSkipVerify bool
+
 	TSIGFile string
 
 	TSIGAlgorithm string
 	TSIGKey       string
 	TSIGSecret    string
 
+	// GSS-TSIG (Kerberos) credentials: a keytab (with a username and a realm), or a credentials cache.
+	GSSKrb5Conf string
+	GSSKeytab   string
+	GSSUsername string
+	GSSRealm    string
+	GSSCCache   string
+
 	PropagationTimeout time.Duration
 	PollingInterval    time.Duration
 	TTL                int
@@ -56,28 +93,40 @@ type Config struct {
 // NewDefaultConfig returns a default configuration for the DNSProvider.
 func NewDefaultConfig() *Config {
 	return &Config{
-		TSIGAlgorithm:      env.GetOrDefaultString(EnvTSIGAlgorithm, dns.HmacSHA1),
-		TTL:                env.GetOrDefaultInt(EnvTTL, dns01.DefaultTTL),
-		PropagationTimeout: env.GetOrDefaultSecond(EnvPropagationTimeout, env.GetOrDefaultSecond("RFC2136_TIMEOUT", dns01.DefaultPropagationTimeout)),
-		PollingInterval:    env.GetOrDefaultSecond(EnvPollingInterval, dns01.DefaultPollingInterval),
-		SequenceInterval:   env.GetOrDefaultSecond(EnvSequenceInterval, dns01.DefaultPropagationTimeout),
-		DNSTimeout:         env.GetOrDefaultSecond(EnvDNSTimeout, 10*time.Second),
+		TSIGAlgorithm:         env.GetOrDefaultString(EnvTSIGAlgorithm, dns.HmacSHA1),
+		TTL:                   env.GetOrDefaultInt(EnvTTL, dns01.DefaultTTL),
+		PropagationTimeout:    env.GetOrDefaultSecond(EnvPropagationTimeout, env.GetOrDefaultSecond("RFC2136_TIMEOUT", dns01.DefaultPropagationTimeout)),
+		PollingInterval:       env.GetOrDefaultSecond(EnvPollingInterval, dns01.DefaultPollingInterval),
+		SequenceInterval:      env.GetOrDefaultSecond(EnvSequenceInterval, dns01.DefaultPropagationTimeout),
+		DNSTimeout:            env.GetOrDefaultSecond(EnvDNSTimeout, 10*time.Second),
+		Transport:             env.GetOrDefaultString(EnvTransport, TransportUDP),
+		TLSCAFile:             env.GetOrDefaultString(EnvTLSCAFile, ""),
+		TLSInsecureSkipVerify: env.GetOrDefaultBool(EnvTLSInsecureSkipVerify, false),
+		GSSKrb5Conf:           env.GetOrDefaultString(EnvGSSKrb5Conf, env.GetOrDefaultString("KRB5_CONFIG", "/etc/krb5.conf")),
+		GSSCCache:             env.GetOrDefaultString(EnvGSSCCache, strings.TrimPrefix(env.GetOrDefaultString("KRB5CCNAME", ""), "FILE:")),
 	}
 }
 
 // DNSProvider implements the challenge.Provider interface.
 type DNSProvider struct {
 	config *Config
+
+	nameservers []string
+	tlsConfig   *tls.Config
+
+	gss *internal.GSSTSIG
 }
 
 // NewDNSProvider returns a DNSProvider instance configured for rfc2136
 // dynamic update. Configured with environment variables:
-// RFC2136_NAMESERVER: Network address in the form "host" or "host:port".
+// RFC2136_NAMESERVER: Network address in the form "host" or "host:port" (or an ordered list, separated by commas).
+// RFC2136_TRANSPORT: The transport of the updates: udp, tcp, or tcp-tls (DNS over TLS).
 // RFC2136_TSIG_ALGORITHM: Defaults to hmac-md5.sig-alg.reg.int. (HMAC-MD5).
 // See https://github.com/miekg/dns/blob/master/tsig.go for supported values.
 // RFC2136_TSIG_KEY: Name of the secret key as defined in DNS server configuration.
 // RFC2136_TSIG_SECRET: Secret key payload.
 // RFC2136_PROPAGATION_TIMEOUT: DNS propagation timeout in time.ParseDuration format. (60s)
+// RFC2136_TSIG_ALGORITHM=gss-tsig enables GSS-TSIG (Kerberos) with the RFC2136_GSS_* variables.
 // To disable TSIG authentication, leave the RFC2136_TSIG* variables unset.
 func NewDNSProvider() (*DNSProvider, error) {
 	values, err := env.Get(EnvNameserver)
@@ -93,6 +142,10 @@ func NewDNSProvider() (*DNSProvider, error) {
 	config.TSIGKey = env.GetOrFile(EnvTSIGKey)
 	config.TSIGSecret = env.GetOrFile(EnvTSIGSecret)
 
+	config.GSSKeytab = env.GetOrDefaultString(EnvGSSKeytab, "")
+	config.GSSUsername = env.GetOrFile(EnvGSSUsername)
+	config.GSSRealm = env.GetOrFile(EnvGSSRealm)
+
 	return NewDNSProviderConfig(config)
 }
 
@@ -117,13 +170,25 @@ func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
 		config.TSIGSecret = key.Secret
 	}
 
-	// Append the default DNS port if none is specified.
-	if _, _, err := net.SplitHostPort(config.Nameserver); err != nil {
-		if strings.Contains(err.Error(), "missing port") {
-			config.Nameserver = net.JoinHostPort(config.Nameserver, "53")
-		} else {
+	var tlsConfig *tls.Config
+
+	switch config.Transport {
+	case "", TransportUDP, TransportTCP:
+		// valid transport
+	case TransportTLS:
+		var err error
+
+		tlsConfig, err = newTLSConfig(config)
+		if err != nil {
 			return nil, fmt.Errorf("rfc2136: %w", err)
 		}
+	default:
+		return nil, fmt.Errorf("rfc2136: unsupported transport: %s", config.Transport)
+	}
+
+	nameservers, err := parseNameservers(config.Nameserver, config.Transport)
+	if err != nil {
+		return nil, fmt.Errorf("rfc2136: %w", err)
 	}
 
 	if config.TSIGKey == "" || config.TSIGSecret == "" {
@@ -144,11 +209,83 @@ func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
 	switch config.TSIGAlgorithm {
 	case dns.HmacSHA1, dns.HmacSHA224, dns.HmacSHA256, dns.HmacSHA384, dns.HmacSHA512:
 		// valid algorithm
+	case internal.GSSTSIGAlgorithm:
+		// valid algorithm
 	default:
 		return nil, fmt.Errorf("rfc2136: unsupported TSIG algorithm: %s", config.TSIGAlgorithm)
 	}
 
-	return &DNSProvider{config: config}, nil
+	provider := &DNSProvider{
+		config:      config,
+		nameservers: nameservers,
+		tlsConfig:   tlsConfig,
+	}
+
+	if config.TSIGAlgorithm == internal.GSSTSIGAlgorithm {
+		provider.gss, err = newGSSTSIG(config, nameservers)
+		if err != nil {
+			return nil, fmt.Errorf("rfc2136: %w", err)
+		}
+	}
+
+	return provider, nil
+}
+
+func newGSSTSIG(config *Config, nameservers []string) (*internal.GSSTSIG, error) {
+	// The service principal name of the nameserver (DNS/host) requires the hostname.
+	for _, nameserver := range nameservers {
+		host, _, _ := net.SplitHostPort(nameserver)
+		if net.ParseIP(host) != nil {
+			return nil, fmt.Errorf("GSS-TSIG requires the hostname of the nameserver: %s", nameserver)
+		}
+	}
+
+	cl, err := newKerberosClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("GSS-TSIG: %w", err)
+	}
+
+	return internal.NewGSSTSIG(cl), nil
+}
+
+func newKerberosClient(config *Config) (*client.Client, error) {
+	krb5conf, err := krb5config.Load(config.GSSKrb5Conf)
+	if err != nil {
+		return nil, fmt.Errorf("load Kerberos configuration %s: %w", config.GSSKrb5Conf, err)
+	}
+
+	// Active Directory doesn't support FAST (RFC 6113).
+	settings := client.DisablePAFXFAST(true)
+
+	switch {
+	case config.GSSKeytab != "":
+		if config.GSSUsername == "" || config.GSSRealm == "" {
+			return nil, errors.New("the username and the realm are required with a keytab")
+		}
+
+		kt, err := keytab.Load(config.GSSKeytab)
+		if err != nil {
+			return nil, fmt.Errorf("load keytab %s: %w", config.GSSKeytab, err)
+		}
+
+		return client.NewWithKeytab(config.GSSUsername, config.GSSRealm, kt, krb5conf, settings), nil
+
+	case config.GSSCCache != "":
+		ccache, err := credentials.LoadCCache(config.GSSCCache)
+		if err != nil {
+			return nil, fmt.Errorf("load credentials cache %s: %w", config.GSSCCache, err)
+		}
+
+		cl, err := client.NewFromCCache(ccache, krb5conf, settings)
+		if err != nil {
+			return nil, fmt.Errorf("create client from the credentials cache %s: %w", config.GSSCCache, err)
+		}
+
+		return cl, nil
+
+	default:
+		return nil, errors.New("a keytab or a credentials cache is required")
+	}
 }
 
 // Timeout returns the timeout and interval to use when checking for DNS propagation.
@@ -185,9 +322,32 @@ func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 	return nil
 }
 
+// changeRecord sends the update to the nameservers, in order, until a nameserver accepts the update.
 func (d *DNSProvider) changeRecord(action, fqdn, value string, ttl int) error {
+	var errs []error
+
+	for _, nameserver := range d.nameservers {
+		err := d.changeRecordOn(nameserver, action, fqdn, value, ttl)
+		if err == nil {
+			return nil
+		}
+
+		if len(d.nameservers) == 1 {
+			return err
+		}
+
+		errs = append(errs, fmt.Errorf("nameserver %s: %w", nameserver, err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d *DNSProvider) changeRecordOn(nameserver, action, fqdn, value string, ttl int) error {
+	// Setup client
+	c := &dns.Client{Net: d.config.Transport, Timeout: d.config.DNSTimeout, TLSConfig: d.tlsConfig}
+
 	// Find the zone for the given fqdn
-	zone, err := dns01.FindZoneByFqdnCustom(fqdn, []string{d.config.Nameserver})
+	zone, err := d.findZone(c, fqdn, nameserver)
 	if err != nil {
 		return err
 	}
@@ -212,11 +372,21 @@ func (d *DNSProvider) changeRecord(action, fqdn, value string, ttl int) error {
 		return fmt.Errorf("unexpected action: %s", action)
 	}
 
-	// Setup client
-	c := &dns.Client{Timeout: d.config.DNSTimeout}
-
 	// TSIG authentication / msg signing
-	if d.config.TSIGKey != "" && d.config.TSIGSecret != "" {
+	switch {
+	case d.gss != nil:
+		host, _, _ := net.SplitHostPort(nameserver)
+
+		keyName, err := d.gss.KeyName(c, nameserver, host)
+		if err != nil {
+			return fmt.Errorf("GSS-TSIG: %w", err)
+		}
+
+		m.SetTsig(keyName, internal.GSSTSIGAlgorithm, 300, time.Now().Unix())
+
+		c.TsigProvider = d.gss
+
+	case d.config.TSIGKey != "" && d.config.TSIGSecret != "":
 		m.SetTsig(d.config.TSIGKey, d.config.TSIGAlgorithm, 300, time.Now().Unix())
 
 		// Secret(s) for TSIG map[<zonename>]<base64 secret>.
@@ -224,7 +394,7 @@ func (d *DNSProvider) changeRecord(action, fqdn, value string, ttl int) error {
 	}
 
 	// Send the query
-	reply, _, err := c.Exchange(m, d.config.Nameserver)
+	reply, _, err := c.Exchange(m, nameserver)
 	if err != nil {
 		return fmt.Errorf("DNS update failed: %w", err)
 	}
@@ -234,3 +404,86 @@ func (d *DNSProvider) changeRecord(action, fqdn, value string, ttl int) error {
 
 	return nil
 }
+
+// findZone finds the zone of the FQDN.
+// The zone is found with the transport of the updates, except for UDP (the default lookup of the zones).
+func (d *DNSProvider) findZone(c *dns.Client, fqdn, nameserver string) (string, error) {
+	if c.Net == "" || c.Net == TransportUDP {
+		return dns01.FindZoneByFqdnCustom(fqdn, []string{nameserver})
+	}
+
+	for _, index := range dns.Split(fqdn) {
+		domain := fqdn[index:]
+
+		m := new(dns.Msg)
+		m.SetQuestion(domain, dns.TypeSOA)
+
+		reply, _, err := c.Exchange(m, nameserver)
+		if err != nil {
+			return "", fmt.Errorf("SOA query for %s: %w", domain, err)
+		}
+
+		for _, rr := range reply.Answer {
+			if soa, ok := rr.(*dns.SOA); ok && strings.EqualFold(soa.Hdr.Name, domain) {
+				return soa.Hdr.Name, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("could not find the zone for %s", fqdn)
+}
+
+// parseNameservers parses an ordered list of nameservers separated by commas,
+// and appends the default port of the transport if none is specified.
+func parseNameservers(raw, transport string) ([]string, error) {
+	port := "53"
+	if transport == TransportTLS {
+		port = "853"
+	}
+
+	var nameservers []string
+
+	for _, nameserver := range strings.Split(raw, ",") {
+		nameserver = strings.TrimSpace(nameserver)
+		if nameserver == "" {
+			continue
+		}
+
+		if _, _, err := net.SplitHostPort(nameserver); err != nil {
+			if !strings.Contains(err.Error(), "missing port") {
+				return nil, err
+			}
+
+			nameserver = net.JoinHostPort(strings.Trim(nameserver, "[]"), port)
+		}
+
+		nameservers = append(nameservers, nameserver)
+	}
+
+	if len(nameservers) == 0 {
+		return nil, errors.New("nameserver missing")
+	}
+
+	return nameservers, nil
+}
+
+func newTLSConfig(config *Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.TLSInsecureSkipVerify,
+	}
+
+	if config.TLSCAFile != "" {
+		pem, err := os.ReadFile(config.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate in the CA file %s", config.TLSCAFile)
+		}
+	}
+
+	return tlsConfig, nil
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"net"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -11,6 +13,8 @@ import (
 
 	"github.com/go-acme/lego/v4/challenge/dns01"
 	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/jcmturner/gokrb5/v8/iana/etypeID"
+	"github.com/jcmturner/gokrb5/v8/keytab"
 	"github.com/miekg/dns"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -36,6 +40,13 @@ var envTest = tester.NewEnvTest(
 	EnvTSIGAlgorithm,
 	EnvNameserver,
 	EnvDNSTimeout,
+	EnvGSSKrb5Conf,
+	EnvGSSKeytab,
+	EnvGSSUsername,
+	EnvGSSRealm,
+	EnvGSSCCache,
+	"KRB5_CONFIG",
+	"KRB5CCNAME",
 ).WithDomain(envDomain)
 
 func TestNewDNSProvider(t *testing.T) {
@@ -67,6 +78,26 @@ func TestNewDNSProvider(t *testing.T) {
 			},
 			expected: "rfc2136: unsupported TSIG algorithm: foo.",
 		},
+		{
+			desc: "GSS-TSIG",
+			envVars: map[string]string{
+				EnvNameserver:    "dc.example.com",
+				EnvTSIGAlgorithm: "gss-tsig",
+				EnvGSSKrb5Conf:   "./internal/fixtures/krb5.conf",
+				EnvGSSKeytab:     createKeytab(t),
+				EnvGSSUsername:   "lego",
+				EnvGSSRealm:      "EXAMPLE.COM",
+			},
+		},
+		{
+			desc: "GSS-TSIG: missing credentials",
+			envVars: map[string]string{
+				EnvNameserver:    "dc.example.com",
+				EnvTSIGAlgorithm: "gss-tsig",
+				EnvGSSKrb5Conf:   "./internal/fixtures/krb5.conf",
+			},
+			expected: "rfc2136: GSS-TSIG: a keytab or a credentials cache is required",
+		},
 		{
 			desc: "valid TSIG file",
 			envVars: map[string]string{
@@ -113,6 +144,10 @@ func TestNewDNSProviderConfig(t *testing.T) {
 		tsigAlgorithm string
 		tsigKey       string
 		tsigSecret    string
+		transport     string
+		gssKeytab     string
+		gssUsername   string
+		gssRealm      string
 	}{
 		{
 			desc:       "success",
@@ -133,23 +168,85 @@ func TestNewDNSProviderConfig(t *testing.T) {
 			nameserver: "example.com",
 			tsigFile:   "./internal/fixtures/sample.conf",
 		},
+		{
+			desc:       "several nameservers",
+			nameserver: "ns1.example.com,ns2.example.com:5353",
+		},
+		{
+			desc:       "invalid nameservers",
+			nameserver: " , ",
+			expected:   "rfc2136: nameserver missing",
+		},
+		{
+			desc:       "invalid transport",
+			nameserver: "example.com",
+			transport:  "quic",
+			expected:   "rfc2136: unsupported transport: quic",
+		},
+		{
+			desc:       "DNS over TLS",
+			nameserver: "example.com",
+			transport:  TransportTLS,
+		},
 		{
 			desc:       "invalid TSIG file",
 			nameserver: "example.com",
 			tsigFile:   "./internal/fixtures/invalid_key.conf",
 			expected:   "rfc2136: read TSIG file ./internal/fixtures/invalid_key.conf: invalid key line: key {",
 		},
+		{
+			desc:          "GSS-TSIG",
+			nameserver:    "dc.example.com",
+			tsigAlgorithm: "gss-tsig",
+			gssKeytab:     createKeytab(t),
+			gssUsername:   "lego",
+			gssRealm:      "EXAMPLE.COM",
+		},
+		{
+			desc:          "GSS-TSIG: IP address",
+			nameserver:    "192.0.2.1",
+			tsigAlgorithm: "gss-tsig",
+			gssKeytab:     createKeytab(t),
+			gssUsername:   "lego",
+			gssRealm:      "EXAMPLE.COM",
+			expected:      "rfc2136: GSS-TSIG requires the hostname of the nameserver: 192.0.2.1:53",
+		},
+		{
+			desc:          "GSS-TSIG: missing username",
+			nameserver:    "dc.example.com",
+			tsigAlgorithm: "gss-tsig",
+			gssKeytab:     createKeytab(t),
+			gssRealm:      "EXAMPLE.COM",
+			expected:      "rfc2136: GSS-TSIG: the username and the realm are required with a keytab",
+		},
+		{
+			desc:          "GSS-TSIG: missing keytab",
+			nameserver:    "dc.example.com",
+			tsigAlgorithm: "gss-tsig",
+			gssKeytab:     "./internal/fixtures/missing.keytab",
+			gssUsername:   "lego",
+			gssRealm:      "EXAMPLE.COM",
+			expected:      "rfc2136: GSS-TSIG: load keytab ./internal/fixtures/missing.keytab: open ./internal/fixtures/missing.keytab: no such file or directory",
+		},
 	}
 
 	for _, test := range testCases {
 		t.Run(test.desc, func(t *testing.T) {
 			config := NewDefaultConfig()
+			config.GSSKrb5Conf = "./internal/fixtures/krb5.conf"
+			config.GSSKeytab = test.gssKeytab
+			config.GSSUsername = test.gssUsername
+			config.GSSRealm = test.gssRealm
 			config.Nameserver = test.nameserver
 			config.TSIGFile = test.tsigFile
 			config.TSIGAlgorithm = test.tsigAlgorithm
 			config.TSIGKey = test.tsigKey
 			config.TSIGSecret = test.tsigSecret
 
+			if test.transport != "" {
+				config.Transport = test.transport
+			}
+
 			p, err := NewDNSProviderConfig(config)
 
 			if test.expected == "" {
@@ -247,6 +344,102 @@ func TestTsigClient(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestServerFailover(t *testing.T) {
+	dns01.ClearFqdnCache()
+	dns.HandleFunc(fakeZone, serverHandlerReturnSuccess)
+	defer dns.HandleRemove(fakeZone)
+
+	refused, refusedAddr, err := runLocalDNSTestServerHandler("udp", dns.HandlerFunc(serverHandlerRefuseUpdate))
+	require.NoError(t, err, "Failed to start test server")
+	defer func() { _ = refused.Shutdown() }()
+
+	server, addr, err := runLocalDNSTestServer(false)
+	require.NoError(t, err, "Failed to start test server")
+	defer func() { _ = server.Shutdown() }()
+
+	config := NewDefaultConfig()
+	config.Nameserver = refusedAddr + "," + addr
+
+	provider, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	err = provider.Present(fakeDomain, "", fakeKeyAuth)
+	require.NoError(t, err)
+}
+
+func TestServerFailover_error(t *testing.T) {
+	dns01.ClearFqdnCache()
+
+	refused, refusedAddr, err := runLocalDNSTestServerHandler("udp", dns.HandlerFunc(serverHandlerRefuseUpdate))
+	require.NoError(t, err, "Failed to start test server")
+	defer func() { _ = refused.Shutdown() }()
+
+	config := NewDefaultConfig()
+	config.Nameserver = refusedAddr + "," + refusedAddr
+
+	provider, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	err = provider.Present(fakeDomain, "", fakeKeyAuth)
+	require.EqualError(t, err, fmt.Sprintf("rfc2136: failed to insert: nameserver %[1]s: DNS update failed: server replied: REFUSED\n"+
+		"nameserver %[1]s: DNS update failed: server replied: REFUSED", refusedAddr))
+}
+
+func TestServerTCP(t *testing.T) {
+	dns01.ClearFqdnCache()
+
+	server, addr, err := runLocalDNSTestServerHandler("tcp", dns.HandlerFunc(serverHandlerReturnSuccess))
+	require.NoError(t, err, "Failed to start test server")
+	defer func() { _ = server.Shutdown() }()
+
+	config := NewDefaultConfig()
+	config.Nameserver = addr
+	config.Transport = TransportTCP
+
+	provider, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	err = provider.Present(fakeDomain, "", fakeKeyAuth)
+	require.NoError(t, err)
+}
+
+func Test_parseNameservers(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		raw       string
+		transport string
+		expected  []string
+	}{
+		{
+			desc:      "one nameserver",
+			raw:       "ns1.example.com",
+			transport: TransportUDP,
+			expected:  []string{"ns1.example.com:53"},
+		},
+		{
+			desc:      "several nameservers",
+			raw:       "ns1.example.com:5353, 192.0.2.1,[2001:db8::1]",
+			transport: TransportTCP,
+			expected:  []string{"ns1.example.com:5353", "192.0.2.1:53", "[2001:db8::1]:53"},
+		},
+		{
+			desc:      "DNS over TLS",
+			raw:       "ns1.example.com,ns2.example.com:8853",
+			transport: TransportTLS,
+			expected:  []string{"ns1.example.com:853", "ns2.example.com:8853"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			nameservers, err := parseNameservers(test.raw, test.transport)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, nameservers)
+		})
+	}
+}
+
 func TestValidUpdatePacket(t *testing.T) {
 	reqChan := make(chan *dns.Msg, 10)
 
@@ -294,6 +487,25 @@ func TestValidUpdatePacket(t *testing.T) {
 	}
 }
 
+func createKeytab(t *testing.T) string {
+	t.Helper()
+
+	kt := keytab.New()
+
+	err := kt.AddEntry("lego", "EXAMPLE.COM", "secret", time.Now(), 1, etypeID.AES256_CTS_HMAC_SHA1_96)
+	require.NoError(t, err)
+
+	b, err := kt.Marshal()
+	require.NoError(t, err)
+
+	filename := filepath.Join(t.TempDir(), "lego.keytab")
+
+	err = os.WriteFile(filename, b, 0o600)
+	require.NoError(t, err)
+
+	return filename
+}
+
 func runLocalDNSTestServer(tsig bool) (*dns.Server, string, error) {
 	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
 	if err != nil {
@@ -327,6 +539,51 @@ func runLocalDNSTestServer(tsig bool) (*dns.Server, string, error) {
 	return server, pc.LocalAddr().String(), nil
 }
 
+func runLocalDNSTestServerHandler(network string, handler dns.Handler) (*dns.Server, string, error) {
+	server := &dns.Server{
+		Handler:      handler,
+		ReadTimeout:  time.Hour,
+		WriteTimeout: time.Hour,
+		MsgAcceptFunc: func(dh dns.Header) dns.MsgAcceptAction {
+			// bypass defaultMsgAcceptFunc to allow dynamic update (https://github.com/miekg/dns/pull/830)
+			return dns.MsgAccept
+		},
+	}
+
+	var addr string
+
+	switch network {
+	case "tcp":
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, "", err
+		}
+
+		server.Listener = l
+		addr = l.Addr().String()
+
+	default:
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			return nil, "", err
+		}
+
+		server.PacketConn = pc
+		addr = pc.LocalAddr().String()
+	}
+
+	waitLock := sync.Mutex{}
+	waitLock.Lock()
+	server.NotifyStartedFunc = waitLock.Unlock
+
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+
+	waitLock.Lock()
+	return server, addr, nil
+}
+
 func serverHandlerHello(w dns.ResponseWriter, req *dns.Msg) {
 	m := new(dns.Msg)
 	m.SetReply(req)
@@ -357,6 +614,18 @@ func serverHandlerReturnSuccess(w dns.ResponseWriter, req *dns.Msg) {
 	_ = w.WriteMsg(m)
 }
 
+func serverHandlerRefuseUpdate(w dns.ResponseWriter, req *dns.Msg) {
+	if req.Opcode == dns.OpcodeUpdate {
+		m := new(dns.Msg)
+		m.SetRcode(req, dns.RcodeRefused)
+		_ = w.WriteMsg(m)
+
+		return
+	}
+
+	serverHandlerReturnSuccess(w, req)
+}
+
 func serverHandlerReturnErr(w dns.ResponseWriter, req *dns.Msg) {
 	m := new(dns.Msg)
 	m.SetRcode(req, dns.RcodeNotZone)
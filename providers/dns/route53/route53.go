@@ -3,10 +3,12 @@ package route53
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -32,11 +34,15 @@ const (
 	EnvSecretAccessKey = envNamespace + "SECRET_ACCESS_KEY"
 	EnvRegion          = envNamespace + "REGION"
 	EnvHostedZoneID    = envNamespace + "HOSTED_ZONE_ID"
+	EnvHostedZoneIDs   = envNamespace + "HOSTED_ZONE_IDS"
+	EnvPrivateZone     = envNamespace + "PRIVATE_ZONE"
 	EnvMaxRetries      = envNamespace + "MAX_RETRIES"
 	EnvAssumeRoleArn   = envNamespace + "ASSUME_ROLE_ARN"
 	EnvExternalID      = envNamespace + "EXTERNAL_ID"
+	EnvZoneAssumeRoles = envNamespace + "ZONE_ASSUME_ROLES"
 
 	EnvWaitForRecordSetsChanged = envNamespace + "WAIT_FOR_RECORD_SETS_CHANGED"
+	EnvMaxWaitTime              = envNamespace + "MAX_WAIT_TIME"
 
 	EnvTTL                = envNamespace + "TTL"
 	EnvPropagationTimeout = envNamespace + "PROPAGATION_TIMEOUT"
@@ -45,6 +51,12 @@ const (
 
 var _ challenge.ProviderTimeout = (*DNSProvider)(nil)
 
+// AssumeRole an IAM role assumed with STS.
+type AssumeRole struct {
+	ARN        string
+	ExternalID string
+}
+
 // Config is used to configure the creation of the DNSProvider.
 type Config struct {
 	// Static credential chain.
@@ -54,12 +66,29 @@ type Config struct {
 	SessionToken    string
 	Region          string
 
-	HostedZoneID  string
+	HostedZoneID string
+	// HostedZoneIDs the hosted zone IDs by domain (a zone or a parent domain, ex: example.com),
+	// they take precedence over HostedZoneID and the lookup of the hosted zone (ex: public and private zones with the same name).
+	HostedZoneIDs map[string]string
+	// PrivateZone the lookup of the hosted zone selects the private zones instead of the public zones.
+	PrivateZone bool
+
 	MaxRetries    int
 	AssumeRoleArn string
 	ExternalID    string
 
+	// AssumeRoleChain the roles assumed after the role AssumeRoleArn, in order:
+	// each role is assumed with the credentials of the previous role.
+	AssumeRoleChain []AssumeRole
+
+	// ZoneAssumeRoles the chains of roles by domain (a zone or a parent domain, ex: example.com),
+	// used instead of AssumeRoleArn and AssumeRoleChain for the records of the matching zones (ex: a zone of another account).
+	// It is ignored if Client is defined.
+	ZoneAssumeRoles map[string][]AssumeRole
+
 	WaitForRecordSetsChanged bool
+	// MaxWaitTime the maximum time to wait for a change to be INSYNC (PropagationTimeout if not defined).
+	MaxWaitTime time.Duration
 
 	TTL                int
 	PropagationTimeout time.Duration
@@ -70,16 +99,20 @@ type Config struct {
 
 // NewDefaultConfig returns a default configuration for the DNSProvider.
 func NewDefaultConfig() *Config {
+	propagationTimeout := env.GetOrDefaultSecond(EnvPropagationTimeout, 2*time.Minute)
+
 	return &Config{
 		HostedZoneID:  env.GetOrFile(EnvHostedZoneID),
+		PrivateZone:   env.GetOrDefaultBool(EnvPrivateZone, false),
 		MaxRetries:    env.GetOrDefaultInt(EnvMaxRetries, 5),
 		AssumeRoleArn: env.GetOrDefaultString(EnvAssumeRoleArn, ""),
 		ExternalID:    env.GetOrDefaultString(EnvExternalID, ""),
 
 		WaitForRecordSetsChanged: env.GetOrDefaultBool(EnvWaitForRecordSetsChanged, true),
+		MaxWaitTime:              env.GetOrDefaultSecond(EnvMaxWaitTime, propagationTimeout),
 
 		TTL:                env.GetOrDefaultInt(EnvTTL, 10),
-		PropagationTimeout: env.GetOrDefaultSecond(EnvPropagationTimeout, 2*time.Minute),
+		PropagationTimeout: propagationTimeout,
 		PollingInterval:    env.GetOrDefaultSecond(EnvPollingInterval, 4*time.Second),
 	}
 }
@@ -88,6 +121,12 @@ func NewDefaultConfig() *Config {
 type DNSProvider struct {
 	client *route53.Client
 	config *Config
+
+	// awsConfig the configuration of the clients before the roles are assumed.
+	awsConfig aws.Config
+
+	zoneClientsMu sync.Mutex
+	zoneClients   map[string]*route53.Client
 }
 
 // NewDNSProvider returns a DNSProvider instance configured for the AWS Route 53 service.
@@ -100,9 +139,43 @@ type DNSProvider struct {
 //
 // If AWS_HOSTED_ZONE_ID is not set, Lego tries to determine the correct public hosted zone via the FQDN.
 //
+// AWS_ASSUME_ROLE_ARN can be a chain of roles, separated by commas, the external ID of a role can be defined after a pipe:
+// "arn:aws:iam::111111111111:role/first,arn:aws:iam::222222222222:role/second|external-id".
+// AWS_ZONE_ASSUME_ROLES defines the chains of roles by domain, separated by semicolons:
+// "example.com=arn:aws:iam::222222222222:role/dns|external-id;example.org=arn:aws:iam::333333333333:role/dns".
+//
+// AWS_HOSTED_ZONE_IDS (or AWS_HOSTED_ZONE_IDS_FILE) defines the hosted zone IDs by domain:
+// "example.com=Z111;internal.example.com=Z222", or a JSON object: {"example.com": "Z111"}.
+//
 // See also: https://github.com/aws/aws-sdk-go/wiki/configuring-sdk
 func NewDNSProvider() (*DNSProvider, error) {
-	return NewDNSProviderConfig(NewDefaultConfig())
+	config := NewDefaultConfig()
+
+	roles, err := parseAssumeRoles(config.AssumeRoleArn)
+	if err != nil {
+		return nil, fmt.Errorf("route53: %s: %w", EnvAssumeRoleArn, err)
+	}
+
+	if len(roles) > 0 {
+		config.AssumeRoleArn = roles[0].ARN
+		if roles[0].ExternalID != "" {
+			config.ExternalID = roles[0].ExternalID
+		}
+
+		config.AssumeRoleChain = roles[1:]
+	}
+
+	config.ZoneAssumeRoles, err = parseZoneAssumeRoles(env.GetOrDefaultString(EnvZoneAssumeRoles, ""))
+	if err != nil {
+		return nil, fmt.Errorf("route53: %s: %w", EnvZoneAssumeRoles, err)
+	}
+
+	config.HostedZoneIDs, err = parseHostedZoneIDs(env.GetOrFile(EnvHostedZoneIDs))
+	if err != nil {
+		return nil, fmt.Errorf("route53: %s: %w", EnvHostedZoneIDs, err)
+	}
+
+	return NewDNSProviderConfig(config)
 }
 
 // NewDNSProviderConfig takes a given config and returns a custom configured DNSProvider instance.
@@ -117,14 +190,16 @@ func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
 
 	ctx := context.Background()
 
-	cfg, err := createAWSConfig(ctx, config)
+	cfg, err := loadAWSConfig(ctx, config)
 	if err != nil {
 		return nil, err
 	}
 
 	return &DNSProvider{
-		client: route53.NewFromConfig(cfg),
-		config: config,
+		client:      route53.NewFromConfig(assumeRoles(cfg, config.assumeRoles())),
+		config:      config,
+		awsConfig:   cfg,
+		zoneClients: make(map[string]*route53.Client),
 	}, nil
 }
 
@@ -138,12 +213,14 @@ func (d *DNSProvider) Present(domain, token, keyAuth string) error {
 	ctx := context.Background()
 	info := dns01.GetChallengeInfo(domain, keyAuth)
 
-	hostedZoneID, err := d.getHostedZoneID(ctx, info.EffectiveFQDN)
+	client := d.getClient(info.EffectiveFQDN)
+
+	hostedZoneID, err := d.getHostedZoneID(ctx, client, info.EffectiveFQDN)
 	if err != nil {
 		return fmt.Errorf("route53: failed to determine hosted zone ID: %w", err)
 	}
 
-	records, err := d.getExistingRecordSets(ctx, hostedZoneID, info.EffectiveFQDN)
+	records, err := d.getExistingRecordSets(ctx, client, hostedZoneID, info.EffectiveFQDN)
 	if err != nil {
 		return fmt.Errorf("route53: %w", err)
 	}
@@ -168,7 +245,7 @@ func (d *DNSProvider) Present(domain, token, keyAuth string) error {
 		ResourceRecords: records,
 	}
 
-	err = d.changeRecord(ctx, awstypes.ChangeActionUpsert, hostedZoneID, recordSet)
+	err = d.changeRecord(ctx, client, awstypes.ChangeActionUpsert, hostedZoneID, recordSet)
 	if err != nil {
 		return fmt.Errorf("route53: %w", err)
 	}
@@ -181,12 +258,14 @@ func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 	ctx := context.Background()
 	info := dns01.GetChallengeInfo(domain, keyAuth)
 
-	hostedZoneID, err := d.getHostedZoneID(ctx, info.EffectiveFQDN)
+	client := d.getClient(info.EffectiveFQDN)
+
+	hostedZoneID, err := d.getHostedZoneID(ctx, client, info.EffectiveFQDN)
 	if err != nil {
 		return fmt.Errorf("failed to determine Route 53 hosted zone ID: %w", err)
 	}
 
-	existingRecords, err := d.getExistingRecordSets(ctx, hostedZoneID, info.EffectiveFQDN)
+	existingRecords, err := d.getExistingRecordSets(ctx, client, hostedZoneID, info.EffectiveFQDN)
 	if err != nil {
 		return fmt.Errorf("route53: %w", err)
 	}
@@ -218,7 +297,7 @@ func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 		recordSet.ResourceRecords = existingRecords
 	}
 
-	err = d.changeRecord(ctx, action, hostedZoneID, recordSet)
+	err = d.changeRecord(ctx, client, action, hostedZoneID, recordSet)
 	if err != nil {
 		return fmt.Errorf("route53: %w", err)
 	}
@@ -226,7 +305,7 @@ func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 	return nil
 }
 
-func (d *DNSProvider) changeRecord(ctx context.Context, action awstypes.ChangeAction, hostedZoneID string, recordSet *awstypes.ResourceRecordSet) error {
+func (d *DNSProvider) changeRecord(ctx context.Context, client *route53.Client, action awstypes.ChangeAction, hostedZoneID string, recordSet *awstypes.ResourceRecordSet) error {
 	recordSetInput := &route53.ChangeResourceRecordSetsInput{
 		HostedZoneId: aws.String(hostedZoneID),
 		ChangeBatch: &awstypes.ChangeBatch{
@@ -238,7 +317,7 @@ func (d *DNSProvider) changeRecord(ctx context.Context, action awstypes.ChangeAc
 		},
 	}
 
-	resp, err := d.client.ChangeResourceRecordSets(ctx, recordSetInput)
+	resp, err := client.ChangeResourceRecordSets(ctx, recordSetInput)
 	if err != nil {
 		return fmt.Errorf("failed to change record set: %w", err)
 	}
@@ -246,8 +325,13 @@ func (d *DNSProvider) changeRecord(ctx context.Context, action awstypes.ChangeAc
 	changeID := resp.ChangeInfo.Id
 
 	if d.config.WaitForRecordSetsChanged {
-		return wait.For("route53", d.config.PropagationTimeout, d.config.PollingInterval, func() (bool, error) {
-			resp, err := d.client.GetChange(ctx, &route53.GetChangeInput{Id: changeID})
+		maxWaitTime := d.config.MaxWaitTime
+		if maxWaitTime <= 0 {
+			maxWaitTime = d.config.PropagationTimeout
+		}
+
+		return wait.For("route53", maxWaitTime, d.config.PollingInterval, func() (bool, error) {
+			resp, err := client.GetChange(ctx, &route53.GetChangeInput{Id: changeID})
 			if err != nil {
 				return false, fmt.Errorf("failed to query change status: %w", err)
 			}
@@ -263,14 +347,14 @@ func (d *DNSProvider) changeRecord(ctx context.Context, action awstypes.ChangeAc
 	return nil
 }
 
-func (d *DNSProvider) getExistingRecordSets(ctx context.Context, hostedZoneID, fqdn string) ([]awstypes.ResourceRecord, error) {
+func (d *DNSProvider) getExistingRecordSets(ctx context.Context, client *route53.Client, hostedZoneID, fqdn string) ([]awstypes.ResourceRecord, error) {
 	listInput := &route53.ListResourceRecordSetsInput{
 		HostedZoneId:    aws.String(hostedZoneID),
 		StartRecordName: aws.String(fqdn),
 		StartRecordType: "TXT",
 	}
 
-	recordSetsOutput, err := d.client.ListResourceRecordSets(ctx, listInput)
+	recordSetsOutput, err := client.ListResourceRecordSets(ctx, listInput)
 	if err != nil {
 		return nil, err
 	}
@@ -290,7 +374,11 @@ func (d *DNSProvider) getExistingRecordSets(ctx context.Context, hostedZoneID, f
 	return records, nil
 }
 
-func (d *DNSProvider) getHostedZoneID(ctx context.Context, fqdn string) (string, error) {
+func (d *DNSProvider) getHostedZoneID(ctx context.Context, client *route53.Client, fqdn string) (string, error) {
+	if _, hostedZoneID, ok := env.MatchDomain(d.config.HostedZoneIDs, fqdn); ok {
+		return hostedZoneID, nil
+	}
+
 	if d.config.HostedZoneID != "" {
 		return d.config.HostedZoneID, nil
 	}
@@ -304,7 +392,7 @@ func (d *DNSProvider) getHostedZoneID(ctx context.Context, fqdn string) (string,
 	reqParams := &route53.ListHostedZonesByNameInput{
 		DNSName: aws.String(dns01.UnFqdn(authZone)),
 	}
-	resp, err := d.client.ListHostedZonesByName(ctx, reqParams)
+	resp, err := client.ListHostedZonesByName(ctx, reqParams)
 	if err != nil {
 		return "", err
 	}
@@ -312,7 +400,7 @@ func (d *DNSProvider) getHostedZoneID(ctx context.Context, fqdn string) (string,
 	var hostedZoneID string
 	for _, hostedZone := range resp.HostedZones {
 		// .Name has a trailing dot
-		if !hostedZone.Config.PrivateZone && ptr.Deref(hostedZone.Name) == authZone {
+		if hostedZone.Config.PrivateZone == d.config.PrivateZone && ptr.Deref(hostedZone.Name) == authZone {
 			hostedZoneID = ptr.Deref(hostedZone.Id)
 			break
 		}
@@ -327,7 +415,52 @@ func (d *DNSProvider) getHostedZoneID(ctx context.Context, fqdn string) (string,
 	return hostedZoneID, nil
 }
 
-func createAWSConfig(ctx context.Context, config *Config) (aws.Config, error) {
+// getClient returns the client of the zone of the FQDN:
+// the client of the chain of roles of the zone (ZoneAssumeRoles), or the default client.
+func (d *DNSProvider) getClient(fqdn string) *route53.Client {
+	domain, roles, ok := env.MatchDomain(d.config.ZoneAssumeRoles, fqdn)
+	if d.config.Client != nil || !ok {
+		return d.client
+	}
+
+	d.zoneClientsMu.Lock()
+	defer d.zoneClientsMu.Unlock()
+
+	client, ok := d.zoneClients[domain]
+	if !ok {
+		client = route53.NewFromConfig(assumeRoles(d.awsConfig, roles))
+		d.zoneClients[domain] = client
+	}
+
+	return client
+}
+
+// assumeRoles returns the default chain of roles: AssumeRoleArn then AssumeRoleChain.
+func (c *Config) assumeRoles() []AssumeRole {
+	if c.AssumeRoleArn == "" {
+		return c.AssumeRoleChain
+	}
+
+	return append([]AssumeRole{{ARN: c.AssumeRoleArn, ExternalID: c.ExternalID}}, c.AssumeRoleChain...)
+}
+
+// assumeRoles assumes the roles in order: each role is assumed with the credentials of the previous role.
+func assumeRoles(cfg aws.Config, roles []AssumeRole) aws.Config {
+	for _, role := range roles {
+		stsClient := sts.NewFromConfig(cfg)
+
+		cfg.Credentials = stscreds.NewAssumeRoleProvider(stsClient, role.ARN, func(options *stscreds.AssumeRoleOptions) {
+			if role.ExternalID != "" {
+				options.ExternalID = aws.String(role.ExternalID)
+			}
+		})
+	}
+
+	return cfg
+}
+
+// loadAWSConfig loads the configuration of the clients, without the roles.
+func loadAWSConfig(ctx context.Context, config *Config) (aws.Config, error) {
 	if err := createAWSConfigCheckParams(config); err != nil {
 		return aws.Config{}, err
 	}
@@ -364,20 +497,7 @@ func createAWSConfig(ctx context.Context, config *Config) (aws.Config, error) {
 		optFns = append(optFns, awsconfig.WithRegion(config.Region))
 	}
 
-	cfg, err := awsconfig.LoadDefaultConfig(ctx, optFns...)
-	if err != nil {
-		return aws.Config{}, err
-	}
-
-	if config.AssumeRoleArn != "" {
-		cfg.Credentials = stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), config.AssumeRoleArn, func(options *stscreds.AssumeRoleOptions) {
-			if config.ExternalID != "" {
-				options.ExternalID = &config.ExternalID
-			}
-		})
-	}
-
-	return cfg, nil
+	return awsconfig.LoadDefaultConfig(ctx, optFns...)
 }
 
 func createAWSConfigCheckParams(config *Config) error {
@@ -395,3 +515,83 @@ func createAWSConfigCheckParams(config *Config) error {
 
 	return nil
 }
+
+// parseAssumeRoles parses a chain of roles: "arn1,arn2|external-id".
+func parseAssumeRoles(value string) ([]AssumeRole, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var roles []AssumeRole
+
+	for _, raw := range strings.Split(value, ",") {
+		arn, externalID, _ := strings.Cut(strings.TrimSpace(raw), "|")
+		if arn == "" {
+			return nil, fmt.Errorf("missing role ARN in %q", value)
+		}
+
+		roles = append(roles, AssumeRole{ARN: arn, ExternalID: externalID})
+	}
+
+	return roles, nil
+}
+
+// parseZoneAssumeRoles parses the chains of roles by domain: "example.com=arn1,arn2|external-id;example.org=arn3".
+func parseZoneAssumeRoles(value string) (map[string][]AssumeRole, error) {
+	mapping, err := env.ParseDomainMapping(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(mapping) == 0 {
+		return nil, nil
+	}
+
+	zoneRoles := make(map[string][]AssumeRole)
+
+	for domain, chain := range mapping {
+		roles, err := parseAssumeRoles(chain)
+		if err != nil {
+			return nil, err
+		}
+
+		zoneRoles[domain] = roles
+	}
+
+	return zoneRoles, nil
+}
+
+// parseHostedZoneIDs parses the hosted zone IDs by domain: "example.com=Z111;example.org=Z222", or a JSON object.
+func parseHostedZoneIDs(value string) (map[string]string, error) {
+	value = strings.TrimSpace(value)
+
+	if !strings.HasPrefix(value, "{") {
+		zoneIDs, err := env.ParseDomainMapping(value)
+		if err != nil {
+			return nil, err
+		}
+
+		for domain, zoneID := range zoneIDs {
+			zoneIDs[domain] = strings.TrimPrefix(zoneID, "/hostedzone/")
+		}
+
+		return zoneIDs, nil
+	}
+
+	zoneIDs := make(map[string]string)
+
+	err := json.Unmarshal([]byte(value), &zoneIDs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	for domain, zoneID := range zoneIDs {
+		if domain == "" || zoneID == "" {
+			return nil, fmt.Errorf("invalid hosted zone ID %q for the domain %q", zoneID, domain)
+		}
+
+		zoneIDs[domain] = strings.TrimPrefix(zoneID, "/hostedzone/")
+	}
+
+	return zoneIDs, nil
+}
@@ -43,7 +43,7 @@ func TestLiveTTL(t *testing.T) {
 		}
 	}()
 
-	zoneID, err := provider.getHostedZoneID(context.Background(), fqdn)
+	zoneID, err := provider.getHostedZoneID(context.Background(), provider.client, fqdn)
 	require.NoError(t, err)
 
 	params := &route53.ListResourceRecordSetsInput{
@@ -2,7 +2,12 @@ package route53
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"regexp"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,6 +15,7 @@ import (
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/route53"
+	awstypes "github.com/aws/aws-sdk-go-v2/service/route53/types"
 	"github.com/go-acme/lego/v4/platform/tester"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -22,11 +28,17 @@ var envTest = tester.NewEnvTest(
 	EnvSecretAccessKey,
 	EnvRegion,
 	EnvHostedZoneID,
+	EnvHostedZoneIDs,
+	EnvPrivateZone,
 	EnvMaxRetries,
 	EnvTTL,
 	EnvPropagationTimeout,
 	EnvPollingInterval,
-	EnvWaitForRecordSetsChanged).
+	EnvAssumeRoleArn,
+	EnvExternalID,
+	EnvZoneAssumeRoles,
+	EnvWaitForRecordSetsChanged,
+	EnvMaxWaitTime).
 	WithDomain(envDomain).
 	WithLiveTestRequirements(EnvAccessKeyID, EnvSecretAccessKey, EnvRegion, envDomain)
 
@@ -95,12 +107,92 @@ func Test_getHostedZoneID_FromEnv(t *testing.T) {
 	provider, err := NewDNSProvider()
 	require.NoError(t, err)
 
-	hostedZoneID, err := provider.getHostedZoneID(context.Background(), "whatever")
+	hostedZoneID, err := provider.getHostedZoneID(context.Background(), provider.client, "whatever")
 	require.NoError(t, err, "HostedZoneID")
 
 	assert.Equal(t, expectedZoneID, hostedZoneID)
 }
 
+func Test_getHostedZoneID_FromEnvMap(t *testing.T) {
+	defer envTest.RestoreEnv()
+	envTest.ClearEnv()
+
+	envTest.Apply(map[string]string{
+		EnvHostedZoneID:  "ZDEFAULT",
+		EnvHostedZoneIDs: "example.com=ZPUBLIC;internal.example.com=/hostedzone/ZPRIVATE",
+	})
+
+	provider, err := NewDNSProvider()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		fqdn     string
+		expected string
+	}{
+		{fqdn: "_acme-challenge.example.com.", expected: "ZPUBLIC"},
+		{fqdn: "_acme-challenge.www.example.com.", expected: "ZPUBLIC"},
+		{fqdn: "_acme-challenge.internal.example.com.", expected: "ZPRIVATE"},
+		{fqdn: "_acme-challenge.db.internal.example.com.", expected: "ZPRIVATE"},
+		{fqdn: "_acme-challenge.example.org.", expected: "ZDEFAULT"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.fqdn, func(t *testing.T) {
+			hostedZoneID, err := provider.getHostedZoneID(context.Background(), provider.client, test.fqdn)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, hostedZoneID)
+		})
+	}
+}
+
+func Test_parseHostedZoneIDs(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		value       string
+		expected    map[string]string
+		expectedErr string
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc:     "pairs",
+			value:    "example.com=Z111; internal.example.com = /hostedzone/Z222",
+			expected: map[string]string{"example.com": "Z111", "internal.example.com": "Z222"},
+		},
+		{
+			desc:     "JSON",
+			value:    `{"example.com": "Z111", "internal.example.com": "/hostedzone/Z222"}`,
+			expected: map[string]string{"example.com": "Z111", "internal.example.com": "Z222"},
+		},
+		{
+			desc:        "missing zone ID",
+			value:       "example.com=Z111;example.org",
+			expectedErr: "invalid entry 2: the format is 'domain=value'",
+		},
+		{
+			desc:        "invalid JSON",
+			value:       `{"example.com": "Z111"`,
+			expectedErr: "invalid JSON: unexpected end of JSON input",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			zoneIDs, err := parseHostedZoneIDs(test.value)
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, zoneIDs)
+		})
+	}
+}
+
 func TestNewDefaultConfig(t *testing.T) {
 	defer envTest.RestoreEnv()
 
@@ -117,6 +209,7 @@ func TestNewDefaultConfig(t *testing.T) {
 				PropagationTimeout:       2 * time.Minute,
 				PollingInterval:          4 * time.Second,
 				WaitForRecordSetsChanged: true,
+				MaxWaitTime:              2 * time.Minute,
 			},
 		},
 		{
@@ -128,8 +221,12 @@ func TestNewDefaultConfig(t *testing.T) {
 				EnvPollingInterval:          "60",
 				EnvHostedZoneID:             "abc123",
 				EnvWaitForRecordSetsChanged: "false",
+				EnvMaxWaitTime:              "30",
+				EnvPrivateZone:              "true",
 			},
 			expected: &Config{
+				PrivateZone:        true,
+				MaxWaitTime:        30 * time.Second,
 				MaxRetries:         10,
 				TTL:                99,
 				PropagationTimeout: 60 * time.Second,
@@ -177,7 +274,100 @@ func TestDNSProvider_Present(t *testing.T) {
 	require.NoError(t, err, "Expected Present to return no error")
 }
 
-func Test_createAWSConfig(t *testing.T) {
+func TestNewDNSProvider_assumeRoles(t *testing.T) {
+	defer envTest.RestoreEnv()
+	envTest.ClearEnv()
+
+	envTest.Apply(map[string]string{
+		EnvRegion:          "us-east-1",
+		EnvAssumeRoleArn:   "arn:aws:iam::111111111111:role/first, arn:aws:iam::222222222222:role/second|ext-2",
+		EnvExternalID:      "ext-1",
+		EnvZoneAssumeRoles: "example.com=arn:aws:iam::333333333333:role/dns|ext-3;example.org=arn:aws:iam::444444444444:role/dns",
+	})
+
+	provider, err := NewDNSProvider()
+	require.NoError(t, err)
+
+	assert.Equal(t, "arn:aws:iam::111111111111:role/first", provider.config.AssumeRoleArn)
+	assert.Equal(t, "ext-1", provider.config.ExternalID)
+	assert.Equal(t, []AssumeRole{{ARN: "arn:aws:iam::222222222222:role/second", ExternalID: "ext-2"}}, provider.config.AssumeRoleChain)
+
+	expected := map[string][]AssumeRole{
+		"example.com": {{ARN: "arn:aws:iam::333333333333:role/dns", ExternalID: "ext-3"}},
+		"example.org": {{ARN: "arn:aws:iam::444444444444:role/dns"}},
+	}
+	assert.Equal(t, expected, provider.config.ZoneAssumeRoles)
+
+	assert.Same(t, provider.client, provider.getClient("_acme-challenge.example.net."))
+
+	zoneClient := provider.getClient("_acme-challenge.www.example.com.")
+	assert.NotSame(t, provider.client, zoneClient)
+	assert.Same(t, zoneClient, provider.getClient("_acme-challenge.example.com."))
+}
+
+func TestNewDNSProvider_assumeRolesError(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		envVars  map[string]string
+		expected string
+	}{
+		{
+			desc:     "missing ARN in the chain",
+			envVars:  map[string]string{EnvAssumeRoleArn: "arn:aws:iam::111111111111:role/first,|ext"},
+			expected: `route53: AWS_ASSUME_ROLE_ARN: missing role ARN in "arn:aws:iam::111111111111:role/first,|ext"`,
+		},
+		{
+			desc:     "missing domain",
+			envVars:  map[string]string{EnvZoneAssumeRoles: "arn:aws:iam::111111111111:role/dns"},
+			expected: `route53: AWS_ZONE_ASSUME_ROLES: invalid entry 1: the format is 'domain=value'`,
+		},
+		{
+			desc:     "missing roles",
+			envVars:  map[string]string{EnvZoneAssumeRoles: "example.com="},
+			expected: `route53: AWS_ZONE_ASSUME_ROLES: missing value for the domain "example.com"`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			defer envTest.RestoreEnv()
+			envTest.ClearEnv()
+
+			envTest.Apply(test.envVars)
+
+			_, err := NewDNSProvider()
+			require.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func TestDNSProvider_changeRecord_maxWaitTime(t *testing.T) {
+	mockResponses := MockResponseMap{
+		"/2013-04-01/hostedzone/ABCDEFG/rrset": {StatusCode: 200, Body: ChangeResourceRecordSetsResponse},
+		"/2013-04-01/change/123456":            {StatusCode: 200, Body: GetChangePendingResponse},
+	}
+
+	serverURL := setupTest(t, mockResponses)
+
+	defer envTest.RestoreEnv()
+	envTest.ClearEnv()
+
+	provider := makeTestProvider(t, serverURL)
+	provider.config.MaxWaitTime = 100 * time.Millisecond
+	provider.config.PollingInterval = 10 * time.Millisecond
+
+	recordSet := &awstypes.ResourceRecordSet{Name: aws.String("_acme-challenge.example.com."), Type: "TXT"}
+
+	err := provider.changeRecord(context.Background(), provider.client, awstypes.ChangeActionUpsert, "ABCDEFG", recordSet)
+	require.ErrorContains(t, err, "route53: time limit exceeded")
+
+	provider.config.WaitForRecordSetsChanged = false
+
+	err = provider.changeRecord(context.Background(), provider.client, awstypes.ChangeActionUpsert, "ABCDEFG", recordSet)
+	require.NoError(t, err)
+}
+
+func Test_loadAWSConfig(t *testing.T) {
 	testCases := []struct {
 		desc             string
 		env              map[string]string
@@ -269,7 +459,7 @@ func Test_createAWSConfig(t *testing.T) {
 
 			ctx := context.Background()
 
-			cfg, err := createAWSConfig(ctx, test.config)
+			cfg, err := loadAWSConfig(ctx, test.config)
 			requireErr(t, err, test.wantErr)
 
 			if err != nil {
@@ -292,6 +482,87 @@ func Test_createAWSConfig(t *testing.T) {
 	}
 }
 
+func Test_assumeRoles(t *testing.T) {
+	type assumeRoleRequest struct {
+		RoleARN     string
+		ExternalID  string
+		AccessKeyID string
+	}
+
+	var (
+		requests   []assumeRoleRequest
+		requestsMu sync.Mutex
+	)
+
+	accessKeyPattern := regexp.MustCompile(`Credential=([^/]+)/`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var accessKeyID string
+		if m := accessKeyPattern.FindStringSubmatch(req.Header.Get("Authorization")); m != nil {
+			accessKeyID = m[1]
+		}
+
+		requestsMu.Lock()
+		requests = append(requests, assumeRoleRequest{
+			RoleARN:     req.Form.Get("RoleArn"),
+			ExternalID:  req.Form.Get("ExternalId"),
+			AccessKeyID: accessKeyID,
+		})
+		n := len(requests)
+		requestsMu.Unlock()
+
+		// The access key of the role is the number of the role.
+		_, _ = fmt.Fprintf(rw, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>key-%d</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>2100-01-01T00:00:00Z</Expiration>
+    </Credentials>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`, n)
+	}))
+	t.Cleanup(server.Close)
+
+	defer envTest.RestoreEnv()
+	envTest.ClearEnv()
+
+	ctx := context.Background()
+
+	config := &Config{
+		AccessKeyID:     "one",
+		SecretAccessKey: "two",
+		Region:          "us-east-1",
+		AssumeRoleArn:   "arn:aws:iam::111111111111:role/first",
+		ExternalID:      "ext-1",
+		AssumeRoleChain: []AssumeRole{{ARN: "arn:aws:iam::222222222222:role/second", ExternalID: "ext-2"}},
+	}
+
+	cfg, err := loadAWSConfig(ctx, config)
+	require.NoError(t, err)
+
+	cfg.BaseEndpoint = aws.String(server.URL)
+
+	creds, err := assumeRoles(cfg, config.assumeRoles()).Credentials.Retrieve(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, "key-2", creds.AccessKeyID)
+
+	// Each role is assumed with the credentials of the previous role.
+	expected := []assumeRoleRequest{
+		{RoleARN: "arn:aws:iam::111111111111:role/first", ExternalID: "ext-1", AccessKeyID: "one"},
+		{RoleARN: "arn:aws:iam::222222222222:role/second", ExternalID: "ext-2", AccessKeyID: "key-1"},
+	}
+
+	assert.Equal(t, expected, requests)
+}
+
 func requireErr(t *testing.T, err error, wantErr string) {
 	t.Helper()
 
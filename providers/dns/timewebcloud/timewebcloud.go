@@ -6,12 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"sync"
 	"time"
 
 	"github.com/go-acme/lego/v4/challenge"
 	"github.com/go-acme/lego/v4/challenge/dns01"
 	"github.com/go-acme/lego/v4/platform/config/env"
+	"github.com/go-acme/lego/v4/providers/dns/internal/txtprovider"
 	"github.com/go-acme/lego/v4/providers/dns/timewebcloud/internal"
 )
 
@@ -50,11 +50,9 @@ func NewDefaultConfig() *Config {
 
 // DNSProvider implements the challenge.Provider interface.
 type DNSProvider struct {
-	config *Config
-	client *internal.Client
-
-	recordIDs   map[string]int
-	recordIDsMu sync.Mutex
+	config  *Config
+	client  *internal.Client
+	records *txtprovider.Provider[int]
 }
 
 // NewDNSProvider returns a DNSProvider instance configured for Timeweb Cloud.
@@ -83,11 +81,17 @@ func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
 
 	client := internal.NewClient(internal.OAuthStaticAccessToken(config.HTTPClient, config.AuthToken))
 
-	return &DNSProvider{
-		config:    config,
-		client:    client,
-		recordIDs: make(map[string]int),
-	}, nil
+	d := &DNSProvider{
+		config: config,
+		client: client,
+	}
+
+	d.records = txtprovider.New("timewebcloud", txtprovider.Funcs[int]{
+		CreateRecord: d.createRecord,
+		DeleteRecord: d.deleteRecord,
+	})
+
+	return d, nil
 }
 
 // Timeout returns the timeout and interval to use when checking for DNS propagation.
@@ -98,60 +102,27 @@ func (d *DNSProvider) Timeout() (timeout, interval time.Duration) {
 
 // Present creates a TXT record to fulfill the dns-01 challenge.
 func (d *DNSProvider) Present(domain, token, keyAuth string) error {
-	info := dns01.GetChallengeInfo(domain, keyAuth)
-
-	authZone, err := dns01.FindZoneByFqdn(info.EffectiveFQDN)
-	if err != nil {
-		return fmt.Errorf("timewebcloud: could not find zone for domain %q: %w", domain, err)
-	}
-
-	subDomain, err := dns01.ExtractSubDomain(info.EffectiveFQDN, authZone)
-	if err != nil {
-		return fmt.Errorf("timewebcloud: %w", err)
-	}
-
-	record := internal.DNSRecord{
-		Type:      "TXT",
-		Value:     info.Value,
-		SubDomain: subDomain,
-	}
-
-	response, err := d.client.CreateRecord(context.Background(), authZone, record)
-	if err != nil {
-		return fmt.Errorf("timewebcloud: %w", err)
-	}
-
-	d.recordIDsMu.Lock()
-	d.recordIDs[token] = response.ID
-	d.recordIDsMu.Unlock()
-
-	return nil
+	return d.records.Present(domain, token, keyAuth)
 }
 
 // CleanUp removes the TXT record matching the specified parameters.
 func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
-	info := dns01.GetChallengeInfo(domain, keyAuth)
-
-	authZone, err := dns01.FindZoneByFqdn(info.EffectiveFQDN)
-	if err != nil {
-		return fmt.Errorf("timewebcloud: could not find zone for domain %q: %w", domain, err)
-	}
-
-	d.recordIDsMu.Lock()
-	recordID, ok := d.recordIDs[token]
-	d.recordIDsMu.Unlock()
-	if !ok {
-		return fmt.Errorf("timewebcloud: unknown record ID for '%s'", info.EffectiveFQDN)
-	}
+	return d.records.CleanUp(domain, token, keyAuth)
+}
 
-	err = d.client.DeleteRecord(context.Background(), authZone, recordID)
+func (d *DNSProvider) createRecord(ctx context.Context, record txtprovider.Record) (int, error) {
+	response, err := d.client.CreateRecord(ctx, record.Zone, internal.DNSRecord{
+		Type:      "TXT",
+		Value:     record.Value,
+		SubDomain: record.SubDomain,
+	})
 	if err != nil {
-		return fmt.Errorf("timewebcloud: %w", err)
+		return 0, err
 	}
 
-	d.recordIDsMu.Lock()
-	delete(d.recordIDs, token)
-	d.recordIDsMu.Unlock()
+	return response.ID, nil
+}
 
-	return nil
+func (d *DNSProvider) deleteRecord(ctx context.Context, record txtprovider.Record, recordID int) error {
+	return d.client.DeleteRecord(ctx, record.Zone, recordID)
 }
@@ -47,7 +47,7 @@ func TestNewDNSProvider(t *testing.T) {
 				require.NotNil(t, p)
 				require.NotNil(t, p.config)
 				require.NotNil(t, p.client)
-				require.NotNil(t, p.recordIDs)
+				require.NotNil(t, p.records)
 			} else {
 				require.EqualError(t, err, test.expected)
 			}
@@ -83,7 +83,7 @@ func TestNewDNSProviderConfig(t *testing.T) {
 				require.NotNil(t, p)
 				require.NotNil(t, p.config)
 				require.NotNil(t, p.client)
-				require.NotNil(t, p.recordIDs)
+				require.NotNil(t, p.records)
 			} else {
 				require.EqualError(t, err, test.expected)
 			}
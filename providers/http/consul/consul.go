@@ -0,0 +1,151 @@
+// Package consul implements an HTTP provider for solving the HTTP-01 challenge using Consul KV in combination with a webserver.
+package consul
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge/http01"
+)
+
+// DefaultAddress is the default address of the Consul agent.
+const DefaultAddress = "http://127.0.0.1:8500"
+
+// Config is used to configure the creation of the HTTPProvider.
+type Config struct {
+	// Address is the address of the Consul agent (ex: http://127.0.0.1:8500).
+	Address string
+
+	// Prefix is prepended to the challenge path to build the key.
+	Prefix string
+
+	// Token is the ACL token.
+	Token string
+
+	Datacenter string
+	Namespace  string
+
+	HTTPClient *http.Client
+}
+
+// NewDefaultConfig returns a default configuration for the HTTPProvider.
+func NewDefaultConfig() *Config {
+	return &Config{
+		Address:    DefaultAddress,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// HTTPProvider implements ChallengeProvider for `http-01` challenge.
+type HTTPProvider struct {
+	config  *Config
+	baseURL *url.URL
+}
+
+// NewHTTPProvider returns a HTTPProvider instance with the given Consul agent address.
+func NewHTTPProvider(address, prefix string) (*HTTPProvider, error) {
+	config := NewDefaultConfig()
+	config.Prefix = prefix
+
+	if address != "" {
+		config.Address = address
+	}
+
+	return NewHTTPProviderConfig(config)
+}
+
+// NewHTTPProviderConfig returns a HTTPProvider instance configured for Consul.
+func NewHTTPProviderConfig(config *Config) (*HTTPProvider, error) {
+	if config == nil {
+		return nil, errors.New("consul: the configuration of the provider is nil")
+	}
+
+	baseURL, err := url.Parse(config.Address)
+	if err != nil {
+		return nil, fmt.Errorf("consul: invalid address %q: %w", config.Address, err)
+	}
+
+	if baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("consul: invalid address %q: scheme and host are required", config.Address)
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &HTTPProvider{config: config, baseURL: baseURL}, nil
+}
+
+// Present makes the token available at `HTTP01ChallengePath(token)` by storing the key authorization in Consul KV.
+func (p *HTTPProvider) Present(domain, token, keyAuth string) error {
+	err := p.do(context.Background(), http.MethodPut, token, strings.NewReader(keyAuth))
+	if err != nil {
+		return fmt.Errorf("consul: unable to store the key authorization: %w", err)
+	}
+
+	return nil
+}
+
+// CleanUp removes the key created for the challenge.
+func (p *HTTPProvider) CleanUp(domain, token, keyAuth string) error {
+	err := p.do(context.Background(), http.MethodDelete, token, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("consul: unable to remove the key authorization: %w", err)
+	}
+
+	return nil
+}
+
+func (p *HTTPProvider) do(ctx context.Context, method, token string, body io.Reader) error {
+	endpoint := p.baseURL.JoinPath("v1", "kv", p.key(token))
+
+	query := endpoint.Query()
+	if p.config.Datacenter != "" {
+		query.Set("dc", p.config.Datacenter)
+	}
+	if p.config.Namespace != "" {
+		query.Set("ns", p.config.Namespace)
+	}
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), body)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	if p.config.Token != "" {
+		req.Header.Set("X-Consul-Token", p.config.Token)
+	}
+
+	resp, err := p.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	raw, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: [%s] %s: %d: %s", req.Method, req.URL, resp.StatusCode, string(raw))
+	}
+
+	// The KV endpoint answers `true` or `false` to write operations.
+	if strings.TrimSpace(string(raw)) == "false" {
+		return errors.New("the write operation has not been applied")
+	}
+
+	return nil
+}
+
+// key returns the KV key: Consul keys must not start with a slash.
+func (p *HTTPProvider) key(token string) string {
+	return strings.TrimPrefix(p.config.Prefix+path.Join("/", http01.ChallengePath(token)), "/")
+}
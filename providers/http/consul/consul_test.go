@@ -0,0 +1,110 @@
+package consul
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	domain  = "lego.test"
+	token   = "foo"
+	keyAuth = "bar"
+)
+
+func setupFakeConsul(t *testing.T, aclToken string) (map[string]string, *httptest.Server) {
+	t.Helper()
+
+	var mu sync.Mutex
+	data := map[string]string{}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/kv/", func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Consul-Token") != aclToken {
+			http.Error(rw, "Permission denied", http.StatusForbidden)
+			return
+		}
+
+		key := strings.TrimPrefix(req.URL.Path, "/v1/kv/")
+		if dc := req.URL.Query().Get("dc"); dc != "" {
+			key = dc + ":" + key
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch req.Method {
+		case http.MethodPut:
+			value, _ := io.ReadAll(req.Body)
+			data[key] = string(value)
+		case http.MethodDelete:
+			delete(data, key)
+		default:
+			http.Error(rw, "unsupported method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		_, _ = rw.Write([]byte("true"))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return data, server
+}
+
+func TestNewHTTPProvider_invalidAddress(t *testing.T) {
+	_, err := NewHTTPProvider("/v1", "")
+	require.EqualError(t, err, `consul: invalid address "/v1": scheme and host are required`)
+}
+
+func TestHTTPProvider_Present(t *testing.T) {
+	data, server := setupFakeConsul(t, "")
+
+	provider, err := NewHTTPProvider(server.URL, "lego")
+	require.NoError(t, err)
+
+	err = provider.Present(domain, token, keyAuth)
+	require.NoError(t, err)
+
+	assert.Equal(t, keyAuth, data["lego/.well-known/acme-challenge/foo"])
+
+	err = provider.CleanUp(domain, token, keyAuth)
+	require.NoError(t, err)
+
+	assert.Empty(t, data)
+}
+
+func TestHTTPProvider_Present_tokenAndDatacenter(t *testing.T) {
+	data, server := setupFakeConsul(t, "secret")
+
+	config := NewDefaultConfig()
+	config.Address = server.URL
+	config.Token = "secret"
+	config.Datacenter = "dc1"
+
+	provider, err := NewHTTPProviderConfig(config)
+	require.NoError(t, err)
+
+	err = provider.Present(domain, token, keyAuth)
+	require.NoError(t, err)
+
+	assert.Equal(t, keyAuth, data["dc1:.well-known/acme-challenge/foo"])
+}
+
+func TestHTTPProvider_Present_denied(t *testing.T) {
+	_, server := setupFakeConsul(t, "secret")
+
+	provider, err := NewHTTPProvider(server.URL, "")
+	require.NoError(t, err)
+
+	err = provider.Present(domain, token, keyAuth)
+	require.ErrorContains(t, err, "consul: unable to store the key authorization: unexpected status code")
+}
@@ -0,0 +1,254 @@
+// Package etcd implements an HTTP provider for solving the HTTP-01 challenge using etcd in combination with a webserver.
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge/http01"
+)
+
+// DefaultTTL is the default lifetime of the challenge keys.
+const DefaultTTL = 5 * time.Minute
+
+// Config is used to configure the creation of the HTTPProvider.
+type Config struct {
+	// Endpoints are the etcd endpoints (ex: https://127.0.0.1:2379).
+	// They are tried in order until one of them answers.
+	Endpoints []string
+
+	// Prefix is prepended to the challenge path to build the key.
+	Prefix string
+
+	Username string
+	Password string
+
+	// TTL is the lifetime of the lease attached to the challenge keys.
+	TTL time.Duration
+
+	HTTPClient *http.Client
+}
+
+// NewDefaultConfig returns a default configuration for the HTTPProvider.
+func NewDefaultConfig() *Config {
+	return &Config{
+		TTL:        DefaultTTL,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// HTTPProvider implements ChallengeProvider for `http-01` challenge.
+type HTTPProvider struct {
+	config    *Config
+	endpoints []*url.URL
+}
+
+// NewHTTPProvider returns a HTTPProvider instance with the given etcd endpoints.
+func NewHTTPProvider(endpoints []string, prefix string) (*HTTPProvider, error) {
+	config := NewDefaultConfig()
+	config.Endpoints = endpoints
+	config.Prefix = prefix
+
+	return NewHTTPProviderConfig(config)
+}
+
+// NewHTTPProviderConfig returns a HTTPProvider instance configured for etcd.
+func NewHTTPProviderConfig(config *Config) (*HTTPProvider, error) {
+	if config == nil {
+		return nil, errors.New("etcd: the configuration of the provider is nil")
+	}
+
+	if len(config.Endpoints) == 0 {
+		return nil, errors.New("etcd: no endpoints provided")
+	}
+
+	if config.TTL < time.Second {
+		return nil, fmt.Errorf("etcd: invalid TTL: %s", config.TTL)
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	var endpoints []*url.URL
+	for _, endpoint := range config.Endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("etcd: invalid endpoint %q: %w", endpoint, err)
+		}
+
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("etcd: invalid endpoint %q: scheme and host are required", endpoint)
+		}
+
+		endpoints = append(endpoints, u)
+	}
+
+	return &HTTPProvider{config: config, endpoints: endpoints}, nil
+}
+
+// Present makes the token available at `HTTP01ChallengePath(token)` by storing the key authorization in etcd.
+// The key is attached to a lease, so etcd removes it even if CleanUp is never called.
+func (p *HTTPProvider) Present(domain, token, keyAuth string) error {
+	ctx := context.Background()
+
+	var errs []error
+	for _, endpoint := range p.endpoints {
+		err := p.put(ctx, endpoint, p.key(token), keyAuth)
+		if err == nil {
+			return nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	return fmt.Errorf("etcd: unable to store the key authorization: %w", errors.Join(errs...))
+}
+
+// CleanUp removes the key created for the challenge.
+func (p *HTTPProvider) CleanUp(domain, token, keyAuth string) error {
+	ctx := context.Background()
+
+	var errs []error
+	for _, endpoint := range p.endpoints {
+		err := p.delete(ctx, endpoint, p.key(token))
+		if err == nil {
+			return nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	return fmt.Errorf("etcd: unable to remove the key authorization: %w", errors.Join(errs...))
+}
+
+func (p *HTTPProvider) put(ctx context.Context, endpoint *url.URL, key, value string) error {
+	authToken, err := p.authenticate(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+
+	var lease leaseGrantResponse
+	err = p.do(ctx, endpoint, "/v3/lease/grant", authToken, leaseGrantRequest{TTL: int64(p.config.TTL.Seconds())}, &lease)
+	if err != nil {
+		return err
+	}
+
+	req := putRequest{
+		Key:   encode(key),
+		Value: encode(value),
+		Lease: lease.ID,
+	}
+
+	return p.do(ctx, endpoint, "/v3/kv/put", authToken, req, nil)
+}
+
+func (p *HTTPProvider) delete(ctx context.Context, endpoint *url.URL, key string) error {
+	authToken, err := p.authenticate(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+
+	return p.do(ctx, endpoint, "/v3/kv/deleterange", authToken, deleteRangeRequest{Key: encode(key)}, nil)
+}
+
+func (p *HTTPProvider) authenticate(ctx context.Context, endpoint *url.URL) (string, error) {
+	if p.config.Username == "" {
+		return "", nil
+	}
+
+	req := authenticateRequest{Name: p.config.Username, Password: p.config.Password}
+
+	var resp authenticateResponse
+	err := p.do(ctx, endpoint, "/v3/auth/authenticate", "", req, &resp)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Token, nil
+}
+
+func (p *HTTPProvider) do(ctx context.Context, endpoint *url.URL, uri, authToken string, payload, result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to create request JSON body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.JoinPath(uri).String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if authToken != "" {
+		req.Header.Set("Authorization", authToken)
+	}
+
+	resp, err := p.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: [%s] %s: %d: %s", req.Method, req.URL, resp.StatusCode, string(raw))
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(raw, result)
+}
+
+func (p *HTTPProvider) key(token string) string {
+	return p.config.Prefix + path.Join("/", http01.ChallengePath(token))
+}
+
+func encode(value string) string {
+	return base64.StdEncoding.EncodeToString([]byte(value))
+}
+
+type authenticateRequest struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+type authenticateResponse struct {
+	Token string `json:"token"`
+}
+
+type leaseGrantRequest struct {
+	TTL int64 `json:"TTL"`
+}
+
+type leaseGrantResponse struct {
+	// The gRPC gateway encodes int64 values as strings.
+	ID int64 `json:"ID,string"`
+}
+
+type putRequest struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Lease int64  `json:"lease,omitempty"`
+}
+
+type deleteRangeRequest struct {
+	Key string `json:"key"`
+}
@@ -0,0 +1,180 @@
+package etcd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	domain  = "lego.test"
+	token   = "foo"
+	keyAuth = "bar"
+)
+
+type fakeEtcd struct {
+	mu     sync.Mutex
+	data   map[string]string
+	leases map[string]int64
+	auth   string
+}
+
+func setupFakeEtcd(t *testing.T, username, password string) (*fakeEtcd, *httptest.Server) {
+	t.Helper()
+
+	store := &fakeEtcd{data: map[string]string{}, leases: map[string]int64{}}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /v3/auth/authenticate", func(rw http.ResponseWriter, req *http.Request) {
+		var body authenticateRequest
+		_ = json.NewDecoder(req.Body).Decode(&body)
+
+		if body.Name != username || body.Password != password {
+			http.Error(rw, `{"error":"authentication failed"}`, http.StatusBadRequest)
+			return
+		}
+
+		store.auth = "secret-token"
+		_, _ = rw.Write([]byte(`{"token":"secret-token"}`))
+	})
+
+	checkAuth := func(rw http.ResponseWriter, req *http.Request) bool {
+		if username != "" && req.Header.Get("Authorization") != store.auth {
+			http.Error(rw, `{"error":"invalid auth token"}`, http.StatusUnauthorized)
+			return false
+		}
+
+		return true
+	}
+
+	mux.HandleFunc("POST /v3/lease/grant", func(rw http.ResponseWriter, req *http.Request) {
+		if !checkAuth(rw, req) {
+			return
+		}
+
+		_, _ = rw.Write([]byte(`{"ID":"7587862072907233793","TTL":"300"}`))
+	})
+
+	mux.HandleFunc("POST /v3/kv/put", func(rw http.ResponseWriter, req *http.Request) {
+		if !checkAuth(rw, req) {
+			return
+		}
+
+		var body putRequest
+		_ = json.NewDecoder(req.Body).Decode(&body)
+
+		key, _ := base64.StdEncoding.DecodeString(body.Key)
+		value, _ := base64.StdEncoding.DecodeString(body.Value)
+
+		store.mu.Lock()
+		store.data[string(key)] = string(value)
+		store.leases[string(key)] = body.Lease
+		store.mu.Unlock()
+
+		_, _ = rw.Write([]byte(`{}`))
+	})
+
+	mux.HandleFunc("POST /v3/kv/deleterange", func(rw http.ResponseWriter, req *http.Request) {
+		if !checkAuth(rw, req) {
+			return
+		}
+
+		var body deleteRangeRequest
+		_ = json.NewDecoder(req.Body).Decode(&body)
+
+		key, _ := base64.StdEncoding.DecodeString(body.Key)
+
+		store.mu.Lock()
+		delete(store.data, string(key))
+		store.mu.Unlock()
+
+		_, _ = rw.Write([]byte(`{}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return store, server
+}
+
+func TestNewHTTPProvider_noEndpoints(t *testing.T) {
+	_, err := NewHTTPProvider(nil, "")
+	require.EqualError(t, err, "etcd: no endpoints provided")
+}
+
+func TestNewHTTPProvider_invalidEndpoint(t *testing.T) {
+	_, err := NewHTTPProvider([]string{"/v3"}, "")
+	require.EqualError(t, err, `etcd: invalid endpoint "/v3": scheme and host are required`)
+}
+
+func TestHTTPProvider_Present(t *testing.T) {
+	store, server := setupFakeEtcd(t, "", "")
+
+	provider, err := NewHTTPProvider([]string{server.URL}, "/lego")
+	require.NoError(t, err)
+
+	err = provider.Present(domain, token, keyAuth)
+	require.NoError(t, err)
+
+	assert.Equal(t, keyAuth, store.data["/lego/.well-known/acme-challenge/foo"])
+	assert.Equal(t, int64(7587862072907233793), store.leases["/lego/.well-known/acme-challenge/foo"])
+
+	err = provider.CleanUp(domain, token, keyAuth)
+	require.NoError(t, err)
+
+	assert.Empty(t, store.data)
+}
+
+func TestHTTPProvider_Present_auth(t *testing.T) {
+	store, server := setupFakeEtcd(t, "user", "secret")
+
+	config := NewDefaultConfig()
+	config.Endpoints = []string{server.URL}
+	config.Username = "user"
+	config.Password = "secret"
+
+	provider, err := NewHTTPProviderConfig(config)
+	require.NoError(t, err)
+
+	err = provider.Present(domain, token, keyAuth)
+	require.NoError(t, err)
+
+	assert.Equal(t, keyAuth, store.data["/.well-known/acme-challenge/foo"])
+}
+
+func TestHTTPProvider_Present_failover(t *testing.T) {
+	store, server := setupFakeEtcd(t, "", "")
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	provider, err := NewHTTPProvider([]string{down.URL, server.URL}, "")
+	require.NoError(t, err)
+
+	err = provider.Present(domain, token, keyAuth)
+	require.NoError(t, err)
+
+	assert.Equal(t, keyAuth, store.data["/.well-known/acme-challenge/foo"])
+}
+
+func TestHTTPProvider_Present_error(t *testing.T) {
+	_, server := setupFakeEtcd(t, "user", "secret")
+
+	config := NewDefaultConfig()
+	config.Endpoints = []string{server.URL}
+	config.Username = "user"
+	config.Password = "wrong"
+
+	provider, err := NewHTTPProviderConfig(config)
+	require.NoError(t, err)
+
+	err = provider.Present(domain, token, keyAuth)
+	require.ErrorContains(t, err, "etcd: unable to store the key authorization")
+}
@@ -0,0 +1,124 @@
+// Package redis implements an HTTP provider for solving the HTTP-01 challenge using Redis in combination with a webserver.
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge/http01"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultTTL is the default lifetime of the challenge keys.
+const DefaultTTL = 5 * time.Minute
+
+// Config is used to configure the creation of the HTTPProvider.
+type Config struct {
+	// Addrs is the list of Redis addresses (host:port).
+	// A single address targets a standalone server,
+	// several addresses target a cluster,
+	// and when MasterName is set the addresses are the sentinel addresses.
+	Addrs []string
+
+	// MasterName is the name of the master monitored by the sentinels.
+	MasterName string
+
+	Username string
+	Password string
+
+	// SentinelUsername and SentinelPassword are used to authenticate against the sentinels.
+	SentinelUsername string
+	SentinelPassword string
+
+	// DB is the database to select (not supported by clusters).
+	DB int
+
+	// TLSConfig enables TLS when non-nil.
+	TLSConfig *tls.Config
+
+	// KeyPrefix is prepended to the challenge path to build the key.
+	KeyPrefix string
+
+	// TTL is the lifetime of the challenge keys.
+	TTL time.Duration
+}
+
+// NewDefaultConfig returns a default configuration for the HTTPProvider.
+func NewDefaultConfig() *Config {
+	return &Config{TTL: DefaultTTL}
+}
+
+// HTTPProvider implements ChallengeProvider for `http-01` challenge.
+type HTTPProvider struct {
+	config *Config
+	client redis.UniversalClient
+}
+
+// NewHTTPProvider returns a HTTPProvider instance with a configured Redis client.
+func NewHTTPProvider(addrs []string) (*HTTPProvider, error) {
+	config := NewDefaultConfig()
+	config.Addrs = addrs
+
+	return NewHTTPProviderConfig(config)
+}
+
+// NewHTTPProviderConfig returns a HTTPProvider instance configured for Redis.
+func NewHTTPProviderConfig(config *Config) (*HTTPProvider, error) {
+	if config == nil {
+		return nil, errors.New("redis: the configuration of the provider is nil")
+	}
+
+	if len(config.Addrs) == 0 {
+		return nil, errors.New("redis: no hosts provided")
+	}
+
+	if config.TTL <= 0 {
+		return nil, fmt.Errorf("redis: invalid TTL: %s", config.TTL)
+	}
+
+	client := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:            config.Addrs,
+		MasterName:       config.MasterName,
+		Username:         config.Username,
+		Password:         config.Password,
+		SentinelUsername: config.SentinelUsername,
+		SentinelPassword: config.SentinelPassword,
+		DB:               config.DB,
+		TLSConfig:        config.TLSConfig,
+	})
+
+	return &HTTPProvider{config: config, client: client}, nil
+}
+
+// Present makes the token available at `HTTP01ChallengePath(token)` by storing the key authorization in Redis.
+func (p *HTTPProvider) Present(domain, token, keyAuth string) error {
+	err := p.client.Set(context.Background(), p.key(token), keyAuth, p.config.TTL).Err()
+	if err != nil {
+		return fmt.Errorf("redis: unable to store the key authorization: %w", err)
+	}
+
+	return nil
+}
+
+// CleanUp removes the key created for the challenge.
+func (p *HTTPProvider) CleanUp(domain, token, keyAuth string) error {
+	err := p.client.Del(context.Background(), p.key(token)).Err()
+	if err != nil {
+		return fmt.Errorf("redis: unable to remove the key authorization: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the underlying Redis client.
+func (p *HTTPProvider) Close() error {
+	return p.client.Close()
+}
+
+func (p *HTTPProvider) key(token string) string {
+	return p.config.KeyPrefix + path.Join("/", http01.ChallengePath(token))
+}
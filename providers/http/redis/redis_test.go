@@ -0,0 +1,96 @@
+package redis
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-acme/lego/v4/challenge/http01"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	domain  = "lego.test"
+	token   = "foo"
+	keyAuth = "bar"
+)
+
+func TestNewHTTPProvider_noHosts(t *testing.T) {
+	_, err := NewHTTPProvider(nil)
+	require.EqualError(t, err, "redis: no hosts provided")
+}
+
+func TestNewHTTPProviderConfig_invalidTTL(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Addrs = []string{"127.0.0.1:6379"}
+	config.TTL = 0
+
+	_, err := NewHTTPProviderConfig(config)
+	require.EqualError(t, err, "redis: invalid TTL: 0s")
+}
+
+func TestHTTPProvider_Present(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	provider, err := NewHTTPProvider([]string{server.Addr()})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = provider.Close() })
+
+	err = provider.Present(domain, token, keyAuth)
+	require.NoError(t, err)
+
+	key := path.Join("/", http01.ChallengePath(token))
+
+	value, err := server.Get(key)
+	require.NoError(t, err)
+	assert.Equal(t, keyAuth, value)
+	assert.Equal(t, DefaultTTL, server.TTL(key))
+
+	err = provider.CleanUp(domain, token, keyAuth)
+	require.NoError(t, err)
+
+	assert.False(t, server.Exists(key))
+}
+
+func TestHTTPProvider_Present_prefixAndAuth(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.RequireAuth("secret")
+
+	config := NewDefaultConfig()
+	config.Addrs = []string{server.Addr()}
+	config.Password = "secret"
+	config.KeyPrefix = "acme:"
+	config.TTL = time.Minute
+
+	provider, err := NewHTTPProviderConfig(config)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = provider.Close() })
+
+	err = provider.Present(domain, token, keyAuth)
+	require.NoError(t, err)
+
+	key := "acme:" + path.Join("/", http01.ChallengePath(token))
+
+	value, err := server.Get(key)
+	require.NoError(t, err)
+	assert.Equal(t, keyAuth, value)
+	assert.Equal(t, time.Minute, server.TTL(key))
+}
+
+func TestHTTPProvider_Present_unreachable(t *testing.T) {
+	server := miniredis.RunT(t)
+	addr := server.Addr()
+	server.Close()
+
+	provider, err := NewHTTPProvider([]string{addr})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = provider.Close() })
+
+	err = provider.Present(domain, token, keyAuth)
+	require.ErrorContains(t, err, "redis: unable to store the key authorization")
+}
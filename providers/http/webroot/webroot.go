@@ -4,49 +4,207 @@ package webroot
 import (
 	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/go-acme/lego/v4/challenge/http01"
+	"github.com/go-acme/lego/v4/platform/wait"
 )
 
+// Config is used to configure the creation of the HTTPProvider.
+type Config struct {
+	// Paths are the webroot folders.
+	// The challenge files are written in all the folders in parallel.
+	Paths []string
+
+	// SelfCheck enables the verification that the challenge file is reachable
+	// through `http://<domain>/.well-known/acme-challenge/<token>` before returning from Present.
+	SelfCheck bool
+	// SelfCheckTimeout is the maximum time to wait for the challenge file to be reachable.
+	SelfCheckTimeout time.Duration
+	// SelfCheckInterval is the time between two self-check requests.
+	SelfCheckInterval time.Duration
+
+	HTTPClient *http.Client
+}
+
+// NewDefaultConfig returns a default configuration for the HTTPProvider.
+func NewDefaultConfig() *Config {
+	return &Config{
+		SelfCheckTimeout:  60 * time.Second,
+		SelfCheckInterval: 2 * time.Second,
+		HTTPClient:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
 // HTTPProvider implements ChallengeProvider for `http-01` challenge.
 type HTTPProvider struct {
-	path string
+	config *Config
 }
 
 // NewHTTPProvider returns a HTTPProvider instance with a configured webroot path.
 func NewHTTPProvider(path string) (*HTTPProvider, error) {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return nil, errors.New("webroot path does not exist")
+	config := NewDefaultConfig()
+	config.Paths = []string{path}
+
+	return NewHTTPProviderConfig(config)
+}
+
+// NewHTTPProviderConfig returns a HTTPProvider instance configured with several webroot paths.
+func NewHTTPProviderConfig(config *Config) (*HTTPProvider, error) {
+	if config == nil {
+		return nil, errors.New("webroot: the configuration of the provider is nil")
+	}
+
+	if len(config.Paths) == 0 {
+		return nil, errors.New("webroot path is missing")
 	}
 
-	return &HTTPProvider{path: path}, nil
+	for _, path := range config.Paths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if len(config.Paths) == 1 {
+				return nil, errors.New("webroot path does not exist")
+			}
+
+			return nil, fmt.Errorf("webroot path does not exist: %s", path)
+		}
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &HTTPProvider{config: config}, nil
 }
 
-// Present makes the token available at `HTTP01ChallengePath(token)` by creating a file in the given webroot path.
+// Present makes the token available at `HTTP01ChallengePath(token)` by creating a file in the given webroot paths.
 func (w *HTTPProvider) Present(domain, token, keyAuth string) error {
-	var err error
+	err := w.forEachPath(func(path string) error {
+		return writeChallengeFile(path, token, keyAuth)
+	})
+	if err != nil {
+		return err
+	}
+
+	if !w.config.SelfCheck {
+		return nil
+	}
+
+	return w.selfCheck(domain, token, keyAuth)
+}
+
+// CleanUp removes the file created for the challenge.
+func (w *HTTPProvider) CleanUp(domain, token, keyAuth string) error {
+	return w.forEachPath(func(path string) error {
+		err := os.Remove(filepath.Join(path, http01.ChallengePath(token)))
+		if err != nil {
+			return fmt.Errorf("could not remove file in webroot after HTTP challenge: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (w *HTTPProvider) forEachPath(fn func(path string) error) error {
+	errs := make([]error, len(w.config.Paths))
+
+	var wg sync.WaitGroup
+	for i, path := range w.config.Paths {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			errs[i] = fn(path)
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (w *HTTPProvider) selfCheck(domain, token, keyAuth string) error {
+	uri := challengeURL(domain, token)
+
+	return wait.For("webroot self-check", w.config.SelfCheckTimeout, w.config.SelfCheckInterval, func() (bool, error) {
+		resp, err := w.config.HTTPClient.Get(uri)
+		if err != nil {
+			return false, err
+		}
+
+		defer func() { _ = resp.Body.Close() }()
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+		if err != nil {
+			return false, err
+		}
 
-	challengeFilePath := filepath.Join(w.path, http01.ChallengePath(token))
-	err = os.MkdirAll(filepath.Dir(challengeFilePath), 0o755)
+		if resp.StatusCode != http.StatusOK {
+			return false, fmt.Errorf("unexpected status code: %s: %d", uri, resp.StatusCode)
+		}
+
+		if string(body) != keyAuth {
+			return false, fmt.Errorf("unexpected content: %s", uri)
+		}
+
+		return true, nil
+	})
+}
+
+// challengeURL returns the URL of the challenge file, the IPv6 addresses are enclosed in square brackets.
+func challengeURL(domain, token string) string {
+	host := domain
+	if ip := net.ParseIP(domain); ip != nil && ip.To4() == nil {
+		host = "[" + domain + "]"
+	}
+
+	uri := url.URL{Scheme: "http", Host: host, Path: http01.ChallengePath(token)}
+
+	return uri.String()
+}
+
+// writeChallengeFile writes the challenge file atomically:
+// the content is written in a temporary file which is then renamed.
+func writeChallengeFile(webroot, token, keyAuth string) error {
+	challengeFilePath := filepath.Join(webroot, http01.ChallengePath(token))
+
+	err := os.MkdirAll(filepath.Dir(challengeFilePath), 0o755)
 	if err != nil {
 		return fmt.Errorf("could not create required directories in webroot for HTTP challenge: %w", err)
 	}
 
-	err = os.WriteFile(challengeFilePath, []byte(keyAuth), 0o644)
+	tmp, err := os.CreateTemp(filepath.Dir(challengeFilePath), ".lego-*.tmp")
 	if err != nil {
 		return fmt.Errorf("could not write file in webroot for HTTP challenge: %w", err)
 	}
 
-	return nil
-}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	_, err = tmp.WriteString(keyAuth)
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if errC := tmp.Close(); err == nil {
+		err = errC
+	}
+
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), challengeFilePath)
+	}
 
-// CleanUp removes the file created for the challenge.
-func (w *HTTPProvider) CleanUp(domain, token, keyAuth string) error {
-	err := os.Remove(filepath.Join(w.path, http01.ChallengePath(token)))
 	if err != nil {
-		return fmt.Errorf("could not remove file in webroot after HTTP challenge: %w", err)
+		return fmt.Errorf("could not write file in webroot for HTTP challenge: %w", err)
 	}
 
 	return nil
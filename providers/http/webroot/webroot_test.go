@@ -1,8 +1,13 @@
 package webroot
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -38,3 +43,101 @@ func TestHTTPProvider(t *testing.T) {
 	err = provider.CleanUp(domain, token, keyAuth)
 	require.NoError(t, err)
 }
+
+func TestHTTPProvider_multipleRoots(t *testing.T) {
+	roots := []string{t.TempDir(), t.TempDir()}
+
+	config := NewDefaultConfig()
+	config.Paths = roots
+
+	provider, err := NewHTTPProviderConfig(config)
+	require.NoError(t, err)
+
+	err = provider.Present("domain", "token", "keyAuth")
+	require.NoError(t, err)
+
+	for _, root := range roots {
+		data, errR := os.ReadFile(filepath.Join(root, ".well-known", "acme-challenge", "token"))
+		require.NoError(t, errR)
+
+		assert.Equal(t, "keyAuth", string(data))
+
+		entries, errR := os.ReadDir(filepath.Join(root, ".well-known", "acme-challenge"))
+		require.NoError(t, errR)
+
+		assert.Len(t, entries, 1, "temporary files must be removed")
+	}
+
+	err = provider.CleanUp("domain", "token", "keyAuth")
+	require.NoError(t, err)
+
+	for _, root := range roots {
+		assert.NoFileExists(t, filepath.Join(root, ".well-known", "acme-challenge", "token"))
+	}
+}
+
+func TestNewHTTPProviderConfig_missingRoot(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Paths = []string{t.TempDir(), "missing"}
+
+	_, err := NewHTTPProviderConfig(config)
+	require.EqualError(t, err, "webroot path does not exist: missing")
+}
+
+func TestHTTPProvider_selfCheck(t *testing.T) {
+	root := t.TempDir()
+
+	server := httptest.NewServer(http.FileServer(http.Dir(root)))
+	t.Cleanup(server.Close)
+
+	config := NewDefaultConfig()
+	config.Paths = []string{root}
+	config.SelfCheck = true
+	config.SelfCheckTimeout = time.Second
+	config.SelfCheckInterval = 10 * time.Millisecond
+
+	provider, err := NewHTTPProviderConfig(config)
+	require.NoError(t, err)
+
+	domain := strings.TrimPrefix(server.URL, "http://")
+
+	err = provider.Present(domain, "token", "keyAuth")
+	require.NoError(t, err)
+}
+
+func TestHTTPProvider_selfCheck_unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+
+	config := NewDefaultConfig()
+	config.Paths = []string{t.TempDir()}
+	config.SelfCheck = true
+	config.SelfCheckTimeout = 50 * time.Millisecond
+	config.SelfCheckInterval = 10 * time.Millisecond
+
+	provider, err := NewHTTPProviderConfig(config)
+	require.NoError(t, err)
+
+	domain := strings.TrimPrefix(server.URL, "http://")
+
+	err = provider.Present(domain, "token", "keyAuth")
+	require.ErrorContains(t, err, "webroot self-check: time limit exceeded: last error: unexpected status code")
+}
+
+func Test_challengeURL(t *testing.T) {
+	testCases := []struct {
+		domain   string
+		expected string
+	}{
+		{domain: "example.com", expected: "http://example.com/.well-known/acme-challenge/token"},
+		{domain: "192.0.2.1", expected: "http://192.0.2.1/.well-known/acme-challenge/token"},
+		{domain: "2001:db8::1", expected: "http://[2001:db8::1]/.well-known/acme-challenge/token"},
+		{domain: "127.0.0.1:8080", expected: "http://127.0.0.1:8080/.well-known/acme-challenge/token"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.domain, func(t *testing.T) {
+			assert.Equal(t, test.expected, challengeURL(test.domain, "token"))
+		})
+	}
+}
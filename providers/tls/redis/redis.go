@@ -0,0 +1,149 @@
+// Package redis implements a shared storage of the TLS-ALPN-01 challenge certificates using Redis.
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge/tlsalpn01"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultTTL is the default lifetime of the challenge certificates.
+const DefaultTTL = 5 * time.Minute
+
+const (
+	fieldCert = "cert"
+	fieldKey  = "key"
+)
+
+var _ tlsalpn01.CertStorage = (*Storage)(nil)
+
+// Config is used to configure the creation of the Storage.
+type Config struct {
+	// Addrs is the list of Redis addresses (host:port).
+	// A single address targets a standalone server,
+	// several addresses target a cluster,
+	// and when MasterName is set the addresses are the sentinel addresses.
+	Addrs []string
+
+	// MasterName is the name of the master monitored by the sentinels.
+	MasterName string
+
+	Username string
+	Password string
+
+	// DB is the database to select (not supported by clusters).
+	DB int
+
+	// TLSConfig enables TLS when non-nil.
+	TLSConfig *tls.Config
+
+	// KeyPrefix is prepended to the server name to build the key.
+	KeyPrefix string
+
+	// TTL is the lifetime of the challenge certificates.
+	TTL time.Duration
+}
+
+// NewDefaultConfig returns a default configuration for the Storage.
+func NewDefaultConfig() *Config {
+	return &Config{
+		KeyPrefix: "lego:tls-alpn-01:",
+		TTL:       DefaultTTL,
+	}
+}
+
+// Storage implements tlsalpn01.CertStorage.
+// Each challenge certificate is stored in a hash (fields `cert` and `key`) keyed by the server name.
+type Storage struct {
+	config *Config
+	client redis.UniversalClient
+}
+
+// NewStorage returns a Storage instance with a configured Redis client.
+func NewStorage(addrs []string) (*Storage, error) {
+	config := NewDefaultConfig()
+	config.Addrs = addrs
+
+	return NewStorageConfig(config)
+}
+
+// NewStorageConfig returns a Storage instance configured for Redis.
+func NewStorageConfig(config *Config) (*Storage, error) {
+	if config == nil {
+		return nil, errors.New("redis: the configuration of the storage is nil")
+	}
+
+	if len(config.Addrs) == 0 {
+		return nil, errors.New("redis: no hosts provided")
+	}
+
+	if config.TTL <= 0 {
+		return nil, fmt.Errorf("redis: invalid TTL: %s", config.TTL)
+	}
+
+	client := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:      config.Addrs,
+		MasterName: config.MasterName,
+		Username:   config.Username,
+		Password:   config.Password,
+		DB:         config.DB,
+		TLSConfig:  config.TLSConfig,
+	})
+
+	return &Storage{config: config, client: client}, nil
+}
+
+// Put stores the challenge certificate.
+func (s *Storage) Put(serverName string, certPEM, keyPEM []byte) error {
+	ctx := context.Background()
+	key := s.key(serverName)
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, fieldCert, certPEM, fieldKey, keyPEM)
+		pipe.Expire(ctx, key, s.config.TTL)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the challenge certificate.
+func (s *Storage) Get(serverName string) ([]byte, []byte, error) {
+	values, err := s.client.HGetAll(context.Background(), s.key(serverName)).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("redis: %w", err)
+	}
+
+	if values[fieldCert] == "" || values[fieldKey] == "" {
+		return nil, nil, tlsalpn01.ErrCertNotFound
+	}
+
+	return []byte(values[fieldCert]), []byte(values[fieldKey]), nil
+}
+
+// Delete removes the challenge certificate.
+func (s *Storage) Delete(serverName string) error {
+	err := s.client.Del(context.Background(), s.key(serverName)).Err()
+	if err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the underlying Redis client.
+func (s *Storage) Close() error {
+	return s.client.Close()
+}
+
+func (s *Storage) key(serverName string) string {
+	return s.config.KeyPrefix + serverName
+}
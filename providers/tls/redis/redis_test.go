@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-acme/lego/v4/challenge/tlsalpn01"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStorage_noHosts(t *testing.T) {
+	_, err := NewStorage(nil)
+	require.EqualError(t, err, "redis: no hosts provided")
+}
+
+func TestNewStorageConfig_invalidTTL(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Addrs = []string{"127.0.0.1:6379"}
+	config.TTL = 0
+
+	_, err := NewStorageConfig(config)
+	require.EqualError(t, err, "redis: invalid TTL: 0s")
+}
+
+func TestStorage(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	storage, err := NewStorage([]string{server.Addr()})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = storage.Close() })
+
+	err = storage.Put("example.com", []byte("cert"), []byte("key"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "cert", server.HGet("lego:tls-alpn-01:example.com", "cert"))
+	assert.Equal(t, DefaultTTL, server.TTL("lego:tls-alpn-01:example.com"))
+
+	certPEM, keyPEM, err := storage.Get("example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "cert", string(certPEM))
+	assert.Equal(t, "key", string(keyPEM))
+
+	err = storage.Delete("example.com")
+	require.NoError(t, err)
+
+	_, _, err = storage.Get("example.com")
+	require.ErrorIs(t, err, tlsalpn01.ErrCertNotFound)
+}
+
+func TestStorage_sharedCertStore(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	storage, err := NewStorage([]string{server.Addr()})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = storage.Close() })
+
+	store := tlsalpn01.NewSharedCertStore(storage)
+
+	err = store.Present("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	cert, err := store.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com", SupportedProtos: []string{tlsalpn01.ACMETLS1Protocol}})
+	require.NoError(t, err)
+	assert.NotNil(t, cert)
+
+	err = store.CleanUp("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	assert.False(t, server.Exists("lego:tls-alpn-01:example.com"))
+}
@@ -0,0 +1,107 @@
+package snirouter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const envoySecretType = "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.Secret"
+
+// Envoy pushes the challenge certificates through the file-based Secret Discovery Service (SDS) of Envoy.
+// Envoy watches the file and reloads the secret when the file is (atomically) replaced.
+//
+// The secret name is built from the domain: `acme-<domain>`.
+// The Envoy filter chain matching the `acme-tls/1` ALPN must reference this secret.
+//
+// https://www.envoyproxy.io/docs/envoy/latest/configuration/security/secret#example-three-certificate-rotation-for-xds-grpc-connection
+type Envoy struct {
+	// Directory where the SDS files are written (one file per domain: `acme-<domain>.json`).
+	Directory string
+}
+
+// NewEnvoy creates a new Envoy backend.
+func NewEnvoy(directory string) *Envoy {
+	return &Envoy{Directory: directory}
+}
+
+// Add writes the SDS file of the domain.
+func (e *Envoy) Add(domain string, certPEM, keyPEM []byte) error {
+	resp := sdsResponse{
+		Resources: []sdsSecret{{
+			Type: envoySecretType,
+			Name: e.SecretName(domain),
+			TLSCertificate: sdsTLSCertificate{
+				CertificateChain: sdsDataSource{InlineString: string(certPEM)},
+				PrivateKey:       sdsDataSource{InlineString: string(keyPEM)},
+			},
+		}},
+	}
+
+	content, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("envoy: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(e.Directory, ".lego-*.tmp")
+	if err != nil {
+		return fmt.Errorf("envoy: %w", err)
+	}
+
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	_, err = tmp.Write(content)
+
+	if errC := tmp.Close(); err == nil {
+		err = errC
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), e.filename(domain))
+	}
+
+	if err != nil {
+		return fmt.Errorf("envoy: %w", err)
+	}
+
+	return nil
+}
+
+// Remove removes the SDS file of the domain.
+func (e *Envoy) Remove(domain string) error {
+	err := os.Remove(e.filename(domain))
+	if err != nil {
+		return fmt.Errorf("envoy: %w", err)
+	}
+
+	return nil
+}
+
+// SecretName returns the name of the secret of the domain.
+func (e *Envoy) SecretName(domain string) string {
+	return "acme-" + domain
+}
+
+func (e *Envoy) filename(domain string) string {
+	return filepath.Join(e.Directory, e.SecretName(domain)+".json")
+}
+
+type sdsResponse struct {
+	Resources []sdsSecret `json:"resources"`
+}
+
+type sdsSecret struct {
+	Type           string            `json:"@type"`
+	Name           string            `json:"name"`
+	TLSCertificate sdsTLSCertificate `json:"tls_certificate"`
+}
+
+type sdsTLSCertificate struct {
+	CertificateChain sdsDataSource `json:"certificate_chain"`
+	PrivateKey       sdsDataSource `json:"private_key"`
+}
+
+type sdsDataSource struct {
+	InlineString string `json:"inline_string"`
+}
@@ -0,0 +1,137 @@
+package snirouter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strings"
+	"time"
+)
+
+// HAProxy pushes the challenge certificates through the HAProxy runtime API.
+// The certificates are created in memory and added to a crt-list used by a `bind` line
+// declaring `alpn acme-tls/1` support.
+//
+// https://docs.haproxy.org/dev/management.html#9.3
+type HAProxy struct {
+	// Network is "unix" or "tcp".
+	Network string
+	// Address is the path of the stats socket or the address of the TCP listener of the runtime API.
+	Address string
+	// CrtList is the crt-list referenced by the bind line.
+	CrtList string
+	// Directory is the virtual directory used to name the certificates.
+	Directory string
+
+	Timeout time.Duration
+}
+
+// NewHAProxy creates a new HAProxy backend.
+func NewHAProxy(network, address, crtList string) *HAProxy {
+	return &HAProxy{
+		Network:   network,
+		Address:   address,
+		CrtList:   crtList,
+		Directory: "/lego",
+		Timeout:   10 * time.Second,
+	}
+}
+
+// Add creates the certificate, fills and commits it, then adds it to the crt-list.
+func (h *HAProxy) Add(domain string, certPEM, keyPEM []byte) error {
+	name := h.certName(domain)
+
+	payload := strings.TrimSpace(string(certPEM)) + "\n" + strings.TrimSpace(string(keyPEM))
+
+	commands := []string{
+		fmt.Sprintf("new ssl cert %s", name),
+		fmt.Sprintf("set ssl cert %s <<\n%s\n\n", name, payload),
+		fmt.Sprintf("commit ssl cert %s", name),
+		fmt.Sprintf("add ssl crt-list %s %s [alpn acme-tls/1] %s", h.CrtList, name, domain),
+	}
+
+	for _, command := range commands {
+		err := h.exec(command)
+		if err != nil {
+			return fmt.Errorf("haproxy: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Remove removes the certificate from the crt-list and deletes it.
+func (h *HAProxy) Remove(domain string) error {
+	name := h.certName(domain)
+
+	var errs []error
+	for _, command := range []string{
+		fmt.Sprintf("del ssl crt-list %s %s", h.CrtList, name),
+		fmt.Sprintf("del ssl cert %s", name),
+	} {
+		err := h.exec(command)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("haproxy: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+func (h *HAProxy) certName(domain string) string {
+	return path.Join(h.Directory, "acme-"+strings.ReplaceAll(domain, "*", "_")+".pem")
+}
+
+// exec sends a command to the runtime API and checks the response.
+func (h *HAProxy) exec(command string) error {
+	conn, err := net.DialTimeout(h.Network, h.Address, h.Timeout)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	_ = conn.SetDeadline(time.Now().Add(h.Timeout))
+
+	if !strings.HasSuffix(command, "\n") {
+		command += "\n"
+	}
+
+	_, err = io.WriteString(conn, command)
+	if err != nil {
+		return err
+	}
+
+	raw, err := io.ReadAll(bufio.NewReader(conn))
+	if err != nil {
+		return err
+	}
+
+	response := strings.TrimSpace(string(raw))
+
+	if isHAProxyError(response) {
+		firstLine, _, _ := strings.Cut(command, "\n")
+		return fmt.Errorf("command %q failed: %s", firstLine, response)
+	}
+
+	return nil
+}
+
+func isHAProxyError(response string) bool {
+	lower := strings.ToLower(response)
+
+	for _, marker := range []string{"unknown command", "error", "can't", "cannot", "not found", "failed", "permission denied"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,57 @@
+// Package snirouter implements a TLS-ALPN-01 provider pushing the challenge certificate to an external SNI router
+// (HAProxy runtime API, Envoy SDS, or a generic webhook), for environments where the port 443 terminates on a load balancer.
+package snirouter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-acme/lego/v4/challenge/tlsalpn01"
+)
+
+// Backend pushes challenge certificates to an SNI router.
+type Backend interface {
+	// Add makes the router serve the challenge certificate for the domain on `acme-tls/1` connections.
+	Add(domain string, certPEM, keyPEM []byte) error
+	// Remove removes the challenge certificate of the domain from the router.
+	Remove(domain string) error
+}
+
+// TLSProvider implements ChallengeProvider for `TLS-ALPN-01` challenge.
+type TLSProvider struct {
+	backend Backend
+}
+
+// NewTLSProvider creates a new TLSProvider using the given backend.
+func NewTLSProvider(backend Backend) (*TLSProvider, error) {
+	if backend == nil {
+		return nil, errors.New("snirouter: missing backend")
+	}
+
+	return &TLSProvider{backend: backend}, nil
+}
+
+// Present generates the challenge certificate and pushes it to the SNI router.
+func (p *TLSProvider) Present(domain, token, keyAuth string) error {
+	certPEM, keyPEM, err := tlsalpn01.ChallengeBlocks(domain, keyAuth)
+	if err != nil {
+		return fmt.Errorf("snirouter: %w", err)
+	}
+
+	err = p.backend.Add(domain, certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("snirouter: %w", err)
+	}
+
+	return nil
+}
+
+// CleanUp removes the challenge certificate from the SNI router.
+func (p *TLSProvider) CleanUp(domain, token, keyAuth string) error {
+	err := p.backend.Remove(domain)
+	if err != nil {
+		return fmt.Errorf("snirouter: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package snirouter
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTLSProvider_missingBackend(t *testing.T) {
+	_, err := NewTLSProvider(nil)
+	require.EqualError(t, err, "snirouter: missing backend")
+}
+
+func TestTLSProvider_haproxy(t *testing.T) {
+	var mu sync.Mutex
+	var commands []string
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, errA := listener.Accept()
+			if errA != nil {
+				return
+			}
+
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+
+			mu.Lock()
+			commands = append(commands, strings.TrimSpace(line))
+			mu.Unlock()
+
+			_, _ = io.WriteString(conn, "Success!\n")
+			_ = conn.Close()
+		}
+	}()
+
+	provider, err := NewTLSProvider(NewHAProxy("tcp", listener.Addr().String(), "/etc/haproxy/acme.crtlist"))
+	require.NoError(t, err)
+
+	err = provider.Present("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	err = provider.CleanUp("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	expected := []string{
+		"new ssl cert /lego/acme-example.com.pem",
+		"set ssl cert /lego/acme-example.com.pem <<",
+		"commit ssl cert /lego/acme-example.com.pem",
+		"add ssl crt-list /etc/haproxy/acme.crtlist /lego/acme-example.com.pem [alpn acme-tls/1] example.com",
+		"del ssl crt-list /etc/haproxy/acme.crtlist /lego/acme-example.com.pem",
+		"del ssl cert /lego/acme-example.com.pem",
+	}
+
+	assert.Equal(t, expected, commands)
+}
+
+func TestTLSProvider_haproxy_error(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, errA := listener.Accept()
+			if errA != nil {
+				return
+			}
+
+			_, _ = bufio.NewReader(conn).ReadString('\n')
+			_, _ = io.WriteString(conn, "Unknown command: 'new'\n")
+			_ = conn.Close()
+		}
+	}()
+
+	provider, err := NewTLSProvider(NewHAProxy("tcp", listener.Addr().String(), "acme.crtlist"))
+	require.NoError(t, err)
+
+	err = provider.Present("example.com", "token", "keyAuth")
+	require.EqualError(t, err, `snirouter: haproxy: command "new ssl cert /lego/acme-example.com.pem" failed: Unknown command: 'new'`)
+}
+
+func TestTLSProvider_envoy(t *testing.T) {
+	dir := t.TempDir()
+
+	provider, err := NewTLSProvider(NewEnvoy(dir))
+	require.NoError(t, err)
+
+	err = provider.Present("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	raw, err := os.ReadFile(filepath.Join(dir, "acme-example.com.json"))
+	require.NoError(t, err)
+
+	var resp sdsResponse
+	err = json.Unmarshal(raw, &resp)
+	require.NoError(t, err)
+
+	require.Len(t, resp.Resources, 1)
+	assert.Equal(t, envoySecretType, resp.Resources[0].Type)
+	assert.Equal(t, "acme-example.com", resp.Resources[0].Name)
+	assert.Contains(t, resp.Resources[0].TLSCertificate.CertificateChain.InlineString, "BEGIN CERTIFICATE")
+	assert.Contains(t, resp.Resources[0].TLSCertificate.PrivateKey.InlineString, "PRIVATE KEY")
+
+	err = provider.CleanUp("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestTLSProvider_webhook(t *testing.T) {
+	var payload webhookPayload
+	var deleted string
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer secret" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch req.Method {
+		case http.MethodPost:
+			_ = json.NewDecoder(req.Body).Decode(&payload)
+		case http.MethodDelete:
+			deleted = req.URL.Query().Get("domain")
+		}
+
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	endpoint, err := url.Parse(server.URL + "/certs")
+	require.NoError(t, err)
+
+	backend := NewWebhook(endpoint)
+	backend.Headers.Set("Authorization", "Bearer secret")
+
+	provider, err := NewTLSProvider(backend)
+	require.NoError(t, err)
+
+	err = provider.Present("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	assert.Equal(t, "example.com", payload.Domain)
+	assert.Contains(t, payload.Certificate, "BEGIN CERTIFICATE")
+
+	err = provider.CleanUp("example.com", "token", "keyAuth")
+	require.NoError(t, err)
+
+	assert.Equal(t, "example.com", deleted)
+}
@@ -0,0 +1,101 @@
+package snirouter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Webhook pushes the challenge certificates to an HTTP endpoint.
+//
+// On Present, a POST request is sent with a JSON body:
+//
+//	{"domain": "example.com", "certificate": "<PEM>", "private_key": "<PEM>"}
+//
+// On CleanUp, a DELETE request is sent to the same endpoint with the `domain` query parameter.
+type Webhook struct {
+	Endpoint *url.URL
+	// Headers are added to all the requests (ex: Authorization).
+	Headers http.Header
+
+	HTTPClient *http.Client
+}
+
+// NewWebhook creates a new Webhook backend.
+func NewWebhook(endpoint *url.URL) *Webhook {
+	return &Webhook{
+		Endpoint:   endpoint,
+		Headers:    http.Header{},
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Add sends the challenge certificate to the endpoint.
+func (w *Webhook) Add(domain string, certPEM, keyPEM []byte) error {
+	payload := webhookPayload{
+		Domain:      domain,
+		Certificate: string(certPEM),
+		PrivateKey:  string(keyPEM),
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, w.Endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return w.do(req)
+}
+
+// Remove asks the endpoint to remove the challenge certificate.
+func (w *Webhook) Remove(domain string) error {
+	endpoint := *w.Endpoint
+
+	query := endpoint.Query()
+	query.Set("domain", domain)
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodDelete, endpoint.String(), http.NoBody)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+
+	return w.do(req)
+}
+
+func (w *Webhook) do(req *http.Request) error {
+	for k, v := range w.Headers {
+		req.Header[k] = v
+	}
+
+	resp, err := w.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("webhook: unexpected status code: [%s] %s: %d: %s", req.Method, req.URL, resp.StatusCode, string(raw))
+	}
+
+	return nil
+}
+
+type webhookPayload struct {
+	Domain      string `json:"domain"`
+	Certificate string `json:"certificate"`
+	PrivateKey  string `json:"private_key"`
+}
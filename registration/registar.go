@@ -1,8 +1,17 @@
+// Package registration manages the ACME account of a user: registration, update, key change, and deactivation.
+//
+// The External Account Binding of an existing account cannot be replaced:
+// a newAccount request signed by the key of an existing account returns this account without processing the binding (RFC 8555 section 7.3.1).
+// After a rotation of the EAB key by the CA, register a new account with a new key and the new binding (RegisterWithExternalAccountBinding),
+// and then deactivate the previous account (DeleteRegistration) with its own key if needed.
 package registration
 
 import (
+	"crypto"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/go-acme/lego/v4/acme"
 	"github.com/go-acme/lego/v4/acme/api"
@@ -70,6 +79,7 @@ func (r *Registrar) Register(options RegisterOptions) (*Resource, error) {
 }
 
 // RegisterWithExternalAccountBinding Register the current account to the ACME server.
+// If the key of the user is already registered, the server returns the existing account, and the binding is ignored.
 func (r *Registrar) RegisterWithExternalAccountBinding(options RegisterEABOptions) (*Resource, error) {
 	accMsg := acme.Account{
 		TermsOfServiceAgreed: options.TermsOfServiceAgreed,
@@ -143,6 +153,92 @@ func (r *Registrar) UpdateRegistration(options RegisterOptions) (*Resource, erro
 	return &Resource{URI: accountURL, Body: account}, nil
 }
 
+// UpdateContacts replaces the contacts of the current account (ex: after an email change), without registering again.
+// The email addresses without scheme are prefixed by "mailto:", an empty list removes all the contacts.
+// The returned Resource contains the contacts validated by the CA.
+func (r *Registrar) UpdateContacts(contacts []string) (*Resource, error) {
+	if r == nil || r.user == nil || r.user.GetRegistration() == nil {
+		return nil, errors.New("acme: cannot update the contacts of a nil client or user")
+	}
+
+	var normalized []string
+
+	for _, contact := range contacts {
+		contact = strings.TrimSpace(contact)
+		if contact == "" {
+			continue
+		}
+
+		if !strings.Contains(contact, ":") {
+			contact = mailTo + contact
+		}
+
+		normalized = append(normalized, contact)
+	}
+
+	accountURL := r.user.GetRegistration().URI
+
+	log.Infof("acme: Updating the contacts of the account %s", accountURL)
+
+	account, err := r.core.Accounts.UpdateContacts(accountURL, normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resource{URI: accountURL, Body: account}, nil
+}
+
+// ListOrders lists the orders of the current account (ex: to audit the pending orders).
+func (r *Registrar) ListOrders() ([]acme.ExtendedOrder, error) {
+	if r == nil || r.user == nil || r.user.GetRegistration() == nil {
+		return nil, errors.New("acme: cannot list the orders of a nil client or user")
+	}
+
+	accountURL := r.user.GetRegistration().URI
+
+	account, err := r.core.Accounts.Get(accountURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Orders == "" {
+		return nil, fmt.Errorf("acme: the server does not provide the orders of the account %s", accountURL)
+	}
+
+	orderURLs, err := r.core.Accounts.ListOrders(account.Orders)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []acme.ExtendedOrder
+
+	for _, orderURL := range orderURLs {
+		order, err := r.core.Orders.Get(orderURL)
+		if err != nil {
+			return nil, fmt.Errorf("acme: get order %s: %w", orderURL, err)
+		}
+
+		order.Location = orderURL
+
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+// ChangeAccountKey replaces the key of the current account by newKey (account key rollover).
+// After a successful rollover, all the requests are signed with the new key,
+// the new key must be stored by the caller (i.e. User.GetPrivateKey must return it).
+func (r *Registrar) ChangeAccountKey(newKey crypto.PrivateKey) error {
+	if r == nil || r.user == nil || r.user.GetRegistration() == nil {
+		return errors.New("acme: cannot change the key of a nil client or user")
+	}
+
+	log.Infof("acme: Changing the key of the account %s", r.user.GetRegistration().URI)
+
+	return r.core.Accounts.ChangeKey(newKey)
+}
+
 // DeleteRegistration deletes the client's user registration from the ACME server.
 func (r *Registrar) DeleteRegistration() error {
 	if r == nil || r.user == nil {
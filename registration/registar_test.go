@@ -1,14 +1,20 @@
 package registration
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"testing"
 
 	"github.com/go-acme/lego/v4/acme"
 	"github.com/go-acme/lego/v4/acme/api"
 	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/go-jose/go-jose/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -46,3 +52,220 @@ func TestRegistrar_ResolveAccountByKey(t *testing.T) {
 
 	assert.Equal(t, "valid", res.Body.Status, "Unexpected account status")
 }
+
+func TestRegistrar_ChangeAccountKey(t *testing.T) {
+	mux, apiURL := tester.SetupFakeAPI(t)
+
+	oldKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err, "Could not generate test key")
+
+	newKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err, "Could not generate test key")
+
+	mux.HandleFunc("/keyChange", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		outer, err := jose.ParseSigned(string(body), []jose.SignatureAlgorithm{jose.RS256})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		content, err := outer.Verify(oldKey.Public())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		inner, err := jose.ParseSigned(string(content), []jose.SignatureAlgorithm{jose.RS256})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		jwk := inner.Signatures[0].Protected.JSONWebKey
+		if jwk == nil || inner.Signatures[0].Protected.ExtraHeaders["url"] != apiURL+"/keyChange" {
+			http.Error(w, "invalid inner JWS header", http.StatusBadRequest)
+			return
+		}
+
+		payload, err := inner.Verify(jwk)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var keyChange struct {
+			Account string          `json:"account"`
+			OldKey  jose.JSONWebKey `json:"oldKey"`
+		}
+
+		err = json.Unmarshal(payload, &keyChange)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if keyChange.Account != apiURL+"/account/1" || !oldKey.PublicKey.Equal(keyChange.OldKey.Key) || !newKey.PublicKey.Equal(jwk.Key) {
+			http.Error(w, "invalid key change", http.StatusBadRequest)
+			return
+		}
+	})
+
+	user := mockUser{
+		email:      "test@test.com",
+		regres:     &Resource{URI: apiURL + "/account/1"},
+		privatekey: oldKey,
+	}
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", apiURL+"/account/1", oldKey)
+	require.NoError(t, err)
+
+	registrar := NewRegistrar(core, user)
+
+	err = registrar.ChangeAccountKey(newKey)
+	require.NoError(t, err)
+
+	// the requests are now signed with the new key.
+	keyAuth, err := core.GetKeyAuthorization("token")
+	require.NoError(t, err)
+
+	thumbprint, err := (&jose.JSONWebKey{Key: newKey.Public()}).Thumbprint(crypto.SHA256)
+	require.NoError(t, err)
+
+	assert.Equal(t, "token."+base64.RawURLEncoding.EncodeToString(thumbprint), keyAuth)
+}
+
+func TestRegistrar_ListOrders(t *testing.T) {
+	mux, apiURL := tester.SetupFakeAPI(t)
+
+	mux.HandleFunc("/account/1", func(w http.ResponseWriter, _ *http.Request) {
+		err := tester.WriteJSONResponse(w, acme.Account{Status: "valid", Orders: apiURL + "/account/1/orders"})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	mux.HandleFunc("/account/1/orders", func(w http.ResponseWriter, r *http.Request) {
+		list := acme.OrdersList{Orders: []string{apiURL + "/order/1"}}
+
+		if r.URL.Query().Get("page") == "2" {
+			list = acme.OrdersList{Orders: []string{apiURL + "/order/2"}}
+		} else {
+			w.Header().Add("Link", fmt.Sprintf(`<%s/account/1/orders?page=2>;rel="next"`, apiURL))
+		}
+
+		err := tester.WriteJSONResponse(w, list)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	mux.HandleFunc("/order/", func(w http.ResponseWriter, r *http.Request) {
+		status := acme.StatusPending
+		if r.URL.Path == "/order/2" {
+			status = acme.StatusValid
+		}
+
+		err := tester.WriteJSONResponse(w, acme.Order{Status: status})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	user := mockUser{
+		email:      "test@test.com",
+		regres:     &Resource{URI: apiURL + "/account/1"},
+		privatekey: key,
+	}
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", apiURL+"/account/1", key)
+	require.NoError(t, err)
+
+	orders, err := NewRegistrar(core, user).ListOrders()
+	require.NoError(t, err)
+
+	require.Len(t, orders, 2)
+
+	assert.Equal(t, apiURL+"/order/1", orders[0].Location)
+	assert.Equal(t, acme.StatusPending, orders[0].Status)
+	assert.Equal(t, apiURL+"/order/2", orders[1].Location)
+	assert.Equal(t, acme.StatusValid, orders[1].Status)
+}
+
+func TestRegistrar_UpdateContacts(t *testing.T) {
+	mux, apiURL := tester.SetupFakeAPI(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err, "Could not generate test key")
+
+	var raw map[string]json.RawMessage
+
+	mux.HandleFunc("/account/1", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		jws, err := jose.ParseSigned(string(body), []jose.SignatureAlgorithm{jose.RS256})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		payload, err := jws.Verify(key.Public())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if err = json.Unmarshal(payload, &raw); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var account acme.Account
+		if err = json.Unmarshal(payload, &account); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		account.Status = acme.StatusValid
+
+		err = tester.WriteJSONResponse(w, account)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	user := mockUser{
+		email:      "test@test.com",
+		regres:     &Resource{URI: apiURL + "/account/1"},
+		privatekey: key,
+	}
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", apiURL+"/account/1", key)
+	require.NoError(t, err)
+
+	registrar := NewRegistrar(core, user)
+
+	res, err := registrar.UpdateContacts([]string{"new@example.com", " mailto:other@example.com", ""})
+	require.NoError(t, err)
+
+	assert.Equal(t, apiURL+"/account/1", res.URI)
+	assert.Equal(t, []string{"mailto:new@example.com", "mailto:other@example.com"}, res.Body.Contact)
+
+	// an empty list removes the contacts.
+	_, err = registrar.UpdateContacts(nil)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, "[]", string(raw["contact"]))
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	filePerm os.FileMode = 0o600
+	dirPerm  os.FileMode = 0o700
+)
+
+// tmpSuffix the suffix of the temporary files (`.<name>.tmp<random>`), used by the atomic writes.
+const tmpSuffix = ".tmp"
+
+var _ CertificatesStorage = (*FileStorage)(nil)
+
+// FileStorage stores the files on the disk (ex: `.lego/`).
+type FileStorage struct {
+	rootPath string
+}
+
+// NewFileStorage creates a new FileStorage, the files are stored in the root path.
+func NewFileStorage(rootPath string) *FileStorage {
+	return &FileStorage{rootPath: rootPath}
+}
+
+func (s *FileStorage) ReadFile(key string) ([]byte, error) {
+	return os.ReadFile(s.Location(key))
+}
+
+// WriteFile writes the file atomically (see [WriteFileAtomic]).
+func (s *FileStorage) WriteFile(key string, data []byte) error {
+	filePath := s.Location(key)
+
+	err := os.MkdirAll(filepath.Dir(filePath), dirPerm)
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(filePath, data, filePerm, nil)
+}
+
+func (s *FileStorage) Exists(key string) (bool, error) {
+	_, err := os.Stat(s.Location(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *FileStorage) Remove(key string) error {
+	return os.Remove(s.Location(key))
+}
+
+func (s *FileStorage) List(prefix string) ([]string, error) {
+	root := s.Location(prefix)
+
+	var keys []string
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+
+			return err
+		}
+
+		if d.IsDir() || isTempFile(d.Name()) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.rootPath, path)
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, filepath.ToSlash(rel))
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Location returns the path of the file on the disk.
+func (s *FileStorage) Location(key string) string {
+	return filepath.Join(s.rootPath, filepath.FromSlash(key))
+}
+
+// WriteFileAtomic writes a file atomically: the data is written to a temporary file of the same directory, synced to the disk,
+// and the temporary file is renamed, so a reader never sees a partially written file.
+// The optional setup function is called with the temporary file before the rename (ex: to change the owner of the file).
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode, setup func(f *os.File) error) error {
+	dir := filepath.Dir(filename)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+tmpSuffix+"*")
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	err = writeAndSync(tmp, data, perm, setup)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmp.Name(), filename)
+	if err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+func writeAndSync(f *os.File, data []byte, perm os.FileMode, setup func(f *os.File) error) error {
+	err := f.Chmod(perm)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if setup != nil {
+		err = setup(f)
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+
+	_, err = f.Write(data)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	err = f.Sync()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// syncDir syncs the directory to the disk, so the rename of a file is persisted.
+// The directories cannot be synced on Windows.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = d.Close() }()
+
+	return d.Sync()
+}
+
+// isTempFile returns true if the file is a temporary file of an atomic write (ex: an interrupted write).
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.Contains(name, tmpSuffix)
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStorage(t *testing.T) {
+	rootPath := t.TempDir()
+
+	s := NewFileStorage(rootPath)
+
+	exists, err := s.Exists("certificates/example.com.crt")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = s.ReadFile("certificates/example.com.crt")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	require.NoError(t, s.WriteFile("certificates/example.com.crt", []byte("cert")))
+	require.NoError(t, s.WriteFile("accounts/example.org/foo@example.com/account.json", []byte("{}")))
+
+	assert.FileExists(t, filepath.Join(rootPath, "certificates", "example.com.crt"))
+
+	exists, err = s.Exists("certificates/example.com.crt")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	data, err := s.ReadFile("certificates/example.com.crt")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("cert"), data)
+
+	keys, err := s.List("accounts")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"accounts/example.org/foo@example.com/account.json"}, keys)
+
+	require.NoError(t, s.Remove("certificates/example.com.crt"))
+
+	keys, err = s.List("certificates")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestFileStorage_List_notExist(t *testing.T) {
+	s := NewFileStorage(t.TempDir())
+
+	keys, err := s.List("archives")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestFileStorage_WriteFile_atomic(t *testing.T) {
+	rootPath := t.TempDir()
+
+	s := NewFileStorage(rootPath)
+
+	require.NoError(t, s.WriteFile("certificates/example.com.crt", []byte("old")))
+	require.NoError(t, s.WriteFile("certificates/example.com.crt", []byte("new")))
+
+	data, err := s.ReadFile("certificates/example.com.crt")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new"), data)
+
+	info, err := os.Stat(filepath.Join(rootPath, "certificates", "example.com.crt"))
+	require.NoError(t, err)
+	assert.Equal(t, filePerm, info.Mode().Perm())
+
+	// leftover of an interrupted write.
+	require.NoError(t, os.WriteFile(filepath.Join(rootPath, "certificates", ".example.com.key.tmp123"), []byte("key"), filePerm))
+
+	keys, err := s.List("certificates")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"certificates/example.com.crt"}, keys)
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "example.com.crt")
+
+	var tmpName string
+
+	err := WriteFileAtomic(filename, []byte("cert"), 0o644, func(f *os.File) error {
+		tmpName = f.Name()
+		return nil
+	})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("cert"), data)
+
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+
+	assert.True(t, isTempFile(filepath.Base(tmpName)))
+	assert.NoFileExists(t, tmpName)
+
+	// The file is untouched if the setup fails.
+	err = WriteFileAtomic(filename, []byte("new"), 0o644, func(*os.File) error {
+		return fs.ErrPermission
+	})
+	require.ErrorIs(t, err, fs.ErrPermission)
+
+	data, err = os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("cert"), data)
+
+	entries, err := os.ReadDir(filepath.Dir(filename))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,321 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// In-cluster configuration.
+// https://kubernetes.io/docs/tasks/run-application/access-api-from-pod/#directly-accessing-the-rest-api
+const (
+	serviceAccountPath      = "/var/run/secrets/kubernetes.io/serviceaccount"
+	serviceAccountToken     = serviceAccountPath + "/token"
+	serviceAccountCA        = serviceAccountPath + "/ca.crt"
+	serviceAccountNamespace = serviceAccountPath + "/namespace"
+)
+
+// apiClient a minimal client of the Kubernetes API server.
+type apiClient struct {
+	baseURL    *url.URL
+	token      string
+	tokenFile  string
+	namespace  string
+	httpClient *http.Client
+}
+
+// newInClusterClient creates a client from the service account of the pod.
+func newInClusterClient() (*apiClient, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("not running in a cluster: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not defined")
+	}
+
+	caData, err := os.ReadFile(serviceAccountCA)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := newTLSConfig(caData, false)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := os.ReadFile(serviceAccountNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiClient{
+		baseURL:    &url.URL{Scheme: "https", Host: net.JoinHostPort(host, port)},
+		tokenFile:  serviceAccountToken,
+		namespace:  strings.TrimSpace(string(namespace)),
+		httpClient: newHTTPClient(tlsConfig),
+	}, nil
+}
+
+// https://kubernetes.io/docs/concepts/configuration/organize-cluster-access-kubeconfig/
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Contexts       []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Clusters []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string `yaml:"token"`
+			TokenFile             string `yaml:"tokenFile"`
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKey             string `yaml:"client-key"`
+			ClientKeyData         string `yaml:"client-key-data"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// newKubeconfigClient creates a client from the current context of a kubeconfig file.
+// Only the tokens and the client certificates are supported as credentials (no exec or auth provider plugins).
+func newKubeconfigClient(filename string) (*apiClient, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var config kubeconfig
+
+	err = yaml.Unmarshal(raw, &config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig %s: %w", filename, err)
+	}
+
+	client := &apiClient{}
+
+	var clusterName, userName string
+
+	for _, c := range config.Contexts {
+		if c.Name == config.CurrentContext {
+			clusterName, userName, client.namespace = c.Context.Cluster, c.Context.User, c.Context.Namespace
+			break
+		}
+	}
+
+	if clusterName == "" {
+		return nil, fmt.Errorf("kubeconfig %s: context %q not found", filename, config.CurrentContext)
+	}
+
+	dir := filepath.Dir(filename)
+
+	var tlsConfig *tls.Config
+
+	for _, c := range config.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+
+		client.baseURL, err = url.Parse(c.Cluster.Server)
+		if err != nil {
+			return nil, fmt.Errorf("kubeconfig %s: invalid server: %w", filename, err)
+		}
+
+		caData, err := readData(dir, c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority)
+		if err != nil {
+			return nil, fmt.Errorf("kubeconfig %s: certificate authority: %w", filename, err)
+		}
+
+		tlsConfig, err = newTLSConfig(caData, c.Cluster.InsecureSkipTLSVerify)
+		if err != nil {
+			return nil, fmt.Errorf("kubeconfig %s: %w", filename, err)
+		}
+	}
+
+	if client.baseURL == nil {
+		return nil, fmt.Errorf("kubeconfig %s: cluster %q not found", filename, clusterName)
+	}
+
+	for _, u := range config.Users {
+		if u.Name != userName {
+			continue
+		}
+
+		client.token = u.User.Token
+
+		if u.User.TokenFile != "" {
+			client.tokenFile = resolvePath(dir, u.User.TokenFile)
+		}
+
+		certData, err := readData(dir, u.User.ClientCertificateData, u.User.ClientCertificate)
+		if err != nil {
+			return nil, fmt.Errorf("kubeconfig %s: client certificate: %w", filename, err)
+		}
+
+		keyData, err := readData(dir, u.User.ClientKeyData, u.User.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("kubeconfig %s: client key: %w", filename, err)
+		}
+
+		if len(certData) > 0 {
+			cert, err := tls.X509KeyPair(certData, keyData)
+			if err != nil {
+				return nil, fmt.Errorf("kubeconfig %s: client certificate: %w", filename, err)
+			}
+
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+	}
+
+	client.httpClient = newHTTPClient(tlsConfig)
+
+	return client, nil
+}
+
+func (c *apiClient) do(ctx context.Context, method, endpoint string, query url.Values, payload, result any) error {
+	var body io.Reader = http.NoBody
+
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("unable to marshal request: %w", err)
+		}
+
+		body = bytes.NewReader(raw)
+	}
+
+	reqURL := c.baseURL.JoinPath(endpoint)
+	reqURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), body)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	token, err := c.getToken()
+	if err != nil {
+		return err
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	raw, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fs.ErrNotExist
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code: [%s] %s: %d: %s", req.Method, req.URL, resp.StatusCode, string(raw))
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	err = json.Unmarshal(raw, result)
+	if err != nil {
+		return fmt.Errorf("unable to unmarshal response: [status code: %d] body: %s", resp.StatusCode, string(raw))
+	}
+
+	return nil
+}
+
+// getToken returns the bearer token, the token file is read for each request as the tokens are rotated.
+func (c *apiClient) getToken() (string, error) {
+	if c.tokenFile == "" {
+		return c.token, nil
+	}
+
+	token, err := os.ReadFile(c.tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the token: %w", err)
+	}
+
+	return strings.TrimSpace(string(token)), nil
+}
+
+func newTLSConfig(caData []byte, insecure bool) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecure} //nolint:gosec // explicitly defined by the kubeconfig.
+
+	if len(caData) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, errors.New("invalid certificate authority")
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+func newHTTPClient(tlsConfig *tls.Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Timeout: 30 * time.Second, Transport: transport}
+}
+
+// readData returns the decoded inline data (base64), or the content of the file.
+func readData(dir, data, filename string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+
+	if filename == "" {
+		return nil, nil
+	}
+
+	return os.ReadFile(resolvePath(dir, filename))
+}
+
+// resolvePath resolves the paths relative to the kubeconfig file.
+func resolvePath(dir, filename string) string {
+	if filepath.IsAbs(filename) {
+		return filename
+	}
+
+	return filepath.Join(dir, filename)
+}
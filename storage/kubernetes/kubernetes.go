@@ -0,0 +1,588 @@
+// Package kubernetes implements a certificates storage using Kubernetes Secrets.
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-acme/lego/v4/storage"
+)
+
+const defaultNamespace = "default"
+
+// Secret types.
+const (
+	secretTypeTLS    = "kubernetes.io/tls"
+	secretTypeOpaque = "Opaque"
+)
+
+// Metadata of the secrets managed by the storage.
+const (
+	labelManagedBy = "app.kubernetes.io/managed-by"
+	managedBy      = "lego"
+
+	// annotationCertificate the base name of the certificate files stored in a "kubernetes.io/tls" secret.
+	annotationCertificate = "lego.go-acme.io/certificate"
+	// annotationKey the key of the file stored in an "Opaque" secret.
+	annotationKey = "lego.go-acme.io/key"
+)
+
+// contentDataKey the data key of the content of the files stored in "Opaque" secrets.
+const contentDataKey = "content"
+
+// certificatesDir the directory of the certificate files stored in "kubernetes.io/tls" secrets.
+const certificatesDir = "certificates"
+
+// Data keys of the "kubernetes.io/tls" secrets.
+const (
+	tlsCertKey = "tls.crt"
+	tlsKeyKey  = "tls.key"
+)
+
+// tlsDataKeys the data keys of the certificate files, by file extension.
+var tlsDataKeys = map[string]string{
+	".crt":         tlsCertKey,
+	".key":         tlsKeyKey,
+	".issuer.crt":  "ca.crt",
+	".pem":         "tls.pem",
+	".pfx":         "tls.pfx",
+	".json":        "lego.json",
+	".leaf.crt":    "leaf.crt",
+	".chain.crt":   "chain.crt",
+	".root.crt":    "root.crt",
+	".der":         "tls.der",
+	".p7b":         "tls.p7b",
+	".haproxy.pem": "haproxy.pem",
+	".ocsp":        "tls.ocsp",
+
+	".keystore.jks":   "keystore.jks",
+	".truststore.jks": "truststore.jks",
+	".keystore.p12":   "keystore.p12",
+	".truststore.p12": "truststore.p12",
+}
+
+// multiDotExts the extensions containing several dots.
+var multiDotExts = []string{
+	".issuer.crt", ".leaf.crt", ".chain.crt", ".root.crt", ".haproxy.pem",
+	".keystore.jks", ".truststore.jks", ".keystore.p12", ".truststore.p12",
+}
+
+// https://kubernetes.io/docs/concepts/overview/working-with-objects/names/#dns-subdomain-names
+var dnsSubdomain = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+// Config is used to configure the creation of the Storage.
+type Config struct {
+	// Kubeconfig is the path of a kubeconfig file (the current context is used).
+	// The in-cluster configuration (the service account of the pod) is used if empty.
+	Kubeconfig string
+
+	// Namespace overrides the namespace of the secrets.
+	// Default: the namespace of the pod (in-cluster), or the namespace of the current context (kubeconfig), or "default".
+	Namespace string
+
+	// SecretPrefix is prepended to the names of the secrets.
+	SecretPrefix string
+}
+
+var (
+	_ storage.CertificatesStorage = (*Storage)(nil)
+	_ storage.MultiFileWriter     = (*Storage)(nil)
+)
+
+// Storage stores the files as Kubernetes Secrets.
+//
+// The certificate files (`certificates/<name>.crt`, `.key`, `.issuer.crt`, etc.) are stored in a "kubernetes.io/tls" secret
+// named after the certificate (`<prefix><name>`, the wildcard certificates `_.example.com` are named `wildcard.example.com`),
+// so the secret can be used directly by the Ingresses.
+// The other files (accounts, archives) are stored in "Opaque" secrets (one secret by file).
+// The storage only modifies the secrets with the label `app.kubernetes.io/managed-by=lego`.
+type Storage struct {
+	config    *Config
+	client    *apiClient
+	namespace string
+}
+
+// NewStorage returns a Storage instance configured for Kubernetes.
+func NewStorage(config *Config) (*Storage, error) {
+	if config == nil {
+		return nil, errors.New("kubernetes: the configuration of the storage is nil")
+	}
+
+	var client *apiClient
+	var err error
+
+	if config.Kubeconfig != "" {
+		client, err = newKubeconfigClient(config.Kubeconfig)
+	} else {
+		client, err = newInClusterClient()
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes: %w", err)
+	}
+
+	namespace := config.Namespace
+	if namespace == "" {
+		namespace = client.namespace
+	}
+
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	return &Storage{config: config, client: client, namespace: namespace}, nil
+}
+
+func (s *Storage) ReadFile(key string) ([]byte, error) {
+	loc, err := s.locate(key)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes: read %s: %w", key, err)
+	}
+
+	sec, err := s.getSecret(loc.name)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes: read %s: %w", key, err)
+	}
+
+	data, ok := sec.Data[loc.dataKey]
+	if !ok || (len(data) == 0 && loc.secretType == secretTypeTLS) {
+		return nil, fmt.Errorf("kubernetes: read %s: %w", key, fs.ErrNotExist)
+	}
+
+	return data, nil
+}
+
+func (s *Storage) WriteFile(key string, data []byte) error {
+	return s.WriteFiles(map[string][]byte{key: data})
+}
+
+// WriteFiles creates or replaces several files.
+// The files stored in the same secret (ex: the certificate and its private key) are written by a single update of the secret,
+// so the consumers of the secret never see a certificate without its private key.
+func (s *Storage) WriteFiles(files map[string][]byte) error {
+	var names []string
+
+	bySecret := make(map[string][]secretFile)
+
+	for key, data := range files {
+		loc, err := s.locate(key)
+		if err != nil {
+			return fmt.Errorf("kubernetes: write %s: %w", key, err)
+		}
+
+		if data == nil {
+			data = []byte{}
+		}
+
+		if _, ok := bySecret[loc.name]; !ok {
+			names = append(names, loc.name)
+		}
+
+		bySecret[loc.name] = append(bySecret[loc.name], secretFile{key: key, loc: loc, data: data})
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		err := s.writeSecret(bySecret[name])
+		if err != nil {
+			return fmt.Errorf("kubernetes: write %s: %w", joinKeys(bySecret[name]), err)
+		}
+	}
+
+	return nil
+}
+
+// writeSecret creates or updates a secret with the files (the files must have the same location name).
+// The existing secrets not managed by the storage are not modified.
+func (s *Storage) writeSecret(files []secretFile) error {
+	loc := files[0].loc
+
+	sec, err := s.getSecret(loc.name)
+	if errors.Is(err, fs.ErrNotExist) {
+		sec = loc.newSecret()
+
+		for _, file := range files {
+			file.loc.adopt(sec, file.key)
+			sec.Data[file.loc.dataKey] = file.data
+		}
+
+		return s.client.do(context.Background(), http.MethodPost, s.secretsEndpoint(""), nil, sec, nil)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = checkManaged(sec, loc.secretType)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		file.loc.adopt(sec, file.key)
+		sec.Data[file.loc.dataKey] = file.data
+	}
+
+	return s.updateSecret(sec)
+}
+
+// WriteTLSSecret creates or updates a "kubernetes.io/tls" secret with the certificate and the private key (PEM).
+// Unlike the certificate files written by WriteFile, the name of the secret is not derived from the certificate,
+// and the secret is not listed by the storage.
+func (s *Storage) WriteTLSSecret(name string, cert, key []byte) error {
+	if len(name) > 253 || !dnsSubdomain.MatchString(name) {
+		return fmt.Errorf("kubernetes: invalid secret name %q", name)
+	}
+
+	sec, err := s.getSecret(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		sec = &secret{
+			APIVersion: "v1",
+			Kind:       "Secret",
+			Metadata:   metadata{Name: name, Labels: map[string]string{labelManagedBy: managedBy}},
+			Type:       secretTypeTLS,
+			Data:       map[string][]byte{tlsCertKey: cert, tlsKeyKey: key},
+		}
+
+		err = s.client.do(context.Background(), http.MethodPost, s.secretsEndpoint(""), nil, sec, nil)
+		if err != nil {
+			return fmt.Errorf("kubernetes: write secret %s: %w", name, err)
+		}
+
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("kubernetes: write secret %s: %w", name, err)
+	}
+
+	err = checkManaged(sec, secretTypeTLS)
+	if err != nil {
+		return fmt.Errorf("kubernetes: write secret %s: %w", name, err)
+	}
+
+	if sec.Data == nil {
+		sec.Data = map[string][]byte{}
+	}
+
+	sec.Data[tlsCertKey] = cert
+	sec.Data[tlsKeyKey] = key
+
+	err = s.updateSecret(sec)
+	if err != nil {
+		return fmt.Errorf("kubernetes: write secret %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) Exists(key string) (bool, error) {
+	_, err := s.ReadFile(key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Remove removes the file.
+// The secret is deleted when it does not contain any file.
+func (s *Storage) Remove(key string) error {
+	loc, err := s.locate(key)
+	if err != nil {
+		return fmt.Errorf("kubernetes: remove %s: %w", key, err)
+	}
+
+	sec, err := s.getSecret(loc.name)
+	if err != nil {
+		return fmt.Errorf("kubernetes: remove %s: %w", key, err)
+	}
+
+	err = checkManaged(sec, loc.secretType)
+	if err != nil {
+		return fmt.Errorf("kubernetes: remove %s: %w", key, err)
+	}
+
+	if loc.dataKey == tlsCertKey || loc.dataKey == tlsKeyKey {
+		// The "kubernetes.io/tls" secrets must contain the certificate and the private key data keys.
+		sec.Data[loc.dataKey] = []byte{}
+	} else {
+		delete(sec.Data, loc.dataKey)
+	}
+
+	if hasData(sec) {
+		err = s.updateSecret(sec)
+	} else {
+		err = s.client.do(context.Background(), http.MethodDelete, s.secretsEndpoint(loc.name), nil, nil, nil)
+	}
+
+	if err != nil {
+		return fmt.Errorf("kubernetes: remove %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) List(prefix string) ([]string, error) {
+	query := url.Values{"labelSelector": {labelManagedBy + "=" + managedBy}}
+
+	var result secretList
+
+	err := s.client.do(context.Background(), http.MethodGet, s.secretsEndpoint(""), query, nil, &result)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes: list %s: %w", prefix, err)
+	}
+
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	var keys []string
+
+	for _, sec := range result.Items {
+		if !strings.HasPrefix(sec.Metadata.Name, s.config.SecretPrefix) {
+			continue
+		}
+
+		for _, key := range secretKeys(sec) {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// Location returns the location of the file (ex: "default/example.com/tls.crt").
+func (s *Storage) Location(key string) string {
+	loc, err := s.locate(key)
+	if err != nil {
+		return key
+	}
+
+	return path.Join(s.namespace, loc.name, loc.dataKey)
+}
+
+func (s *Storage) getSecret(name string) (*secret, error) {
+	var sec secret
+
+	err := s.client.do(context.Background(), http.MethodGet, s.secretsEndpoint(name), nil, nil, &sec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sec, nil
+}
+
+// updateSecret replaces the secret, the resource version ensures that the secret has not been modified since read.
+func (s *Storage) updateSecret(sec *secret) error {
+	return s.client.do(context.Background(), http.MethodPut, s.secretsEndpoint(sec.Metadata.Name), nil, sec, nil)
+}
+
+func (s *Storage) secretsEndpoint(name string) string {
+	return path.Join("/api/v1/namespaces", s.namespace, "secrets", name)
+}
+
+// location the location of a file: a data key of a secret.
+type location struct {
+	name       string
+	secretType string
+	dataKey    string
+	// certificate the base name of the certificate files (only for the "kubernetes.io/tls" secrets).
+	certificate string
+}
+
+func (s *Storage) locate(key string) (*location, error) {
+	key = strings.Trim(key, "/")
+
+	if certificate, ext, ok := splitCertificateKey(key); ok {
+		name := s.config.SecretPrefix + certificate
+		if after, found := strings.CutPrefix(certificate, "_."); found {
+			name = s.config.SecretPrefix + "wildcard." + after
+		}
+
+		if len(name) > 253 || !dnsSubdomain.MatchString(name) {
+			return nil, fmt.Errorf("invalid secret name %q", name)
+		}
+
+		return &location{name: name, secretType: secretTypeTLS, dataKey: tlsDataKeys[ext], certificate: certificate}, nil
+	}
+
+	hash := sha256.Sum256([]byte(key))
+
+	return &location{
+		name:       s.config.SecretPrefix + "file-" + hex.EncodeToString(hash[:16]),
+		secretType: secretTypeOpaque,
+		dataKey:    contentDataKey,
+	}, nil
+}
+
+// newSecret creates an empty secret.
+func (l *location) newSecret() *secret {
+	sec := &secret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   metadata{Name: l.name},
+		Type:       l.secretType,
+		Data:       map[string][]byte{},
+	}
+
+	if l.secretType == secretTypeTLS {
+		sec.Data[tlsCertKey] = []byte{}
+		sec.Data[tlsKeyKey] = []byte{}
+	}
+
+	return sec
+}
+
+// adopt defines the label and the annotation of the secrets managed by the storage.
+func (l *location) adopt(sec *secret, key string) {
+	if sec.Metadata.Labels == nil {
+		sec.Metadata.Labels = map[string]string{}
+	}
+
+	if sec.Metadata.Annotations == nil {
+		sec.Metadata.Annotations = map[string]string{}
+	}
+
+	if sec.Data == nil {
+		sec.Data = map[string][]byte{}
+	}
+
+	sec.Metadata.Labels[labelManagedBy] = managedBy
+
+	if l.secretType == secretTypeTLS {
+		sec.Metadata.Annotations[annotationCertificate] = l.certificate
+	} else {
+		sec.Metadata.Annotations[annotationKey] = key
+	}
+}
+
+// secretFile a file to write in a secret.
+type secretFile struct {
+	key  string
+	loc  *location
+	data []byte
+}
+
+func joinKeys(files []secretFile) string {
+	var keys []string
+	for _, file := range files {
+		keys = append(keys, file.key)
+	}
+
+	sort.Strings(keys)
+
+	return strings.Join(keys, ", ")
+}
+
+// checkManaged checks that an existing secret is managed by the storage (label "app.kubernetes.io/managed-by=lego"),
+// the other secrets (ex: created by another tool) are never modified.
+func checkManaged(sec *secret, secretType string) error {
+	if sec.Metadata.Labels[labelManagedBy] != managedBy {
+		return fmt.Errorf("the existing secret is not managed by lego (missing label %s=%s)", labelManagedBy, managedBy)
+	}
+
+	if sec.Type != secretType {
+		return fmt.Errorf("the type of the existing secret is %q", sec.Type)
+	}
+
+	return nil
+}
+
+// splitCertificateKey splits the key of a certificate file (`certificates/<name><ext>`) into the base name and the extension.
+func splitCertificateKey(key string) (string, string, bool) {
+	if path.Dir(key) != certificatesDir {
+		return "", "", false
+	}
+
+	filename := path.Base(key)
+
+	ext := path.Ext(filename)
+
+	for _, multiDotExt := range multiDotExts {
+		if strings.HasSuffix(filename, multiDotExt) {
+			ext = multiDotExt
+			break
+		}
+	}
+
+	if _, ok := tlsDataKeys[ext]; !ok || filename == ext {
+		return "", "", false
+	}
+
+	return strings.TrimSuffix(filename, ext), ext, true
+}
+
+// hasData checks if the secret contains at least one non-empty data key.
+func hasData(sec *secret) bool {
+	for _, data := range sec.Data {
+		if len(data) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// secretKeys returns the keys of the files stored in the secret.
+func secretKeys(sec secret) []string {
+	if key, ok := sec.Metadata.Annotations[annotationKey]; ok {
+		return []string{key}
+	}
+
+	certificate, ok := sec.Metadata.Annotations[annotationCertificate]
+	if !ok {
+		return nil
+	}
+
+	var keys []string
+
+	for ext, dataKey := range tlsDataKeys {
+		if len(sec.Data[dataKey]) > 0 {
+			keys = append(keys, path.Join(certificatesDir, certificate+ext))
+		}
+	}
+
+	return keys
+}
+
+// https://kubernetes.io/docs/reference/kubernetes-api/config-and-storage-resources/secret-v1/
+type secret struct {
+	APIVersion string            `json:"apiVersion,omitempty"`
+	Kind       string            `json:"kind,omitempty"`
+	Metadata   metadata          `json:"metadata"`
+	Type       string            `json:"type,omitempty"`
+	Data       map[string][]byte `json:"data,omitempty"`
+}
+
+type metadata struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+}
+
+type secretList struct {
+	Items []secret `json:"items"`
+}
@@ -0,0 +1,337 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPIServer a minimal Kubernetes API server (secrets of the "lego" namespace).
+type fakeAPIServer struct {
+	mu      sync.Mutex
+	secrets map[string]*secret
+	// writes the number of the creations and updates of secrets.
+	writes int
+}
+
+func (f *fakeAPIServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Header.Get("Authorization") != "Bearer token" {
+		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name, found := strings.CutPrefix(req.URL.Path, "/api/v1/namespaces/lego/secrets")
+	if !found {
+		http.NotFound(rw, req)
+		return
+	}
+
+	name = strings.TrimPrefix(name, "/")
+
+	switch {
+	case name == "" && req.Method == http.MethodGet:
+		list := secretList{}
+		for _, sec := range f.secrets {
+			if sec.Metadata.Labels[labelManagedBy] == managedBy {
+				list.Items = append(list.Items, *sec)
+			}
+		}
+
+		_ = json.NewEncoder(rw).Encode(list)
+
+	case name == "" && req.Method == http.MethodPost:
+		sec, ok := decodeSecret(rw, req)
+		if !ok {
+			return
+		}
+
+		if _, exists := f.secrets[sec.Metadata.Name]; exists {
+			http.Error(rw, "AlreadyExists", http.StatusConflict)
+			return
+		}
+
+		sec.Metadata.ResourceVersion = "1"
+		f.secrets[sec.Metadata.Name] = sec
+		f.writes++
+
+		rw.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(rw).Encode(sec)
+
+	case req.Method == http.MethodGet:
+		sec, ok := f.secrets[name]
+		if !ok {
+			http.Error(rw, "NotFound", http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewEncoder(rw).Encode(sec)
+
+	case req.Method == http.MethodPut:
+		sec, ok := decodeSecret(rw, req)
+		if !ok {
+			return
+		}
+
+		current, exists := f.secrets[name]
+		if !exists {
+			http.Error(rw, "NotFound", http.StatusNotFound)
+			return
+		}
+
+		if sec.Metadata.ResourceVersion != current.Metadata.ResourceVersion {
+			http.Error(rw, "Conflict", http.StatusConflict)
+			return
+		}
+
+		sec.Metadata.ResourceVersion += "1"
+		f.secrets[name] = sec
+		f.writes++
+
+		_ = json.NewEncoder(rw).Encode(sec)
+
+	case req.Method == http.MethodDelete:
+		delete(f.secrets, name)
+
+	default:
+		http.Error(rw, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func decodeSecret(rw http.ResponseWriter, req *http.Request) (*secret, bool) {
+	var sec secret
+
+	err := json.NewDecoder(req.Body).Decode(&sec)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	// https://kubernetes.io/docs/concepts/configuration/secret/#tls-secrets
+	if sec.Type == secretTypeTLS {
+		for _, key := range []string{tlsCertKey, tlsKeyKey} {
+			if _, ok := sec.Data[key]; !ok {
+				http.Error(rw, "Invalid: missing "+key, http.StatusUnprocessableEntity)
+				return nil, false
+			}
+		}
+	}
+
+	return &sec, true
+}
+
+func setupStorage(t *testing.T, prefix string) (*Storage, *fakeAPIServer) {
+	t.Helper()
+
+	fake := &fakeAPIServer{secrets: map[string]*secret{}}
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: test
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+    namespace: lego
+clusters:
+- name: test
+  cluster:
+    server: %s
+users:
+- name: test
+  user:
+    token: token
+`, server.URL)
+
+	filename := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(filename, []byte(kubeconfig), 0o600))
+
+	s, err := NewStorage(&Config{Kubeconfig: filename, SecretPrefix: prefix})
+	require.NoError(t, err)
+
+	return s, fake
+}
+
+func TestStorage_certificates(t *testing.T) {
+	s, fake := setupStorage(t, "")
+
+	exists, err := s.Exists("certificates/_.example.com.crt")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, s.WriteFile("certificates/_.example.com.crt", []byte("cert")))
+
+	require.Contains(t, fake.secrets, "wildcard.example.com")
+
+	sec := fake.secrets["wildcard.example.com"]
+	assert.Equal(t, secretTypeTLS, sec.Type)
+	assert.Equal(t, map[string][]byte{"tls.crt": []byte("cert"), "tls.key": {}}, sec.Data)
+
+	// the private key is not written yet.
+	exists, err = s.Exists("certificates/_.example.com.key")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, s.WriteFile("certificates/_.example.com.key", []byte("key")))
+	require.NoError(t, s.WriteFile("certificates/_.example.com.issuer.crt", []byte("issuer")))
+
+	data, err := s.ReadFile("certificates/_.example.com.key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key"), data)
+
+	keys, err := s.List("certificates")
+	require.NoError(t, err)
+
+	expected := []string{
+		"certificates/_.example.com.crt",
+		"certificates/_.example.com.issuer.crt",
+		"certificates/_.example.com.key",
+	}
+	assert.Equal(t, expected, keys)
+
+	assert.Equal(t, "lego/wildcard.example.com/tls.crt", s.Location("certificates/_.example.com.crt"))
+
+	for _, key := range expected {
+		require.NoError(t, s.Remove(key))
+	}
+
+	assert.Empty(t, fake.secrets)
+}
+
+func TestStorage_WriteFiles(t *testing.T) {
+	s, fake := setupStorage(t, "")
+
+	require.NoError(t, s.WriteFiles(map[string][]byte{
+		"certificates/example.com.crt":        []byte("cert"),
+		"certificates/example.com.key":        []byte("key"),
+		"certificates/example.com.issuer.crt": []byte("issuer"),
+	}))
+
+	assert.Equal(t, 1, fake.writes)
+
+	require.NoError(t, s.WriteFiles(map[string][]byte{
+		"certificates/example.com.crt": []byte("cert2"),
+		"certificates/example.com.key": []byte("key2"),
+	}))
+
+	assert.Equal(t, 2, fake.writes)
+
+	require.Contains(t, fake.secrets, "example.com")
+
+	expected := map[string][]byte{"tls.crt": []byte("cert2"), "tls.key": []byte("key2"), "ca.crt": []byte("issuer")}
+	assert.Equal(t, expected, fake.secrets["example.com"].Data)
+}
+
+func TestStorage_foreignSecret(t *testing.T) {
+	s, fake := setupStorage(t, "")
+
+	fake.secrets["example.com"] = &secret{
+		Metadata: metadata{Name: "example.com", ResourceVersion: "1"},
+		Type:     secretTypeTLS,
+		Data:     map[string][]byte{"tls.crt": []byte("foreign"), "tls.key": []byte("foreign")},
+	}
+
+	expected := "the existing secret is not managed by lego (missing label app.kubernetes.io/managed-by=lego)"
+
+	err := s.WriteFile("certificates/example.com.crt", []byte("cert"))
+	require.EqualError(t, err, "kubernetes: write certificates/example.com.crt: "+expected)
+
+	err = s.Remove("certificates/example.com.key")
+	require.EqualError(t, err, "kubernetes: remove certificates/example.com.key: "+expected)
+
+	err = s.WriteTLSSecret("example.com", []byte("cert"), []byte("key"))
+	require.EqualError(t, err, "kubernetes: write secret example.com: "+expected)
+
+	assert.Equal(t, 0, fake.writes)
+	assert.Equal(t, []byte("foreign"), fake.secrets["example.com"].Data["tls.crt"])
+}
+
+func TestStorage_files(t *testing.T) {
+	s, fake := setupStorage(t, "lego-")
+
+	key := "accounts/acme-v02.api.letsencrypt.org/foo@example.com/account.json"
+
+	_, err := s.ReadFile(key)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	require.NoError(t, s.WriteFile(key, []byte("{}")))
+	require.NoError(t, s.WriteFile(key, []byte(`{"email":"foo@example.com"}`)))
+
+	require.Len(t, fake.secrets, 1)
+
+	for name, sec := range fake.secrets {
+		assert.True(t, strings.HasPrefix(name, "lego-file-"))
+		assert.Equal(t, secretTypeOpaque, sec.Type)
+		assert.Equal(t, key, sec.Metadata.Annotations[annotationKey])
+	}
+
+	data, err := s.ReadFile(key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"email":"foo@example.com"}`), data)
+
+	keys, err := s.List("accounts")
+	require.NoError(t, err)
+	assert.Equal(t, []string{key}, keys)
+
+	keys, err = s.List("certificates")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	require.NoError(t, s.Remove(key))
+
+	assert.Empty(t, fake.secrets)
+}
+
+func TestStorage_WriteTLSSecret(t *testing.T) {
+	s, fake := setupStorage(t, "lego-")
+
+	require.NoError(t, s.WriteTLSSecret("example-tls", []byte("cert"), []byte("key")))
+	require.NoError(t, s.WriteTLSSecret("example-tls", []byte("cert2"), []byte("key2")))
+
+	require.Contains(t, fake.secrets, "example-tls")
+
+	sec := fake.secrets["example-tls"]
+	assert.Equal(t, secretTypeTLS, sec.Type)
+	assert.Equal(t, map[string][]byte{"tls.crt": []byte("cert2"), "tls.key": []byte("key2")}, sec.Data)
+
+	// the secret is not a file of the storage.
+	keys, err := s.List("certificates")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	require.NoError(t, s.WriteFile("accounts/foo@example.com/account.json", []byte("{}")))
+
+	for name := range fake.secrets {
+		if strings.HasPrefix(name, "lego-file-") {
+			err = s.WriteTLSSecret(name, []byte("cert"), []byte("key"))
+			require.ErrorContains(t, err, `the type of the existing secret is "Opaque"`)
+		}
+	}
+
+	err = s.WriteTLSSecret("Invalid_Name", []byte("cert"), []byte("key"))
+	require.EqualError(t, err, `kubernetes: invalid secret name "Invalid_Name"`)
+}
+
+func TestNewStorage_inCluster(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "")
+
+	_, err := NewStorage(&Config{})
+	require.EqualError(t, err, "kubernetes: not running in a cluster: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not defined")
+}
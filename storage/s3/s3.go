@@ -0,0 +1,253 @@
+// Package s3 implements a certificates storage using an S3 bucket (AWS S3, MinIO, or any S3 compatible object storage).
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/go-acme/lego/v4/storage"
+)
+
+// Config is used to configure the creation of the Storage.
+type Config struct {
+	// Bucket is the name of the bucket.
+	Bucket string
+
+	// Prefix is prepended to the keys of the files to build the keys of the objects.
+	Prefix string
+
+	// Region overrides the region of the AWS configuration.
+	Region string
+
+	// Endpoint is a custom endpoint (ex: http://127.0.0.1:9000 for MinIO).
+	// The path-style addressing is used if a custom endpoint is defined.
+	Endpoint string
+
+	// ServerSideEncryption is the server-side encryption algorithm ("AES256" or "aws:kms").
+	// The server-side encryption is disabled if empty.
+	ServerSideEncryption string
+
+	// KMSKeyID is the ID of the KMS key used by the "aws:kms" server-side encryption.
+	// The AWS managed key is used if empty.
+	KMSKeyID string
+
+	// RequireVersioning ensures that the versioning is enabled on the bucket,
+	// so the previous versions of the files (private keys, certificates, accounts) are kept by each write.
+	RequireVersioning bool
+}
+
+// NewDefaultConfig returns a default configuration for the Storage.
+func NewDefaultConfig() *Config {
+	return &Config{
+		ServerSideEncryption: string(types.ServerSideEncryptionAes256),
+	}
+}
+
+var _ storage.CertificatesStorage = (*Storage)(nil)
+
+// Storage stores the files as objects of an S3 bucket.
+// The credentials are loaded from the AWS default sources (environment variables, shared configuration, etc.).
+type Storage struct {
+	config *Config
+	client *s3.Client
+}
+
+// NewStorage returns a Storage instance configured for S3.
+func NewStorage(config *Config) (*Storage, error) {
+	if config == nil {
+		return nil, errors.New("s3: the configuration of the storage is nil")
+	}
+
+	if config.Bucket == "" {
+		return nil, errors.New("s3: bucket name missing")
+	}
+
+	switch types.ServerSideEncryption(config.ServerSideEncryption) {
+	case "", types.ServerSideEncryptionAes256:
+		if config.KMSKeyID != "" {
+			return nil, errors.New("s3: the KMS key ID requires the aws:kms server-side encryption")
+		}
+	case types.ServerSideEncryptionAwsKms:
+	default:
+		return nil, fmt.Errorf("s3: unsupported server-side encryption: %s", config.ServerSideEncryption)
+	}
+
+	ctx := context.Background()
+
+	cfg, err := loadAWSConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("s3: unable to create AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(cfg, func(options *s3.Options) {
+		if config.Endpoint != "" {
+			options.BaseEndpoint = aws.String(config.Endpoint)
+			options.UsePathStyle = true
+		}
+	})
+
+	s := &Storage{config: config, client: client}
+
+	if config.RequireVersioning {
+		err = s.checkVersioning(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("s3: %w", err)
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Storage) ReadFile(key string) ([]byte, error) {
+	output, err := s.client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(s.objectKey(key)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3: read %s: %w", key, notExist(err))
+	}
+
+	defer func() { _ = output.Body.Close() }()
+
+	data, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, fmt.Errorf("s3: read %s: %w", key, err)
+	}
+
+	return data, nil
+}
+
+func (s *Storage) WriteFile(key string, data []byte) error {
+	params := &s3.PutObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(s.objectKey(key)),
+		Body:   bytes.NewReader(data),
+	}
+
+	if s.config.ServerSideEncryption != "" {
+		params.ServerSideEncryption = types.ServerSideEncryption(s.config.ServerSideEncryption)
+	}
+
+	if s.config.KMSKeyID != "" {
+		params.SSEKMSKeyId = aws.String(s.config.KMSKeyID)
+	}
+
+	_, err := s.client.PutObject(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("s3: write %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) Exists(key string) (bool, error) {
+	_, err := s.client.HeadObject(context.Background(), &s3.HeadObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(s.objectKey(key)),
+	})
+
+	if errors.Is(notExist(err), fs.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("s3: exists %s: %w", key, err)
+	}
+
+	return true, nil
+}
+
+// Remove removes the object (a delete marker is created if the versioning is enabled on the bucket).
+func (s *Storage) Remove(key string) error {
+	_, err := s.client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(s.objectKey(key)),
+	})
+	if err != nil {
+		return fmt.Errorf("s3: remove %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) List(prefix string) ([]string, error) {
+	objectPrefix := s.objectKey(prefix)
+	if objectPrefix != "" {
+		objectPrefix += "/"
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.config.Bucket),
+		Prefix: aws.String(objectPrefix),
+	})
+
+	var keys []string
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("s3: list %s: %w", prefix, err)
+		}
+
+		for _, object := range page.Contents {
+			keys = append(keys, strings.TrimPrefix(aws.ToString(object.Key), s.objectKey("")+"/"))
+		}
+	}
+
+	return keys, nil
+}
+
+// Location returns the URI of the object (ex: "s3://bucket/certificates/example.com.crt").
+func (s *Storage) Location(key string) string {
+	return "s3://" + path.Join(s.config.Bucket, s.objectKey(key))
+}
+
+func (s *Storage) objectKey(key string) string {
+	return strings.Trim(path.Join(s.config.Prefix, key), "/")
+}
+
+func (s *Storage) checkVersioning(ctx context.Context) error {
+	output, err := s.client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{
+		Bucket: aws.String(s.config.Bucket),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to get the versioning state of the bucket %s: %w", s.config.Bucket, err)
+	}
+
+	if output.Status != types.BucketVersioningStatusEnabled {
+		return fmt.Errorf("the versioning is not enabled on the bucket %s", s.config.Bucket)
+	}
+
+	return nil
+}
+
+func loadAWSConfig(ctx context.Context, config *Config) (aws.Config, error) {
+	var optFns []func(*awsconfig.LoadOptions) error
+	if config.Region != "" {
+		optFns = append(optFns, awsconfig.WithRegion(config.Region))
+	}
+
+	return awsconfig.LoadDefaultConfig(ctx, optFns...)
+}
+
+// notExist wraps the "not found" errors with fs.ErrNotExist.
+func notExist(err error) error {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return fmt.Errorf("%w: %w", fs.ErrNotExist, err)
+	}
+
+	return err
+}
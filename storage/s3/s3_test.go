@@ -0,0 +1,248 @@
+package s3
+
+import (
+	"encoding/xml"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeObject struct {
+	data       []byte
+	encryption string
+	kmsKeyID   string
+}
+
+// fakeS3 a minimal S3 server (path-style) with a single bucket.
+type fakeS3 struct {
+	mu         sync.Mutex
+	bucket     string
+	versioning string
+	objects    map[string]fakeObject
+}
+
+func (f *fakeS3) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		writeS3Error(rw, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	switch {
+	case key == "" && req.URL.Query().Has("versioning"):
+		_ = xml.NewEncoder(rw).Encode(struct {
+			XMLName xml.Name `xml:"VersioningConfiguration"`
+			Status  string   `xml:"Status,omitempty"`
+		}{Status: f.versioning})
+
+	case key == "" && req.URL.Query().Get("list-type") == "2":
+		f.list(rw, req.URL.Query().Get("prefix"))
+
+	case req.Method == http.MethodPut:
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			writeS3Error(rw, http.StatusBadRequest, "BadRequest")
+			return
+		}
+
+		f.objects[key] = fakeObject{
+			data:       data,
+			encryption: req.Header.Get("X-Amz-Server-Side-Encryption"),
+			kmsKeyID:   req.Header.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"),
+		}
+
+	case req.Method == http.MethodGet, req.Method == http.MethodHead:
+		object, ok := f.objects[key]
+		if !ok {
+			writeS3Error(rw, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+
+		if req.Method == http.MethodGet {
+			_, _ = rw.Write(object.data)
+		}
+
+	case req.Method == http.MethodDelete:
+		delete(f.objects, key)
+		rw.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeS3Error(rw, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (f *fakeS3) list(rw http.ResponseWriter, prefix string) {
+	type content struct {
+		Key string `xml:"Key"`
+	}
+
+	result := struct {
+		XMLName  xml.Name  `xml:"ListBucketResult"`
+		Name     string    `xml:"Name"`
+		Prefix   string    `xml:"Prefix"`
+		KeyCount int       `xml:"KeyCount"`
+		Contents []content `xml:"Contents"`
+	}{Name: f.bucket, Prefix: prefix}
+
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		result.Contents = append(result.Contents, content{Key: key})
+	}
+
+	result.KeyCount = len(keys)
+
+	_ = xml.NewEncoder(rw).Encode(result)
+}
+
+func writeS3Error(rw http.ResponseWriter, status int, code string) {
+	rw.Header().Set("Content-Type", "application/xml")
+	rw.WriteHeader(status)
+	_, _ = rw.Write([]byte(`<Error><Code>` + code + `</Code></Error>`))
+}
+
+func setupFakeS3(t *testing.T) (*fakeS3, *Config) {
+	t.Helper()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_CONFIG_FILE", "/dev/null")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/dev/null")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	fake := &fakeS3{bucket: "lego", objects: map[string]fakeObject{}}
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	config := NewDefaultConfig()
+	config.Bucket = "lego"
+	config.Endpoint = server.URL
+
+	return fake, config
+}
+
+func TestStorage(t *testing.T) {
+	fake, config := setupFakeS3(t)
+	config.Prefix = "prod"
+
+	s, err := NewStorage(config)
+	require.NoError(t, err)
+
+	exists, err := s.Exists("certificates/example.com.crt")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = s.ReadFile("certificates/example.com.crt")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	require.NoError(t, s.WriteFile("certificates/example.com.crt", []byte("cert")))
+	require.NoError(t, s.WriteFile("accounts/example.org/foo@example.com/account.json", []byte("{}")))
+
+	require.Contains(t, fake.objects, "prod/certificates/example.com.crt")
+	assert.Equal(t, "AES256", fake.objects["prod/certificates/example.com.crt"].encryption)
+
+	exists, err = s.Exists("certificates/example.com.crt")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	data, err := s.ReadFile("certificates/example.com.crt")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("cert"), data)
+
+	keys, err := s.List("")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"accounts/example.org/foo@example.com/account.json", "certificates/example.com.crt"}, keys)
+
+	require.NoError(t, s.Remove("certificates/example.com.crt"))
+
+	keys, err = s.List("certificates")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	assert.Equal(t, "s3://lego/prod/certificates/example.com.crt", s.Location("certificates/example.com.crt"))
+}
+
+func TestStorage_kms(t *testing.T) {
+	fake, config := setupFakeS3(t)
+	config.ServerSideEncryption = "aws:kms"
+	config.KMSKeyID = "key-id"
+
+	s, err := NewStorage(config)
+	require.NoError(t, err)
+
+	require.NoError(t, s.WriteFile("certificates/example.com.key", []byte("key")))
+
+	object := fake.objects["certificates/example.com.key"]
+	assert.Equal(t, "aws:kms", object.encryption)
+	assert.Equal(t, "key-id", object.kmsKeyID)
+}
+
+func TestNewStorage_requireVersioning(t *testing.T) {
+	fake, config := setupFakeS3(t)
+	config.RequireVersioning = true
+
+	_, err := NewStorage(config)
+	require.EqualError(t, err, "s3: the versioning is not enabled on the bucket lego")
+
+	fake.versioning = "Enabled"
+
+	_, err = NewStorage(config)
+	require.NoError(t, err)
+}
+
+func TestNewStorage_error(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		config   *Config
+		expected string
+	}{
+		{
+			desc:     "nil config",
+			expected: "s3: the configuration of the storage is nil",
+		},
+		{
+			desc:     "missing bucket",
+			config:   &Config{},
+			expected: "s3: bucket name missing",
+		},
+		{
+			desc:     "unsupported server-side encryption",
+			config:   &Config{Bucket: "lego", ServerSideEncryption: "foo"},
+			expected: "s3: unsupported server-side encryption: foo",
+		},
+		{
+			desc:     "KMS key without KMS encryption",
+			config:   &Config{Bucket: "lego", ServerSideEncryption: "AES256", KMSKeyID: "key-id"},
+			expected: "s3: the KMS key ID requires the aws:kms server-side encryption",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewStorage(test.config)
+			require.EqualError(t, err, test.expected)
+		})
+	}
+}
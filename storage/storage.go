@@ -0,0 +1,33 @@
+// Package storage provides the storages of the certificates and the accounts (certificates, private keys, resources).
+package storage
+
+// CertificatesStorage stores the files of the certificates and the accounts.
+//
+// The keys are slash-separated paths relative to the root of the storage
+// (ex: "certificates/example.com.crt", "accounts/acme-v02.api.letsencrypt.org/foo@example.com/account.json").
+type CertificatesStorage interface {
+	// ReadFile reads the content of the file.
+	// The error wraps fs.ErrNotExist if the file does not exist.
+	ReadFile(key string) ([]byte, error)
+
+	// WriteFile creates or replaces the file.
+	WriteFile(key string, data []byte) error
+
+	// Exists checks if the file exists.
+	Exists(key string) (bool, error)
+
+	// Remove removes the file.
+	Remove(key string) error
+
+	// List returns the keys of all the files under the prefix (recursively).
+	List(prefix string) ([]string, error)
+
+	// Location returns a human-readable location of the file (ex: the path of the file on the disk).
+	Location(key string) string
+}
+
+// MultiFileWriter is implemented by the storages able to write several files at once.
+type MultiFileWriter interface {
+	// WriteFiles creates or replaces the files (by key).
+	WriteFiles(files map[string][]byte) error
+}
@@ -0,0 +1,282 @@
+// Package vault implements a certificates storage using the HashiCorp Vault KV secrets engine (version 2).
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/storage"
+)
+
+// Default values of the configuration.
+const (
+	DefaultMountPath = "secret"
+	DefaultBasePath  = "lego"
+)
+
+// contentField the field of the secrets containing the content of the files (base64 encoded).
+const contentField = "content"
+
+// Config is used to configure the creation of the Storage.
+type Config struct {
+	// Address is the address of the Vault server (ex: https://vault.example.com:8200).
+	Address string
+
+	// Token is the Vault token.
+	Token string
+
+	// Namespace is the Vault Enterprise namespace.
+	Namespace string
+
+	// MountPath is the path of the KV secrets engine (version 2).
+	MountPath string
+
+	// BasePath is prepended to the keys of the files to build the paths of the secrets.
+	BasePath string
+
+	HTTPClient *http.Client
+}
+
+// NewDefaultConfig returns a default configuration for the Storage.
+func NewDefaultConfig() *Config {
+	return &Config{
+		MountPath:  DefaultMountPath,
+		BasePath:   DefaultBasePath,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+var _ storage.CertificatesStorage = (*Storage)(nil)
+
+// Storage stores the files as secrets of a Vault KV secrets engine (version 2).
+// Each file is a secret with a single field ("content") containing the base64 encoded content of the file.
+type Storage struct {
+	config  *Config
+	baseURL *url.URL
+}
+
+// NewStorage returns a Storage instance configured for Vault.
+func NewStorage(config *Config) (*Storage, error) {
+	if config == nil {
+		return nil, errors.New("vault: the configuration of the storage is nil")
+	}
+
+	if config.Token == "" {
+		return nil, errors.New("vault: missing token")
+	}
+
+	baseURL, err := url.Parse(config.Address)
+	if err != nil {
+		return nil, fmt.Errorf("vault: invalid address %q: %w", config.Address, err)
+	}
+
+	if baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("vault: invalid address %q: scheme and host are required", config.Address)
+	}
+
+	if config.MountPath == "" {
+		config.MountPath = DefaultMountPath
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Storage{config: config, baseURL: baseURL}, nil
+}
+
+func (s *Storage) ReadFile(key string) ([]byte, error) {
+	var result secretResponse
+
+	err := s.do(context.Background(), http.MethodGet, s.endpoint("data", key), nil, &result)
+	if err != nil {
+		return nil, fmt.Errorf("vault: read %s: %w", key, err)
+	}
+
+	content, ok := result.Data.Data[contentField].(string)
+	if !ok {
+		return nil, fmt.Errorf("vault: read %s: missing field %q", key, contentField)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, fmt.Errorf("vault: read %s: %w", key, err)
+	}
+
+	return data, nil
+}
+
+func (s *Storage) WriteFile(key string, data []byte) error {
+	payload := map[string]any{
+		"data": map[string]any{contentField: base64.StdEncoding.EncodeToString(data)},
+	}
+
+	err := s.do(context.Background(), http.MethodPost, s.endpoint("data", key), payload, nil)
+	if err != nil {
+		return fmt.Errorf("vault: write %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// WriteSecret writes a secret with several fields (ex: the certificate and the private key in PEM format).
+// Unlike WriteFile, the values are not base64 encoded, so the secret can be read directly by the consumers (ex: Vault Agent templates).
+func (s *Storage) WriteSecret(key string, fields map[string]string) error {
+	payload := map[string]any{"data": fields}
+
+	err := s.do(context.Background(), http.MethodPost, s.endpoint("data", key), payload, nil)
+	if err != nil {
+		return fmt.Errorf("vault: write %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) Exists(key string) (bool, error) {
+	_, err := s.ReadFile(key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Remove removes the secret (all the versions and the metadata).
+func (s *Storage) Remove(key string) error {
+	err := s.do(context.Background(), http.MethodDelete, s.endpoint("metadata", key), nil, nil)
+	if err != nil {
+		return fmt.Errorf("vault: remove %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) List(prefix string) ([]string, error) {
+	prefix = strings.Trim(prefix, "/")
+
+	endpoint := s.endpoint("metadata", prefix)
+	endpoint.RawQuery = url.Values{"list": {"true"}}.Encode()
+
+	var result listResponse
+
+	err := s.do(context.Background(), http.MethodGet, endpoint, nil, &result)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("vault: list %s: %w", prefix, err)
+	}
+
+	var keys []string
+
+	for _, name := range result.Data.Keys {
+		key := path.Join(prefix, name)
+
+		if !strings.HasSuffix(name, "/") {
+			keys = append(keys, key)
+			continue
+		}
+
+		children, err := s.List(key)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, children...)
+	}
+
+	return keys, nil
+}
+
+// Location returns the path of the secret (ex: "secret/lego/certificates/example.com.crt").
+func (s *Storage) Location(key string) string {
+	return path.Join(s.config.MountPath, s.config.BasePath, key)
+}
+
+func (s *Storage) endpoint(kind, key string) *url.URL {
+	return s.baseURL.JoinPath("v1", s.config.MountPath, kind, s.config.BasePath, key)
+}
+
+func (s *Storage) do(ctx context.Context, method string, endpoint *url.URL, payload, result any) error {
+	var body io.Reader = http.NoBody
+
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("unable to marshal request: %w", err)
+		}
+
+		body = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), body)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Set("X-Vault-Token", s.config.Token)
+
+	if s.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.config.Namespace)
+	}
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	raw, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fs.ErrNotExist
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code: [%s] %s: %d: %s", req.Method, req.URL, resp.StatusCode, string(raw))
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	err = json.Unmarshal(raw, result)
+	if err != nil {
+		return fmt.Errorf("unable to unmarshal response: [status code: %d] body: %s", resp.StatusCode, string(raw))
+	}
+
+	return nil
+}
+
+type secretResponse struct {
+	Data struct {
+		Data map[string]any `json:"data"`
+	} `json:"data"`
+}
+
+type listResponse struct {
+	Data struct {
+		Keys []string `json:"keys"`
+	} `json:"data"`
+}
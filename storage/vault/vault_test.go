@@ -0,0 +1,226 @@
+package vault
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVault a minimal KV secrets engine (version 2) mounted on "secret".
+type fakeVault struct {
+	mu      sync.Mutex
+	secrets map[string]map[string]any
+}
+
+func (f *fakeVault) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Header.Get("X-Vault-Token") != "token" {
+		http.Error(rw, `{"errors":["permission denied"]}`, http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(req.URL.Path, "/v1/secret/data/"):
+		secretPath := strings.TrimPrefix(req.URL.Path, "/v1/secret/data/")
+
+		switch req.Method {
+		case http.MethodGet:
+			data, ok := f.secrets[secretPath]
+			if !ok {
+				http.Error(rw, `{"errors":[]}`, http.StatusNotFound)
+				return
+			}
+
+			_ = json.NewEncoder(rw).Encode(map[string]any{"data": map[string]any{"data": data}})
+
+		case http.MethodPost:
+			var payload struct {
+				Data map[string]any `json:"data"`
+			}
+
+			if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+				http.Error(rw, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			f.secrets[secretPath] = payload.Data
+
+			_, _ = rw.Write([]byte(`{"data":{"version":1}}`))
+
+		default:
+			http.Error(rw, "unsupported method", http.StatusMethodNotAllowed)
+		}
+
+	case strings.HasPrefix(req.URL.Path, "/v1/secret/metadata/"):
+		secretPath := strings.TrimPrefix(req.URL.Path, "/v1/secret/metadata/")
+
+		switch {
+		case req.Method == http.MethodDelete:
+			delete(f.secrets, secretPath)
+			rw.WriteHeader(http.StatusNoContent)
+
+		case req.Method == http.MethodGet && req.URL.Query().Get("list") == "true":
+			f.list(rw, strings.TrimSuffix(secretPath, "/")+"/")
+
+		default:
+			http.Error(rw, "unsupported method", http.StatusMethodNotAllowed)
+		}
+
+	default:
+		http.NotFound(rw, req)
+	}
+}
+
+func (f *fakeVault) list(rw http.ResponseWriter, prefix string) {
+	seen := map[string]struct{}{}
+
+	for secretPath := range f.secrets {
+		rest, found := strings.CutPrefix(secretPath, prefix)
+		if !found {
+			continue
+		}
+
+		if dir, _, isDir := strings.Cut(rest, "/"); isDir {
+			seen[dir+"/"] = struct{}{}
+		} else {
+			seen[rest] = struct{}{}
+		}
+	}
+
+	if len(seen) == 0 {
+		http.Error(rw, `{"errors":[]}`, http.StatusNotFound)
+		return
+	}
+
+	var keys []string
+	for key := range seen {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	_ = json.NewEncoder(rw).Encode(map[string]any{"data": map[string]any{"keys": keys}})
+}
+
+func setupStorage(t *testing.T) (*Storage, *fakeVault) {
+	t.Helper()
+
+	vault := &fakeVault{secrets: map[string]map[string]any{}}
+
+	server := httptest.NewServer(vault)
+	t.Cleanup(server.Close)
+
+	config := NewDefaultConfig()
+	config.Address = server.URL
+	config.Token = "token"
+
+	s, err := NewStorage(config)
+	require.NoError(t, err)
+
+	return s, vault
+}
+
+func TestStorage(t *testing.T) {
+	s, vault := setupStorage(t)
+
+	exists, err := s.Exists("certificates/example.com.crt")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = s.ReadFile("certificates/example.com.crt")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	require.NoError(t, s.WriteFile("certificates/example.com.crt", []byte("cert")))
+	require.NoError(t, s.WriteFile("certificates/_.example.org.crt", []byte("wildcard")))
+	require.NoError(t, s.WriteFile("accounts/example.org/foo@example.com/account.json", []byte("{}")))
+
+	assert.Equal(t, map[string]any{"content": "Y2VydA=="}, vault.secrets["lego/certificates/example.com.crt"])
+
+	exists, err = s.Exists("certificates/example.com.crt")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	data, err := s.ReadFile("certificates/example.com.crt")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("cert"), data)
+
+	keys, err := s.List("")
+	require.NoError(t, err)
+
+	expected := []string{
+		"accounts/example.org/foo@example.com/account.json",
+		"certificates/_.example.org.crt",
+		"certificates/example.com.crt",
+	}
+	assert.Equal(t, expected, keys)
+
+	require.NoError(t, s.Remove("certificates/example.com.crt"))
+
+	keys, err = s.List("certificates")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"certificates/_.example.org.crt"}, keys)
+
+	keys, err = s.List("archives")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	assert.Equal(t, "secret/lego/certificates/example.com.crt", s.Location("certificates/example.com.crt"))
+}
+
+func TestStorage_WriteSecret(t *testing.T) {
+	s, vault := setupStorage(t)
+
+	require.NoError(t, s.WriteSecret("certificates/example.com", map[string]string{"certificate": "cert", "private_key": "key"}))
+
+	assert.Equal(t, map[string]any{"certificate": "cert", "private_key": "key"}, vault.secrets["lego/certificates/example.com"])
+}
+
+func TestStorage_invalidToken(t *testing.T) {
+	s, _ := setupStorage(t)
+	s.config.Token = "foo"
+
+	err := s.WriteFile("certificates/example.com.crt", []byte("cert"))
+	require.ErrorContains(t, err, "403")
+}
+
+func TestNewStorage_error(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		config   *Config
+		expected string
+	}{
+		{
+			desc:     "nil config",
+			expected: "vault: the configuration of the storage is nil",
+		},
+		{
+			desc:     "missing token",
+			config:   &Config{Address: "https://vault.example.com"},
+			expected: "vault: missing token",
+		},
+		{
+			desc:     "missing address",
+			config:   &Config{Token: "token"},
+			expected: `vault: invalid address "": scheme and host are required`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewStorage(test.config)
+			require.EqualError(t, err, test.expected)
+		})
+	}
+}